@@ -231,6 +231,7 @@ func main() {
 		stop,
 		msgBroker,
 	)
+	meshCatalog.EnableInboundPolicyCache()
 
 	proxyRegistry := registry.NewProxyRegistry()
 	// Create and start the ADS gRPC service
@@ -312,6 +313,7 @@ func startMetricsStore() {
 		metricsstore.DefaultMetricsStore.AdmissionWebhookResponseTotal,
 		metricsstore.DefaultMetricsStore.EventsQueued,
 		metricsstore.DefaultMetricsStore.ReconciliationTotal,
+		metricsstore.DefaultMetricsStore.InboundRulesPerPolicy,
 	)
 }
 
@@ -50,6 +50,37 @@ const (
 	LocalProxyModePodIP LocalProxyMode = "PodIP"
 )
 
+// TrafficSplitMissingBackendMode is a type alias representing how a TrafficSplit backend that does not exist is handled
+type TrafficSplitMissingBackendMode string
+
+const (
+	// TrafficSplitMissingBackendSkip indicates that a missing backend is skipped and the weights of the remaining backends are renormalized
+	TrafficSplitMissingBackendSkip TrafficSplitMissingBackendMode = "Skip"
+	// TrafficSplitMissingBackendError indicates that a missing backend is an error, and no routes are programmed for the apex service
+	TrafficSplitMissingBackendError TrafficSplitMissingBackendMode = "Error"
+)
+
+// TrafficSplitDrainedBackendsMode is a type alias representing how a TrafficSplit whose backends all have no ready endpoints is handled
+type TrafficSplitDrainedBackendsMode string
+
+const (
+	// TrafficSplitDrainedBackendsRetain indicates that traffic continues to be routed to the backends of the TrafficSplit
+	TrafficSplitDrainedBackendsRetain TrafficSplitDrainedBackendsMode = "Retain"
+	// TrafficSplitDrainedBackendsFailoverToApex indicates that traffic is routed to the endpoints of the apex service instead,
+	// provided the apex service has ready endpoints
+	TrafficSplitDrainedBackendsFailoverToApex TrafficSplitDrainedBackendsMode = "FailoverToApex"
+)
+
+// HostnameVariantsMode is a type alias representing which hostname variants of a service are programmed on routes
+type HostnameVariantsMode string
+
+const (
+	// HostnameVariantsAll indicates that all the hostname variants of a service are programmed, from the short name to the FQDN
+	HostnameVariantsAll HostnameVariantsMode = "All"
+	// HostnameVariantsFQDN indicates that only the FQDN of a service and its FQDN:port form are programmed
+	HostnameVariantsFQDN HostnameVariantsMode = "FQDN"
+)
+
 // SidecarSpec is the type used to represent the specifications for the proxy sidecar.
 type SidecarSpec struct {
 	// EnablePrivilegedInitContainer defines a boolean indicating whether the init container for a meshed pod should run as privileged.
@@ -90,6 +121,10 @@ type SidecarSpec struct {
 
 	// LocalProxyMode defines the network interface the envoy proxy will use to send traffic to the backend service application. Acceptable values are [`Localhost`, `PodIP`]. The default is `Localhost`
 	LocalProxyMode LocalProxyMode `json:"localProxyMode,omitempty"`
+
+	// LocalClusterAddress defines the address the envoy proxy will use to send inbound traffic to the backend service application.
+	// It is either an IP address or a unix domain socket path of the form `unix://<path>`. The default is `127.0.0.1`
+	LocalClusterAddress string `json:"localClusterAddress,omitempty"`
 }
 
 // TrafficSpec is the type used to represent OSM's traffic management configuration.
@@ -113,6 +148,75 @@ type TrafficSpec struct {
 	// EnablePermissiveTrafficPolicyMode defines a boolean indicating if permissive traffic policy mode is enabled mesh-wide.
 	EnablePermissiveTrafficPolicyMode bool `json:"enablePermissiveTrafficPolicyMode"`
 
+	// EnablePermissiveTrafficTrustDomainPrincipals defines a boolean indicating if inbound routes in permissive traffic
+	// policy mode only allow the principals of the active trust domains instead of any principal. It allows scoping
+	// permissive mode to clients of known trust domains, e.g. while migrating trust domains.
+	EnablePermissiveTrafficTrustDomainPrincipals bool `json:"enablePermissiveTrafficTrustDomainPrincipals,omitempty"`
+
+	// EnableInboundWildcardVirtualHost defines a boolean indicating if a catch-all virtual host is programmed
+	// on inbound route configurations to answer requests whose host does not match any known hostname with a 404.
+	EnableInboundWildcardVirtualHost bool `json:"enableInboundWildcardVirtualHost,omitempty"`
+
+	// EnableInboundTCPFilterChainPerIdentity defines a boolean indicating if inbound traffic to TCP services is
+	// scoped per allowed downstream identity, with a network RBAC policy per identity instead of per TrafficTarget.
+	// It only applies when permissive traffic policy mode is disabled.
+	EnableInboundTCPFilterChainPerIdentity bool `json:"enableInboundTCPFilterChainPerIdentity,omitempty"`
+
+	// EnableInboundCORSPreflight defines a boolean indicating if the OPTIONS method is implicitly allowed on inbound
+	// HTTP routes that match an explicit list of methods, so that CORS preflight requests are not rejected.
+	// Routes matching all methods are not affected. It only applies when permissive traffic policy mode is disabled.
+	EnableInboundCORSPreflight bool `json:"enableInboundCORSPreflight,omitempty"`
+
+	// EnableLocalityAwareLoadBalancing defines a boolean indicating if outbound traffic is load balanced across the
+	// zones of the upstream endpoints, as specified by the topology.kubernetes.io/zone label on their pods, preferring
+	// the endpoints in the same zone as the downstream pods.
+	EnableLocalityAwareLoadBalancing bool `json:"enableLocalityAwareLoadBalancing,omitempty"`
+
+	// TrafficSplitMissingBackendMode defines how a TrafficSplit backend service that does not exist is handled. Acceptable values are [`Skip`, `Error`]. The default is `Skip`
+	TrafficSplitMissingBackendMode TrafficSplitMissingBackendMode `json:"trafficSplitMissingBackendMode,omitempty"`
+
+	// TrafficSplitDrainedBackendsMode defines how a TrafficSplit whose backend services all have no ready endpoints is handled.
+	// Acceptable values are [`Retain`, `FailoverToApex`]. The default is `Retain`
+	TrafficSplitDrainedBackendsMode TrafficSplitDrainedBackendsMode `json:"trafficSplitDrainedBackendsMode,omitempty"`
+
+	// InboundProbePaths defines a list of HTTP paths used by health-check and readiness probes. Inbound routes
+	// for these paths are programmed on every HTTP port and allow unauthenticated access, so that probes which
+	// traverse the sidecar proxy without mTLS are not rejected.
+	InboundProbePaths []string `json:"inboundProbePaths,omitempty"`
+
+	// EnableSelfTraffic defines a boolean indicating if a service identity is implicitly allowed to access its own
+	// services, e.g. via the apex service, without an explicit SMI TrafficTarget. It only applies when permissive
+	// traffic policy mode is disabled.
+	EnableSelfTraffic bool `json:"enableSelfTraffic,omitempty"`
+
+	// HostnameVariants defines which hostname variants of a service are programmed on HTTP routes. Acceptable values are [`All`, `FQDN`].
+	// The default is `All`. `FQDN` reduces the size of the route configuration for large meshes.
+	HostnameVariants HostnameVariantsMode `json:"hostnameVariants,omitempty"`
+
+	// DisablePortSuffixedHostnames defines a boolean indicating if the hostname variants suffixed with the service port,
+	// e.g. `service:port`, are omitted from HTTP routes. It reduces the size of the route configuration when the port is
+	// not part of the Host header used for route matching.
+	DisablePortSuffixedHostnames bool `json:"disablePortSuffixedHostnames,omitempty"`
+
+	// ClusterDomain defines the DNS domain of the cluster the hostname variants of a service are built with, e.g.
+	// `service.namespace.svc.<ClusterDomain>`. The default is `cluster.local`. It is distinct from the trust domain
+	// of the MeshRootCertificate, which is used for service identities.
+	ClusterDomain string `json:"clusterDomain,omitempty"`
+
+	// InboundPolicyPortExclusions defines the service ports, per namespace or per service, excluded from inbound mesh
+	// traffic policy generation. No inbound cluster, filter chain match or route configuration is programmed for an
+	// excluded port, e.g. a metrics or admin port that should not be meshed.
+	InboundPolicyPortExclusions []PortExclusionSpec `json:"inboundPolicyPortExclusions,omitempty"`
+
+	// PrewarmZeroWeightBackends defines a boolean indicating if the clusters for TrafficSplit backends with a weight of 0
+	// are programmed ahead of time, so that promoting such a backend, e.g. a canary, does not require new clusters.
+	PrewarmZeroWeightBackends bool `json:"prewarmZeroWeightBackends,omitempty"`
+
+	// WeightedClusterRuntimeKeyPrefix defines the prefix of the Envoy runtime keys used to override the weights of the
+	// weighted clusters on HTTP routes at runtime. The runtime key for a cluster on the routes of a service is
+	// <prefix>.<service FQDN>.<cluster name>, and defaults to the configured weight. Runtime keys are not used if empty.
+	WeightedClusterRuntimeKeyPrefix string `json:"weightedClusterRuntimeKeyPrefix,omitempty"`
+
 	// InboundExternalAuthorization defines a ruleset that, if enabled, will configure a remote external authorization endpoint
 	// for all inbound and ingress traffic in the mesh.
 	InboundExternalAuthorization ExternalAuthzSpec `json:"inboundExternalAuthorization,omitempty"`
@@ -123,6 +227,20 @@ type TrafficSpec struct {
 	NetworkInterfaceExclusionList []string `json:"networkInterfaceExclusionList"`
 }
 
+// PortExclusionSpec is the type to represent the service ports excluded from inbound mesh traffic policy generation.
+type PortExclusionSpec struct {
+	// Namespace defines the namespace of the services the ports are excluded for.
+	Namespace string `json:"namespace"`
+
+	// Service defines the name of the service the ports are excluded for. The ports are excluded for all the
+	// services in the namespace if empty.
+	// +optional
+	Service string `json:"service,omitempty"`
+
+	// Ports defines the service ports that are excluded.
+	Ports []uint16 `json:"ports"`
+}
+
 // ObservabilitySpec is the type to represent OSM's observability configurations.
 type ObservabilitySpec struct {
 	// OSMLogLevel defines the log level for OSM control plane logs.
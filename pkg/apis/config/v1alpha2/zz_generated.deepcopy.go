@@ -435,6 +435,27 @@ func (in *ObservabilitySpec) DeepCopy() *ObservabilitySpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PortExclusionSpec) DeepCopyInto(out *PortExclusionSpec) {
+	*out = *in
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]uint16, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PortExclusionSpec.
+func (in *PortExclusionSpec) DeepCopy() *PortExclusionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PortExclusionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProviderSpec) DeepCopyInto(out *ProviderSpec) {
 	*out = *in
@@ -548,6 +569,18 @@ func (in *TrafficSpec) DeepCopyInto(out *TrafficSpec) {
 		*out = make([]int, len(*in))
 		copy(*out, *in)
 	}
+	if in.InboundProbePaths != nil {
+		in, out := &in.InboundProbePaths, &out.InboundProbePaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InboundPolicyPortExclusions != nil {
+		in, out := &in.InboundPolicyPortExclusions, &out.InboundPolicyPortExclusions
+		*out = make([]PortExclusionSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	out.InboundExternalAuthorization = in.InboundExternalAuthorization
 	if in.NetworkInterfaceExclusionList != nil {
 		in, out := &in.NetworkInterfaceExclusionList, &out.NetworkInterfaceExclusionList
@@ -39,6 +39,24 @@ type UpstreamTrafficSettingSpec struct {
 	// +optional
 	ConnectionSettings *ConnectionSettingsSpec `json:"connectionSettings,omitempty"`
 
+	// HealthCheck specifies the active health checking of the endpoints
+	// of the upstream host.
+	// +optional
+	HealthCheck *HealthCheckSpec `json:"healthCheck,omitempty"`
+
+	// OutlierDetection specifies the passive health checking of the
+	// endpoints of the upstream host, ejecting failing endpoints from
+	// load balancing.
+	// Outlier detection is disabled if not specified.
+	// +optional
+	OutlierDetection *OutlierDetectionSpec `json:"outlierDetection,omitempty"`
+
+	// LBPolicy specifies the load balancing policy used to distribute the
+	// traffic across the endpoints of the upstream host.
+	// Defaults to round robin load balancing if not specified.
+	// +optional
+	LBPolicy *LBPolicySpec `json:"lbPolicy,omitempty"`
+
 	// RateLimit specifies the rate limit settings for the traffic
 	// directed to the upstream host.
 	// If HTTP rate limiting is specified, the rate limiting is applied
@@ -47,11 +65,241 @@ type UpstreamTrafficSettingSpec struct {
 	// +optional
 	RateLimit *RateLimitSpec `json:"rateLimit,omitempty"`
 
+	// RetryPolicy specifies the retry policy for the HTTP traffic
+	// directed to the upstream host. The retry policy is applied
+	// at the VirtualHost level applicable to all routes within the
+	// VirtualHost.
+	// +optional
+	RetryPolicy *RetryPolicySpec `json:"retryPolicy,omitempty"`
+
+	// Cors specifies the Cross-Origin Resource Sharing (CORS) policy
+	// for the HTTP traffic directed to the upstream host. The CORS
+	// policy is applied at the VirtualHost level applicable to all
+	// routes within the VirtualHost.
+	// +optional
+	Cors *CorsSpec `json:"cors,omitempty"`
+
+	// EnableWebSocket specifies whether WebSocket upgrades are allowed
+	// for the HTTP traffic directed to the upstream host. WebSocket
+	// upgrades are allowed on all routes within the VirtualHost.
+	// Defaults to false.
+	// +optional
+	EnableWebSocket bool `json:"enableWebSocket,omitempty"`
+
 	// HTTPRoutes defines the list of HTTP routes settings
 	// for the upstream host. Settings are applied at a per
 	// route level.
 	// +optional
 	HTTPRoutes []HTTPRouteSpec `json:"httpRoutes,omitempty"`
+
+	// ServerName specifies the SNI downstream clients must set on the
+	// TLS handshake for inbound traffic to the upstream host. When
+	// specified, the HTTP routes for the upstream host only apply to
+	// connections negotiated with this SNI.
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+
+	// AdditionalServerNames specifies additional server names, matched
+	// using SNI, that inbound connections to the upstream host may
+	// present, ex. custom SANs presented to external clients.
+	// +optional
+	AdditionalServerNames []string `json:"additionalServerNames,omitempty"`
+
+	// EnforceSMI specifies whether SMI traffic policies are enforced
+	// for inbound traffic to the upstream host even when permissive
+	// traffic policy mode is enabled mesh-wide.
+	// +optional
+	EnforceSMI bool `json:"enforceSMI,omitempty"`
+
+	// DeniedServiceAccounts specifies the service accounts denied access
+	// to the upstream host when SMI traffic policies are enforced, even
+	// if they are allowed by an SMI TrafficTarget. Denials take
+	// precedence over the allows of SMI TrafficTargets.
+	// +optional
+	DeniedServiceAccounts []ServiceAccountSpec `json:"deniedServiceAccounts,omitempty"`
+
+	// AllowedSourceRanges specifies the IP address ranges, in CIDR
+	// notation, downstream clients must connect from to access the
+	// upstream host. Clients must also be allowed by their identity,
+	// which all clients are in permissive traffic policy mode.
+	// Defaults to allowing all the source addresses.
+	// +optional
+	AllowedSourceRanges []string `json:"allowedSourceRanges,omitempty"`
+
+	// HTTPRequestTimeout specifies the request timeout applied to all
+	// HTTP routes for the upstream host, unless overridden by the
+	// timeout of a route in HTTPRoutes. A timeout of 0 disables the
+	// request timeout.
+	// Defaults to the mesh default request timeout if not specified.
+	// +optional
+	HTTPRequestTimeout *metav1.Duration `json:"httpRequestTimeout,omitempty"`
+
+	// TCPIdleTimeout specifies the idle timeout of the TCP connections
+	// directed to the upstream host, after which a connection without
+	// any activity is closed. A timeout of 0 disables the idle timeout.
+	// Defaults to the Envoy default TCP idle timeout if not specified.
+	// +optional
+	TCPIdleTimeout *metav1.Duration `json:"tcpIdleTimeout,omitempty"`
+
+	// CaseInsensitivePathMatch specifies whether the paths of the HTTP
+	// routes for the upstream host are matched case insensitively.
+	// Defaults to false.
+	// +optional
+	CaseInsensitivePathMatch bool `json:"caseInsensitivePathMatch,omitempty"`
+
+	// AccessLogFormat specifies the Envoy access log format for the
+	// inbound HTTP traffic directed to the upstream host, overriding
+	// the access log format of the proxy. The format can either be
+	// unstructured or structured (e.g. JSON).
+	// Refer to https://www.envoyproxy.io/docs/envoy/latest/configuration/observability/access_log/usage#format-strings
+	// regarding how a format string can be specified.
+	// +optional
+	AccessLogFormat string `json:"accessLogFormat,omitempty"`
+
+	// RequireTLS specifies whether plaintext HTTP requests directed to
+	// the upstream host are redirected to HTTPS instead of being routed
+	// to the upstream host.
+	// Defaults to false.
+	// +optional
+	RequireTLS bool `json:"requireTls,omitempty"`
+
+	// HeaderToMetadata specifies the rules copying the values of
+	// inbound HTTP request headers directed to the upstream host
+	// into the dynamic metadata of the request, ex. for tracing or
+	// routing decisions based on the header values.
+	// +optional
+	HeaderToMetadata []HeaderToMetadataSpec `json:"headerToMetadata,omitempty"`
+
+	// UpstreamProtocol specifies the HTTP protocol the sidecar uses
+	// for its connections to the upstream host, overriding the
+	// protocol of the upstream service's port, ex. to force HTTP/2
+	// for HTTP/2-only backends. One of http1, http2 or h2c.
+	// Defaults to the protocol of the downstream connection.
+	// +optional
+	UpstreamProtocol string `json:"upstreamProtocol,omitempty"`
+
+	// EndpointSubsets specifies the subsets of the upstream host's
+	// endpoints, selected by endpoint labels, the inbound HTTP traffic
+	// directed to the upstream host is split across by weight, ex. for
+	// canary deployments selected by pod labels instead of a service.
+	// +optional
+	EndpointSubsets []EndpointSubsetSpec `json:"endpointSubsets,omitempty"`
+
+	// ResponseHeadersToAdd specifies the HTTP headers added to the
+	// responses of the inbound HTTP requests directed to the upstream
+	// host, ex. to add security headers such as X-Frame-Options.
+	// +optional
+	ResponseHeadersToAdd []HTTPHeaderValue `json:"responseHeadersToAdd,omitempty"`
+
+	// ResponseHeadersToRemove specifies the names of the HTTP headers
+	// removed from the responses of the inbound HTTP requests directed
+	// to the upstream host, ex. to strip the Server header.
+	// +optional
+	ResponseHeadersToRemove []string `json:"responseHeadersToRemove,omitempty"`
+
+	// EnableGRPCWeb specifies whether the gRPC-Web requests of browser
+	// clients directed to the upstream host are translated into gRPC
+	// requests. Only applicable to upstream hosts serving the http or
+	// grpc protocols.
+	// +optional
+	EnableGRPCWeb bool `json:"enableGrpcWeb,omitempty"`
+
+	// DirectResponse defines the fixed response returned for all the HTTP
+	// routes of the upstream host instead of routing the requests to the
+	// upstream host, ex. to put it into maintenance. The DirectResponse
+	// of an HTTP route takes precedence.
+	// +optional
+	DirectResponse *DirectResponseSpec `json:"directResponse,omitempty"`
+
+	// HostRewrite defines the rewrite of the Host header of the requests
+	// on all the HTTP routes of the upstream host, for applications that
+	// expect a Host header different from the hostname of the service.
+	// +optional
+	HostRewrite *HostRewriteSpec `json:"hostRewrite,omitempty"`
+}
+
+// HostRewriteSpec defines the rewrite of the Host header of requests.
+// Exactly one of Literal and AutoToUpstream must be set.
+type HostRewriteSpec struct {
+	// Literal defines the value the Host header is rewritten to.
+	// +optional
+	Literal string `json:"literal,omitempty"`
+
+	// AutoToUpstream defines whether the Host header is rewritten to the
+	// hostname of the upstream endpoint the request is routed to.
+	// +optional
+	AutoToUpstream bool `json:"autoToUpstream,omitempty"`
+}
+
+// HeaderToMetadataSpec defines a rule copying the value of a request
+// header into the dynamic metadata of the request.
+type HeaderToMetadataSpec struct {
+	// Header defines the name of the request header.
+	Header string `json:"header"`
+
+	// MetadataNamespace defines the namespace of the dynamic metadata
+	// the header value is copied to.
+	// Defaults to the namespace of the Envoy header-to-metadata filter.
+	// +optional
+	MetadataNamespace string `json:"metadataNamespace,omitempty"`
+
+	// MetadataKey defines the key of the dynamic metadata the header
+	// value is copied to.
+	MetadataKey string `json:"metadataKey"`
+
+	// Remove defines whether the header is removed from the request
+	// once its value is copied.
+	// Defaults to false.
+	// +optional
+	Remove bool `json:"remove,omitempty"`
+}
+
+// EndpointSubsetSpec defines a weighted subset of the endpoints of an
+// upstream host, selected by endpoint labels.
+type EndpointSubsetSpec struct {
+	// Labels defines the labels of the endpoints in the subset.
+	Labels map[string]string `json:"labels"`
+
+	// Weight defines the weight of the subset, relative to the weights
+	// of the other subsets.
+	Weight int `json:"weight"`
+}
+
+// ServiceAccountSpec defines the name and namespace of a service account.
+type ServiceAccountSpec struct {
+	// Name defines the name of the service account.
+	Name string `json:"name"`
+
+	// Namespace defines the namespace of the service account.
+	Namespace string `json:"namespace"`
+}
+
+// CorsSpec defines the Cross-Origin Resource Sharing (CORS) policy
+// for an upstream host.
+type CorsSpec struct {
+	// AllowOrigins defines the list of origins allowed to make
+	// cross-origin requests. An origin of '*' allows all origins.
+	AllowOrigins []string `json:"allowOrigins"`
+
+	// AllowMethods defines the list of HTTP methods allowed for
+	// cross-origin requests.
+	// +optional
+	AllowMethods []string `json:"allowMethods,omitempty"`
+
+	// AllowHeaders defines the list of HTTP headers allowed in
+	// cross-origin requests.
+	// +optional
+	AllowHeaders []string `json:"allowHeaders,omitempty"`
+
+	// MaxAge defines how long the results of a preflight request
+	// can be cached by the client.
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+
+	// AllowCredentials defines whether the client is allowed to send
+	// credentials with cross-origin requests.
+	// +optional
+	AllowCredentials bool `json:"allowCredentials,omitempty"`
 }
 
 // ConnectionSettingsSpec defines the connection settings for an
@@ -67,6 +315,114 @@ type ConnectionSettingsSpec struct {
 	HTTP *HTTPConnectionSettings `json:"http,omitempty"`
 }
 
+// HealthCheckSpec defines the active health checking of the endpoints
+// of an upstream host. Endpoints of HTTP services are health checked
+// with HTTP requests, while endpoints of TCP services are health
+// checked by establishing a TCP connection.
+type HealthCheckSpec struct {
+	// Path specifies the HTTP path requested to health check an endpoint.
+	// Not applicable to TCP services.
+	// Defaults to / if not specified.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Interval specifies the duration between health checks.
+	// Defaults to 10s if not specified.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Timeout specifies the duration to wait for a health check response.
+	// Defaults to 1s if not specified.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// UnhealthyThreshold specifies the number of consecutive failed health
+	// checks before an endpoint is marked unhealthy.
+	// Defaults to 3 if not specified.
+	// +optional
+	UnhealthyThreshold *uint32 `json:"unhealthyThreshold,omitempty"`
+
+	// HealthyThreshold specifies the number of consecutive successful health
+	// checks before an unhealthy endpoint is marked healthy again.
+	// Defaults to 1 if not specified.
+	// +optional
+	HealthyThreshold *uint32 `json:"healthyThreshold,omitempty"`
+}
+
+// OutlierDetectionSpec defines the passive health checking of the
+// endpoints of an upstream host.
+type OutlierDetectionSpec struct {
+	// Consecutive5xx specifies the number of consecutive 5xx responses or
+	// connection failures before an endpoint is ejected.
+	// Defaults to 5 if not specified.
+	// +optional
+	Consecutive5xx *uint32 `json:"consecutive5xx,omitempty"`
+
+	// Interval specifies the duration between ejection sweep analyses.
+	// Defaults to 10s if not specified.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// BaseEjectionTime specifies the base duration an endpoint is ejected
+	// for. The ejection time is the base ejection time multiplied by the
+	// number of times the endpoint has been ejected.
+	// Defaults to 30s if not specified.
+	// +optional
+	BaseEjectionTime *metav1.Duration `json:"baseEjectionTime,omitempty"`
+
+	// MaxEjectionPercent specifies the maximum percentage of endpoints
+	// that can be ejected at the same time.
+	// Defaults to 10 if not specified.
+	// +optional
+	MaxEjectionPercent *uint32 `json:"maxEjectionPercent,omitempty"`
+}
+
+// LBPolicyType is a type alias representing the load balancing algorithm
+// used to distribute traffic across the endpoints of an upstream host
+type LBPolicyType string
+
+const (
+	// LBPolicyRoundRobin selects the endpoints in turn
+	LBPolicyRoundRobin LBPolicyType = "RoundRobin"
+	// LBPolicyLeastRequest selects the endpoint with the fewest active requests
+	LBPolicyLeastRequest LBPolicyType = "LeastRequest"
+	// LBPolicyRingHash selects the endpoint by consistent hashing of the request
+	LBPolicyRingHash LBPolicyType = "RingHash"
+	// LBPolicyMaglev selects the endpoint by consistent hashing of the request using a Maglev lookup table
+	LBPolicyMaglev LBPolicyType = "Maglev"
+)
+
+// LBPolicySpec defines the load balancing policy for an upstream host.
+type LBPolicySpec struct {
+	// Type specifies the load balancing algorithm.
+	// Acceptable values are [`RoundRobin`, `LeastRequest`, `RingHash`, `Maglev`].
+	Type LBPolicyType `json:"type"`
+
+	// HashKeyHeader specifies the HTTP header whose value is hashed to
+	// select an endpoint, so that requests with the same header value
+	// are sent to the same endpoint. Only applicable to the `RingHash`
+	// and `Maglev` load balancing algorithms.
+	// +optional
+	HashKeyHeader string `json:"hashKeyHeader,omitempty"`
+
+	// HashKeyCookie specifies the HTTP cookie whose value is hashed to
+	// select an endpoint, providing session affinity. Only applicable to
+	// the `RingHash` and `Maglev` load balancing algorithms.
+	// +optional
+	HashKeyCookie *HashKeyCookieSpec `json:"hashKeyCookie,omitempty"`
+}
+
+// HashKeyCookieSpec defines the HTTP cookie used for session affinity.
+type HashKeyCookieSpec struct {
+	// Name specifies the name of the cookie.
+	Name string `json:"name"`
+
+	// TTL specifies the lifetime of the cookie generated for requests
+	// without the cookie. A cookie is not generated if not specified.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+}
+
 // TCPConnectionSettings defines the TCP connection settings for an
 // upstream host.
 type TCPConnectionSettings struct {
@@ -80,6 +436,35 @@ type TCPConnectionSettings struct {
 	// Defaults to 5s if not specified.
 	// +optional
 	ConnectTimeout *metav1.Duration `json:"connectTimeout,omitempty"`
+
+	// TCPKeepalive specifies the TCP keep-alive settings for connections
+	// to the upstream host.
+	// Defaults to no TCP keep-alive if not specified.
+	// +optional
+	TCPKeepalive *TCPKeepaliveSpec `json:"tcpKeepalive,omitempty"`
+}
+
+// TCPKeepaliveSpec defines the TCP keep-alive settings for connections
+// to an upstream host.
+type TCPKeepaliveSpec struct {
+	// Probes specifies the maximum number of keep-alive probes to send
+	// without response before deciding the connection is dead.
+	// Defaults to the OS level configuration (9 on Linux) if not specified.
+	// +optional
+	Probes *uint32 `json:"probes,omitempty"`
+
+	// Time specifies the duration a connection needs to be idle before
+	// keep-alive probes start being sent. The duration is rounded down
+	// to the second.
+	// Defaults to the OS level configuration (2h on Linux) if not specified.
+	// +optional
+	Time *metav1.Duration `json:"time,omitempty"`
+
+	// Interval specifies the duration between keep-alive probes. The
+	// duration is rounded down to the second.
+	// Defaults to the OS level configuration (75s on Linux) if not specified.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
 }
 
 // HTTPConnectionSettings defines the HTTP connection settings for an
@@ -167,6 +552,11 @@ type TCPLocalRateLimitSpec struct {
 	// rate that are allowed in a short period of time.
 	// +optional
 	Burst uint32 `json:"burst,omitempty"`
+
+	// FillInterval defines the interval at which the allowed connections
+	// are replenished, overriding the period defined by Unit.
+	// +optional
+	FillInterval *metav1.Duration `json:"fillInterval,omitempty"`
 }
 
 // HTTPLocalRateLimitSpec defines the local rate limiting specification
@@ -186,6 +576,11 @@ type HTTPLocalRateLimitSpec struct {
 	// +optional
 	Burst uint32 `json:"burst,omitempty"`
 
+	// FillInterval defines the interval at which the allowed requests
+	// are replenished, overriding the period defined by Unit.
+	// +optional
+	FillInterval *metav1.Duration `json:"fillInterval,omitempty"`
+
 	// ResponseStatusCode defines the HTTP status code to use for responses
 	// to rate limited requests. Code must be in the 400-599 (inclusive)
 	// error range. If not specified, a default of 429 (Too Many Requests) is used.
@@ -325,7 +720,7 @@ type HTTPGlobalRateLimitDescriptor struct {
 
 // HTTPGlobalRateLimitDescriptorEntry defines the rate limit descriptor entry
 // to use in the rate limit service request for HTTP requests.
-// Only one of GenericKey, RemoteAddress, RequestHeader, HeaderValueMatch may be set.
+// Only one of GenericKey, RemoteAddress, RequestHeader, HeaderValueMatch, RequestPath may be set.
 type HTTPGlobalRateLimitDescriptorEntry struct {
 	// GenericKey defines a descriptor entry with a static key-value pair.
 	// +optional
@@ -346,6 +741,11 @@ type HTTPGlobalRateLimitDescriptorEntry struct {
 	// request header matches the given HTTP header match criteria.
 	// +optional
 	HeaderValueMatch *HeaderValueMatchDescriptorEntry `json:"headerValueMatch,omitempty"`
+
+	// RequestPath defines a descriptor entry with key 'path' and value
+	// equal to the path of the request.
+	// +optional
+	RequestPath *RequestPathDescriptorEntry `json:"requestPath,omitempty"`
 }
 
 // GenericKeyDescriptorEntry defines a descriptor entry with a static
@@ -376,6 +776,15 @@ type RequestHeaderDescriptorEntry struct {
 	Key string `json:"key"`
 }
 
+// RequestPathDescriptorEntry defines a descriptor entry whose value is
+// the path of the request, including the query string if present.
+type RequestPathDescriptorEntry struct {
+	// Key defines the descriptor entry's key.
+	// Defaults to 'path'.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
 // HeaderValueMatchDescriptorEntry defines the descriptor entry that is generated
 // when the request header matches the given HTTP header match criteria.
 type HeaderValueMatchDescriptorEntry struct {
@@ -449,6 +858,91 @@ type HTTPRouteSpec struct {
 	// RateLimit defines the HTTP rate limiting specification for
 	// the specified HTTP route.
 	RateLimit *HTTPPerRouteRateLimitSpec `json:"rateLimit,omitempty"`
+
+	// RequireClientCertificate defines whether the specified HTTP route
+	// is only matched for requests that present a client certificate
+	// validated by the proxy.
+	// +optional
+	RequireClientCertificate bool `json:"requireClientCertificate,omitempty"`
+
+	// Timeout defines the request timeout for the specified HTTP route,
+	// overriding the HTTPRequestTimeout of the upstream host. A timeout
+	// of 0 disables the request timeout.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// QueryParams defines the query parameters, keyed by name, that
+	// requests must have with the given exact values for the specified
+	// HTTP route to match.
+	// +optional
+	QueryParams map[string]string `json:"queryParams,omitempty"`
+
+	// Authority defines the :authority (host) header requests must have
+	// for the specified HTTP route to match, for logical hosts served by
+	// the upstream host. The authority must be one of the hostnames the
+	// upstream host is reachable with for requests to match the route.
+	// +optional
+	Authority string `json:"authority,omitempty"`
+
+	// FaultInjection defines the faults injected into the requests
+	// matching the specified HTTP route.
+	// +optional
+	FaultInjection *HTTPFaultInjectionSpec `json:"faultInjection,omitempty"`
+
+	// DirectResponse defines the fixed response returned for requests
+	// matching the specified HTTP route instead of routing them to the
+	// upstream host.
+	// +optional
+	DirectResponse *DirectResponseSpec `json:"directResponse,omitempty"`
+
+	// RewritePrefix defines the path prefix that replaces the matched
+	// path of the specified HTTP route before requests are forwarded to
+	// the upstream host. Not supported for HTTP routes whose path is a
+	// regex, only for paths matched exactly.
+	// +optional
+	RewritePrefix string `json:"rewritePrefix,omitempty"`
+}
+
+// DirectResponseSpec defines a fixed response returned without
+// forwarding the requests upstream.
+type DirectResponseSpec struct {
+	// StatusCode defines the HTTP status code of the response, ex. 503.
+	StatusCode uint32 `json:"statusCode"`
+
+	// Body defines the body of the response.
+	// +optional
+	Body string `json:"body,omitempty"`
+}
+
+// HTTPFaultInjectionSpec defines the faults injected into the requests
+// matching an HTTP route.
+type HTTPFaultInjectionSpec struct {
+	// Percentage defines the percentage of requests, between 0 and 100,
+	// the faults are injected into.
+	Percentage uint32 `json:"percentage"`
+
+	// Abort defines the abort fault, which responds to requests with the
+	// given HTTP status without forwarding them upstream.
+	// +optional
+	Abort *HTTPFaultAbortSpec `json:"abort,omitempty"`
+
+	// Delay defines the delay fault, which delays requests before
+	// forwarding them upstream.
+	// +optional
+	Delay *HTTPFaultDelaySpec `json:"delay,omitempty"`
+}
+
+// HTTPFaultAbortSpec defines the specification of an HTTP abort fault.
+type HTTPFaultAbortSpec struct {
+	// HTTPStatus defines the HTTP status code of the response returned
+	// for aborted requests.
+	HTTPStatus uint32 `json:"httpStatus"`
+}
+
+// HTTPFaultDelaySpec defines the specification of an HTTP delay fault.
+type HTTPFaultDelaySpec struct {
+	// FixedDelay defines the duration requests are delayed by.
+	FixedDelay metav1.Duration `json:"fixedDelay"`
 }
 
 // HTTPPerRouteRateLimitSpec defines the rate limiting specification
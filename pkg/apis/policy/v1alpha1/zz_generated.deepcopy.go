@@ -68,6 +68,58 @@ func (in *ConnectionSettingsSpec) DeepCopy() *ConnectionSettingsSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CorsSpec) DeepCopyInto(out *CorsSpec) {
+	*out = *in
+	if in.AllowOrigins != nil {
+		in, out := &in.AllowOrigins, &out.AllowOrigins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowMethods != nil {
+		in, out := &in.AllowMethods, &out.AllowMethods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowHeaders != nil {
+		in, out := &in.AllowHeaders, &out.AllowHeaders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CorsSpec.
+func (in *CorsSpec) DeepCopy() *CorsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CorsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DirectResponseSpec) DeepCopyInto(out *DirectResponseSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DirectResponseSpec.
+func (in *DirectResponseSpec) DeepCopy() *DirectResponseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DirectResponseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Egress) DeepCopyInto(out *Egress) {
 	*out = *in
@@ -187,6 +239,29 @@ func (in *EgressSpec) DeepCopy() *EgressSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EndpointSubsetSpec) DeepCopyInto(out *EndpointSubsetSpec) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointSubsetSpec.
+func (in *EndpointSubsetSpec) DeepCopy() *EndpointSubsetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EndpointSubsetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvoyAccessLogConfig) DeepCopyInto(out *EnvoyAccessLogConfig) {
 	*out = *in
@@ -326,6 +401,65 @@ func (in *HTTPConnectionSettings) DeepCopy() *HTTPConnectionSettings {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPFaultAbortSpec) DeepCopyInto(out *HTTPFaultAbortSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPFaultAbortSpec.
+func (in *HTTPFaultAbortSpec) DeepCopy() *HTTPFaultAbortSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPFaultAbortSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPFaultDelaySpec) DeepCopyInto(out *HTTPFaultDelaySpec) {
+	*out = *in
+	out.FixedDelay = in.FixedDelay
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPFaultDelaySpec.
+func (in *HTTPFaultDelaySpec) DeepCopy() *HTTPFaultDelaySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPFaultDelaySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPFaultInjectionSpec) DeepCopyInto(out *HTTPFaultInjectionSpec) {
+	*out = *in
+	if in.Abort != nil {
+		in, out := &in.Abort, &out.Abort
+		*out = new(HTTPFaultAbortSpec)
+		**out = **in
+	}
+	if in.Delay != nil {
+		in, out := &in.Delay, &out.Delay
+		*out = new(HTTPFaultDelaySpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPFaultInjectionSpec.
+func (in *HTTPFaultInjectionSpec) DeepCopy() *HTTPFaultInjectionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPFaultInjectionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPGlobalPerRouteRateLimitSpec) DeepCopyInto(out *HTTPGlobalPerRouteRateLimitSpec) {
 	*out = *in
@@ -395,6 +529,11 @@ func (in *HTTPGlobalRateLimitDescriptorEntry) DeepCopyInto(out *HTTPGlobalRateLi
 		*out = new(HeaderValueMatchDescriptorEntry)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RequestPath != nil {
+		in, out := &in.RequestPath, &out.RequestPath
+		*out = new(RequestPathDescriptorEntry)
+		**out = **in
+	}
 	return
 }
 
@@ -487,6 +626,11 @@ func (in *HTTPHeaderValue) DeepCopy() *HTTPHeaderValue {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPLocalRateLimitSpec) DeepCopyInto(out *HTTPLocalRateLimitSpec) {
 	*out = *in
+	if in.FillInterval != nil {
+		in, out := &in.FillInterval, &out.FillInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	if in.ResponseHeadersToAdd != nil {
 		in, out := &in.ResponseHeadersToAdd, &out.ResponseHeadersToAdd
 		*out = make([]HTTPHeaderValue, len(*in))
@@ -539,6 +683,28 @@ func (in *HTTPRouteSpec) DeepCopyInto(out *HTTPRouteSpec) {
 		*out = new(HTTPPerRouteRateLimitSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.QueryParams != nil {
+		in, out := &in.QueryParams, &out.QueryParams
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FaultInjection != nil {
+		in, out := &in.FaultInjection, &out.FaultInjection
+		*out = new(HTTPFaultInjectionSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DirectResponse != nil {
+		in, out := &in.DirectResponse, &out.DirectResponse
+		*out = new(DirectResponseSpec)
+		**out = **in
+	}
 	return
 }
 
@@ -552,6 +718,43 @@ func (in *HTTPRouteSpec) DeepCopy() *HTTPRouteSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HashKeyCookieSpec) DeepCopyInto(out *HashKeyCookieSpec) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HashKeyCookieSpec.
+func (in *HashKeyCookieSpec) DeepCopy() *HashKeyCookieSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HashKeyCookieSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeaderToMetadataSpec) DeepCopyInto(out *HeaderToMetadataSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeaderToMetadataSpec.
+func (in *HeaderToMetadataSpec) DeepCopy() *HeaderToMetadataSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HeaderToMetadataSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HeaderValueMatchDescriptorEntry) DeepCopyInto(out *HeaderValueMatchDescriptorEntry) {
 	*out = *in
@@ -580,6 +783,58 @@ func (in *HeaderValueMatchDescriptorEntry) DeepCopy() *HeaderValueMatchDescripto
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheckSpec) DeepCopyInto(out *HealthCheckSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.UnhealthyThreshold != nil {
+		in, out := &in.UnhealthyThreshold, &out.UnhealthyThreshold
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.HealthyThreshold != nil {
+		in, out := &in.HealthyThreshold, &out.HealthyThreshold
+		*out = new(uint32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheckSpec.
+func (in *HealthCheckSpec) DeepCopy() *HealthCheckSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheckSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostRewriteSpec) DeepCopyInto(out *HostRewriteSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostRewriteSpec.
+func (in *HostRewriteSpec) DeepCopy() *HostRewriteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HostRewriteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressBackend) DeepCopyInto(out *IngressBackend) {
 	*out = *in
@@ -708,13 +963,34 @@ func (in *IngressSourceSpec) DeepCopy() *IngressSourceSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LBPolicySpec) DeepCopyInto(out *LBPolicySpec) {
+	*out = *in
+	if in.HashKeyCookie != nil {
+		in, out := &in.HashKeyCookie, &out.HashKeyCookie
+		*out = new(HashKeyCookieSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LBPolicySpec.
+func (in *LBPolicySpec) DeepCopy() *LBPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LBPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocalRateLimitSpec) DeepCopyInto(out *LocalRateLimitSpec) {
 	*out = *in
 	if in.TCP != nil {
 		in, out := &in.TCP, &out.TCP
 		*out = new(TCPLocalRateLimitSpec)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.HTTP != nil {
 		in, out := &in.HTTP, &out.HTTP
@@ -734,6 +1010,42 @@ func (in *LocalRateLimitSpec) DeepCopy() *LocalRateLimitSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutlierDetectionSpec) DeepCopyInto(out *OutlierDetectionSpec) {
+	*out = *in
+	if in.Consecutive5xx != nil {
+		in, out := &in.Consecutive5xx, &out.Consecutive5xx
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.BaseEjectionTime != nil {
+		in, out := &in.BaseEjectionTime, &out.BaseEjectionTime
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxEjectionPercent != nil {
+		in, out := &in.MaxEjectionPercent, &out.MaxEjectionPercent
+		*out = new(uint32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutlierDetectionSpec.
+func (in *OutlierDetectionSpec) DeepCopy() *OutlierDetectionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OutlierDetectionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PortSpec) DeepCopyInto(out *PortSpec) {
 	*out = *in
@@ -824,6 +1136,22 @@ func (in *RequestHeaderDescriptorEntry) DeepCopy() *RequestHeaderDescriptorEntry
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequestPathDescriptorEntry) DeepCopyInto(out *RequestPathDescriptorEntry) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequestPathDescriptorEntry.
+func (in *RequestPathDescriptorEntry) DeepCopy() *RequestPathDescriptorEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(RequestPathDescriptorEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Retry) DeepCopyInto(out *Retry) {
 	*out = *in
@@ -954,6 +1282,22 @@ func (in *RetrySrcDstSpec) DeepCopy() *RetrySrcDstSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceAccountSpec) DeepCopyInto(out *ServiceAccountSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceAccountSpec.
+func (in *ServiceAccountSpec) DeepCopy() *ServiceAccountSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceAccountSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TCPConnectionSettings) DeepCopyInto(out *TCPConnectionSettings) {
 	*out = *in
@@ -967,6 +1311,11 @@ func (in *TCPConnectionSettings) DeepCopyInto(out *TCPConnectionSettings) {
 		*out = new(metav1.Duration)
 		**out = **in
 	}
+	if in.TCPKeepalive != nil {
+		in, out := &in.TCPKeepalive, &out.TCPKeepalive
+		*out = new(TCPKeepaliveSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1014,9 +1363,45 @@ func (in *TCPGlobalRateLimitSpec) DeepCopy() *TCPGlobalRateLimitSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TCPKeepaliveSpec) DeepCopyInto(out *TCPKeepaliveSpec) {
+	*out = *in
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TCPKeepaliveSpec.
+func (in *TCPKeepaliveSpec) DeepCopy() *TCPKeepaliveSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TCPKeepaliveSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TCPLocalRateLimitSpec) DeepCopyInto(out *TCPLocalRateLimitSpec) {
 	*out = *in
+	if in.FillInterval != nil {
+		in, out := &in.FillInterval, &out.FillInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	return
 }
 
@@ -1262,11 +1647,36 @@ func (in *UpstreamTrafficSettingSpec) DeepCopyInto(out *UpstreamTrafficSettingSp
 		*out = new(ConnectionSettingsSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(HealthCheckSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OutlierDetection != nil {
+		in, out := &in.OutlierDetection, &out.OutlierDetection
+		*out = new(OutlierDetectionSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LBPolicy != nil {
+		in, out := &in.LBPolicy, &out.LBPolicy
+		*out = new(LBPolicySpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.RateLimit != nil {
 		in, out := &in.RateLimit, &out.RateLimit
 		*out = new(RateLimitSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Cors != nil {
+		in, out := &in.Cors, &out.Cors
+		*out = new(CorsSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.HTTPRoutes != nil {
 		in, out := &in.HTTPRoutes, &out.HTTPRoutes
 		*out = make([]HTTPRouteSpec, len(*in))
@@ -1274,6 +1684,63 @@ func (in *UpstreamTrafficSettingSpec) DeepCopyInto(out *UpstreamTrafficSettingSp
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AdditionalServerNames != nil {
+		in, out := &in.AdditionalServerNames, &out.AdditionalServerNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeniedServiceAccounts != nil {
+		in, out := &in.DeniedServiceAccounts, &out.DeniedServiceAccounts
+		*out = make([]ServiceAccountSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedSourceRanges != nil {
+		in, out := &in.AllowedSourceRanges, &out.AllowedSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HTTPRequestTimeout != nil {
+		in, out := &in.HTTPRequestTimeout, &out.HTTPRequestTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.TCPIdleTimeout != nil {
+		in, out := &in.TCPIdleTimeout, &out.TCPIdleTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.HeaderToMetadata != nil {
+		in, out := &in.HeaderToMetadata, &out.HeaderToMetadata
+		*out = make([]HeaderToMetadataSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.EndpointSubsets != nil {
+		in, out := &in.EndpointSubsets, &out.EndpointSubsets
+		*out = make([]EndpointSubsetSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ResponseHeadersToAdd != nil {
+		in, out := &in.ResponseHeadersToAdd, &out.ResponseHeadersToAdd
+		*out = make([]HTTPHeaderValue, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResponseHeadersToRemove != nil {
+		in, out := &in.ResponseHeadersToRemove, &out.ResponseHeadersToRemove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DirectResponse != nil {
+		in, out := &in.DirectResponse, &out.DirectResponse
+		*out = new(DirectResponseSpec)
+		**out = **in
+	}
+	if in.HostRewrite != nil {
+		in, out := &in.HostRewrite, &out.HostRewrite
+		*out = new(HostRewriteSpec)
+		**out = **in
+	}
 	return
 }
 
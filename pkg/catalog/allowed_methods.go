@@ -0,0 +1,144 @@
+package catalog
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set"
+
+	"github.com/openservicemesh/osm/pkg/constants"
+	"github.com/openservicemesh/osm/pkg/service"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
+)
+
+// GetAllowedMethods returns the sorted list of HTTP methods allowed on the given path of the service reachable using
+// the given host. The allowed methods are the union of the methods of the inbound routes matching the path, as built
+// for the proxies of the services. They reflect the traffic policy mode of each service, its protocol, and the routes of
+// the TrafficSplit apex services it is a backend of. The path may include a query string, matched against the query
+// parameters of the routes. The headers of a request are not known, so routes requiring headers other than the authority,
+// or requiring gRPC requests, are considered to match. The wildcard method is returned if any method is allowed. An error
+// is returned if no service is reachable using the host, or if no route allows requests to the path.
+func (mc *MeshCatalog) GetAllowedMethods(host string, path string) ([]string, error) {
+	serviceFound := false
+	methods := mapset.NewSet()
+	for _, svc := range mc.ListServices() {
+		svcIdentities, err := mc.ListServiceIdentitiesForService(svc.Name, svc.Namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, svcIdentity := range svcIdentities {
+			// The policies are built without the inbound policy cache, so that building them for every service does
+			// not evict the cached policies of the proxies
+			routeConfigPerPort, err := mc.getInboundMeshHTTPRouteConfigsPerPort(svcIdentity, []service.MeshService{svc}, nil)
+			if err != nil {
+				logInboundMeshHTTPRouteConfigsError(err, svcIdentity)
+			}
+			for _, policies := range routeConfigPerPort {
+				for _, policy := range policies {
+					if !isHostForPolicy(host, policy) {
+						continue
+					}
+					serviceFound = true
+
+					for _, rule := range policy.Rules {
+						if !isRouteMatch(rule.Route.HTTPRouteMatch, host, path) {
+							continue
+						}
+						for _, method := range rule.Route.HTTPRouteMatch.Methods {
+							methods.Add(method)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if !serviceFound {
+		return nil, fmt.Errorf("%w: %s", errNoServiceForHost, host)
+	}
+	if methods.Cardinality() == 0 {
+		return nil, fmt.Errorf("%w: %s%s", errNoRouteForPath, host, path)
+	}
+	if methods.Contains(constants.WildcardHTTPMethod) {
+		return []string{constants.WildcardHTTPMethod}, nil
+	}
+
+	allowedMethods := make([]string, 0, methods.Cardinality())
+	for _, method := range methods.ToSlice() {
+		allowedMethods = append(allowedMethods, method.(string))
+	}
+	sort.Strings(allowedMethods)
+	return allowedMethods, nil
+}
+
+// isHostForPolicy returns a boolean indicating if the given host is one of the hostnames of the given inbound policy
+func isHostForPolicy(host string, policy *trafficpolicy.InboundTrafficPolicy) bool {
+	for _, hostname := range policy.Hostnames {
+		if strings.EqualFold(host, hostname) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRouteMatch returns a boolean indicating if a request with the given host and path, which may include a query string,
+// matches the authority, path and query parameters of the given route match, the same way they are matched by the proxy
+func isRouteMatch(match trafficpolicy.HTTPRouteMatch, host string, path string) bool {
+	if match.Authority != "" && match.Authority != host {
+		return false
+	}
+
+	path, rawQuery, _ := strings.Cut(path, "?")
+	if !isPathMatch(match, path) {
+		return false
+	}
+	if len(match.QueryParams) == 0 {
+		return true
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return false
+	}
+	for name, value := range match.QueryParams {
+		// The first value of a query parameter is matched, as done by the proxy
+		if !query.Has(name) || query.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// isPathMatch returns a boolean indicating if the given path matches the path of the given route match, the same way
+// the path is matched by the proxy for the route
+func isPathMatch(match trafficpolicy.HTTPRouteMatch, path string) bool {
+	caseSensitive := match.IsCaseSensitive()
+	switch match.PathMatchType {
+	case trafficpolicy.PathMatchExact:
+		if !caseSensitive {
+			return strings.EqualFold(match.Path, path)
+		}
+		return match.Path == path
+	case trafficpolicy.PathMatchPrefix:
+		if !caseSensitive {
+			return strings.HasPrefix(strings.ToLower(path), strings.ToLower(match.Path))
+		}
+		return strings.HasPrefix(path, match.Path)
+	default:
+		// Regex paths must match the whole path
+		flags := ""
+		if !caseSensitive {
+			flags = "(?i)"
+		}
+		pathRegex, err := regexp.Compile(fmt.Sprintf("%s^(?:%s)$", flags, match.Path))
+		if err != nil {
+			log.Error().Err(err).Msgf("Invalid path regex %s", match.Path)
+			return false
+		}
+		return pathRegex.MatchString(path)
+	}
+}
@@ -0,0 +1,278 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	access "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/access/v1alpha3"
+	spec "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/specs/v1alpha4"
+	split "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/split/v1alpha2"
+	tassert "github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
+	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
+	tresorFake "github.com/openservicemesh/osm/pkg/certificate/providers/tresor/fake"
+	"github.com/openservicemesh/osm/pkg/compute"
+	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/service"
+	"github.com/openservicemesh/osm/pkg/smi"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
+)
+
+func TestGetAllowedMethods(t *testing.T) {
+	s1 := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	s1Identity := identity.K8sServiceAccount{Name: "sa1", Namespace: "ns1"}.ToServiceIdentity()
+
+	newTrafficTarget := func(name string, source string, matches []string) *access.TrafficTarget {
+		return &access.TrafficTarget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1"},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+				Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: source, Namespace: "ns2"}},
+				Rules:       []access.TrafficTargetRule{{Kind: smi.HTTPRouteGroupKind, Name: "routes", Matches: matches}},
+			},
+		}
+	}
+	httpRouteGroup := &spec.HTTPRouteGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "routes", Namespace: "ns1"},
+		Spec: spec.HTTPRouteGroupSpec{
+			Matches: []spec.HTTPMatch{
+				{Name: "get", PathRegex: "/get", Methods: []string{"GET", "HEAD"}},
+				{Name: "get-post", PathRegex: "/get", Methods: []string{"POST"}},
+				{Name: "items", PathRegex: "/items/.*", Methods: []string{"PUT"}},
+				{Name: "any", PathRegex: "/any"},
+			},
+		},
+	}
+
+	trafficSplit := &split.TrafficSplit{
+		ObjectMeta: metav1.ObjectMeta{Name: "split", Namespace: "ns1"},
+		Spec: split.TrafficSplitSpec{
+			Service:  "apex",
+			Backends: []split.TrafficSplitBackend{{Service: "s1", Weight: 100}},
+		},
+	}
+	enforceSMI := &policyv1alpha1.UpstreamTrafficSetting{
+		ObjectMeta: metav1.ObjectMeta{Name: "u1", Namespace: "ns1"},
+		Spec:       policyv1alpha1.UpstreamTrafficSettingSpec{Host: s1.FQDN(), EnforceSMI: true},
+	}
+
+	testCases := []struct {
+		name                   string
+		permissiveMode         bool
+		trafficTargets         []*access.TrafficTarget
+		trafficSplits          []*split.TrafficSplit
+		upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting
+		host                   string
+		path                   string
+		expectedMethods        []string
+		expectedErr            error
+	}{
+		{
+			name:            "SMI mode returns the union of methods across TrafficTargets",
+			trafficTargets:  []*access.TrafficTarget{newTrafficTarget("t1", "sa2", []string{"get"}), newTrafficTarget("t2", "sa3", []string{"get-post", "items"})},
+			host:            "s1.ns1",
+			path:            "/get",
+			expectedMethods: []string{"GET", "HEAD", "POST"},
+		},
+		{
+			name:            "SMI mode matches the path regex against the whole path",
+			trafficTargets:  []*access.TrafficTarget{newTrafficTarget("t1", "sa2", []string{"get", "items"})},
+			host:            "s1.ns1",
+			path:            "/items/1",
+			expectedMethods: []string{"PUT"},
+		},
+		{
+			name:            "SMI mode with a route allowing any method",
+			trafficTargets:  []*access.TrafficTarget{newTrafficTarget("t1", "sa2", []string{"get", "any"})},
+			host:            "s1.ns1",
+			path:            "/any",
+			expectedMethods: []string{"*"},
+		},
+		{
+			name:           "SMI mode without a route matching the path",
+			trafficTargets: []*access.TrafficTarget{newTrafficTarget("t1", "sa2", []string{"get"})},
+			host:           "s1.ns1",
+			path:           "/get/more",
+			expectedErr:    errNoRouteForPath,
+		},
+		{
+			name:           "SMI mode without a service for the host",
+			trafficTargets: []*access.TrafficTarget{newTrafficTarget("t1", "sa2", []string{"get"})},
+			host:           "unknown.ns1",
+			path:           "/get",
+			expectedErr:    errNoServiceForHost,
+		},
+		{
+			name:            "permissive mode allows any method",
+			permissiveMode:  true,
+			host:            "s1.ns1",
+			path:            "/get",
+			expectedMethods: []string{"*"},
+		},
+		{
+			name:                   "permissive mode with a service opting in to SMI enforcement",
+			permissiveMode:         true,
+			trafficTargets:         []*access.TrafficTarget{newTrafficTarget("t1", "sa2", []string{"get"})},
+			upstreamTrafficSetting: enforceSMI,
+			host:                   "s1.ns1",
+			path:                   "/get",
+			expectedMethods:        []string{"GET", "HEAD"},
+		},
+		{
+			name:            "SMI mode with the host of a TrafficSplit apex service",
+			trafficTargets:  []*access.TrafficTarget{newTrafficTarget("t1", "sa2", []string{"get"})},
+			trafficSplits:   []*split.TrafficSplit{trafficSplit},
+			host:            "apex.ns1",
+			path:            "/get",
+			expectedMethods: []string{"GET", "HEAD"},
+		},
+		{
+			name:           "permissive mode without a service for the host",
+			permissiveMode: true,
+			host:           "unknown.ns1",
+			path:           "/get",
+			expectedErr:    errNoServiceForHost,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			provider := compute.NewMockInterface(mockCtrl)
+			mc := MeshCatalog{
+				certManager: tresorFake.NewFake(1 * time.Hour),
+				Interface:   provider,
+			}
+
+			provider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+				Spec: v1alpha2.MeshConfigSpec{
+					Traffic: v1alpha2.TrafficSpec{
+						EnablePermissiveTrafficPolicyMode: tc.permissiveMode,
+					},
+				},
+			}).AnyTimes()
+			provider.EXPECT().ListServices().Return([]service.MeshService{s1}).AnyTimes()
+			provider.EXPECT().ListTrafficTargets().Return(tc.trafficTargets).AnyTimes()
+			provider.EXPECT().ListHTTPTrafficSpecs().Return([]*spec.HTTPRouteGroup{httpRouteGroup}).AnyTimes()
+			provider.EXPECT().ListTrafficSplits().Return(tc.trafficSplits).AnyTimes()
+			provider.EXPECT().ListServiceIdentitiesForService(s1.Name, s1.Namespace).Return([]identity.ServiceIdentity{s1Identity}, nil).AnyTimes()
+			var upstreamTrafficSettings []*policyv1alpha1.UpstreamTrafficSetting
+			if tc.upstreamTrafficSetting != nil {
+				upstreamTrafficSettings = append(upstreamTrafficSettings, tc.upstreamTrafficSetting)
+			}
+			provider.EXPECT().ListUpstreamTrafficSettings().Return(upstreamTrafficSettings).AnyTimes()
+			provider.EXPECT().GetUpstreamTrafficSettingByService(gomock.Any()).DoAndReturn(
+				func(svc *service.MeshService) *policyv1alpha1.UpstreamTrafficSetting {
+					if svc.Name != s1.Name {
+						return nil
+					}
+					return tc.upstreamTrafficSetting
+				}).AnyTimes()
+			provider.EXPECT().GetHostnamesForService(gomock.Any(), true).DoAndReturn(
+				func(svc service.MeshService, _ bool) []string {
+					return []string{svc.Name, fmt.Sprintf("%s:%d", svc.Name, svc.Port), svc.Name + "." + svc.Namespace,
+						fmt.Sprintf("%s.%s:%d", svc.Name, svc.Namespace, svc.Port)}
+				}).AnyTimes()
+
+			actual, err := mc.GetAllowedMethods(tc.host, tc.path)
+			assert.True(errors.Is(err, tc.expectedErr))
+			assert.Equal(tc.expectedMethods, actual)
+		})
+	}
+}
+
+func TestIsPathMatch(t *testing.T) {
+	assert := tassert.New(t)
+
+	assert.True(isPathMatch(trafficpolicy.HTTPRouteMatch{Path: "/get", PathMatchType: trafficpolicy.PathMatchExact}, "/get"))
+	assert.False(isPathMatch(trafficpolicy.HTTPRouteMatch{Path: "/get", PathMatchType: trafficpolicy.PathMatchExact}, "/get/1"))
+	assert.True(isPathMatch(trafficpolicy.HTTPRouteMatch{Path: "/get", PathMatchType: trafficpolicy.PathMatchPrefix}, "/get/1"))
+	assert.True(isPathMatch(trafficpolicy.HTTPRouteMatch{Path: "/get/.*", PathMatchType: trafficpolicy.PathMatchRegex}, "/get/1"))
+	assert.False(isPathMatch(trafficpolicy.HTTPRouteMatch{Path: "/get", PathMatchType: trafficpolicy.PathMatchRegex}, "/get/1"))
+	assert.False(isPathMatch(trafficpolicy.HTTPRouteMatch{Path: "(", PathMatchType: trafficpolicy.PathMatchRegex}, "("))
+}
+
+func TestIsPathMatchCaseInsensitive(t *testing.T) {
+	assert := tassert.New(t)
+	caseInsensitive := false
+
+	assert.True(isPathMatch(trafficpolicy.HTTPRouteMatch{Path: "/Get", PathMatchType: trafficpolicy.PathMatchExact, CaseSensitive: &caseInsensitive}, "/gET"))
+	assert.True(isPathMatch(trafficpolicy.HTTPRouteMatch{Path: "/Get", PathMatchType: trafficpolicy.PathMatchPrefix, CaseSensitive: &caseInsensitive}, "/gET/1"))
+	assert.True(isPathMatch(trafficpolicy.HTTPRouteMatch{Path: "/Get/.*", PathMatchType: trafficpolicy.PathMatchRegex, CaseSensitive: &caseInsensitive}, "/gET/1"))
+	assert.False(isPathMatch(trafficpolicy.HTTPRouteMatch{Path: "/Get", PathMatchType: trafficpolicy.PathMatchExact}, "/gET"))
+}
+
+func TestIsRouteMatch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		match    trafficpolicy.HTTPRouteMatch
+		host     string
+		path     string
+		expected bool
+	}{
+		{
+			name:     "path without query string",
+			match:    trafficpolicy.HTTPRouteMatch{Path: "/get", PathMatchType: trafficpolicy.PathMatchExact},
+			host:     "s1.ns1",
+			path:     "/get",
+			expected: true,
+		},
+		{
+			name:     "query string is not matched against the path",
+			match:    trafficpolicy.HTTPRouteMatch{Path: "/get", PathMatchType: trafficpolicy.PathMatchExact},
+			host:     "s1.ns1",
+			path:     "/get?version=1",
+			expected: true,
+		},
+		{
+			name:     "matching query parameter",
+			match:    trafficpolicy.HTTPRouteMatch{Path: "/get", PathMatchType: trafficpolicy.PathMatchExact, QueryParams: map[string]string{"version": "1"}},
+			host:     "s1.ns1",
+			path:     "/get?version=1&debug=true",
+			expected: true,
+		},
+		{
+			name:     "query parameter with another value",
+			match:    trafficpolicy.HTTPRouteMatch{Path: "/get", PathMatchType: trafficpolicy.PathMatchExact, QueryParams: map[string]string{"version": "1"}},
+			host:     "s1.ns1",
+			path:     "/get?version=2",
+			expected: false,
+		},
+		{
+			name:     "missing query parameter",
+			match:    trafficpolicy.HTTPRouteMatch{Path: "/get", PathMatchType: trafficpolicy.PathMatchExact, QueryParams: map[string]string{"debug": ""}},
+			host:     "s1.ns1",
+			path:     "/get",
+			expected: false,
+		},
+		{
+			name:     "matching authority",
+			match:    trafficpolicy.HTTPRouteMatch{Path: "/get", PathMatchType: trafficpolicy.PathMatchExact, Authority: "s1.ns1"},
+			host:     "s1.ns1",
+			path:     "/get",
+			expected: true,
+		},
+		{
+			name:     "other authority",
+			match:    trafficpolicy.HTTPRouteMatch{Path: "/get", PathMatchType: trafficpolicy.PathMatchExact, Authority: "s1.ns1:80"},
+			host:     "s1.ns1",
+			path:     "/get",
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			assert.Equal(tc.expected, isRouteMatch(tc.match, tc.host, tc.path))
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/openservicemesh/osm/pkg/certificate"
 	"github.com/openservicemesh/osm/pkg/compute"
 	"github.com/openservicemesh/osm/pkg/messaging"
+	"github.com/openservicemesh/osm/pkg/service"
 	"github.com/openservicemesh/osm/pkg/ticker"
 )
 
@@ -24,5 +25,40 @@ func NewMeshCatalog(computeInterface compute.Interface, certManager *certificate
 	resyncTicker := ticker.NewResyncTicker(msgBroker, 30*time.Second /* min resync interval */)
 	resyncTicker.Start(stop)
 
+	go deleteMetricsOfDeletedServices(msgBroker, stop)
+
 	return mc
 }
+
+// defaultClusterNameFormatter formats local cluster names as "<namespace>/<name>|<port>|local"
+type defaultClusterNameFormatter struct{}
+
+// LocalClusterName returns the name of the local cluster for the given upstream service
+func (defaultClusterNameFormatter) LocalClusterName(svc service.MeshService) string {
+	return svc.EnvoyLocalClusterName()
+}
+
+// SetClusterNameFormatter sets the formatter used to name the local clusters for upstream services.
+// A nil formatter restores the default naming scheme.
+func (mc *MeshCatalog) SetClusterNameFormatter(formatter ClusterNameFormatter) {
+	mc.clusterNameFormatter = formatter
+
+	// The cached inbound policies reference the local clusters by the names of the previous formatter
+	if mc.inboundPolicyCache != nil {
+		mc.inboundPolicyCache.reset()
+	}
+}
+
+// EnableInboundPolicyCache enables caching the inbound HTTP route configs built for the upstream identity and services
+// of each proxy, so that they are only rebuilt after a change to the resources they are built from.
+func (mc *MeshCatalog) EnableInboundPolicyCache() {
+	mc.inboundPolicyCache = newInboundPolicyCache(inboundPolicyCacheMaxEntries)
+}
+
+// localClusterName returns the name of the local cluster for the given upstream service
+func (mc *MeshCatalog) localClusterName(svc service.MeshService) string {
+	if mc.clusterNameFormatter == nil {
+		return defaultClusterNameFormatter{}.LocalClusterName(svc)
+	}
+	return mc.clusterNameFormatter.LocalClusterName(svc)
+}
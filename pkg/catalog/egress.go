@@ -327,6 +327,11 @@ func getHTTPRouteMatchesFromHTTPRouteGroup(httpRouteGroup *smiSpecs.HTTPRouteGro
 			Headers:       match.Headers,
 		}
 
+		// SMI HTTPRouteGroup header values are regexes
+		if len(httpRouteMatch.Headers) > 0 {
+			httpRouteMatch.HeaderMatchType = trafficpolicy.HeaderMatchRegex
+		}
+
 		// When pathRegex and/or methods are not defined, they should be wildcarded
 		if httpRouteMatch.Path == "" {
 			httpRouteMatch.Path = constants.RegexMatchAll
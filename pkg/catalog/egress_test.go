@@ -887,6 +887,34 @@ func TestGetHTTPRouteMatchesFromHTTPRouteGroup(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "HTTP route match with regex headers",
+			httpRouteGroup: &specs.HTTPRouteGroup{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "specs.smi-spec.io/v1alpha4",
+					Kind:       "HTTPRouteGroup",
+				},
+				Spec: spec.HTTPRouteGroupSpec{
+					Matches: []specs.HTTPMatch{
+						{
+							Name:      "match-1",
+							PathRegex: "/foo",
+							Methods:   []string{"GET"},
+							Headers:   map[string]string{"user-agent": ".*Chrome.*"},
+						},
+					},
+				},
+			},
+			expectedMatches: []trafficpolicy.HTTPRouteMatch{
+				{
+					Path:            "/foo",
+					PathMatchType:   trafficpolicy.PathMatchRegex,
+					Methods:         []string{"GET"},
+					Headers:         map[string]string{"user-agent": ".*Chrome.*"},
+					HeaderMatchType: trafficpolicy.HeaderMatchRegex,
+				},
+			},
+		},
 		{
 			name:            "nil HTTPRouteGroup",
 			httpRouteGroup:  nil,
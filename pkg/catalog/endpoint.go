@@ -1,9 +1,12 @@
 package catalog
 
 import (
+	"sort"
+
 	"github.com/openservicemesh/osm/pkg/endpoint"
 	"github.com/openservicemesh/osm/pkg/identity"
 	"github.com/openservicemesh/osm/pkg/service"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
 )
 
 // ListAllowedUpstreamEndpointsForService returns the list of endpoints over which the downstream client identity
@@ -45,3 +48,48 @@ func (mc *MeshCatalog) ListAllowedUpstreamEndpointsForService(downstreamIdentity
 
 	return allowedEndpoints
 }
+
+// GetIdentityZone returns the zone of the endpoints of the given service identity, or an empty string if
+// its endpoints do not reside in a single known zone
+func (mc *MeshCatalog) GetIdentityZone(svcIdentity identity.ServiceIdentity) string {
+	var zone string
+	for _, ep := range mc.ListEndpointsForIdentity(svcIdentity) {
+		if ep.Zone == "" || (zone != "" && ep.Zone != zone) {
+			return ""
+		}
+		zone = ep.Zone
+	}
+	return zone
+}
+
+// GetLocalityWeights returns the locality weights of the zones of the given local cluster endpoints, weighted by
+// their number of endpoints. The given local zone has the highest priority when it has endpoints, so that traffic
+// is only sent to the other zones when the endpoints in the local zone are unhealthy. Locality weights are not
+// returned if any endpoint's zone is unknown.
+func GetLocalityWeights(endpoints []endpoint.Endpoint, localZone string) []trafficpolicy.LocalityWeight {
+	endpointsPerZone := make(map[string]uint32)
+	for _, ep := range endpoints {
+		// Endpoints with a weight belong to a remote cluster, and have their own locality
+		if ep.Weight != 0 {
+			continue
+		}
+		if ep.Zone == "" {
+			return nil
+		}
+		endpointsPerZone[ep.Zone]++
+	}
+
+	_, hasLocalZone := endpointsPerZone[localZone]
+	var localityWeights []trafficpolicy.LocalityWeight
+	for zone, count := range endpointsPerZone {
+		localityWeight := trafficpolicy.LocalityWeight{Zone: zone, Weight: count}
+		if hasLocalZone && zone != localZone {
+			localityWeight.Priority = 1
+		}
+		localityWeights = append(localityWeights, localityWeight)
+	}
+	sort.Slice(localityWeights, func(i, j int) bool {
+		return localityWeights[i].Zone < localityWeights[j].Zone
+	})
+	return localityWeights
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/openservicemesh/osm/pkg/k8s"
 	"github.com/openservicemesh/osm/pkg/service"
 	"github.com/openservicemesh/osm/pkg/tests"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
 )
 
 func TestListAllowedUpstreamEndpointsForService(t *testing.T) {
@@ -208,3 +209,71 @@ func TestListAllowedUpstreamEndpointsForService(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLocalityWeights(t *testing.T) {
+	testCases := []struct {
+		name      string
+		endpoints []endpoint.Endpoint
+		localZone string
+		expected  []trafficpolicy.LocalityWeight
+	}{
+		{
+			name: "endpoints in the local zone are preferred",
+			endpoints: []endpoint.Endpoint{
+				{IP: net.ParseIP("10.0.0.1"), Zone: "zone-b"},
+				{IP: net.ParseIP("10.0.0.2"), Zone: "zone-a"},
+				{IP: net.ParseIP("10.0.0.3"), Zone: "zone-b"},
+			},
+			localZone: "zone-a",
+			expected: []trafficpolicy.LocalityWeight{
+				{Zone: "zone-a", Weight: 1, Priority: 0},
+				{Zone: "zone-b", Weight: 2, Priority: 1},
+			},
+		},
+		{
+			name: "no endpoints in the local zone",
+			endpoints: []endpoint.Endpoint{
+				{IP: net.ParseIP("10.0.0.1"), Zone: "zone-b"},
+				{IP: net.ParseIP("10.0.0.2"), Zone: "zone-c"},
+			},
+			localZone: "zone-a",
+			expected: []trafficpolicy.LocalityWeight{
+				{Zone: "zone-b", Weight: 1, Priority: 0},
+				{Zone: "zone-c", Weight: 1, Priority: 0},
+			},
+		},
+		{
+			name: "endpoint without a zone",
+			endpoints: []endpoint.Endpoint{
+				{IP: net.ParseIP("10.0.0.1"), Zone: "zone-a"},
+				{IP: net.ParseIP("10.0.0.2")},
+			},
+			localZone: "zone-a",
+			expected:  nil,
+		},
+		{
+			name: "remote endpoints are ignored",
+			endpoints: []endpoint.Endpoint{
+				{IP: net.ParseIP("10.0.0.1"), Zone: "zone-a"},
+				{IP: net.ParseIP("10.0.0.2"), Weight: 10},
+			},
+			localZone: "zone-a",
+			expected: []trafficpolicy.LocalityWeight{
+				{Zone: "zone-a", Weight: 1, Priority: 0},
+			},
+		},
+		{
+			name:      "no endpoints",
+			endpoints: nil,
+			localZone: "zone-a",
+			expected:  nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			assert.Equal(tc.expected, GetLocalityWeights(tc.endpoints, tc.localZone))
+		})
+	}
+}
@@ -5,4 +5,34 @@ import "fmt"
 var (
 	// errNoTrafficSpecFoundForTrafficPolicy is an error for when OSM cannot find a traffic spec for the given traffic policy.
 	errNoTrafficSpecFoundForTrafficPolicy = fmt.Errorf("no traffic spec found for the traffic policy")
+
+	// errMeshRootCertificateNotFound is an error for when OSM cannot find the given MeshRootCertificate.
+	errMeshRootCertificateNotFound = fmt.Errorf("mesh root certificate not found")
+
+	// errMissingTrafficSplitBackend is an error for when a backend service referenced by a TrafficSplit does not exist.
+	errMissingTrafficSplitBackend = fmt.Errorf("traffic split backend service not found")
+
+	// errUnknownTrafficSplitBackendNamespace is an error for when a TrafficSplit backend references a namespace not monitored by the mesh.
+	errUnknownTrafficSplitBackendNamespace = fmt.Errorf("traffic split backend namespace not found")
+
+	// errInvalidServiceIdentity is an error for when a service identity is not in the format <name>.<namespace>.
+	errInvalidServiceIdentity = fmt.Errorf("invalid service identity")
+
+	// errNoServiceForHost is an error for when OSM cannot find a service reachable using the given host.
+	errNoServiceForHost = fmt.Errorf("no service found for host")
+
+	// errNoRouteForPath is an error for when OSM cannot find a route allowing requests to the given path.
+	errNoRouteForPath = fmt.Errorf("no route found for path")
+
+	// errInvalidHTTPRouteGroup is an error for when an HTTPRouteGroup cannot be used to build routes.
+	errInvalidHTTPRouteGroup = fmt.Errorf("invalid HTTPRouteGroup")
+
+	// errHTTPRouteGroupNotFound is an error for when an HTTPRouteGroup referenced by a TrafficTarget does not exist.
+	errHTTPRouteGroupNotFound = fmt.Errorf("HTTPRouteGroup not found")
+
+	// errHTTPRouteGroupMatchNotFound is an error for when a match referenced by a TrafficTarget does not exist in the HTTPRouteGroup.
+	errHTTPRouteGroupMatchNotFound = fmt.Errorf("HTTPRouteGroup match not found")
+
+	// errNoInboundPolicyForService is an error for when OSM cannot find an inbound traffic policy for the given service and port.
+	errNoInboundPolicyForService = fmt.Errorf("no inbound traffic policy found for service")
 )
@@ -11,7 +11,10 @@ import (
 	split "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/split/v1alpha2"
 
 	"github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
+	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
 	"github.com/openservicemesh/osm/pkg/compute"
+	"github.com/openservicemesh/osm/pkg/compute/kube"
+	"github.com/openservicemesh/osm/pkg/k8s"
 	"github.com/openservicemesh/osm/pkg/service"
 
 	tresorFake "github.com/openservicemesh/osm/pkg/certificate/providers/tresor/fake"
@@ -53,3 +56,26 @@ func newFakeMeshCatalogForRoutes(t *testing.T, testParams testParams) *MeshCatal
 	return NewMeshCatalog(provider, tresorFake.NewFake(1*time.Hour),
 		stop, messaging.NewBroker(stop))
 }
+
+// catalogTestResources are the resources listed by the mock controller backing the MeshCatalog returned by
+// newFakeMeshCatalogWithResources. Resources that are not set are not found.
+type catalogTestResources struct {
+	meshConfig              v1alpha2.MeshConfig
+	trafficTargets          []*access.TrafficTarget
+	httpRouteGroups         []*specs.HTTPRouteGroup
+	trafficSplits           []*split.TrafficSplit
+	upstreamTrafficSettings []*policyv1alpha1.UpstreamTrafficSetting
+}
+
+// newFakeMeshCatalogWithResources returns a MeshCatalog backed by a mock controller listing the given resources,
+// along with the mock controller so that tests can set expectations on other resources.
+func newFakeMeshCatalogWithResources(mockCtrl *gomock.Controller, resources catalogTestResources) (*MeshCatalog, *k8s.MockController) {
+	mockK8s := k8s.NewMockController(mockCtrl)
+	mockK8s.EXPECT().GetMeshConfig().Return(resources.meshConfig).AnyTimes()
+	mockK8s.EXPECT().ListTrafficTargets().Return(resources.trafficTargets).AnyTimes()
+	mockK8s.EXPECT().ListHTTPTrafficSpecs().Return(resources.httpRouteGroups).AnyTimes()
+	mockK8s.EXPECT().ListTrafficSplits().Return(resources.trafficSplits).AnyTimes()
+	mockK8s.EXPECT().ListUpstreamTrafficSettings().Return(resources.upstreamTrafficSettings).AnyTimes()
+
+	return &MeshCatalog{Interface: kube.NewClient(mockK8s)}, mockK8s
+}
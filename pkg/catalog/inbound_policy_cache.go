@@ -0,0 +1,207 @@
+package catalog
+
+import (
+	"container/list"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	hashstructure "github.com/mitchellh/hashstructure/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/service"
+	"github.com/openservicemesh/osm/pkg/smi"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
+)
+
+// inboundPolicyCacheMaxEntries is the maximum number of upstream identity and services combinations whose inbound
+// HTTP route configs are cached. Proxies of the same workload share an entry, so the least recently used entries
+// only belong to workloads that have not been programmed recently, ex. workloads that were deleted.
+const inboundPolicyCacheMaxEntries = 2048
+
+// inboundPolicyCache caches the inbound HTTP route configs built for the upstream identity and services of a proxy.
+// Each entry records the hash of the versions of the inputs the route configs were built from, so that an entry is
+// only reused while the resources it was built from are unchanged, and is rebuilt on the next lookup after any of them
+// changes. The least recently used entry is evicted when the cache is full.
+type inboundPolicyCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[uint64]*list.Element
+	// lru orders the entries from the most to the least recently used
+	lru *list.List
+}
+
+// inboundPolicyCacheEntry is the inbound HTTP route configs cached for the upstream identity and services of a proxy
+type inboundPolicyCacheEntry struct {
+	proxyKey           uint64
+	inputsHash         uint64
+	routeConfigPerPort map[int][]*trafficpolicy.InboundTrafficPolicy
+	err                error
+}
+
+// newInboundPolicyCache returns an empty inboundPolicyCache holding at most the given number of entries
+func newInboundPolicyCache(maxEntries int) *inboundPolicyCache {
+	return &inboundPolicyCache{
+		maxEntries: maxEntries,
+		entries:    make(map[uint64]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// get returns the cached inbound HTTP route configs for the given proxy key if they were built from inputs with the given hash
+func (c *inboundPolicyCache) get(proxyKey uint64, inputsHash uint64) (inboundPolicyCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[proxyKey]
+	if !ok {
+		return inboundPolicyCacheEntry{}, false
+	}
+	entry := elem.Value.(inboundPolicyCacheEntry)
+	if entry.inputsHash != inputsHash {
+		return inboundPolicyCacheEntry{}, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry, true
+}
+
+// set caches the given entry, replacing the entry built from previous inputs for the same proxy key if any, and
+// evicting the least recently used entry if the cache is full
+func (c *inboundPolicyCache) set(entry inboundPolicyCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[entry.proxyKey]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[entry.proxyKey] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(inboundPolicyCacheEntry).proxyKey)
+	}
+}
+
+// len returns the number of entries in the cache
+func (c *inboundPolicyCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// reset removes all the entries from the cache
+func (c *inboundPolicyCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[uint64]*list.Element)
+	c.lru.Init()
+}
+
+// getCachedInboundMeshHTTPRouteConfigsPerPort returns a map of the given inbound traffic policy per port for the given
+// upstream identity and services, along with an error if the policies could only be partially built. The policies are
+// served from the inbound policy cache when the resources they are built from are unchanged since they were last built.
+// The returned policies may be shared with other callers and must not be modified.
+func (mc *MeshCatalog) getCachedInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity identity.ServiceIdentity,
+	upstreamServices []service.MeshService) (map[int][]*trafficpolicy.InboundTrafficPolicy, error) {
+	if mc.inboundPolicyCache == nil {
+		return mc.getInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices, nil)
+	}
+
+	// The inputs are hashed before building the policies, so that a change to the inputs while the policies are
+	// built results in a cache miss on the next lookup
+	proxyKey, inputsHash, err := mc.hashInboundPolicyCacheInputs(upstreamIdentity, upstreamServices)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error hashing the inputs of the inbound HTTP route configs for upstream identity %s, skipping the cache",
+			upstreamIdentity)
+		return mc.getInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices, nil)
+	}
+
+	if entry, ok := mc.inboundPolicyCache.get(proxyKey, inputsHash); ok {
+		return entry.routeConfigPerPort, entry.err
+	}
+
+	routeConfigPerPort, err := mc.getInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices, nil)
+	mc.inboundPolicyCache.set(inboundPolicyCacheEntry{
+		proxyKey:           proxyKey,
+		inputsHash:         inputsHash,
+		routeConfigPerPort: routeConfigPerPort,
+		err:                err,
+	})
+	return routeConfigPerPort, err
+}
+
+// hashInboundPolicyCacheInputs returns the key identifying the given upstream identity and services in the inbound policy
+// cache, and the hash of the versions of the inputs the inbound HTTP route configs for them are built from. Resources are
+// identified by their resource version instead of their content, so that hashing the inputs is cheap compared to building
+// the route configs.
+func (mc *MeshCatalog) hashInboundPolicyCacheInputs(upstreamIdentity identity.ServiceIdentity,
+	upstreamServices []service.MeshService) (uint64, uint64, error) {
+	proxyKey, err := hashstructure.Hash(struct {
+		UpstreamIdentity identity.ServiceIdentity
+		UpstreamServices []service.MeshService
+	}{upstreamIdentity, upstreamServices}, hashstructure.FormatV2, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	principalInfosHash, err := hashstructure.Hash(mc.getIssuerPrincipalInfos(), hashstructure.FormatV2, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	meshConfig := mc.GetMeshConfig()
+	objects := []metav1.Object{&meshConfig}
+	for _, trafficTarget := range mc.ListTrafficTargetsByOptions(smi.WithTrafficTargetDestination(upstreamIdentity.ToK8sServiceAccount())) {
+		objects = append(objects, trafficTarget)
+	}
+	for _, routeGroup := range mc.ListHTTPTrafficSpecs() {
+		objects = append(objects, routeGroup)
+	}
+	for _, split := range mc.ListTrafficSplits() {
+		objects = append(objects, split)
+	}
+	for _, upstreamTrafficSetting := range mc.ListUpstreamTrafficSettings() {
+		objects = append(objects, upstreamTrafficSetting)
+	}
+
+	// The versions are sorted so that the hash does not depend on the order the resources are listed in
+	versions := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		version, err := getObjectVersion(obj)
+		if err != nil {
+			return 0, 0, err
+		}
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	hash := fnv.New64a()
+	_, _ = fmt.Fprintf(hash, "%d\n", principalInfosHash)
+	for _, version := range versions {
+		_, _ = fmt.Fprintln(hash, version)
+	}
+
+	return proxyKey, hash.Sum64(), nil
+}
+
+// getObjectVersion returns a string identifying the given version of the given resource. Resources read from the
+// API server are identified by their resource version. Resources without a resource version, ex. resources that
+// were not read from the API server, are identified by the hash of their content.
+func getObjectVersion(obj metav1.Object) (string, error) {
+	if resourceVersion := obj.GetResourceVersion(); resourceVersion != "" {
+		return fmt.Sprintf("%T/%s/%s@%s", obj, obj.GetNamespace(), obj.GetName(), resourceVersion), nil
+	}
+
+	contentHash, err := hashstructure.Hash(obj, hashstructure.FormatV2, nil)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%T/%s/%s#%d", obj, obj.GetNamespace(), obj.GetName(), contentHash), nil
+}
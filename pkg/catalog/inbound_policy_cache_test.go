@@ -0,0 +1,160 @@
+package catalog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	access "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/access/v1alpha3"
+	spec "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/specs/v1alpha4"
+	tassert "github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
+	tresorFake "github.com/openservicemesh/osm/pkg/certificate/providers/tresor/fake"
+	"github.com/openservicemesh/osm/pkg/compute/kube"
+	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/k8s"
+	"github.com/openservicemesh/osm/pkg/service"
+)
+
+func TestInboundPolicyCache(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamServices := []service.MeshService{{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}}
+
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rule-1"},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{{Name: "route-1", PathRegex: "/hello", Methods: []string{"GET"}}},
+			},
+		},
+	}
+	newTrafficTarget := func(source string) *access.TrafficTarget {
+		return &access.TrafficTarget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "t1"},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+				Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: source, Namespace: "ns2"}},
+				Rules:       []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "rule-1", Matches: []string{"route-1"}}},
+			},
+		}
+	}
+	trafficTargets := []*access.TrafficTarget{newTrafficTarget("sa2")}
+
+	mockK8s := k8s.NewMockController(mockCtrl)
+	mockK8s.EXPECT().ListUpstreamTrafficSettings().Return(nil).AnyTimes()
+	mockK8s.EXPECT().ListTrafficSplits().Return(nil).AnyTimes()
+	mockK8s.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{}).AnyTimes()
+	mockK8s.EXPECT().ListHTTPTrafficSpecs().Return(httpRouteGroups).AnyTimes()
+	mockK8s.EXPECT().ListTrafficTargets().DoAndReturn(func() []*access.TrafficTarget {
+		return trafficTargets
+	}).AnyTimes()
+
+	mc := MeshCatalog{
+		certManager: tresorFake.NewFake(1 * time.Hour),
+		Interface:   kube.NewClient(mockK8s),
+	}
+	mc.EnableInboundPolicyCache()
+
+	policies := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices)
+	assert.Len(policies[8080], 1)
+
+	// Unchanged resources are served from the cache
+	cached := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices)
+	assert.Len(cached[8080], 1)
+	assert.Same(policies[8080][0], cached[8080][0])
+
+	// A change to the TrafficTarget invalidates the cached policies
+	trafficTargets = []*access.TrafficTarget{newTrafficTarget("sa3")}
+	updated := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices)
+	assert.Len(updated[8080], 1)
+	assert.NotSame(policies[8080][0], updated[8080][0])
+	assert.Len(updated[8080][0].Rules, 1)
+	assert.False(policies[8080][0].Rules[0].AllowedPrincipals.Equal(updated[8080][0].Rules[0].AllowedPrincipals))
+
+	// Other upstream services of the same identity are cached separately
+	otherServices := []service.MeshService{{Name: "s2", Namespace: "ns1", Port: 80, TargetPort: 9090, Protocol: "http"}}
+	other := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, otherServices)
+	assert.Len(other[9090], 1)
+	assert.Same(updated[8080][0], mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices)[8080][0])
+}
+
+func TestInboundPolicyCacheResourceVersion(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamServices := []service.MeshService{{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}}
+
+	meshConfig := v1alpha2.MeshConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "osm-system", Name: "osm-mesh-config", ResourceVersion: "1"},
+		Spec: v1alpha2.MeshConfigSpec{
+			Traffic: v1alpha2.TrafficSpec{EnablePermissiveTrafficPolicyMode: true},
+		},
+	}
+
+	mockK8s := k8s.NewMockController(mockCtrl)
+	mockK8s.EXPECT().ListUpstreamTrafficSettings().Return(nil).AnyTimes()
+	mockK8s.EXPECT().ListTrafficSplits().Return(nil).AnyTimes()
+	mockK8s.EXPECT().ListHTTPTrafficSpecs().Return(nil).AnyTimes()
+	mockK8s.EXPECT().ListTrafficTargets().Return(nil).AnyTimes()
+	mockK8s.EXPECT().GetMeshConfig().DoAndReturn(func() v1alpha2.MeshConfig { return meshConfig }).AnyTimes()
+
+	mc := MeshCatalog{
+		certManager: tresorFake.NewFake(1 * time.Hour),
+		Interface:   kube.NewClient(mockK8s),
+	}
+	mc.EnableInboundPolicyCache()
+
+	policies := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices)
+	assert.Len(policies[8080], 1)
+
+	// Resources are identified by their resource version instead of their content
+	meshConfig.Spec.Traffic.InboundProbePaths = []string{"/healthz"}
+	assert.Same(policies[8080][0], mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices)[8080][0])
+
+	// A new resource version invalidates the cached policies
+	meshConfig.ResourceVersion = "2"
+	updated := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices)
+	assert.NotSame(policies[8080][0], updated[8080][0])
+	assert.Len(updated[8080][0].Rules, 2)
+}
+
+func TestInboundPolicyCacheEviction(t *testing.T) {
+	assert := tassert.New(t)
+
+	cache := newInboundPolicyCache(2)
+	cache.set(inboundPolicyCacheEntry{proxyKey: 1, inputsHash: 10})
+	cache.set(inboundPolicyCacheEntry{proxyKey: 2, inputsHash: 20})
+
+	// Looking up an entry makes it the most recently used
+	_, ok := cache.get(1, 10)
+	assert.True(ok)
+
+	// The least recently used entry is evicted when the cache is full
+	cache.set(inboundPolicyCacheEntry{proxyKey: 3, inputsHash: 30})
+	assert.Equal(2, cache.len())
+	_, ok = cache.get(2, 20)
+	assert.False(ok)
+	_, ok = cache.get(1, 10)
+	assert.True(ok)
+	_, ok = cache.get(3, 30)
+	assert.True(ok)
+
+	// Replacing the entry of a proxy does not grow the cache
+	cache.set(inboundPolicyCacheEntry{proxyKey: 3, inputsHash: 31})
+	assert.Equal(2, cache.len())
+	_, ok = cache.get(3, 30)
+	assert.False(ok)
+	_, ok = cache.get(3, 31)
+	assert.True(ok)
+
+	cache.reset()
+	assert.Equal(0, cache.len())
+}
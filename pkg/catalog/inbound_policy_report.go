@@ -0,0 +1,75 @@
+package catalog
+
+import (
+	"sort"
+
+	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/service"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
+)
+
+// InboundPolicyReport is the report of the inbound policies computed for the services in a namespace
+type InboundPolicyReport struct {
+	// Namespace is the namespace of the services
+	Namespace string
+
+	// Identities is the list of inbound policies per upstream identity, sorted by identity
+	Identities []InboundIdentityPolicies
+}
+
+// InboundIdentityPolicies is the inbound policies computed for the services of an upstream identity, as programmed
+// on the proxies of the identity
+type InboundIdentityPolicies struct {
+	// Identity is the upstream identity
+	Identity identity.ServiceIdentity
+
+	// Services is the list of services in the namespace backed by the upstream identity
+	Services []service.MeshService
+
+	// HTTPRouteConfigsPerPort is the inbound traffic policy per port, as returned by GetInboundMeshHTTPRouteConfigsPerPort
+	HTTPRouteConfigsPerPort map[int][]*trafficpolicy.InboundTrafficPolicy
+
+	// TrafficMatches is the list of inbound traffic matches, as returned by GetInboundMeshTrafficMatches
+	TrafficMatches []*trafficpolicy.TrafficMatch
+}
+
+// GetInboundPoliciesForNamespace returns a report of the inbound policies computed for the services in the given
+// namespace. The services are grouped by the upstream identities backing them, and the policies for each identity
+// are computed as they are for the proxies of the identity. It is meant for debugging.
+func (mc *MeshCatalog) GetInboundPoliciesForNamespace(ns string) (*InboundPolicyReport, error) {
+	servicesPerIdentity := make(map[identity.ServiceIdentity][]service.MeshService)
+	for _, svc := range mc.ListServices() {
+		if svc.Namespace != ns {
+			continue
+		}
+
+		svcIdentities, err := mc.ListServiceIdentitiesForService(svc.Name, svc.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, svcIdentity := range svcIdentities {
+			servicesPerIdentity[svcIdentity] = append(servicesPerIdentity[svcIdentity], svc)
+		}
+	}
+
+	upstreamIdentities := make([]identity.ServiceIdentity, 0, len(servicesPerIdentity))
+	for upstreamIdentity := range servicesPerIdentity {
+		upstreamIdentities = append(upstreamIdentities, upstreamIdentity)
+	}
+	sort.Slice(upstreamIdentities, func(i, j int) bool {
+		return upstreamIdentities[i] < upstreamIdentities[j]
+	})
+
+	report := &InboundPolicyReport{Namespace: ns}
+	for _, upstreamIdentity := range upstreamIdentities {
+		upstreamServices := servicesPerIdentity[upstreamIdentity]
+		report.Identities = append(report.Identities, InboundIdentityPolicies{
+			Identity:                upstreamIdentity,
+			Services:                upstreamServices,
+			HTTPRouteConfigsPerPort: mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices),
+			TrafficMatches:          mc.GetInboundMeshTrafficMatches(upstreamIdentity, upstreamServices),
+		})
+	}
+
+	return report, nil
+}
@@ -0,0 +1,121 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	tassert "github.com/stretchr/testify/assert"
+
+	"github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
+	"github.com/openservicemesh/osm/pkg/compute"
+	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/service"
+)
+
+func TestGetInboundPoliciesForNamespace(t *testing.T) {
+	s1 := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	s2 := service.MeshService{Name: "s2", Namespace: "ns1", Port: 90, TargetPort: 9090, Protocol: "http"}
+	s3 := service.MeshService{Name: "s3", Namespace: "ns1", Port: 70, TargetPort: 7070, Protocol: "http"}
+	s4 := service.MeshService{Name: "s4", Namespace: "ns2", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	sa1 := identity.K8sServiceAccount{Name: "sa1", Namespace: "ns1"}.ToServiceIdentity()
+	sa2 := identity.K8sServiceAccount{Name: "sa2", Namespace: "ns1"}.ToServiceIdentity()
+	sa3 := identity.K8sServiceAccount{Name: "sa3", Namespace: "ns2"}.ToServiceIdentity()
+	svcIdentities := map[string][]identity.ServiceIdentity{
+		s1.Name: {sa2},
+		s2.Name: {sa1},
+		s3.Name: {sa1},
+		s4.Name: {sa3},
+	}
+
+	testCases := []struct {
+		name                   string
+		namespace              string
+		listIdentitiesErr      error
+		expectedIdentities     []identity.ServiceIdentity
+		expectedServices       [][]service.MeshService
+		expectedTrafficMatches [][]string
+		expectErr              bool
+	}{
+		{
+			name:                   "services in the namespace are grouped by identity",
+			namespace:              "ns1",
+			expectedIdentities:     []identity.ServiceIdentity{sa1, sa2},
+			expectedServices:       [][]service.MeshService{{s2, s3}, {s1}},
+			expectedTrafficMatches: [][]string{{s2.InboundTrafficMatchName(), s3.InboundTrafficMatchName()}, {s1.InboundTrafficMatchName()}},
+		},
+		{
+			name:                   "services in other namespaces are filtered out",
+			namespace:              "ns2",
+			expectedIdentities:     []identity.ServiceIdentity{sa3},
+			expectedServices:       [][]service.MeshService{{s4}},
+			expectedTrafficMatches: [][]string{{s4.InboundTrafficMatchName()}},
+		},
+		{
+			name:               "namespace without services",
+			namespace:          "ns3",
+			expectedIdentities: nil,
+		},
+		{
+			name:              "error listing the identities of a service",
+			namespace:         "ns1",
+			listIdentitiesErr: errors.New("service not found"),
+			expectErr:         true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockCompute := compute.NewMockInterface(mockCtrl)
+			mc := MeshCatalog{Interface: mockCompute}
+
+			mockCompute.EXPECT().ListServices().Return([]service.MeshService{s1, s2, s3, s4}).AnyTimes()
+			mockCompute.EXPECT().ListServiceIdentitiesForService(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(name, namespace string) ([]identity.ServiceIdentity, error) {
+					if tc.listIdentitiesErr != nil {
+						return nil, tc.listIdentitiesErr
+					}
+					return svcIdentities[name], nil
+				}).AnyTimes()
+			mockCompute.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+				Spec: v1alpha2.MeshConfigSpec{
+					Traffic: v1alpha2.TrafficSpec{
+						EnablePermissiveTrafficPolicyMode: true,
+					},
+				},
+			}).AnyTimes()
+			mockCompute.EXPECT().ListTrafficSplits().AnyTimes()
+			mockCompute.EXPECT().GetUpstreamTrafficSettingByService(gomock.Any()).AnyTimes()
+			mockCompute.EXPECT().GetHostnamesForService(gomock.Any(), gomock.Any()).AnyTimes()
+			mockCompute.EXPECT().ListSubdomainsForService(gomock.Any()).AnyTimes()
+
+			report, err := mc.GetInboundPoliciesForNamespace(tc.namespace)
+			assert.Equal(tc.expectErr, err != nil)
+			if tc.expectErr {
+				return
+			}
+
+			assert.Equal(tc.namespace, report.Namespace)
+			assert.Len(report.Identities, len(tc.expectedIdentities))
+			for i, identityPolicies := range report.Identities {
+				assert.Equal(tc.expectedIdentities[i], identityPolicies.Identity)
+				assert.Equal(tc.expectedServices[i], identityPolicies.Services)
+
+				var trafficMatchNames []string
+				for _, trafficMatch := range identityPolicies.TrafficMatches {
+					trafficMatchNames = append(trafficMatchNames, trafficMatch.Name)
+				}
+				assert.ElementsMatch(tc.expectedTrafficMatches[i], trafficMatchNames)
+
+				for _, svc := range identityPolicies.Services {
+					assert.Len(identityPolicies.HTTPRouteConfigsPerPort[int(svc.TargetPort)], 1)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package catalog
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	mapset "github.com/deckarep/golang-set"
+
+	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
+
+	"github.com/openservicemesh/osm/pkg/service"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
+)
+
+// inboundPolicySnapshot is the serialized form of the inbound traffic policies for a port
+type inboundPolicySnapshot struct {
+	Port     int                            `json:"port"`
+	Policies []inboundTrafficPolicySnapshot `json:"policies"`
+}
+
+// inboundTrafficPolicySnapshot is the serialized form of a trafficpolicy.InboundTrafficPolicy
+type inboundTrafficPolicySnapshot struct {
+	Name                    string                                `json:"name"`
+	Hostnames               []string                              `json:"hostnames"`
+	Rules                   []ruleSnapshot                        `json:"rules"`
+	RateLimit               *policyv1alpha1.RateLimitSpec         `json:"rateLimit,omitempty"`
+	RetryPolicy             *policyv1alpha1.RetryPolicySpec       `json:"retryPolicy,omitempty"`
+	Cors                    *policyv1alpha1.CorsSpec              `json:"cors,omitempty"`
+	ServerName              string                                `json:"serverName,omitempty"`
+	AllowWebSocketUpgrade   bool                                  `json:"allowWebSocketUpgrade,omitempty"`
+	HeaderToMetadata        []policyv1alpha1.HeaderToMetadataSpec `json:"headerToMetadata,omitempty"`
+	ResponseHeadersToAdd    []policyv1alpha1.HTTPHeaderValue      `json:"responseHeadersToAdd,omitempty"`
+	ResponseHeadersToRemove []string                              `json:"responseHeadersToRemove,omitempty"`
+	EnableGRPCWeb           bool                                  `json:"enableGRPCWeb,omitempty"`
+}
+
+// ruleSnapshot is the serialized form of a trafficpolicy.Rule, with the set of allowed principals as a sorted list
+type ruleSnapshot struct {
+	Route               routeSnapshot     `json:"route"`
+	AllowedPrincipals   []string          `json:"allowedPrincipals"`
+	DeniedPrincipals    []string          `json:"deniedPrincipals,omitempty"`
+	RequiredHeaders     map[string]string `json:"requiredHeaders,omitempty"`
+	AllowedSourceRanges []string          `json:"allowedSourceRanges,omitempty"`
+}
+
+// routeSnapshot is the serialized form of a trafficpolicy.RouteWeightedClusters, with the set of weighted clusters
+// as a sorted list
+type routeSnapshot struct {
+	HTTPRouteMatch           trafficpolicy.HTTPRouteMatch              `json:"httpRouteMatch"`
+	WeightedClusters         []service.WeightedCluster                 `json:"weightedClusters"`
+	RetryPolicy              *policyv1alpha1.RetryPolicySpec           `json:"retryPolicy,omitempty"`
+	RateLimit                *policyv1alpha1.HTTPPerRouteRateLimitSpec `json:"rateLimit,omitempty"`
+	RequireClientCertificate bool                                      `json:"requireClientCertificate,omitempty"`
+	StatPrefix               string                                    `json:"statPrefix,omitempty"`
+	RuntimeKeyPrefix         string                                    `json:"runtimeKeyPrefix,omitempty"`
+	Timeout                  *time.Duration                            `json:"timeout,omitempty"`
+	FaultInjection           *policyv1alpha1.HTTPFaultInjectionSpec    `json:"faultInjection,omitempty"`
+	Redirect                 *trafficpolicy.RouteRedirect              `json:"redirect,omitempty"`
+	DirectResponse           *policyv1alpha1.DirectResponseSpec        `json:"directResponse,omitempty"`
+	RewritePrefix            string                                    `json:"rewritePrefix,omitempty"`
+	HostRewrite              *policyv1alpha1.HostRewriteSpec           `json:"hostRewrite,omitempty"`
+}
+
+// SerializeInboundPolicy returns a stable JSON serialization of the given inbound traffic policies per port, as returned
+// by GetInboundMeshHTTPRouteConfigsPerPort. Ports and the elements of sets are sorted so that the same policies always
+// serialize to the same bytes, allowing a snapshot to be diffed against the live state.
+func SerializeInboundPolicy(policiesPerPort map[int][]*trafficpolicy.InboundTrafficPolicy) ([]byte, error) {
+	ports := make([]int, 0, len(policiesPerPort))
+	for port := range policiesPerPort {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+
+	snapshots := make([]inboundPolicySnapshot, 0, len(ports))
+	for _, port := range ports {
+		snapshot := inboundPolicySnapshot{Port: port}
+		for _, policy := range policiesPerPort[port] {
+			policySnapshot := inboundTrafficPolicySnapshot{
+				Name:                    policy.Name,
+				Hostnames:               policy.Hostnames,
+				RateLimit:               policy.RateLimit,
+				RetryPolicy:             policy.RetryPolicy,
+				Cors:                    policy.Cors,
+				ServerName:              policy.ServerName,
+				AllowWebSocketUpgrade:   policy.AllowWebSocketUpgrade,
+				HeaderToMetadata:        policy.HeaderToMetadata,
+				ResponseHeadersToAdd:    policy.ResponseHeadersToAdd,
+				ResponseHeadersToRemove: policy.ResponseHeadersToRemove,
+				EnableGRPCWeb:           policy.EnableGRPCWeb,
+			}
+			for _, rule := range policy.Rules {
+				policySnapshot.Rules = append(policySnapshot.Rules, ruleSnapshot{
+					Route: routeSnapshot{
+						HTTPRouteMatch:           rule.Route.HTTPRouteMatch,
+						WeightedClusters:         sortedWeightedClusters(rule.Route.WeightedClusters),
+						RetryPolicy:              rule.Route.RetryPolicy,
+						RateLimit:                rule.Route.RateLimit,
+						RequireClientCertificate: rule.Route.RequireClientCertificate,
+						StatPrefix:               rule.Route.StatPrefix,
+						RuntimeKeyPrefix:         rule.Route.RuntimeKeyPrefix,
+						Timeout:                  rule.Route.Timeout,
+						FaultInjection:           rule.Route.FaultInjection,
+						Redirect:                 rule.Route.Redirect,
+						DirectResponse:           rule.Route.DirectResponse,
+						RewritePrefix:            rule.Route.RewritePrefix,
+						HostRewrite:              rule.Route.HostRewrite,
+					},
+					AllowedPrincipals:   sortedPrincipals(rule.AllowedPrincipals),
+					DeniedPrincipals:    sortedPrincipals(rule.DeniedPrincipals),
+					RequiredHeaders:     rule.RequiredHeaders,
+					AllowedSourceRanges: rule.AllowedSourceRanges,
+				})
+			}
+			snapshot.Policies = append(snapshot.Policies, policySnapshot)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return json.Marshal(snapshots)
+}
+
+// LoadInboundPolicy returns the inbound traffic policies per port from the given snapshot serialized by SerializeInboundPolicy
+func LoadInboundPolicy(data []byte) (map[int][]*trafficpolicy.InboundTrafficPolicy, error) {
+	var snapshots []inboundPolicySnapshot
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return nil, err
+	}
+
+	policiesPerPort := make(map[int][]*trafficpolicy.InboundTrafficPolicy)
+	for _, snapshot := range snapshots {
+		var policies []*trafficpolicy.InboundTrafficPolicy
+		for _, policySnapshot := range snapshot.Policies {
+			policy := &trafficpolicy.InboundTrafficPolicy{
+				Name:                    policySnapshot.Name,
+				Hostnames:               policySnapshot.Hostnames,
+				RateLimit:               policySnapshot.RateLimit,
+				RetryPolicy:             policySnapshot.RetryPolicy,
+				Cors:                    policySnapshot.Cors,
+				ServerName:              policySnapshot.ServerName,
+				AllowWebSocketUpgrade:   policySnapshot.AllowWebSocketUpgrade,
+				HeaderToMetadata:        policySnapshot.HeaderToMetadata,
+				ResponseHeadersToAdd:    policySnapshot.ResponseHeadersToAdd,
+				ResponseHeadersToRemove: policySnapshot.ResponseHeadersToRemove,
+				EnableGRPCWeb:           policySnapshot.EnableGRPCWeb,
+			}
+			for _, rule := range policySnapshot.Rules {
+				policy.Rules = append(policy.Rules, &trafficpolicy.Rule{
+					Route: trafficpolicy.RouteWeightedClusters{
+						HTTPRouteMatch:           rule.Route.HTTPRouteMatch,
+						WeightedClusters:         weightedClustersToSet(rule.Route.WeightedClusters),
+						RetryPolicy:              rule.Route.RetryPolicy,
+						RateLimit:                rule.Route.RateLimit,
+						RequireClientCertificate: rule.Route.RequireClientCertificate,
+						StatPrefix:               rule.Route.StatPrefix,
+						RuntimeKeyPrefix:         rule.Route.RuntimeKeyPrefix,
+						Timeout:                  rule.Route.Timeout,
+						FaultInjection:           rule.Route.FaultInjection,
+						Redirect:                 rule.Route.Redirect,
+						DirectResponse:           rule.Route.DirectResponse,
+						RewritePrefix:            rule.Route.RewritePrefix,
+						HostRewrite:              rule.Route.HostRewrite,
+					},
+					AllowedPrincipals:   principalsToSet(rule.AllowedPrincipals),
+					DeniedPrincipals:    principalsToSet(rule.DeniedPrincipals),
+					RequiredHeaders:     rule.RequiredHeaders,
+					AllowedSourceRanges: rule.AllowedSourceRanges,
+				})
+			}
+			policies = append(policies, policy)
+		}
+		policiesPerPort[snapshot.Port] = policies
+	}
+
+	return policiesPerPort, nil
+}
+
+// sortedWeightedClusters returns the weighted clusters in the given set sorted by cluster name and weight.
+// A nil set is returned as a nil slice so that it is preserved across a round-trip.
+func sortedWeightedClusters(set mapset.Set) []service.WeightedCluster {
+	if set == nil {
+		return nil
+	}
+	clusters := make([]service.WeightedCluster, 0, set.Cardinality())
+	for _, wc := range set.ToSlice() {
+		clusters = append(clusters, wc.(service.WeightedCluster))
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].ClusterName == clusters[j].ClusterName {
+			return clusters[i].Weight < clusters[j].Weight
+		}
+		return clusters[i].ClusterName < clusters[j].ClusterName
+	})
+	return clusters
+}
+
+// sortedPrincipals returns the principals in the given set sorted alphabetically.
+// A nil set is returned as a nil slice so that it is preserved across a round-trip.
+func sortedPrincipals(set mapset.Set) []string {
+	if set == nil {
+		return nil
+	}
+	principals := make([]string, 0, set.Cardinality())
+	for _, p := range set.ToSlice() {
+		principals = append(principals, p.(string))
+	}
+	sort.Strings(principals)
+	return principals
+}
+
+func weightedClustersToSet(clusters []service.WeightedCluster) mapset.Set {
+	if clusters == nil {
+		return nil
+	}
+	set := mapset.NewSet()
+	for _, wc := range clusters {
+		set.Add(wc)
+	}
+	return set
+}
+
+func principalsToSet(principals []string) mapset.Set {
+	if principals == nil {
+		return nil
+	}
+	set := mapset.NewSet()
+	for _, p := range principals {
+		set.Add(p)
+	}
+	return set
+}
@@ -0,0 +1,185 @@
+package catalog
+
+import (
+	"testing"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/golang/mock/gomock"
+	tassert "github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
+	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
+	"github.com/openservicemesh/osm/pkg/compute/kube"
+	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/k8s"
+	"github.com/openservicemesh/osm/pkg/service"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
+)
+
+func TestInboundPolicySnapshotRoundTrip(t *testing.T) {
+	retryTimeout := metav1.Duration{Duration: 5}
+
+	testCases := []struct {
+		name     string
+		policies map[int][]*trafficpolicy.InboundTrafficPolicy
+	}{
+		{
+			name:     "no policies",
+			policies: map[int][]*trafficpolicy.InboundTrafficPolicy{},
+		},
+		{
+			name: "policies on multiple ports",
+			policies: map[int][]*trafficpolicy.InboundTrafficPolicy{
+				8080: {
+					{
+						Name:      "s1.ns1.svc.cluster.local",
+						Hostnames: []string{"s1", "s1.ns1"},
+						RateLimit: &policyv1alpha1.RateLimitSpec{
+							Local: &policyv1alpha1.LocalRateLimitSpec{
+								HTTP: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 10, Unit: "second"},
+							},
+						},
+						Rules: []*trafficpolicy.Rule{
+							{
+								Route: trafficpolicy.RouteWeightedClusters{
+									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+										Path:          "/get",
+										PathMatchType: trafficpolicy.PathMatchRegex,
+										Methods:       []string{"GET"},
+										Headers:       map[string]string{"foo": "bar", "baz": "qux"},
+									},
+									WeightedClusters: mapset.NewSet(
+										service.WeightedCluster{ClusterName: "ns1/s1|8080|local", Weight: 100},
+										service.WeightedCluster{ClusterName: "ns1/s1-v2|8080|local", Weight: 50},
+									),
+									RetryPolicy: &policyv1alpha1.RetryPolicySpec{
+										RetryOn:       "5xx",
+										PerTryTimeout: &retryTimeout,
+										NumRetries:    pointer.Uint32(3),
+									},
+									RateLimit: &policyv1alpha1.HTTPPerRouteRateLimitSpec{
+										Local: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 5, Unit: "minute"},
+									},
+									RequireClientCertificate: true,
+									StatPrefix:               "ns1_s1_80.route_0123abcd.rate_limited_true",
+								},
+								AllowedPrincipals: mapset.NewSet("sa2.ns2.cluster.local", "sa3.ns3.cluster.local"),
+							},
+						},
+					},
+				},
+				9090: {
+					{
+						Name:      "s2.ns1.svc.cluster.local",
+						Hostnames: []string{"s2"},
+						ResponseHeadersToAdd: []policyv1alpha1.HTTPHeaderValue{
+							{Name: "x-frame-options", Value: "DENY"},
+						},
+						ResponseHeadersToRemove: []string{"server", "x-powered-by"},
+						EnableGRPCWeb:           true,
+						Rules: []*trafficpolicy.Rule{
+							{
+								Route: trafficpolicy.RouteWeightedClusters{
+									HTTPRouteMatch:   trafficpolicy.WildCardRouteMatch,
+									WeightedClusters: mapset.NewSet(service.WeightedCluster{ClusterName: "ns1/s2|9090|local", Weight: 100}),
+								},
+								AllowedPrincipals: mapset.NewSet(identity.WildcardPrincipal),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			data, err := SerializeInboundPolicy(tc.policies)
+			assert.NoError(err)
+
+			loaded, err := LoadInboundPolicy(data)
+			assert.NoError(err)
+			assert.Equal(tc.policies, loaded)
+
+			// Serializing the loaded policy results in the same snapshot
+			reserialized, err := SerializeInboundPolicy(loaded)
+			assert.NoError(err)
+			assert.Equal(string(data), string(reserialized))
+		})
+	}
+}
+
+func TestSerializeInboundPolicyIsDeterministic(t *testing.T) {
+	assert := tassert.New(t)
+
+	newPolicies := func(principals []interface{}) map[int][]*trafficpolicy.InboundTrafficPolicy {
+		policies := make(map[int][]*trafficpolicy.InboundTrafficPolicy)
+		for _, port := range []int{9090, 8080, 7070} {
+			policies[port] = []*trafficpolicy.InboundTrafficPolicy{
+				{
+					Name:      "s1.ns1.svc.cluster.local",
+					Hostnames: []string{"s1"},
+					Rules: []*trafficpolicy.Rule{
+						{
+							Route: trafficpolicy.RouteWeightedClusters{
+								HTTPRouteMatch:   trafficpolicy.WildCardRouteMatch,
+								WeightedClusters: mapset.NewSet(service.WeightedCluster{ClusterName: "ns1/s1|8080|local", Weight: 100}),
+							},
+							AllowedPrincipals: mapset.NewSetFromSlice(principals),
+						},
+					},
+				},
+			}
+		}
+		return policies
+	}
+
+	expected, err := SerializeInboundPolicy(newPolicies([]interface{}{"a.ns.cluster.local", "b.ns.cluster.local", "c.ns.cluster.local"}))
+	assert.NoError(err)
+
+	for i := 0; i < 10; i++ {
+		actual, err := SerializeInboundPolicy(newPolicies([]interface{}{"c.ns.cluster.local", "a.ns.cluster.local", "b.ns.cluster.local"}))
+		assert.NoError(err)
+		assert.Equal(string(expected), string(actual))
+	}
+}
+
+func TestInboundPolicySnapshotOfLiveState(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockK8s := k8s.NewMockController(mockCtrl)
+	mc := MeshCatalog{Interface: kube.NewClient(mockK8s)}
+
+	mockK8s.EXPECT().ListUpstreamTrafficSettings().Return(nil).AnyTimes()
+	mockK8s.EXPECT().ListTrafficSplits().Return(nil).AnyTimes()
+	mockK8s.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+		Spec: v1alpha2.MeshConfigSpec{
+			Traffic: v1alpha2.TrafficSpec{
+				EnablePermissiveTrafficPolicyMode: true,
+			},
+		},
+	}).AnyTimes()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamServices := []service.MeshService{
+		{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"},
+		{Name: "s2", Namespace: "ns1", Port: 90, TargetPort: 9090, Protocol: "http"},
+	}
+
+	live := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices)
+	snapshot, err := SerializeInboundPolicy(live)
+	assert.NoError(err)
+
+	loaded, err := LoadInboundPolicy(snapshot)
+	assert.NoError(err)
+	assert.Equal(live, loaded)
+
+	_, err = LoadInboundPolicy([]byte("not json"))
+	assert.Error(err)
+}
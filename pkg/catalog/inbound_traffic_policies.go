@@ -2,15 +2,30 @@ package catalog
 
 import (
 	"fmt"
+	"hash/fnv"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
 	mapset "github.com/deckarep/golang-set"
+	"github.com/hashicorp/go-multierror"
+	hashstructure "github.com/mitchellh/hashstructure/v2"
 	access "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/access/v1alpha3"
+	smiSplit "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/split/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
 
+	configv1alpha2 "github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
 	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
 
+	"github.com/openservicemesh/osm/pkg/certificate"
 	"github.com/openservicemesh/osm/pkg/constants"
 	"github.com/openservicemesh/osm/pkg/errcode"
 	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/k8s/events"
+	"github.com/openservicemesh/osm/pkg/messaging"
+	"github.com/openservicemesh/osm/pkg/metricsstore"
 	"github.com/openservicemesh/osm/pkg/service"
 	"github.com/openservicemesh/osm/pkg/smi"
 	"github.com/openservicemesh/osm/pkg/trafficpolicy"
@@ -24,46 +39,95 @@ const (
 	DisallowPartialHostnamesMatch bool = false
 )
 
-// GetInboundMeshClusterConfigs returns the cluster configs for the inbound mesh traffic policy for the given upstream services
-func (mc *MeshCatalog) GetInboundMeshClusterConfigs(upstreamServices []service.MeshService) []*trafficpolicy.MeshClusterConfig {
-	allUpstreamServices := mc.getUpstreamServicesIncludeApex(upstreamServices)
+// GetInboundMeshClusterConfigs returns the cluster configs for the inbound mesh traffic policy for the given upstream identity and services.
+// TCP services whose port is not allowed by the TCPRoutes of the TrafficTargets for the upstream identity are skipped.
+func (mc *MeshCatalog) GetInboundMeshClusterConfigs(upstreamIdentity identity.ServiceIdentity, upstreamServices []service.MeshService) []*trafficpolicy.MeshClusterConfig {
+	allUpstreamServices := mc.getUpstreamServicesIncludeApex(resolvePortProtocolConflicts(mc.withoutExcludedPorts(upstreamServices)))
+	listTrafficTargets := mc.lazyInboundTrafficTargets(upstreamIdentity)
+
+	localClusterAddress := mc.GetMeshConfig().Spec.Sidecar.LocalClusterAddress
+	if localClusterAddress == "" {
+		localClusterAddress = constants.LocalhostIPAddress
+	}
 
 	// Used to avoid duplicate clusters that can arise when multiple
 	// upstream services reference the same global rate limit service
 	rlsClusterSet := mapset.NewSet()
 
+	// Used to avoid duplicate local clusters that can arise when multiple
+	// upstream services, such as the ports of a backend shared by multiple
+	// apex services, map to the same local cluster
+	localClusterSet := mapset.NewSet()
+
 	var clusterConfigs []*trafficpolicy.MeshClusterConfig
 
 	// Build configurations per upstream service
 	for _, upstreamSvc := range allUpstreamServices {
 		upstreamSvc := upstreamSvc // To prevent loop variable memory aliasing in for loop
+		if isTCPService(upstreamSvc) && !isTCPPortAllowedByTrafficTargets(listTrafficTargets(), upstreamSvc.TargetPort) {
+			log.Debug().Msgf("Skipping inbound cluster config for upstream service %s, its port is not allowed by any TCPRoute", upstreamSvc)
+			continue
+		}
+		upstreamTrafficSetting := mc.GetUpstreamTrafficSettingByService(&upstreamSvc)
 
 		// ---
 		// Create local cluster configs for this upstram service
-		clusterConfigForSvc := &trafficpolicy.MeshClusterConfig{
-			Name:    upstreamSvc.EnvoyLocalClusterName(),
-			Service: upstreamSvc,
-			Address: constants.LocalhostIPAddress,
-			Port:    uint32(upstreamSvc.TargetPort),
+		localClusterName := mc.localClusterName(upstreamSvc)
+		if newlyAdded := localClusterSet.Add(localClusterName); newlyAdded {
+			clusterConfigForSvc := &trafficpolicy.MeshClusterConfig{
+				Name:             localClusterName,
+				Service:          upstreamSvc,
+				Address:          localClusterAddress,
+				Port:             uint32(upstreamSvc.TargetPort),
+				UpstreamProtocol: getLocalClusterUpstreamProtocol(upstreamSvc, upstreamTrafficSetting),
+			}
+			if upstreamTrafficSetting != nil {
+				// Circuit breaking thresholds configured for the service's host apply to the traffic accepted by the service
+				clusterConfigForSvc.ConnectionSettings = upstreamTrafficSetting.Spec.ConnectionSettings
+				clusterConfigForSvc.SubsetSelectors = getEndpointSubsetSelectors(upstreamTrafficSetting)
+			}
+			clusterConfigs = append(clusterConfigs, clusterConfigForSvc)
 		}
-		clusterConfigs = append(clusterConfigs, clusterConfigForSvc)
 
-		upstreamTrafficSetting := mc.GetUpstreamTrafficSettingByService(&upstreamSvc)
 		clusterConfigs = append(clusterConfigs, getRateLimitServiceClusters(upstreamTrafficSetting, rlsClusterSet)...)
 	}
 
+	// Sort the clusters by name so that the same clusters are always returned in the same order,
+	// regardless of the order of the given upstream services
+	sort.Slice(clusterConfigs, func(i, j int) bool {
+		return clusterConfigs[i].Name < clusterConfigs[j].Name
+	})
+
 	return clusterConfigs
 }
 
-// GetInboundMeshTrafficMatches returns the traffic matches for the inbound mesh traffic policy for the given upstream services
-func (mc *MeshCatalog) GetInboundMeshTrafficMatches(upstreamServices []service.MeshService) []*trafficpolicy.TrafficMatch {
+// GetInboundMeshTrafficMatches returns the traffic matches for the inbound mesh traffic policy for the given upstream identity and services.
+// A TrafficMatch is returned for each upstream service regardless of its protocol, including HTTP services whose routes are
+// programmed by the route configs, so that LDS can build a filter chain matching the service's port and server names.
+// When inbound TCP filter chains per identity are enabled in SMI mode, a TrafficMatch is returned for each downstream
+// identity allowed to access a TCP service instead, with the principals of the identity as its AllowedPrincipals.
+// TCP services whose port is not allowed by the TCPRoutes of the TrafficTargets for the upstream identity are skipped.
+// As for the inbound routes, an invalid UpstreamTrafficSetting for a service is ignored.
+func (mc *MeshCatalog) GetInboundMeshTrafficMatches(upstreamIdentity identity.ServiceIdentity, upstreamServices []service.MeshService) []*trafficpolicy.TrafficMatch {
 	var trafficMatches []*trafficpolicy.TrafficMatch
 
+	trafficSpec := mc.GetMeshConfig().Spec.Traffic
+	perIdentity := trafficSpec.EnableInboundTCPFilterChainPerIdentity && !trafficSpec.EnablePermissiveTrafficPolicyMode
+	var principalInfos []certificate.PrincipalInfo
+	if perIdentity {
+		principalInfos = mc.getIssuerPrincipalInfos()
+	}
+	listTrafficTargets := mc.lazyInboundTrafficTargets(upstreamIdentity)
+
 	// Build configurations per upstream service
-	for _, upstreamSvc := range upstreamServices {
+	for _, upstreamSvc := range resolvePortProtocolConflicts(mc.withoutExcludedPorts(upstreamServices)) {
 		upstreamSvc := upstreamSvc // To prevent loop variable memory aliasing in for loop
+		if isTCPService(upstreamSvc) && !isTCPPortAllowedByTrafficTargets(listTrafficTargets(), upstreamSvc.TargetPort) {
+			log.Debug().Msgf("Skipping inbound traffic match for upstream service %s, its port is not allowed by any TCPRoute", upstreamSvc)
+			continue
+		}
 
-		upstreamTrafficSetting := mc.GetUpstreamTrafficSettingByService(&upstreamSvc)
+		upstreamTrafficSetting := mc.getValidUpstreamTrafficSettingByService(upstreamSvc)
 
 		// ---
 		// Create a TrafficMatch for this upstream servic.
@@ -82,42 +146,349 @@ func (mc *MeshCatalog) GetInboundMeshTrafficMatches(upstreamServices []service.M
 			Name:                upstreamSvc.InboundTrafficMatchName(),
 			DestinationPort:     int(upstreamSvc.TargetPort),
 			DestinationProtocol: upstreamSvc.Protocol,
-			ServerNames:         []string{upstreamSvc.ServerName()},
-			Cluster:             upstreamSvc.EnvoyLocalClusterName(),
+			ServerNames:         mc.getServerNamesForService(upstreamSvc, upstreamTrafficSetting),
+			Cluster:             mc.localClusterName(upstreamSvc),
 		}
 		if upstreamTrafficSetting != nil {
 			trafficMatchForUpstreamSvc.RateLimit = upstreamTrafficSetting.Spec.RateLimit
+			trafficMatchForUpstreamSvc.AccessLogFormat = upstreamTrafficSetting.Spec.AccessLogFormat
+			// Only accept connections negotiated with the required SNI
+			trafficMatchForUpstreamSvc.RequiredServerName = upstreamTrafficSetting.Spec.ServerName
+			if idleTimeout := upstreamTrafficSetting.Spec.TCPIdleTimeout; idleTimeout != nil && isTCPService(upstreamSvc) {
+				trafficMatchForUpstreamSvc.IdleTimeout = &idleTimeout.Duration
+			}
+			if isTCPService(upstreamSvc) || isTLSPassthroughService(upstreamSvc) {
+				// The source ranges of HTTP services are enforced on their inbound routes instead
+				trafficMatchForUpstreamSvc.AllowedSourceRanges = getAllowedSourceRanges(upstreamTrafficSetting)
+			}
 		}
+
+		if isTLSPassthroughService(upstreamSvc) {
+			// TLS is terminated by the application, so the connection is routed by SNI without
+			// requiring a mesh identity from the downstream
+			trafficMatches = append(trafficMatches, trafficMatchForUpstreamSvc)
+			continue
+		}
+
+		if perIdentity && isTCPService(upstreamSvc) {
+			for _, downstreamIdentity := range getAllowedTCPDownstreamIdentities(listTrafficTargets(), upstreamSvc.TargetPort) {
+				trafficMatchForIdentity := *trafficMatchForUpstreamSvc
+				for _, principalInfo := range principalInfos {
+					trafficMatchForIdentity.AllowedPrincipals = append(trafficMatchForIdentity.AllowedPrincipals,
+						downstreamIdentity.AsPrincipal(principalInfo.TrustDomain, principalInfo.SpiffeEnabled))
+				}
+				trafficMatches = append(trafficMatches, &trafficMatchForIdentity)
+			}
+			continue
+		}
+
 		trafficMatches = append(trafficMatches, trafficMatchForUpstreamSvc)
 	}
 
 	return trafficMatches
 }
 
+// GetServerNamesForService returns the server names accepted by the inbound traffic match of the given upstream service,
+// as set on the ServerNames of the TrafficMatch returned by GetInboundMeshTrafficMatches for the service
+func (mc *MeshCatalog) GetServerNamesForService(svc service.MeshService) []string {
+	return mc.getServerNamesForService(svc, mc.getValidUpstreamTrafficSettingByService(svc))
+}
+
+// getServerNamesForService returns the server names accepted by the inbound traffic match of the given upstream service
+// configured by the given UpstreamTrafficSetting. The server names are derived from the FQDN of the service and of the pods
+// backing it if it is headless, unless the UpstreamTrafficSetting requires a server name, followed by the additional server
+// names of the UpstreamTrafficSetting.
+func (mc *MeshCatalog) getServerNamesForService(svc service.MeshService, upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) []string {
+	if upstreamTrafficSetting != nil && upstreamTrafficSetting.Spec.ServerName != "" {
+		return withAdditionalServerNames([]string{upstreamTrafficSetting.Spec.ServerName}, upstreamTrafficSetting)
+	}
+
+	serverNames := []string{svc.ServerName()}
+	// Clients may address specific replicas of a headless service using the stable DNS names of its pods
+	for _, subdomain := range mc.ListSubdomainsForService(svc) {
+		podSvc := svc
+		podSvc.Subdomain = subdomain
+		serverNames = append(serverNames, podSvc.ServerName())
+	}
+	return withAdditionalServerNames(serverNames, upstreamTrafficSetting)
+}
+
+// withAdditionalServerNames returns the given server names followed by the additional server names of the given
+// UpstreamTrafficSetting that are not already in the list
+func withAdditionalServerNames(serverNames []string, upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) []string {
+	if upstreamTrafficSetting == nil {
+		return serverNames
+	}
+
+	serverNameSet := mapset.NewSet()
+	for _, serverName := range serverNames {
+		serverNameSet.Add(serverName)
+	}
+	for _, serverName := range upstreamTrafficSetting.Spec.AdditionalServerNames {
+		if serverNameSet.Add(serverName) {
+			serverNames = append(serverNames, serverName)
+		}
+	}
+	return serverNames
+}
+
+// lazyInboundTrafficTargets returns a function that lists the inbound TrafficTargets with routes for the given upstream
+// identity on its first call, so that TrafficTargets are only listed when the upstream services include a TCP service
+func (mc *MeshCatalog) lazyInboundTrafficTargets(upstreamIdentity identity.ServiceIdentity) func() []trafficpolicy.TrafficTargetWithRoutes {
+	var trafficTargets []trafficpolicy.TrafficTargetWithRoutes
+	listed := false
+	return func() []trafficpolicy.TrafficTargetWithRoutes {
+		if listed {
+			return trafficTargets
+		}
+		listed = true
+
+		var err error
+		if trafficTargets, err = mc.ListInboundTrafficTargetsWithRoutes(upstreamIdentity); err != nil {
+			log.Error().Err(err).Msgf("Error listing inbound TrafficTargets for upstream identity %s", upstreamIdentity)
+		}
+		return trafficTargets
+	}
+}
+
+// getLocalClusterUpstreamProtocol returns the HTTP protocol used for the connections to the given local service, overridden
+// by the given UpstreamTrafficSetting if set. An empty string is returned if the service accepts the downstream protocol.
+func getLocalClusterUpstreamProtocol(upstreamSvc service.MeshService, upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) string {
+	if isTCPService(upstreamSvc) || isTLSPassthroughService(upstreamSvc) {
+		return ""
+	}
+	if upstreamTrafficSetting != nil && upstreamTrafficSetting.Spec.UpstreamProtocol != "" {
+		return upstreamTrafficSetting.Spec.UpstreamProtocol
+	}
+
+	switch upstreamSvc.Protocol {
+	case constants.ProtocolH2C, constants.ProtocolHTTP2, constants.ProtocolHTTP1:
+		return upstreamSvc.Protocol
+	default:
+		// HTTP and gRPC services accept the protocol of the downstream connection
+		return ""
+	}
+}
+
+// isTCPService returns true if the given service's protocol is TCP based
+func isTCPService(svc service.MeshService) bool {
+	return svc.Protocol == constants.ProtocolTCP || svc.Protocol == constants.ProtocolTCPServerFirst
+}
+
+// isTLSPassthroughService returns true if the given service terminates its own TLS connections
+func isTLSPassthroughService(svc service.MeshService) bool {
+	return svc.Protocol == constants.ProtocolTLSPassthrough
+}
+
+// isTCPPortAllowedByTrafficTargets returns true if any of the given TrafficTargets allows the given TCP port,
+// or if there are no TrafficTargets, e.g. in permissive traffic policy mode
+func isTCPPortAllowedByTrafficTargets(trafficTargets []trafficpolicy.TrafficTargetWithRoutes, port uint16) bool {
+	if len(trafficTargets) == 0 {
+		return true
+	}
+	for _, trafficTarget := range trafficTargets {
+		if isTCPPortAllowed(trafficTarget.TCPRouteMatches, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// getAllowedTCPDownstreamIdentities returns the sorted list of downstream identities allowed by the given TrafficTargets
+// to access the given TCP port. A TrafficTarget without TCP routes allows its sources to access every port.
+func getAllowedTCPDownstreamIdentities(trafficTargets []trafficpolicy.TrafficTargetWithRoutes, port uint16) []identity.ServiceIdentity {
+	allowed := mapset.NewSet()
+
+	for _, trafficTarget := range trafficTargets {
+		if !isTCPPortAllowed(trafficTarget.TCPRouteMatches, port) {
+			continue
+		}
+		for _, source := range trafficTarget.Sources {
+			allowed.Add(source)
+		}
+	}
+
+	var downstreamIdentities []identity.ServiceIdentity
+	for downstreamIdentity := range allowed.Iter() {
+		downstreamIdentities = append(downstreamIdentities, downstreamIdentity.(identity.ServiceIdentity))
+	}
+	sort.Slice(downstreamIdentities, func(i, j int) bool {
+		return downstreamIdentities[i] < downstreamIdentities[j]
+	})
+
+	return downstreamIdentities
+}
+
+// isTCPPortAllowed returns true if the given TCP route matches allow the given port
+func isTCPPortAllowed(tcpRouteMatches []trafficpolicy.TCPRouteMatch, port uint16) bool {
+	if len(tcpRouteMatches) == 0 {
+		return true
+	}
+	for _, tcpRouteMatch := range tcpRouteMatches {
+		if len(tcpRouteMatch.Ports) == 0 {
+			return true
+		}
+		for _, allowedPort := range tcpRouteMatch.Ports {
+			if allowedPort == port {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // GetInboundMeshHTTPRouteConfigsPerPort returns a map of the given inbound traffic policy per port for the given upstream identity and services
 func (mc *MeshCatalog) GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity identity.ServiceIdentity, upstreamServices []service.MeshService) map[int][]*trafficpolicy.InboundTrafficPolicy {
+	routeConfigPerPort, err := mc.getCachedInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices)
+	if err != nil {
+		logInboundMeshHTTPRouteConfigsError(err, upstreamIdentity)
+	}
+	return routeConfigPerPort
+}
+
+// GetInboundMeshHTTPRouteConfigsPerPortWithError returns a map of the given inbound traffic policy per port for the given
+// upstream identity and services, along with an error if the policies could only be partially built, ex. when a TrafficTarget
+// references an HTTPRouteGroup that does not exist. The policies built despite the error are returned.
+func (mc *MeshCatalog) GetInboundMeshHTTPRouteConfigsPerPortWithError(upstreamIdentity identity.ServiceIdentity,
+	upstreamServices []service.MeshService) (map[int][]*trafficpolicy.InboundTrafficPolicy, error) {
+	return mc.getCachedInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices)
+}
+
+// RecordInboundRulesPerPolicy records the number of rules in the given inbound traffic policies, built for the given
+// upstream services, per service. The rules of a service's policies on different ports are counted together.
+func (mc *MeshCatalog) RecordInboundRulesPerPolicy(upstreamServices []service.MeshService,
+	routeConfigPerPort map[int][]*trafficpolicy.InboundTrafficPolicy) {
+	// Policies are named after the FQDN of the upstream service, which may be the apex service of a TrafficSplit
+	servicePerPolicyName := make(map[string]service.MeshService)
+	for _, svc := range mc.getUpstreamServicesIncludeApex(upstreamServices) {
+		servicePerPolicyName[svc.FQDN()] = service.MeshService{Namespace: svc.Namespace, Name: svc.Name}
+	}
+
+	rulesPerService := make(map[service.MeshService]int)
+	for _, policies := range routeConfigPerPort {
+		for _, policy := range policies {
+			if svc, ok := servicePerPolicyName[policy.Name]; ok {
+				rulesPerService[svc] += len(policy.Rules)
+			}
+		}
+	}
+
+	for svc, numRules := range rulesPerService {
+		metricsstore.DefaultMetricsStore.InboundRulesPerPolicy.WithLabelValues(svc.Namespace, svc.Name).Set(float64(numRules))
+	}
+}
+
+// deleteMetricsOfDeletedServices deletes the number of inbound rules recorded for services once they are deleted, so
+// that the metric does not keep reporting the rules of services that no longer exist
+func deleteMetricsOfDeletedServices(msgBroker *messaging.Broker, stop <-chan struct{}) {
+	svcDeleteChan, unsub := msgBroker.SubscribeKubeEvents(events.Service.Deleted())
+	defer unsub()
+
+	for {
+		select {
+		case <-stop:
+			log.Info().Msg("Received stop signal, exiting deleted service metrics routine")
+			return
+
+		case svcDeletedMsg := <-svcDeleteChan:
+			psubMessage, castOk := svcDeletedMsg.(events.PubSubMessage)
+			if !castOk {
+				log.Error().Msgf("Error casting to events.PubSubMessage, got type %T", svcDeletedMsg)
+				continue
+			}
+
+			// The deleted object is the old object of a deletion event
+			deletedSvc, castOk := psubMessage.OldObj.(*corev1.Service)
+			if !castOk {
+				log.Error().Msgf("Error casting to *corev1.Service, got type %T", psubMessage.OldObj)
+				continue
+			}
+			metricsstore.DefaultMetricsStore.InboundRulesPerPolicy.DeleteLabelValues(deletedSvc.Namespace, deletedSvc.Name)
+		}
+	}
+}
+
+// logInboundMeshHTTPRouteConfigsError logs the error returned while building the inbound HTTP route configs for the given
+// upstream identity
+func logInboundMeshHTTPRouteConfigsError(err error, upstreamIdentity identity.ServiceIdentity) {
+	log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrFetchingSMIHTTPRouteGroupForTrafficTarget)).
+		Msgf("Error building inbound HTTP route configs for upstream identity %s", upstreamIdentity)
+}
+
+// BuildInboundPolicyWithTrustDomains returns a map of the given inbound traffic policy per port for the given upstream identity
+// and services, with the downstream principals built for the given trust domains instead of the trust domains of the issuers
+// configured on the certificate manager. It is meant for unit testing and tooling that simulate trust domain changes.
+func (mc *MeshCatalog) BuildInboundPolicyWithTrustDomains(upstreamIdentity identity.ServiceIdentity, upstreamServices []service.MeshService,
+	trustDomains []string, spiffeEnabled bool) map[int][]*trafficpolicy.InboundTrafficPolicy {
+	principalInfos := make([]certificate.PrincipalInfo, 0, len(trustDomains))
+	for _, trustDomain := range trustDomains {
+		principalInfos = append(principalInfos, certificate.PrincipalInfo{TrustDomain: trustDomain, SpiffeEnabled: spiffeEnabled})
+	}
+
+	routeConfigPerPort, err := mc.getInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices, principalInfos)
+	if err != nil {
+		logInboundMeshHTTPRouteConfigsError(err, upstreamIdentity)
+	}
+	return routeConfigPerPort
+}
+
+// getInboundMeshHTTPRouteConfigsPerPort returns a map of the given inbound traffic policy per port for the given upstream
+// identity and services. Downstream principals are built for the given principal infos, or for the issuers configured on
+// the certificate manager if principalInfos is nil. The errors encountered while building the policies are returned along
+// with the policies that could be built.
+func (mc *MeshCatalog) getInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity identity.ServiceIdentity, upstreamServices []service.MeshService,
+	principalInfos []certificate.PrincipalInfo) (map[int][]*trafficpolicy.InboundTrafficPolicy, error) {
+	// Routes are only built for the services whose port is programmed with their protocol by the clusters and filter chains
+	upstreamServices = resolvePortProtocolConflicts(mc.withoutExcludedPorts(upstreamServices))
 	allUpstreamServices := mc.getUpstreamServicesIncludeApex(upstreamServices)
+	requestHeadersPerApex := mc.getRequestHeadersPerApexService(upstreamServices)
 
 	var trafficTargets []*access.TrafficTarget
+	var errs *multierror.Error
 	routeConfigPerPort := make(map[int][]*trafficpolicy.InboundTrafficPolicy)
 
-	permissiveMode := mc.GetMeshConfig().Spec.Traffic.EnablePermissiveTrafficPolicyMode
-	if !permissiveMode {
+	meshConfig := mc.GetMeshConfig()
+	permissiveMode := meshConfig.Spec.Traffic.EnablePermissiveTrafficPolicyMode
+	computePrincipalInfos := func() {
+		if principalInfos == nil {
+			principalInfos = mc.getIssuerPrincipalInfos()
+		}
+	}
+	smiPoliciesComputed := false
+	computeSMIPolicies := func() {
+		if smiPoliciesComputed {
+			return
+		}
+		smiPoliciesComputed = true
+
 		// Pre-computing the list of TrafficTarget optimizes to avoid repeated
 		// cache lookups for each upstream service.
 		destinationFilter := smi.WithTrafficTargetDestination(upstreamIdentity.ToK8sServiceAccount())
 		trafficTargets = mc.ListTrafficTargetsByOptions(destinationFilter)
+
+		computePrincipalInfos()
+	}
+	if !permissiveMode {
+		computeSMIPolicies()
+	} else if meshConfig.Spec.Traffic.EnablePermissiveTrafficTrustDomainPrincipals {
+		// Principals in permissive mode are scoped to the active trust domains
+		computePrincipalInfos()
 	}
 
 	// Build configurations per upstream service
 	for _, upstreamSvc := range allUpstreamServices {
 		upstreamSvc := upstreamSvc // To prevent loop variable memory aliasing in for loop
 
-		upstreamTrafficSetting := mc.GetUpstreamTrafficSettingByService(&upstreamSvc)
+		upstreamTrafficSetting := mc.getValidUpstreamTrafficSettingByService(upstreamSvc)
+		if upstreamTrafficSetting != nil {
+			// Other UpstreamTrafficSetting resources may configure rate limits for the same host
+			upstreamTrafficSetting = mc.withResolvedRateLimits(upstreamTrafficSetting)
+		}
 
 		// Build the HTTP route configs for this service and port combination.
 		// If the port's protocol corresponds to TCP, we can skip this step
-		if upstreamSvc.Protocol == constants.ProtocolTCP || upstreamSvc.Protocol == constants.ProtocolTCPServerFirst {
+		if upstreamSvc.Protocol == constants.ProtocolTCP || upstreamSvc.Protocol == constants.ProtocolTCPServerFirst ||
+			upstreamSvc.Protocol == constants.ProtocolTLSPassthrough {
 			continue
 		}
 		// ---
@@ -126,125 +497,534 @@ func (mc *MeshCatalog) GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity id
 		// The routes are derived from SMI TrafficTarget and TrafficSplit policies in SMI mode,
 		// and are wildcarded in permissive mode. The downstreams that can access this upstream
 		// on the configured routes is also determined based on the traffic policy mode.
-		inboundTrafficPolicies := mc.getInboundTrafficPoliciesForUpstream(upstreamSvc, permissiveMode, trafficTargets, upstreamTrafficSetting)
+		// A service can opt in to SMI enforcement using an UpstreamTrafficSetting even when permissive mode is enabled
+		svcPermissiveMode := permissiveMode && (upstreamTrafficSetting == nil || !upstreamTrafficSetting.Spec.EnforceSMI)
+		if !svcPermissiveMode {
+			computeSMIPolicies()
+		}
+		inboundTrafficPolicies, err := mc.getInboundTrafficPoliciesForUpstream(upstreamSvc, svcPermissiveMode, trafficTargets, principalInfos, upstreamTrafficSetting)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("error building inbound traffic policy for upstream service %s: %w", upstreamSvc, err))
+		}
+		inboundTrafficPolicies.Rules = append(inboundTrafficPolicies.Rules, mc.getProbePathRules(upstreamSvc, meshConfig.Spec.Traffic.InboundProbePaths)...)
+		if !svcPermissiveMode && meshConfig.Spec.Traffic.EnableSelfTraffic {
+			inboundTrafficPolicies.Rules = mc.getSelfTrafficRules(inboundTrafficPolicies.Rules, upstreamIdentity, upstreamSvc, principalInfos, upstreamTrafficSetting)
+		}
+		if requestHeaders, ok := requestHeadersPerApex[upstreamSvc]; ok {
+			// Requests to the apex service are routed to this backend, so the headers configured for the backend are added
+			setRequestHeadersToAdd(inboundTrafficPolicies.Rules, requestHeaders)
+		}
 		routeConfigPerPort[int(upstreamSvc.TargetPort)] = append(routeConfigPerPort[int(upstreamSvc.TargetPort)], inboundTrafficPolicies)
 	}
 
-	return routeConfigPerPort
+	// The wildcard virtual host must be ordered last so that it only applies when no specific host matches. Policies
+	// requiring an SNI are programmed on a route configuration per SNI, so each of them gets its own wildcard virtual host.
+	if meshConfig.Spec.Traffic.EnableInboundWildcardVirtualHost {
+		for port, policies := range routeConfigPerPort {
+			serverNames := mapset.NewSet()
+			for _, policy := range policies {
+				if added := serverNames.Add(policy.ServerName); added {
+					routeConfigPerPort[port] = append(routeConfigPerPort[port], getWildcardInboundTrafficPolicy(policy.ServerName))
+				}
+			}
+		}
+	}
+
+	return routeConfigPerPort, errs.ErrorOrNil()
+}
+
+// getProbePathRules returns the rules allowing unauthenticated access to the given probe paths on the upstream service
+func (mc *MeshCatalog) getProbePathRules(upstreamSvc service.MeshService, probePaths []string) []*trafficpolicy.Rule {
+	localCluster := service.WeightedCluster{
+		ClusterName: service.ClusterName(mc.localClusterName(upstreamSvc)),
+		Weight:      constants.ClusterWeightAcceptAll,
+	}
+
+	var rules []*trafficpolicy.Rule
+	for _, probePath := range probePaths {
+		probeRouteMatch := trafficpolicy.HTTPRouteMatch{
+			Path:          probePath,
+			PathMatchType: trafficpolicy.PathMatchExact,
+			Methods:       []string{constants.WildcardHTTPMethod},
+		}
+		rule := &trafficpolicy.Rule{
+			Route:             *trafficpolicy.NewRouteWeightedCluster(probeRouteMatch, []service.WeightedCluster{localCluster}, nil),
+			AllowedPrincipals: mapset.NewSetWith(identity.WildcardPrincipal),
+		}
+		rule.Route.StatPrefix = getRouteStatPrefix(upstreamSvc, rule.Route)
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// getSelfTrafficRules returns the given rules updated to allow the upstream identity to access its own service. The upstream
+// identity is allowed on each of the given rules, and on a wildcard route ordered last for paths not matched by any rule.
+func (mc *MeshCatalog) getSelfTrafficRules(rules []*trafficpolicy.Rule, upstreamIdentity identity.ServiceIdentity, upstreamSvc service.MeshService,
+	principalInfos []certificate.PrincipalInfo, upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) []*trafficpolicy.Rule {
+	selfPrincipals := mapset.NewSet()
+	for _, principalInfo := range principalInfos {
+		selfPrincipals.Add(upstreamIdentity.AsPrincipal(principalInfo.TrustDomain, principalInfo.SpiffeEnabled))
+	}
+
+	for _, rule := range rules {
+		if rule.AllowedPrincipals.Contains(identity.WildcardPrincipal) {
+			continue
+		}
+		// Principal sets may be shared between rules, so a new set is created instead of updating it in place
+		rule.AllowedPrincipals = rule.AllowedPrincipals.Union(selfPrincipals)
+	}
+
+	localCluster := service.WeightedCluster{
+		ClusterName: service.ClusterName(mc.localClusterName(upstreamSvc)),
+		Weight:      constants.ClusterWeightAcceptAll,
+	}
+	selfRule := &trafficpolicy.Rule{
+		Route:             *trafficpolicy.NewRouteWeightedCluster(trafficpolicy.WildCardRouteMatch, []service.WeightedCluster{localCluster}, upstreamTrafficSetting),
+		AllowedPrincipals: selfPrincipals,
+	}
+	selfRule.Route.StatPrefix = getRouteStatPrefix(upstreamSvc, selfRule.Route)
+
+	return trafficpolicy.MergeRules(rules, []*trafficpolicy.Rule{selfRule})
+}
+
+// getWildcardInboundTrafficPolicy returns a catch-all inbound traffic policy for the given SNI, for requests whose host
+// does not match any of the policies on the port. Such requests are answered with a 404 instead of being routed to a
+// service, so that they are not handled using the rules, principals and clusters of an unrelated service.
+func getWildcardInboundTrafficPolicy(serverName string) *trafficpolicy.InboundTrafficPolicy {
+	return &trafficpolicy.InboundTrafficPolicy{
+		Name:       constants.WildcardHostname,
+		Hostnames:  []string{constants.WildcardHostname},
+		ServerName: serverName,
+		Rules: []*trafficpolicy.Rule{
+			{
+				Route: trafficpolicy.RouteWeightedClusters{
+					HTTPRouteMatch:   trafficpolicy.WildCardRouteMatch,
+					WeightedClusters: mapset.NewSet(),
+					DirectResponse:   &policyv1alpha1.DirectResponseSpec{StatusCode: http.StatusNotFound},
+				},
+				// The route never reaches a cluster, so any downstream client is answered with the direct response
+				AllowedPrincipals: mapset.NewSetWith(identity.WildcardPrincipal),
+			},
+		},
+	}
 }
 
 func (mc *MeshCatalog) getInboundTrafficPoliciesForUpstream(upstreamSvc service.MeshService, permissiveMode bool,
-	trafficTargets []*access.TrafficTarget, upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) *trafficpolicy.InboundTrafficPolicy {
+	trafficTargets []*access.TrafficTarget, principalInfos []certificate.PrincipalInfo,
+	upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) (*trafficpolicy.InboundTrafficPolicy, error) {
 	var inboundPolicyForUpstreamSvc *trafficpolicy.InboundTrafficPolicy
+	var err error
+	trafficSpec := mc.GetMeshConfig().Spec.Traffic
 
 	if permissiveMode {
 		// Add a wildcard HTTP route that allows any downstream client to access the upstream service
 		hostnames := mc.GetHostnamesForService(upstreamSvc, true /* local namespace FQDN should always be allowed for inbound routes*/)
 		inboundPolicyForUpstreamSvc = trafficpolicy.NewInboundTrafficPolicy(upstreamSvc.FQDN(), hostnames, upstreamTrafficSetting)
-		localCluster := service.WeightedCluster{
-			ClusterName: service.ClusterName(upstreamSvc.EnvoyLocalClusterName()),
-			Weight:      constants.ClusterWeightAcceptAll,
+		localClusters := mc.getLocalWeightedClusters(upstreamSvc, upstreamTrafficSetting)
+		allowedPrincipals := mapset.NewSetWith(identity.WildcardPrincipal)
+		if trafficSpec.EnablePermissiveTrafficTrustDomainPrincipals {
+			// Only allow the downstream clients in the active trust domains
+			allowedPrincipals = mapset.NewSet()
+			for _, principalInfo := range principalInfos {
+				allowedPrincipals.Add(identity.TrustDomainPrincipal(principalInfo.TrustDomain, principalInfo.SpiffeEnabled))
+			}
 		}
 		// Only a single rule for permissive mode.
 		inboundPolicyForUpstreamSvc.Rules = []*trafficpolicy.Rule{
 			{
-				Route:             *trafficpolicy.NewRouteWeightedCluster(trafficpolicy.WildCardRouteMatch, []service.WeightedCluster{localCluster}, upstreamTrafficSetting),
-				AllowedPrincipals: mapset.NewSetWith(identity.WildcardPrincipal),
+				Route:             *trafficpolicy.NewRouteWeightedCluster(trafficpolicy.WildCardRouteMatch, localClusters, upstreamTrafficSetting),
+				AllowedPrincipals: allowedPrincipals,
 			},
 		}
 	} else {
 		// Build the HTTP routes from SMI TrafficTarget and HTTPRouteGroup configurations
-		inboundPolicyForUpstreamSvc = mc.buildInboundHTTPPolicyFromTrafficTarget(upstreamSvc, trafficTargets, upstreamTrafficSetting)
+		inboundPolicyForUpstreamSvc, err = mc.buildInboundHTTPPolicyFromTrafficTarget(upstreamSvc, trafficTargets, principalInfos, upstreamTrafficSetting,
+			trafficSpec.EnableInboundCORSPreflight)
+	}
+
+	runtimeKeyPrefix := getWeightedClusterRuntimeKeyPrefix(trafficSpec.WeightedClusterRuntimeKeyPrefix, upstreamSvc)
+	allowedSourceRanges := getAllowedSourceRanges(upstreamTrafficSetting)
+	for _, rule := range inboundPolicyForUpstreamSvc.Rules {
+		rule.Route.StatPrefix = getRouteStatPrefix(upstreamSvc, rule.Route)
+		rule.Route.RuntimeKeyPrefix = runtimeKeyPrefix
+		rule.AllowedSourceRanges = allowedSourceRanges
 	}
 
-	return inboundPolicyForUpstreamSvc
+	return inboundPolicyForUpstreamSvc, err
+}
+
+// resolveRateLimitForHost returns the effective virtual host rate limit, and the effective per route rate limits keyed by
+// route path, for the given host. They are resolved from all the valid UpstreamTrafficSetting resources targeting the host
+// using the following precedence rule: the oldest resource by creation timestamp wins, with ties broken by the namespace
+// and then the name of the resources in lexical order. The virtual host rate limit and the rate limit of each route path
+// are resolved independently, so a newer resource can configure the rate limit of a path older resources do not configure.
+func (mc *MeshCatalog) resolveRateLimitForHost(host string) (*policyv1alpha1.RateLimitSpec, map[string]*policyv1alpha1.HTTPPerRouteRateLimitSpec) {
+	var upstreamTrafficSettings []*policyv1alpha1.UpstreamTrafficSetting
+	for _, upstreamTrafficSetting := range mc.ListUpstreamTrafficSettings() {
+		if upstreamTrafficSetting.Spec.Host != host || len(ValidateUpstreamTrafficSetting(upstreamTrafficSetting)) > 0 {
+			continue
+		}
+		upstreamTrafficSettings = append(upstreamTrafficSettings, upstreamTrafficSetting)
+	}
+
+	sort.Slice(upstreamTrafficSettings, func(i, j int) bool {
+		x, y := upstreamTrafficSettings[i], upstreamTrafficSettings[j]
+		if !x.CreationTimestamp.Equal(&y.CreationTimestamp) {
+			return x.CreationTimestamp.Before(&y.CreationTimestamp)
+		}
+		if x.Namespace != y.Namespace {
+			return x.Namespace < y.Namespace
+		}
+		return x.Name < y.Name
+	})
+
+	var rateLimit *policyv1alpha1.RateLimitSpec
+	routeRateLimits := make(map[string]*policyv1alpha1.HTTPPerRouteRateLimitSpec)
+	for _, upstreamTrafficSetting := range upstreamTrafficSettings {
+		if rateLimit == nil {
+			rateLimit = upstreamTrafficSetting.Spec.RateLimit
+		}
+		for _, route := range upstreamTrafficSetting.Spec.HTTPRoutes {
+			if _, ok := routeRateLimits[route.Path]; !ok && route.RateLimit != nil {
+				routeRateLimits[route.Path] = route.RateLimit
+			}
+		}
+	}
+
+	return rateLimit, routeRateLimits
+}
+
+// withResolvedRateLimits returns a copy of the given UpstreamTrafficSetting with its virtual host and per route rate
+// limits replaced by the effective rate limits for its host, resolved by resolveRateLimitForHost
+func (mc *MeshCatalog) withResolvedRateLimits(upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) *policyv1alpha1.UpstreamTrafficSetting {
+	rateLimit, routeRateLimits := mc.resolveRateLimitForHost(upstreamTrafficSetting.Spec.Host)
+
+	resolved := upstreamTrafficSetting.DeepCopy()
+	resolved.Spec.RateLimit = rateLimit
+	for i := range resolved.Spec.HTTPRoutes {
+		route := &resolved.Spec.HTTPRoutes[i]
+		route.RateLimit = routeRateLimits[route.Path]
+		delete(routeRateLimits, route.Path)
+	}
+
+	// Rate limits of paths only configured by other resources are added as routes of their own
+	paths := make([]string, 0, len(routeRateLimits))
+	for path := range routeRateLimits {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		resolved.Spec.HTTPRoutes = append(resolved.Spec.HTTPRoutes, policyv1alpha1.HTTPRouteSpec{Path: path, RateLimit: routeRateLimits[path]})
+	}
+
+	return resolved
+}
+
+// getLocalWeightedClusters returns the weighted clusters routing to the local cluster of the given upstream service. The
+// traffic is split across the endpoint subsets configured by the given UpstreamTrafficSetting, if any.
+func (mc *MeshCatalog) getLocalWeightedClusters(upstreamSvc service.MeshService, upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) []service.WeightedCluster {
+	clusterName := service.ClusterName(mc.localClusterName(upstreamSvc))
+
+	var localClusters []service.WeightedCluster
+	if upstreamTrafficSetting != nil {
+		for _, subset := range upstreamTrafficSetting.Spec.EndpointSubsets {
+			if subset.Weight == 0 {
+				// A weighted cluster with a weight of 0 is rejected by Envoy
+				continue
+			}
+			localClusters = append(localClusters, service.WeightedCluster{
+				ClusterName:   clusterName,
+				Weight:        subset.Weight,
+				LabelSelector: service.NewLabelSelector(subset.Labels),
+			})
+		}
+	}
+	if len(localClusters) == 0 {
+		localClusters = append(localClusters, service.WeightedCluster{
+			ClusterName: clusterName,
+			Weight:      constants.ClusterWeightAcceptAll,
+		})
+	}
+
+	return localClusters
+}
+
+// getEndpointSubsetSelectors returns the distinct label selectors of the endpoint subsets configured by the given
+// UpstreamTrafficSetting
+func getEndpointSubsetSelectors(upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) []service.LabelSelector {
+	if upstreamTrafficSetting == nil {
+		return nil
+	}
+
+	selectorSet := mapset.NewSet()
+	var selectors []service.LabelSelector
+	for _, subset := range upstreamTrafficSetting.Spec.EndpointSubsets {
+		selector := service.NewLabelSelector(subset.Labels)
+		if newlyAdded := selectorSet.Add(selector); newlyAdded {
+			selectors = append(selectors, selector)
+		}
+	}
+	return selectors
 }
 
 func (mc *MeshCatalog) buildInboundHTTPPolicyFromTrafficTarget(upstreamSvc service.MeshService, trafficTargets []*access.TrafficTarget,
-	upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) *trafficpolicy.InboundTrafficPolicy {
+	principalInfos []certificate.PrincipalInfo, upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting,
+	allowCORSPreflight bool) (*trafficpolicy.InboundTrafficPolicy, error) {
 	hostnames := mc.GetHostnamesForService(upstreamSvc, true /* local namespace FQDN should always be allowed for inbound routes*/)
 	inboundPolicy := trafficpolicy.NewInboundTrafficPolicy(upstreamSvc.FQDN(), hostnames, upstreamTrafficSetting)
 
-	localCluster := service.WeightedCluster{
-		ClusterName: service.ClusterName(upstreamSvc.EnvoyLocalClusterName()),
-		Weight:      constants.ClusterWeightAcceptAll,
+	// Every route is routed to the local cluster, so a single set is shared by the rules instead of allocating a set per rule
+	localClusters := mapset.NewSet()
+	for _, localCluster := range mc.getLocalWeightedClusters(upstreamSvc, upstreamTrafficSetting) {
+		localClusters.Add(localCluster)
 	}
 
 	var routingRules []*trafficpolicy.Rule
+	var errs *multierror.Error
 	// From each TrafficTarget and HTTPRouteGroup configuration associated with this service, build routes for it.
 	for _, trafficTarget := range trafficTargets {
-		rules := mc.getRoutingRulesFromTrafficTarget(*trafficTarget, localCluster, upstreamTrafficSetting)
-		// Multiple TrafficTarget objects can reference the same route, in which case such routes
-		// need to be merged to create a single route that includes all the downstream client identities
-		// this route is authorized for.
+		rules, err := mc.getRoutingRulesFromTrafficTarget(*trafficTarget, upstreamSvc.Protocol, localClusters, principalInfos, upstreamTrafficSetting, allowCORSPreflight)
+		if err != nil {
+			// The rules built from the routes that could be found are still applied
+			errs = multierror.Append(errs, err)
+		}
+		// Multiple TrafficTarget objects can reference the same route, or different HTTPRouteGroup matches
+		// resulting in identical routes, in which case such routes need to be merged to create a single route
+		// that includes all the downstream client identities this route is authorized for.
 		routingRules = trafficpolicy.MergeRules(routingRules, rules)
 	}
 	inboundPolicy.Rules = routingRules
 
-	return inboundPolicy
+	return inboundPolicy, errs.ErrorOrNil()
 }
 
-func (mc *MeshCatalog) getRoutingRulesFromTrafficTarget(trafficTarget access.TrafficTarget, routingCluster service.WeightedCluster,
-	upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) []*trafficpolicy.Rule {
-	// Compute the HTTP route matches associated with the given TrafficTarget object
-	httpRouteMatches, err := mc.routesFromRules(trafficTarget.Spec.Rules, trafficTarget.Namespace)
+func (mc *MeshCatalog) getRoutingRulesFromTrafficTarget(trafficTarget access.TrafficTarget, protocol string, routingClusters mapset.Set,
+	principalInfos []certificate.PrincipalInfo, upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting,
+	allowCORSPreflight bool) ([]*trafficpolicy.Rule, error) {
+	// Compute the HTTP route matches associated with the given TrafficTarget object. The route matches that could be found
+	// are returned along with the error.
+	httpRouteMatches, err := mc.routesFromRules(trafficTarget.Spec.Rules, trafficTarget.Namespace, protocol)
 	if err != nil {
-		log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrFetchingSMIHTTPRouteGroupForTrafficTarget)).
-			Msgf("Error finding route matches from TrafficTarget %s in namespace %s", trafficTarget.Name, trafficTarget.Namespace)
-		return nil
+		err = fmt.Errorf("error finding route matches from TrafficTarget %s/%s: %w", trafficTarget.Namespace, trafficTarget.Name, err)
 	}
 
 	// Compute the allowed downstream service identities for the given TrafficTarget object
-	issuers := mc.certManager.GetIssuersInfo()
 	allowedDownstreamPrincipals := mapset.NewSet()
 	for _, source := range trafficTarget.Spec.Sources {
-		allowedDownstreamPrincipals.Add(trafficTargetIdentityToSvcAccount(source).AsPrincipal(issuers.Signing.TrustDomain, issuers.Signing.SpiffeEnabled))
-
-		if issuers.AreDifferent() {
-			allowedDownstreamPrincipals.Add(trafficTargetIdentityToSvcAccount(source).AsPrincipal(issuers.Validating.TrustDomain, issuers.Validating.SpiffeEnabled))
+		for _, principalInfo := range principalInfos {
+			if source.Namespace == identity.WildcardNamespace {
+				// The service account is allowed in all namespaces, its name is still matched exactly
+				allowedDownstreamPrincipals.Add(identity.WildcardNamespacePrincipal(source.Name, principalInfo.TrustDomain, principalInfo.SpiffeEnabled))
+				continue
+			}
+			allowedDownstreamPrincipals.Add(trafficTargetIdentityToSvcAccount(source).AsPrincipal(principalInfo.TrustDomain, principalInfo.SpiffeEnabled))
 		}
 	}
 
+	deniedDownstreamPrincipals := getDeniedPrincipals(upstreamTrafficSetting, principalInfos)
+	requireRouteHeaders := isRouteHeadersRequired(trafficTarget)
+
 	var routingRules []*trafficpolicy.Rule
 	for _, httpRouteMatch := range httpRouteMatches {
+		if allowCORSPreflight {
+			httpRouteMatch = withCORSPreflightMethod(httpRouteMatch)
+		}
 		rule := &trafficpolicy.Rule{
-			Route:             *trafficpolicy.NewRouteWeightedCluster(httpRouteMatch, []service.WeightedCluster{routingCluster}, upstreamTrafficSetting),
+			Route:             *trafficpolicy.NewRouteWeightedClusterWithSet(httpRouteMatch, routingClusters, upstreamTrafficSetting),
 			AllowedPrincipals: allowedDownstreamPrincipals,
+			DeniedPrincipals:  deniedDownstreamPrincipals,
+		}
+		if requireRouteHeaders && len(httpRouteMatch.Headers) > 0 {
+			rule.RequiredHeaders = httpRouteMatch.Headers
 		}
 		routingRules = append(routingRules, rule)
 	}
 
-	return routingRules
+	return routingRules, err
+}
+
+// getDeniedPrincipals returns the principals of the service accounts denied access by the given UpstreamTrafficSetting,
+// or nil if it does not deny any service account
+func getDeniedPrincipals(upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting, principalInfos []certificate.PrincipalInfo) mapset.Set {
+	if upstreamTrafficSetting == nil || len(upstreamTrafficSetting.Spec.DeniedServiceAccounts) == 0 {
+		return nil
+	}
+
+	deniedPrincipals := mapset.NewSet()
+	for _, deniedSvcAccount := range upstreamTrafficSetting.Spec.DeniedServiceAccounts {
+		svcAccount := identity.K8sServiceAccount{Name: deniedSvcAccount.Name, Namespace: deniedSvcAccount.Namespace}
+		for _, principalInfo := range principalInfos {
+			deniedPrincipals.Add(svcAccount.AsPrincipal(principalInfo.TrustDomain, principalInfo.SpiffeEnabled))
+		}
+	}
+	return deniedPrincipals
+}
+
+// getValidUpstreamTrafficSettingByService returns the UpstreamTrafficSetting for the given upstream service, or nil if
+// it fails validation. An invalid UpstreamTrafficSetting, such as one with a malformed allowed source range, would result
+// in a configuration rejected by the proxy, so it is ignored.
+func (mc *MeshCatalog) getValidUpstreamTrafficSettingByService(upstreamSvc service.MeshService) *policyv1alpha1.UpstreamTrafficSetting {
+	upstreamTrafficSetting := mc.GetUpstreamTrafficSettingByService(&upstreamSvc)
+	validationErrs := ValidateUpstreamTrafficSetting(upstreamTrafficSetting)
+	if len(validationErrs) == 0 {
+		return upstreamTrafficSetting
+	}
+	for _, err := range validationErrs {
+		log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrInvalidUpstreamTrafficSetting)).
+			Msgf("Ignoring invalid UpstreamTrafficSetting %s/%s for upstream service %s",
+				upstreamTrafficSetting.Namespace, upstreamTrafficSetting.Name, upstreamSvc)
+	}
+	return nil
+}
+
+// getAllowedSourceRanges returns the IP address ranges downstream clients must connect from to access the upstream host
+// of the given UpstreamTrafficSetting, or nil if the source addresses are not restricted
+func getAllowedSourceRanges(upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) []string {
+	if upstreamTrafficSetting == nil || len(upstreamTrafficSetting.Spec.AllowedSourceRanges) == 0 {
+		return nil
+	}
+	return upstreamTrafficSetting.Spec.AllowedSourceRanges
+}
+
+// withCORSPreflightMethod returns the given HTTP route match with the OPTIONS method added to its methods, so that
+// CORS preflight requests are allowed. A route match matching all methods or already matching OPTIONS is returned as is.
+func withCORSPreflightMethod(match trafficpolicy.HTTPRouteMatch) trafficpolicy.HTTPRouteMatch {
+	for _, method := range match.Methods {
+		if method == constants.WildcardHTTPMethod || strings.EqualFold(method, constants.HTTPMethodOptions) {
+			return match
+		}
+	}
+	// Copy the methods to avoid modifying the route match shared with other TrafficTargets
+	match.Methods = append(append([]string(nil), match.Methods...), constants.HTTPMethodOptions)
+	return match
+}
+
+// isRouteHeadersRequired returns whether the headers of the HTTPRouteGroup matches referenced by the given
+// TrafficTarget must be enforced by RBAC, as opted into using the TrafficTargetRequireRouteHeadersAnnotation
+func isRouteHeadersRequired(trafficTarget access.TrafficTarget) bool {
+	value, ok := trafficTarget.Annotations[constants.TrafficTargetRequireRouteHeadersAnnotation]
+	if !ok {
+		return false
+	}
+	required, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Warn().Msgf("Ignoring invalid value %q for annotation %s on TrafficTarget %s/%s",
+			value, constants.TrafficTargetRequireRouteHeadersAnnotation, trafficTarget.Namespace, trafficTarget.Name)
+		return false
+	}
+	return required
+}
+
+// getIssuerPrincipalInfos returns the principal info of the signing issuer, and that of the validating issuer if it differs
+func (mc *MeshCatalog) getIssuerPrincipalInfos() []certificate.PrincipalInfo {
+	issuers := mc.certManager.GetIssuersInfo()
+	principalInfos := []certificate.PrincipalInfo{issuers.Signing}
+	if issuers.AreDifferent() {
+		principalInfos = append(principalInfos, issuers.Validating)
+	}
+	return principalInfos
 }
 
-// routesFromRules takes a set of traffic target rules and the namespace of the traffic target and returns a list of
-// http route matches (trafficpolicy.HTTPRouteMatch)
-func (mc *MeshCatalog) routesFromRules(rules []access.TrafficTargetRule, trafficTargetNamespace string) ([]trafficpolicy.HTTPRouteMatch, error) {
+// routesFromRules takes a set of traffic target rules, the namespace of the traffic target and the protocol of the
+// upstream service, and returns a list of distinct http route matches (trafficpolicy.HTTPRouteMatch). A rule without
+// matches yields all the matches of its HTTPRouteGroup. For gRPC services, the route matches only match gRPC requests,
+// and their paths are interpreted as gRPC method paths. An error is returned along with the route matches that could
+// be found if the rules reference HTTPRouteGroups or matches that do not exist.
+func (mc *MeshCatalog) routesFromRules(rules []access.TrafficTargetRule, trafficTargetNamespace string, protocol string) ([]trafficpolicy.HTTPRouteMatch, error) {
 	var routes []trafficpolicy.HTTPRouteMatch
+	var errs *multierror.Error
 
 	specMatchRoute, err := mc.getHTTPPathsPerRoute() // returns map[traffic_spec_name]map[match_name]trafficpolicy.HTTPRoute
 	if err != nil {
 		return nil, err
 	}
 
-	if len(specMatchRoute) == 0 {
-		log.Trace().Msg("No elements in map[traffic_spec_name]map[match name]trafficpolicyHTTPRoute")
-		return routes, nil
-	}
+	// Rules may reference the same match more than once, or matches resulting in identical route matches
+	routeHashes := make(map[uint64]struct{})
 
 	for _, rule := range rules {
+		kind, ok := smi.ParseRouteKind(rule.Kind)
+		if !ok {
+			log.Warn().Msgf("Skipping rule %s with unknown kind %s in TrafficTarget (in namespace %s)", rule.Name, rule.Kind, trafficTargetNamespace)
+			continue
+		}
+		// A TrafficTarget may mix HTTPRouteGroup and TCPRoute rules, only HTTPRouteGroup rules yield HTTP routes
+		if kind != smi.HTTPRouteGroupKind {
+			continue
+		}
 		trafficSpecName := getTrafficSpecName(smi.HTTPRouteGroupKind, trafficTargetNamespace, rule.Name)
-		for _, match := range rule.Matches {
-			matchedRoute, found := specMatchRoute[trafficSpecName][trafficpolicy.TrafficSpecMatchName(match)]
-			if found {
-				routes = append(routes, matchedRoute)
-			} else {
-				log.Debug().Msgf("No matching trafficpolicy.HTTPRoute found for match name %s in Traffic Spec %s (in namespace %s)", match, trafficSpecName, trafficTargetNamespace)
+		matchRoutes, found := specMatchRoute[trafficSpecName]
+		if !found {
+			errs = multierror.Append(errs, fmt.Errorf("%w: %s/%s", errHTTPRouteGroupNotFound, trafficTargetNamespace, rule.Name))
+			continue
+		}
+		// A rule without matches allows all the matches of the HTTPRouteGroup, as specified by SMI
+		matches := rule.Matches
+		if len(matches) == 0 {
+			matches = mc.getHTTPRouteGroupMatchNames(trafficTargetNamespace, rule.Name)
+		}
+		// Match names are only looked up in the HTTPRouteGroup referenced by the rule, other HTTPRouteGroups
+		// may define matches with the same name
+		for _, match := range matches {
+			matchedRoute, found := matchRoutes[trafficpolicy.TrafficSpecMatchName(match)]
+			if !found {
+				errs = multierror.Append(errs, fmt.Errorf("%w: match %s in %s/%s", errHTTPRouteGroupMatchNotFound, match, trafficTargetNamespace, rule.Name))
+				continue
+			}
+			if protocol == constants.ProtocolGRPC {
+				matchedRoute = getGRPCRouteMatch(matchedRoute)
+			}
+			// The order of the methods of a route match does not make it distinct
+			if hash, err := hashstructure.Hash(matchedRoute, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true}); err == nil {
+				if _, duplicate := routeHashes[hash]; duplicate {
+					continue
+				}
+				routeHashes[hash] = struct{}{}
 			}
+			routes = append(routes, matchedRoute)
 		}
 	}
 
-	return routes, nil
+	return routes, errs.ErrorOrNil()
+}
+
+// getHTTPRouteGroupMatchNames returns the names of the matches of the given HTTPRouteGroup, in the order they are defined
+func (mc *MeshCatalog) getHTTPRouteGroupMatchNames(namespace string, name string) []string {
+	var matchNames []string
+	for _, routeGroup := range mc.ListHTTPTrafficSpecs() {
+		if routeGroup.Namespace != namespace || routeGroup.Name != name {
+			continue
+		}
+		for _, match := range routeGroup.Spec.Matches {
+			matchNames = append(matchNames, match.Name)
+		}
+	}
+	return matchNames
+}
+
+var (
+	// grpcMethodPathRegex matches the path of a gRPC method, of the form /<package>.<Service>/<Method>
+	grpcMethodPathRegex = regexp.MustCompile(`^/[A-Za-z_][A-Za-z0-9_.]*/[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// grpcServicePathRegex matches the path regex of all the methods of a gRPC service, of the form /<package>.<Service>/.*
+	grpcServicePathRegex = regexp.MustCompile(`^/[A-Za-z_][A-Za-z0-9_.]*/\.\*$`)
+)
+
+// getGRPCRouteMatch returns the given HTTP route match as a route match only matching gRPC requests. A path of
+// the form /<package>.<Service>/<Method> is matched exactly as a gRPC method, and a path of the form
+// /<package>.<Service>/.* is matched as a prefix for all the methods of the gRPC service. Other paths are
+// matched as they are specified.
+func getGRPCRouteMatch(match trafficpolicy.HTTPRouteMatch) trafficpolicy.HTTPRouteMatch {
+	match.GRPC = true
+	switch {
+	case grpcMethodPathRegex.MatchString(match.Path):
+		match.PathMatchType = trafficpolicy.PathMatchExact
+	case grpcServicePathRegex.MatchString(match.Path):
+		match.Path = strings.TrimSuffix(match.Path, constants.RegexMatchAll)
+		match.PathMatchType = trafficpolicy.PathMatchPrefix
+	}
+	return match
 }
 
 func (mc *MeshCatalog) getHTTPPathsPerRoute() (map[trafficpolicy.TrafficSpecName]map[trafficpolicy.TrafficSpecMatchName]trafficpolicy.HTTPRouteMatch, error) {
@@ -264,13 +1044,21 @@ func (mc *MeshCatalog) getHTTPPathsPerRoute() (map[trafficpolicy.TrafficSpecName
 			serviceRoute := trafficpolicy.HTTPRouteMatch{
 				Path:          trafficSpecsMatches.PathRegex,
 				PathMatchType: trafficpolicy.PathMatchRegex,
-				Methods:       trafficSpecsMatches.Methods,
+				Methods:       normalizeHTTPMethods(trafficSpecsMatches.Methods),
 				Headers:       trafficSpecsMatches.Headers,
 			}
 
+			// SMI HTTPRouteGroup header values are regexes
+			if len(serviceRoute.Headers) > 0 {
+				serviceRoute.HeaderMatchType = trafficpolicy.HeaderMatchRegex
+			}
+
 			// When pathRegex or/and methods are not defined, they will be wildcarded
 			if serviceRoute.Path == "" {
 				serviceRoute.Path = constants.RegexMatchAll
+			} else if isLiteralPath(serviceRoute.Path) {
+				// A pathRegex without regex metacharacters is matched exactly, avoiding regex evaluation
+				serviceRoute.PathMatchType = trafficpolicy.PathMatchExact
 			}
 			if len(serviceRoute.Methods) == 0 {
 				serviceRoute.Methods = []string{constants.WildcardHTTPMethod}
@@ -282,11 +1070,75 @@ func (mc *MeshCatalog) getHTTPPathsPerRoute() (map[trafficpolicy.TrafficSpecName
 	return routePolicies, nil
 }
 
+// normalizeHTTPMethods returns a copy of the given HTTP methods in uppercase, since Envoy matches the :method
+// header case-sensitively and HTTP methods are uppercase by convention. The wildcard method is preserved.
+func normalizeHTTPMethods(methods []string) []string {
+	if len(methods) == 0 {
+		return nil
+	}
+	normalized := make([]string, 0, len(methods))
+	for _, method := range methods {
+		if method == constants.WildcardHTTPMethod {
+			normalized = append(normalized, method)
+			continue
+		}
+		normalized = append(normalized, strings.ToUpper(method))
+	}
+	return normalized
+}
+
+// isLiteralPath returns true if the given path regex has no regex metacharacters, and thus only matches itself
+func isLiteralPath(pathRegex string) bool {
+	return regexp.QuoteMeta(pathRegex) == pathRegex
+}
+
 func getTrafficSpecName(trafficSpecKind string, trafficSpecNamespace string, trafficSpecName string) trafficpolicy.TrafficSpecName {
 	specKey := fmt.Sprintf("%s/%s/%s", trafficSpecKind, trafficSpecNamespace, trafficSpecName)
 	return trafficpolicy.TrafficSpecName(specKey)
 }
 
+// getRouteStatPrefix returns the stat prefix for the given route on the upstream service. The prefix encodes the
+// service, a route name derived from the route's match, and whether the route is rate limited, in the form
+// <namespace>_<name>_<port>.route_<hash>.rate_limited_<true|false>, so that per route stats carry these labels.
+// The prefix is deterministic for a given service and route match.
+func getRouteStatPrefix(upstreamSvc service.MeshService, route trafficpolicy.RouteWeightedClusters) string {
+	return fmt.Sprintf("%s_%s_%d.route_%s.rate_limited_%t", upstreamSvc.Namespace, upstreamSvc.Name, upstreamSvc.Port,
+		getRouteMatchName(route.HTTPRouteMatch), route.RateLimit != nil)
+}
+
+// getWeightedClusterRuntimeKeyPrefix returns the prefix of the runtime keys overriding the weights of the weighted
+// clusters on the routes of the given service, or an empty string if runtime keys are not configured
+func getWeightedClusterRuntimeKeyPrefix(prefix string, svc service.MeshService) string {
+	if prefix == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s.%s", prefix, svc.FQDN())
+}
+
+// getRouteMatchName returns a stable name for the given HTTP route match, computed as a hash of the
+// match attributes in a canonical order
+func getRouteMatchName(match trafficpolicy.HTTPRouteMatch) string {
+	methods := append([]string(nil), match.Methods...)
+	sort.Strings(methods)
+
+	headers := make([]string, 0, len(match.Headers))
+	for k, v := range match.Headers {
+		headers = append(headers, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(headers)
+
+	key := fmt.Sprintf("%d|%s|%s|%s", match.PathMatchType, match.Path, strings.Join(methods, ","), strings.Join(headers, ","))
+	if match.Authority != "" {
+		// Only appended when set so that the names of routes without an authority are unchanged
+		key += "|" + match.Authority
+	}
+
+	h := fnv.New32a()
+	// Writes to a hash.Hash never return an error
+	_, _ = h.Write([]byte(key))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
 // getUpstreamServicesIncludeApex returns a list of all upstream services associated with the given list
 // of services. An upstream service is associated with another service if it is a backend for an apex/root service
 // in a TrafficSplit config. This function returns a list consisting of the given upstream services and all apex
@@ -303,8 +1155,10 @@ func (mc *MeshCatalog) getUpstreamServicesIncludeApex(upstreamServices []service
 		}
 
 		for _, split := range mc.ListTrafficSplitsByOptions(smi.WithTrafficSplitBackendService(svc)) {
+			// The apex service is in the namespace of the TrafficSplit, which may differ from the backend's
+			// namespace when the backend is referenced in the form "name.namespace"
 			apexMeshService := service.MeshService{
-				Namespace:  svc.Namespace,
+				Namespace:  split.Namespace,
 				Name:       split.Spec.Service,
 				Port:       svc.Port,
 				TargetPort: svc.TargetPort,
@@ -320,6 +1174,158 @@ func (mc *MeshCatalog) getUpstreamServicesIncludeApex(upstreamServices []service
 	return allServices
 }
 
+// getRequestHeadersPerApexService returns the request headers to add to the requests routed to the given upstream services
+// through the apex services of the TrafficSplit resources they are backends of, per apex service. Apex services without
+// request headers configured for the given upstream services are omitted.
+func (mc *MeshCatalog) getRequestHeadersPerApexService(upstreamServices []service.MeshService) map[service.MeshService]service.RequestHeaders {
+	requestHeadersPerApex := make(map[service.MeshService]service.RequestHeaders)
+	for _, svc := range upstreamServices {
+		for _, split := range mc.ListTrafficSplitsByOptions(smi.WithTrafficSplitBackendService(svc)) {
+			requestHeaders := getBackendRequestHeaders(split, getSplitBackendReference(split, svc))
+			if requestHeaders == "" {
+				continue
+			}
+			apexMeshService := service.MeshService{
+				Namespace:  split.Namespace,
+				Name:       split.Spec.Service,
+				Port:       svc.Port,
+				TargetPort: svc.TargetPort,
+				Protocol:   svc.Protocol,
+			}
+			requestHeadersPerApex[apexMeshService] = requestHeaders
+		}
+	}
+	return requestHeadersPerApex
+}
+
+// getSplitBackendReference returns the backend of the given TrafficSplit referencing the given service, as specified on
+// the TrafficSplit, ex. "name" for a service in the namespace of the TrafficSplit or "name.namespace" otherwise
+func getSplitBackendReference(split *smiSplit.TrafficSplit, svc service.MeshService) string {
+	for _, backend := range split.Spec.Backends {
+		if name, namespace := smi.GetTrafficSplitBackendService(split, backend.Service); name == svc.Name && namespace == svc.Namespace {
+			return backend.Service
+		}
+	}
+	return svc.Name
+}
+
+// getBackendRequestHeaders returns the request headers to add to the requests routed to the given backend of the
+// TrafficSplit, as configured by the TrafficSplit annotation for the backend. Invalid headers are ignored.
+func getBackendRequestHeaders(split *smiSplit.TrafficSplit, backend string) service.RequestHeaders {
+	annotation := fmt.Sprintf("%s/%s", constants.TrafficSplitBackendRequestHeadersAnnotationPrefix, backend)
+	value, ok := split.Annotations[annotation]
+	if !ok {
+		return ""
+	}
+
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		name, headerValue, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || name == "" {
+			log.Warn().Msgf("Ignoring invalid request header %q in annotation %s on TrafficSplit %s/%s, expected <name>=<value>",
+				pair, annotation, split.Namespace, split.Name)
+			continue
+		}
+		headers[name] = headerValue
+	}
+	return service.NewRequestHeaders(headers)
+}
+
+// setRequestHeadersToAdd sets the given request headers to add on the weighted clusters of the given rules
+func setRequestHeadersToAdd(rules []*trafficpolicy.Rule, requestHeaders service.RequestHeaders) {
+	for _, rule := range rules {
+		// Weighted cluster sets may be shared between rules, so a new set is created instead of updating it in place
+		weightedClusters := mapset.NewSet()
+		for clusterInterface := range rule.Route.WeightedClusters.Iter() {
+			cluster := clusterInterface.(service.WeightedCluster)
+			cluster.RequestHeadersToAdd = requestHeaders
+			weightedClusters.Add(cluster)
+		}
+		rule.Route.WeightedClusters = weightedClusters
+	}
+}
+
+// portProtocolPrecedence is the order in which protocols are preferred when upstream services
+// declare conflicting protocols for the same target port. TLS passthrough is preferred last among the
+// known protocols since its connections are neither terminated nor authenticated by the proxy.
+var portProtocolPrecedence = []string{constants.ProtocolHTTP, constants.ProtocolGRPC, constants.ProtocolTCP, constants.ProtocolTCPServerFirst,
+	constants.ProtocolTLSPassthrough}
+
+// getPortProtocolRank returns the rank of the given protocol in portProtocolPrecedence, where a lower rank
+// is preferred. Protocols not in portProtocolPrecedence are ranked last.
+func getPortProtocolRank(protocol string) int {
+	for i, p := range portProtocolPrecedence {
+		if p == protocol {
+			return i
+		}
+	}
+	return len(portProtocolPrecedence)
+}
+
+// withoutExcludedPorts returns the given upstream services without the services whose port is excluded from
+// inbound mesh traffic policy generation by the MeshConfig
+func (mc *MeshCatalog) withoutExcludedPorts(upstreamServices []service.MeshService) []service.MeshService {
+	exclusions := mc.GetMeshConfig().Spec.Traffic.InboundPolicyPortExclusions
+	if len(exclusions) == 0 {
+		return upstreamServices
+	}
+
+	var included []service.MeshService
+	for _, svc := range upstreamServices {
+		if isPortExcluded(svc, exclusions) {
+			log.Debug().Msgf("Skipping upstream service %s, its port %d is excluded from inbound mesh traffic policies", svc, svc.Port)
+			continue
+		}
+		included = append(included, svc)
+	}
+	return included
+}
+
+// isPortExcluded returns true if the port of the given service is excluded by any of the given exclusions
+func isPortExcluded(svc service.MeshService, exclusions []configv1alpha2.PortExclusionSpec) bool {
+	for _, exclusion := range exclusions {
+		if exclusion.Namespace != svc.Namespace || (exclusion.Service != "" && exclusion.Service != svc.Name) {
+			continue
+		}
+		for _, port := range exclusion.Ports {
+			if port == svc.Port {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolvePortProtocolConflicts returns the given upstream services without the services that declare
+// a protocol for a target port that conflicts with the protocol of another service on the same target port.
+// The protocol for a target port is resolved deterministically using portProtocolPrecedence, with ties
+// between protocols not in portProtocolPrecedence broken lexicographically.
+func resolvePortProtocolConflicts(upstreamServices []service.MeshService) []service.MeshService {
+	protocolForPort := make(map[uint16]string)
+	for _, svc := range upstreamServices {
+		protocol, ok := protocolForPort[svc.TargetPort]
+		if !ok {
+			protocolForPort[svc.TargetPort] = svc.Protocol
+			continue
+		}
+		rank, currentRank := getPortProtocolRank(svc.Protocol), getPortProtocolRank(protocol)
+		if rank < currentRank || (rank == currentRank && svc.Protocol < protocol) {
+			protocolForPort[svc.TargetPort] = svc.Protocol
+		}
+	}
+
+	var resolved []service.MeshService
+	for _, svc := range upstreamServices {
+		if protocol := protocolForPort[svc.TargetPort]; svc.Protocol != protocol {
+			log.Error().Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrConflictingPortProtocols)).
+				Msgf("Ignoring service %s with protocol %s for port %d, conflicts with protocol %s for the port", svc, svc.Protocol, svc.TargetPort, protocol)
+			continue
+		}
+		resolved = append(resolved, svc)
+	}
+	return resolved
+}
+
 // getRateLimitServiceClusters returns a list of MeshClusterConfig objects corresponding to the global
 // rate limit service instance. It ensures only a single cluster config if the same rate limit service
 // is used for both TCP and HTTP rate limiting.
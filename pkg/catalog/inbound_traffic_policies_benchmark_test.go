@@ -0,0 +1,119 @@
+package catalog
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	access "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/access/v1alpha3"
+	spec "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/specs/v1alpha4"
+	tassert "github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
+	tresorFake "github.com/openservicemesh/osm/pkg/certificate/providers/tresor/fake"
+	"github.com/openservicemesh/osm/pkg/compute/kube"
+	configFake "github.com/openservicemesh/osm/pkg/gen/client/config/clientset/versioned/fake"
+	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/k8s"
+	"github.com/openservicemesh/osm/pkg/logger"
+	"github.com/openservicemesh/osm/pkg/service"
+)
+
+func BenchmarkGetInboundMeshHTTPRouteConfigsPerPort(b *testing.B) {
+	benchmarkGetInboundMeshHTTPRouteConfigsPerPort(b, false)
+}
+
+// BenchmarkGetInboundMeshHTTPRouteConfigsPerPortCached measures the repeated calls served from the inbound policy cache
+// while the resources the route configs are built from are unchanged
+func BenchmarkGetInboundMeshHTTPRouteConfigsPerPortCached(b *testing.B) {
+	benchmarkGetInboundMeshHTTPRouteConfigsPerPort(b, true)
+}
+
+func benchmarkGetInboundMeshHTTPRouteConfigsPerPort(b *testing.B, enableCache bool) {
+	if err := logger.SetLogLevel("error"); err != nil {
+		b.Logf("Failed to set log level to error: %s", err)
+	}
+
+	assert := tassert.New(b)
+	mockCtrl := gomock.NewController(b)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	// A single HTTPRouteGroup with 100 matches, all routed to the same local cluster
+	const numMatches = 100
+	var matches []spec.HTTPMatch
+	var matchNames []string
+	for i := 0; i < numMatches; i++ {
+		name := fmt.Sprintf("route-%d", i)
+		matches = append(matches, spec.HTTPMatch{Name: name, PathRegex: fmt.Sprintf("/path-%d", i), Methods: []string{"GET"}})
+		matchNames = append(matchNames, name)
+	}
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rule-1"},
+			Spec:       spec.HTTPRouteGroupSpec{Matches: matches},
+		},
+	}
+	trafficTargets := []*access.TrafficTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "t1"},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+				Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "sa2", Namespace: "ns2"}},
+				Rules:       []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "rule-1", Matches: matchNames}},
+			},
+		},
+	}
+
+	mrc := &v1alpha2.MeshRootCertificate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "osm-mesh-root-certificate",
+			Namespace: "osm-system",
+		},
+		Spec: v1alpha2.MeshRootCertificateSpec{
+			TrustDomain: "cluster.local",
+			Intent:      v1alpha2.ActiveIntent,
+		},
+	}
+
+	configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+	mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+	fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+	mockK8s := k8s.NewMockController(mockCtrl)
+	mrcClient.NewCertEvent(mrc.Name)
+
+	mc := MeshCatalog{
+		certManager: fakeCertManager,
+		Interface:   kube.NewClient(mockK8s),
+	}
+	if enableCache {
+		mc.EnableInboundPolicyCache()
+	}
+
+	mockK8s.EXPECT().ListUpstreamTrafficSettings().Return(nil).AnyTimes()
+	mockK8s.EXPECT().ListTrafficSplits().Return(nil).AnyTimes()
+	mockK8s.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{}).AnyTimes()
+	mockK8s.EXPECT().ListTrafficTargets().Return(trafficTargets).AnyTimes()
+	mockK8s.EXPECT().ListHTTPTrafficSpecs().Return(httpRouteGroups).AnyTimes()
+
+	// The rules routing to the local cluster share a single set of weighted clusters
+	routeConfigs := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+	rules := routeConfigs[int(upstreamSvc.TargetPort)][0].Rules
+	assert.Len(rules, numMatches)
+	for _, rule := range rules {
+		assert.Same(rules[0].Route.WeightedClusters, rule.Route.WeightedClusters)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+	}
+	b.StopTimer()
+}
@@ -3,7 +3,9 @@ package catalog
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -13,9 +15,11 @@ import (
 	spec "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/specs/v1alpha4"
 	split "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/split/v1alpha2"
 	tassert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
 
 	"github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
 	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
@@ -25,6 +29,9 @@ import (
 	configFake "github.com/openservicemesh/osm/pkg/gen/client/config/clientset/versioned/fake"
 	"github.com/openservicemesh/osm/pkg/identity"
 	"github.com/openservicemesh/osm/pkg/k8s"
+	"github.com/openservicemesh/osm/pkg/k8s/events"
+	"github.com/openservicemesh/osm/pkg/messaging"
+	"github.com/openservicemesh/osm/pkg/metricsstore"
 	"github.com/openservicemesh/osm/pkg/service"
 	"github.com/openservicemesh/osm/pkg/tests"
 	"github.com/openservicemesh/osm/pkg/trafficpolicy"
@@ -46,6 +53,15 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 			},
 		},
 	}
+	virtualHostRetryPolicy := &policyv1alpha1.RetryPolicySpec{
+		RetryOn:       "5xx",
+		NumRetries:    pointer.Uint32Ptr(3),
+		PerTryTimeout: &metav1.Duration{Duration: time.Second},
+	}
+	virtualHostCorsPolicy := &policyv1alpha1.CorsSpec{
+		AllowOrigins: []string{"https://foo.com"},
+		AllowMethods: []string{"GET", "POST"},
+	}
 	perRouteGlobalRateLimitConfig := &policyv1alpha1.HTTPPerRouteRateLimitSpec{
 		Global: &policyv1alpha1.HTTPGlobalPerRouteRateLimitSpec{},
 	}
@@ -162,6 +178,7 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 			},
 			trafficSplits: nil,
 			prepare: func(mockK8s *k8s.MockController, trafficSplits []*split.TrafficSplit, trafficTargets []*access.TrafficTarget, upstreamTrafficSettings []*policyv1alpha1.UpstreamTrafficSetting) {
+				mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 				mockK8s.EXPECT().ListUpstreamTrafficSettings().Return(upstreamTrafficSettings).AnyTimes()
 				mockK8s.EXPECT().ListTrafficSplits().Return(trafficSplits).AnyTimes()
 				mockK8s.EXPECT().ListTrafficTargets().Return(trafficTargets).AnyTimes()
@@ -188,11 +205,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s1|8080|local",
@@ -227,11 +245,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s2|9090|local",
@@ -363,6 +382,173 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:             "multiple services, SMI mode, 1 TrafficTarget with HTTPRouteGroup and TCPRoute rules, 0 TrafficSplit",
+			upstreamIdentity: upstreamSvcAccount.ToServiceIdentity(),
+			upstreamServices: []service.MeshService{
+				{
+					Name:       "s1",
+					Namespace:  "ns1",
+					Port:       80,
+					TargetPort: 8080,
+					Protocol:   "http",
+				},
+				{
+					Name:       "s2",
+					Namespace:  "ns1",
+					Port:       3306,
+					TargetPort: 3306,
+					Protocol:   "tcp",
+				},
+			},
+			permissiveMode: false,
+			trafficTargets: []*access.TrafficTarget{
+				{
+					TypeMeta: metav1.TypeMeta{
+						APIVersion: "access.smi-spec.io/v1alpha3",
+						Kind:       "TrafficTarget",
+					},
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "t1",
+						Namespace: "ns1",
+					},
+					Spec: access.TrafficTargetSpec{
+						Destination: access.IdentityBindingSubject{
+							Kind:      "ServiceAccount",
+							Name:      "sa1",
+							Namespace: "ns1",
+						},
+						Sources: []access.IdentityBindingSubject{{
+							Kind:      "ServiceAccount",
+							Name:      "sa2",
+							Namespace: "ns2",
+						}},
+						Rules: []access.TrafficTargetRule{
+							{
+								Kind:    "HTTPRouteGroup",
+								Name:    "rule-1",
+								Matches: []string{"route-1"},
+							},
+							{
+								// TCPRoute with the same name as the HTTPRouteGroup must not be resolved as an HTTP route
+								Kind:    "TCPRoute",
+								Name:    "rule-1",
+								Matches: []string{"route-1"},
+							},
+						},
+					},
+				},
+			},
+			httpRouteGroups: []*spec.HTTPRouteGroup{
+				{
+					TypeMeta: metav1.TypeMeta{
+						APIVersion: "specs.smi-spec.io/v1alpha4",
+						Kind:       "HTTPRouteGroup",
+					},
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ns1",
+						Name:      "rule-1",
+					},
+					Spec: spec.HTTPRouteGroupSpec{
+						Matches: []spec.HTTPMatch{
+							{
+								Name:      "route-1",
+								PathRegex: "/get",
+								Methods:   []string{"GET"},
+							},
+						},
+					},
+				},
+			},
+			tcpRoutes: []*spec.TCPRoute{
+				{
+					TypeMeta: metav1.TypeMeta{
+						APIVersion: "specs.smi-spec.io/v1alpha4",
+						Kind:       "TCPRoute",
+					},
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ns1",
+						Name:      "rule-1",
+					},
+					Spec: spec.TCPRouteSpec{
+						Matches: spec.TCPMatch{
+							Ports: []int{3306},
+						},
+					},
+				},
+			},
+			trafficSplits: nil,
+			prepare: func(mockK8s *k8s.MockController, trafficSplits []*split.TrafficSplit, trafficTargets []*access.TrafficTarget, upstreamTrafficSettings []*policyv1alpha1.UpstreamTrafficSetting) {
+				mockK8s.EXPECT().ListTrafficSplits().Return(trafficSplits).AnyTimes()
+				mockK8s.EXPECT().ListMeshRootCertificates().Return(nil, nil).AnyTimes()
+			},
+			expectedInboundMeshHTTPRouteConfigsPerPort: map[int][]*trafficpolicy.InboundTrafficPolicy{
+				8080: {
+					{
+						Name: "s1.ns1.svc.cluster.local",
+						Hostnames: []string{
+							"s1",
+							"s1:80",
+							"s1.ns1",
+							"s1.ns1:80",
+							"s1.ns1.svc",
+							"s1.ns1.svc:80",
+							"s1.ns1.svc.cluster",
+							"s1.ns1.svc.cluster:80",
+							"s1.ns1.svc.cluster.local",
+							"s1.ns1.svc.cluster.local:80",
+						},
+						Rules: []*trafficpolicy.Rule{
+							{
+								Route: trafficpolicy.RouteWeightedClusters{
+									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+										Path:          "/get",
+										PathMatchType: trafficpolicy.PathMatchExact,
+										Methods:       []string{"GET"},
+									},
+									WeightedClusters: mapset.NewSet(service.WeightedCluster{
+										ClusterName: "ns1/s1|8080|local",
+										Weight:      100,
+									}),
+								},
+								AllowedPrincipals: mapset.NewSet("sa2.ns2.cluster.local"),
+							},
+						},
+					},
+				},
+				3306: nil,
+			},
+			expectedInboundMeshTrafficMatches: []*trafficpolicy.TrafficMatch{
+				{
+					Name:                "inbound_ns1/s1_8080_http",
+					DestinationPort:     8080,
+					DestinationProtocol: "http",
+					ServerNames:         []string{"s1.ns1.svc.cluster.local"},
+					Cluster:             "ns1/s1|8080|local",
+				},
+				{
+					Name:                "inbound_ns1/s2_3306_tcp",
+					DestinationPort:     3306,
+					DestinationProtocol: "tcp",
+					ServerNames:         []string{"s2.ns1.svc.cluster.local"},
+					Cluster:             "ns1/s2|3306|local",
+				},
+			},
+			expectedInboundMeshClusterConfigs: []*trafficpolicy.MeshClusterConfig{
+				{
+					Name:    "ns1/s1|8080|local",
+					Service: service.MeshService{Namespace: "ns1", Name: "s1", Port: 80, TargetPort: 8080, Protocol: "http"},
+					Address: "127.0.0.1",
+					Port:    8080,
+				},
+				{
+					Name:    "ns1/s2|3306|local",
+					Service: service.MeshService{Namespace: "ns1", Name: "s2", Port: 3306, TargetPort: 3306, Protocol: "tcp"},
+					Address: "127.0.0.1",
+					Port:    3306,
+				},
+			},
+		},
 		{
 			name:             "multiple services, SMI mode, 1 TrafficTarget, multiple HTTPRouteGroup, 0 TrafficSplit",
 			upstreamIdentity: upstreamSvcAccount.ToServiceIdentity(),
@@ -491,11 +677,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s1|80|local",
@@ -511,11 +698,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/put",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"PUT"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s1|80|local",
@@ -550,11 +738,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s2|90|local",
@@ -570,11 +759,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/put",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"PUT"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s2|90|local",
@@ -724,11 +914,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s1|80|local",
@@ -761,11 +952,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s1-apex|80|local",
@@ -800,11 +992,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s2|90|local",
@@ -1103,11 +1296,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s1|80|local",
@@ -1270,11 +1464,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s1|80|local",
@@ -1314,11 +1509,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s2|90|local",
@@ -1355,9 +1551,10 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 			},
 		},
 		{
-			name: "multiple services, SMI mode, 1 TrafficTarget, 1 HTTPRouteGroup, 1 TrafficSplit with backend same as apex",
-			// This test configures a TrafficSplit where the backend service is the same as the apex. This is a supported
-			// SMI configuration and mimics the e2e test e2e_trafficsplit_recursive_split.go.
+			name: "multiple services, SMI mode, multiple TrafficTarget with identical routes from different HTTPRouteGroups",
+			// This test configures multiple TrafficTarget resources referencing different HTTPRouteGroup resources that define
+			// identical matches under different names. The test verifies that routing rules with identical routes are merged to
+			// a single routing rule with merged downstream client identities.
 			upstreamIdentity: upstreamSvcAccount.ToServiceIdentity(),
 			upstreamServices: []service.MeshService{
 				{
@@ -1404,6 +1601,33 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 						}},
 					},
 				},
+				{
+					TypeMeta: metav1.TypeMeta{
+						APIVersion: "access.smi-spec.io/v1alpha3",
+						Kind:       "TrafficTarget",
+					},
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "t2",
+						Namespace: "ns1",
+					},
+					Spec: access.TrafficTargetSpec{
+						Destination: access.IdentityBindingSubject{
+							Kind:      "ServiceAccount",
+							Name:      "sa1",
+							Namespace: "ns1",
+						},
+						Sources: []access.IdentityBindingSubject{{
+							Kind:      "ServiceAccount",
+							Name:      "sa3",
+							Namespace: "ns3",
+						}},
+						Rules: []access.TrafficTargetRule{{
+							Kind:    "HTTPRouteGroup",
+							Name:    "rule-2",
+							Matches: []string{"route-2"},
+						}},
+					},
+				},
 			},
 			httpRouteGroups: []*spec.HTTPRouteGroup{
 				{
@@ -1428,24 +1652,30 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 						},
 					},
 				},
-			},
-			trafficSplits: []*split.TrafficSplit{
 				{
+					TypeMeta: metav1.TypeMeta{
+						APIVersion: "specs.smi-spec.io/v1alpha4",
+						Kind:       "HTTPRouteGroup",
+					},
 					ObjectMeta: metav1.ObjectMeta{
 						Namespace: "ns1",
-						Name:      "split1",
+						Name:      "rule-2",
 					},
-					Spec: split.TrafficSplitSpec{
-						Service: "s1",
-						Backends: []split.TrafficSplitBackend{
+					Spec: spec.HTTPRouteGroupSpec{
+						Matches: []spec.HTTPMatch{
 							{
-								Service: "s1",
-								Weight:  100,
+								Name:      "route-2",
+								PathRegex: "/get",
+								Methods:   []string{"GET"},
+								Headers: map[string]string{
+									"foo": "bar",
+								},
 							},
 						},
 					},
 				},
 			},
+			trafficSplits: nil,
 			prepare: func(mockK8s *k8s.MockController, trafficSplits []*split.TrafficSplit, trafficTargets []*access.TrafficTarget, upstreamTrafficSettings []*policyv1alpha1.UpstreamTrafficSetting) {
 				mockK8s.EXPECT().ListTrafficSplits().Return(trafficSplits).AnyTimes()
 				mockK8s.EXPECT().ListMeshRootCertificates().Return(nil, nil).AnyTimes()
@@ -1471,21 +1701,27 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s1|80|local",
 										Weight:      100,
 									}),
 								},
-								AllowedPrincipals: mapset.NewSet(identity.K8sServiceAccount{
-									Name:      "sa2",
-									Namespace: "ns2",
-								}.AsPrincipal("cluster.local", false)),
+								AllowedPrincipals: mapset.NewSet(
+									identity.K8sServiceAccount{
+										Name:      "sa2",
+										Namespace: "ns2",
+									}.AsPrincipal("cluster.local", false),
+									identity.K8sServiceAccount{
+										Name:      "sa3",
+										Namespace: "ns3",
+									}.AsPrincipal("cluster.local", false)),
 							},
 						},
 					},
@@ -1510,21 +1746,27 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s2|90|local",
 										Weight:      100,
 									}),
 								},
-								AllowedPrincipals: mapset.NewSet(identity.K8sServiceAccount{
-									Name:      "sa2",
-									Namespace: "ns2",
-								}.AsPrincipal("cluster.local", false)),
+								AllowedPrincipals: mapset.NewSet(
+									identity.K8sServiceAccount{
+										Name:      "sa2",
+										Namespace: "ns2",
+									}.AsPrincipal("cluster.local", false),
+									identity.K8sServiceAccount{
+										Name:      "sa3",
+										Namespace: "ns3",
+									}.AsPrincipal("cluster.local", false)),
 							},
 						},
 					},
@@ -1546,7 +1788,9 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 			},
 		},
 		{
-			name:             "multiple services, permissive mode, 1 TrafficSplit, MeshService is apex for another MeshService",
+			name: "multiple services, SMI mode, 1 TrafficTarget, 1 HTTPRouteGroup, 1 TrafficSplit with backend same as apex",
+			// This test configures a TrafficSplit where the backend service is the same as the apex. This is a supported
+			// SMI configuration and mimics the e2e test e2e_trafficsplit_recursive_split.go.
 			upstreamIdentity: upstreamSvcAccount.ToServiceIdentity(),
 			upstreamServices: []service.MeshService{
 				{
@@ -1557,25 +1801,216 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 					Protocol:   "http",
 				},
 				{
-					Name:       "s2-apex", // Also an apex service for ns1/s1
+					Name:       "s2",
 					Namespace:  "ns1",
-					Port:       80,
-					TargetPort: 80,
+					Port:       90,
+					TargetPort: 90,
 					Protocol:   "http",
 				},
 			},
-			permissiveMode:  true,
-			trafficTargets:  nil,
-			httpRouteGroups: nil,
-			trafficSplits: []*split.TrafficSplit{
+			permissiveMode: false,
+			trafficTargets: []*access.TrafficTarget{
 				{
+					TypeMeta: metav1.TypeMeta{
+						APIVersion: "access.smi-spec.io/v1alpha3",
+						Kind:       "TrafficTarget",
+					},
 					ObjectMeta: metav1.ObjectMeta{
+						Name:      "t1",
 						Namespace: "ns1",
-						Name:      "split1",
 					},
-					Spec: split.TrafficSplitSpec{
-						Service: "s2-apex",
-						Backends: []split.TrafficSplitBackend{
+					Spec: access.TrafficTargetSpec{
+						Destination: access.IdentityBindingSubject{
+							Kind:      "ServiceAccount",
+							Name:      "sa1",
+							Namespace: "ns1",
+						},
+						Sources: []access.IdentityBindingSubject{{
+							Kind:      "ServiceAccount",
+							Name:      "sa2",
+							Namespace: "ns2",
+						}},
+						Rules: []access.TrafficTargetRule{{
+							Kind:    "HTTPRouteGroup",
+							Name:    "rule-1",
+							Matches: []string{"route-1"},
+						}},
+					},
+				},
+			},
+			httpRouteGroups: []*spec.HTTPRouteGroup{
+				{
+					TypeMeta: metav1.TypeMeta{
+						APIVersion: "specs.smi-spec.io/v1alpha4",
+						Kind:       "HTTPRouteGroup",
+					},
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ns1",
+						Name:      "rule-1",
+					},
+					Spec: spec.HTTPRouteGroupSpec{
+						Matches: []spec.HTTPMatch{
+							{
+								Name:      "route-1",
+								PathRegex: "/get",
+								Methods:   []string{"GET"},
+								Headers: map[string]string{
+									"foo": "bar",
+								},
+							},
+						},
+					},
+				},
+			},
+			trafficSplits: []*split.TrafficSplit{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ns1",
+						Name:      "split1",
+					},
+					Spec: split.TrafficSplitSpec{
+						Service: "s1",
+						Backends: []split.TrafficSplitBackend{
+							{
+								Service: "s1",
+								Weight:  100,
+							},
+						},
+					},
+				},
+			},
+			prepare: func(mockK8s *k8s.MockController, trafficSplits []*split.TrafficSplit, trafficTargets []*access.TrafficTarget, upstreamTrafficSettings []*policyv1alpha1.UpstreamTrafficSetting) {
+				mockK8s.EXPECT().ListTrafficSplits().Return(trafficSplits).AnyTimes()
+				mockK8s.EXPECT().ListMeshRootCertificates().Return(nil, nil).AnyTimes()
+			},
+			expectedInboundMeshHTTPRouteConfigsPerPort: map[int][]*trafficpolicy.InboundTrafficPolicy{
+				80: {
+					{
+						Name: "s1.ns1.svc.cluster.local",
+						Hostnames: []string{
+							"s1",
+							"s1:80",
+							"s1.ns1",
+							"s1.ns1:80",
+							"s1.ns1.svc",
+							"s1.ns1.svc:80",
+							"s1.ns1.svc.cluster",
+							"s1.ns1.svc.cluster:80",
+							"s1.ns1.svc.cluster.local",
+							"s1.ns1.svc.cluster.local:80",
+						},
+						Rules: []*trafficpolicy.Rule{
+							{
+								Route: trafficpolicy.RouteWeightedClusters{
+									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+										Path:          "/get",
+										PathMatchType: trafficpolicy.PathMatchExact,
+										Methods:       []string{"GET"},
+										Headers: map[string]string{
+											"foo": "bar",
+										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
+									},
+									WeightedClusters: mapset.NewSet(service.WeightedCluster{
+										ClusterName: "ns1/s1|80|local",
+										Weight:      100,
+									}),
+								},
+								AllowedPrincipals: mapset.NewSet(identity.K8sServiceAccount{
+									Name:      "sa2",
+									Namespace: "ns2",
+								}.AsPrincipal("cluster.local", false)),
+							},
+						},
+					},
+				},
+				90: {
+					{
+						Name: "s2.ns1.svc.cluster.local",
+						Hostnames: []string{
+							"s2",
+							"s2:90",
+							"s2.ns1",
+							"s2.ns1:90",
+							"s2.ns1.svc",
+							"s2.ns1.svc:90",
+							"s2.ns1.svc.cluster",
+							"s2.ns1.svc.cluster:90",
+							"s2.ns1.svc.cluster.local",
+							"s2.ns1.svc.cluster.local:90",
+						},
+						Rules: []*trafficpolicy.Rule{
+							{
+								Route: trafficpolicy.RouteWeightedClusters{
+									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+										Path:          "/get",
+										PathMatchType: trafficpolicy.PathMatchExact,
+										Methods:       []string{"GET"},
+										Headers: map[string]string{
+											"foo": "bar",
+										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
+									},
+									WeightedClusters: mapset.NewSet(service.WeightedCluster{
+										ClusterName: "ns1/s2|90|local",
+										Weight:      100,
+									}),
+								},
+								AllowedPrincipals: mapset.NewSet(identity.K8sServiceAccount{
+									Name:      "sa2",
+									Namespace: "ns2",
+								}.AsPrincipal("cluster.local", false)),
+							},
+						},
+					},
+				},
+			},
+			expectedInboundMeshClusterConfigs: []*trafficpolicy.MeshClusterConfig{
+				{
+					Name:    "ns1/s1|80|local",
+					Service: service.MeshService{Namespace: "ns1", Name: "s1", Port: 80, TargetPort: 80, Protocol: "http"},
+					Address: "127.0.0.1",
+					Port:    80,
+				},
+				{
+					Name:    "ns1/s2|90|local",
+					Service: service.MeshService{Namespace: "ns1", Name: "s2", Port: 90, TargetPort: 90, Protocol: "http"},
+					Address: "127.0.0.1",
+					Port:    90,
+				},
+			},
+		},
+		{
+			name:             "multiple services, permissive mode, 1 TrafficSplit, MeshService is apex for another MeshService",
+			upstreamIdentity: upstreamSvcAccount.ToServiceIdentity(),
+			upstreamServices: []service.MeshService{
+				{
+					Name:       "s1",
+					Namespace:  "ns1",
+					Port:       80,
+					TargetPort: 80,
+					Protocol:   "http",
+				},
+				{
+					Name:       "s2-apex", // Also an apex service for ns1/s1
+					Namespace:  "ns1",
+					Port:       80,
+					TargetPort: 80,
+					Protocol:   "http",
+				},
+			},
+			permissiveMode:  true,
+			trafficTargets:  nil,
+			httpRouteGroups: nil,
+			trafficSplits: []*split.TrafficSplit{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ns1",
+						Name:      "split1",
+					},
+					Spec: split.TrafficSplitSpec{
+						Service: "s2-apex",
+						Backends: []split.TrafficSplitBackend{
 							{
 								Service: "s1",
 								Weight:  10,
@@ -1797,11 +2232,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s1|8080|local",
@@ -1838,11 +2274,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s2|9090|local",
@@ -2010,7 +2447,7 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 			},
 		},
 		{
-			name:             "multiple services, permissive mode, 0 TrafficSplit, with global rate limiting",
+			name:             "multiple services, permissive mode, 0 TrafficSplit, with retry policy",
 			upstreamIdentity: upstreamSvcAccount.ToServiceIdentity(),
 			upstreamServices: []service.MeshService{
 				{
@@ -2035,14 +2472,8 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 						Namespace: "ns1",
 					},
 					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
-						Host:      "s1.ns1.svc.cluster.local",
-						RateLimit: virtualHostGlobalRateLimitConfig,
-						HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
-							{
-								Path:      ".*", // matches wildcard path regex for permissive mode
-								RateLimit: perRouteGlobalRateLimitConfig,
-							},
-						},
+						Host:        "s1.ns1.svc.cluster.local",
+						RetryPolicy: virtualHostRetryPolicy,
 					},
 				},
 				{
@@ -2050,14 +2481,8 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 						Namespace: "ns1",
 					},
 					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
-						Host:      "s2.ns1.svc.cluster.local",
-						RateLimit: virtualHostGlobalRateLimitConfig,
-						HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
-							{
-								Path:      ".*", // matches wildcard path regex for permissive mode
-								RateLimit: perRouteGlobalRateLimitConfig,
-							},
-						},
+						Host:        "s2.ns1.svc.cluster.local",
+						RetryPolicy: virtualHostRetryPolicy,
 					},
 				},
 			},
@@ -2081,7 +2506,7 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 							"s1.ns1.svc.cluster.local",
 							"s1.ns1.svc.cluster.local:80",
 						},
-						RateLimit: virtualHostGlobalRateLimitConfig,
+						RetryPolicy: virtualHostRetryPolicy,
 						Rules: []*trafficpolicy.Rule{
 							{
 								Route: trafficpolicy.RouteWeightedClusters{
@@ -2090,7 +2515,6 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 										ClusterName: "ns1/s1|80|local",
 										Weight:      100,
 									}),
-									RateLimit: perRouteGlobalRateLimitConfig,
 								},
 								AllowedPrincipals: mapset.NewSet(identity.WildcardPrincipal),
 							},
@@ -2112,7 +2536,7 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 							"s2.ns1.svc.cluster.local",
 							"s2.ns1.svc.cluster.local:90",
 						},
-						RateLimit: virtualHostGlobalRateLimitConfig,
+						RetryPolicy: virtualHostRetryPolicy,
 						Rules: []*trafficpolicy.Rule{
 							{
 								Route: trafficpolicy.RouteWeightedClusters{
@@ -2121,7 +2545,6 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 										ClusterName: "ns1/s2|90|local",
 										Weight:      100,
 									}),
-									RateLimit: perRouteGlobalRateLimitConfig,
 								},
 								AllowedPrincipals: mapset.NewSet(identity.WildcardPrincipal),
 							},
@@ -2142,16 +2565,10 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 					Address: "127.0.0.1",
 					Port:    90,
 				},
-				{
-					Name:     "foo.bar|8080",
-					Address:  "foo.bar",
-					Port:     8080,
-					Protocol: constants.ProtocolH2C,
-				},
 			},
 		},
 		{
-			name:             "multiple services, SMI mode, 1 TrafficTarget, 1 HTTPRouteGroup, 1 TrafficSplit and multiple trust domains",
+			name:             "multiple services, permissive mode, 0 TrafficSplit, with CORS policy and local rate limiting",
 			upstreamIdentity: upstreamSvcAccount.ToServiceIdentity(),
 			upstreamServices: []service.MeshService{
 				{
@@ -2169,77 +2586,159 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 					Protocol:   "http",
 				},
 			},
-			permissiveMode: false,
-			newTrustDomain: "cluster.new",
-			trafficTargets: []*access.TrafficTarget{
+			permissiveMode: true,
+			upstreamTrafficSettings: []*policyv1alpha1.UpstreamTrafficSetting{
 				{
-					TypeMeta: metav1.TypeMeta{
-						APIVersion: "access.smi-spec.io/v1alpha3",
-						Kind:       "TrafficTarget",
-					},
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      "t1",
 						Namespace: "ns1",
 					},
-					Spec: access.TrafficTargetSpec{
-						Destination: access.IdentityBindingSubject{
-							Kind:      "ServiceAccount",
-							Name:      "sa1",
-							Namespace: "ns1",
-						},
-						Sources: []access.IdentityBindingSubject{{
-							Kind:      "ServiceAccount",
-							Name:      "sa2",
-							Namespace: "ns2",
-						}},
-						Rules: []access.TrafficTargetRule{{
-							Kind:    "HTTPRouteGroup",
-							Name:    "rule-1",
-							Matches: []string{"route-1"},
-						}},
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						Host:      "s1.ns1.svc.cluster.local",
+						RateLimit: virtualHostLocalRateLimitConfig,
+						Cors:      virtualHostCorsPolicy,
 					},
 				},
-			},
-			httpRouteGroups: []*spec.HTTPRouteGroup{
 				{
-					TypeMeta: metav1.TypeMeta{
-						APIVersion: "specs.smi-spec.io/v1alpha4",
-						Kind:       "HTTPRouteGroup",
-					},
 					ObjectMeta: metav1.ObjectMeta{
 						Namespace: "ns1",
-						Name:      "rule-1",
 					},
-					Spec: spec.HTTPRouteGroupSpec{
-						Matches: []spec.HTTPMatch{
-							{
-								Name:      "route-1",
-								PathRegex: "/get",
-								Methods:   []string{"GET"},
-								Headers: map[string]string{
-									"foo": "bar",
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						Host:      "s2.ns1.svc.cluster.local",
+						RateLimit: virtualHostLocalRateLimitConfig,
+						Cors:      virtualHostCorsPolicy,
+					},
+				},
+			},
+			prepare: func(mockK8s *k8s.MockController, trafficSplits []*split.TrafficSplit, trafficTargets []*access.TrafficTarget, upstreamTrafficSettings []*policyv1alpha1.UpstreamTrafficSetting) {
+				mockK8s.EXPECT().ListTrafficSplits().Return(trafficSplits).AnyTimes()
+				mockK8s.EXPECT().ListMeshRootCertificates().Return(nil, nil).AnyTimes()
+			},
+			expectedInboundMeshHTTPRouteConfigsPerPort: map[int][]*trafficpolicy.InboundTrafficPolicy{
+				80: {
+					{
+						Name: "s1.ns1.svc.cluster.local",
+						Hostnames: []string{
+							"s1",
+							"s1:80",
+							"s1.ns1",
+							"s1.ns1:80",
+							"s1.ns1.svc",
+							"s1.ns1.svc:80",
+							"s1.ns1.svc.cluster",
+							"s1.ns1.svc.cluster:80",
+							"s1.ns1.svc.cluster.local",
+							"s1.ns1.svc.cluster.local:80",
+						},
+						RateLimit: virtualHostLocalRateLimitConfig,
+						Cors:      virtualHostCorsPolicy,
+						Rules: []*trafficpolicy.Rule{
+							{
+								Route: trafficpolicy.RouteWeightedClusters{
+									HTTPRouteMatch: trafficpolicy.WildCardRouteMatch,
+									WeightedClusters: mapset.NewSet(service.WeightedCluster{
+										ClusterName: "ns1/s1|80|local",
+										Weight:      100,
+									}),
+								},
+								AllowedPrincipals: mapset.NewSet(identity.WildcardPrincipal),
+							},
+						},
+					},
+				},
+				90: {
+					{
+						Name: "s2.ns1.svc.cluster.local",
+						Hostnames: []string{
+							"s2",
+							"s2:90",
+							"s2.ns1",
+							"s2.ns1:90",
+							"s2.ns1.svc",
+							"s2.ns1.svc:90",
+							"s2.ns1.svc.cluster",
+							"s2.ns1.svc.cluster:90",
+							"s2.ns1.svc.cluster.local",
+							"s2.ns1.svc.cluster.local:90",
+						},
+						RateLimit: virtualHostLocalRateLimitConfig,
+						Cors:      virtualHostCorsPolicy,
+						Rules: []*trafficpolicy.Rule{
+							{
+								Route: trafficpolicy.RouteWeightedClusters{
+									HTTPRouteMatch: trafficpolicy.WildCardRouteMatch,
+									WeightedClusters: mapset.NewSet(service.WeightedCluster{
+										ClusterName: "ns1/s2|90|local",
+										Weight:      100,
+									}),
 								},
+								AllowedPrincipals: mapset.NewSet(identity.WildcardPrincipal),
 							},
 						},
 					},
 				},
 			},
-			trafficSplits: []*split.TrafficSplit{
+			expectedInboundMeshClusterConfigs: []*trafficpolicy.MeshClusterConfig{
+				{
+					Name:    "ns1/s1|80|local",
+					Service: service.MeshService{Namespace: "ns1", Name: "s1", Port: 80, TargetPort: 80, Protocol: "http"},
+					Address: "127.0.0.1",
+					Port:    80,
+				},
+				{
+					Name:    "ns1/s2|90|local",
+					Service: service.MeshService{Namespace: "ns1", Name: "s2", Port: 90, TargetPort: 90, Protocol: "http"},
+					Address: "127.0.0.1",
+					Port:    90,
+				},
+			},
+		},
+		{
+			name:             "multiple services, permissive mode, 0 TrafficSplit, with global rate limiting",
+			upstreamIdentity: upstreamSvcAccount.ToServiceIdentity(),
+			upstreamServices: []service.MeshService{
+				{
+					Name:       "s1",
+					Namespace:  "ns1",
+					Port:       80,
+					TargetPort: 80,
+					Protocol:   "http",
+				},
+				{
+					Name:       "s2",
+					Namespace:  "ns1",
+					Port:       90,
+					TargetPort: 90,
+					Protocol:   "http",
+				},
+			},
+			permissiveMode: true,
+			upstreamTrafficSettings: []*policyv1alpha1.UpstreamTrafficSetting{
 				{
 					ObjectMeta: metav1.ObjectMeta{
 						Namespace: "ns1",
-						Name:      "split1",
 					},
-					Spec: split.TrafficSplitSpec{
-						Service: "s1-apex",
-						Backends: []split.TrafficSplitBackend{
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						Host:      "s1.ns1.svc.cluster.local",
+						RateLimit: virtualHostGlobalRateLimitConfig,
+						HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
 							{
-								Service: "s1",
-								Weight:  10,
+								Path:      ".*", // matches wildcard path regex for permissive mode
+								RateLimit: perRouteGlobalRateLimitConfig,
 							},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ns1",
+					},
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						Host:      "s2.ns1.svc.cluster.local",
+						RateLimit: virtualHostGlobalRateLimitConfig,
+						HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
 							{
-								Service: "s-unused",
-								Weight:  90,
+								Path:      ".*", // matches wildcard path regex for permissive mode
+								RateLimit: perRouteGlobalRateLimitConfig,
 							},
 						},
 					},
@@ -2247,6 +2746,7 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 			},
 			prepare: func(mockK8s *k8s.MockController, trafficSplits []*split.TrafficSplit, trafficTargets []*access.TrafficTarget, upstreamTrafficSettings []*policyv1alpha1.UpstreamTrafficSetting) {
 				mockK8s.EXPECT().ListTrafficSplits().Return(trafficSplits).AnyTimes()
+				mockK8s.EXPECT().ListMeshRootCertificates().Return(nil, nil).AnyTimes()
 			},
 			expectedInboundMeshHTTPRouteConfigsPerPort: map[int][]*trafficpolicy.InboundTrafficPolicy{
 				80: {
@@ -2264,69 +2764,18 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 							"s1.ns1.svc.cluster.local",
 							"s1.ns1.svc.cluster.local:80",
 						},
+						RateLimit: virtualHostGlobalRateLimitConfig,
 						Rules: []*trafficpolicy.Rule{
 							{
 								Route: trafficpolicy.RouteWeightedClusters{
-									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
-										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
-										Methods:       []string{"GET"},
-										Headers: map[string]string{
-											"foo": "bar",
-										},
-									},
+									HTTPRouteMatch: trafficpolicy.WildCardRouteMatch,
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s1|80|local",
 										Weight:      100,
 									}),
+									RateLimit: perRouteGlobalRateLimitConfig,
 								},
-								AllowedPrincipals: mapset.NewSet(identity.K8sServiceAccount{
-									Name:      "sa2",
-									Namespace: "ns2",
-								}.AsPrincipal("cluster.local", false), identity.K8sServiceAccount{
-									Name:      "sa2",
-									Namespace: "ns2",
-								}.AsPrincipal("cluster.new", false)),
-							},
-						},
-					},
-					{
-						Name: "s1-apex.ns1.svc.cluster.local",
-						Hostnames: []string{
-							"s1-apex",
-							"s1-apex:80",
-							"s1-apex.ns1",
-							"s1-apex.ns1:80",
-							"s1-apex.ns1.svc",
-							"s1-apex.ns1.svc:80",
-							"s1-apex.ns1.svc.cluster",
-							"s1-apex.ns1.svc.cluster:80",
-							"s1-apex.ns1.svc.cluster.local",
-							"s1-apex.ns1.svc.cluster.local:80",
-						},
-						Rules: []*trafficpolicy.Rule{
-							{
-								Route: trafficpolicy.RouteWeightedClusters{
-									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
-										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
-										Methods:       []string{"GET"},
-										Headers: map[string]string{
-											"foo": "bar",
-										},
-									},
-									WeightedClusters: mapset.NewSet(service.WeightedCluster{
-										ClusterName: "ns1/s1-apex|80|local",
-										Weight:      100,
-									}),
-								},
-								AllowedPrincipals: mapset.NewSet(identity.K8sServiceAccount{
-									Name:      "sa2",
-									Namespace: "ns2",
-								}.AsPrincipal("cluster.local", false), identity.K8sServiceAccount{
-									Name:      "sa2",
-									Namespace: "ns2",
-								}.AsPrincipal("cluster.new", false)),
+								AllowedPrincipals: mapset.NewSet(identity.WildcardPrincipal),
 							},
 						},
 					},
@@ -2346,29 +2795,18 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 							"s2.ns1.svc.cluster.local",
 							"s2.ns1.svc.cluster.local:90",
 						},
+						RateLimit: virtualHostGlobalRateLimitConfig,
 						Rules: []*trafficpolicy.Rule{
 							{
 								Route: trafficpolicy.RouteWeightedClusters{
-									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
-										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
-										Methods:       []string{"GET"},
-										Headers: map[string]string{
-											"foo": "bar",
-										},
-									},
+									HTTPRouteMatch: trafficpolicy.WildCardRouteMatch,
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s2|90|local",
 										Weight:      100,
 									}),
+									RateLimit: perRouteGlobalRateLimitConfig,
 								},
-								AllowedPrincipals: mapset.NewSet(identity.K8sServiceAccount{
-									Name:      "sa2",
-									Namespace: "ns2",
-								}.AsPrincipal("cluster.local", false), identity.K8sServiceAccount{
-									Name:      "sa2",
-									Namespace: "ns2",
-								}.AsPrincipal("cluster.new", false)),
+								AllowedPrincipals: mapset.NewSet(identity.WildcardPrincipal),
 							},
 						},
 					},
@@ -2381,23 +2819,22 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 					Address: "127.0.0.1",
 					Port:    80,
 				},
-				{
-					Name:    "ns1/s1-apex|80|local",
-					Service: service.MeshService{Namespace: "ns1", Name: "s1-apex", Port: 80, TargetPort: 80, Protocol: "http"},
-					Address: "127.0.0.1",
-					Port:    80,
-				},
 				{
 					Name:    "ns1/s2|90|local",
 					Service: service.MeshService{Namespace: "ns1", Name: "s2", Port: 90, TargetPort: 90, Protocol: "http"},
 					Address: "127.0.0.1",
 					Port:    90,
 				},
+				{
+					Name:     "foo.bar|8080",
+					Address:  "foo.bar",
+					Port:     8080,
+					Protocol: constants.ProtocolH2C,
+				},
 			},
 		},
 		{
-			name:             "multiple services, SMI mode, 1 TrafficTarget, 1 HTTPRouteGroup, 1 TrafficSplit and spiffe enabled",
-			spiffeEnabled:    true,
+			name:             "multiple services, SMI mode, 1 TrafficTarget, 1 HTTPRouteGroup, 1 TrafficSplit and multiple trust domains",
 			upstreamIdentity: upstreamSvcAccount.ToServiceIdentity(),
 			upstreamServices: []service.MeshService{
 				{
@@ -2416,6 +2853,7 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 				},
 			},
 			permissiveMode: false,
+			newTrustDomain: "cluster.new",
 			trafficTargets: []*access.TrafficTarget{
 				{
 					TypeMeta: metav1.TypeMeta{
@@ -2514,11 +2952,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s1|80|local",
@@ -2528,7 +2967,10 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								AllowedPrincipals: mapset.NewSet(identity.K8sServiceAccount{
 									Name:      "sa2",
 									Namespace: "ns2",
-								}.AsPrincipal("cluster.local", true)),
+								}.AsPrincipal("cluster.local", false), identity.K8sServiceAccount{
+									Name:      "sa2",
+									Namespace: "ns2",
+								}.AsPrincipal("cluster.new", false)),
 							},
 						},
 					},
@@ -2551,11 +2993,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s1-apex|80|local",
@@ -2565,7 +3008,10 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								AllowedPrincipals: mapset.NewSet(identity.K8sServiceAccount{
 									Name:      "sa2",
 									Namespace: "ns2",
-								}.AsPrincipal("cluster.local", true)),
+								}.AsPrincipal("cluster.local", false), identity.K8sServiceAccount{
+									Name:      "sa2",
+									Namespace: "ns2",
+								}.AsPrincipal("cluster.new", false)),
 							},
 						},
 					},
@@ -2590,11 +3036,12 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								Route: trafficpolicy.RouteWeightedClusters{
 									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
 										Path:          "/get",
-										PathMatchType: trafficpolicy.PathMatchRegex,
+										PathMatchType: trafficpolicy.PathMatchExact,
 										Methods:       []string{"GET"},
 										Headers: map[string]string{
 											"foo": "bar",
 										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 									},
 									WeightedClusters: mapset.NewSet(service.WeightedCluster{
 										ClusterName: "ns1/s2|90|local",
@@ -2604,7 +3051,10 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 								AllowedPrincipals: mapset.NewSet(identity.K8sServiceAccount{
 									Name:      "sa2",
 									Namespace: "ns2",
-								}.AsPrincipal("cluster.local", true)),
+								}.AsPrincipal("cluster.local", false), identity.K8sServiceAccount{
+									Name:      "sa2",
+									Namespace: "ns2",
+								}.AsPrincipal("cluster.new", false)),
 							},
 						},
 					},
@@ -2631,21 +3081,260 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 				},
 			},
 		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			assert := tassert.New(t)
-			mockCtrl := gomock.NewController(t)
-			defer mockCtrl.Finish()
-
-			mrc1 := &v1alpha2.MeshRootCertificate{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "osm-mesh-root-certificate",
-					Namespace: "osm-system",
-				},
-				Spec: v1alpha2.MeshRootCertificateSpec{
-					TrustDomain:   "cluster.local",
+		{
+			name:             "multiple services, SMI mode, 1 TrafficTarget, 1 HTTPRouteGroup, 1 TrafficSplit and spiffe enabled",
+			spiffeEnabled:    true,
+			upstreamIdentity: upstreamSvcAccount.ToServiceIdentity(),
+			upstreamServices: []service.MeshService{
+				{
+					Name:       "s1",
+					Namespace:  "ns1",
+					Port:       80,
+					TargetPort: 80,
+					Protocol:   "http",
+				},
+				{
+					Name:       "s2",
+					Namespace:  "ns1",
+					Port:       90,
+					TargetPort: 90,
+					Protocol:   "http",
+				},
+			},
+			permissiveMode: false,
+			trafficTargets: []*access.TrafficTarget{
+				{
+					TypeMeta: metav1.TypeMeta{
+						APIVersion: "access.smi-spec.io/v1alpha3",
+						Kind:       "TrafficTarget",
+					},
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "t1",
+						Namespace: "ns1",
+					},
+					Spec: access.TrafficTargetSpec{
+						Destination: access.IdentityBindingSubject{
+							Kind:      "ServiceAccount",
+							Name:      "sa1",
+							Namespace: "ns1",
+						},
+						Sources: []access.IdentityBindingSubject{{
+							Kind:      "ServiceAccount",
+							Name:      "sa2",
+							Namespace: "ns2",
+						}},
+						Rules: []access.TrafficTargetRule{{
+							Kind:    "HTTPRouteGroup",
+							Name:    "rule-1",
+							Matches: []string{"route-1"},
+						}},
+					},
+				},
+			},
+			httpRouteGroups: []*spec.HTTPRouteGroup{
+				{
+					TypeMeta: metav1.TypeMeta{
+						APIVersion: "specs.smi-spec.io/v1alpha4",
+						Kind:       "HTTPRouteGroup",
+					},
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ns1",
+						Name:      "rule-1",
+					},
+					Spec: spec.HTTPRouteGroupSpec{
+						Matches: []spec.HTTPMatch{
+							{
+								Name:      "route-1",
+								PathRegex: "/get",
+								Methods:   []string{"GET"},
+								Headers: map[string]string{
+									"foo": "bar",
+								},
+							},
+						},
+					},
+				},
+			},
+			trafficSplits: []*split.TrafficSplit{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ns1",
+						Name:      "split1",
+					},
+					Spec: split.TrafficSplitSpec{
+						Service: "s1-apex",
+						Backends: []split.TrafficSplitBackend{
+							{
+								Service: "s1",
+								Weight:  10,
+							},
+							{
+								Service: "s-unused",
+								Weight:  90,
+							},
+						},
+					},
+				},
+			},
+			prepare: func(mockK8s *k8s.MockController, trafficSplits []*split.TrafficSplit, trafficTargets []*access.TrafficTarget, upstreamTrafficSettings []*policyv1alpha1.UpstreamTrafficSetting) {
+				mockK8s.EXPECT().ListTrafficSplits().Return(trafficSplits).AnyTimes()
+			},
+			expectedInboundMeshHTTPRouteConfigsPerPort: map[int][]*trafficpolicy.InboundTrafficPolicy{
+				80: {
+					{
+						Name: "s1.ns1.svc.cluster.local",
+						Hostnames: []string{
+							"s1",
+							"s1:80",
+							"s1.ns1",
+							"s1.ns1:80",
+							"s1.ns1.svc",
+							"s1.ns1.svc:80",
+							"s1.ns1.svc.cluster",
+							"s1.ns1.svc.cluster:80",
+							"s1.ns1.svc.cluster.local",
+							"s1.ns1.svc.cluster.local:80",
+						},
+						Rules: []*trafficpolicy.Rule{
+							{
+								Route: trafficpolicy.RouteWeightedClusters{
+									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+										Path:          "/get",
+										PathMatchType: trafficpolicy.PathMatchExact,
+										Methods:       []string{"GET"},
+										Headers: map[string]string{
+											"foo": "bar",
+										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
+									},
+									WeightedClusters: mapset.NewSet(service.WeightedCluster{
+										ClusterName: "ns1/s1|80|local",
+										Weight:      100,
+									}),
+								},
+								AllowedPrincipals: mapset.NewSet(identity.K8sServiceAccount{
+									Name:      "sa2",
+									Namespace: "ns2",
+								}.AsPrincipal("cluster.local", true)),
+							},
+						},
+					},
+					{
+						Name: "s1-apex.ns1.svc.cluster.local",
+						Hostnames: []string{
+							"s1-apex",
+							"s1-apex:80",
+							"s1-apex.ns1",
+							"s1-apex.ns1:80",
+							"s1-apex.ns1.svc",
+							"s1-apex.ns1.svc:80",
+							"s1-apex.ns1.svc.cluster",
+							"s1-apex.ns1.svc.cluster:80",
+							"s1-apex.ns1.svc.cluster.local",
+							"s1-apex.ns1.svc.cluster.local:80",
+						},
+						Rules: []*trafficpolicy.Rule{
+							{
+								Route: trafficpolicy.RouteWeightedClusters{
+									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+										Path:          "/get",
+										PathMatchType: trafficpolicy.PathMatchExact,
+										Methods:       []string{"GET"},
+										Headers: map[string]string{
+											"foo": "bar",
+										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
+									},
+									WeightedClusters: mapset.NewSet(service.WeightedCluster{
+										ClusterName: "ns1/s1-apex|80|local",
+										Weight:      100,
+									}),
+								},
+								AllowedPrincipals: mapset.NewSet(identity.K8sServiceAccount{
+									Name:      "sa2",
+									Namespace: "ns2",
+								}.AsPrincipal("cluster.local", true)),
+							},
+						},
+					},
+				},
+				90: {
+					{
+						Name: "s2.ns1.svc.cluster.local",
+						Hostnames: []string{
+							"s2",
+							"s2:90",
+							"s2.ns1",
+							"s2.ns1:90",
+							"s2.ns1.svc",
+							"s2.ns1.svc:90",
+							"s2.ns1.svc.cluster",
+							"s2.ns1.svc.cluster:90",
+							"s2.ns1.svc.cluster.local",
+							"s2.ns1.svc.cluster.local:90",
+						},
+						Rules: []*trafficpolicy.Rule{
+							{
+								Route: trafficpolicy.RouteWeightedClusters{
+									HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+										Path:          "/get",
+										PathMatchType: trafficpolicy.PathMatchExact,
+										Methods:       []string{"GET"},
+										Headers: map[string]string{
+											"foo": "bar",
+										},
+										HeaderMatchType: trafficpolicy.HeaderMatchRegex,
+									},
+									WeightedClusters: mapset.NewSet(service.WeightedCluster{
+										ClusterName: "ns1/s2|90|local",
+										Weight:      100,
+									}),
+								},
+								AllowedPrincipals: mapset.NewSet(identity.K8sServiceAccount{
+									Name:      "sa2",
+									Namespace: "ns2",
+								}.AsPrincipal("cluster.local", true)),
+							},
+						},
+					},
+				},
+			},
+			expectedInboundMeshClusterConfigs: []*trafficpolicy.MeshClusterConfig{
+				{
+					Name:    "ns1/s1|80|local",
+					Service: service.MeshService{Namespace: "ns1", Name: "s1", Port: 80, TargetPort: 80, Protocol: "http"},
+					Address: "127.0.0.1",
+					Port:    80,
+				},
+				{
+					Name:    "ns1/s1-apex|80|local",
+					Service: service.MeshService{Namespace: "ns1", Name: "s1-apex", Port: 80, TargetPort: 80, Protocol: "http"},
+					Address: "127.0.0.1",
+					Port:    80,
+				},
+				{
+					Name:    "ns1/s2|90|local",
+					Service: service.MeshService{Namespace: "ns1", Name: "s2", Port: 90, TargetPort: 90, Protocol: "http"},
+					Address: "127.0.0.1",
+					Port:    90,
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mrc1 := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain:   "cluster.local",
 					Intent:        v1alpha2.ActiveIntent,
 					SpiffeEnabled: tc.spiffeEnabled,
 				},
@@ -2699,12 +3388,25 @@ func TestGetInboundMeshTrafficPolicy(t *testing.T) {
 				}, 2*time.Second, 100*time.Millisecond)
 			}
 
-			actualClusterConfigs := mc.GetInboundMeshClusterConfigs(tc.upstreamServices)
+			actualClusterConfigs := mc.GetInboundMeshClusterConfigs(tests.BookstoreServiceIdentity, tc.upstreamServices)
 			actualHTTPRouteConfigsPerPort := mc.GetInboundMeshHTTPRouteConfigsPerPort(tc.upstreamIdentity, tc.upstreamServices)
-			actualTrafficMatches := mc.GetInboundMeshTrafficMatches(tc.upstreamServices)
+			actualTrafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, tc.upstreamServices)
+
+			// Route stat prefixes are verified in TestGetRouteStatPrefix, clear them before comparing the policies
+			for _, policies := range actualHTTPRouteConfigsPerPort {
+				for _, policy := range policies {
+					for _, rule := range policy.Rules {
+						assert.NotEmpty(rule.Route.StatPrefix)
+						rule.Route.StatPrefix = ""
+					}
+				}
+			}
 
 			// Verify expected fields
 			assert.ElementsMatch(tc.expectedInboundMeshClusterConfigs, actualClusterConfigs)
+			assert.True(sort.SliceIsSorted(actualClusterConfigs, func(i, j int) bool {
+				return actualClusterConfigs[i].Name < actualClusterConfigs[j].Name
+			}))
 			for expectedKey, expectedVal := range tc.expectedInboundMeshHTTPRouteConfigsPerPort {
 				assert.ElementsMatch(expectedVal, actualHTTPRouteConfigsPerPort[expectedKey])
 			}
@@ -2732,6 +3434,7 @@ func TestRoutesFromRules(t *testing.T) {
 		rules          []access.TrafficTargetRule
 		namespace      string
 		expectedRoutes []trafficpolicy.HTTPRouteMatch
+		expectedErr    error
 	}{
 		{
 			name: "http route group and match name exist",
@@ -2756,18 +3459,195 @@ func TestRoutesFromRules(t *testing.T) {
 			},
 			namespace:      tests.Namespace,
 			expectedRoutes: nil,
+			expectedErr:    errHTTPRouteGroupNotFound,
 		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(fmt.Sprintf("Testing routesFromRules where %s", tc.name), func(t *testing.T) {
-			routes, err := mc.routesFromRules(tc.rules, tc.namespace)
-			assert.Nil(err)
+		{
+			name: "match name does not exist in http route group",
+			rules: []access.TrafficTargetRule{
+				{
+					Kind:    "HTTPRouteGroup",
+					Name:    tests.RouteGroupName,
+					Matches: []string{tests.BuyBooksMatchName, "DoesNotExist"},
+				},
+			},
+			namespace:      tests.Namespace,
+			expectedRoutes: []trafficpolicy.HTTPRouteMatch{tests.BookstoreBuyHTTPRoute},
+			expectedErr:    errHTTPRouteGroupMatchNotFound,
+		},
+		{
+			name: "group qualified http route group kind",
+			rules: []access.TrafficTargetRule{
+				{
+					Kind:    "HTTPRouteGroup.specs.smi-spec.io",
+					Name:    tests.RouteGroupName,
+					Matches: []string{tests.BuyBooksMatchName},
+				},
+			},
+			namespace:      tests.Namespace,
+			expectedRoutes: []trafficpolicy.HTTPRouteMatch{tests.BookstoreBuyHTTPRoute},
+		},
+		{
+			name: "group and version qualified http route group kind",
+			rules: []access.TrafficTargetRule{
+				{
+					Kind:    "HTTPRouteGroup.v1alpha4.specs.smi-spec.io",
+					Name:    tests.RouteGroupName,
+					Matches: []string{tests.BuyBooksMatchName},
+				},
+			},
+			namespace:      tests.Namespace,
+			expectedRoutes: []trafficpolicy.HTTPRouteMatch{tests.BookstoreBuyHTTPRoute},
+		},
+		{
+			name: "unknown kind is skipped",
+			rules: []access.TrafficTargetRule{
+				{
+					Kind:    "HTTPRoutGroup",
+					Name:    tests.RouteGroupName,
+					Matches: []string{tests.BuyBooksMatchName},
+				},
+			},
+			namespace:      tests.Namespace,
+			expectedRoutes: nil,
+		},
+		{
+			name: "mixed list of known, qualified and unknown kinds",
+			rules: []access.TrafficTargetRule{
+				{
+					Kind:    "HTTPRouteGroup.example.com",
+					Name:    tests.RouteGroupName,
+					Matches: []string{tests.SellBooksMatchName},
+				},
+				{
+					Kind:    "HTTPRouteGroup.specs.smi-spec.io",
+					Name:    tests.RouteGroupName,
+					Matches: []string{tests.BuyBooksMatchName},
+				},
+				{
+					Kind: "TCPRoute",
+					Name: "tcp-route",
+				},
+				{
+					Kind:    "HTTPRouteGroup",
+					Name:    tests.RouteGroupName,
+					Matches: []string{tests.SellBooksMatchName},
+				},
+			},
+			namespace:      tests.Namespace,
+			expectedRoutes: []trafficpolicy.HTTPRouteMatch{tests.BookstoreBuyHTTPRoute, tests.BookstoreSellHTTPRoute},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("Testing routesFromRules where %s", tc.name), func(t *testing.T) {
+			routes, err := mc.routesFromRules(tc.rules, tc.namespace, constants.ProtocolHTTP)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+			} else {
+				assert.Nil(err)
+			}
 			assert.EqualValues(tc.expectedRoutes, routes)
 		})
 	}
 }
 
+func TestRoutesFromRulesWithoutMatches(t *testing.T) {
+	assert := tassert.New(t)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "group-a"},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{{Name: "route-1", PathRegex: "/a", Methods: []string{"GET"}}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "group-b"},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{
+					{Name: "route-2", PathRegex: "/b/.*", Methods: []string{"POST"}},
+					{Name: "route-1", PathRegex: "/b", Methods: []string{"GET"}},
+				},
+			},
+		},
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		httpRouteGroups: httpRouteGroups,
+	})
+
+	// All the matches of the referenced HTTPRouteGroup are returned in the order they are defined
+	routes, err := mc.routesFromRules([]access.TrafficTargetRule{
+		{Kind: "HTTPRouteGroup", Name: "group-b"},
+	}, "ns1", constants.ProtocolHTTP)
+	assert.Nil(err)
+	assert.Equal([]trafficpolicy.HTTPRouteMatch{
+		{Path: "/b/.*", PathMatchType: trafficpolicy.PathMatchRegex, Methods: []string{"POST"}},
+		{Path: "/b", PathMatchType: trafficpolicy.PathMatchExact, Methods: []string{"GET"}},
+	}, routes)
+
+	// A rule without matches referencing an HTTPRouteGroup that does not exist is an error
+	routes, err = mc.routesFromRules([]access.TrafficTargetRule{
+		{Kind: "HTTPRouteGroup", Name: "group-c"},
+	}, "ns1", constants.ProtocolHTTP)
+	assert.ErrorIs(err, errHTTPRouteGroupNotFound)
+	assert.Empty(routes)
+}
+
+func TestRoutesFromRulesWithSharedMatchName(t *testing.T) {
+	assert := tassert.New(t)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// Both HTTPRouteGroups define a match named route-1
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "group-a"},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{{Name: "route-1", PathRegex: "/a", Methods: []string{"GET"}}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "group-b"},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{
+					{Name: "route-1", PathRegex: "/b", Methods: []string{"GET", "POST"}},
+					{Name: "route-2", PathRegex: "/b", Methods: []string{"POST", "GET"}},
+				},
+			},
+		},
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		httpRouteGroups: httpRouteGroups,
+	})
+
+	routeB := trafficpolicy.HTTPRouteMatch{Path: "/b", PathMatchType: trafficpolicy.PathMatchExact, Methods: []string{"GET", "POST"}}
+
+	// The match of the HTTPRouteGroup referenced by the rule is used
+	routes, err := mc.routesFromRules([]access.TrafficTargetRule{
+		{Kind: "HTTPRouteGroup", Name: "group-b", Matches: []string{"route-1"}},
+	}, "ns1", constants.ProtocolHTTP)
+	assert.Nil(err)
+	assert.Equal([]trafficpolicy.HTTPRouteMatch{routeB}, routes)
+
+	// Identical route matches are only returned once
+	routes, err = mc.routesFromRules([]access.TrafficTargetRule{
+		{Kind: "HTTPRouteGroup", Name: "group-b", Matches: []string{"route-1", "route-1", "route-2"}},
+		{Kind: "HTTPRouteGroup", Name: "group-a", Matches: []string{"route-1"}},
+		{Kind: "HTTPRouteGroup", Name: "group-b", Matches: []string{"route-1"}},
+	}, "ns1", constants.ProtocolHTTP)
+	assert.Nil(err)
+	assert.Equal([]trafficpolicy.HTTPRouteMatch{
+		routeB,
+		{Path: "/a", PathMatchType: trafficpolicy.PathMatchExact, Methods: []string{"GET"}},
+	}, routes)
+}
+
 func TestGetHTTPPathsPerRoute(t *testing.T) {
 	assert := tassert.New(t)
 
@@ -2813,19 +3693,21 @@ func TestGetHTTPPathsPerRoute(t *testing.T) {
 				"HTTPRouteGroup/default/bookstore-service-routes": {
 					trafficpolicy.TrafficSpecMatchName(tests.BuyBooksMatchName): {
 						Path:          tests.BookstoreBuyPath,
-						PathMatchType: trafficpolicy.PathMatchRegex,
+						PathMatchType: trafficpolicy.PathMatchExact,
 						Methods:       []string{"GET"},
 						Headers: map[string]string{
 							"user-agent": tests.HTTPUserAgent,
 						},
+						HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 					},
 					trafficpolicy.TrafficSpecMatchName(tests.SellBooksMatchName): {
 						Path:          tests.BookstoreSellPath,
-						PathMatchType: trafficpolicy.PathMatchRegex,
+						PathMatchType: trafficpolicy.PathMatchExact,
 						Methods:       []string{"GET"},
 						Headers: map[string]string{
 							"user-agent": tests.HTTPUserAgent,
 						},
+						HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 					},
 				},
 			},
@@ -2860,12 +3742,12 @@ func TestGetHTTPPathsPerRoute(t *testing.T) {
 				"HTTPRouteGroup/default/bookstore-service-routes": {
 					trafficpolicy.TrafficSpecMatchName(tests.BuyBooksMatchName): {
 						Path:          tests.BookstoreBuyPath,
-						PathMatchType: trafficpolicy.PathMatchRegex,
+						PathMatchType: trafficpolicy.PathMatchExact,
 						Methods:       []string{"*"},
 					},
 					trafficpolicy.TrafficSpecMatchName(tests.SellBooksMatchName): {
 						Path:          tests.BookstoreSellPath,
-						PathMatchType: trafficpolicy.PathMatchRegex,
+						PathMatchType: trafficpolicy.PathMatchExact,
 						Methods:       []string{"*"},
 					},
 				},
@@ -2933,6 +3815,101 @@ func TestGetHTTPPathsPerRoute(t *testing.T) {
 						Headers: map[string]string{
 							"user-agent": tests.HTTPUserAgent,
 						},
+						HeaderMatchType: trafficpolicy.HeaderMatchRegex,
+					},
+				},
+			},
+		},
+		{
+			name: "HTTP routes with literal and regex paths",
+			trafficSpec: spec.HTTPRouteGroup{
+				TypeMeta: v1.TypeMeta{
+					APIVersion: "specs.smi-spec.io/v1alpha4",
+					Kind:       "HTTPRouteGroup",
+				},
+				ObjectMeta: v1.ObjectMeta{
+					Namespace: "default",
+					Name:      tests.RouteGroupName,
+				},
+
+				Spec: spec.HTTPRouteGroupSpec{
+					Matches: []spec.HTTPMatch{
+						{
+							Name:      "users",
+							PathRegex: "/api/v1/users",
+							Methods:   []string{"GET"},
+						},
+						{
+							Name:      "user",
+							PathRegex: "/api/v1/users/[0-9]+",
+							Methods:   []string{"GET"},
+						},
+						{
+							Name:      "version",
+							PathRegex: "/api/v1.0",
+							Methods:   []string{"GET"},
+						},
+					},
+				},
+			},
+			expectedHTTPPathsPerRoute: map[trafficpolicy.TrafficSpecName]map[trafficpolicy.TrafficSpecMatchName]trafficpolicy.HTTPRouteMatch{
+				"HTTPRouteGroup/default/bookstore-service-routes": {
+					"users": {
+						Path:          "/api/v1/users",
+						PathMatchType: trafficpolicy.PathMatchExact,
+						Methods:       []string{"GET"},
+					},
+					"user": {
+						Path:          "/api/v1/users/[0-9]+",
+						PathMatchType: trafficpolicy.PathMatchRegex,
+						Methods:       []string{"GET"},
+					},
+					"version": {
+						Path:          "/api/v1.0",
+						PathMatchType: trafficpolicy.PathMatchRegex,
+						Methods:       []string{"GET"},
+					},
+				},
+			},
+		},
+		{
+			name: "HTTP route with mixed-case methods",
+			trafficSpec: spec.HTTPRouteGroup{
+				TypeMeta: v1.TypeMeta{
+					APIVersion: "specs.smi-spec.io/v1alpha4",
+					Kind:       "HTTPRouteGroup",
+				},
+				ObjectMeta: v1.ObjectMeta{
+					Namespace: "default",
+					Name:      tests.RouteGroupName,
+				},
+
+				Spec: spec.HTTPRouteGroupSpec{
+					Matches: []spec.HTTPMatch{
+						{
+							Name:      tests.BuyBooksMatchName,
+							PathRegex: tests.BookstoreBuyPath,
+							Methods:   []string{"get", "Post", "PUT"},
+						},
+						{
+							Name:      tests.SellBooksMatchName,
+							PathRegex: tests.BookstoreSellPath,
+							Methods:   []string{"*"},
+						},
+					},
+				},
+			},
+			expectedHTTPPathsPerRoute: map[trafficpolicy.TrafficSpecName]map[trafficpolicy.TrafficSpecMatchName]trafficpolicy.HTTPRouteMatch{
+				"HTTPRouteGroup/default/bookstore-service-routes": {
+					trafficpolicy.TrafficSpecMatchName(tests.BuyBooksMatchName): {
+						Path:          tests.BookstoreBuyPath,
+						PathMatchType: trafficpolicy.PathMatchExact,
+						Methods:       []string{"GET", "POST", "PUT"},
+					},
+					trafficpolicy.TrafficSpecMatchName(tests.SellBooksMatchName): {
+						Path:          tests.BookstoreSellPath,
+						PathMatchType: trafficpolicy.PathMatchExact,
+						Methods:       []string{"*"},
 					},
 				},
 			},
@@ -2958,6 +3935,17 @@ func TestGetHTTPPathsPerRoute(t *testing.T) {
 	}
 }
 
+func TestIsLiteralPath(t *testing.T) {
+	assert := tassert.New(t)
+
+	assert.True(isLiteralPath("/get"))
+	assert.True(isLiteralPath("/api/v1/users"))
+	assert.False(isLiteralPath(".*"))
+	assert.False(isLiteralPath("/items/.*"))
+	assert.False(isLiteralPath("/api/v1.0"))
+	assert.False(isLiteralPath("/get|/post"))
+}
+
 func TestGetTrafficSpecName(t *testing.T) {
 	assert := tassert.New(t)
 
@@ -2965,3 +3953,4139 @@ func TestGetTrafficSpecName(t *testing.T) {
 	expected := trafficpolicy.TrafficSpecName(fmt.Sprintf("HTTPRouteGroup/%s/%s", tests.Namespace, tests.RouteGroupName))
 	assert.Equal(actual, expected)
 }
+
+func TestGlobalRateLimitFailOpen(t *testing.T) {
+	httpSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	tcpSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 3306, TargetPort: 3306, Protocol: "tcp"}
+
+	testCases := []struct {
+		name             string
+		failOpen         *bool
+		expectedFailOpen *bool
+	}{
+		{
+			name:             "fail-open is unset and defaults to fail-open",
+			failOpen:         nil,
+			expectedFailOpen: nil,
+		},
+		{
+			name:             "fail-open is enabled",
+			failOpen:         pointer.BoolPtr(true),
+			expectedFailOpen: pointer.BoolPtr(true),
+		},
+		{
+			name:             "fail-open is disabled",
+			failOpen:         pointer.BoolPtr(false),
+			expectedFailOpen: pointer.BoolPtr(false),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			rlsSpec := policyv1alpha1.RateLimitServiceSpec{Host: "foo.bar", Port: 8080}
+			upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns1"},
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						Host: httpSvc.FQDN(),
+						RateLimit: &policyv1alpha1.RateLimitSpec{
+							Global: &policyv1alpha1.GlobalRateLimitSpec{
+								HTTP: &policyv1alpha1.HTTPGlobalRateLimitSpec{
+									RateLimitService: rlsSpec,
+									FailOpen:         tc.failOpen,
+								},
+							},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns1"},
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						Host: tcpSvc.FQDN(),
+						RateLimit: &policyv1alpha1.RateLimitSpec{
+							Global: &policyv1alpha1.GlobalRateLimitSpec{
+								TCP: &policyv1alpha1.TCPGlobalRateLimitSpec{
+									RateLimitService: rlsSpec,
+									FailOpen:         tc.failOpen,
+								},
+							},
+						},
+					},
+				},
+			}
+
+			mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							EnablePermissiveTrafficPolicyMode: true,
+						},
+					},
+				},
+				upstreamTrafficSettings: upstreamTrafficSettings,
+			})
+
+			mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+			upstreamServices := []service.MeshService{httpSvc, tcpSvc}
+
+			// HTTP global rate limit is attached to the inbound HTTP traffic policy
+			routeConfigs := mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity(), upstreamServices)
+			assert.Len(routeConfigs[int(httpSvc.TargetPort)], 1)
+			httpPolicy := routeConfigs[int(httpSvc.TargetPort)][0]
+			assert.NotNil(httpPolicy.RateLimit)
+			assert.Equal(tc.expectedFailOpen, httpPolicy.RateLimit.Global.HTTP.FailOpen)
+
+			// TCP global rate limit is attached to the inbound TrafficMatch
+			var tcpMatch *trafficpolicy.TrafficMatch
+			for _, match := range mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, upstreamServices) {
+				if match.DestinationPort == int(tcpSvc.TargetPort) {
+					tcpMatch = match
+				}
+			}
+			assert.NotNil(tcpMatch)
+			assert.NotNil(tcpMatch.RateLimit)
+			assert.Equal(tc.expectedFailOpen, tcpMatch.RateLimit.Global.TCP.FailOpen)
+		})
+	}
+}
+
+func TestInboundWildcardVirtualHost(t *testing.T) {
+	// s1 and s2 share the same target port, so their policies are programmed on the same route configuration
+	upstreamServices := []service.MeshService{
+		{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"},
+		{Name: "s2", Namespace: "ns1", Port: 90, TargetPort: 8080, Protocol: "http"},
+		{Name: "s3", Namespace: "ns1", Port: 91, TargetPort: 9090, Protocol: "http"},
+	}
+
+	testCases := []struct {
+		name                    string
+		enableWildcardVhost     bool
+		expectedPoliciesPerPort map[int][]string
+	}{
+		{
+			name:                "wildcard virtual host is disabled by default",
+			enableWildcardVhost: false,
+			expectedPoliciesPerPort: map[int][]string{
+				8080: {"s1.ns1.svc.cluster.local", "s2.ns1.svc.cluster.local"},
+				9090: {"s3.ns1.svc.cluster.local"},
+			},
+		},
+		{
+			name:                "wildcard virtual host is enabled and ordered last",
+			enableWildcardVhost: true,
+			expectedPoliciesPerPort: map[int][]string{
+				8080: {"s1.ns1.svc.cluster.local", "s2.ns1.svc.cluster.local", "*"},
+				9090: {"s3.ns1.svc.cluster.local", "*"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							EnablePermissiveTrafficPolicyMode: true,
+							EnableInboundWildcardVirtualHost:  tc.enableWildcardVhost,
+						},
+					},
+				},
+			})
+
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity(), upstreamServices)
+			assert.Len(actual, len(tc.expectedPoliciesPerPort))
+
+			for port, expectedNames := range tc.expectedPoliciesPerPort {
+				var actualNames []string
+				for _, policy := range actual[port] {
+					actualNames = append(actualNames, policy.Name)
+				}
+				assert.Equal(expectedNames, actualNames)
+
+				if tc.enableWildcardVhost {
+					// Requests for unknown hosts are answered with a 404 instead of reaching any of the services
+					wildcardPolicy := actual[port][len(actual[port])-1]
+					assert.Equal([]string{constants.WildcardHostname}, wildcardPolicy.Hostnames)
+					assert.Len(wildcardPolicy.Rules, 1)
+					assert.Equal(&policyv1alpha1.DirectResponseSpec{StatusCode: http.StatusNotFound}, wildcardPolicy.Rules[0].Route.DirectResponse)
+					assert.Equal(0, wildcardPolicy.Rules[0].Route.WeightedClusters.Cardinality())
+				}
+			}
+		})
+	}
+}
+
+func TestBuildInboundPolicyWithTrustDomains(t *testing.T) {
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamServices := []service.MeshService{
+		{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"},
+		{Name: "s2", Namespace: "ns1", Port: 90, TargetPort: 9090, Protocol: "http"},
+	}
+	trafficTargets := []*access.TrafficTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "t1",
+				Namespace: "ns1",
+			},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{
+					Kind:      "ServiceAccount",
+					Name:      "sa1",
+					Namespace: "ns1",
+				},
+				Sources: []access.IdentityBindingSubject{
+					{
+						Kind:      "ServiceAccount",
+						Name:      "sa2",
+						Namespace: "ns2",
+					},
+					{
+						Kind:      "ServiceAccount",
+						Name:      "sa3",
+						Namespace: "ns3",
+					},
+				},
+				Rules: []access.TrafficTargetRule{{
+					Kind:    "HTTPRouteGroup",
+					Name:    "rule-1",
+					Matches: []string{"route-1"},
+				}},
+			},
+		},
+	}
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      "rule-1",
+			},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{
+					{
+						Name:      "route-1",
+						PathRegex: "/get",
+						Methods:   []string{"GET"},
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name           string
+		spiffeEnabled  bool
+		newTrustDomain string
+		trustDomains   []string
+	}{
+		{
+			name:         "single trust domain",
+			trustDomains: []string{"cluster.local"},
+		},
+		{
+			name:          "single trust domain with SPIFFE enabled",
+			spiffeEnabled: true,
+			trustDomains:  []string{"cluster.local"},
+		},
+		{
+			name:           "multiple trust domains",
+			newTrustDomain: "cluster.new",
+			trustDomains:   []string{"cluster.local", "cluster.new"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mrc1 := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain:   "cluster.local",
+					Intent:        v1alpha2.ActiveIntent,
+					SpiffeEnabled: tc.spiffeEnabled,
+				},
+			}
+
+			configClient := configFake.NewSimpleClientset([]runtime.Object{mrc1}...)
+			mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+			fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+			mrcClient.NewCertEvent(mrc1.Name)
+
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				trafficTargets:  trafficTargets,
+				httpRouteGroups: httpRouteGroups,
+			})
+			mc.certManager = fakeCertManager
+
+			if tc.newTrustDomain != "" {
+				mrc2 := &v1alpha2.MeshRootCertificate{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "osm-mesh-root-certificate-2",
+						Namespace: "osm-system",
+					},
+					Spec: v1alpha2.MeshRootCertificateSpec{
+						TrustDomain: tc.newTrustDomain,
+						Intent:      v1alpha2.ActiveIntent,
+					},
+				}
+				_, err := configClient.ConfigV1alpha2().MeshRootCertificates("osm-system").Create(context.Background(), mrc2, metav1.CreateOptions{})
+				assert.NoError(err)
+
+				mrcClient.NewCertEvent(mrc2.Name)
+				assert.Eventually(func() bool {
+					return fakeCertManager.GetIssuersInfo().AreDifferent()
+				}, 2*time.Second, 100*time.Millisecond)
+			}
+
+			expected := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices)
+			actual := mc.BuildInboundPolicyWithTrustDomains(upstreamIdentity, upstreamServices, tc.trustDomains, tc.spiffeEnabled)
+
+			assert.Len(actual, len(expected))
+			for port, expectedPolicies := range expected {
+				assert.ElementsMatch(expectedPolicies, actual[port])
+			}
+		})
+	}
+}
+
+func TestInboundRouteRequireClientCertificate(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	certRequiredSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	plaintextSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 90, TargetPort: 9090, Protocol: "http"}
+
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host: certRequiredSvc.FQDN(),
+				HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+					{
+						Path:                     constants.RegexMatchAll,
+						RequireClientCertificate: true,
+					},
+				},
+			},
+		},
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+				},
+			},
+		},
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+
+	actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity(),
+		[]service.MeshService{certRequiredSvc, plaintextSvc})
+
+	// The route for s1 only matches requests presenting a validated client certificate
+	assert.Len(actual[int(certRequiredSvc.TargetPort)], 1)
+	certRequiredRules := actual[int(certRequiredSvc.TargetPort)][0].Rules
+	assert.Len(certRequiredRules, 1)
+	assert.True(certRequiredRules[0].Route.RequireClientCertificate)
+
+	// The route for s2 has no client certificate requirement, so plaintext requests continue to match
+	assert.Len(actual[int(plaintextSvc.TargetPort)], 1)
+	plaintextRules := actual[int(plaintextSvc.TargetPort)][0].Rules
+	assert.Len(plaintextRules, 1)
+	assert.False(plaintextRules[0].Route.RequireClientCertificate)
+}
+
+func TestGetRouteStatPrefix(t *testing.T) {
+	svc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	getRoute := trafficpolicy.RouteWeightedClusters{
+		HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+			Path:            "/get",
+			PathMatchType:   trafficpolicy.PathMatchRegex,
+			Methods:         []string{"GET", "HEAD"},
+			Headers:         map[string]string{"user-agent": "foo", "x-version": "v1"},
+			HeaderMatchType: trafficpolicy.HeaderMatchRegex,
+		},
+	}
+	postRoute := trafficpolicy.RouteWeightedClusters{
+		HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+			Path:          "/post",
+			PathMatchType: trafficpolicy.PathMatchRegex,
+			Methods:       []string{"POST"},
+		},
+		RateLimit: &policyv1alpha1.HTTPPerRouteRateLimitSpec{
+			Local: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 10, Unit: "second"},
+		},
+	}
+
+	assert := tassert.New(t)
+
+	getPrefix := getRouteStatPrefix(svc, getRoute)
+	assert.Regexp(`^ns1_s1_80\.route_[0-9a-f]{8}\.rate_limited_false$`, getPrefix)
+
+	// The prefix is deterministic and does not depend on the order of methods
+	reordered := getRoute
+	reordered.HTTPRouteMatch.Methods = []string{"HEAD", "GET"}
+	for i := 0; i < 10; i++ {
+		assert.Equal(getPrefix, getRouteStatPrefix(svc, getRoute))
+		assert.Equal(getPrefix, getRouteStatPrefix(svc, reordered))
+	}
+
+	// Different routes on the same service have different prefixes
+	postPrefix := getRouteStatPrefix(svc, postRoute)
+	assert.Regexp(`^ns1_s1_80\.route_[0-9a-f]{8}\.rate_limited_true$`, postPrefix)
+	assert.NotEqual(getPrefix, postPrefix)
+
+	// The same route on different services have different prefixes
+	assert.NotEqual(getPrefix, getRouteStatPrefix(service.MeshService{Name: "s2", Namespace: "ns1", Port: 80}, getRoute))
+}
+
+func TestPerRouteRateLimitPrecedence(t *testing.T) {
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	trafficTargets := []*access.TrafficTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "t1",
+				Namespace: "ns1",
+			},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{
+					Kind:      "ServiceAccount",
+					Name:      "sa1",
+					Namespace: "ns1",
+				},
+				Sources: []access.IdentityBindingSubject{{
+					Kind:      "ServiceAccount",
+					Name:      "sa2",
+					Namespace: "ns2",
+				}},
+				Rules: []access.TrafficTargetRule{{
+					Kind:    "HTTPRouteGroup",
+					Name:    "rule-1",
+					Matches: []string{"route-get", "route-post"},
+				}},
+			},
+		},
+	}
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      "rule-1",
+			},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{
+					{
+						Name:      "route-get",
+						PathRegex: "/get",
+						Methods:   []string{"GET"},
+					},
+					{
+						Name:      "route-post",
+						PathRegex: "/post",
+						Methods:   []string{"POST"},
+					},
+				},
+			},
+		},
+	}
+
+	wildcardRateLimit := &policyv1alpha1.HTTPPerRouteRateLimitSpec{
+		Local: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 100, Unit: "minute"},
+	}
+	getRateLimit := &policyv1alpha1.HTTPPerRouteRateLimitSpec{
+		Local: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 10, Unit: "minute"},
+	}
+
+	testCases := []struct {
+		name       string
+		httpRoutes []policyv1alpha1.HTTPRouteSpec
+	}{
+		{
+			name: "wildcard path listed before the specific path",
+			httpRoutes: []policyv1alpha1.HTTPRouteSpec{
+				{Path: ".*", RateLimit: wildcardRateLimit},
+				{Path: "/get", RateLimit: getRateLimit},
+			},
+		},
+		{
+			name: "wildcard path listed after the specific path",
+			httpRoutes: []policyv1alpha1.HTTPRouteSpec{
+				{Path: "/get", RateLimit: getRateLimit},
+				{Path: ".*", RateLimit: wildcardRateLimit},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mrc := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain: "cluster.local",
+					Intent:      v1alpha2.ActiveIntent,
+				},
+			}
+
+			configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+			mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+			fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+			mrcClient.NewCertEvent(mrc.Name)
+
+			upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns1"},
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						Host:       upstreamSvc.FQDN(),
+						HTTPRoutes: tc.httpRoutes,
+					},
+				},
+			}
+
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				trafficTargets:          trafficTargets,
+				httpRouteGroups:         httpRouteGroups,
+				upstreamTrafficSettings: upstreamTrafficSettings,
+			})
+			mc.certManager = fakeCertManager
+
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+			assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+
+			rateLimitPerPath := make(map[string]*policyv1alpha1.HTTPPerRouteRateLimitSpec)
+			for _, rule := range actual[int(upstreamSvc.TargetPort)][0].Rules {
+				rateLimitPerPath[rule.Route.HTTPRouteMatch.Path] = rule.Route.RateLimit
+			}
+
+			// The specific path's rate limit wins over the wildcard for /get
+			assert.Equal(getRateLimit, rateLimitPerPath["/get"])
+			// Only the wildcard matches /post
+			assert.Equal(wildcardRateLimit, rateLimitPerPath["/post"])
+		})
+	}
+}
+
+func TestInboundProbePathRoutes(t *testing.T) {
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	httpSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	tcpSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 3306, TargetPort: 3306, Protocol: "tcp"}
+
+	testCases := []struct {
+		name               string
+		probePaths         []string
+		expectedProbePaths []string
+	}{
+		{
+			name:               "no probe paths configured",
+			probePaths:         nil,
+			expectedProbePaths: nil,
+		},
+		{
+			name:               "probe paths configured",
+			probePaths:         []string{"/healthz", "/ready"},
+			expectedProbePaths: []string{"/healthz", "/ready"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mrc := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain: "cluster.local",
+					Intent:      v1alpha2.ActiveIntent,
+				},
+			}
+
+			configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+			mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+			fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+			mrcClient.NewCertEvent(mrc.Name)
+
+			// No TrafficTarget allows access to the upstream, so only the probe paths are reachable
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							InboundProbePaths: tc.probePaths,
+						},
+					},
+				},
+			})
+			mc.certManager = fakeCertManager
+
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{httpSvc, tcpSvc})
+
+			// Probe routes are not programmed for TCP ports
+			assert.Len(actual, 1)
+			assert.Len(actual[int(httpSvc.TargetPort)], 1)
+
+			var actualProbePaths []string
+			for _, rule := range actual[int(httpSvc.TargetPort)][0].Rules {
+				actualProbePaths = append(actualProbePaths, rule.Route.HTTPRouteMatch.Path)
+				assert.Equal(trafficpolicy.PathMatchExact, rule.Route.HTTPRouteMatch.PathMatchType)
+				assert.Equal(mapset.NewSet(service.WeightedCluster{ClusterName: "ns1/s1|8080|local", Weight: 100}), rule.Route.WeightedClusters)
+				// Probe routes allow unauthenticated access
+				assert.Equal(mapset.NewSetWith(identity.WildcardPrincipal), rule.AllowedPrincipals)
+			}
+			assert.Equal(tc.expectedProbePaths, actualProbePaths)
+		})
+	}
+}
+
+func TestInboundSelfTraffic(t *testing.T) {
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	selfPrincipal := upstreamIdentity.AsPrincipal("cluster.local", false)
+	downstreamPrincipal := identity.K8sServiceAccount{Namespace: "ns2", Name: "sa2"}.AsPrincipal("cluster.local", false)
+
+	trafficTarget := &access.TrafficTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "t1",
+			Namespace: "ns1",
+		},
+		Spec: access.TrafficTargetSpec{
+			Destination: access.IdentityBindingSubject{
+				Kind:      "ServiceAccount",
+				Name:      "sa1",
+				Namespace: "ns1",
+			},
+			Sources: []access.IdentityBindingSubject{{
+				Kind:      "ServiceAccount",
+				Name:      "sa2",
+				Namespace: "ns2",
+			}},
+			Rules: []access.TrafficTargetRule{{
+				Kind:    "HTTPRouteGroup",
+				Name:    "rule-1",
+				Matches: []string{"route-1"},
+			}},
+		},
+	}
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      "rule-1",
+			},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{
+					{
+						Name:      "route-1",
+						PathRegex: "/get",
+						Methods:   []string{"GET"},
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name                      string
+		enableSelfTraffic         bool
+		trafficTargets            []*access.TrafficTarget
+		expectedPrincipalsPerPath map[string]mapset.Set
+	}{
+		{
+			name:              "self traffic is not allowed when disabled",
+			enableSelfTraffic: false,
+			trafficTargets:    []*access.TrafficTarget{trafficTarget},
+			expectedPrincipalsPerPath: map[string]mapset.Set{
+				"/get": mapset.NewSet(downstreamPrincipal),
+			},
+		},
+		{
+			name:              "self traffic is allowed on all paths when enabled",
+			enableSelfTraffic: true,
+			trafficTargets:    []*access.TrafficTarget{trafficTarget},
+			expectedPrincipalsPerPath: map[string]mapset.Set{
+				"/get":                  mapset.NewSet(downstreamPrincipal, selfPrincipal),
+				constants.RegexMatchAll: mapset.NewSet(selfPrincipal),
+			},
+		},
+		{
+			name:              "self traffic is allowed without any TrafficTarget when enabled",
+			enableSelfTraffic: true,
+			trafficTargets:    nil,
+			expectedPrincipalsPerPath: map[string]mapset.Set{
+				constants.RegexMatchAll: mapset.NewSet(selfPrincipal),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mrc := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain: "cluster.local",
+					Intent:      v1alpha2.ActiveIntent,
+				},
+			}
+
+			configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+			mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+			fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+			mrcClient.NewCertEvent(mrc.Name)
+
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							EnableSelfTraffic: tc.enableSelfTraffic,
+						},
+					},
+				},
+				trafficTargets:  tc.trafficTargets,
+				httpRouteGroups: httpRouteGroups,
+			})
+			mc.certManager = fakeCertManager
+
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+			assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+
+			rules := actual[int(upstreamSvc.TargetPort)][0].Rules
+			assert.Len(rules, len(tc.expectedPrincipalsPerPath))
+			for _, rule := range rules {
+				expectedPrincipals, ok := tc.expectedPrincipalsPerPath[rule.Route.HTTPRouteMatch.Path]
+				assert.True(ok, "unexpected route for path %s", rule.Route.HTTPRouteMatch.Path)
+				assert.True(expectedPrincipals.Equal(rule.AllowedPrincipals))
+			}
+			if tc.enableSelfTraffic {
+				// The wildcard route for self traffic is ordered last
+				assert.Equal(constants.RegexMatchAll, rules[len(rules)-1].Route.HTTPRouteMatch.Path)
+			}
+		})
+	}
+}
+
+func TestInboundPolicyOnServiceProtocolChange(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+				},
+			},
+		},
+	})
+
+	mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	// The protocol of the service port flips from http to tcp between two generations, e.g. due to an appProtocol change
+	generations := []struct {
+		svc                    service.MeshService
+		expectHTTPRouteConfigs bool
+	}{
+		{
+			svc:                    service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: constants.ProtocolHTTP},
+			expectHTTPRouteConfigs: true,
+		},
+		{
+			svc:                    service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: constants.ProtocolTCP},
+			expectHTTPRouteConfigs: false,
+		},
+	}
+
+	for _, gen := range generations {
+		upstreamServices := []service.MeshService{gen.svc}
+
+		routeConfigs := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices)
+		_, hasHTTPRouteConfig := routeConfigs[int(gen.svc.TargetPort)]
+		assert.Equal(gen.expectHTTPRouteConfigs, hasHTTPRouteConfig)
+
+		trafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, upstreamServices)
+		assert.Len(trafficMatches, 1)
+		assert.Equal(int(gen.svc.TargetPort), trafficMatches[0].DestinationPort)
+		assert.Equal(gen.svc.Protocol, trafficMatches[0].DestinationProtocol)
+		if gen.svc.Protocol == constants.ProtocolTCP {
+			// A TCP match routes directly to the local cluster instead of via an HTTP route config
+			assert.Equal(gen.svc.EnvoyLocalClusterName(), trafficMatches[0].Cluster)
+		}
+
+		clusterConfigs := mc.GetInboundMeshClusterConfigs(tests.BookstoreServiceIdentity, upstreamServices)
+		assert.Len(clusterConfigs, 1)
+		assert.Equal(gen.svc, clusterConfigs[0].Service)
+	}
+}
+
+func TestInboundRoutesRequireServerName(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockK8s := k8s.NewMockController(mockCtrl)
+	mc := MeshCatalog{Interface: kube.NewClient(mockK8s)}
+
+	// Both services share the same target port, and are distinguished by the SNI on the TLS handshake
+	tenantASvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	tenantBSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 90, TargetPort: 8080, Protocol: "http"}
+
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "tenant-a"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host:       tenantASvc.FQDN(),
+				ServerName: "tenant-a.example.com",
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "tenant-b"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host:       tenantBSvc.FQDN(),
+				ServerName: "tenant-b.example.com",
+			},
+		},
+	}
+
+	mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockK8s.EXPECT().ListUpstreamTrafficSettings().Return(upstreamTrafficSettings).AnyTimes()
+	mockK8s.EXPECT().ListTrafficSplits().Return(nil).AnyTimes()
+	meshConfig := v1alpha2.MeshConfig{
+		Spec: v1alpha2.MeshConfigSpec{
+			Traffic: v1alpha2.TrafficSpec{
+				EnablePermissiveTrafficPolicyMode: true,
+			},
+		},
+	}
+	mockK8s.EXPECT().GetMeshConfig().DoAndReturn(func() v1alpha2.MeshConfig { return meshConfig }).AnyTimes()
+
+	upstreamServices := []service.MeshService{tenantASvc, tenantBSvc}
+
+	// Each TrafficMatch only accepts connections negotiated with its required SNI
+	trafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, upstreamServices)
+	assert.Len(trafficMatches, 2)
+	assert.Equal(8080, trafficMatches[0].DestinationPort)
+	assert.Equal([]string{"tenant-a.example.com"}, trafficMatches[0].ServerNames)
+	assert.Equal("tenant-a.example.com", trafficMatches[0].RequiredServerName)
+	assert.Equal(8080, trafficMatches[1].DestinationPort)
+	assert.Equal([]string{"tenant-b.example.com"}, trafficMatches[1].ServerNames)
+	assert.Equal("tenant-b.example.com", trafficMatches[1].RequiredServerName)
+
+	// The policies on the shared port carry the SNI their routes apply to
+	actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity(), upstreamServices)
+	assert.Len(actual, 1)
+	policies := actual[8080]
+	assert.Len(policies, 2)
+
+	serverNameToClusters := make(map[string][]interface{})
+	for _, policy := range policies {
+		assert.Len(policy.Rules, 1)
+		serverNameToClusters[policy.ServerName] = policy.Rules[0].Route.WeightedClusters.ToSlice()
+	}
+	assert.Equal(map[string][]interface{}{
+		"tenant-a.example.com": {service.WeightedCluster{ClusterName: service.ClusterName(tenantASvc.EnvoyLocalClusterName()), Weight: constants.ClusterWeightAcceptAll}},
+		"tenant-b.example.com": {service.WeightedCluster{ClusterName: service.ClusterName(tenantBSvc.EnvoyLocalClusterName()), Weight: constants.ClusterWeightAcceptAll}},
+	}, serverNameToClusters)
+
+	// Each SNI gets its own wildcard virtual host, so that it is programmed on the route configuration of that SNI
+	meshConfig.Spec.Traffic.EnableInboundWildcardVirtualHost = true
+	actual = mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity(), upstreamServices)
+	policies = actual[8080]
+	assert.Len(policies, 4)
+	assert.Equal(constants.WildcardHostname, policies[2].Name)
+	assert.Equal("tenant-a.example.com", policies[2].ServerName)
+	assert.Equal(constants.WildcardHostname, policies[3].Name)
+	assert.Equal("tenant-b.example.com", policies[3].ServerName)
+}
+
+func TestGetInboundMeshClusterConfigsDedupesSharedBackend(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	newSplit := func(apex string) *split.TrafficSplit {
+		return &split.TrafficSplit{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: apex},
+			Spec: split.TrafficSplitSpec{
+				Service:  apex,
+				Backends: []split.TrafficSplitBackend{{Service: "s1", Weight: 100}},
+			},
+		}
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		trafficSplits: []*split.TrafficSplit{newSplit("apex-a"), newSplit("apex-b")},
+	})
+
+	// The backend exposes two ports that map to the same target port, and is shared by two apex services
+	upstreamServices := []service.MeshService{
+		{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"},
+		{Name: "s1", Namespace: "ns1", Port: 81, TargetPort: 8080, Protocol: "http"},
+	}
+
+	clusterConfigs := mc.GetInboundMeshClusterConfigs(tests.BookstoreServiceIdentity, upstreamServices)
+
+	var clusterNames []string
+	for _, config := range clusterConfigs {
+		clusterNames = append(clusterNames, config.Name)
+	}
+	assert.Equal([]string{
+		"ns1/apex-a|8080|local",
+		"ns1/apex-b|8080|local",
+		"ns1/s1|8080|local",
+	}, clusterNames)
+}
+
+func TestGetInboundMeshTrafficMatchesWithTCPLocalRateLimit(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	dbSvc := service.MeshService{Name: "db", Namespace: "ns1", Port: 5432, TargetPort: 5432, Protocol: "tcp"}
+	otherSvc := service.MeshService{Name: "cache", Namespace: "ns1", Port: 6379, TargetPort: 6379, Protocol: "tcp"}
+
+	tcpLocalRateLimit := &policyv1alpha1.TCPLocalRateLimitSpec{
+		Connections: 10,
+		Unit:        "second",
+		Burst:       5,
+	}
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "db"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host: dbSvc.FQDN(),
+				RateLimit: &policyv1alpha1.RateLimitSpec{
+					Local: &policyv1alpha1.LocalRateLimitSpec{
+						TCP: tcpLocalRateLimit,
+					},
+				},
+			},
+		},
+	}
+
+	mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+
+	mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	trafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, []service.MeshService{dbSvc, otherSvc})
+	assert.Len(trafficMatches, 2)
+
+	// The local connection rate limit is attached to the TrafficMatch of the rate limited service only
+	assert.Equal(dbSvc.InboundTrafficMatchName(), trafficMatches[0].Name)
+	assert.NotNil(trafficMatches[0].RateLimit)
+	assert.Equal(tcpLocalRateLimit, trafficMatches[0].RateLimit.Local.TCP)
+	assert.Nil(trafficMatches[0].RateLimit.Global)
+
+	assert.Equal(otherSvc.InboundTrafficMatchName(), trafficMatches[1].Name)
+	assert.Nil(trafficMatches[1].RateLimit)
+}
+
+func TestGetInboundMeshTrafficMatchesWithTCPGlobalRateLimitDescriptors(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	dbSvc := service.MeshService{Name: "db", Namespace: "ns1", Port: 5432, TargetPort: 5432, Protocol: "tcp"}
+
+	tcpGlobalRateLimit := &policyv1alpha1.TCPGlobalRateLimitSpec{
+		RateLimitService: policyv1alpha1.RateLimitServiceSpec{
+			Host: "ratelimiter.ns1.svc.cluster.local",
+			Port: 8081,
+		},
+		Domain: "db",
+		Descriptors: []policyv1alpha1.TCPRateLimitDescriptor{
+			{
+				Entries: []policyv1alpha1.TCPRateLimitDescriptorEntry{
+					{Key: "service", Value: "db"},
+					{Key: "tier", Value: "storage"},
+				},
+			},
+		},
+	}
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "db"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host: dbSvc.FQDN(),
+				RateLimit: &policyv1alpha1.RateLimitSpec{
+					Global: &policyv1alpha1.GlobalRateLimitSpec{
+						TCP: tcpGlobalRateLimit,
+					},
+				},
+			},
+		},
+	}
+
+	mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+
+	mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	trafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, []service.MeshService{dbSvc})
+	assert.Len(trafficMatches, 1)
+
+	// The global connection rate limit descriptors are attached to the TrafficMatch of the TCP service
+	assert.NotNil(trafficMatches[0].RateLimit)
+	assert.NotNil(trafficMatches[0].RateLimit.Global)
+	assert.Equal(tcpGlobalRateLimit.Descriptors, trafficMatches[0].RateLimit.Global.TCP.Descriptors)
+	assert.Nil(trafficMatches[0].RateLimit.Local)
+}
+
+func TestGetInboundMeshTrafficMatchesWithTCPIdleTimeout(t *testing.T) {
+	oneHour := time.Hour
+	disabled := time.Duration(0)
+
+	testCases := []struct {
+		name                string
+		idleTimeout         *metav1.Duration
+		expectedIdleTimeout *time.Duration
+	}{
+		{
+			name:                "idle timeout is set",
+			idleTimeout:         &metav1.Duration{Duration: time.Hour},
+			expectedIdleTimeout: &oneHour,
+		},
+		{
+			name:                "idle timeout of 0 disables the idle timeout",
+			idleTimeout:         &metav1.Duration{Duration: 0},
+			expectedIdleTimeout: &disabled,
+		},
+		{
+			name:                "idle timeout is not set",
+			idleTimeout:         nil,
+			expectedIdleTimeout: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			dbSvc := service.MeshService{Name: "db", Namespace: "ns1", Port: 5432, TargetPort: 5432, Protocol: "tcp"}
+			otherSvc := service.MeshService{Name: "cache", Namespace: "ns1", Port: 6379, TargetPort: 6379, Protocol: "tcp"}
+
+			upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "db"},
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						Host:           dbSvc.FQDN(),
+						TCPIdleTimeout: tc.idleTimeout,
+					},
+				},
+			}
+
+			mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				upstreamTrafficSettings: upstreamTrafficSettings,
+			})
+
+			mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+			trafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, []service.MeshService{dbSvc, otherSvc})
+			assert.Len(trafficMatches, 2)
+
+			// The idle timeout is set on the TrafficMatch of the service the UpstreamTrafficSetting applies to only
+			assert.Equal(dbSvc.InboundTrafficMatchName(), trafficMatches[0].Name)
+			assert.Equal(tc.expectedIdleTimeout, trafficMatches[0].IdleTimeout)
+
+			assert.Equal(otherSvc.InboundTrafficMatchName(), trafficMatches[1].Name)
+			assert.Nil(trafficMatches[1].IdleTimeout)
+		})
+	}
+}
+
+func TestGetInboundMeshTrafficMatchesWithAllowedSourceRanges(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tcpSvc := service.MeshService{Name: "db", Namespace: "ns1", Port: 5432, TargetPort: 5432, Protocol: "tcp"}
+	httpSvc := service.MeshService{Name: "db", Namespace: "ns1", Port: 8080, TargetPort: 8080, Protocol: "http"}
+
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "db"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host:                tcpSvc.FQDN(),
+				AllowedSourceRanges: []string{"10.0.0.0/8"},
+			},
+		},
+	}
+
+	mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+
+	mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	trafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, []service.MeshService{tcpSvc, httpSvc})
+	assert.Len(trafficMatches, 2)
+
+	// The source ranges are enforced by the network RBAC of TCP services, and by the inbound routes of HTTP services
+	assert.Equal(tcpSvc.InboundTrafficMatchName(), trafficMatches[0].Name)
+	assert.Equal([]string{"10.0.0.0/8"}, trafficMatches[0].AllowedSourceRanges)
+
+	assert.Equal(httpSvc.InboundTrafficMatchName(), trafficMatches[1].Name)
+	assert.Nil(trafficMatches[1].AllowedSourceRanges)
+}
+
+func TestGetInboundMeshTrafficMatchesWithInvalidAllowedSourceRanges(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tcpSvc := service.MeshService{Name: "db", Namespace: "ns1", Port: 5432, TargetPort: 5432, Protocol: "tcp"}
+
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "db"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host:                tcpSvc.FQDN(),
+				AllowedSourceRanges: []string{"10.0.0.0/8", "not-a-cidr"},
+			},
+		},
+	}
+
+	mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+
+	mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	// The invalid UpstreamTrafficSetting is ignored instead of resulting in a filter chain rejected by LDS
+	trafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, []service.MeshService{tcpSvc})
+	assert.Len(trafficMatches, 1)
+	assert.Equal(tcpSvc.InboundTrafficMatchName(), trafficMatches[0].Name)
+	assert.Nil(trafficMatches[0].AllowedSourceRanges)
+}
+
+func TestGetInboundMeshTrafficMatchesWithAdditionalServerNames(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	dbSvc := service.MeshService{Name: "mysql", Subdomain: "mysql-0", Namespace: "ns1", Port: 3306, TargetPort: 3306, Protocol: "tcp"}
+
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "mysql"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host: dbSvc.FQDN(),
+				// The default server name is not duplicated
+				AdditionalServerNames: []string{"db.example.com", dbSvc.ServerName()},
+			},
+		},
+	}
+
+	mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+
+	mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	trafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, []service.MeshService{dbSvc})
+	assert.Len(trafficMatches, 1)
+	assert.Equal([]string{"mysql-0.mysql.ns1.svc.cluster.local", "db.example.com"}, trafficMatches[0].ServerNames)
+	assert.Empty(trafficMatches[0].RequiredServerName)
+}
+
+func TestGetInboundMeshTrafficMatchesForHeadlessService(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mysqlSvc := service.MeshService{Name: "mysql", Namespace: "ns1", Port: 3306, TargetPort: 3306, Protocol: "tcp"}
+
+	mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{})
+
+	mockK8s.EXPECT().GetService("mysql", "ns1").Return(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "ns1"},
+		Spec:       corev1.ServiceSpec{ClusterIP: corev1.ClusterIPNone},
+	}).AnyTimes()
+	mockK8s.EXPECT().GetEndpoints("mysql", "ns1").Return(&corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "ns1"},
+		Subsets: []corev1.EndpointSubset{
+			{
+				Addresses: []corev1.EndpointAddress{
+					{IP: "10.0.0.1", Hostname: "mysql-0"},
+					{IP: "10.0.0.2", Hostname: "mysql-1"},
+					{IP: "10.0.0.3", Hostname: "mysql-2"},
+				},
+				Ports: []corev1.EndpointPort{{Port: 3306}},
+			},
+		},
+	}, nil).AnyTimes()
+
+	trafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, []service.MeshService{mysqlSvc})
+	assert.Len(trafficMatches, 1)
+
+	// The stable DNS name of each pod is accepted in addition to the service's
+	assert.Equal([]string{
+		"mysql.ns1.svc.cluster.local",
+		"mysql-0.mysql.ns1.svc.cluster.local",
+		"mysql-1.mysql.ns1.svc.cluster.local",
+		"mysql-2.mysql.ns1.svc.cluster.local",
+	}, trafficMatches[0].ServerNames)
+	assert.Equal("ns1/mysql|3306|local", trafficMatches[0].Cluster)
+
+	// A single local cluster is built for the service
+	clusterConfigs := mc.GetInboundMeshClusterConfigs(tests.BookstoreServiceIdentity, []service.MeshService{mysqlSvc})
+	assert.Len(clusterConfigs, 1)
+	assert.Equal("ns1/mysql|3306|local", clusterConfigs[0].Name)
+}
+
+func TestGetServerNamesForService(t *testing.T) {
+	webSvc := service.MeshService{Name: "web", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	headlessSvc := service.MeshService{Name: "mysql", Namespace: "ns1", Port: 3306, TargetPort: 3306, Protocol: "http"}
+
+	testCases := []struct {
+		name                    string
+		svc                     service.MeshService
+		upstreamTrafficSettings []*policyv1alpha1.UpstreamTrafficSetting
+		expectedServerNames     []string
+	}{
+		{
+			name:                "service",
+			svc:                 webSvc,
+			expectedServerNames: []string{"web.ns1.svc.cluster.local"},
+		},
+		{
+			name: "headless service",
+			svc:  headlessSvc,
+			expectedServerNames: []string{
+				"mysql.ns1.svc.cluster.local",
+				"mysql-0.mysql.ns1.svc.cluster.local",
+				"mysql-1.mysql.ns1.svc.cluster.local",
+			},
+		},
+		{
+			name: "required and additional server names",
+			svc:  headlessSvc,
+			upstreamTrafficSettings: []*policyv1alpha1.UpstreamTrafficSetting{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "mysql"},
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						Host:                  headlessSvc.FQDN(),
+						ServerName:            "mysql.example.com",
+						AdditionalServerNames: []string{"db.example.com", "mysql.example.com"},
+					},
+				},
+			},
+			expectedServerNames: []string{"mysql.example.com", "db.example.com"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				upstreamTrafficSettings: tc.upstreamTrafficSettings,
+			})
+
+			mockK8s.EXPECT().GetService("web", "ns1").Return(&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns1"},
+				Spec:       corev1.ServiceSpec{ClusterIP: "10.0.1.1"},
+			}).AnyTimes()
+			mockK8s.EXPECT().GetService("mysql", "ns1").Return(&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "ns1"},
+				Spec:       corev1.ServiceSpec{ClusterIP: corev1.ClusterIPNone},
+			}).AnyTimes()
+			mockK8s.EXPECT().GetEndpoints("mysql", "ns1").Return(&corev1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "ns1"},
+				Subsets: []corev1.EndpointSubset{
+					{
+						Addresses: []corev1.EndpointAddress{
+							{IP: "10.0.0.1", Hostname: "mysql-0"},
+							{IP: "10.0.0.2", Hostname: "mysql-1"},
+						},
+						Ports: []corev1.EndpointPort{{Port: 3306}},
+					},
+				},
+			}, nil).AnyTimes()
+
+			serverNames := mc.GetServerNamesForService(tc.svc)
+			assert.Equal(tc.expectedServerNames, serverNames)
+
+			// The server names are the ones accepted by the traffic match of the service
+			trafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, []service.MeshService{tc.svc})
+			assert.Len(trafficMatches, 1)
+			assert.Equal(trafficMatches[0].ServerNames, serverNames)
+		})
+	}
+}
+
+func TestGetInboundMeshTrafficMatchesForTLSPassthroughService(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tlsSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 443, TargetPort: 8443, Protocol: "tls-passthrough"}
+
+	mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{})
+
+	mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	trafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, []service.MeshService{tlsSvc})
+	assert.Equal([]*trafficpolicy.TrafficMatch{
+		{
+			Name:                "inbound_ns1/s1_8443_tls-passthrough",
+			DestinationPort:     8443,
+			DestinationProtocol: "tls-passthrough",
+			ServerNames:         []string{"s1.ns1.svc.cluster.local"},
+			Cluster:             "ns1/s1|8443|local",
+		},
+	}, trafficMatches)
+
+	// The local cluster is built for the service
+	clusterConfigs := mc.GetInboundMeshClusterConfigs(tests.BookstoreServiceIdentity, []service.MeshService{tlsSvc})
+	assert.Len(clusterConfigs, 1)
+	assert.Equal("ns1/s1|8443|local", clusterConfigs[0].Name)
+	assert.Equal(uint32(8443), clusterConfigs[0].Port)
+	assert.Empty(clusterConfigs[0].UpstreamProtocol)
+
+	// No HTTP routes are built for the service
+	assert.Empty(mc.GetInboundMeshHTTPRouteConfigsPerPort(tests.BookstoreServiceIdentity, []service.MeshService{tlsSvc}))
+}
+
+func TestInboundPolicyWithConflictingPortProtocols(t *testing.T) {
+	httpSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	tcpSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 81, TargetPort: 8080, Protocol: "tcp"}
+	otherSvc := service.MeshService{Name: "s3", Namespace: "ns1", Port: 90, TargetPort: 9090, Protocol: "tcp"}
+	grpcSvc := service.MeshService{Name: "s4", Namespace: "ns1", Port: 82, TargetPort: 8080, Protocol: "grpc"}
+
+	testCases := []struct {
+		name             string
+		upstreamServices []service.MeshService
+	}{
+		{
+			name:             "http declared before tcp",
+			upstreamServices: []service.MeshService{httpSvc, tcpSvc, otherSvc},
+		},
+		{
+			name:             "tcp declared before http",
+			upstreamServices: []service.MeshService{tcpSvc, otherSvc, httpSvc},
+		},
+		{
+			name:             "grpc declared before http",
+			upstreamServices: []service.MeshService{grpcSvc, tcpSvc, otherSvc, httpSvc},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							EnablePermissiveTrafficPolicyMode: true,
+						},
+					},
+				},
+			})
+
+			mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+			// The http protocol takes precedence over tcp for port 8080 regardless of the order of the services
+			var trafficMatchProtocols []string
+			for _, trafficMatch := range mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, tc.upstreamServices) {
+				trafficMatchProtocols = append(trafficMatchProtocols, fmt.Sprintf("%d/%s", trafficMatch.DestinationPort, trafficMatch.DestinationProtocol))
+			}
+			assert.ElementsMatch([]string{"8080/http", "9090/tcp"}, trafficMatchProtocols)
+
+			var clusterServices []service.MeshService
+			for _, clusterConfig := range mc.GetInboundMeshClusterConfigs(tests.BookstoreServiceIdentity, tc.upstreamServices) {
+				clusterServices = append(clusterServices, clusterConfig.Service)
+			}
+			assert.ElementsMatch([]service.MeshService{httpSvc, otherSvc}, clusterServices)
+
+			// Routes are only built for the service whose protocol was resolved for port 8080
+			var routeHosts []string
+			for _, policy := range mc.GetInboundMeshHTTPRouteConfigsPerPort(tests.BookstoreServiceIdentity, tc.upstreamServices)[8080] {
+				routeHosts = append(routeHosts, policy.Name)
+			}
+			assert.Equal([]string{httpSvc.FQDN()}, routeHosts)
+		})
+	}
+}
+
+func TestResolvePortProtocolConflicts(t *testing.T) {
+	assert := tassert.New(t)
+
+	grpcSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "grpc"}
+	serverFirstSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 81, TargetPort: 8080, Protocol: "tcp-server-first"}
+	unknownSvc := service.MeshService{Name: "s3", Namespace: "ns1", Port: 82, TargetPort: 8080, Protocol: "udp"}
+	tcpSvc := service.MeshService{Name: "s4", Namespace: "ns1", Port: 83, TargetPort: 8080, Protocol: "tcp"}
+	tlsSvc := service.MeshService{Name: "s5", Namespace: "ns1", Port: 84, TargetPort: 8080, Protocol: "tls-passthrough"}
+
+	assert.Equal([]service.MeshService{grpcSvc}, resolvePortProtocolConflicts([]service.MeshService{unknownSvc, serverFirstSvc, tcpSvc, grpcSvc}))
+	assert.Equal([]service.MeshService{tcpSvc}, resolvePortProtocolConflicts([]service.MeshService{unknownSvc, serverFirstSvc, tcpSvc}))
+	assert.Equal([]service.MeshService{serverFirstSvc}, resolvePortProtocolConflicts([]service.MeshService{unknownSvc, serverFirstSvc}))
+	assert.Equal([]service.MeshService{serverFirstSvc}, resolvePortProtocolConflicts([]service.MeshService{tlsSvc, serverFirstSvc}))
+	assert.Equal([]service.MeshService{tlsSvc}, resolvePortProtocolConflicts([]service.MeshService{unknownSvc, tlsSvc}))
+	assert.Nil(resolvePortProtocolConflicts(nil))
+}
+
+func TestInboundPolicyWithoutBackedTrafficSplitApex(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// The proxy only backs s2, which is not a backend of the split for the s1-apex service
+	backedSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 90, TargetPort: 90, Protocol: "http"}
+	trafficSplits := []*split.TrafficSplit{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "split1"},
+			Spec: split.TrafficSplitSpec{
+				Service: "s1-apex",
+				Backends: []split.TrafficSplitBackend{
+					{Service: "s1", Weight: 50},
+					{Service: "s3", Weight: 50},
+				},
+			},
+		},
+	}
+
+	mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+				},
+			},
+		},
+		trafficSplits: trafficSplits,
+	})
+
+	mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	upstreamServices := []service.MeshService{backedSvc}
+
+	routeConfigs := mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa2"}.ToServiceIdentity(), upstreamServices)
+	assert.Len(routeConfigs, 1)
+	assert.Len(routeConfigs[int(backedSvc.TargetPort)], 1)
+	assert.Equal(backedSvc.FQDN(), routeConfigs[int(backedSvc.TargetPort)][0].Name)
+
+	var clusterNames []string
+	for _, config := range mc.GetInboundMeshClusterConfigs(tests.BookstoreServiceIdentity, upstreamServices) {
+		clusterNames = append(clusterNames, config.Name)
+	}
+	assert.Equal([]string{backedSvc.EnvoyLocalClusterName()}, clusterNames)
+
+	trafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, upstreamServices)
+	assert.Len(trafficMatches, 1)
+	assert.Equal(backedSvc.InboundTrafficMatchName(), trafficMatches[0].Name)
+}
+
+func TestInboundPolicyWithFQDNHostnameVariants(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+					HostnameVariants:                  v1alpha2.HostnameVariantsFQDN,
+				},
+			},
+		},
+	})
+
+	actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity(),
+		[]service.MeshService{upstreamSvc})
+
+	// Only the FQDN and FQDN:port hostnames are programmed
+	assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+	assert.Equal([]string{"s1.ns1.svc.cluster.local", "s1.ns1.svc.cluster.local:80"}, actual[int(upstreamSvc.TargetPort)][0].Hostnames)
+}
+
+func TestInboundPolicyWithPortSuffixedHostnamesDisabled(t *testing.T) {
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	testCases := []struct {
+		name                         string
+		disablePortSuffixedHostnames bool
+		expectedHostnames            []string
+	}{
+		{
+			name:                         "bare and port-suffixed hostnames by default",
+			disablePortSuffixedHostnames: false,
+			expectedHostnames: []string{
+				"s1",
+				"s1:80",
+				"s1.ns1",
+				"s1.ns1:80",
+				"s1.ns1.svc",
+				"s1.ns1.svc:80",
+				"s1.ns1.svc.cluster",
+				"s1.ns1.svc.cluster:80",
+				"s1.ns1.svc.cluster.local",
+				"s1.ns1.svc.cluster.local:80",
+			},
+		},
+		{
+			name:                         "only bare hostnames when port-suffixed hostnames are disabled",
+			disablePortSuffixedHostnames: true,
+			expectedHostnames: []string{
+				"s1",
+				"s1.ns1",
+				"s1.ns1.svc",
+				"s1.ns1.svc.cluster",
+				"s1.ns1.svc.cluster.local",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							EnablePermissiveTrafficPolicyMode: true,
+							DisablePortSuffixedHostnames:      tc.disablePortSuffixedHostnames,
+						},
+					},
+				},
+			})
+
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity(),
+				[]service.MeshService{upstreamSvc})
+
+			assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+			assert.ElementsMatch(tc.expectedHostnames, actual[int(upstreamSvc.TargetPort)][0].Hostnames)
+		})
+	}
+}
+
+func TestInboundPolicyWithExcludedPorts(t *testing.T) {
+	webSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	metricsSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 9090, TargetPort: 9090, Protocol: "http"}
+
+	testCases := []struct {
+		name       string
+		exclusions []v1alpha2.PortExclusionSpec
+	}{
+		{
+			name:       "port excluded for the service",
+			exclusions: []v1alpha2.PortExclusionSpec{{Namespace: "ns1", Service: "s1", Ports: []uint16{9090}}},
+		},
+		{
+			name:       "port excluded for the namespace",
+			exclusions: []v1alpha2.PortExclusionSpec{{Namespace: "ns1", Ports: []uint16{9090}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							EnablePermissiveTrafficPolicyMode: true,
+							InboundPolicyPortExclusions:       tc.exclusions,
+						},
+					},
+				},
+			})
+
+			mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+			upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+			upstreamServices := []service.MeshService{webSvc, metricsSvc}
+
+			// Only the service port that is not excluded produces a cluster, traffic match and route config
+			clusterConfigs := mc.GetInboundMeshClusterConfigs(upstreamIdentity, upstreamServices)
+			assert.Len(clusterConfigs, 1)
+			assert.Equal("ns1/s1|8080|local", clusterConfigs[0].Name)
+
+			trafficMatches := mc.GetInboundMeshTrafficMatches(upstreamIdentity, upstreamServices)
+			assert.Len(trafficMatches, 1)
+			assert.Equal(webSvc.InboundTrafficMatchName(), trafficMatches[0].Name)
+
+			routeConfigs := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices)
+			assert.Len(routeConfigs, 1)
+			assert.Contains(routeConfigs, int(webSvc.TargetPort))
+			assert.NotContains(routeConfigs, int(metricsSvc.TargetPort))
+		})
+	}
+}
+
+func TestInboundPolicyWithClusterDomain(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+					ClusterDomain:                     "cluster.internal",
+				},
+			},
+		},
+	})
+
+	actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity(),
+		[]service.MeshService{upstreamSvc})
+
+	assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+	assert.ElementsMatch([]string{
+		"s1",
+		"s1:80",
+		"s1.ns1",
+		"s1.ns1:80",
+		"s1.ns1.svc",
+		"s1.ns1.svc:80",
+		"s1.ns1.svc.cluster",
+		"s1.ns1.svc.cluster:80",
+		"s1.ns1.svc.cluster.internal",
+		"s1.ns1.svc.cluster.internal:80",
+	}, actual[int(upstreamSvc.TargetPort)][0].Hostnames)
+}
+
+func TestInboundPolicyWithSMIEnforcedInPermissiveMode(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	sensitiveSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	otherSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 90, TargetPort: 9090, Protocol: "http"}
+	downstreamPrincipal := identity.K8sServiceAccount{Namespace: "ns2", Name: "sa2"}.AsPrincipal("cluster.local", false)
+
+	trafficTargets := []*access.TrafficTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "t1", Namespace: "ns1"},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+				Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "sa2", Namespace: "ns2"}},
+				Rules:       []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "rule-1", Matches: []string{"route-1"}}},
+			},
+		},
+	}
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rule-1"},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{{Name: "route-1", PathRegex: "/get", Methods: []string{"GET"}}},
+			},
+		},
+	}
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "s1"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host:       sensitiveSvc.FQDN(),
+				EnforceSMI: true,
+			},
+		},
+	}
+
+	mrc := &v1alpha2.MeshRootCertificate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "osm-mesh-root-certificate",
+			Namespace: "osm-system",
+		},
+		Spec: v1alpha2.MeshRootCertificateSpec{
+			TrustDomain: "cluster.local",
+			Intent:      v1alpha2.ActiveIntent,
+		},
+	}
+
+	configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+	mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+	fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+	mrcClient.NewCertEvent(mrc.Name)
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+				},
+			},
+		},
+		trafficTargets:          trafficTargets,
+		httpRouteGroups:         httpRouteGroups,
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+	mc.certManager = fakeCertManager
+
+	actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{sensitiveSvc, otherSvc})
+
+	// The opted-in service only allows the routes and downstreams specified by the SMI TrafficTarget
+	assert.Len(actual[int(sensitiveSvc.TargetPort)], 1)
+	sensitiveRules := actual[int(sensitiveSvc.TargetPort)][0].Rules
+	assert.Len(sensitiveRules, 1)
+	assert.Equal("/get", sensitiveRules[0].Route.HTTPRouteMatch.Path)
+	assert.Equal([]string{"GET"}, sensitiveRules[0].Route.HTTPRouteMatch.Methods)
+	assert.True(mapset.NewSet(downstreamPrincipal).Equal(sensitiveRules[0].AllowedPrincipals))
+
+	// Other services remain permissive
+	assert.Len(actual[int(otherSvc.TargetPort)], 1)
+	otherRules := actual[int(otherSvc.TargetPort)][0].Rules
+	assert.Len(otherRules, 1)
+	assert.Equal(trafficpolicy.WildCardRouteMatch, otherRules[0].Route.HTTPRouteMatch)
+	assert.True(mapset.NewSet(identity.WildcardPrincipal).Equal(otherRules[0].AllowedPrincipals))
+}
+
+func TestGetInboundMeshClusterConfigsIsDeterministic(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{})
+
+	s1 := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	s2 := service.MeshService{Name: "s2", Namespace: "ns1", Port: 90, TargetPort: 9090, Protocol: "http"}
+	s3 := service.MeshService{Name: "s3", Namespace: "ns1", Port: 70, TargetPort: 7070, Protocol: "tcp"}
+
+	expected := mc.GetInboundMeshClusterConfigs(tests.BookstoreServiceIdentity, []service.MeshService{s1, s2, s3})
+	assert.Equal(expected, mc.GetInboundMeshClusterConfigs(tests.BookstoreServiceIdentity, []service.MeshService{s1, s2, s3}))
+	assert.Equal(expected, mc.GetInboundMeshClusterConfigs(tests.BookstoreServiceIdentity, []service.MeshService{s3, s1, s2}))
+	assert.Equal(expected, mc.GetInboundMeshClusterConfigs(tests.BookstoreServiceIdentity, []service.MeshService{s2, s3, s1}))
+
+	var clusterNames []string
+	for _, config := range expected {
+		clusterNames = append(clusterNames, config.Name)
+	}
+	assert.Equal([]string{"ns1/s1|8080|local", "ns1/s2|9090|local", "ns1/s3|7070|local"}, clusterNames)
+}
+
+func TestGetInboundMeshClusterConfigsLocalClusterAddress(t *testing.T) {
+	testCases := []struct {
+		name                string
+		localClusterAddress string
+		expectedAddress     string
+	}{
+		{
+			name:                "default local cluster address",
+			localClusterAddress: "",
+			expectedAddress:     constants.LocalhostIPAddress,
+		},
+		{
+			name:                "custom IP local cluster address",
+			localClusterAddress: "10.0.0.5",
+			expectedAddress:     "10.0.0.5",
+		},
+		{
+			name:                "unix domain socket local cluster address",
+			localClusterAddress: "unix:///var/run/app.sock",
+			expectedAddress:     "unix:///var/run/app.sock",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Sidecar: v1alpha2.SidecarSpec{
+							LocalClusterAddress: tc.localClusterAddress,
+						},
+					},
+				},
+			})
+
+			svc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+			actual := mc.GetInboundMeshClusterConfigs(tests.BookstoreServiceIdentity, []service.MeshService{svc})
+			assert.Len(actual, 1)
+			assert.Equal(tc.expectedAddress, actual[0].Address)
+		})
+	}
+}
+
+func TestInboundRoutesWithWeightedClusterRuntimeKeys(t *testing.T) {
+	svc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	testCases := []struct {
+		name                     string
+		prefix                   string
+		expectedRuntimeKeyPrefix string
+	}{
+		{
+			name:                     "runtime keys are not used by default",
+			prefix:                   "",
+			expectedRuntimeKeyPrefix: "",
+		},
+		{
+			name:                     "runtime keys are scoped to the upstream service",
+			prefix:                   "osm.weights",
+			expectedRuntimeKeyPrefix: "osm.weights.s1.ns1.svc.cluster.local",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							EnablePermissiveTrafficPolicyMode: true,
+							WeightedClusterRuntimeKeyPrefix:   tc.prefix,
+						},
+					},
+				},
+			})
+
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity(),
+				[]service.MeshService{svc})
+
+			assert.Len(actual[int(svc.TargetPort)], 1)
+			rules := actual[int(svc.TargetPort)][0].Rules
+			assert.Len(rules, 1)
+			assert.Equal(tc.expectedRuntimeKeyPrefix, rules[0].Route.RuntimeKeyPrefix)
+
+			// The configured weights are preserved as the defaults for the runtime keys
+			assert.Equal(mapset.NewSet(service.WeightedCluster{
+				ClusterName: "ns1/s1|8080|local",
+				Weight:      100,
+			}), rules[0].Route.WeightedClusters)
+		})
+	}
+}
+
+func TestInboundRouteTimeout(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	timeoutSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	defaultSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 90, TargetPort: 9090, Protocol: "http"}
+
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host:               timeoutSvc.FQDN(),
+				HTTPRequestTimeout: &metav1.Duration{Duration: 30 * time.Second},
+				HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+					{
+						Path:    constants.RegexMatchAll,
+						Timeout: &metav1.Duration{Duration: 5 * time.Second},
+					},
+				},
+			},
+		},
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+				},
+			},
+		},
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+
+	actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity(),
+		[]service.MeshService{timeoutSvc, defaultSvc})
+
+	// The per route timeout for s1 takes precedence over the timeout of the upstream host
+	assert.Len(actual[int(timeoutSvc.TargetPort)], 1)
+	timeoutRules := actual[int(timeoutSvc.TargetPort)][0].Rules
+	assert.Len(timeoutRules, 1)
+	assert.NotNil(timeoutRules[0].Route.Timeout)
+	assert.Equal(5*time.Second, *timeoutRules[0].Route.Timeout)
+
+	// The route for s2 falls back to the mesh default timeout
+	assert.Len(actual[int(defaultSvc.TargetPort)], 1)
+	defaultRules := actual[int(defaultSvc.TargetPort)][0].Rules
+	assert.Len(defaultRules, 1)
+	assert.Nil(defaultRules[0].Route.Timeout)
+}
+
+func TestInboundRoutesForGRPCService(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mrc := &v1alpha2.MeshRootCertificate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "osm-mesh-root-certificate",
+			Namespace: "osm-system",
+		},
+		Spec: v1alpha2.MeshRootCertificateSpec{
+			TrustDomain: "cluster.local",
+			Intent:      v1alpha2.ActiveIntent,
+		},
+	}
+
+	configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+	mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+	fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+	mrcClient.NewCertEvent(mrc.Name)
+
+	grpcSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: constants.ProtocolGRPC}
+	trafficTargets := []*access.TrafficTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "t1", Namespace: "ns1"},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+				Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "sa2", Namespace: "ns2"}},
+				Rules:       []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "rule-1", Matches: []string{"method", "service"}}},
+			},
+		},
+	}
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rule-1"},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{
+					{Name: "method", PathRegex: "/bookstore.v1.Bookstore/BuyBook", Methods: []string{"POST"}},
+					{Name: "service", PathRegex: "/bookstore.v1.Inventory/.*", Methods: []string{"POST"}},
+				},
+			},
+		},
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		trafficTargets:  trafficTargets,
+		httpRouteGroups: httpRouteGroups,
+	})
+	mc.certManager = fakeCertManager
+
+	actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity(),
+		[]service.MeshService{grpcSvc})
+
+	assert.Len(actual[int(grpcSvc.TargetPort)], 1)
+	policy := actual[int(grpcSvc.TargetPort)][0]
+
+	// The name, hostnames and cluster of the policy are the same as for an HTTP service
+	assert.Equal(grpcSvc.FQDN(), policy.Name)
+	assert.Equal(mc.GetHostnamesForService(grpcSvc, true), policy.Hostnames)
+
+	var routes []trafficpolicy.HTTPRouteMatch
+	for _, rule := range policy.Rules {
+		assert.Equal(mapset.NewSet(service.WeightedCluster{
+			ClusterName: service.ClusterName(grpcSvc.EnvoyLocalClusterName()),
+			Weight:      constants.ClusterWeightAcceptAll,
+		}), rule.Route.WeightedClusters)
+		routes = append(routes, rule.Route.HTTPRouteMatch)
+	}
+	assert.ElementsMatch([]trafficpolicy.HTTPRouteMatch{
+		{
+			Path:          "/bookstore.v1.Bookstore/BuyBook",
+			PathMatchType: trafficpolicy.PathMatchExact,
+			Methods:       []string{"POST"},
+			GRPC:          true,
+		},
+		{
+			Path:          "/bookstore.v1.Inventory/",
+			PathMatchType: trafficpolicy.PathMatchPrefix,
+			Methods:       []string{"POST"},
+			GRPC:          true,
+		},
+	}, routes)
+}
+
+func TestGetGRPCRouteMatch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		match    trafficpolicy.HTTPRouteMatch
+		expected trafficpolicy.HTTPRouteMatch
+	}{
+		{
+			name:     "gRPC method path is matched exactly",
+			match:    trafficpolicy.HTTPRouteMatch{Path: "/helloworld.Greeter/SayHello", PathMatchType: trafficpolicy.PathMatchRegex},
+			expected: trafficpolicy.HTTPRouteMatch{Path: "/helloworld.Greeter/SayHello", PathMatchType: trafficpolicy.PathMatchExact, GRPC: true},
+		},
+		{
+			name:     "gRPC service path is matched as a prefix",
+			match:    trafficpolicy.HTTPRouteMatch{Path: "/helloworld.Greeter/.*", PathMatchType: trafficpolicy.PathMatchRegex},
+			expected: trafficpolicy.HTTPRouteMatch{Path: "/helloworld.Greeter/", PathMatchType: trafficpolicy.PathMatchPrefix, GRPC: true},
+		},
+		{
+			name:     "wildcard path is matched as a regex",
+			match:    trafficpolicy.HTTPRouteMatch{Path: constants.RegexMatchAll, PathMatchType: trafficpolicy.PathMatchRegex},
+			expected: trafficpolicy.HTTPRouteMatch{Path: constants.RegexMatchAll, PathMatchType: trafficpolicy.PathMatchRegex, GRPC: true},
+		},
+		{
+			name:     "non gRPC path regex is matched as a regex",
+			match:    trafficpolicy.HTTPRouteMatch{Path: "/helloworld.*/Say.*", PathMatchType: trafficpolicy.PathMatchRegex},
+			expected: trafficpolicy.HTTPRouteMatch{Path: "/helloworld.*/Say.*", PathMatchType: trafficpolicy.PathMatchRegex, GRPC: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			assert.Equal(tc.expected, getGRPCRouteMatch(tc.match))
+		})
+	}
+}
+
+func TestInboundRoutesWithTrafficSplitBackendRequestHeaders(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	trafficSplits := []*split.TrafficSplit{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "split1",
+				Namespace: "ns1",
+				Annotations: map[string]string{
+					"request-headers.openservicemesh.io/s1-v1": "x-version=v1",
+					"request-headers.openservicemesh.io/s1-v2": "x-version=v2, x-canary=true",
+				},
+			},
+			Spec: split.TrafficSplitSpec{
+				Service: "s1",
+				Backends: []split.TrafficSplitBackend{
+					{Service: "s1-v1", Weight: 90},
+					{Service: "s1-v2", Weight: 10},
+				},
+			},
+		},
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+				},
+			},
+		},
+		trafficSplits: trafficSplits,
+	})
+
+	testCases := []struct {
+		name                    string
+		backend                 service.MeshService
+		expectedRequestHeaders  service.RequestHeaders
+		expectedApexClusterName string
+	}{
+		{
+			name:                    "backend s1-v1 adds its version header",
+			backend:                 service.MeshService{Name: "s1-v1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"},
+			expectedRequestHeaders:  "x-version=v1",
+			expectedApexClusterName: "ns1/s1|8080|local",
+		},
+		{
+			name:                    "backend s1-v2 adds its version and canary headers",
+			backend:                 service.MeshService{Name: "s1-v2", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"},
+			expectedRequestHeaders:  "x-canary=true,x-version=v2",
+			expectedApexClusterName: "ns1/s1|8080|local",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity(),
+				[]service.MeshService{tc.backend})
+
+			policies := actual[int(tc.backend.TargetPort)]
+			assert.Len(policies, 2)
+			for _, policy := range policies {
+				assert.Len(policy.Rules, 1)
+				clusters := policy.Rules[0].Route.WeightedClusters.ToSlice()
+				assert.Len(clusters, 1)
+				cluster := clusters[0].(service.WeightedCluster)
+
+				if policy.Name == tc.backend.FQDN() {
+					// Requests addressed to the backend service directly are not modified
+					assert.Equal(service.RequestHeaders(""), cluster.RequestHeadersToAdd)
+					continue
+				}
+				// Requests split from the apex service carry the headers configured for the backend
+				assert.Equal("s1.ns1.svc.cluster.local", policy.Name)
+				assert.Equal(service.ClusterName(tc.expectedApexClusterName), cluster.ClusterName)
+				assert.Equal(tc.expectedRequestHeaders, cluster.RequestHeadersToAdd)
+			}
+		})
+	}
+}
+
+func TestGetBackendRequestHeaders(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		backend     string
+		expected    service.RequestHeaders
+	}{
+		{
+			name:        "no annotation for the backend",
+			annotations: map[string]string{"request-headers.openservicemesh.io/s1-v2": "x-version=v2"},
+			backend:     "s1-v1",
+			expected:    "",
+		},
+		{
+			name:        "multiple headers for the backend",
+			annotations: map[string]string{"request-headers.openservicemesh.io/s1-v1": "x-version=v1,x-canary=false"},
+			backend:     "s1-v1",
+			expected:    "x-canary=false,x-version=v1",
+		},
+		{
+			name:        "invalid headers are ignored",
+			annotations: map[string]string{"request-headers.openservicemesh.io/s1-v1": "x-version=v1,invalid,=empty"},
+			backend:     "s1-v1",
+			expected:    "x-version=v1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			trafficSplit := &split.TrafficSplit{
+				ObjectMeta: metav1.ObjectMeta{Name: "split1", Namespace: "ns1", Annotations: tc.annotations},
+			}
+			assert.Equal(tc.expected, getBackendRequestHeaders(trafficSplit, tc.backend))
+		})
+	}
+}
+
+func TestGetInboundMeshClusterConfigsWithConnectionSettings(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	s1 := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	s2 := service.MeshService{Name: "s2", Namespace: "ns1", Port: 90, TargetPort: 9090, Protocol: "http"}
+
+	connectionSettings := &policyv1alpha1.ConnectionSettingsSpec{
+		TCP: &policyv1alpha1.TCPConnectionSettings{
+			MaxConnections: pointer.Uint32(100),
+		},
+		HTTP: &policyv1alpha1.HTTPConnectionSettings{
+			MaxPendingRequests: pointer.Uint32(10),
+		},
+	}
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "s1"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host:               s1.FQDN(),
+				ConnectionSettings: connectionSettings,
+			},
+		},
+		{
+			// The host must match the FQDN of the service, a short hostname does not match
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "s2"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host:               "s2",
+				ConnectionSettings: connectionSettings,
+			},
+		},
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+
+	clusterConfigs := mc.GetInboundMeshClusterConfigs(tests.BookstoreServiceIdentity, []service.MeshService{s1, s2})
+	assert.Len(clusterConfigs, 2)
+
+	assert.Equal(s1.EnvoyLocalClusterName(), clusterConfigs[0].Name)
+	assert.Equal(connectionSettings, clusterConfigs[0].ConnectionSettings)
+
+	assert.Equal(s2.EnvoyLocalClusterName(), clusterConfigs[1].Name)
+	assert.Nil(clusterConfigs[1].ConnectionSettings)
+}
+
+func TestInboundPermissiveModeTrustDomainPrincipals(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		trustDomainPrincipals bool
+		spiffeEnabled         bool
+		expectedPrincipals    mapset.Set
+	}{
+		{
+			name:                  "permissive mode allows any principal by default",
+			trustDomainPrincipals: false,
+			expectedPrincipals:    mapset.NewSet(identity.WildcardPrincipal),
+		},
+		{
+			name:                  "permissive mode allows the principals of each active trust domain",
+			trustDomainPrincipals: true,
+			expectedPrincipals:    mapset.NewSet("*.cluster.local", "*.cluster.new"),
+		},
+		{
+			name:                  "permissive mode allows the SPIFFE principals of each active trust domain",
+			trustDomainPrincipals: true,
+			spiffeEnabled:         true,
+			expectedPrincipals:    mapset.NewSet("spiffe://cluster.local/*", "spiffe://cluster.new/*"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mrc := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain:   "cluster.local",
+					Intent:        v1alpha2.ActiveIntent,
+					SpiffeEnabled: tc.spiffeEnabled,
+				},
+			}
+
+			configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+			mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+			fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+			mrcClient.NewCertEvent(mrc.Name)
+
+			// create a second MRC with a new trust domain, as done when migrating trust domains
+			mrc2 := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate-2",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain:   "cluster.new",
+					Intent:        v1alpha2.ActiveIntent,
+					SpiffeEnabled: tc.spiffeEnabled,
+				},
+			}
+			_, err := configClient.ConfigV1alpha2().MeshRootCertificates("osm-system").Create(context.Background(), mrc2, metav1.CreateOptions{})
+			assert.NoError(err)
+
+			// generate an MRCEvent for the new MRC to update the issuers and trigger a rotation
+			mrcClient.NewCertEvent(mrc2.Name)
+			assert.Eventually(func() bool {
+				return fakeCertManager.GetIssuersInfo().AreDifferent()
+			}, 2*time.Second, 100*time.Millisecond)
+
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							EnablePermissiveTrafficPolicyMode:            true,
+							EnablePermissiveTrafficTrustDomainPrincipals: tc.trustDomainPrincipals,
+						},
+					},
+				},
+			})
+			mc.certManager = fakeCertManager
+
+			svc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity(),
+				[]service.MeshService{svc})
+
+			assert.Len(actual[int(svc.TargetPort)], 1)
+			rules := actual[int(svc.TargetPort)][0].Rules
+			assert.Len(rules, 1)
+			assert.Equal(tc.expectedPrincipals, rules[0].AllowedPrincipals)
+		})
+	}
+}
+
+func TestGetInboundMeshTrafficMatchesForHTTPAndTCPServices(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{})
+
+	mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	httpSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	grpcSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 90, TargetPort: 9090, Protocol: "grpc"}
+	tcpSvc := service.MeshService{Name: "s3", Namespace: "ns1", Port: 3306, TargetPort: 3306, Protocol: "tcp"}
+
+	trafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, []service.MeshService{httpSvc, grpcSvc, tcpSvc})
+
+	// HTTP services get a TrafficMatch like TCP services, so that LDS can match their filter chain by server name
+	assert.ElementsMatch([]*trafficpolicy.TrafficMatch{
+		{
+			Name:                "inbound_ns1/s1_8080_http",
+			DestinationPort:     8080,
+			DestinationProtocol: "http",
+			ServerNames:         []string{"s1.ns1.svc.cluster.local"},
+			Cluster:             "ns1/s1|8080|local",
+		},
+		{
+			Name:                "inbound_ns1/s2_9090_grpc",
+			DestinationPort:     9090,
+			DestinationProtocol: "grpc",
+			ServerNames:         []string{"s2.ns1.svc.cluster.local"},
+			Cluster:             "ns1/s2|9090|local",
+		},
+		{
+			Name:                "inbound_ns1/s3_3306_tcp",
+			DestinationPort:     3306,
+			DestinationProtocol: "tcp",
+			ServerNames:         []string{"s3.ns1.svc.cluster.local"},
+			Cluster:             "ns1/s3|3306|local",
+		},
+	}, trafficMatches)
+}
+
+func TestInboundRoutesWithGlobalRateLimitDescriptors(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	trafficTargets := []*access.TrafficTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "t1",
+				Namespace: "ns1",
+			},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{
+					Kind:      "ServiceAccount",
+					Name:      "sa1",
+					Namespace: "ns1",
+				},
+				Sources: []access.IdentityBindingSubject{{
+					Kind:      "ServiceAccount",
+					Name:      "sa2",
+					Namespace: "ns2",
+				}},
+				Rules: []access.TrafficTargetRule{{
+					Kind:    "HTTPRouteGroup",
+					Name:    "rule-1",
+					Matches: []string{"route-get"},
+				}},
+			},
+		},
+	}
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      "rule-1",
+			},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{
+					{
+						Name:      "route-get",
+						PathRegex: "/get",
+						Methods:   []string{"GET"},
+					},
+				},
+			},
+		},
+	}
+
+	globalRateLimit := &policyv1alpha1.HTTPPerRouteRateLimitSpec{
+		Global: &policyv1alpha1.HTTPGlobalPerRouteRateLimitSpec{
+			Descriptors: []policyv1alpha1.HTTPGlobalRateLimitDescriptor{
+				{
+					Entries: []policyv1alpha1.HTTPGlobalRateLimitDescriptorEntry{
+						{
+							RequestPath: &policyv1alpha1.RequestPathDescriptorEntry{},
+						},
+						{
+							RequestHeader: &policyv1alpha1.RequestHeaderDescriptorEntry{Name: "x-user", Key: "user"},
+						},
+						{
+							RemoteAddress: &policyv1alpha1.RemoteAddressDescriptorEntry{},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	mrc := &v1alpha2.MeshRootCertificate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "osm-mesh-root-certificate",
+			Namespace: "osm-system",
+		},
+		Spec: v1alpha2.MeshRootCertificateSpec{
+			TrustDomain: "cluster.local",
+			Intent:      v1alpha2.ActiveIntent,
+		},
+	}
+
+	configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+	mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+	fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+	mrcClient.NewCertEvent(mrc.Name)
+
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host: upstreamSvc.FQDN(),
+				HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+					{Path: "/get", RateLimit: globalRateLimit},
+				},
+			},
+		},
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		trafficTargets:          trafficTargets,
+		httpRouteGroups:         httpRouteGroups,
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+	mc.certManager = fakeCertManager
+
+	actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+	assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+	assert.Len(actual[int(upstreamSvc.TargetPort)][0].Rules, 1)
+
+	rateLimit := actual[int(upstreamSvc.TargetPort)][0].Rules[0].Route.RateLimit
+	assert.NotNil(rateLimit)
+	assert.NotNil(rateLimit.Global)
+	assert.Equal(globalRateLimit.Global.Descriptors, rateLimit.Global.Descriptors)
+}
+
+func TestInboundRoutesWithRequiredRouteHeaders(t *testing.T) {
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      "rule-1",
+			},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{
+					{
+						Name:      "route-tenant",
+						PathRegex: "/get",
+						Methods:   []string{"GET"},
+						Headers:   map[string]string{"x-tenant": "tenant-1"},
+					},
+					{
+						Name:      "route-no-headers",
+						PathRegex: "/post",
+						Methods:   []string{"POST"},
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name                    string
+		annotations             map[string]string
+		expectedRequiredHeaders map[string]map[string]string // path -> required headers
+	}{
+		{
+			name:        "route headers are not required without the annotation",
+			annotations: nil,
+			expectedRequiredHeaders: map[string]map[string]string{
+				"/get":  nil,
+				"/post": nil,
+			},
+		},
+		{
+			name:        "route headers are required with the annotation",
+			annotations: map[string]string{constants.TrafficTargetRequireRouteHeadersAnnotation: "true"},
+			expectedRequiredHeaders: map[string]map[string]string{
+				"/get":  {"x-tenant": "tenant-1"},
+				"/post": nil,
+			},
+		},
+		{
+			name:        "route headers are not required with an invalid annotation value",
+			annotations: map[string]string{constants.TrafficTargetRequireRouteHeadersAnnotation: "invalid"},
+			expectedRequiredHeaders: map[string]map[string]string{
+				"/get":  nil,
+				"/post": nil,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			trafficTargets := []*access.TrafficTarget{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "t1",
+						Namespace:   "ns1",
+						Annotations: tc.annotations,
+					},
+					Spec: access.TrafficTargetSpec{
+						Destination: access.IdentityBindingSubject{
+							Kind:      "ServiceAccount",
+							Name:      "sa1",
+							Namespace: "ns1",
+						},
+						Sources: []access.IdentityBindingSubject{{
+							Kind:      "ServiceAccount",
+							Name:      "sa2",
+							Namespace: "ns2",
+						}},
+						Rules: []access.TrafficTargetRule{{
+							Kind:    "HTTPRouteGroup",
+							Name:    "rule-1",
+							Matches: []string{"route-tenant", "route-no-headers"},
+						}},
+					},
+				},
+			}
+
+			mrc := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain: "cluster.local",
+					Intent:      v1alpha2.ActiveIntent,
+				},
+			}
+
+			configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+			mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+			fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+			mrcClient.NewCertEvent(mrc.Name)
+
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				trafficTargets:  trafficTargets,
+				httpRouteGroups: httpRouteGroups,
+			})
+			mc.certManager = fakeCertManager
+
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+			assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+
+			requiredHeadersPerPath := make(map[string]map[string]string)
+			for _, rule := range actual[int(upstreamSvc.TargetPort)][0].Rules {
+				requiredHeadersPerPath[rule.Route.HTTPRouteMatch.Path] = rule.RequiredHeaders
+			}
+			assert.Equal(tc.expectedRequiredHeaders, requiredHeadersPerPath)
+		})
+	}
+}
+
+func TestGetInboundMeshTrafficMatchesPerIdentity(t *testing.T) {
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	httpSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	tcpSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 3306, TargetPort: 3306, Protocol: "tcp"}
+
+	tcpRoutes := map[string]*spec.TCPRoute{
+		"ns1/tcp-route-1": {
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "tcp-route-1"},
+			Spec:       spec.TCPRouteSpec{Matches: spec.TCPMatch{Ports: []int{3306}}},
+		},
+		"ns1/tcp-route-2": {
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "tcp-route-2"},
+			Spec:       spec.TCPRouteSpec{Matches: spec.TCPMatch{Ports: []int{9999}}},
+		},
+	}
+	trafficTargets := []*access.TrafficTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "t1", Namespace: "ns1"},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+				Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "sa2", Namespace: "ns2"}},
+				Rules:       []access.TrafficTargetRule{{Kind: "TCPRoute", Name: "tcp-route-1"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "t2", Namespace: "ns1"},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+				Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "sa3", Namespace: "ns3"}},
+				Rules:       []access.TrafficTargetRule{{Kind: "TCPRoute", Name: "tcp-route-1"}},
+			},
+		},
+		{
+			// Does not allow the port of the TCP service
+			ObjectMeta: metav1.ObjectMeta{Name: "t3", Namespace: "ns1"},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+				Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "sa4", Namespace: "ns4"}},
+				Rules:       []access.TrafficTargetRule{{Kind: "TCPRoute", Name: "tcp-route-2"}},
+			},
+		},
+	}
+
+	httpTrafficMatch := &trafficpolicy.TrafficMatch{
+		Name:                "inbound_ns1/s1_8080_http",
+		DestinationPort:     8080,
+		DestinationProtocol: "http",
+		ServerNames:         []string{"s1.ns1.svc.cluster.local"},
+		Cluster:             "ns1/s1|8080|local",
+	}
+
+	testCases := []struct {
+		name                   string
+		permissiveMode         bool
+		perIdentity            bool
+		expectedTrafficMatches []*trafficpolicy.TrafficMatch
+	}{
+		{
+			name:        "a TrafficMatch per downstream identity for the TCP service",
+			perIdentity: true,
+			expectedTrafficMatches: []*trafficpolicy.TrafficMatch{
+				httpTrafficMatch,
+				{
+					Name:                "inbound_ns1/s2_3306_tcp",
+					DestinationPort:     3306,
+					DestinationProtocol: "tcp",
+					ServerNames:         []string{"s2.ns1.svc.cluster.local"},
+					Cluster:             "ns1/s2|3306|local",
+					AllowedPrincipals:   []string{"sa2.ns2.cluster.local"},
+				},
+				{
+					Name:                "inbound_ns1/s2_3306_tcp",
+					DestinationPort:     3306,
+					DestinationProtocol: "tcp",
+					ServerNames:         []string{"s2.ns1.svc.cluster.local"},
+					Cluster:             "ns1/s2|3306|local",
+					AllowedPrincipals:   []string{"sa3.ns3.cluster.local"},
+				},
+			},
+		},
+		{
+			name:        "a single TrafficMatch for the TCP service when disabled",
+			perIdentity: false,
+			expectedTrafficMatches: []*trafficpolicy.TrafficMatch{
+				httpTrafficMatch,
+				{
+					Name:                "inbound_ns1/s2_3306_tcp",
+					DestinationPort:     3306,
+					DestinationProtocol: "tcp",
+					ServerNames:         []string{"s2.ns1.svc.cluster.local"},
+					Cluster:             "ns1/s2|3306|local",
+				},
+			},
+		},
+		{
+			name:           "a single TrafficMatch for the TCP service in permissive mode",
+			permissiveMode: true,
+			perIdentity:    true,
+			expectedTrafficMatches: []*trafficpolicy.TrafficMatch{
+				httpTrafficMatch,
+				{
+					Name:                "inbound_ns1/s2_3306_tcp",
+					DestinationPort:     3306,
+					DestinationProtocol: "tcp",
+					ServerNames:         []string{"s2.ns1.svc.cluster.local"},
+					Cluster:             "ns1/s2|3306|local",
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mrc := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain: "cluster.local",
+					Intent:      v1alpha2.ActiveIntent,
+				},
+			}
+
+			configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+			mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+			fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+			mrcClient.NewCertEvent(mrc.Name)
+
+			mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							EnablePermissiveTrafficPolicyMode:      tc.permissiveMode,
+							EnableInboundTCPFilterChainPerIdentity: tc.perIdentity,
+						},
+					},
+				},
+				trafficTargets: trafficTargets,
+			})
+			mc.certManager = fakeCertManager
+
+			mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			mockK8s.EXPECT().GetTCPRoute(gomock.Any()).DoAndReturn(func(name string) *spec.TCPRoute {
+				return tcpRoutes[name]
+			}).AnyTimes()
+
+			actual := mc.GetInboundMeshTrafficMatches(upstreamIdentity, []service.MeshService{httpSvc, tcpSvc})
+			assert.Equal(tc.expectedTrafficMatches, actual)
+		})
+	}
+}
+
+func TestInboundMeshTCPRoutePortSubset(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "mysql"}.ToServiceIdentity()
+	mysqlSvc := service.MeshService{Name: "mysql", Namespace: "ns1", Port: 3306, TargetPort: 3306, Protocol: "tcp"}
+	adminSvc := service.MeshService{Name: "mysql", Namespace: "ns1", Port: 33060, TargetPort: 33060, Protocol: "tcp"}
+
+	tcpRoute := &spec.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "tcp-route"},
+		Spec:       spec.TCPRouteSpec{Matches: spec.TCPMatch{Ports: []int{3306}}},
+	}
+	trafficTargets := []*access.TrafficTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "t1", Namespace: "ns1"},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "mysql", Namespace: "ns1"},
+				Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "client", Namespace: "ns2"}},
+				Rules:       []access.TrafficTargetRule{{Kind: "TCPRoute", Name: "tcp-route"}},
+			},
+		},
+	}
+
+	mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		trafficTargets: trafficTargets,
+	})
+
+	mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockK8s.EXPECT().GetTCPRoute("ns1/tcp-route").Return(tcpRoute).AnyTimes()
+
+	// Only the port listed in the TCPRoute gets a TrafficMatch and a local cluster
+	trafficMatches := mc.GetInboundMeshTrafficMatches(upstreamIdentity, []service.MeshService{mysqlSvc, adminSvc})
+	assert.Len(trafficMatches, 1)
+	assert.Equal(mysqlSvc.InboundTrafficMatchName(), trafficMatches[0].Name)
+	assert.Equal(3306, trafficMatches[0].DestinationPort)
+
+	clusterConfigs := mc.GetInboundMeshClusterConfigs(upstreamIdentity, []service.MeshService{mysqlSvc, adminSvc})
+	assert.Len(clusterConfigs, 1)
+	assert.Equal(mysqlSvc.EnvoyLocalClusterName(), clusterConfigs[0].Name)
+}
+
+func TestInboundRoutesWithWebSocketUpgrade(t *testing.T) {
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	testCases := []struct {
+		name                          string
+		upstreamTrafficSetting        *policyv1alpha1.UpstreamTrafficSetting
+		expectedAllowWebSocketUpgrade bool
+	}{
+		{
+			name:                          "WebSocket upgrades are not allowed by default",
+			upstreamTrafficSetting:        nil,
+			expectedAllowWebSocketUpgrade: false,
+		},
+		{
+			name: "WebSocket upgrades are allowed when enabled for the host",
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "s1"},
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					Host:            upstreamSvc.FQDN(),
+					EnableWebSocket: true,
+				},
+			},
+			expectedAllowWebSocketUpgrade: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			var upstreamTrafficSettings []*policyv1alpha1.UpstreamTrafficSetting
+			if tc.upstreamTrafficSetting != nil {
+				upstreamTrafficSettings = append(upstreamTrafficSettings, tc.upstreamTrafficSetting)
+			}
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							EnablePermissiveTrafficPolicyMode: true,
+						},
+					},
+				},
+				upstreamTrafficSettings: upstreamTrafficSettings,
+			})
+
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+			assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+			assert.Equal(tc.expectedAllowWebSocketUpgrade, actual[int(upstreamSvc.TargetPort)][0].AllowWebSocketUpgrade)
+		})
+	}
+}
+
+func TestInboundRoutesWithResponseHeaders(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "s1"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host: upstreamSvc.FQDN(),
+				ResponseHeadersToAdd: []policyv1alpha1.HTTPHeaderValue{
+					{Name: "X-Frame-Options", Value: "DENY"},
+					{Name: "Cache-Control", Value: "no-store"},
+					{Name: "Cache-Control", Value: "no-cache"},
+				},
+				ResponseHeadersToRemove: []string{"X-Powered-By", "Server", "X-Powered-By"},
+			},
+		},
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+				},
+			},
+		},
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+
+	actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+	assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+	policy := actual[int(upstreamSvc.TargetPort)][0]
+
+	// Headers are ordered by name, and the values of a repeated header keep their configured order
+	assert.Equal([]policyv1alpha1.HTTPHeaderValue{
+		{Name: "Cache-Control", Value: "no-store"},
+		{Name: "Cache-Control", Value: "no-cache"},
+		{Name: "X-Frame-Options", Value: "DENY"},
+	}, policy.ResponseHeadersToAdd)
+	assert.Equal([]string{"Server", "X-Powered-By"}, policy.ResponseHeadersToRemove)
+
+	// The UpstreamTrafficSetting is not modified
+	assert.Equal("X-Frame-Options", upstreamTrafficSettings[0].Spec.ResponseHeadersToAdd[0].Name)
+	assert.Len(upstreamTrafficSettings[0].Spec.ResponseHeadersToRemove, 3)
+}
+
+func TestInboundRoutesWithQueryParams(t *testing.T) {
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	testCases := []struct {
+		name                string
+		httpRoutes          []policyv1alpha1.HTTPRouteSpec
+		expectedQueryParams map[string]string
+	}{
+		{
+			name:                "routes are unchanged without query parameter constraints",
+			httpRoutes:          nil,
+			expectedQueryParams: nil,
+		},
+		{
+			name: "query parameter constraints are merged into the matching route",
+			httpRoutes: []policyv1alpha1.HTTPRouteSpec{
+				{Path: constants.RegexMatchAll, QueryParams: map[string]string{"version": "v2"}},
+			},
+			expectedQueryParams: map[string]string{"version": "v2"},
+		},
+		{
+			name: "query parameter constraints for another path are ignored",
+			httpRoutes: []policyv1alpha1.HTTPRouteSpec{
+				{Path: "/other", QueryParams: map[string]string{"version": "v2"}},
+			},
+			expectedQueryParams: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "s1"},
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						Host:       upstreamSvc.FQDN(),
+						HTTPRoutes: tc.httpRoutes,
+					},
+				},
+			}
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							EnablePermissiveTrafficPolicyMode: true,
+						},
+					},
+				},
+				upstreamTrafficSettings: upstreamTrafficSettings,
+			})
+
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+			assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+			assert.Len(actual[int(upstreamSvc.TargetPort)][0].Rules, 1)
+			assert.Equal(tc.expectedQueryParams, actual[int(upstreamSvc.TargetPort)][0].Rules[0].Route.HTTPRouteMatch.QueryParams)
+		})
+	}
+}
+
+func TestInboundRoutesWithCORSPreflight(t *testing.T) {
+	testCases := []struct {
+		name                       string
+		enableInboundCORSPreflight bool
+		expectedMethodsPerPath     map[string][]string
+	}{
+		{
+			name:                       "OPTIONS is not added when CORS preflight is disabled",
+			enableInboundCORSPreflight: false,
+			expectedMethodsPerPath: map[string][]string{
+				"/books":  {"GET", "HEAD"},
+				"/orders": {"GET", "OPTIONS"},
+				".*":      {constants.WildcardHTTPMethod},
+			},
+		},
+		{
+			name:                       "OPTIONS is added to routes with explicit methods when CORS preflight is enabled",
+			enableInboundCORSPreflight: true,
+			expectedMethodsPerPath: map[string][]string{
+				"/books":  {"GET", "HEAD", constants.HTTPMethodOptions},
+				"/orders": {"GET", "OPTIONS"},
+				".*":      {constants.WildcardHTTPMethod},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mrc := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain: "cluster.local",
+					Intent:      v1alpha2.ActiveIntent,
+				},
+			}
+
+			configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+			mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+			fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+			mrcClient.NewCertEvent(mrc.Name)
+
+			svc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+			trafficTargets := []*access.TrafficTarget{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "t1", Namespace: "ns1"},
+					Spec: access.TrafficTargetSpec{
+						Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+						Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "sa2", Namespace: "ns2"}},
+						Rules:       []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "rule-1", Matches: []string{"books", "orders", "all"}}},
+					},
+				},
+			}
+			httpRouteGroups := []*spec.HTTPRouteGroup{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rule-1"},
+					Spec: spec.HTTPRouteGroupSpec{
+						Matches: []spec.HTTPMatch{
+							{Name: "books", PathRegex: "/books", Methods: []string{"GET", "HEAD"}},
+							{Name: "orders", PathRegex: "/orders", Methods: []string{"GET", "OPTIONS"}},
+							{Name: "all"},
+						},
+					},
+				},
+			}
+
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							EnableInboundCORSPreflight: tc.enableInboundCORSPreflight,
+						},
+					},
+				},
+				trafficTargets:  trafficTargets,
+				httpRouteGroups: httpRouteGroups,
+			})
+			mc.certManager = fakeCertManager
+
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity(),
+				[]service.MeshService{svc})
+
+			assert.Len(actual[int(svc.TargetPort)], 1)
+			methodsPerPath := make(map[string][]string)
+			for _, rule := range actual[int(svc.TargetPort)][0].Rules {
+				methodsPerPath[rule.Route.HTTPRouteMatch.Path] = rule.Route.HTTPRouteMatch.Methods
+			}
+			assert.Equal(tc.expectedMethodsPerPath, methodsPerPath)
+		})
+	}
+}
+
+func TestWithCORSPreflightMethod(t *testing.T) {
+	assert := tassert.New(t)
+
+	methods := []string{"GET", "HEAD"}
+	match := trafficpolicy.HTTPRouteMatch{Path: "/books", Methods: methods}
+
+	actual := withCORSPreflightMethod(match)
+	assert.Equal([]string{"GET", "HEAD", constants.HTTPMethodOptions}, actual.Methods)
+	// The methods of the given route match are not modified
+	assert.Equal([]string{"GET", "HEAD"}, methods)
+
+	wildcard := trafficpolicy.HTTPRouteMatch{Path: "/books", Methods: []string{constants.WildcardHTTPMethod}}
+	assert.Equal(wildcard, withCORSPreflightMethod(wildcard))
+
+	lowercaseOptions := trafficpolicy.HTTPRouteMatch{Path: "/books", Methods: []string{"GET", "options"}}
+	assert.Equal(lowercaseOptions, withCORSPreflightMethod(lowercaseOptions))
+}
+
+func TestGetInboundMeshTrafficMatchesWithAccessLogFormat(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	svc1 := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	svc2 := service.MeshService{Name: "s2", Namespace: "ns1", Port: 90, TargetPort: 9090, Protocol: "http"}
+	svc3 := service.MeshService{Name: "s3", Namespace: "ns1", Port: 70, TargetPort: 7070, Protocol: "http"}
+
+	textFormat := "[%START_TIME%] %REQ(:METHOD)% %RESPONSE_CODE%"
+	jsonFormat := `{"method": "%REQ(:METHOD)%", "response_code": "%RESPONSE_CODE%"}`
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "s1"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host:            svc1.FQDN(),
+				AccessLogFormat: textFormat,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "s2"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host:            svc2.FQDN(),
+				AccessLogFormat: jsonFormat,
+			},
+		},
+	}
+
+	mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+
+	mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	trafficMatches := mc.GetInboundMeshTrafficMatches(tests.BookstoreServiceIdentity, []service.MeshService{svc1, svc2, svc3})
+	assert.Len(trafficMatches, 3)
+
+	// Each host has the access log format of its UpstreamTrafficSetting, and the proxy's format is used otherwise
+	assert.Equal(svc1.InboundTrafficMatchName(), trafficMatches[0].Name)
+	assert.Equal(textFormat, trafficMatches[0].AccessLogFormat)
+	assert.Equal(svc2.InboundTrafficMatchName(), trafficMatches[1].Name)
+	assert.Equal(jsonFormat, trafficMatches[1].AccessLogFormat)
+	assert.Equal(svc3.InboundTrafficMatchName(), trafficMatches[2].Name)
+	assert.Empty(trafficMatches[2].AccessLogFormat)
+}
+
+func TestInboundRoutesWithDeniedServiceAccounts(t *testing.T) {
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	trafficTargets := []*access.TrafficTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "t1",
+				Namespace: "ns1",
+			},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{
+					Kind:      "ServiceAccount",
+					Name:      "sa1",
+					Namespace: "ns1",
+				},
+				Sources: []access.IdentityBindingSubject{
+					{
+						Kind:      "ServiceAccount",
+						Name:      "sa2",
+						Namespace: "ns2",
+					},
+					{
+						Kind:      "ServiceAccount",
+						Name:      "sa3",
+						Namespace: "ns3",
+					},
+				},
+				Rules: []access.TrafficTargetRule{{
+					Kind:    "HTTPRouteGroup",
+					Name:    "rule-1",
+					Matches: []string{"route-get"},
+				}},
+			},
+		},
+	}
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      "rule-1",
+			},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{
+					{
+						Name:      "route-get",
+						PathRegex: "/get",
+						Methods:   []string{"GET"},
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name                     string
+		deniedServiceAccounts    []policyv1alpha1.ServiceAccountSpec
+		expectedDeniedPrincipals mapset.Set
+	}{
+		{
+			name:                     "no denied service accounts",
+			deniedServiceAccounts:    nil,
+			expectedDeniedPrincipals: nil,
+		},
+		{
+			name: "denied service account that is also allowed by the TrafficTarget",
+			deniedServiceAccounts: []policyv1alpha1.ServiceAccountSpec{
+				{Name: "sa2", Namespace: "ns2"},
+			},
+			expectedDeniedPrincipals: mapset.NewSet(
+				identity.K8sServiceAccount{Name: "sa2", Namespace: "ns2"}.AsPrincipal("cluster.local", false),
+			),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mrc := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain: "cluster.local",
+					Intent:      v1alpha2.ActiveIntent,
+				},
+			}
+
+			configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+			mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+			fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+			mrcClient.NewCertEvent(mrc.Name)
+
+			upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns1"},
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						Host:                  upstreamSvc.FQDN(),
+						DeniedServiceAccounts: tc.deniedServiceAccounts,
+					},
+				},
+			}
+
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				trafficTargets:          trafficTargets,
+				httpRouteGroups:         httpRouteGroups,
+				upstreamTrafficSettings: upstreamTrafficSettings,
+			})
+			mc.certManager = fakeCertManager
+
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+			assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+
+			rules := actual[int(upstreamSvc.TargetPort)][0].Rules
+			assert.Len(rules, 1)
+			// The denied service account remains allowed by the TrafficTarget, the RBAC filter gives precedence to the denial
+			assert.True(rules[0].AllowedPrincipals.Contains(
+				identity.K8sServiceAccount{Name: "sa2", Namespace: "ns2"}.AsPrincipal("cluster.local", false)))
+			if tc.expectedDeniedPrincipals == nil {
+				assert.Nil(rules[0].DeniedPrincipals)
+			} else {
+				assert.True(tc.expectedDeniedPrincipals.Equal(rules[0].DeniedPrincipals))
+			}
+		})
+	}
+}
+
+func TestInboundRoutesWithAllowedSourceRanges(t *testing.T) {
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	testCases := []struct {
+		name                        string
+		allowedSourceRanges         []string
+		expectedAllowedSourceRanges []string
+	}{
+		{
+			name:                        "no allowed source ranges",
+			allowedSourceRanges:         nil,
+			expectedAllowedSourceRanges: nil,
+		},
+		{
+			name:                        "allowed source ranges",
+			allowedSourceRanges:         []string{"10.0.0.0/8", "fd00::/8"},
+			expectedAllowedSourceRanges: []string{"10.0.0.0/8", "fd00::/8"},
+		},
+		{
+			name:                        "invalid allowed source range ignores the UpstreamTrafficSetting",
+			allowedSourceRanges:         []string{"10.0.0.0"},
+			expectedAllowedSourceRanges: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns1"},
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						Host:                upstreamSvc.FQDN(),
+						AllowedSourceRanges: tc.allowedSourceRanges,
+					},
+				},
+			}
+
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							EnablePermissiveTrafficPolicyMode: true,
+						},
+					},
+				},
+				upstreamTrafficSettings: upstreamTrafficSettings,
+			})
+			mc.certManager = tresorFake.NewFake(1 * time.Hour)
+
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+			assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+
+			rules := actual[int(upstreamSvc.TargetPort)][0].Rules
+			assert.Len(rules, 1)
+			// The source ranges restrict the downstream clients allowed by their identity
+			assert.True(rules[0].AllowedPrincipals.Contains(identity.WildcardPrincipal))
+			assert.Equal(tc.expectedAllowedSourceRanges, rules[0].AllowedSourceRanges)
+		})
+	}
+}
+
+func TestInboundRoutesIgnoreInvalidUpstreamTrafficSetting(t *testing.T) {
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	trafficTargets := []*access.TrafficTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "t1",
+				Namespace: "ns1",
+			},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{
+					Kind:      "ServiceAccount",
+					Name:      "sa1",
+					Namespace: "ns1",
+				},
+				Sources: []access.IdentityBindingSubject{{
+					Kind:      "ServiceAccount",
+					Name:      "sa2",
+					Namespace: "ns2",
+				}},
+				Rules: []access.TrafficTargetRule{{
+					Kind:    "HTTPRouteGroup",
+					Name:    "rule-1",
+					Matches: []string{"route-get"},
+				}},
+			},
+		},
+	}
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      "rule-1",
+			},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{
+					{
+						Name:      "route-get",
+						PathRegex: "/get",
+						Methods:   []string{"GET"},
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name              string
+		rateLimit         *policyv1alpha1.RateLimitSpec
+		expectedRateLimit *policyv1alpha1.RateLimitSpec
+	}{
+		{
+			name: "valid UpstreamTrafficSetting is applied",
+			rateLimit: &policyv1alpha1.RateLimitSpec{
+				Local: &policyv1alpha1.LocalRateLimitSpec{
+					HTTP: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 10, Unit: "minute"},
+				},
+			},
+			expectedRateLimit: &policyv1alpha1.RateLimitSpec{
+				Local: &policyv1alpha1.LocalRateLimitSpec{
+					HTTP: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 10, Unit: "minute"},
+				},
+			},
+		},
+		{
+			name: "UpstreamTrafficSetting with an invalid rate limit unit is ignored",
+			rateLimit: &policyv1alpha1.RateLimitSpec{
+				Local: &policyv1alpha1.LocalRateLimitSpec{
+					HTTP: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 10, Unit: "day"},
+				},
+			},
+			expectedRateLimit: nil,
+		},
+		{
+			name: "UpstreamTrafficSetting with a global rate limit service without a host is ignored",
+			rateLimit: &policyv1alpha1.RateLimitSpec{
+				Global: &policyv1alpha1.GlobalRateLimitSpec{
+					HTTP: &policyv1alpha1.HTTPGlobalRateLimitSpec{
+						RateLimitService: policyv1alpha1.RateLimitServiceSpec{Port: 8081},
+					},
+				},
+			},
+			expectedRateLimit: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mrc := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain: "cluster.local",
+					Intent:      v1alpha2.ActiveIntent,
+				},
+			}
+
+			configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+			mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+			fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+			mrcClient.NewCertEvent(mrc.Name)
+
+			upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "u1"},
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						Host:      upstreamSvc.FQDN(),
+						RateLimit: tc.rateLimit,
+					},
+				},
+			}
+
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				trafficTargets:          trafficTargets,
+				httpRouteGroups:         httpRouteGroups,
+				upstreamTrafficSettings: upstreamTrafficSettings,
+			})
+			mc.certManager = fakeCertManager
+
+			actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+			assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+			assert.Equal(tc.expectedRateLimit, actual[int(upstreamSvc.TargetPort)][0].RateLimit)
+			// The routes are still built without the settings of an ignored UpstreamTrafficSetting
+			assert.Len(actual[int(upstreamSvc.TargetPort)][0].Rules, 1)
+		})
+	}
+}
+
+func TestInboundRoutesWithRequireTLS(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "u1"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host:       upstreamSvc.FQDN(),
+				RequireTLS: true,
+			},
+		},
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+				},
+			},
+		},
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+
+	actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+	assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+
+	rules := actual[int(upstreamSvc.TargetPort)][0].Rules
+	assert.Len(rules, 1)
+	// Plaintext requests are redirected to HTTPS and not routed to the local cluster
+	assert.Equal(&trafficpolicy.RouteRedirect{HTTPSRedirect: true}, rules[0].Route.Redirect)
+	assert.Equal(0, rules[0].Route.WeightedClusters.Cardinality())
+}
+
+type testClusterNameFormatter struct{}
+
+func (testClusterNameFormatter) LocalClusterName(svc service.MeshService) string {
+	return fmt.Sprintf("inbound_%s_%s_%d", svc.Namespace, svc.Name, svc.TargetPort)
+}
+
+func TestInboundClusterNamesWithCustomFormatter(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	mc, mockK8s := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+				},
+			},
+		},
+	})
+	mc.SetClusterNameFormatter(testClusterNameFormatter{})
+
+	mockK8s.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	clusterConfigs := mc.GetInboundMeshClusterConfigs(upstreamIdentity, []service.MeshService{upstreamSvc})
+	assert.Len(clusterConfigs, 1)
+	assert.Equal("inbound_ns1_s1_8080", clusterConfigs[0].Name)
+
+	trafficMatches := mc.GetInboundMeshTrafficMatches(upstreamIdentity, []service.MeshService{upstreamSvc})
+	assert.Len(trafficMatches, 1)
+	assert.Equal("inbound_ns1_s1_8080", trafficMatches[0].Cluster)
+
+	// The routes reference the cluster by the same name as the cluster config
+	routeConfigs := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+	assert.Len(routeConfigs[int(upstreamSvc.TargetPort)], 1)
+	for _, rule := range routeConfigs[int(upstreamSvc.TargetPort)][0].Rules {
+		for _, cluster := range rule.Route.WeightedClusters.ToSlice() {
+			assert.Equal(service.ClusterName("inbound_ns1_s1_8080"), cluster.(service.WeightedCluster).ClusterName)
+		}
+	}
+
+	// Restoring the default naming scheme
+	mc.SetClusterNameFormatter(nil)
+	clusterConfigs = mc.GetInboundMeshClusterConfigs(upstreamIdentity, []service.MeshService{upstreamSvc})
+	assert.Len(clusterConfigs, 1)
+	assert.Equal("ns1/s1|8080|local", clusterConfigs[0].Name)
+}
+
+func TestInboundPolicyWithHeaderToMetadata(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	s1 := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	s2 := service.MeshService{Name: "s2", Namespace: "ns1", Port: 81, TargetPort: 8080, Protocol: "http"}
+
+	headerToMetadata := []policyv1alpha1.HeaderToMetadataSpec{
+		{Header: "x-tenant-id", MetadataKey: "tenant"},
+	}
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "u1"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host:             s1.FQDN(),
+				HeaderToMetadata: headerToMetadata,
+			},
+		},
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+				},
+			},
+		},
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+
+	actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{s1, s2})
+	policies := actual[int(s1.TargetPort)]
+	assert.Len(policies, 2)
+
+	// The mapping is attached to the policy of the host the UpstreamTrafficSetting applies to only
+	assert.Equal(s1.FQDN(), policies[0].Name)
+	assert.Equal(headerToMetadata, policies[0].HeaderToMetadata)
+	assert.Equal(s2.FQDN(), policies[1].Name)
+	assert.Nil(policies[1].HeaderToMetadata)
+}
+
+func TestInboundRulesPerPolicyMetric(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	metricsstore.DefaultMetricsStore.Start(metricsstore.DefaultMetricsStore.InboundRulesPerPolicy)
+	defer metricsstore.DefaultMetricsStore.Stop(metricsstore.DefaultMetricsStore.InboundRulesPerPolicy)
+	metricsstore.DefaultMetricsStore.InboundRulesPerPolicy.Reset()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	httpPort := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	grpcPort := service.MeshService{Name: "s1", Namespace: "ns1", Port: 81, TargetPort: 9090, Protocol: "grpc"}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+					InboundProbePaths:                 []string{"/healthz", "/ready"},
+				},
+			},
+		},
+	})
+
+	// Each port has a wildcard rule and a rule per probe path
+	actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{httpPort, grpcPort})
+	assert.Len(actual[int(httpPort.TargetPort)][0].Rules, 3)
+	assert.Len(actual[int(grpcPort.TargetPort)][0].Rules, 3)
+
+	// Building the policies does not record the metric, only the policies programmed on the proxy are recorded
+	assert.False(metricsstore.DefaultMetricsStore.Contains(`osm_inbound_rules_per_policy{namespace="ns1",service="s1"}`))
+
+	// The rules of the policies on both ports are counted for the service
+	mc.RecordInboundRulesPerPolicy([]service.MeshService{httpPort, grpcPort}, actual)
+	assert.True(metricsstore.DefaultMetricsStore.Contains(`osm_inbound_rules_per_policy{namespace="ns1",service="s1"} 6` + "\n"))
+}
+
+func TestDeleteMetricsOfDeletedServices(t *testing.T) {
+	assert := tassert.New(t)
+
+	metricsstore.DefaultMetricsStore.Start(metricsstore.DefaultMetricsStore.InboundRulesPerPolicy)
+	defer metricsstore.DefaultMetricsStore.Stop(metricsstore.DefaultMetricsStore.InboundRulesPerPolicy)
+	metricsstore.DefaultMetricsStore.InboundRulesPerPolicy.Reset()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	msgBroker := messaging.NewBroker(stop)
+	go deleteMetricsOfDeletedServices(msgBroker, stop)
+
+	metricsstore.DefaultMetricsStore.InboundRulesPerPolicy.WithLabelValues("ns1", "s1").Set(3)
+	metricsstore.DefaultMetricsStore.InboundRulesPerPolicy.WithLabelValues("ns1", "s2").Set(2)
+
+	// The event is published until it is received, as the routine may not have subscribed yet
+	deletedSvc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "s1"}}
+	assert.Eventually(func() bool {
+		msgBroker.PublishKubeEvent(events.PubSubMessage{Kind: events.Service, Type: events.Deleted, OldObj: deletedSvc})
+		return !metricsstore.DefaultMetricsStore.Contains(`osm_inbound_rules_per_policy{namespace="ns1",service="s1"}`)
+	}, time.Second, 10*time.Millisecond)
+
+	// The metric of the other service is kept
+	assert.True(metricsstore.DefaultMetricsStore.Contains(`osm_inbound_rules_per_policy{namespace="ns1",service="s2"} 2` + "\n"))
+}
+
+func TestInboundClusterUpstreamProtocol(t *testing.T) {
+	httpSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: constants.ProtocolHTTP}
+	h2cSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: constants.ProtocolH2C}
+	tcpSvc := service.MeshService{Name: "s3", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: constants.ProtocolTCP}
+
+	testCases := []struct {
+		name                     string
+		upstreamSvc              service.MeshService
+		upstreamTrafficSetting   *policyv1alpha1.UpstreamTrafficSetting
+		expectedUpstreamProtocol string
+	}{
+		{
+			name:                     "HTTP service uses the downstream protocol",
+			upstreamSvc:              httpSvc,
+			expectedUpstreamProtocol: "",
+		},
+		{
+			name:                     "h2c service",
+			upstreamSvc:              h2cSvc,
+			expectedUpstreamProtocol: constants.ProtocolH2C,
+		},
+		{
+			name:        "HTTP service with UpstreamTrafficSetting forcing HTTP/2",
+			upstreamSvc: httpSvc,
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "u1"},
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					Host:             httpSvc.FQDN(),
+					UpstreamProtocol: constants.ProtocolHTTP2,
+				},
+			},
+			expectedUpstreamProtocol: constants.ProtocolHTTP2,
+		},
+		{
+			name:        "TCP service ignores the UpstreamTrafficSetting",
+			upstreamSvc: tcpSvc,
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "u1"},
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					Host:             tcpSvc.FQDN(),
+					UpstreamProtocol: constants.ProtocolHTTP2,
+				},
+			},
+			expectedUpstreamProtocol: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+
+			var upstreamTrafficSettings []*policyv1alpha1.UpstreamTrafficSetting
+			if tc.upstreamTrafficSetting != nil {
+				upstreamTrafficSettings = append(upstreamTrafficSettings, tc.upstreamTrafficSetting)
+			}
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				meshConfig: v1alpha2.MeshConfig{
+					Spec: v1alpha2.MeshConfigSpec{
+						Traffic: v1alpha2.TrafficSpec{
+							EnablePermissiveTrafficPolicyMode: true,
+						},
+					},
+				},
+				upstreamTrafficSettings: upstreamTrafficSettings,
+			})
+
+			clusterConfigs := mc.GetInboundMeshClusterConfigs(upstreamIdentity, []service.MeshService{tc.upstreamSvc})
+			assert.Len(clusterConfigs, 1)
+			assert.Equal(tc.expectedUpstreamProtocol, clusterConfigs[0].UpstreamProtocol)
+		})
+	}
+}
+
+func TestInboundPoliciesWithCrossNamespaceSplitBackend(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns2", Name: "sa2"}.ToServiceIdentity()
+	sharedSvc := service.MeshService{Name: "shared", Namespace: "ns2", Port: 80, TargetPort: 8080, Protocol: "http"}
+	apexSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	trafficSplit := &split.TrafficSplit{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "split1",
+			Namespace: "ns1",
+			Annotations: map[string]string{
+				constants.TrafficSplitBackendRequestHeadersAnnotationPrefix + "/shared.ns2": "x-split=shared",
+			},
+		},
+		Spec: split.TrafficSplitSpec{
+			Service: "s1",
+			Backends: []split.TrafficSplitBackend{
+				{Service: "s1-v1", Weight: 50},
+				{Service: "shared.ns2", Weight: 50},
+			},
+		},
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+				},
+			},
+		},
+		trafficSplits: []*split.TrafficSplit{trafficSplit},
+	})
+
+	actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{sharedSvc})
+	policies := actual[int(sharedSvc.TargetPort)]
+	assert.Len(policies, 2)
+	assert.Equal(sharedSvc.FQDN(), policies[0].Name)
+
+	// The apex service of the TrafficSplit is in the namespace of the TrafficSplit
+	assert.Equal(apexSvc.FQDN(), policies[1].Name)
+	assert.Contains(policies[1].Hostnames, "s1.ns1")
+	assert.NotContains(policies[1].Hostnames, "s1.ns2")
+
+	// The request headers configured for the cross-namespace backend apply to the requests routed through the apex service
+	for _, rule := range policies[1].Rules {
+		for _, clusterInterface := range rule.Route.WeightedClusters.ToSlice() {
+			cluster := clusterInterface.(service.WeightedCluster)
+			assert.Equal(service.ClusterName("ns1/s1|8080|local"), cluster.ClusterName)
+			assert.Equal(service.NewRequestHeaders(map[string]string{"x-split": "shared"}), cluster.RequestHeadersToAdd)
+		}
+	}
+}
+
+func TestInboundEndpointSubsets(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	upstreamTrafficSettings := []*policyv1alpha1.UpstreamTrafficSetting{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "u1"},
+			Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				Host: upstreamSvc.FQDN(),
+				EndpointSubsets: []policyv1alpha1.EndpointSubsetSpec{
+					{Labels: map[string]string{"version": "v1"}, Weight: 80},
+					{Labels: map[string]string{"version": "v2"}, Weight: 20},
+				},
+			},
+		},
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+				},
+			},
+		},
+		upstreamTrafficSettings: upstreamTrafficSettings,
+	})
+
+	v1Selector := service.NewLabelSelector(map[string]string{"version": "v1"})
+	v2Selector := service.NewLabelSelector(map[string]string{"version": "v2"})
+
+	// The local cluster is configured with the selectors of both subsets
+	clusterConfigs := mc.GetInboundMeshClusterConfigs(upstreamIdentity, []service.MeshService{upstreamSvc})
+	assert.Len(clusterConfigs, 1)
+	assert.Equal([]service.LabelSelector{v1Selector, v2Selector}, clusterConfigs[0].SubsetSelectors)
+
+	// The traffic to the local cluster is split across the subsets
+	routeConfigs := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+	policies := routeConfigs[int(upstreamSvc.TargetPort)]
+	assert.Len(policies, 1)
+	assert.Len(policies[0].Rules, 1)
+	assert.True(policies[0].Rules[0].Route.WeightedClusters.Equal(mapset.NewSet(
+		service.WeightedCluster{ClusterName: "ns1/s1|8080|local", Weight: 80, LabelSelector: v1Selector},
+		service.WeightedCluster{ClusterName: "ns1/s1|8080|local", Weight: 20, LabelSelector: v2Selector},
+	)))
+}
+
+func TestResolveRateLimitForHost(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	newRateLimit := func(requests uint32) *policyv1alpha1.RateLimitSpec {
+		return &policyv1alpha1.RateLimitSpec{
+			Local: &policyv1alpha1.LocalRateLimitSpec{
+				HTTP: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: requests, Unit: "second"},
+			},
+		}
+	}
+	newRouteRateLimit := func(requests uint32) *policyv1alpha1.HTTPPerRouteRateLimitSpec {
+		return &policyv1alpha1.HTTPPerRouteRateLimitSpec{
+			Local: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: requests, Unit: "second"},
+		}
+	}
+
+	created := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := &policyv1alpha1.UpstreamTrafficSetting{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "a-newer", CreationTimestamp: metav1.NewTime(created.Add(time.Hour))},
+		Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+			Host:      upstreamSvc.FQDN(),
+			RateLimit: newRateLimit(20),
+			HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+				{Path: "/api", RateLimit: newRouteRateLimit(2)},
+				{Path: "/admin", RateLimit: newRouteRateLimit(3)},
+			},
+		},
+	}
+	older := &policyv1alpha1.UpstreamTrafficSetting{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "b-older", CreationTimestamp: metav1.NewTime(created)},
+		Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+			Host:      upstreamSvc.FQDN(),
+			RateLimit: newRateLimit(10),
+			HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+				{Path: "/api", RateLimit: newRouteRateLimit(1)},
+			},
+		},
+	}
+
+	// The newer setting is listed first, and is the one returned for the service
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+				},
+			},
+		},
+		upstreamTrafficSettings: []*policyv1alpha1.UpstreamTrafficSetting{newer, older},
+	})
+
+	// The oldest setting wins, and paths it does not configure fall back to the newer setting
+	rateLimit, routeRateLimits := mc.resolveRateLimitForHost(upstreamSvc.FQDN())
+	assert.Equal(newRateLimit(10), rateLimit)
+	assert.Equal(map[string]*policyv1alpha1.HTTPPerRouteRateLimitSpec{
+		"/api":   newRouteRateLimit(1),
+		"/admin": newRouteRateLimit(3),
+	}, routeRateLimits)
+
+	// The virtual host of the service is rate limited as configured by the oldest setting
+	routeConfigs := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+	policies := routeConfigs[int(upstreamSvc.TargetPort)]
+	assert.Len(policies, 1)
+	assert.Equal(newRateLimit(10), policies[0].RateLimit)
+
+	// The settings are not modified when resolving the rate limits
+	assert.Equal(newRateLimit(20), newer.Spec.RateLimit)
+	assert.Equal(newRouteRateLimit(2), newer.Spec.HTTPRoutes[0].RateLimit)
+
+	// Settings created at the same time are ordered by name
+	older.CreationTimestamp = newer.CreationTimestamp
+	rateLimit, routeRateLimits = mc.resolveRateLimitForHost(upstreamSvc.FQDN())
+	assert.Equal(newRateLimit(20), rateLimit)
+	assert.Equal(newRouteRateLimit(2), routeRateLimits["/api"])
+
+	// Hosts without settings are not rate limited
+	rateLimit, routeRateLimits = mc.resolveRateLimitForHost("s2.ns1.svc.cluster.local")
+	assert.Nil(rateLimit)
+	assert.Empty(routeRateLimits)
+}
+
+func TestInboundPolicyWithGRPCWeb(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	webSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	otherSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	dbSvc := service.MeshService{Name: "s3", Namespace: "ns1", Port: 5432, TargetPort: 5432, Protocol: "tcp"}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		meshConfig: v1alpha2.MeshConfig{
+			Spec: v1alpha2.MeshConfigSpec{
+				Traffic: v1alpha2.TrafficSpec{
+					EnablePermissiveTrafficPolicyMode: true,
+				},
+			},
+		},
+		upstreamTrafficSettings: []*policyv1alpha1.UpstreamTrafficSetting{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "s1"},
+				Spec:       policyv1alpha1.UpstreamTrafficSettingSpec{Host: webSvc.FQDN(), EnableGRPCWeb: true},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "s3"},
+				Spec:       policyv1alpha1.UpstreamTrafficSettingSpec{Host: dbSvc.FQDN(), EnableGRPCWeb: true},
+			},
+		},
+	})
+
+	actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{webSvc, otherSvc, dbSvc})
+
+	// gRPC-Web is only enabled on the policy of the host the UpstreamTrafficSetting applies to
+	enabled := make(map[string]bool)
+	for _, policy := range actual[int(webSvc.TargetPort)] {
+		enabled[policy.Name] = policy.EnableGRPCWeb
+	}
+	assert.True(enabled[webSvc.FQDN()])
+	assert.Contains(enabled, otherSvc.FQDN())
+	assert.False(enabled[otherSvc.FQDN()])
+
+	// The TCP service has no HTTP policy to enable gRPC-Web on
+	assert.NotContains(actual, int(dbSvc.TargetPort))
+}
+
+func TestInboundPolicyWithWildcardNamespaceSource(t *testing.T) {
+	testCases := []struct {
+		name               string
+		spiffeEnabled      bool
+		expectedPrincipals []string
+	}{
+		{
+			name:               "wildcard namespace source",
+			expectedPrincipals: []string{"prometheus.*.cluster.local", "sa2.ns2.cluster.local"},
+		},
+		{
+			name:               "wildcard namespace source with SPIFFE",
+			spiffeEnabled:      true,
+			expectedPrincipals: []string{"spiffe://cluster.local/prometheus/*", "spiffe://cluster.local/sa2/ns2"},
+		},
+	}
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rule-1"},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{{Name: "metrics", PathRegex: "/metrics", Methods: []string{"GET"}}},
+			},
+		},
+	}
+	trafficTargets := []*access.TrafficTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "t1"},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+				Sources: []access.IdentityBindingSubject{
+					{Kind: "ServiceAccount", Name: "prometheus", Namespace: "*"},
+					{Kind: "ServiceAccount", Name: "sa2", Namespace: "ns2"},
+				},
+				Rules: []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "rule-1", Matches: []string{"metrics"}}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+				trafficTargets:  trafficTargets,
+				httpRouteGroups: httpRouteGroups,
+			})
+
+			actual := mc.BuildInboundPolicyWithTrustDomains(upstreamIdentity, []service.MeshService{upstreamSvc}, []string{"cluster.local"}, tc.spiffeEnabled)
+			assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+			rules := actual[int(upstreamSvc.TargetPort)][0].Rules
+			assert.Len(rules, 1)
+
+			// Only the namespace of the wildcard namespace source is loosened, the other sources are unchanged
+			var principals []string
+			for principal := range rules[0].AllowedPrincipals.Iter() {
+				principals = append(principals, principal.(string))
+			}
+			assert.ElementsMatch(tc.expectedPrincipals, principals)
+		})
+	}
+}
+
+func TestGetInboundMeshHTTPRouteConfigsPerPortWithError(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rule-1"},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{{Name: "get", PathRegex: "/get", Methods: []string{"GET"}}},
+			},
+		},
+	}
+	newTrafficTarget := func(name string, routeGroup string, match string) *access.TrafficTarget {
+		return &access.TrafficTarget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: name},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+				Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "sa2", Namespace: "ns2"}},
+				Rules:       []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: routeGroup, Matches: []string{match}}},
+			},
+		}
+	}
+
+	mc, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{
+		trafficTargets: []*access.TrafficTarget{
+			newTrafficTarget("t1", "rule-1", "get"),
+			newTrafficTarget("t2", "missing", "post"),
+		},
+		httpRouteGroups: httpRouteGroups,
+	})
+	mc.certManager = tresorFake.NewFake(1 * time.Hour)
+
+	actual, err := mc.GetInboundMeshHTTPRouteConfigsPerPortWithError(upstreamIdentity, []service.MeshService{upstreamSvc})
+
+	// The missing HTTPRouteGroup is reported, and the route of the valid TrafficTarget is still built
+	assert.ErrorIs(err, errHTTPRouteGroupNotFound)
+	assert.Contains(err.Error(), "ns1/t2")
+	assert.Contains(err.Error(), "ns1/missing")
+	assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+	rules := actual[int(upstreamSvc.TargetPort)][0].Rules
+	assert.Len(rules, 1)
+	assert.Equal("/get", rules[0].Route.HTTPRouteMatch.Path)
+
+	// The error is logged and dropped by GetInboundMeshHTTPRouteConfigsPerPort, which returns the same policies
+	assert.Equal(actual, mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc}))
+}
+
+func TestInboundRoutesWithDirectResponse(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	mockK8s := k8s.NewMockController(mockCtrl)
+	mc := MeshCatalog{
+		Interface: kube.NewClient(mockK8s),
+	}
+
+	upstreamTrafficSetting := &policyv1alpha1.UpstreamTrafficSetting{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "u1"},
+		Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+			Host: upstreamSvc.FQDN(),
+		},
+	}
+
+	mockK8s.EXPECT().ListUpstreamTrafficSettings().DoAndReturn(func() []*policyv1alpha1.UpstreamTrafficSetting {
+		return []*policyv1alpha1.UpstreamTrafficSetting{upstreamTrafficSetting}
+	}).AnyTimes()
+	mockK8s.EXPECT().ListTrafficSplits().Return(nil).AnyTimes()
+	mockK8s.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+		Spec: v1alpha2.MeshConfigSpec{
+			Traffic: v1alpha2.TrafficSpec{
+				EnablePermissiveTrafficPolicyMode: true,
+			},
+		},
+	}).AnyTimes()
+
+	getRoute := func() trafficpolicy.RouteWeightedClusters {
+		actual := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+		assert.Len(actual[int(upstreamSvc.TargetPort)], 1)
+		rules := actual[int(upstreamSvc.TargetPort)][0].Rules
+		assert.Len(rules, 1)
+		return rules[0].Route
+	}
+
+	// Requests are routed to the local cluster by default
+	route := getRoute()
+	assert.Nil(route.DirectResponse)
+	assert.Equal(1, route.WeightedClusters.Cardinality())
+
+	// The host is put into maintenance, its routes return a 503 instead of routing requests to the local cluster
+	maintenance := &policyv1alpha1.DirectResponseSpec{StatusCode: 503, Body: "down for maintenance"}
+	upstreamTrafficSetting.Spec.DirectResponse = maintenance
+	route = getRoute()
+	assert.Equal(maintenance, route.DirectResponse)
+	assert.Equal(0, route.WeightedClusters.Cardinality())
+
+	// The direct response of a route takes precedence over the direct response of the host
+	routeResponse := &policyv1alpha1.DirectResponseSpec{StatusCode: 200, Body: "ok"}
+	upstreamTrafficSetting.Spec.HTTPRoutes = []policyv1alpha1.HTTPRouteSpec{
+		{Path: constants.RegexMatchAll, DirectResponse: routeResponse},
+	}
+	route = getRoute()
+	assert.Equal(routeResponse, route.DirectResponse)
+
+	// The host is taken out of maintenance
+	upstreamTrafficSetting.Spec.DirectResponse = nil
+	upstreamTrafficSetting.Spec.HTTPRoutes = nil
+	route = getRoute()
+	assert.Nil(route.DirectResponse)
+	assert.Equal(1, route.WeightedClusters.Cardinality())
+}
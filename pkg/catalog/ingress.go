@@ -180,7 +180,7 @@ func (mc *MeshCatalog) GetIngressHTTPRoutePoliciesForSvc(svc service.MeshService
 		// 'Matches' field in the spec can be used to extend this to perform
 		// stricter enforcement.
 		backendCluster := service.WeightedCluster{
-			ClusterName: service.ClusterName(svc.EnvoyLocalClusterName()),
+			ClusterName: service.ClusterName(mc.localClusterName(svc)),
 			Weight:      constants.ClusterWeightAcceptAll,
 		}
 		routingRule := &trafficpolicy.Rule{
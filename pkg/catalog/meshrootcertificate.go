@@ -0,0 +1,106 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set"
+
+	"github.com/openservicemesh/osm/pkg/constants"
+	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/service"
+)
+
+// GetServicesAffectedByMRCRemoval returns the services whose inbound principals include the trust domain of the
+// given MeshRootCertificate. Downstream clients of these services may be cut off if the MeshRootCertificate is removed.
+// Services only allowing any client, ex. in permissive mode, do not depend on the trust domain and are not returned.
+func (mc *MeshCatalog) GetServicesAffectedByMRCRemoval(mrcName string) ([]service.MeshService, error) {
+	mrc := mc.GetMeshRootCertificate(mrcName)
+	if mrc == nil {
+		return nil, fmt.Errorf("%w: %s", errMeshRootCertificateNotFound, mrcName)
+	}
+	trustDomain := mrc.Spec.TrustDomain
+
+	var affectedServices []service.MeshService
+	for _, svc := range mc.ListServices() {
+		svcIdentities, err := mc.ListServiceIdentitiesForService(svc.Name, svc.Namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, svcIdentity := range svcIdentities {
+			if principalsInTrustDomain(mc.getInboundPrincipals(svcIdentity, svc), trustDomain) {
+				affectedServices = append(affectedServices, svc)
+				break
+			}
+		}
+	}
+
+	return affectedServices, nil
+}
+
+// getInboundPrincipals returns the set of principals allowed to access the given upstream service. The inbound policies
+// are built without the inbound policy cache, so that building them for every service does not evict the cached
+// policies of the proxies.
+func (mc *MeshCatalog) getInboundPrincipals(upstreamIdentity identity.ServiceIdentity, upstreamSvc service.MeshService) mapset.Set {
+	principals := mapset.NewSet()
+
+	if upstreamSvc.Protocol != constants.ProtocolTCP && upstreamSvc.Protocol != constants.ProtocolTCPServerFirst {
+		routeConfigPerPort, err := mc.getInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc}, nil)
+		if err != nil {
+			logInboundMeshHTTPRouteConfigsError(err, upstreamIdentity)
+		}
+		for _, policies := range routeConfigPerPort {
+			for _, policy := range policies {
+				for _, rule := range policy.Rules {
+					principals = principals.Union(rule.AllowedPrincipals)
+				}
+			}
+		}
+		return principals
+	}
+
+	// TCP services do not have HTTP routing rules. Any downstream client is allowed in permissive mode as TCP traffic
+	// is not subject to RBAC, otherwise their principals are derived from the TrafficTarget sources.
+	if mc.GetMeshConfig().Spec.Traffic.EnablePermissiveTrafficPolicyMode {
+		principals.Add(identity.WildcardPrincipal)
+		return principals
+	}
+
+	trafficTargets, err := mc.ListInboundTrafficTargetsWithRoutes(upstreamIdentity)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error listing inbound TrafficTargets for upstream identity %s", upstreamIdentity)
+		return principals
+	}
+
+	principalInfos := mc.getIssuerPrincipalInfos()
+	for _, trafficTarget := range trafficTargets {
+		for _, source := range trafficTarget.Sources {
+			for _, principalInfo := range principalInfos {
+				principals.Add(source.AsPrincipal(principalInfo.TrustDomain, principalInfo.SpiffeEnabled))
+			}
+		}
+	}
+
+	return principals
+}
+
+// principalsInTrustDomain returns true if any of the given principals matches the identities of the given trust domain,
+// including the principal matching all the identities of the trust domain. The wildcard principal matching any identity
+// does not depend on the certificates of a particular trust domain, so it is not in any trust domain.
+func principalsInTrustDomain(principals mapset.Set, trustDomain string) bool {
+	for p := range principals.Iter() {
+		principal := p.(string)
+		if principal == identity.TrustDomainPrincipal(trustDomain, false) ||
+			principal == fmt.Sprintf("spiffe://%s", trustDomain) ||
+			strings.HasPrefix(principal, fmt.Sprintf("spiffe://%s/", trustDomain)) {
+			return true
+		}
+
+		// A principal is of the form <name>.<namespace>.<trust-domain>, the trust domain may contain dots
+		if chunks := strings.SplitN(principal, ".", 3); len(chunks) == 3 && chunks[2] == trustDomain {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,272 @@
+package catalog
+
+import (
+	"testing"
+	"time"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/golang/mock/gomock"
+	access "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/access/v1alpha3"
+	spec "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/specs/v1alpha4"
+	tassert "github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
+	tresorFake "github.com/openservicemesh/osm/pkg/certificate/providers/tresor/fake"
+	"github.com/openservicemesh/osm/pkg/compute"
+	configFake "github.com/openservicemesh/osm/pkg/gen/client/config/clientset/versioned/fake"
+	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/service"
+)
+
+func TestGetServicesAffectedByMRCRemoval(t *testing.T) {
+	httpSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	tcpSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 3306, TargetPort: 3306, Protocol: "tcp"}
+	unreferencedSvc := service.MeshService{Name: "s3", Namespace: "ns1", Port: 90, TargetPort: 9090, Protocol: "http"}
+
+	svcIdentities := map[string]identity.ServiceIdentity{
+		httpSvc.Name:         identity.K8sServiceAccount{Name: "sa1", Namespace: "ns1"}.ToServiceIdentity(),
+		tcpSvc.Name:          identity.K8sServiceAccount{Name: "sa2", Namespace: "ns1"}.ToServiceIdentity(),
+		unreferencedSvc.Name: identity.K8sServiceAccount{Name: "sa3", Namespace: "ns1"}.ToServiceIdentity(),
+	}
+
+	newTrafficTarget := func(name, destination string, rule access.TrafficTargetRule) *access.TrafficTarget {
+		return &access.TrafficTarget{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "ns1",
+			},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{
+					Kind:      "ServiceAccount",
+					Name:      destination,
+					Namespace: "ns1",
+				},
+				Sources: []access.IdentityBindingSubject{{
+					Kind:      "ServiceAccount",
+					Name:      "client",
+					Namespace: "ns2",
+				}},
+				Rules: []access.TrafficTargetRule{rule},
+			},
+		}
+	}
+	trafficTargets := []*access.TrafficTarget{
+		newTrafficTarget("t1", "sa1", access.TrafficTargetRule{Kind: "HTTPRouteGroup", Name: "rule-1", Matches: []string{"route-1"}}),
+		newTrafficTarget("t2", "sa2", access.TrafficTargetRule{Kind: "TCPRoute", Name: "rule-2"}),
+	}
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      "rule-1",
+			},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{
+					{
+						Name:      "route-1",
+						PathRegex: "/get",
+					},
+				},
+			},
+		},
+	}
+	tcpRoute := &spec.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "rule-2",
+		},
+		Spec: spec.TCPRouteSpec{
+			Matches: spec.TCPMatch{
+				Ports: []int{3306},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name                        string
+		mrcName                     string
+		mrcTrustDomain              string
+		spiffeEnabled               bool
+		permissiveMode              bool
+		permissiveTrustDomainsScope bool
+		expectedServices            []service.MeshService
+		expectErr                   bool
+	}{
+		{
+			name:             "trust domain in use by some services",
+			mrcName:          "osm-mesh-root-certificate",
+			mrcTrustDomain:   "cluster.local",
+			expectedServices: []service.MeshService{httpSvc, tcpSvc},
+		},
+		{
+			name:             "trust domain in use by some services with SPIFFE enabled",
+			mrcName:          "osm-mesh-root-certificate",
+			mrcTrustDomain:   "cluster.local",
+			spiffeEnabled:    true,
+			expectedServices: []service.MeshService{httpSvc, tcpSvc},
+		},
+		{
+			name:             "trust domain not in use by any service",
+			mrcName:          "other-mesh-root-certificate",
+			mrcTrustDomain:   "other.domain",
+			expectedServices: nil,
+		},
+		{
+			name:             "services only allowing any client in permissive mode are not affected",
+			mrcName:          "osm-mesh-root-certificate",
+			mrcTrustDomain:   "cluster.local",
+			permissiveMode:   true,
+			expectedServices: nil,
+		},
+		{
+			name:                        "permissive mode scoped to the active trust domains",
+			mrcName:                     "osm-mesh-root-certificate",
+			mrcTrustDomain:              "cluster.local",
+			permissiveMode:              true,
+			permissiveTrustDomainsScope: true,
+			expectedServices:            []service.MeshService{httpSvc, unreferencedSvc},
+		},
+		{
+			name:                        "permissive mode scoped to other trust domains",
+			mrcName:                     "other-mesh-root-certificate",
+			mrcTrustDomain:              "other.domain",
+			permissiveMode:              true,
+			permissiveTrustDomainsScope: true,
+			expectedServices:            nil,
+		},
+		{
+			name:      "MeshRootCertificate does not exist",
+			mrcName:   "does-not-exist",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			activeMRC := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain:   "cluster.local",
+					Intent:        v1alpha2.ActiveIntent,
+					SpiffeEnabled: tc.spiffeEnabled,
+				},
+			}
+			configClient := configFake.NewSimpleClientset([]runtime.Object{activeMRC}...)
+			mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+			fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+			mrcClient.NewCertEvent(activeMRC.Name)
+
+			mockCompute := compute.NewMockInterface(mockCtrl)
+			mc := MeshCatalog{
+				certManager: fakeCertManager,
+				Interface:   mockCompute,
+			}
+
+			var mrc *v1alpha2.MeshRootCertificate
+			if tc.mrcTrustDomain != "" {
+				mrc = &v1alpha2.MeshRootCertificate{
+					ObjectMeta: metav1.ObjectMeta{Name: tc.mrcName, Namespace: "osm-system"},
+					Spec:       v1alpha2.MeshRootCertificateSpec{TrustDomain: tc.mrcTrustDomain},
+				}
+			}
+			mockCompute.EXPECT().GetMeshRootCertificate(tc.mrcName).Return(mrc).AnyTimes()
+			mockCompute.EXPECT().ListServices().Return([]service.MeshService{httpSvc, tcpSvc, unreferencedSvc}).AnyTimes()
+			mockCompute.EXPECT().ListServiceIdentitiesForService(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(name, namespace string) ([]identity.ServiceIdentity, error) {
+					return []identity.ServiceIdentity{svcIdentities[name]}, nil
+				}).AnyTimes()
+			mockCompute.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+				Spec: v1alpha2.MeshConfigSpec{
+					Traffic: v1alpha2.TrafficSpec{
+						EnablePermissiveTrafficPolicyMode:            tc.permissiveMode,
+						EnablePermissiveTrafficTrustDomainPrincipals: tc.permissiveTrustDomainsScope,
+					},
+				},
+			}).AnyTimes()
+			mockCompute.EXPECT().ListTrafficSplits().AnyTimes()
+			mockCompute.EXPECT().GetUpstreamTrafficSettingByService(gomock.Any()).AnyTimes()
+			mockCompute.EXPECT().GetHostnamesForService(gomock.Any(), gomock.Any()).AnyTimes()
+			mockCompute.EXPECT().ListTrafficTargets().Return(trafficTargets).AnyTimes()
+			mockCompute.EXPECT().ListHTTPTrafficSpecs().Return(httpRouteGroups).AnyTimes()
+			mockCompute.EXPECT().GetTCPRoute("ns1/rule-2").Return(tcpRoute).AnyTimes()
+
+			actual, err := mc.GetServicesAffectedByMRCRemoval(tc.mrcName)
+			assert.Equal(tc.expectErr, err != nil)
+			assert.ElementsMatch(tc.expectedServices, actual)
+		})
+	}
+}
+
+func TestPrincipalsInTrustDomain(t *testing.T) {
+	testCases := []struct {
+		name        string
+		principals  mapset.Set
+		trustDomain string
+		expected    bool
+	}{
+		{
+			name:        "principal in trust domain",
+			principals:  mapset.NewSet("sa.ns.cluster.local"),
+			trustDomain: "cluster.local",
+			expected:    true,
+		},
+		{
+			name:        "SPIFFE principal in trust domain",
+			principals:  mapset.NewSet("spiffe://cluster.local/sa/ns"),
+			trustDomain: "cluster.local",
+			expected:    true,
+		},
+		{
+			name:        "principal in a different trust domain",
+			principals:  mapset.NewSet("sa.ns.cluster.local", "spiffe://cluster.local/sa/ns"),
+			trustDomain: "other.local",
+			expected:    false,
+		},
+		{
+			name:        "principal in a nested trust domain",
+			principals:  mapset.NewSet("sa.ns.evil.cluster.local"),
+			trustDomain: "cluster.local",
+			expected:    false,
+		},
+		{
+			name:        "wildcard principal",
+			principals:  mapset.NewSet(identity.WildcardPrincipal),
+			trustDomain: "cluster.local",
+			expected:    false,
+		},
+		{
+			name:        "trust domain principal",
+			principals:  mapset.NewSet(identity.TrustDomainPrincipal("cluster.local", false)),
+			trustDomain: "cluster.local",
+			expected:    true,
+		},
+		{
+			name:        "SPIFFE trust domain principal",
+			principals:  mapset.NewSet(identity.TrustDomainPrincipal("cluster.local", true)),
+			trustDomain: "cluster.local",
+			expected:    true,
+		},
+		{
+			name:        "trust domain principal of a different trust domain",
+			principals:  mapset.NewSet(identity.TrustDomainPrincipal("other.local", false)),
+			trustDomain: "cluster.local",
+			expected:    false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			assert.Equal(tc.expected, principalsInTrustDomain(tc.principals, tc.trustDomain))
+		})
+	}
+}
@@ -1,7 +1,17 @@
 package catalog
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
 	mapset "github.com/deckarep/golang-set"
+	smiSplit "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/split/v1alpha2"
+
+	configv1alpha2 "github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
+	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
 
 	"github.com/openservicemesh/osm/pkg/constants"
 	"github.com/openservicemesh/osm/pkg/errcode"
@@ -17,7 +27,11 @@ func (mc *MeshCatalog) GetOutboundMeshTrafficMatches(downstreamIdentity identity
 
 	for _, meshSvc := range mc.ListOutboundServicesForIdentity(downstreamIdentity) {
 		meshSvc := meshSvc // To prevent loop variable memory aliasing in for loop
-		upstreamClusters := mc.getUpstreamClusters(meshSvc)
+		upstreamClusters, err := mc.getUpstreamClusters(downstreamIdentity, meshSvc)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error computing upstream clusters for service %s, skipping traffic match", meshSvc)
+			continue
+		}
 		var destinationIPRanges []string
 		destinationIPSet := mapset.NewSet()
 		for _, endp := range mc.GetResolvableEndpointsForService(meshSvc) {
@@ -44,22 +58,80 @@ func (mc *MeshCatalog) GetOutboundMeshTrafficMatches(downstreamIdentity identity
 	return trafficMatches
 }
 
-// GetOutboundMeshClusterConfigs returns the cluster configs for the outbound mesh traffic policy for the given downstream identity
+// GetOutboundMeshClusterConfigs returns the cluster configs for the outbound mesh traffic policy for the given downstream identity.
+// When locality aware load balancing is enabled, the cluster configs have the locality weights of the zones of the
+// endpoints the downstream identity is allowed to access, preferring the zone of the downstream identity.
 func (mc *MeshCatalog) GetOutboundMeshClusterConfigs(downstreamIdentity identity.ServiceIdentity) []*trafficpolicy.MeshClusterConfig {
 	var clusterConfigs []*trafficpolicy.MeshClusterConfig
 
-	for _, meshSvc := range mc.ListOutboundServicesForIdentity(downstreamIdentity) {
-		meshSvc := meshSvc // To prevent loop variable memory aliasing in for loop
+	trafficSpec := mc.GetMeshConfig().Spec.Traffic
+	var downstreamZone string
+	if trafficSpec.EnableLocalityAwareLoadBalancing {
+		downstreamZone = mc.GetIdentityZone(downstreamIdentity)
+	}
 
+	outboundServices := mc.ListOutboundServicesForIdentity(downstreamIdentity)
+	for _, meshSvc := range outboundServices {
 		// ---
 		// Create the cluster config for this upstream service
-		clusterConfigForServicePort := &trafficpolicy.MeshClusterConfig{
-			Name:                          meshSvc.EnvoyClusterName(),
-			Service:                       meshSvc,
-			EnableEnvoyActiveHealthChecks: mc.GetMeshConfig().Spec.FeatureFlags.EnableEnvoyActiveHealthChecks,
-			UpstreamTrafficSetting:        mc.GetUpstreamTrafficSettingByService(&meshSvc),
+		clusterConfig := mc.getOutboundMeshClusterConfig(meshSvc)
+		if trafficSpec.EnableLocalityAwareLoadBalancing {
+			clusterConfig.LocalityWeights = GetLocalityWeights(mc.ListAllowedUpstreamEndpointsForService(downstreamIdentity, meshSvc), downstreamZone)
+		}
+		clusterConfigs = append(clusterConfigs, clusterConfig)
+	}
+
+	if trafficSpec.PrewarmZeroWeightBackends {
+		clusterConfigs = append(clusterConfigs, mc.getZeroWeightBackendClusterConfigs(outboundServices)...)
+	}
+
+	return clusterConfigs
+}
+
+// getOutboundMeshClusterConfig returns the cluster config for the given upstream service
+func (mc *MeshCatalog) getOutboundMeshClusterConfig(meshSvc service.MeshService) *trafficpolicy.MeshClusterConfig {
+	clusterConfig := &trafficpolicy.MeshClusterConfig{
+		Name:                          meshSvc.EnvoyClusterName(),
+		Service:                       meshSvc,
+		EnableEnvoyActiveHealthChecks: mc.GetMeshConfig().Spec.FeatureFlags.EnableEnvoyActiveHealthChecks,
+		UpstreamTrafficSetting:        mc.GetUpstreamTrafficSettingByService(&meshSvc),
+	}
+	if clusterConfig.UpstreamTrafficSetting != nil {
+		clusterConfig.HealthCheck = clusterConfig.UpstreamTrafficSetting.Spec.HealthCheck
+		clusterConfig.OutlierDetection = clusterConfig.UpstreamTrafficSetting.Spec.OutlierDetection
+		clusterConfig.LoadBalancer = clusterConfig.UpstreamTrafficSetting.Spec.LBPolicy
+	}
+	return clusterConfig
+}
+
+// getZeroWeightBackendClusterConfigs returns the cluster configs for the zero-weight backends of the TrafficSplits
+// for the given apex services, excluding the clusters of the given services themselves
+func (mc *MeshCatalog) getZeroWeightBackendClusterConfigs(apexServices []service.MeshService) []*trafficpolicy.MeshClusterConfig {
+	var clusterConfigs []*trafficpolicy.MeshClusterConfig
+
+	clusterSet := mapset.NewSet()
+	for _, meshSvc := range apexServices {
+		clusterSet.Add(meshSvc.EnvoyClusterName())
+	}
+
+	for _, meshSvc := range apexServices {
+		for _, split := range mc.ListTrafficSplitsByOptions(smi.WithTrafficSplitApexService(meshSvc)) {
+			for _, backend := range split.Spec.Backends {
+				if _, isExternal := smi.GetTrafficSplitExternalBackendHost(backend.Service); backend.Weight != 0 || isExternal {
+					continue
+				}
+				backendMeshSvc, err := mc.getSplitBackendMeshService(split, backend.Service, meshSvc.Port)
+				if err != nil {
+					log.Error().Err(err).Msgf("Error fetching zero-weight backend %s for TrafficSplit %s/%s, ignoring it",
+						backend.Service, split.Namespace, split.Name)
+					continue
+				}
+				if added := clusterSet.Add(backendMeshSvc.EnvoyClusterName()); !added {
+					continue
+				}
+				clusterConfigs = append(clusterConfigs, mc.getOutboundMeshClusterConfig(backendMeshSvc))
+			}
 		}
-		clusterConfigs = append(clusterConfigs, clusterConfigForServicePort)
 	}
 
 	return clusterConfigs
@@ -68,35 +140,93 @@ func (mc *MeshCatalog) GetOutboundMeshClusterConfigs(downstreamIdentity identity
 // GetOutboundMeshHTTPRouteConfigsPerPort returns the map of outbound traffic policies per port for the given downstream identity
 func (mc *MeshCatalog) GetOutboundMeshHTTPRouteConfigsPerPort(downstreamIdentity identity.ServiceIdentity) map[int][]*trafficpolicy.OutboundTrafficPolicy {
 	routeConfigPerPort := make(map[int][]*trafficpolicy.OutboundTrafficPolicy)
-	downstreamSvcAccount := downstreamIdentity.ToK8sServiceAccount()
 
 	// For each service, build the traffic policies required to access it.
 	// It is important to aggregate HTTP route configs by the service's port.
 	for _, meshSvc := range mc.ListOutboundServicesForIdentity(downstreamIdentity) {
-		meshSvc := meshSvc // To prevent loop variable memory aliasing in for loop
-		upstreamClusters := mc.getUpstreamClusters(meshSvc)
-		retryPolicy := mc.getRetryPolicy(downstreamIdentity, meshSvc)
+		if outboundTrafficPolicy := mc.getOutboundMeshHTTPRouteConfig(downstreamIdentity, meshSvc); outboundTrafficPolicy != nil {
+			routeConfigPerPort[int(meshSvc.Port)] = append(routeConfigPerPort[int(meshSvc.Port)], outboundTrafficPolicy)
+		}
+	}
+
+	return routeConfigPerPort
+}
+
+// GetOutboundRouteConfigsForDestination returns the map of outbound traffic policies per port the given source identity uses
+// to reach the given destination service, as returned by GetOutboundMeshHTTPRouteConfigsPerPort. The destination matches the
+// services with the same name and namespace, on all their ports unless the port of the destination is specified.
+// The map is empty if the source is not allowed to reach the destination.
+func (mc *MeshCatalog) GetOutboundRouteConfigsForDestination(source identity.ServiceIdentity, dest service.MeshService) map[int][]*trafficpolicy.OutboundTrafficPolicy {
+	routeConfigPerPort := make(map[int][]*trafficpolicy.OutboundTrafficPolicy)
 
-		// Build the HTTP route configs for this service and port combination.
-		// If the port's protocol corresponds to TCP, we can skip this step
-		if meshSvc.Protocol == constants.ProtocolTCP || meshSvc.Protocol == constants.ProtocolTCPServerFirst {
+	for _, meshSvc := range mc.ListOutboundServicesForIdentity(source) {
+		if meshSvc.Name != dest.Name || meshSvc.Namespace != dest.Namespace || (dest.Port != 0 && meshSvc.Port != dest.Port) {
 			continue
 		}
-		// Create a route to access the upstream service via it's hostnames and upstream weighted clusters
-		httpHostNamesForServicePort := mc.GetHostnamesForService(meshSvc, downstreamSvcAccount.Namespace == meshSvc.Namespace)
-		outboundTrafficPolicy := trafficpolicy.NewOutboundTrafficPolicy(meshSvc.FQDN(), httpHostNamesForServicePort)
-		if err := outboundTrafficPolicy.AddRoute(trafficpolicy.WildCardRouteMatch, retryPolicy, upstreamClusters...); err != nil {
-			log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrAddingRouteToOutboundTrafficPolicy)).
-				Msgf("Error adding route to outbound mesh HTTP traffic policy for destination %s", meshSvc)
-			continue
+		if outboundTrafficPolicy := mc.getOutboundMeshHTTPRouteConfig(source, meshSvc); outboundTrafficPolicy != nil {
+			routeConfigPerPort[int(meshSvc.Port)] = append(routeConfigPerPort[int(meshSvc.Port)], outboundTrafficPolicy)
 		}
-		routeConfigPerPort[int(meshSvc.Port)] = append(routeConfigPerPort[int(meshSvc.Port)], outboundTrafficPolicy)
 	}
 
 	return routeConfigPerPort
 }
 
-func (mc *MeshCatalog) getUpstreamClusters(meshSvc service.MeshService) []service.WeightedCluster {
+// getOutboundMeshHTTPRouteConfig returns the outbound traffic policy the given downstream identity uses to access the given
+// service, or nil if the service is not accessed over HTTP or the policy cannot be built
+func (mc *MeshCatalog) getOutboundMeshHTTPRouteConfig(downstreamIdentity identity.ServiceIdentity, meshSvc service.MeshService) *trafficpolicy.OutboundTrafficPolicy {
+	// Build the HTTP route configs for this service and port combination.
+	// If the port's protocol corresponds to TCP, we can skip this step
+	if meshSvc.Protocol == constants.ProtocolTCP || meshSvc.Protocol == constants.ProtocolTCPServerFirst ||
+		meshSvc.Protocol == constants.ProtocolTLSPassthrough {
+		return nil
+	}
+
+	upstreamClusters, err := mc.getUpstreamClusters(downstreamIdentity, meshSvc)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error computing upstream clusters for service %s, skipping HTTP route", meshSvc)
+		return nil
+	}
+	retryPolicy := mc.getRetryPolicy(downstreamIdentity, meshSvc)
+	// Create a route to access the upstream service via it's hostnames and upstream weighted clusters
+	downstreamSvcAccount := downstreamIdentity.ToK8sServiceAccount()
+	httpHostNamesForServicePort := mc.GetHostnamesForService(meshSvc, downstreamSvcAccount.Namespace == meshSvc.Namespace)
+	outboundTrafficPolicy := trafficpolicy.NewOutboundTrafficPolicy(meshSvc.FQDN(), httpHostNamesForServicePort)
+	if err := outboundTrafficPolicy.AddRoute(trafficpolicy.WildCardRouteMatch, retryPolicy, upstreamClusters...); err != nil {
+		log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrAddingRouteToOutboundTrafficPolicy)).
+			Msgf("Error adding route to outbound mesh HTTP traffic policy for destination %s", meshSvc)
+		return nil
+	}
+	runtimeKeyPrefix := getWeightedClusterRuntimeKeyPrefix(mc.GetMeshConfig().Spec.Traffic.WeightedClusterRuntimeKeyPrefix, meshSvc)
+	requestMirrorPolicies := mc.getRequestMirrorPolicies(meshSvc)
+	consistentHashLBPolicy := mc.getConsistentHashLBPolicy(meshSvc)
+	for _, route := range outboundTrafficPolicy.Routes {
+		route.RuntimeKeyPrefix = runtimeKeyPrefix
+		route.RequestMirrorPolicies = requestMirrorPolicies
+		if consistentHashLBPolicy != nil {
+			route.HashKeyHeader = consistentHashLBPolicy.HashKeyHeader
+			route.HashKeyCookie = consistentHashLBPolicy.HashKeyCookie
+		}
+	}
+
+	return outboundTrafficPolicy
+}
+
+// getConsistentHashLBPolicy returns the load balancing policy of the given service if it uses consistent hashing load
+// balancing, in which case the hash keys of the policy are hashed to select the endpoints of the service, or nil otherwise
+func (mc *MeshCatalog) getConsistentHashLBPolicy(meshSvc service.MeshService) *policyv1alpha1.LBPolicySpec {
+	upstreamTrafficSetting := mc.GetUpstreamTrafficSettingByService(&meshSvc)
+	if upstreamTrafficSetting == nil || !isConsistentHashLBPolicy(upstreamTrafficSetting.Spec.LBPolicy) {
+		return nil
+	}
+	return upstreamTrafficSetting.Spec.LBPolicy
+}
+
+// isConsistentHashLBPolicy returns true if the given load balancing policy selects endpoints by consistent hashing
+func isConsistentHashLBPolicy(lbPolicy *policyv1alpha1.LBPolicySpec) bool {
+	return lbPolicy != nil && (lbPolicy.Type == policyv1alpha1.LBPolicyRingHash || lbPolicy.Type == policyv1alpha1.LBPolicyMaglev)
+}
+
+func (mc *MeshCatalog) getUpstreamClusters(downstreamIdentity identity.ServiceIdentity, meshSvc service.MeshService) ([]service.WeightedCluster, error) {
 	var upstreamClusters []service.WeightedCluster
 	// Check if there is a traffic split corresponding to this service.
 	// The upstream clusters are to be derived from the traffic split backends
@@ -110,11 +240,51 @@ func (mc *MeshCatalog) getUpstreamClusters(meshSvc service.MeshService) []servic
 	if len(trafficSplits) != 0 {
 		// Program routes to the backends specified in the traffic split
 		split := trafficSplits[0] // TODO(#2759): support multiple traffic splits per apex service
+		missingBackendMode := mc.GetMeshConfig().Spec.Traffic.TrafficSplitMissingBackendMode
 
+		totalWeight, resolvedWeight := 0, 0
+		var backendServices []service.MeshService
+		hasExternalBackend := false
 		for _, backend := range split.Spec.Backends {
-			backendMeshSvc, err := mc.GetMeshService(backend.Service, meshSvc.Namespace, meshSvc.Port)
+			if _, isMirror := getBackendMirrorPercentage(split, backend.Service); isMirror {
+				// Requests are mirrored to a mirror backend instead of being routed to it, so it is
+				// excluded from the weight distribution
+				continue
+			}
+
+			totalWeight += backend.Weight
+
+			if backend.Weight == 0 {
+				// No traffic is routed to a zero-weight backend, and a weighted cluster with a weight of 0 is
+				// rejected by Envoy, so the backend is excluded from the weighted clusters. Its cluster may still
+				// be pre-warmed so that it is ready once it is promoted.
+				continue
+			}
+
+			if host, isExternal := smi.GetTrafficSplitExternalBackendHost(backend.Service); isExternal {
+				// Route the backend's weight to the DNS resolvable cluster for the external host
+				upstreamClusters = append(upstreamClusters, service.WeightedCluster{
+					ClusterName: service.ClusterName(getExternalBackendClusterName(host, int(meshSvc.Port))),
+					Weight:      backend.Weight,
+				})
+				resolvedWeight += backend.Weight
+				hasExternalBackend = true
+				continue
+			}
+
+			backendMeshSvc, err := mc.getSplitBackendMeshService(split, backend.Service, meshSvc.Port)
+			if errors.Is(err, errUnknownTrafficSplitBackendNamespace) {
+				// A reference to a namespace outside the mesh is never resolvable, so the backend is dropped
+				log.Warn().Err(err).Msgf("Ignoring backend %s for TrafficSplit %s/%s", backend.Service, split.Namespace, split.Name)
+				continue
+			}
 			if err != nil {
-				log.Error().Err(err).Msgf("Error fetching target port for leaf service %s, ignoring it", backendMeshSvc)
+				if missingBackendMode == configv1alpha2.TrafficSplitMissingBackendError {
+					return nil, fmt.Errorf("%w: backend %s of TrafficSplit %s/%s: %s", errMissingTrafficSplitBackend,
+						backend.Service, split.Namespace, split.Name, err)
+				}
+				log.Error().Err(err).Msgf("Error fetching leaf service %s for TrafficSplit %s/%s, ignoring it",
+					backend.Service, split.Namespace, split.Name)
 				continue
 			}
 
@@ -123,6 +293,40 @@ func (mc *MeshCatalog) getUpstreamClusters(meshSvc service.MeshService) []servic
 				Weight:      backend.Weight,
 			}
 			upstreamClusters = append(upstreamClusters, wc)
+			resolvedWeight += backend.Weight
+			backendServices = append(backendServices, backendMeshSvc)
+		}
+
+		// Redistribute the weight of skipped backends across the remaining backends
+		if resolvedWeight > 0 && resolvedWeight != totalWeight {
+			upstreamClusters = renormalizeWeightedClusters(upstreamClusters, resolvedWeight, totalWeight)
+		}
+
+		if mc.GetMeshConfig().Spec.Traffic.TrafficSplitDrainedBackendsMode == configv1alpha2.TrafficSplitDrainedBackendsFailoverToApex &&
+			!hasExternalBackend && mc.areSplitBackendsDrained(downstreamIdentity, backendServices) {
+			// Failing over is only useful if the apex service can serve the traffic instead
+			if len(mc.ListAllowedUpstreamEndpointsForService(downstreamIdentity, meshSvc)) > 0 {
+				log.Warn().Msgf("All backends of TrafficSplit %s/%s have no ready endpoints, failing over to apex service %s",
+					split.Namespace, split.Name, meshSvc)
+				upstreamClusters = []service.WeightedCluster{{
+					ClusterName: service.ClusterName(meshSvc.EnvoyClusterName()),
+					Weight:      constants.ClusterWeightAcceptAll,
+				}}
+			} else {
+				log.Warn().Msgf("All backends of TrafficSplit %s/%s and apex service %s have no ready endpoints, retaining the backends",
+					split.Namespace, split.Name, meshSvc)
+			}
+		}
+
+		if len(upstreamClusters) == 0 {
+			// A route without weighted clusters is rejected by Envoy, so the traffic is routed to the apex service
+			// when every backend is zero-weight or skipped
+			log.Warn().Msgf("No backend of TrafficSplit %s/%s can receive traffic, routing to apex service %s instead",
+				split.Namespace, split.Name, meshSvc)
+			upstreamClusters = []service.WeightedCluster{{
+				ClusterName: service.ClusterName(meshSvc.EnvoyClusterName()),
+				Weight:      constants.ClusterWeightAcceptAll,
+			}}
 		}
 	} else {
 		wc := service.WeightedCluster{
@@ -133,7 +337,218 @@ func (mc *MeshCatalog) getUpstreamClusters(meshSvc service.MeshService) []servic
 		upstreamClusters = append(upstreamClusters, wc)
 	}
 
-	return upstreamClusters
+	return upstreamClusters, nil
+}
+
+// getRequestMirrorPolicies returns the policies mirroring the requests to the given upstream service to the mirror
+// backends of its TrafficSplit, as configured by the TrafficSplit annotations for the backends
+func (mc *MeshCatalog) getRequestMirrorPolicies(meshSvc service.MeshService) []trafficpolicy.RequestMirrorPolicy {
+	trafficSplits := mc.ListTrafficSplitsByOptions(smi.WithTrafficSplitApexService(meshSvc))
+	if len(trafficSplits) == 0 {
+		return nil
+	}
+
+	var requestMirrorPolicies []trafficpolicy.RequestMirrorPolicy
+	split := trafficSplits[0] // TODO(#2759): support multiple traffic splits per apex service
+	for _, backend := range split.Spec.Backends {
+		percentage, isMirror := getBackendMirrorPercentage(split, backend.Service)
+		if !isMirror {
+			continue
+		}
+
+		var clusterName string
+		if host, isExternal := smi.GetTrafficSplitExternalBackendHost(backend.Service); isExternal {
+			clusterName = getExternalBackendClusterName(host, int(meshSvc.Port))
+		} else {
+			backendMeshSvc, err := mc.getSplitBackendMeshService(split, backend.Service, meshSvc.Port)
+			if err != nil {
+				log.Error().Err(err).Msgf("Error fetching mirror backend %s for TrafficSplit %s/%s, ignoring it",
+					backend.Service, split.Namespace, split.Name)
+				continue
+			}
+			clusterName = backendMeshSvc.EnvoyClusterName()
+		}
+
+		requestMirrorPolicies = append(requestMirrorPolicies, trafficpolicy.RequestMirrorPolicy{
+			ClusterName: service.ClusterName(clusterName),
+			Percentage:  percentage,
+		})
+	}
+
+	return requestMirrorPolicies
+}
+
+// getBackendMirrorPercentage returns the percentage of the requests mirrored to the given backend of the TrafficSplit,
+// and whether the backend is a mirror backend, as configured by the TrafficSplit annotation for the backend.
+// An invalid percentage is ignored, in which case the backend is not a mirror backend.
+func getBackendMirrorPercentage(split *smiSplit.TrafficSplit, backend string) (uint32, bool) {
+	annotation := fmt.Sprintf("%s/%s", constants.TrafficSplitBackendMirrorAnnotationPrefix, backend)
+	value, ok := split.Annotations[annotation]
+	if !ok {
+		return 0, false
+	}
+
+	percentage, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+	if err != nil || percentage == 0 || percentage > 100 {
+		log.Warn().Msgf("Ignoring invalid mirror percentage %q in annotation %s on TrafficSplit %s/%s, expected an integer between 1 and 100",
+			value, annotation, split.Namespace, split.Name)
+		return 0, false
+	}
+	return uint32(percentage), true
+}
+
+// areSplitBackendsDrained returns true if the given TrafficSplit backend services are all without ready endpoints the
+// given downstream identity is allowed to reach, i.e. without endpoints programmed by EDS for the downstream identity
+func (mc *MeshCatalog) areSplitBackendsDrained(downstreamIdentity identity.ServiceIdentity, backendServices []service.MeshService) bool {
+	if len(backendServices) == 0 {
+		return false
+	}
+	for _, backendSvc := range backendServices {
+		if len(mc.ListAllowedUpstreamEndpointsForService(downstreamIdentity, backendSvc)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// GetOutboundExternalClusterConfigs returns the cluster configs for the external hosts referenced as backends by the
+// TrafficSplits of the services the given downstream identity is allowed to reach
+func (mc *MeshCatalog) GetOutboundExternalClusterConfigs(downstreamIdentity identity.ServiceIdentity) []*trafficpolicy.EgressClusterConfig {
+	var clusterConfigs []*trafficpolicy.EgressClusterConfig
+	clusterSet := mapset.NewSet()
+
+	for _, meshSvc := range mc.ListOutboundServicesForIdentity(downstreamIdentity) {
+		for _, split := range mc.ListTrafficSplitsByOptions(smi.WithTrafficSplitApexService(meshSvc)) {
+			for _, backend := range split.Spec.Backends {
+				host, isExternal := smi.GetTrafficSplitExternalBackendHost(backend.Service)
+				if !isExternal {
+					continue
+				}
+				clusterName := getExternalBackendClusterName(host, int(meshSvc.Port))
+				if added := clusterSet.Add(clusterName); !added {
+					continue
+				}
+				clusterConfigs = append(clusterConfigs, &trafficpolicy.EgressClusterConfig{
+					Name: clusterName,
+					Host: host,
+					Port: int(meshSvc.Port),
+				})
+			}
+		}
+	}
+
+	return clusterConfigs
+}
+
+// getSplitBackendMeshService returns the MeshService for the given port of the service referenced by the given
+// backend of the TrafficSplit, which can reside in another namespace than the TrafficSplit
+func (mc *MeshCatalog) getSplitBackendMeshService(split *smiSplit.TrafficSplit, backend string, port uint16) (service.MeshService, error) {
+	name, namespace := smi.GetTrafficSplitBackendService(split, backend)
+	if namespace != split.Namespace && !mc.isMonitoredNamespace(namespace) {
+		return service.MeshService{}, fmt.Errorf("%w: %s", errUnknownTrafficSplitBackendNamespace, namespace)
+	}
+	return mc.GetMeshService(name, namespace, port)
+}
+
+// isMonitoredNamespace returns a boolean indicating if the given namespace is monitored by the mesh
+func (mc *MeshCatalog) isMonitoredNamespace(namespace string) bool {
+	namespaces, err := mc.ListNamespaces()
+	if err != nil {
+		log.Error().Err(err).Msg("Error listing the namespaces monitored by the mesh")
+		return false
+	}
+	for _, ns := range namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// getExternalBackendClusterName returns the name of the cluster for the given external host and port, which
+// matches the name of the cluster generated for the same host and port by an Egress policy
+func getExternalBackendClusterName(host string, port int) string {
+	return fmt.Sprintf("%s:%d", host, port)
+}
+
+// renormalizeWeightedClusters scales the weights of the given clusters, which add up to currentTotal,
+// so that they add up to targetTotal while preserving their relative proportions. The weight lost to
+// integer division is assigned to the clusters with the largest remainders, and a cluster with a non-zero
+// weight is never scaled down to a zero weight.
+func renormalizeWeightedClusters(clusters []service.WeightedCluster, currentTotal int, targetTotal int) []service.WeightedCluster {
+	renormalized := make([]service.WeightedCluster, 0, len(clusters))
+	remainders := make([]int, 0, len(clusters))
+	sum := 0
+	for _, wc := range clusters {
+		scaled := wc
+		scaled.Weight = wc.Weight * targetTotal / currentTotal
+		remainder := wc.Weight * targetTotal % currentTotal
+		if wc.Weight > 0 && scaled.Weight == 0 {
+			scaled.Weight = 1
+			remainder = 0
+		}
+		renormalized = append(renormalized, scaled)
+		remainders = append(remainders, remainder)
+		sum += scaled.Weight
+	}
+
+	// Distribute the weight lost to integer division, or take back the weight added by the minimum of 1,
+	// so that the weights add up to targetTotal
+	order := make([]int, len(renormalized))
+	for i := range order {
+		order[i] = i
+	}
+	for sum < targetTotal {
+		sort.SliceStable(order, func(i, j int) bool { return remainders[order[i]] > remainders[order[j]] })
+		idx := order[0]
+		renormalized[idx].Weight++
+		remainders[idx] = -1
+		sum++
+	}
+	for sum > targetTotal {
+		sort.SliceStable(order, func(i, j int) bool { return renormalized[order[i]].Weight > renormalized[order[j]].Weight })
+		idx := order[0]
+		if renormalized[idx].Weight <= 1 {
+			break
+		}
+		renormalized[idx].Weight--
+		sum--
+	}
+
+	return renormalized
+}
+
+// GetOutboundAllowedServices returns the services the given downstream identity is allowed to reach, as per the
+// SMI TrafficTarget policies or permissive traffic policy mode. The services are resolved the same way as for the
+// outbound cluster configs, and include the apex services of TrafficSplits whose backends are allowed.
+func (mc *MeshCatalog) GetOutboundAllowedServices(downstreamIdentity identity.ServiceIdentity) ([]service.MeshService, error) {
+	if downstreamIdentity.IsWildcard() || !strings.Contains(downstreamIdentity.String(), ".") {
+		return nil, fmt.Errorf("%w: %s", errInvalidServiceIdentity, downstreamIdentity)
+	}
+
+	svcSet := mapset.NewSet()
+	var allowedServices []service.MeshService
+	for _, svc := range mc.ListOutboundServicesForIdentity(downstreamIdentity) {
+		if added := svcSet.Add(svc); added {
+			allowedServices = append(allowedServices, svc)
+		}
+
+		// An allowed TrafficSplit backend makes its apex service reachable
+		for _, split := range mc.ListTrafficSplitsByOptions(smi.WithTrafficSplitBackendService(svc)) {
+			// The apex service is in the namespace of the TrafficSplit, which may differ from the backend's namespace
+			apexSvc, err := mc.GetMeshService(split.Spec.Service, split.Namespace, svc.Port)
+			if err != nil {
+				log.Error().Err(err).Msgf("Error fetching apex service %s/%s for TrafficSplit %s/%s, ignoring it",
+					split.Namespace, split.Spec.Service, split.Namespace, split.Name)
+				continue
+			}
+			if added := svcSet.Add(apexSvc); added {
+				allowedServices = append(allowedServices, apexSvc)
+			}
+		}
+	}
+
+	return allowedServices, nil
 }
 
 // ListOutboundServicesForIdentity list the services the given service account is allowed to initiate outbound connections to
@@ -149,7 +564,7 @@ func (mc *MeshCatalog) ListOutboundServicesForIdentity(serviceIdentity identity.
 
 	for _, t := range mc.ListTrafficTargetsByOptions() { // loop through all traffic targets
 		for _, source := range t.Spec.Sources {
-			if source.Name != svcAccount.Name || source.Namespace != svcAccount.Namespace {
+			if !isTrafficTargetSourceMatch(source, svcAccount) {
 				// Source doesn't match the downstream's service identity
 				continue
 			}
@@ -1,8 +1,10 @@
 package catalog
 
 import (
+	"errors"
 	"net"
 	"testing"
+	"time"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/golang/mock/gomock"
@@ -10,11 +12,12 @@ import (
 	split "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/split/v1alpha2"
 	tassert "github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 
 	"github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
 	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
 	"github.com/openservicemesh/osm/pkg/compute"
-	"github.com/openservicemesh/osm/pkg/compute/kube"
+	"github.com/openservicemesh/osm/pkg/constants"
 
 	"github.com/openservicemesh/osm/pkg/endpoint"
 	"github.com/openservicemesh/osm/pkg/identity"
@@ -169,6 +172,11 @@ func TestGetOutboundMeshTrafficPolicy(t *testing.T) {
 		},
 		Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
 			Host: meshSvc1P1.FQDN(),
+			ConnectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+				HTTP: &policyv1alpha1.HTTPConnectionSettings{
+					MaxRequestsPerConnection: pointer.Uint32(10),
+				},
+			},
 		},
 	}
 
@@ -602,11 +610,12 @@ func TestGetOutboundMeshTrafficPolicy(t *testing.T) {
 			mockProvider.EXPECT().GetMeshService(meshSvc3V2.Name, meshSvc3V2.Namespace, meshSvc3.Port).Return(meshSvc3V2, nil).AnyTimes()
 
 			// Mock ServiceIdentity -> Service lookups executed when TrafficTargets are evaluated
+			hostnamesClient, _ := newFakeMeshCatalogWithResources(mockCtrl, catalogTestResources{})
 			for svcIdentity, services := range svcIdentityToSvcMapping {
 				mockProvider.EXPECT().GetServicesForServiceIdentity(svcIdentity).Return(services).AnyTimes()
 				for _, service := range services {
 					mockProvider.EXPECT().GetHostnamesForService(service, downstreamIdentity.ToK8sServiceAccount().Namespace == service.Namespace).
-						Return(kube.NewClient(nil).GetHostnamesForService(service, downstreamIdentity.ToK8sServiceAccount().Namespace == service.Namespace)).AnyTimes()
+						Return(hostnamesClient.GetHostnamesForService(service, downstreamIdentity.ToK8sServiceAccount().Namespace == service.Namespace)).AnyTimes()
 				}
 			}
 
@@ -693,3 +702,1241 @@ func TestListOutboundServicesForIdentity(t *testing.T) {
 		})
 	}
 }
+
+func TestOutboundPolicyWithWildcardNamespaceSource(t *testing.T) {
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 8080, TargetPort: 8080, Protocol: "http"}
+	upstreamIdentity := identity.K8sServiceAccount{Name: "sa1", Namespace: "ns1"}.ToServiceIdentity()
+
+	trafficTarget := &access.TrafficTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "t1", Namespace: "ns1"},
+		Spec: access.TrafficTargetSpec{
+			Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+			Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "prometheus", Namespace: "*"}},
+			Rules:       []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "routes", Matches: []string{"metrics"}}},
+		},
+	}
+
+	testCases := []struct {
+		name               string
+		downstreamIdentity identity.ServiceIdentity
+		expectAllowed      bool
+	}{
+		{
+			name:               "service account of the wildcard namespace source in an arbitrary namespace",
+			downstreamIdentity: identity.K8sServiceAccount{Name: "prometheus", Namespace: "monitoring"}.ToServiceIdentity(),
+			expectAllowed:      true,
+		},
+		{
+			name:               "other service account in an arbitrary namespace",
+			downstreamIdentity: identity.K8sServiceAccount{Name: "grafana", Namespace: "monitoring"}.ToServiceIdentity(),
+			expectAllowed:      false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockProvider := compute.NewMockInterface(mockCtrl)
+			mc := MeshCatalog{Interface: mockProvider}
+
+			mockProvider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{}).AnyTimes()
+			mockProvider.EXPECT().ListTrafficTargets().Return([]*access.TrafficTarget{trafficTarget}).AnyTimes()
+			mockProvider.EXPECT().GetServicesForServiceIdentity(upstreamIdentity).Return([]service.MeshService{upstreamSvc}).AnyTimes()
+			mockProvider.EXPECT().ListTrafficSplits().Return(nil).AnyTimes()
+			mockProvider.EXPECT().GetUpstreamTrafficSettingByService(gomock.Any()).Return(nil).AnyTimes()
+			mockProvider.EXPECT().GetHostnamesForService(upstreamSvc, false).Return([]string{upstreamSvc.FQDN()}).AnyTimes()
+
+			routeConfigs := mc.GetOutboundMeshHTTPRouteConfigsPerPort(tc.downstreamIdentity)
+			outboundIdentities := mc.ListOutboundServiceIdentities(tc.downstreamIdentity)
+			if !tc.expectAllowed {
+				assert.Empty(routeConfigs)
+				assert.Empty(outboundIdentities)
+				return
+			}
+
+			// The downstream gets the routes to, and may reach the endpoints of, the upstream service
+			assert.Len(routeConfigs[int(upstreamSvc.Port)], 1)
+			assert.Equal([]identity.ServiceIdentity{upstreamIdentity}, outboundIdentities)
+		})
+	}
+}
+
+func TestGetUpstreamClustersWithMissingSplitBackend(t *testing.T) {
+	apexSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 8080, TargetPort: 8080, Protocol: "http"}
+	downstreamIdentity := identity.K8sServiceAccount{Name: "sa2", Namespace: "ns2"}.ToServiceIdentity()
+	backendV1 := service.MeshService{Name: "s1-v1", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+	backendV2 := service.MeshService{Name: "s1-v2", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+
+	trafficSplit := &split.TrafficSplit{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "split1",
+			Namespace: "ns1",
+		},
+		Spec: split.TrafficSplitSpec{
+			Service: "s1",
+			Backends: []split.TrafficSplitBackend{
+				{Service: "s1-v1", Weight: 10},
+				{Service: "s1-v2", Weight: 30},
+				{Service: "s1-missing", Weight: 60},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name             string
+		mode             v1alpha2.TrafficSplitMissingBackendMode
+		expectedClusters []service.WeightedCluster
+		expectErr        bool
+	}{
+		{
+			name: "missing backend is skipped and weights are renormalized by default",
+			mode: "",
+			expectedClusters: []service.WeightedCluster{
+				{ClusterName: "ns1/s1-v1|80", Weight: 25},
+				{ClusterName: "ns1/s1-v2|80", Weight: 75},
+			},
+		},
+		{
+			name: "missing backend is skipped and weights are renormalized",
+			mode: v1alpha2.TrafficSplitMissingBackendSkip,
+			expectedClusters: []service.WeightedCluster{
+				{ClusterName: "ns1/s1-v1|80", Weight: 25},
+				{ClusterName: "ns1/s1-v2|80", Weight: 75},
+			},
+		},
+		{
+			name:      "missing backend results in an error",
+			mode:      v1alpha2.TrafficSplitMissingBackendError,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockProvider := compute.NewMockInterface(mockCtrl)
+			mc := MeshCatalog{
+				Interface: mockProvider,
+			}
+
+			mockProvider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+				Spec: v1alpha2.MeshConfigSpec{
+					Traffic: v1alpha2.TrafficSpec{
+						TrafficSplitMissingBackendMode: tc.mode,
+					},
+				},
+			}).AnyTimes()
+			mockProvider.EXPECT().ListTrafficSplits().Return([]*split.TrafficSplit{trafficSplit}).AnyTimes()
+			mockProvider.EXPECT().GetMeshService(backendV1.Name, backendV1.Namespace, apexSvc.Port).Return(backendV1, nil).AnyTimes()
+			mockProvider.EXPECT().GetMeshService(backendV2.Name, backendV2.Namespace, apexSvc.Port).Return(backendV2, nil).AnyTimes()
+			mockProvider.EXPECT().GetMeshService("s1-missing", "ns1", apexSvc.Port).Return(service.MeshService{}, errors.New("service not found")).AnyTimes()
+
+			actual, err := mc.getUpstreamClusters(downstreamIdentity, apexSvc)
+			assert.Equal(tc.expectErr, err != nil)
+			assert.Equal(tc.expectedClusters, actual)
+		})
+	}
+}
+
+func TestGetUpstreamClustersWithZeroWeightSplitBackend(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	apexSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 8080, TargetPort: 8080, Protocol: "http"}
+	downstreamIdentity := identity.K8sServiceAccount{Name: "sa2", Namespace: "ns2"}.ToServiceIdentity()
+	backendV1 := service.MeshService{Name: "s1-v1", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+	backendV2 := service.MeshService{Name: "s1-v2", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+
+	trafficSplit := &split.TrafficSplit{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "split1",
+			Namespace: "ns1",
+		},
+		Spec: split.TrafficSplitSpec{
+			Service: "s1",
+			Backends: []split.TrafficSplitBackend{
+				{Service: "s1-v1", Weight: 0},
+				{Service: "s1-v2", Weight: 100},
+				{Service: "external:legacy.example.com", Weight: 0},
+			},
+		},
+	}
+
+	mockProvider := compute.NewMockInterface(mockCtrl)
+	mc := MeshCatalog{
+		Interface: mockProvider,
+	}
+
+	mockProvider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{}).AnyTimes()
+	mockProvider.EXPECT().ListTrafficSplits().Return([]*split.TrafficSplit{trafficSplit}).AnyTimes()
+	mockProvider.EXPECT().GetMeshService(backendV1.Name, backendV1.Namespace, apexSvc.Port).Return(backendV1, nil).AnyTimes()
+	mockProvider.EXPECT().GetMeshService(backendV2.Name, backendV2.Namespace, apexSvc.Port).Return(backendV2, nil).AnyTimes()
+
+	actual, err := mc.getUpstreamClusters(downstreamIdentity, apexSvc)
+	assert.NoError(err)
+
+	// The zero-weight backends are excluded from the weighted clusters instead of being included with a weight of 0
+	weightedClusters := trafficpolicy.NewRouteWeightedCluster(trafficpolicy.WildCardRouteMatch, actual, nil).WeightedClusters
+	assert.Equal(mapset.NewSet(service.WeightedCluster{ClusterName: "ns1/s1-v2|80", Weight: 100}), weightedClusters)
+	assert.False(weightedClusters.Contains(service.WeightedCluster{ClusterName: "ns1/s1-v1|80", Weight: 0}))
+}
+
+func TestGetUpstreamClustersWithoutRoutableSplitBackend(t *testing.T) {
+	apexSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 8080, TargetPort: 8080, Protocol: "http"}
+	downstreamIdentity := identity.K8sServiceAccount{Name: "sa2", Namespace: "ns2"}.ToServiceIdentity()
+	backendV1 := service.MeshService{Name: "s1-v1", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+
+	testCases := []struct {
+		name     string
+		backends []split.TrafficSplitBackend
+	}{
+		{
+			name: "every backend is zero-weight",
+			backends: []split.TrafficSplitBackend{
+				{Service: "s1-v1", Weight: 0},
+				{Service: "external:legacy.example.com", Weight: 0},
+			},
+		},
+		{
+			name: "every weighted backend is skipped",
+			backends: []split.TrafficSplitBackend{
+				{Service: "s1-v1", Weight: 0},
+				{Service: "s1-missing", Weight: 100},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			trafficSplit := &split.TrafficSplit{
+				ObjectMeta: metav1.ObjectMeta{Name: "split1", Namespace: "ns1"},
+				Spec: split.TrafficSplitSpec{
+					Service:  "s1",
+					Backends: tc.backends,
+				},
+			}
+
+			mockProvider := compute.NewMockInterface(mockCtrl)
+			mc := MeshCatalog{
+				Interface: mockProvider,
+			}
+
+			mockProvider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{}).AnyTimes()
+			mockProvider.EXPECT().ListTrafficSplits().Return([]*split.TrafficSplit{trafficSplit}).AnyTimes()
+			mockProvider.EXPECT().GetMeshService(backendV1.Name, backendV1.Namespace, apexSvc.Port).Return(backendV1, nil).AnyTimes()
+			mockProvider.EXPECT().GetMeshService("s1-missing", "ns1", apexSvc.Port).Return(service.MeshService{}, errors.New("not found")).AnyTimes()
+
+			actual, err := mc.getUpstreamClusters(downstreamIdentity, apexSvc)
+			assert.NoError(err)
+
+			// The traffic is routed to the apex service instead of an empty set of weighted clusters
+			assert.Equal([]service.WeightedCluster{{ClusterName: "ns1/s1|8080", Weight: constants.ClusterWeightAcceptAll}}, actual)
+		})
+	}
+}
+
+func TestGetUpstreamClustersWithMirrorSplitBackend(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	apexSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 8080, TargetPort: 8080, Protocol: "http"}
+	downstreamIdentity := identity.K8sServiceAccount{Name: "sa2", Namespace: "ns2"}.ToServiceIdentity()
+	backendV1 := service.MeshService{Name: "s1-v1", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+	backendV2 := service.MeshService{Name: "s1-v2", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+	shadow := service.MeshService{Name: "s1-shadow", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+
+	trafficSplit := &split.TrafficSplit{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "split1",
+			Namespace: "ns1",
+			Annotations: map[string]string{
+				"mirror.openservicemesh.io/s1-shadow": "25",
+				// An invalid percentage is ignored
+				"mirror.openservicemesh.io/s1-v2": "200",
+			},
+		},
+		Spec: split.TrafficSplitSpec{
+			Service: "s1",
+			Backends: []split.TrafficSplitBackend{
+				{Service: "s1-v1", Weight: 50},
+				{Service: "s1-v2", Weight: 50},
+				{Service: "s1-shadow", Weight: 50},
+			},
+		},
+	}
+
+	mockProvider := compute.NewMockInterface(mockCtrl)
+	mc := MeshCatalog{
+		Interface: mockProvider,
+	}
+
+	mockProvider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{}).AnyTimes()
+	mockProvider.EXPECT().ListTrafficSplits().Return([]*split.TrafficSplit{trafficSplit}).AnyTimes()
+	mockProvider.EXPECT().GetMeshService(backendV1.Name, backendV1.Namespace, apexSvc.Port).Return(backendV1, nil).AnyTimes()
+	mockProvider.EXPECT().GetMeshService(backendV2.Name, backendV2.Namespace, apexSvc.Port).Return(backendV2, nil).AnyTimes()
+	mockProvider.EXPECT().GetMeshService(shadow.Name, shadow.Namespace, apexSvc.Port).Return(shadow, nil).AnyTimes()
+
+	// The mirror backend does not affect the weight distribution of the other backends
+	actual, err := mc.getUpstreamClusters(downstreamIdentity, apexSvc)
+	assert.NoError(err)
+	assert.Equal([]service.WeightedCluster{
+		{ClusterName: "ns1/s1-v1|80", Weight: 50},
+		{ClusterName: "ns1/s1-v2|80", Weight: 50},
+	}, actual)
+
+	assert.Equal([]trafficpolicy.RequestMirrorPolicy{
+		{ClusterName: "ns1/s1-shadow|80", Percentage: 25},
+	}, mc.getRequestMirrorPolicies(apexSvc))
+
+	// Services without a TrafficSplit have no request mirror policies
+	assert.Nil(mc.getRequestMirrorPolicies(backendV1))
+}
+
+func TestGetUpstreamClustersWithCrossNamespaceSplitBackend(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	apexSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 8080, TargetPort: 8080, Protocol: "http"}
+	downstreamIdentity := identity.K8sServiceAccount{Name: "sa2", Namespace: "ns2"}.ToServiceIdentity()
+	localBackend := service.MeshService{Name: "s1-v1", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+	sharedBackend := service.MeshService{Name: "shared", Namespace: "ns2", Port: 8080, TargetPort: 80, Protocol: "http"}
+
+	trafficSplit := &split.TrafficSplit{
+		ObjectMeta: metav1.ObjectMeta{Name: "split1", Namespace: "ns1"},
+		Spec: split.TrafficSplitSpec{
+			Service: "s1",
+			Backends: []split.TrafficSplitBackend{
+				{Service: "s1-v1", Weight: 40},
+				{Service: "shared.ns2", Weight: 40},
+				// Referencing a namespace that is not part of the mesh, dropped
+				{Service: "shared.unknown", Weight: 20},
+			},
+		},
+	}
+
+	mockProvider := compute.NewMockInterface(mockCtrl)
+	mc := MeshCatalog{
+		Interface: mockProvider,
+	}
+
+	mockProvider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{}).AnyTimes()
+	mockProvider.EXPECT().ListTrafficSplits().Return([]*split.TrafficSplit{trafficSplit}).AnyTimes()
+	mockProvider.EXPECT().ListNamespaces().Return([]string{"ns1", "ns2"}, nil).AnyTimes()
+	mockProvider.EXPECT().GetMeshService(localBackend.Name, localBackend.Namespace, apexSvc.Port).Return(localBackend, nil).AnyTimes()
+	mockProvider.EXPECT().GetMeshService(sharedBackend.Name, sharedBackend.Namespace, apexSvc.Port).Return(sharedBackend, nil).AnyTimes()
+
+	// The cluster of the cross-namespace backend is in the backend's namespace, and the weight
+	// of the dropped backend is redistributed
+	actual, err := mc.getUpstreamClusters(downstreamIdentity, apexSvc)
+	assert.NoError(err)
+	assert.Equal([]service.WeightedCluster{
+		{ClusterName: "ns1/s1-v1|80", Weight: 50},
+		{ClusterName: "ns2/shared|80", Weight: 50},
+	}, actual)
+}
+
+func TestRenormalizeWeightedClusters(t *testing.T) {
+	assert := tassert.New(t)
+
+	clusters := []service.WeightedCluster{
+		{ClusterName: "ns1/s1-v1|80", Weight: 1},
+		{ClusterName: "ns1/s1-v2|80", Weight: 2},
+	}
+
+	actual := renormalizeWeightedClusters(clusters, 3, 100)
+	assert.Equal([]service.WeightedCluster{
+		{ClusterName: "ns1/s1-v1|80", Weight: 33},
+		{ClusterName: "ns1/s1-v2|80", Weight: 67},
+	}, actual)
+
+	// The input must not be modified
+	assert.Equal(1, clusters[0].Weight)
+	assert.Equal(2, clusters[1].Weight)
+
+	// Proportions that don't divide evenly add up to the target total, and the other fields are preserved
+	clusters = []service.WeightedCluster{
+		{ClusterName: "ns1/s1-v1|80", Weight: 1, RequestHeadersToAdd: service.NewRequestHeaders(map[string]string{"x-version": "v1"})},
+		{ClusterName: "ns1/s1-v2|80", Weight: 1},
+		{ClusterName: "ns1/s1-v3|80", Weight: 1},
+	}
+	actual = renormalizeWeightedClusters(clusters, 3, 100)
+	assert.Equal([]service.WeightedCluster{
+		{ClusterName: "ns1/s1-v1|80", Weight: 34, RequestHeadersToAdd: service.NewRequestHeaders(map[string]string{"x-version": "v1"})},
+		{ClusterName: "ns1/s1-v2|80", Weight: 33},
+		{ClusterName: "ns1/s1-v3|80", Weight: 33},
+	}, actual)
+
+	// Small weights are not scaled down to zero
+	clusters = []service.WeightedCluster{
+		{ClusterName: "ns1/s1-v1|80", Weight: 1},
+		{ClusterName: "ns1/s1-v2|80", Weight: 1},
+		{ClusterName: "ns1/s1-v3|80", Weight: 998},
+	}
+	actual = renormalizeWeightedClusters(clusters, 1000, 100)
+	assert.Equal([]service.WeightedCluster{
+		{ClusterName: "ns1/s1-v1|80", Weight: 1},
+		{ClusterName: "ns1/s1-v2|80", Weight: 1},
+		{ClusterName: "ns1/s1-v3|80", Weight: 98},
+	}, actual)
+}
+
+func TestGetOutboundAllowedServices(t *testing.T) {
+	testCases := []struct {
+		name             string
+		permissiveMode   bool
+		downstream       identity.ServiceIdentity
+		expectedServices []service.MeshService
+		expectErr        bool
+	}{
+		{
+			name:             "SMI mode includes the apex service of allowed split backends",
+			permissiveMode:   false,
+			downstream:       tests.BookbuyerServiceIdentity,
+			expectedServices: []service.MeshService{tests.BookstoreV1Service, tests.BookstoreV2Service, tests.BookstoreApexService},
+		},
+		{
+			name:             "SMI mode without traffic targets for the identity",
+			permissiveMode:   false,
+			downstream:       identity.K8sServiceAccount{Name: "some-name", Namespace: "some-ns"}.ToServiceIdentity(),
+			expectedServices: nil,
+		},
+		{
+			name:             "permissive mode allows all services",
+			permissiveMode:   true,
+			downstream:       tests.BookbuyerServiceIdentity,
+			expectedServices: []service.MeshService{tests.BookstoreApexService, tests.BookbuyerService, tests.BookstoreV1Service, tests.BookstoreV2Service},
+		},
+		{
+			name:       "invalid identity",
+			downstream: identity.ServiceIdentity("invalid"),
+			expectErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			provider := compute.NewMockInterface(mockCtrl)
+			mc := MeshCatalog{Interface: provider}
+
+			provider.EXPECT().ListServices().Return([]service.MeshService{
+				tests.BookstoreApexService,
+				tests.BookbuyerService,
+				tests.BookstoreV1Service,
+				tests.BookstoreV2Service,
+			}).AnyTimes()
+			provider.EXPECT().GetServicesForServiceIdentity(gomock.Any()).Return([]service.MeshService{
+				tests.BookstoreV1Service, tests.BookstoreV2Service,
+			}).AnyTimes()
+			provider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+				Spec: v1alpha2.MeshConfigSpec{
+					Traffic: v1alpha2.TrafficSpec{
+						EnablePermissiveTrafficPolicyMode: tc.permissiveMode,
+					},
+				},
+			}).AnyTimes()
+			provider.EXPECT().ListTrafficTargets().Return([]*access.TrafficTarget{&tests.TrafficTarget, &tests.BookstoreV2TrafficTarget}).AnyTimes()
+			provider.EXPECT().ListTrafficSplits().Return([]*split.TrafficSplit{&tests.TrafficSplit}).AnyTimes()
+			provider.EXPECT().GetMeshService(tests.BookstoreApexServiceName, tests.Namespace, uint16(tests.ServicePort)).Return(tests.BookstoreApexService, nil).AnyTimes()
+
+			actual, err := mc.GetOutboundAllowedServices(tc.downstream)
+			assert.Equal(tc.expectErr, err != nil)
+			assert.ElementsMatch(tc.expectedServices, actual)
+		})
+	}
+}
+
+func TestGetOutboundMeshClusterConfigsWithTCPKeepalive(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	provider := compute.NewMockInterface(mockCtrl)
+	mc := MeshCatalog{Interface: provider}
+
+	tcpSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 3306, TargetPort: 3306, Protocol: "tcp"}
+	httpSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	keepalive := &policyv1alpha1.TCPKeepaliveSpec{
+		Probes:   pointer.Uint32(3),
+		Time:     &metav1.Duration{Duration: 60 * time.Second},
+		Interval: &metav1.Duration{Duration: 10 * time.Second},
+	}
+	upstreamTrafficSetting := &policyv1alpha1.UpstreamTrafficSetting{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1"},
+		Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+			Host: tcpSvc.FQDN(),
+			ConnectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+				TCP: &policyv1alpha1.TCPConnectionSettings{
+					TCPKeepalive: keepalive,
+				},
+			},
+		},
+	}
+
+	provider.EXPECT().ListServices().Return([]service.MeshService{tcpSvc, httpSvc}).AnyTimes()
+	provider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+		Spec: v1alpha2.MeshConfigSpec{
+			Traffic: v1alpha2.TrafficSpec{
+				EnablePermissiveTrafficPolicyMode: true,
+			},
+		},
+	}).AnyTimes()
+	provider.EXPECT().GetUpstreamTrafficSettingByService(&tcpSvc).Return(upstreamTrafficSetting).AnyTimes()
+	provider.EXPECT().GetUpstreamTrafficSettingByService(&httpSvc).Return(nil).AnyTimes()
+
+	configs := mc.GetOutboundMeshClusterConfigs(tests.BookbuyerServiceIdentity)
+	assert.Len(configs, 2)
+	for _, config := range configs {
+		switch config.Service {
+		case tcpSvc:
+			// The TCP keep-alive settings are threaded into the tcp cluster
+			assert.NotNil(config.UpstreamTrafficSetting)
+			assert.Equal(keepalive, config.UpstreamTrafficSetting.Spec.ConnectionSettings.TCP.TCPKeepalive)
+		case httpSvc:
+			// TCP keep-alive is off for clusters without the setting
+			assert.Nil(config.UpstreamTrafficSetting)
+		default:
+			t.Errorf("unexpected cluster config for service %s", config.Service)
+		}
+	}
+}
+
+func TestGetOutboundMeshClusterConfigsWithHealthCheck(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	provider := compute.NewMockInterface(mockCtrl)
+	mc := MeshCatalog{Interface: provider}
+
+	tcpSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 3306, TargetPort: 3306, Protocol: "tcp"}
+	httpSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	healthCheck := &policyv1alpha1.HealthCheckSpec{
+		Interval:           &metav1.Duration{Duration: 5 * time.Second},
+		UnhealthyThreshold: pointer.Uint32(2),
+	}
+	upstreamTrafficSetting := &policyv1alpha1.UpstreamTrafficSetting{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1"},
+		Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+			Host:        tcpSvc.FQDN(),
+			HealthCheck: healthCheck,
+		},
+	}
+
+	provider.EXPECT().ListServices().Return([]service.MeshService{tcpSvc, httpSvc}).AnyTimes()
+	provider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+		Spec: v1alpha2.MeshConfigSpec{
+			Traffic: v1alpha2.TrafficSpec{
+				EnablePermissiveTrafficPolicyMode: true,
+			},
+		},
+	}).AnyTimes()
+	provider.EXPECT().GetUpstreamTrafficSettingByService(&tcpSvc).Return(upstreamTrafficSetting).AnyTimes()
+	provider.EXPECT().GetUpstreamTrafficSettingByService(&httpSvc).Return(nil).AnyTimes()
+
+	configs := mc.GetOutboundMeshClusterConfigs(tests.BookbuyerServiceIdentity)
+	assert.Len(configs, 2)
+	for _, config := range configs {
+		switch config.Service {
+		case tcpSvc:
+			assert.Equal(healthCheck, config.HealthCheck)
+		case httpSvc:
+			assert.Nil(config.HealthCheck)
+		default:
+			t.Errorf("unexpected cluster config for service %s", config.Service)
+		}
+	}
+}
+
+func TestGetOutboundMeshClusterConfigsWithOutlierDetection(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	provider := compute.NewMockInterface(mockCtrl)
+	mc := MeshCatalog{Interface: provider}
+
+	svc1 := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	svc2 := service.MeshService{Name: "s2", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	outlierDetection := &policyv1alpha1.OutlierDetectionSpec{
+		Consecutive5xx:     pointer.Uint32(3),
+		Interval:           &metav1.Duration{Duration: 5 * time.Second},
+		BaseEjectionTime:   &metav1.Duration{Duration: time.Minute},
+		MaxEjectionPercent: pointer.Uint32(50),
+	}
+	upstreamTrafficSetting := &policyv1alpha1.UpstreamTrafficSetting{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1"},
+		Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+			Host:             svc1.FQDN(),
+			OutlierDetection: outlierDetection,
+		},
+	}
+
+	provider.EXPECT().ListServices().Return([]service.MeshService{svc1, svc2}).AnyTimes()
+	provider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+		Spec: v1alpha2.MeshConfigSpec{
+			Traffic: v1alpha2.TrafficSpec{
+				EnablePermissiveTrafficPolicyMode: true,
+			},
+		},
+	}).AnyTimes()
+	provider.EXPECT().GetUpstreamTrafficSettingByService(&svc1).Return(upstreamTrafficSetting).AnyTimes()
+	provider.EXPECT().GetUpstreamTrafficSettingByService(&svc2).Return(nil).AnyTimes()
+
+	configs := mc.GetOutboundMeshClusterConfigs(tests.BookbuyerServiceIdentity)
+	assert.Len(configs, 2)
+	for _, config := range configs {
+		switch config.Service {
+		case svc1:
+			assert.Equal(outlierDetection, config.OutlierDetection)
+		case svc2:
+			// Outlier detection is disabled without the setting
+			assert.Nil(config.OutlierDetection)
+		default:
+			t.Errorf("unexpected cluster config for service %s", config.Service)
+		}
+	}
+}
+
+func TestGetOutboundMeshConfigsWithLBPolicy(t *testing.T) {
+	cookieAffinity := &policyv1alpha1.LBPolicySpec{
+		Type:          policyv1alpha1.LBPolicyRingHash,
+		HashKeyCookie: &policyv1alpha1.HashKeyCookieSpec{Name: "session", TTL: &metav1.Duration{Duration: time.Hour}},
+	}
+
+	testCases := []struct {
+		name                           string
+		lbPolicy                       *policyv1alpha1.LBPolicySpec
+		expectedConsistentHashLBPolicy *policyv1alpha1.LBPolicySpec
+	}{
+		{
+			name:                           "no load balancing policy",
+			lbPolicy:                       nil,
+			expectedConsistentHashLBPolicy: nil,
+		},
+		{
+			name:                           "least request",
+			lbPolicy:                       &policyv1alpha1.LBPolicySpec{Type: policyv1alpha1.LBPolicyLeastRequest},
+			expectedConsistentHashLBPolicy: nil,
+		},
+		{
+			name: "hash keys are ignored without consistent hashing",
+			lbPolicy: &policyv1alpha1.LBPolicySpec{
+				Type:          policyv1alpha1.LBPolicyRoundRobin,
+				HashKeyCookie: &policyv1alpha1.HashKeyCookieSpec{Name: "session"},
+			},
+			expectedConsistentHashLBPolicy: nil,
+		},
+		{
+			name:                           "ring hash with a hash key header",
+			lbPolicy:                       &policyv1alpha1.LBPolicySpec{Type: policyv1alpha1.LBPolicyRingHash, HashKeyHeader: "x-user-id"},
+			expectedConsistentHashLBPolicy: &policyv1alpha1.LBPolicySpec{Type: policyv1alpha1.LBPolicyRingHash, HashKeyHeader: "x-user-id"},
+		},
+		{
+			name:                           "ring hash with cookie based session affinity",
+			lbPolicy:                       cookieAffinity,
+			expectedConsistentHashLBPolicy: cookieAffinity,
+		},
+		{
+			name:                           "maglev with a hash key header",
+			lbPolicy:                       &policyv1alpha1.LBPolicySpec{Type: policyv1alpha1.LBPolicyMaglev, HashKeyHeader: "x-user-id"},
+			expectedConsistentHashLBPolicy: &policyv1alpha1.LBPolicySpec{Type: policyv1alpha1.LBPolicyMaglev, HashKeyHeader: "x-user-id"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			provider := compute.NewMockInterface(mockCtrl)
+			mc := MeshCatalog{Interface: provider}
+
+			svc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+			upstreamTrafficSetting := &policyv1alpha1.UpstreamTrafficSetting{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1"},
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					Host:     svc.FQDN(),
+					LBPolicy: tc.lbPolicy,
+				},
+			}
+
+			provider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{}).AnyTimes()
+			provider.EXPECT().GetUpstreamTrafficSettingByService(&svc).Return(upstreamTrafficSetting).AnyTimes()
+
+			// The load balancing policy is threaded into the cluster config of the service
+			assert.Equal(tc.lbPolicy, mc.getOutboundMeshClusterConfig(svc).LoadBalancer)
+
+			// The hash keys are only set on the routes to a consistent hashing cluster
+			assert.Equal(tc.expectedConsistentHashLBPolicy, mc.getConsistentHashLBPolicy(svc))
+		})
+	}
+}
+
+func TestGetOutboundMeshClusterConfigsWithCircuitBreakerThresholds(t *testing.T) {
+	meshSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	testCases := []struct {
+		name               string
+		connectionSettings *policyv1alpha1.ConnectionSettingsSpec
+	}{
+		{
+			name: "max connections",
+			connectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+				TCP: &policyv1alpha1.TCPConnectionSettings{MaxConnections: pointer.Uint32(1)},
+			},
+		},
+		{
+			name: "max pending requests",
+			connectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+				HTTP: &policyv1alpha1.HTTPConnectionSettings{MaxPendingRequests: pointer.Uint32(2)},
+			},
+		},
+		{
+			name: "max requests",
+			connectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+				HTTP: &policyv1alpha1.HTTPConnectionSettings{MaxRequests: pointer.Uint32(3)},
+			},
+		},
+		{
+			name: "max retries",
+			connectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+				HTTP: &policyv1alpha1.HTTPConnectionSettings{MaxRetries: pointer.Uint32(4)},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			provider := compute.NewMockInterface(mockCtrl)
+			mc := MeshCatalog{Interface: provider}
+
+			upstreamTrafficSetting := &policyv1alpha1.UpstreamTrafficSetting{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1"},
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					Host:               meshSvc.FQDN(),
+					ConnectionSettings: tc.connectionSettings,
+				},
+			}
+
+			provider.EXPECT().ListServices().Return([]service.MeshService{meshSvc}).AnyTimes()
+			provider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+				Spec: v1alpha2.MeshConfigSpec{
+					Traffic: v1alpha2.TrafficSpec{
+						EnablePermissiveTrafficPolicyMode: true,
+					},
+				},
+			}).AnyTimes()
+			provider.EXPECT().GetUpstreamTrafficSettingByService(&meshSvc).Return(upstreamTrafficSetting).AnyTimes()
+
+			configs := mc.GetOutboundMeshClusterConfigs(tests.BookbuyerServiceIdentity)
+			assert.Len(configs, 1)
+			assert.NotNil(configs[0].UpstreamTrafficSetting)
+			// Only the configured threshold is set, the others are left unset to preserve the defaults
+			assert.Equal(tc.connectionSettings, configs[0].UpstreamTrafficSetting.Spec.ConnectionSettings)
+		})
+	}
+}
+
+func TestOutboundPolicyWithExternalSplitBackend(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	apexSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 8080, TargetPort: 8080, Protocol: "http"}
+	backendSvc := service.MeshService{Name: "s1-v1", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+	downstreamIdentity := identity.K8sServiceAccount{Name: "sa2", Namespace: "ns2"}.ToServiceIdentity()
+
+	trafficSplit := &split.TrafficSplit{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "split1",
+			Namespace: "ns1",
+		},
+		Spec: split.TrafficSplitSpec{
+			Service: "s1",
+			Backends: []split.TrafficSplitBackend{
+				{Service: "s1-v1", Weight: 70},
+				{Service: "external:legacy.example.com", Weight: 30},
+			},
+		},
+	}
+
+	mockProvider := compute.NewMockInterface(mockCtrl)
+	mc := MeshCatalog{Interface: mockProvider}
+
+	mockProvider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+		Spec: v1alpha2.MeshConfigSpec{
+			Traffic: v1alpha2.TrafficSpec{
+				EnablePermissiveTrafficPolicyMode: true,
+			},
+		},
+	}).AnyTimes()
+	mockProvider.EXPECT().ListServices().Return([]service.MeshService{apexSvc, backendSvc}).AnyTimes()
+	mockProvider.EXPECT().ListTrafficSplits().Return([]*split.TrafficSplit{trafficSplit}).AnyTimes()
+	mockProvider.EXPECT().GetMeshService(backendSvc.Name, backendSvc.Namespace, apexSvc.Port).Return(backendSvc, nil).AnyTimes()
+	mockProvider.EXPECT().GetHostnamesForService(gomock.Any(), gomock.Any()).Return([]string{"s1.ns1"}).AnyTimes()
+	mockProvider.EXPECT().GetUpstreamTrafficSettingByService(gomock.Any()).Return(nil).AnyTimes()
+
+	// The weight of the external backend is routed to the DNS cluster for the external host
+	expectedClusters := []service.WeightedCluster{
+		{ClusterName: "ns1/s1-v1|80", Weight: 70},
+		{ClusterName: "legacy.example.com:8080", Weight: 30},
+	}
+	actualClusters, err := mc.getUpstreamClusters(downstreamIdentity, apexSvc)
+	assert.NoError(err)
+	assert.Equal(expectedClusters, actualClusters)
+
+	routeConfigs := mc.GetOutboundMeshHTTPRouteConfigsPerPort(downstreamIdentity)
+	var apexPolicy *trafficpolicy.OutboundTrafficPolicy
+	for _, policy := range routeConfigs[int(apexSvc.Port)] {
+		if policy.Name == apexSvc.FQDN() {
+			apexPolicy = policy
+		}
+	}
+	assert.NotNil(apexPolicy)
+	assert.Len(apexPolicy.Routes, 1)
+	assert.ElementsMatch([]interface{}{
+		service.WeightedCluster{ClusterName: "ns1/s1-v1|80", Weight: 70},
+		service.WeightedCluster{ClusterName: "legacy.example.com:8080", Weight: 30},
+	}, apexPolicy.Routes[0].WeightedClusters.ToSlice())
+
+	// The mesh backend has an outbound mesh cluster, and the external backend has a DNS resolvable cluster
+	var meshClusterNames []string
+	for _, config := range mc.GetOutboundMeshClusterConfigs(downstreamIdentity) {
+		meshClusterNames = append(meshClusterNames, config.Name)
+	}
+	assert.Contains(meshClusterNames, "ns1/s1-v1|80")
+
+	assert.Equal([]*trafficpolicy.EgressClusterConfig{
+		{
+			Name: "legacy.example.com:8080",
+			Host: "legacy.example.com",
+			Port: 8080,
+		},
+	}, mc.GetOutboundExternalClusterConfigs(downstreamIdentity))
+}
+
+func TestOutboundPolicyWithPrewarmedZeroWeightBackend(t *testing.T) {
+	apexSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 8080, TargetPort: 8080, Protocol: "http"}
+	primarySvc := service.MeshService{Name: "s1-primary", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+	canarySvc := service.MeshService{Name: "s1-canary", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+	downstreamIdentity := identity.K8sServiceAccount{Name: "sa2", Namespace: "ns2"}.ToServiceIdentity()
+
+	trafficTarget := &access.TrafficTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "t1", Namespace: "ns1"},
+		Spec: access.TrafficTargetSpec{
+			Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+			Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "sa2", Namespace: "ns2"}},
+			Rules:       []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "routes", Matches: []string{"all"}}},
+		},
+	}
+	trafficSplit := &split.TrafficSplit{
+		ObjectMeta: metav1.ObjectMeta{Name: "split1", Namespace: "ns1"},
+		Spec: split.TrafficSplitSpec{
+			Service: "s1",
+			Backends: []split.TrafficSplitBackend{
+				{Service: "s1-primary", Weight: 100},
+				{Service: "s1-canary", Weight: 0},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name                 string
+		prewarm              bool
+		expectedClusterNames []string
+		expectedRouteWeights []service.WeightedCluster
+	}{
+		{
+			name:                 "zero-weight backend is not pre-warmed by default",
+			prewarm:              false,
+			expectedClusterNames: []string{"ns1/s1|8080", "ns1/s1-primary|80"},
+			expectedRouteWeights: []service.WeightedCluster{
+				{ClusterName: "ns1/s1-primary|80", Weight: 100},
+			},
+		},
+		{
+			name:                 "zero-weight backend cluster is pre-warmed without route weight",
+			prewarm:              true,
+			expectedClusterNames: []string{"ns1/s1|8080", "ns1/s1-primary|80", "ns1/s1-canary|80"},
+			expectedRouteWeights: []service.WeightedCluster{
+				{ClusterName: "ns1/s1-primary|80", Weight: 100},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockProvider := compute.NewMockInterface(mockCtrl)
+			mc := MeshCatalog{Interface: mockProvider}
+
+			mockProvider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+				Spec: v1alpha2.MeshConfigSpec{
+					Traffic: v1alpha2.TrafficSpec{
+						PrewarmZeroWeightBackends: tc.prewarm,
+					},
+				},
+			}).AnyTimes()
+			mockProvider.EXPECT().ListTrafficTargets().Return([]*access.TrafficTarget{trafficTarget}).AnyTimes()
+			mockProvider.EXPECT().GetServicesForServiceIdentity(gomock.Any()).Return([]service.MeshService{apexSvc, primarySvc}).AnyTimes()
+			mockProvider.EXPECT().ListTrafficSplits().Return([]*split.TrafficSplit{trafficSplit}).AnyTimes()
+			mockProvider.EXPECT().GetMeshService(primarySvc.Name, primarySvc.Namespace, apexSvc.Port).Return(primarySvc, nil).AnyTimes()
+			mockProvider.EXPECT().GetMeshService(canarySvc.Name, canarySvc.Namespace, apexSvc.Port).Return(canarySvc, nil).AnyTimes()
+			mockProvider.EXPECT().GetUpstreamTrafficSettingByService(gomock.Any()).Return(nil).AnyTimes()
+
+			var clusterNames []string
+			for _, config := range mc.GetOutboundMeshClusterConfigs(downstreamIdentity) {
+				clusterNames = append(clusterNames, config.Name)
+			}
+			assert.ElementsMatch(tc.expectedClusterNames, clusterNames)
+
+			actualClusters, err := mc.getUpstreamClusters(downstreamIdentity, apexSvc)
+			assert.NoError(err)
+			assert.Equal(tc.expectedRouteWeights, actualClusters)
+		})
+	}
+}
+
+func TestOutboundRoutesWithWeightedClusterRuntimeKeys(t *testing.T) {
+	apexSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 8080, TargetPort: 8080, Protocol: "http"}
+	v1Svc := service.MeshService{Name: "s1-v1", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+	v2Svc := service.MeshService{Name: "s1-v2", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+	downstreamIdentity := identity.K8sServiceAccount{Name: "sa2", Namespace: "ns2"}.ToServiceIdentity()
+
+	trafficTarget := &access.TrafficTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "t1", Namespace: "ns1"},
+		Spec: access.TrafficTargetSpec{
+			Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+			Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "sa2", Namespace: "ns2"}},
+			Rules:       []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "routes", Matches: []string{"all"}}},
+		},
+	}
+	trafficSplit := &split.TrafficSplit{
+		ObjectMeta: metav1.ObjectMeta{Name: "split1", Namespace: "ns1"},
+		Spec: split.TrafficSplitSpec{
+			Service: "s1",
+			Backends: []split.TrafficSplitBackend{
+				{Service: "s1-v1", Weight: 90},
+				{Service: "s1-v2", Weight: 10},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name                     string
+		prefix                   string
+		expectedRuntimeKeyPrefix string
+	}{
+		{
+			name:                     "runtime keys are not used by default",
+			prefix:                   "",
+			expectedRuntimeKeyPrefix: "",
+		},
+		{
+			name:                     "runtime keys are scoped to the upstream service",
+			prefix:                   "osm.weights",
+			expectedRuntimeKeyPrefix: "osm.weights.s1.ns1.svc.cluster.local",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockProvider := compute.NewMockInterface(mockCtrl)
+			mc := MeshCatalog{Interface: mockProvider}
+
+			mockProvider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+				Spec: v1alpha2.MeshConfigSpec{
+					Traffic: v1alpha2.TrafficSpec{
+						WeightedClusterRuntimeKeyPrefix: tc.prefix,
+					},
+				},
+			}).AnyTimes()
+			mockProvider.EXPECT().ListTrafficTargets().Return([]*access.TrafficTarget{trafficTarget}).AnyTimes()
+			mockProvider.EXPECT().GetServicesForServiceIdentity(gomock.Any()).Return([]service.MeshService{apexSvc}).AnyTimes()
+			mockProvider.EXPECT().ListTrafficSplits().Return([]*split.TrafficSplit{trafficSplit}).AnyTimes()
+			mockProvider.EXPECT().GetMeshService(v1Svc.Name, v1Svc.Namespace, apexSvc.Port).Return(v1Svc, nil).AnyTimes()
+			mockProvider.EXPECT().GetMeshService(v2Svc.Name, v2Svc.Namespace, apexSvc.Port).Return(v2Svc, nil).AnyTimes()
+			mockProvider.EXPECT().GetUpstreamTrafficSettingByService(gomock.Any()).Return(nil).AnyTimes()
+			mockProvider.EXPECT().GetHostnamesForService(apexSvc, false).Return([]string{apexSvc.FQDN()}).AnyTimes()
+
+			actual := mc.GetOutboundMeshHTTPRouteConfigsPerPort(downstreamIdentity)
+
+			assert.Len(actual[int(apexSvc.Port)], 1)
+			routes := actual[int(apexSvc.Port)][0].Routes
+			assert.Len(routes, 1)
+			assert.Equal(tc.expectedRuntimeKeyPrefix, routes[0].RuntimeKeyPrefix)
+
+			// The configured weights are preserved as the defaults for the runtime keys
+			assert.Equal(mapset.NewSet(
+				service.WeightedCluster{ClusterName: "ns1/s1-v1|80", Weight: 90},
+				service.WeightedCluster{ClusterName: "ns1/s1-v2|80", Weight: 10},
+			), routes[0].WeightedClusters)
+		})
+	}
+}
+
+func TestGetUpstreamClustersWithDrainedSplitBackends(t *testing.T) {
+	apexSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 8080, TargetPort: 8080, Protocol: "http"}
+	downstreamIdentity := identity.K8sServiceAccount{Name: "sa2", Namespace: "ns2"}.ToServiceIdentity()
+	v1Svc := service.MeshService{Name: "s1-v1", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+	v2Svc := service.MeshService{Name: "s1-v2", Namespace: "ns1", Port: 8080, TargetPort: 80, Protocol: "http"}
+	readyEndpoint := endpoint.Endpoint{IP: net.ParseIP("10.0.0.1"), Port: 80}
+
+	trafficSplit := &split.TrafficSplit{
+		ObjectMeta: metav1.ObjectMeta{Name: "split1", Namespace: "ns1"},
+		Spec: split.TrafficSplitSpec{
+			Service: "s1",
+			Backends: []split.TrafficSplitBackend{
+				{Service: "s1-v1", Weight: 90},
+				{Service: "s1-v2", Weight: 10},
+			},
+		},
+	}
+	splitClusters := []service.WeightedCluster{
+		{ClusterName: "ns1/s1-v1|80", Weight: 90},
+		{ClusterName: "ns1/s1-v2|80", Weight: 10},
+	}
+	apexClusters := []service.WeightedCluster{
+		{ClusterName: "ns1/s1|8080", Weight: constants.ClusterWeightAcceptAll},
+	}
+
+	testCases := []struct {
+		name             string
+		mode             v1alpha2.TrafficSplitDrainedBackendsMode
+		endpoints        map[service.MeshService][]endpoint.Endpoint
+		expectedClusters []service.WeightedCluster
+	}{
+		{
+			name:             "drained backends are retained by default",
+			mode:             "",
+			endpoints:        map[service.MeshService][]endpoint.Endpoint{apexSvc: {readyEndpoint}},
+			expectedClusters: splitClusters,
+		},
+		{
+			name:             "drained backends are retained",
+			mode:             v1alpha2.TrafficSplitDrainedBackendsRetain,
+			endpoints:        map[service.MeshService][]endpoint.Endpoint{apexSvc: {readyEndpoint}},
+			expectedClusters: splitClusters,
+		},
+		{
+			name:             "drained backends fail over to the apex service",
+			mode:             v1alpha2.TrafficSplitDrainedBackendsFailoverToApex,
+			endpoints:        map[service.MeshService][]endpoint.Endpoint{apexSvc: {readyEndpoint}},
+			expectedClusters: apexClusters,
+		},
+		{
+			name:             "drained backends are retained when the apex service is also drained",
+			mode:             v1alpha2.TrafficSplitDrainedBackendsFailoverToApex,
+			endpoints:        nil,
+			expectedClusters: splitClusters,
+		},
+		{
+			name:             "backends with a ready endpoint do not fail over to the apex service",
+			mode:             v1alpha2.TrafficSplitDrainedBackendsFailoverToApex,
+			endpoints:        map[service.MeshService][]endpoint.Endpoint{apexSvc: {readyEndpoint}, v2Svc: {readyEndpoint}},
+			expectedClusters: splitClusters,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockProvider := compute.NewMockInterface(mockCtrl)
+			mc := MeshCatalog{Interface: mockProvider}
+
+			mockProvider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+				Spec: v1alpha2.MeshConfigSpec{
+					Traffic: v1alpha2.TrafficSpec{
+						EnablePermissiveTrafficPolicyMode: true,
+						TrafficSplitDrainedBackendsMode:   tc.mode,
+					},
+				},
+			}).AnyTimes()
+			mockProvider.EXPECT().ListTrafficSplits().Return([]*split.TrafficSplit{trafficSplit}).AnyTimes()
+			mockProvider.EXPECT().GetMeshService(v1Svc.Name, v1Svc.Namespace, apexSvc.Port).Return(v1Svc, nil).AnyTimes()
+			mockProvider.EXPECT().GetMeshService(v2Svc.Name, v2Svc.Namespace, apexSvc.Port).Return(v2Svc, nil).AnyTimes()
+			mockProvider.EXPECT().ListEndpointsForService(gomock.Any()).DoAndReturn(
+				func(svc service.MeshService) []endpoint.Endpoint {
+					return tc.endpoints[svc]
+				}).AnyTimes()
+
+			actual, err := mc.getUpstreamClusters(downstreamIdentity, apexSvc)
+			assert.NoError(err)
+			assert.ElementsMatch(tc.expectedClusters, actual)
+		})
+	}
+}
+
+func TestGetOutboundMeshClusterConfigsWithLocalityWeights(t *testing.T) {
+	httpSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	endpoints := []endpoint.Endpoint{
+		{IP: net.ParseIP("10.0.0.1"), Port: 8080, Zone: "zone-a"},
+		{IP: net.ParseIP("10.0.0.2"), Port: 8080, Zone: "zone-b"},
+		{IP: net.ParseIP("10.0.0.3"), Port: 8080, Zone: "zone-b"},
+	}
+
+	testCases := []struct {
+		name                    string
+		enableLocalityAwareLB   bool
+		downstreamEndpoints     []endpoint.Endpoint
+		expectedLocalityWeights []trafficpolicy.LocalityWeight
+	}{
+		{
+			name:                    "locality aware load balancing disabled",
+			enableLocalityAwareLB:   false,
+			downstreamEndpoints:     []endpoint.Endpoint{{IP: net.ParseIP("10.0.1.1"), Zone: "zone-a"}},
+			expectedLocalityWeights: nil,
+		},
+		{
+			name:                  "zone of the downstream identity is preferred",
+			enableLocalityAwareLB: true,
+			downstreamEndpoints:   []endpoint.Endpoint{{IP: net.ParseIP("10.0.1.1"), Zone: "zone-a"}},
+			expectedLocalityWeights: []trafficpolicy.LocalityWeight{
+				{Zone: "zone-a", Weight: 1, Priority: 0},
+				{Zone: "zone-b", Weight: 2, Priority: 1},
+			},
+		},
+		{
+			name:                  "no zone is preferred when the downstream identity spans multiple zones",
+			enableLocalityAwareLB: true,
+			downstreamEndpoints: []endpoint.Endpoint{
+				{IP: net.ParseIP("10.0.1.1"), Zone: "zone-a"},
+				{IP: net.ParseIP("10.0.1.2"), Zone: "zone-b"},
+			},
+			expectedLocalityWeights: []trafficpolicy.LocalityWeight{
+				{Zone: "zone-a", Weight: 1, Priority: 0},
+				{Zone: "zone-b", Weight: 2, Priority: 0},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			provider := compute.NewMockInterface(mockCtrl)
+			mc := MeshCatalog{Interface: provider}
+
+			provider.EXPECT().ListServices().Return([]service.MeshService{httpSvc}).AnyTimes()
+			provider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+				Spec: v1alpha2.MeshConfigSpec{
+					Traffic: v1alpha2.TrafficSpec{
+						EnablePermissiveTrafficPolicyMode: true,
+						EnableLocalityAwareLoadBalancing:  tc.enableLocalityAwareLB,
+					},
+				},
+			}).AnyTimes()
+			provider.EXPECT().GetUpstreamTrafficSettingByService(gomock.Any()).Return(nil).AnyTimes()
+			provider.EXPECT().ListEndpointsForService(httpSvc).Return(endpoints).AnyTimes()
+			provider.EXPECT().ListEndpointsForIdentity(tests.BookbuyerServiceIdentity).Return(tc.downstreamEndpoints).AnyTimes()
+
+			configs := mc.GetOutboundMeshClusterConfigs(tests.BookbuyerServiceIdentity)
+			assert.Len(configs, 1)
+			assert.Equal(tc.expectedLocalityWeights, configs[0].LocalityWeights)
+		})
+	}
+}
+
+func TestGetOutboundRouteConfigsForDestination(t *testing.T) {
+	s1 := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	s1Metrics := service.MeshService{Name: "s1", Namespace: "ns1", Port: 9090, TargetPort: 9090, Protocol: "http"}
+	s2 := service.MeshService{Name: "s2", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	sourceIdentity := identity.K8sServiceAccount{Name: "sa2", Namespace: "ns2"}.ToServiceIdentity()
+
+	testCases := []struct {
+		name                string
+		dest                service.MeshService
+		expectedPolicyPorts map[int]string
+	}{
+		{
+			name:                "destination on all ports",
+			dest:                service.MeshService{Name: "s1", Namespace: "ns1"},
+			expectedPolicyPorts: map[int]string{80: s1.FQDN(), 9090: s1Metrics.FQDN()},
+		},
+		{
+			name:                "destination on a single port",
+			dest:                service.MeshService{Name: "s1", Namespace: "ns1", Port: 9090},
+			expectedPolicyPorts: map[int]string{9090: s1Metrics.FQDN()},
+		},
+		{
+			name:                "other destination",
+			dest:                service.MeshService{Name: "s2", Namespace: "ns1"},
+			expectedPolicyPorts: map[int]string{80: s2.FQDN()},
+		},
+		{
+			name:                "unknown destination",
+			dest:                service.MeshService{Name: "s3", Namespace: "ns1"},
+			expectedPolicyPorts: map[int]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockProvider := compute.NewMockInterface(mockCtrl)
+			mc := MeshCatalog{Interface: mockProvider}
+
+			mockProvider.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+				Spec: v1alpha2.MeshConfigSpec{
+					Traffic: v1alpha2.TrafficSpec{
+						EnablePermissiveTrafficPolicyMode: true,
+					},
+				},
+			}).AnyTimes()
+			mockProvider.EXPECT().ListServices().Return([]service.MeshService{s1, s1Metrics, s2}).AnyTimes()
+			mockProvider.EXPECT().ListTrafficSplits().Return(nil).AnyTimes()
+			mockProvider.EXPECT().GetUpstreamTrafficSettingByService(gomock.Any()).Return(nil).AnyTimes()
+			mockProvider.EXPECT().GetHostnamesForService(gomock.Any(), false).DoAndReturn(func(svc service.MeshService, _ bool) []string {
+				return []string{svc.FQDN()}
+			}).AnyTimes()
+
+			actual := mc.GetOutboundRouteConfigsForDestination(sourceIdentity, tc.dest)
+
+			// Only the route configs of the requested destination are returned, as they would be for all the destinations
+			all := mc.GetOutboundMeshHTTPRouteConfigsPerPort(sourceIdentity)
+			assert.Len(actual, len(tc.expectedPolicyPorts))
+			for port, expectedName := range tc.expectedPolicyPorts {
+				assert.Len(actual[port], 1)
+				assert.Equal(expectedName, actual[port][0].Name)
+				assert.Contains(all[port], actual[port][0])
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set"
+	xds_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
+
+	"github.com/openservicemesh/osm/pkg/constants"
+	"github.com/openservicemesh/osm/pkg/service"
+	"github.com/openservicemesh/osm/pkg/smi"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
+)
+
+const (
+	trafficTargetKind = "TrafficTarget"
+	trafficSplitKind  = "TrafficSplit"
+)
+
+// validRateLimitUnits is the set of units accepted for local rate limits
+var validRateLimitUnits = mapset.NewSetWith("second", "minute", "hour")
+
+// PolicyValidationError describes a policy resource that fails validation
+type PolicyValidationError struct {
+	// Kind is the kind of the policy resource
+	Kind string `json:"kind"`
+
+	// Namespace is the namespace of the policy resource
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the policy resource
+	Name string `json:"name"`
+
+	// Reason describes why the policy resource is invalid
+	Reason string `json:"reason"`
+}
+
+// Error returns the string representation of the PolicyValidationError
+func (e PolicyValidationError) Error() string {
+	return fmt.Sprintf("%s %s/%s is invalid: %s", e.Kind, e.Namespace, e.Name, e.Reason)
+}
+
+// GetInvalidPolicyResources returns the validation errors for the TrafficTarget, HTTPRouteGroup, TrafficSplit and
+// UpstreamTrafficSetting resources that fail validation, sorted by kind, namespace and name. A resource may have
+// multiple validation errors. Valid resources are not reported.
+func (mc *MeshCatalog) GetInvalidPolicyResources() ([]PolicyValidationError, error) {
+	var validationErrors []PolicyValidationError
+
+	specMatchRoute, err := mc.getHTTPPathsPerRoute()
+	if err != nil {
+		return nil, err
+	}
+
+	validationErrors = append(validationErrors, mc.getInvalidHTTPRouteGroups()...)
+	validationErrors = append(validationErrors, mc.getInvalidTrafficTargets(specMatchRoute)...)
+	validationErrors = append(validationErrors, mc.getInvalidTrafficSplits()...)
+	validationErrors = append(validationErrors, mc.getInvalidUpstreamTrafficSettings()...)
+
+	sort.SliceStable(validationErrors, func(i, j int) bool {
+		a, b := validationErrors[i], validationErrors[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	return validationErrors, nil
+}
+
+func (mc *MeshCatalog) getInvalidHTTPRouteGroups() []PolicyValidationError {
+	var validationErrors []PolicyValidationError
+
+	for _, routeGroup := range mc.ListHTTPTrafficSpecs() {
+		newError := func(reason string) PolicyValidationError {
+			return PolicyValidationError{Kind: smi.HTTPRouteGroupKind, Namespace: routeGroup.Namespace, Name: routeGroup.Name, Reason: reason}
+		}
+
+		if len(routeGroup.Spec.Matches) == 0 {
+			validationErrors = append(validationErrors, newError("no matches specified"))
+			continue
+		}
+		for _, match := range routeGroup.Spec.Matches {
+			if match.PathRegex == "" {
+				continue
+			}
+			if _, err := regexp.Compile(match.PathRegex); err != nil {
+				validationErrors = append(validationErrors, newError(fmt.Sprintf("invalid path regex %q for match %s: %s", match.PathRegex, match.Name, err)))
+			}
+		}
+	}
+
+	return validationErrors
+}
+
+func (mc *MeshCatalog) getInvalidTrafficTargets(
+	specMatchRoute map[trafficpolicy.TrafficSpecName]map[trafficpolicy.TrafficSpecMatchName]trafficpolicy.HTTPRouteMatch) []PolicyValidationError {
+	var validationErrors []PolicyValidationError
+
+	for _, trafficTarget := range mc.ListTrafficTargets() {
+		newError := func(reason string) PolicyValidationError {
+			return PolicyValidationError{Kind: trafficTargetKind, Namespace: trafficTarget.Namespace, Name: trafficTarget.Name, Reason: reason}
+		}
+
+		if trafficTarget.Namespace != trafficTarget.Spec.Destination.Namespace {
+			validationErrors = append(validationErrors, newError(fmt.Sprintf("destination namespace %s does not match the TrafficTarget namespace",
+				trafficTarget.Spec.Destination.Namespace)))
+		}
+		if !smi.HasValidRules(trafficTarget.Spec.Rules) {
+			validationErrors = append(validationErrors, newError("no rules specified or a rule has an invalid kind"))
+			continue
+		}
+		for _, rule := range trafficTarget.Spec.Rules {
+			if kind, _ := smi.ParseRouteKind(rule.Kind); kind != smi.HTTPRouteGroupKind {
+				continue
+			}
+			matches, found := specMatchRoute[getTrafficSpecName(smi.HTTPRouteGroupKind, trafficTarget.Namespace, rule.Name)]
+			if !found {
+				validationErrors = append(validationErrors, newError(fmt.Sprintf("HTTPRouteGroup %s/%s not found", trafficTarget.Namespace, rule.Name)))
+				continue
+			}
+			for _, match := range rule.Matches {
+				if _, found := matches[trafficpolicy.TrafficSpecMatchName(match)]; !found {
+					validationErrors = append(validationErrors, newError(fmt.Sprintf("match %s not found in HTTPRouteGroup %s/%s", match, trafficTarget.Namespace, rule.Name)))
+				}
+			}
+		}
+	}
+
+	return validationErrors
+}
+
+func (mc *MeshCatalog) getInvalidTrafficSplits() []PolicyValidationError {
+	var validationErrors []PolicyValidationError
+
+	services := mapset.NewSet()
+	for _, svc := range mc.ListServices() {
+		services.Add(fmt.Sprintf("%s/%s", svc.Namespace, svc.Name))
+	}
+
+	for _, split := range mc.ListTrafficSplits() {
+		newError := func(reason string) PolicyValidationError {
+			return PolicyValidationError{Kind: trafficSplitKind, Namespace: split.Namespace, Name: split.Name, Reason: reason}
+		}
+
+		if !services.Contains(fmt.Sprintf("%s/%s", split.Namespace, split.Spec.Service)) {
+			validationErrors = append(validationErrors, newError(fmt.Sprintf("apex service %s/%s not found", split.Namespace, split.Spec.Service)))
+		}
+		if len(split.Spec.Backends) == 0 {
+			validationErrors = append(validationErrors, newError("no backends specified"))
+		}
+		for _, backend := range split.Spec.Backends {
+			if backend.Weight < 0 {
+				validationErrors = append(validationErrors, newError(fmt.Sprintf("backend %s has a negative weight %d", backend.Service, backend.Weight)))
+			}
+			if _, isExternal := smi.GetTrafficSplitExternalBackendHost(backend.Service); isExternal {
+				continue
+			}
+			name, namespace := smi.GetTrafficSplitBackendService(split, backend.Service)
+			if !services.Contains(fmt.Sprintf("%s/%s", namespace, name)) {
+				validationErrors = append(validationErrors, newError(fmt.Sprintf("backend service %s/%s not found", namespace, name)))
+			}
+		}
+	}
+
+	return validationErrors
+}
+
+func (mc *MeshCatalog) getInvalidUpstreamTrafficSettings() []PolicyValidationError {
+	var validationErrors []PolicyValidationError
+
+	servicesByHost := make(map[string][]service.MeshService)
+	for _, svc := range mc.ListServices() {
+		servicesByHost[svc.FQDN()] = append(servicesByHost[svc.FQDN()], svc)
+	}
+
+	for _, upstreamTrafficSetting := range mc.ListUpstreamTrafficSettings() {
+		newError := func(reason string) PolicyValidationError {
+			return PolicyValidationError{Kind: upstreamTrafficSettingKind, Namespace: upstreamTrafficSetting.Namespace, Name: upstreamTrafficSetting.Name, Reason: reason}
+		}
+
+		if len(strings.Split(upstreamTrafficSetting.Spec.Host, ".")) < 2 {
+			validationErrors = append(validationErrors, newError(fmt.Sprintf("invalid FQDN %q specified as host", upstreamTrafficSetting.Spec.Host)))
+		}
+		for _, route := range upstreamTrafficSetting.Spec.HTTPRoutes {
+			if _, err := regexp.Compile(route.Path); err != nil {
+				validationErrors = append(validationErrors, newError(fmt.Sprintf("invalid path regex %q for HTTP route: %s", route.Path, err)))
+			}
+		}
+		for _, err := range ValidateUpstreamTrafficSetting(upstreamTrafficSetting) {
+			validationErrors = append(validationErrors, newError(err.Error()))
+		}
+		if upstreamTrafficSetting.Spec.EnableGRPCWeb {
+			// gRPC-Web translation is an HTTP filter, it cannot apply to ports not serving HTTP or gRPC
+			for _, svc := range servicesByHost[upstreamTrafficSetting.Spec.Host] {
+				if svc.Protocol != constants.ProtocolHTTP && svc.Protocol != constants.ProtocolGRPC {
+					validationErrors = append(validationErrors, newError(fmt.Sprintf("gRPC-Web is not supported for port %d of service %s with protocol %s", svc.Port, svc, svc.Protocol)))
+				}
+			}
+		}
+	}
+
+	return validationErrors
+}
+
+// ValidateUpstreamTrafficSetting returns the errors in the rate limiting configuration of the given UpstreamTrafficSetting.
+// Rate limit units must be one of second, minute or hour, the number of requests and connections allowed and the
+// fill intervals must be positive, and the rate limit service used for global rate limiting must specify a host and
+// a port. The status codes of direct responses must be between 200 and 599, hash keys are only supported by the
+// RingHash and Maglev load balancing algorithms, and paths are only rewritten for HTTP routes whose path is not a regex.
+// TCP keep-alive durations must not be negative, a host rewrite must either specify a literal host or rewrite the host
+// to the upstream endpoint, and the allowed source ranges must be valid CIDRs.
+func ValidateUpstreamTrafficSetting(upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) []error {
+	if upstreamTrafficSetting == nil {
+		return nil
+	}
+
+	var errs []error
+	if rl := upstreamTrafficSetting.Spec.RateLimit; rl != nil {
+		if rl.Local != nil && rl.Local.TCP != nil {
+			if !validRateLimitUnits.Contains(rl.Local.TCP.Unit) {
+				errs = append(errs, fmt.Errorf("local TCP rate limit: invalid unit %q", rl.Local.TCP.Unit))
+			}
+			if rl.Local.TCP.Connections == 0 {
+				errs = append(errs, errors.New("local TCP rate limit: connections must be positive"))
+			}
+			if rl.Local.TCP.FillInterval != nil && rl.Local.TCP.FillInterval.Duration <= 0 {
+				errs = append(errs, errors.New("local TCP rate limit: fill interval must be positive"))
+			}
+		}
+		if rl.Local != nil && rl.Local.HTTP != nil {
+			for _, reason := range getHTTPLocalRateLimitValidationErrors(rl.Local.HTTP) {
+				errs = append(errs, fmt.Errorf("local HTTP rate limit: %s", reason))
+			}
+		}
+		if rl.Global != nil && rl.Global.TCP != nil {
+			for _, reason := range getRateLimitServiceValidationErrors(rl.Global.TCP.RateLimitService) {
+				errs = append(errs, fmt.Errorf("global TCP rate limit: %s", reason))
+			}
+		}
+		if rl.Global != nil && rl.Global.HTTP != nil {
+			for _, reason := range getRateLimitServiceValidationErrors(rl.Global.HTTP.RateLimitService) {
+				errs = append(errs, fmt.Errorf("global HTTP rate limit: %s", reason))
+			}
+		}
+	}
+	if lbPolicy := upstreamTrafficSetting.Spec.LBPolicy; lbPolicy != nil && !isConsistentHashLBPolicy(lbPolicy) {
+		if lbPolicy.HashKeyHeader != "" {
+			errs = append(errs, fmt.Errorf("load balancing policy: hash key header is not supported by the %s algorithm", lbPolicy.Type))
+		}
+		if lbPolicy.HashKeyCookie != nil {
+			errs = append(errs, fmt.Errorf("load balancing policy: hash key cookie is not supported by the %s algorithm", lbPolicy.Type))
+		}
+	}
+	if cs := upstreamTrafficSetting.Spec.ConnectionSettings; cs != nil && cs.TCP != nil && cs.TCP.TCPKeepalive != nil {
+		if keepalive := cs.TCP.TCPKeepalive; keepalive.Time != nil && keepalive.Time.Duration < 0 {
+			errs = append(errs, errors.New("TCP keep-alive: time must not be negative"))
+		}
+		if keepalive := cs.TCP.TCPKeepalive; keepalive.Interval != nil && keepalive.Interval.Duration < 0 {
+			errs = append(errs, errors.New("TCP keep-alive: interval must not be negative"))
+		}
+	}
+	if hostRewrite := upstreamTrafficSetting.Spec.HostRewrite; hostRewrite != nil && (hostRewrite.Literal != "") == hostRewrite.AutoToUpstream {
+		errs = append(errs, errors.New("host rewrite: exactly one of literal and autoToUpstream must be set"))
+	}
+	for _, sourceRange := range upstreamTrafficSetting.Spec.AllowedSourceRanges {
+		if _, _, err := net.ParseCIDR(sourceRange); err != nil {
+			errs = append(errs, fmt.Errorf("allowed source range: invalid CIDR %q", sourceRange))
+		}
+	}
+	if !isValidDirectResponse(upstreamTrafficSetting.Spec.DirectResponse) {
+		errs = append(errs, fmt.Errorf("direct response: invalid status code %d", upstreamTrafficSetting.Spec.DirectResponse.StatusCode))
+	}
+	for _, route := range upstreamTrafficSetting.Spec.HTTPRoutes {
+		if route.RateLimit != nil && route.RateLimit.Local != nil {
+			for _, reason := range getHTTPLocalRateLimitValidationErrors(route.RateLimit.Local) {
+				errs = append(errs, fmt.Errorf("local rate limit for HTTP route %s: %s", route.Path, reason))
+			}
+		}
+		if !isValidDirectResponse(route.DirectResponse) {
+			errs = append(errs, fmt.Errorf("direct response for HTTP route %s: invalid status code %d", route.Path, route.DirectResponse.StatusCode))
+		}
+		if route.RewritePrefix != "" && !isLiteralPath(route.Path) {
+			errs = append(errs, fmt.Errorf("rewrite prefix for HTTP route %s: not supported for a regex path", route.Path))
+		}
+	}
+
+	return errs
+}
+
+// getHTTPLocalRateLimitValidationErrors returns the reasons the given local HTTP rate limit is invalid
+func getHTTPLocalRateLimitValidationErrors(rl *policyv1alpha1.HTTPLocalRateLimitSpec) []string {
+	var reasons []string
+	if !validRateLimitUnits.Contains(rl.Unit) {
+		reasons = append(reasons, fmt.Sprintf("invalid unit %q", rl.Unit))
+	}
+	if rl.Requests == 0 {
+		reasons = append(reasons, "requests must be positive")
+	}
+	if rl.FillInterval != nil && rl.FillInterval.Duration <= 0 {
+		reasons = append(reasons, "fill interval must be positive")
+	}
+	if _, ok := xds_type.StatusCode_name[int32(rl.ResponseStatusCode)]; !ok {
+		reasons = append(reasons, fmt.Sprintf("invalid response status code %d", rl.ResponseStatusCode))
+	}
+	return reasons
+}
+
+// isValidDirectResponse returns true if the given direct response is not set or has a status code between 200 and 599
+func isValidDirectResponse(directResponse *policyv1alpha1.DirectResponseSpec) bool {
+	return directResponse == nil || (directResponse.StatusCode >= 200 && directResponse.StatusCode <= 599)
+}
+
+// getRateLimitServiceValidationErrors returns the reasons the given global rate limit service is invalid
+func getRateLimitServiceValidationErrors(rls policyv1alpha1.RateLimitServiceSpec) []string {
+	var reasons []string
+	if rls.Host == "" {
+		reasons = append(reasons, "rate limit service host not specified")
+	}
+	if rls.Port == 0 {
+		reasons = append(reasons, "rate limit service port not specified")
+	}
+	return reasons
+}
@@ -0,0 +1,389 @@
+package catalog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	access "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/access/v1alpha3"
+	spec "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/specs/v1alpha4"
+	split "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/split/v1alpha2"
+	tassert "github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
+	"github.com/openservicemesh/osm/pkg/compute"
+	"github.com/openservicemesh/osm/pkg/service"
+)
+
+func TestGetInvalidPolicyResources(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	provider := compute.NewMockInterface(mockCtrl)
+	mc := MeshCatalog{Interface: provider}
+
+	newTrafficTarget := func(name string, destinationNamespace string, rules []access.TrafficTargetRule) *access.TrafficTarget {
+		return &access.TrafficTarget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1"},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: destinationNamespace},
+				Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "sa2", Namespace: "ns2"}},
+				Rules:       rules,
+			},
+		}
+	}
+	newSplit := func(name string, apex string, backends ...split.TrafficSplitBackend) *split.TrafficSplit {
+		return &split.TrafficSplit{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1"},
+			Spec:       split.TrafficSplitSpec{Service: apex, Backends: backends},
+		}
+	}
+	newUpstreamTrafficSetting := func(name string, host string, spec policyv1alpha1.UpstreamTrafficSettingSpec) *policyv1alpha1.UpstreamTrafficSetting {
+		spec.Host = host
+		return &policyv1alpha1.UpstreamTrafficSetting{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1"},
+			Spec:       spec,
+		}
+	}
+
+	provider.EXPECT().ListServices().Return([]service.MeshService{
+		{Name: "s1", Namespace: "ns1", Port: 80},
+		{Name: "s1-v1", Namespace: "ns1", Port: 80},
+		{Name: "api", Namespace: "ns1", Port: 9090, Protocol: "grpc"},
+		{Name: "db", Namespace: "ns1", Port: 5432, Protocol: "tcp"},
+	}).AnyTimes()
+	provider.EXPECT().ListHTTPTrafficSpecs().Return([]*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "valid-routes", Namespace: "ns1"},
+			Spec:       spec.HTTPRouteGroupSpec{Matches: []spec.HTTPMatch{{Name: "get", PathRegex: "/get", Methods: []string{"GET"}}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "bad-regex", Namespace: "ns1"},
+			Spec:       spec.HTTPRouteGroupSpec{Matches: []spec.HTTPMatch{{Name: "bad", PathRegex: "/get(", Methods: []string{"GET"}}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-matches", Namespace: "ns1"},
+		},
+	}).AnyTimes()
+	provider.EXPECT().ListTrafficTargets().Return([]*access.TrafficTarget{
+		newTrafficTarget("valid", "ns1", []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "valid-routes", Matches: []string{"get"}}}),
+		newTrafficTarget("bad-namespace", "ns2", []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "valid-routes", Matches: []string{"get"}}}),
+		newTrafficTarget("bad-group-ref", "ns1", []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "missing-routes", Matches: []string{"get"}}}),
+		newTrafficTarget("bad-match-ref", "ns1", []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "valid-routes", Matches: []string{"post"}}}),
+		newTrafficTarget("no-rules", "ns1", nil),
+	}).AnyTimes()
+	provider.EXPECT().ListTrafficSplits().Return([]*split.TrafficSplit{
+		newSplit("valid", "s1", split.TrafficSplitBackend{Service: "s1-v1", Weight: 100}, split.TrafficSplitBackend{Service: "external:legacy.example.com", Weight: 0}),
+		newSplit("missing-apex", "s2", split.TrafficSplitBackend{Service: "s1-v1", Weight: 100}),
+		newSplit("missing-backend", "s1", split.TrafficSplitBackend{Service: "s1-v2", Weight: 100}),
+	}).AnyTimes()
+	provider.EXPECT().ListUpstreamTrafficSettings().Return([]*policyv1alpha1.UpstreamTrafficSetting{
+		newUpstreamTrafficSetting("valid", "s1.ns1.svc.cluster.local", policyv1alpha1.UpstreamTrafficSettingSpec{
+			RateLimit: &policyv1alpha1.RateLimitSpec{
+				Local: &policyv1alpha1.LocalRateLimitSpec{
+					TCP:  &policyv1alpha1.TCPLocalRateLimitSpec{Connections: 10, Unit: "second"},
+					HTTP: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 10, Unit: "minute", ResponseStatusCode: 429},
+				},
+			},
+			HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{{Path: "/get/.*"}},
+		}),
+		newUpstreamTrafficSetting("bad-host", "s1", policyv1alpha1.UpstreamTrafficSettingSpec{}),
+		newUpstreamTrafficSetting("bad-rate-limit", "s1-v1.ns1.svc.cluster.local", policyv1alpha1.UpstreamTrafficSettingSpec{
+			RateLimit: &policyv1alpha1.RateLimitSpec{
+				Local: &policyv1alpha1.LocalRateLimitSpec{
+					HTTP: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 10, Unit: "day", ResponseStatusCode: 1},
+				},
+			},
+		}),
+		newUpstreamTrafficSetting("bad-route", "s1-v2.ns1.svc.cluster.local", policyv1alpha1.UpstreamTrafficSettingSpec{
+			HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{{Path: "/get("}},
+		}),
+		newUpstreamTrafficSetting("bad-direct-response", "s1.ns1.svc.cluster.local", policyv1alpha1.UpstreamTrafficSettingSpec{
+			DirectResponse: &policyv1alpha1.DirectResponseSpec{StatusCode: 42},
+		}),
+		newUpstreamTrafficSetting("grpc-web", "api.ns1.svc.cluster.local", policyv1alpha1.UpstreamTrafficSettingSpec{
+			EnableGRPCWeb: true,
+		}),
+		newUpstreamTrafficSetting("grpc-web-tcp", "db.ns1.svc.cluster.local", policyv1alpha1.UpstreamTrafficSettingSpec{
+			EnableGRPCWeb: true,
+		}),
+	}).AnyTimes()
+
+	actual, err := mc.GetInvalidPolicyResources()
+	assert.NoError(err)
+
+	type invalidResource struct {
+		kind string
+		name string
+	}
+	var invalidResources []invalidResource
+	for _, validationError := range actual {
+		assert.Equal("ns1", validationError.Namespace)
+		assert.NotEmpty(validationError.Reason)
+		invalidResources = append(invalidResources, invalidResource{kind: validationError.Kind, name: validationError.Name})
+	}
+
+	// Only the invalid resources are reported, sorted by kind and name, once per reason
+	assert.Equal([]invalidResource{
+		{kind: "HTTPRouteGroup", name: "bad-regex"},
+		{kind: "HTTPRouteGroup", name: "no-matches"},
+		{kind: "TrafficSplit", name: "missing-apex"},
+		{kind: "TrafficSplit", name: "missing-backend"},
+		{kind: "TrafficTarget", name: "bad-group-ref"},
+		{kind: "TrafficTarget", name: "bad-match-ref"},
+		{kind: "TrafficTarget", name: "bad-namespace"},
+		{kind: "TrafficTarget", name: "no-rules"},
+		{kind: "UpstreamTrafficSetting", name: "bad-direct-response"},
+		{kind: "UpstreamTrafficSetting", name: "bad-host"},
+		{kind: "UpstreamTrafficSetting", name: "bad-rate-limit"},
+		{kind: "UpstreamTrafficSetting", name: "bad-rate-limit"},
+		{kind: "UpstreamTrafficSetting", name: "bad-route"},
+		{kind: "UpstreamTrafficSetting", name: "grpc-web-tcp"},
+	}, invalidResources)
+
+	assert.Equal(PolicyValidationError{
+		Kind:      "TrafficSplit",
+		Namespace: "ns1",
+		Name:      "missing-backend",
+		Reason:    "backend service ns1/s1-v2 not found",
+	}, actual[3])
+	assert.Equal("TrafficSplit ns1/missing-backend is invalid: backend service ns1/s1-v2 not found", actual[3].Error())
+
+	// gRPC-Web is rejected for the TCP service and accepted for the gRPC service
+	assert.Equal("gRPC-Web is not supported for port 5432 of service ns1/db with protocol tcp", actual[13].Reason)
+}
+
+func TestValidateUpstreamTrafficSetting(t *testing.T) {
+	validRateLimitService := policyv1alpha1.RateLimitServiceSpec{Host: "ratelimiter.ns1.svc.cluster.local", Port: 8081}
+
+	testCases := []struct {
+		name           string
+		spec           policyv1alpha1.UpstreamTrafficSettingSpec
+		expectedErrors []string
+	}{
+		{
+			name: "valid rate limits",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				RateLimit: &policyv1alpha1.RateLimitSpec{
+					Local: &policyv1alpha1.LocalRateLimitSpec{
+						TCP:  &policyv1alpha1.TCPLocalRateLimitSpec{Connections: 10, Unit: "second"},
+						HTTP: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 10, Unit: "minute"},
+					},
+					Global: &policyv1alpha1.GlobalRateLimitSpec{
+						TCP:  &policyv1alpha1.TCPGlobalRateLimitSpec{RateLimitService: validRateLimitService},
+						HTTP: &policyv1alpha1.HTTPGlobalRateLimitSpec{RateLimitService: validRateLimitService},
+					},
+				},
+				HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+					{
+						Path:      "/get",
+						RateLimit: &policyv1alpha1.HTTPPerRouteRateLimitSpec{Local: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 1, Unit: "hour"}},
+					},
+				},
+			},
+			expectedErrors: nil,
+		},
+		{
+			name:           "no rate limits",
+			spec:           policyv1alpha1.UpstreamTrafficSettingSpec{},
+			expectedErrors: nil,
+		},
+		{
+			name: "invalid local TCP rate limit unit",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				RateLimit: &policyv1alpha1.RateLimitSpec{
+					Local: &policyv1alpha1.LocalRateLimitSpec{
+						TCP: &policyv1alpha1.TCPLocalRateLimitSpec{Connections: 10, Unit: "day"},
+					},
+				},
+			},
+			expectedErrors: []string{`local TCP rate limit: invalid unit "day"`},
+		},
+		{
+			name: "zero local TCP rate limit connections",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				RateLimit: &policyv1alpha1.RateLimitSpec{
+					Local: &policyv1alpha1.LocalRateLimitSpec{
+						TCP: &policyv1alpha1.TCPLocalRateLimitSpec{Connections: 0, Unit: "second"},
+					},
+				},
+			},
+			expectedErrors: []string{"local TCP rate limit: connections must be positive"},
+		},
+		{
+			name: "invalid local HTTP rate limit unit",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				RateLimit: &policyv1alpha1.RateLimitSpec{
+					Local: &policyv1alpha1.LocalRateLimitSpec{
+						HTTP: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 10, Unit: "Minute"},
+					},
+				},
+			},
+			expectedErrors: []string{`local HTTP rate limit: invalid unit "Minute"`},
+		},
+		{
+			name: "zero local HTTP rate limit requests",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				RateLimit: &policyv1alpha1.RateLimitSpec{
+					Local: &policyv1alpha1.LocalRateLimitSpec{
+						HTTP: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 0, Unit: "minute"},
+					},
+				},
+			},
+			expectedErrors: []string{"local HTTP rate limit: requests must be positive"},
+		},
+		{
+			name: "zero local rate limit fill intervals",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				RateLimit: &policyv1alpha1.RateLimitSpec{
+					Local: &policyv1alpha1.LocalRateLimitSpec{
+						TCP:  &policyv1alpha1.TCPLocalRateLimitSpec{Connections: 10, Unit: "second", FillInterval: &metav1.Duration{}},
+						HTTP: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 10, Unit: "minute", FillInterval: &metav1.Duration{}},
+					},
+				},
+			},
+			expectedErrors: []string{
+				"local TCP rate limit: fill interval must be positive",
+				"local HTTP rate limit: fill interval must be positive",
+			},
+		},
+		{
+			name: "invalid per route local rate limit",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+					{
+						Path:      "/get",
+						RateLimit: &policyv1alpha1.HTTPPerRouteRateLimitSpec{Local: &policyv1alpha1.HTTPLocalRateLimitSpec{Requests: 0, Unit: "day"}},
+					},
+				},
+			},
+			expectedErrors: []string{
+				`local rate limit for HTTP route /get: invalid unit "day"`,
+				"local rate limit for HTTP route /get: requests must be positive",
+			},
+		},
+		{
+			name: "hash key header without ring hash load balancing",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				LBPolicy: &policyv1alpha1.LBPolicySpec{Type: policyv1alpha1.LBPolicyLeastRequest, HashKeyHeader: "x-user-id"},
+			},
+			expectedErrors: []string{"load balancing policy: hash key header is not supported by the LeastRequest algorithm"},
+		},
+		{
+			name: "hash key cookie without consistent hashing load balancing",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				LBPolicy: &policyv1alpha1.LBPolicySpec{
+					Type:          policyv1alpha1.LBPolicyRoundRobin,
+					HashKeyCookie: &policyv1alpha1.HashKeyCookieSpec{Name: "session"},
+				},
+			},
+			expectedErrors: []string{"load balancing policy: hash key cookie is not supported by the RoundRobin algorithm"},
+		},
+		{
+			name: "rewrite prefix for a regex path",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+					{Path: "/old", RewritePrefix: "/new"},
+					{Path: "/old/.*", RewritePrefix: "/new"},
+				},
+			},
+			expectedErrors: []string{"rewrite prefix for HTTP route /old/.*: not supported for a regex path"},
+		},
+		{
+			name: "host rewrite with both literal and autoToUpstream",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				HostRewrite: &policyv1alpha1.HostRewriteSpec{Literal: "api.example.com", AutoToUpstream: true},
+			},
+			expectedErrors: []string{"host rewrite: exactly one of literal and autoToUpstream must be set"},
+		},
+		{
+			name: "empty host rewrite",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				HostRewrite: &policyv1alpha1.HostRewriteSpec{},
+			},
+			expectedErrors: []string{"host rewrite: exactly one of literal and autoToUpstream must be set"},
+		},
+		{
+			name: "valid TCP keep-alive",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				ConnectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+					TCP: &policyv1alpha1.TCPConnectionSettings{
+						TCPKeepalive: &policyv1alpha1.TCPKeepaliveSpec{
+							Time:     &metav1.Duration{Duration: time.Minute},
+							Interval: &metav1.Duration{},
+						},
+					},
+				},
+			},
+			expectedErrors: nil,
+		},
+		{
+			name: "negative TCP keep-alive durations",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				ConnectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+					TCP: &policyv1alpha1.TCPConnectionSettings{
+						TCPKeepalive: &policyv1alpha1.TCPKeepaliveSpec{
+							Time:     &metav1.Duration{Duration: -time.Minute},
+							Interval: &metav1.Duration{Duration: -time.Second},
+						},
+					},
+				},
+			},
+			expectedErrors: []string{
+				"TCP keep-alive: time must not be negative",
+				"TCP keep-alive: interval must not be negative",
+			},
+		},
+		{
+			name: "invalid allowed source range",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				AllowedSourceRanges: []string{"10.0.0.0/8", "10.0.0.1"},
+			},
+			expectedErrors: []string{`allowed source range: invalid CIDR "10.0.0.1"`},
+		},
+		{
+			name: "global TCP rate limit service without host",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				RateLimit: &policyv1alpha1.RateLimitSpec{
+					Global: &policyv1alpha1.GlobalRateLimitSpec{
+						TCP: &policyv1alpha1.TCPGlobalRateLimitSpec{RateLimitService: policyv1alpha1.RateLimitServiceSpec{Port: 8081}},
+					},
+				},
+			},
+			expectedErrors: []string{"global TCP rate limit: rate limit service host not specified"},
+		},
+		{
+			name: "global HTTP rate limit service without port",
+			spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+				RateLimit: &policyv1alpha1.RateLimitSpec{
+					Global: &policyv1alpha1.GlobalRateLimitSpec{
+						HTTP: &policyv1alpha1.HTTPGlobalRateLimitSpec{RateLimitService: policyv1alpha1.RateLimitServiceSpec{Host: "ratelimiter.ns1.svc.cluster.local"}},
+					},
+				},
+			},
+			expectedErrors: []string{"global HTTP rate limit: rate limit service port not specified"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			errs := ValidateUpstreamTrafficSetting(&policyv1alpha1.UpstreamTrafficSetting{
+				ObjectMeta: metav1.ObjectMeta{Name: "u1", Namespace: "ns1"},
+				Spec:       tc.spec,
+			})
+
+			var actualErrors []string
+			for _, err := range errs {
+				actualErrors = append(actualErrors, err.Error())
+			}
+			assert.Equal(tc.expectedErrors, actualErrors)
+		})
+	}
+
+	// A nil UpstreamTrafficSetting has nothing to validate
+	tassert.Empty(t, ValidateUpstreamTrafficSetting(nil))
+}
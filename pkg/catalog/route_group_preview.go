@@ -0,0 +1,64 @@
+package catalog
+
+import (
+	"fmt"
+
+	spec "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/specs/v1alpha4"
+
+	"github.com/openservicemesh/osm/pkg/compute"
+	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/service"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
+)
+
+// httpRouteGroupOverlay is a compute.Interface that lists the HTTPRouteGroup resources of the underlying
+// compute.Interface, with the given HTTPRouteGroup substituted for the existing one of the same namespace
+// and name, or added if no such HTTPRouteGroup exists
+type httpRouteGroupOverlay struct {
+	compute.Interface
+	proposed *spec.HTTPRouteGroup
+}
+
+// ListHTTPTrafficSpecs lists the HTTPRouteGroup resources, including the proposed HTTPRouteGroup
+func (o httpRouteGroupOverlay) ListHTTPTrafficSpecs() []*spec.HTTPRouteGroup {
+	var routeGroups []*spec.HTTPRouteGroup
+	for _, routeGroup := range o.Interface.ListHTTPTrafficSpecs() {
+		if routeGroup.Namespace == o.proposed.Namespace && routeGroup.Name == o.proposed.Name {
+			continue
+		}
+		routeGroups = append(routeGroups, routeGroup)
+	}
+	return append(routeGroups, o.proposed)
+}
+
+// PreviewHTTPRouteGroupChange returns a map of the inbound traffic policy per port for the given upstream identity
+// and services, computed as if the given HTTPRouteGroup was applied. The proposed HTTPRouteGroup substitutes the
+// existing one of the same namespace and name, or is added if no such HTTPRouteGroup exists. It allows previewing
+// the routes affected by a change to an HTTPRouteGroup before it is applied.
+func (mc *MeshCatalog) PreviewHTTPRouteGroupChange(upstreamIdentity identity.ServiceIdentity, upstreamServices []service.MeshService,
+	proposed *spec.HTTPRouteGroup) (map[int][]*trafficpolicy.InboundTrafficPolicy, error) {
+	if proposed == nil {
+		return nil, fmt.Errorf("%w: no HTTPRouteGroup proposed", errInvalidHTTPRouteGroup)
+	}
+	if len(proposed.Spec.Matches) == 0 {
+		return nil, fmt.Errorf("%w: HTTPRouteGroup %s/%s has no matches", errInvalidHTTPRouteGroup, proposed.Namespace, proposed.Name)
+	}
+
+	preview := mc.withOverlay(httpRouteGroupOverlay{Interface: mc.Interface, proposed: proposed})
+	routeConfigPerPort, err := preview.getInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, upstreamServices, nil)
+	if err != nil {
+		logInboundMeshHTTPRouteConfigsError(err, upstreamIdentity)
+	}
+	return routeConfigPerPort, nil
+}
+
+// withOverlay returns a copy of the MeshCatalog that reads the mesh resources from the given overlay of its
+// compute.Interface. The copy keeps every other setting of the MeshCatalog, so that the policies it builds only
+// differ by the resources of the overlay, and has no inbound policy cache, so that the policies built from
+// hypothetical resources are never served to proxies.
+func (mc *MeshCatalog) withOverlay(overlay compute.Interface) *MeshCatalog {
+	preview := *mc
+	preview.Interface = overlay
+	preview.inboundPolicyCache = nil
+	return &preview
+}
@@ -0,0 +1,200 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	access "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/access/v1alpha3"
+	spec "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/specs/v1alpha4"
+	tassert "github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
+	tresorFake "github.com/openservicemesh/osm/pkg/certificate/providers/tresor/fake"
+	"github.com/openservicemesh/osm/pkg/compute/kube"
+	configFake "github.com/openservicemesh/osm/pkg/gen/client/config/clientset/versioned/fake"
+	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/k8s"
+	"github.com/openservicemesh/osm/pkg/service"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
+)
+
+func TestPreviewHTTPRouteGroupChange(t *testing.T) {
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	trafficTargets := []*access.TrafficTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "t1", Namespace: "ns1"},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+				Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "sa2", Namespace: "ns2"}},
+				Rules:       []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "rule-1", Matches: []string{"route-1", "route-2"}}},
+			},
+		},
+	}
+	existingRouteGroup := &spec.HTTPRouteGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rule-1"},
+		Spec: spec.HTTPRouteGroupSpec{
+			Matches: []spec.HTTPMatch{{Name: "route-1", PathRegex: "/get", Methods: []string{"GET"}}},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		proposed      *spec.HTTPRouteGroup
+		expectedPaths []string
+		expectedErr   error
+	}{
+		{
+			name: "proposed HTTPRouteGroup adds a match referenced by a TrafficTarget",
+			proposed: &spec.HTTPRouteGroup{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rule-1"},
+				Spec: spec.HTTPRouteGroupSpec{
+					Matches: []spec.HTTPMatch{
+						{Name: "route-1", PathRegex: "/get", Methods: []string{"GET"}},
+						{Name: "route-2", PathRegex: "/post", Methods: []string{"POST"}},
+					},
+				},
+			},
+			expectedPaths: []string{"/get", "/post"},
+		},
+		{
+			name: "proposed HTTPRouteGroup replaces the existing matches",
+			proposed: &spec.HTTPRouteGroup{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rule-1"},
+				Spec: spec.HTTPRouteGroupSpec{
+					Matches: []spec.HTTPMatch{{Name: "route-2", PathRegex: "/post", Methods: []string{"POST"}}},
+				},
+			},
+			expectedPaths: []string{"/post"},
+		},
+		{
+			name: "proposed HTTPRouteGroup not referenced by a TrafficTarget does not affect the routes",
+			proposed: &spec.HTTPRouteGroup{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rule-2"},
+				Spec: spec.HTTPRouteGroupSpec{
+					Matches: []spec.HTTPMatch{{Name: "route-2", PathRegex: "/post", Methods: []string{"POST"}}},
+				},
+			},
+			expectedPaths: []string{"/get"},
+		},
+		{
+			name: "proposed HTTPRouteGroup without matches",
+			proposed: &spec.HTTPRouteGroup{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rule-1"},
+			},
+			expectedErr: errInvalidHTTPRouteGroup,
+		},
+		{
+			name:        "no proposed HTTPRouteGroup",
+			proposed:    nil,
+			expectedErr: errInvalidHTTPRouteGroup,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mrc := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain: "cluster.local",
+					Intent:      v1alpha2.ActiveIntent,
+				},
+			}
+
+			configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+			mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+			fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+			mockK8s := k8s.NewMockController(mockCtrl)
+			mrcClient.NewCertEvent(mrc.Name)
+
+			mc := MeshCatalog{
+				certManager: fakeCertManager,
+				Interface:   kube.NewClient(mockK8s),
+			}
+
+			mockK8s.EXPECT().ListUpstreamTrafficSettings().Return(nil).AnyTimes()
+			mockK8s.EXPECT().ListTrafficSplits().Return(nil).AnyTimes()
+			mockK8s.EXPECT().ListTrafficTargets().Return(trafficTargets).AnyTimes()
+			mockK8s.EXPECT().ListHTTPTrafficSpecs().Return([]*spec.HTTPRouteGroup{existingRouteGroup}).AnyTimes()
+			mockK8s.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{}).AnyTimes()
+
+			// Without the proposed HTTPRouteGroup, only the existing route is allowed
+			current := mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{upstreamSvc})
+			assert.Equal([]string{"/get"}, getInboundRulePaths(current[int(upstreamSvc.TargetPort)]))
+
+			actual, err := mc.PreviewHTTPRouteGroupChange(upstreamIdentity, []service.MeshService{upstreamSvc}, tc.proposed)
+			assert.True(errors.Is(err, tc.expectedErr))
+			if tc.expectedErr != nil {
+				assert.Nil(actual)
+				return
+			}
+			assert.ElementsMatch(tc.expectedPaths, getInboundRulePaths(actual[int(upstreamSvc.TargetPort)]))
+		})
+	}
+}
+
+func TestHTTPRouteGroupOverlay(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockK8s := k8s.NewMockController(mockCtrl)
+	rg1 := &spec.HTTPRouteGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rg1"}}
+	rg2 := &spec.HTTPRouteGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "ns2", Name: "rg1"}}
+	mockK8s.EXPECT().ListHTTPTrafficSpecs().Return([]*spec.HTTPRouteGroup{rg1, rg2}).AnyTimes()
+
+	// A proposed HTTPRouteGroup with the same namespace and name replaces the existing one
+	proposed := &spec.HTTPRouteGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rg1", Labels: map[string]string{"proposed": "true"}}}
+	overlay := httpRouteGroupOverlay{Interface: kube.NewClient(mockK8s), proposed: proposed}
+	assert.Equal([]*spec.HTTPRouteGroup{rg2, proposed}, overlay.ListHTTPTrafficSpecs())
+
+	// A new HTTPRouteGroup is added to the existing ones
+	proposed = &spec.HTTPRouteGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "ns3", Name: "rg1"}}
+	overlay = httpRouteGroupOverlay{Interface: kube.NewClient(mockK8s), proposed: proposed}
+	assert.Equal([]*spec.HTTPRouteGroup{rg1, rg2, proposed}, overlay.ListHTTPTrafficSpecs())
+}
+
+func TestMeshCatalogWithOverlay(t *testing.T) {
+	assert := tassert.New(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mc := &MeshCatalog{
+		certManager: tresorFake.NewFake(1 * time.Hour),
+		Interface:   kube.NewClient(k8s.NewMockController(mockCtrl)),
+	}
+	mc.SetClusterNameFormatter(testClusterNameFormatter{})
+	mc.EnableInboundPolicyCache()
+
+	// The copy keeps the settings of the catalog, and does not share its cache
+	overlay := httpRouteGroupOverlay{Interface: mc.Interface, proposed: &spec.HTTPRouteGroup{}}
+	preview := mc.withOverlay(overlay)
+	assert.Equal(overlay, preview.Interface)
+	assert.Same(mc.certManager, preview.certManager)
+	assert.Equal(testClusterNameFormatter{}, preview.clusterNameFormatter)
+	assert.Nil(preview.inboundPolicyCache)
+	assert.NotNil(mc.inboundPolicyCache)
+}
+
+// getInboundRulePaths returns the paths of the rules of the given inbound traffic policies
+func getInboundRulePaths(policies []*trafficpolicy.InboundTrafficPolicy) []string {
+	var paths []string
+	for _, policy := range policies {
+		for _, rule := range policy.Rules {
+			paths = append(paths, rule.Route.HTTPRouteMatch.Path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,142 @@
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set"
+
+	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/service"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
+)
+
+// RouteKey identifies an inbound route by its HTTP route match
+type RouteKey struct {
+	// Path is the path of the route
+	Path string
+
+	// PathMatchType is the type of path matching of the route
+	PathMatchType trafficpolicy.PathMatchType
+
+	// CaseInsensitive is true if the path of the route is matched case insensitively
+	CaseInsensitive bool
+
+	// Methods is the comma separated list of sorted HTTP methods of the route
+	Methods string
+
+	// Headers is the comma separated list of sorted <name>=<value> HTTP headers of the route
+	Headers string
+
+	// HeaderMatchType is the type of matching of the header values of the route
+	HeaderMatchType trafficpolicy.HeaderMatchType
+
+	// QueryParams is the comma separated list of sorted <name>=<value> query parameters of the route
+	QueryParams string
+
+	// Authority is the :authority header value of the route
+	Authority string
+
+	// GRPC is true if the route only matches gRPC requests
+	GRPC bool
+}
+
+// RulePrincipals are the principals enforced by the RBAC policy of an inbound route
+type RulePrincipals struct {
+	// Allowed are the sorted principals allowed to access the route
+	Allowed []string
+
+	// Denied are the sorted principals denied access to the route, even if they are allowed
+	Denied []string
+}
+
+// String returns the string representation of the RouteKey
+func (k RouteKey) String() string {
+	str := fmt.Sprintf("path=%s methods=%s headers=%s", k.Path, k.Methods, k.Headers)
+	if k.CaseInsensitive {
+		str += " case-insensitive"
+	}
+	if k.QueryParams != "" {
+		str += fmt.Sprintf(" query=%s", k.QueryParams)
+	}
+	if k.Authority != "" {
+		str += fmt.Sprintf(" authority=%s", k.Authority)
+	}
+	if k.GRPC {
+		str += " grpc"
+	}
+	return str
+}
+
+// newRouteKey returns the RouteKey for the given HTTP route match. Route matches with the same key match the same requests.
+func newRouteKey(match trafficpolicy.HTTPRouteMatch) RouteKey {
+	methods := append([]string(nil), match.Methods...)
+	sort.Strings(methods)
+
+	return RouteKey{
+		Path:            match.Path,
+		PathMatchType:   match.PathMatchType,
+		CaseInsensitive: !match.IsCaseSensitive(),
+		Methods:         strings.Join(methods, ","),
+		Headers:         joinSortedKeyValues(match.Headers),
+		HeaderMatchType: match.HeaderMatchType,
+		QueryParams:     joinSortedKeyValues(match.QueryParams),
+		Authority:       match.Authority,
+		GRPC:            match.GRPC,
+	}
+}
+
+// joinSortedKeyValues returns the comma separated list of sorted <key>=<value> pairs of the given map
+func joinSortedKeyValues(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// GetRulePrincipals returns the principals allowed and denied per route by the inbound traffic policy for the given
+// upstream identity and service on the given port. The principals are the ones enforced by the RBAC policies of the
+// routes, and are meant for debugging.
+func (mc *MeshCatalog) GetRulePrincipals(upstreamIdentity identity.ServiceIdentity, svc service.MeshService, port int) (map[RouteKey]RulePrincipals, error) {
+	for _, policy := range mc.GetInboundMeshHTTPRouteConfigsPerPort(upstreamIdentity, []service.MeshService{svc})[port] {
+		if policy.Name == svc.FQDN() {
+			return getRulePrincipals(policy), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: service %s on port %d", errNoInboundPolicyForService, svc, port)
+}
+
+// getRulePrincipals returns the principals allowed and denied per route by the given inbound traffic policy
+func getRulePrincipals(policy *trafficpolicy.InboundTrafficPolicy) map[RouteKey]RulePrincipals {
+	// Rules for the same route are enforced together, so their principals are merged
+	allowedSetPerRoute := make(map[RouteKey]mapset.Set)
+	deniedSetPerRoute := make(map[RouteKey]mapset.Set)
+	for _, rule := range policy.Rules {
+		key := newRouteKey(rule.Route.HTTPRouteMatch)
+		if _, ok := allowedSetPerRoute[key]; !ok {
+			allowedSetPerRoute[key] = mapset.NewSet()
+			deniedSetPerRoute[key] = mapset.NewSet()
+		}
+		allowedSetPerRoute[key] = allowedSetPerRoute[key].Union(rule.AllowedPrincipals)
+		if rule.DeniedPrincipals != nil {
+			deniedSetPerRoute[key] = deniedSetPerRoute[key].Union(rule.DeniedPrincipals)
+		}
+	}
+
+	principalsPerRoute := make(map[RouteKey]RulePrincipals)
+	for key, allowedSet := range allowedSetPerRoute {
+		var denied []string
+		if deniedSet := deniedSetPerRoute[key]; deniedSet.Cardinality() > 0 {
+			denied = sortedPrincipals(deniedSet)
+		}
+		principalsPerRoute[key] = RulePrincipals{
+			Allowed: sortedPrincipals(allowedSet),
+			Denied:  denied,
+		}
+	}
+
+	return principalsPerRoute
+}
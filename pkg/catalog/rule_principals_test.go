@@ -0,0 +1,254 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/golang/mock/gomock"
+	access "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/access/v1alpha3"
+	spec "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/specs/v1alpha4"
+	tassert "github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
+	tresorFake "github.com/openservicemesh/osm/pkg/certificate/providers/tresor/fake"
+	"github.com/openservicemesh/osm/pkg/compute/kube"
+	configFake "github.com/openservicemesh/osm/pkg/gen/client/config/clientset/versioned/fake"
+	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/k8s"
+	"github.com/openservicemesh/osm/pkg/service"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
+)
+
+func TestGetRulePrincipals(t *testing.T) {
+	upstreamIdentity := identity.K8sServiceAccount{Namespace: "ns1", Name: "sa1"}.ToServiceIdentity()
+	upstreamSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+
+	trafficTargets := []*access.TrafficTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "t1", Namespace: "ns1"},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+				Sources: []access.IdentityBindingSubject{
+					{Kind: "ServiceAccount", Name: "sa3", Namespace: "ns3"},
+					{Kind: "ServiceAccount", Name: "sa2", Namespace: "ns2"},
+				},
+				Rules: []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "rule-1", Matches: []string{"route-1"}}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "t2", Namespace: "ns1"},
+			Spec: access.TrafficTargetSpec{
+				Destination: access.IdentityBindingSubject{Kind: "ServiceAccount", Name: "sa1", Namespace: "ns1"},
+				Sources:     []access.IdentityBindingSubject{{Kind: "ServiceAccount", Name: "sa4", Namespace: "ns4"}},
+				Rules:       []access.TrafficTargetRule{{Kind: "HTTPRouteGroup", Name: "rule-1", Matches: []string{"route-2"}}},
+			},
+		},
+	}
+	httpRouteGroups := []*spec.HTTPRouteGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "rule-1"},
+			Spec: spec.HTTPRouteGroupSpec{
+				Matches: []spec.HTTPMatch{
+					{Name: "route-1", PathRegex: "/get", Methods: []string{"GET", "HEAD"}},
+					{Name: "route-2", PathRegex: "/post", Methods: []string{"POST"}},
+				},
+			},
+		},
+	}
+
+	getRoute := RouteKey{Path: "/get", PathMatchType: trafficpolicy.PathMatchExact, Methods: "GET,HEAD"}
+	postRoute := RouteKey{Path: "/post", PathMatchType: trafficpolicy.PathMatchExact, Methods: "POST"}
+	wildcardRoute := RouteKey{Path: ".*", PathMatchType: trafficpolicy.PathMatchRegex, Methods: "*"}
+
+	testCases := []struct {
+		name               string
+		permissiveMode     bool
+		spiffeEnabled      bool
+		newTrustDomain     string
+		port               int
+		expectedPrincipals map[RouteKey]RulePrincipals
+		expectedErr        error
+	}{
+		{
+			name: "SMI mode",
+			port: 8080,
+			expectedPrincipals: map[RouteKey]RulePrincipals{
+				getRoute:  {Allowed: []string{"sa2.ns2.cluster.local", "sa3.ns3.cluster.local"}},
+				postRoute: {Allowed: []string{"sa4.ns4.cluster.local"}},
+			},
+		},
+		{
+			name:           "permissive mode",
+			permissiveMode: true,
+			port:           8080,
+			expectedPrincipals: map[RouteKey]RulePrincipals{
+				wildcardRoute: {Allowed: []string{"*"}},
+			},
+		},
+		{
+			name:           "SMI mode with multiple trust domains",
+			newTrustDomain: "cluster.new",
+			port:           8080,
+			expectedPrincipals: map[RouteKey]RulePrincipals{
+				getRoute:  {Allowed: []string{"sa2.ns2.cluster.local", "sa2.ns2.cluster.new", "sa3.ns3.cluster.local", "sa3.ns3.cluster.new"}},
+				postRoute: {Allowed: []string{"sa4.ns4.cluster.local", "sa4.ns4.cluster.new"}},
+			},
+		},
+		{
+			name:          "SMI mode with SPIFFE enabled",
+			spiffeEnabled: true,
+			port:          8080,
+			expectedPrincipals: map[RouteKey]RulePrincipals{
+				getRoute:  {Allowed: []string{"spiffe://cluster.local/sa2/ns2", "spiffe://cluster.local/sa3/ns3"}},
+				postRoute: {Allowed: []string{"spiffe://cluster.local/sa4/ns4"}},
+			},
+		},
+		{
+			name:        "no inbound traffic policy for the port",
+			port:        9090,
+			expectedErr: errNoInboundPolicyForService,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mrc := &v1alpha2.MeshRootCertificate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "osm-mesh-root-certificate",
+					Namespace: "osm-system",
+				},
+				Spec: v1alpha2.MeshRootCertificateSpec{
+					TrustDomain:   "cluster.local",
+					Intent:        v1alpha2.ActiveIntent,
+					SpiffeEnabled: tc.spiffeEnabled,
+				},
+			}
+
+			configClient := configFake.NewSimpleClientset([]runtime.Object{mrc}...)
+			mrcClient := tresorFake.NewFakeMRCWithConfig(configClient)
+			fakeCertManager := tresorFake.NewFakeWithMRCClient(mrcClient, 1*time.Hour)
+			mockK8s := k8s.NewMockController(mockCtrl)
+			mrcClient.NewCertEvent(mrc.Name)
+
+			mc := MeshCatalog{
+				certManager: fakeCertManager,
+				Interface:   kube.NewClient(mockK8s),
+			}
+
+			mockK8s.EXPECT().ListUpstreamTrafficSettings().Return(nil).AnyTimes()
+			mockK8s.EXPECT().ListTrafficSplits().Return(nil).AnyTimes()
+			mockK8s.EXPECT().ListTrafficTargets().Return(trafficTargets).AnyTimes()
+			mockK8s.EXPECT().ListHTTPTrafficSpecs().Return(httpRouteGroups).AnyTimes()
+			mockK8s.EXPECT().GetMeshConfig().Return(v1alpha2.MeshConfig{
+				Spec: v1alpha2.MeshConfigSpec{
+					Traffic: v1alpha2.TrafficSpec{
+						EnablePermissiveTrafficPolicyMode: tc.permissiveMode,
+					},
+				},
+			}).AnyTimes()
+
+			if tc.newTrustDomain != "" {
+				// create a new MRC with the newTrustDomain
+				mrc2 := &v1alpha2.MeshRootCertificate{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "osm-mesh-root-certificate-2",
+						Namespace: "osm-system",
+					},
+					Spec: v1alpha2.MeshRootCertificateSpec{
+						TrustDomain: tc.newTrustDomain,
+						Intent:      v1alpha2.ActiveIntent,
+					},
+				}
+				_, err := configClient.ConfigV1alpha2().MeshRootCertificates("osm-system").Create(context.Background(), mrc2, metav1.CreateOptions{})
+				assert.NoError(err)
+
+				// generate an MRCEvent for the new MRC to update the issuers and trigger a rotation
+				mrcClient.NewCertEvent(mrc2.Name)
+				assert.Eventually(func() bool {
+					return fakeCertManager.GetIssuersInfo().AreDifferent()
+				}, 2*time.Second, 100*time.Millisecond)
+			}
+
+			actual, err := mc.GetRulePrincipals(upstreamIdentity, upstreamSvc, tc.port)
+			assert.True(errors.Is(err, tc.expectedErr))
+			assert.Equal(tc.expectedPrincipals, actual)
+		})
+	}
+}
+
+func TestNewRouteKey(t *testing.T) {
+	assert := tassert.New(t)
+
+	key := newRouteKey(trafficpolicy.HTTPRouteMatch{
+		Path:          "/get",
+		PathMatchType: trafficpolicy.PathMatchExact,
+		Methods:       []string{"HEAD", "GET"},
+		Headers:       map[string]string{"user-agent": "test", "accept": "json"},
+	})
+	assert.Equal(RouteKey{Path: "/get", PathMatchType: trafficpolicy.PathMatchExact, Methods: "GET,HEAD", Headers: "accept=json,user-agent=test"}, key)
+	assert.Equal("path=/get methods=GET,HEAD headers=accept=json,user-agent=test", key.String())
+
+	caseInsensitive := false
+	key = newRouteKey(trafficpolicy.HTTPRouteMatch{
+		Path:          "/get",
+		PathMatchType: trafficpolicy.PathMatchExact,
+		Methods:       []string{"GET"},
+		CaseSensitive: &caseInsensitive,
+		QueryParams:   map[string]string{"version": "v2", "debug": "true"},
+		Authority:     "books.example.com",
+		GRPC:          true,
+	})
+	assert.Equal(RouteKey{
+		Path:            "/get",
+		PathMatchType:   trafficpolicy.PathMatchExact,
+		CaseInsensitive: true,
+		Methods:         "GET",
+		QueryParams:     "debug=true,version=v2",
+		Authority:       "books.example.com",
+		GRPC:            true,
+	}, key)
+	assert.Equal("path=/get methods=GET headers= case-insensitive query=debug=true,version=v2 authority=books.example.com grpc", key.String())
+}
+
+func TestGetRulePrincipalsPerRouteMatch(t *testing.T) {
+	assert := tassert.New(t)
+
+	newRule := func(queryParams map[string]string, allowed ...interface{}) *trafficpolicy.Rule {
+		return &trafficpolicy.Rule{
+			Route: trafficpolicy.RouteWeightedClusters{
+				HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+					Path:          "/books",
+					PathMatchType: trafficpolicy.PathMatchExact,
+					Methods:       []string{"GET"},
+					QueryParams:   queryParams,
+				},
+			},
+			AllowedPrincipals: mapset.NewSet(allowed...),
+			DeniedPrincipals:  mapset.NewSet("sa9.ns9.cluster.local"),
+		}
+	}
+	policy := &trafficpolicy.InboundTrafficPolicy{
+		Name: "s1.ns1.svc.cluster.local",
+		Rules: []*trafficpolicy.Rule{
+			newRule(nil, "sa2.ns2.cluster.local"),
+			newRule(map[string]string{"version": "v2"}, "sa3.ns3.cluster.local"),
+		},
+	}
+
+	// Routes differing only by their query parameters match different requests, so their principals are not merged
+	routeKey := RouteKey{Path: "/books", PathMatchType: trafficpolicy.PathMatchExact, Methods: "GET"}
+	v2RouteKey := RouteKey{Path: "/books", PathMatchType: trafficpolicy.PathMatchExact, Methods: "GET", QueryParams: "version=v2"}
+	assert.Equal(map[RouteKey]RulePrincipals{
+		routeKey:   {Allowed: []string{"sa2.ns2.cluster.local"}, Denied: []string{"sa9.ns9.cluster.local"}},
+		v2RouteKey: {Allowed: []string{"sa3.ns3.cluster.local"}, Denied: []string{"sa9.ns9.cluster.local"}},
+	}, getRulePrincipals(policy))
+}
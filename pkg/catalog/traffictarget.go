@@ -113,7 +113,7 @@ func (mc *MeshCatalog) getAllowedDirectionalServiceAccounts(svcIdentity identity
 					continue
 				}
 
-				if source.Name != svcAccount.Name || source.Namespace != svcAccount.Namespace {
+				if !isTrafficTargetSourceMatch(source, svcAccount) {
 					// This TrafficTarget source does not match the given service account, ignore it
 					continue
 				}
@@ -164,7 +164,7 @@ func (mc *MeshCatalog) getTCPRouteMatchesFromTrafficTarget(trafficTarget smiAcce
 	var matches []trafficpolicy.TCPRouteMatch
 
 	for _, rule := range trafficTarget.Spec.Rules {
-		if rule.Kind != smi.TCPRouteKind {
+		if kind, _ := smi.ParseRouteKind(rule.Kind); kind != smi.TCPRouteKind {
 			continue
 		}
 
@@ -220,3 +220,10 @@ func (mc *MeshCatalog) ListTrafficTargetsByOptions(options ...smi.TrafficTargetL
 	}
 	return trafficTargets
 }
+
+// isTrafficTargetSourceMatch returns true if the given TrafficTarget source matches the given service account.
+// A source in the wildcard namespace matches the service accounts with the same name in all namespaces.
+func isTrafficTargetSourceMatch(source smiAccess.IdentityBindingSubject, svcAccount identity.K8sServiceAccount) bool {
+	return source.Name == svcAccount.Name &&
+		(source.Namespace == svcAccount.Namespace || source.Namespace == identity.WildcardNamespace)
+}
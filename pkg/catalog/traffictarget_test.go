@@ -874,6 +874,81 @@ func TestListInboundTrafficTargetsWithRoutes(t *testing.T) {
 			expectError: false, // no errors expected
 		},
 		// Test case 4 end ------------------------------------
+
+		// Test case 5 begin ------------------------------------
+		{
+			name: "Single traffic target with HTTPRouteGroup and TCPRoute rules",
+			trafficTargets: []*smiAccess.TrafficTarget{
+				{
+					TypeMeta: metav1.TypeMeta{
+						APIVersion: "access.smi-spec.io/v1alpha3",
+						Kind:       "TrafficTarget",
+					},
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-1",
+						Namespace: "ns-1",
+					},
+					Spec: smiAccess.TrafficTargetSpec{
+						Destination: smiAccess.IdentityBindingSubject{
+							Kind:      "ServiceAccount",
+							Name:      "sa-1",
+							Namespace: "ns-1",
+						},
+						Sources: []smiAccess.IdentityBindingSubject{{
+							Kind:      "ServiceAccount",
+							Name:      "sa-2",
+							Namespace: "ns-2",
+						}},
+						Rules: []smiAccess.TrafficTargetRule{
+							{
+								Kind:    "HTTPRouteGroup",
+								Name:    "route-1",
+								Matches: []string{"match-1"},
+							},
+							{
+								Kind: "TCPRoute",
+								Name: "route-1",
+							},
+						},
+					},
+				},
+			},
+
+			// Only the TCPRoute rule is resolved, the HTTPRouteGroup rule is ignored
+			tcpRoutes: map[string]*smiSpecs.TCPRoute{
+				"ns-1/route-1": {
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "route-1",
+						Namespace: "ns-1",
+					},
+					Spec: smiSpecs.TCPRouteSpec{
+						Matches: smiSpecs.TCPMatch{
+							Ports: []int{3306},
+						},
+					},
+				},
+			},
+
+			upstreamServiceIdentity: identity.K8sServiceAccount{Namespace: "ns-1", Name: "sa-1"}.ToServiceIdentity(),
+
+			expectedTrafficTargets: []trafficpolicy.TrafficTargetWithRoutes{
+				{
+					Name:        "ns-1/test-1",
+					Destination: identity.ServiceIdentity("sa-1.ns-1"),
+					Sources: []identity.ServiceIdentity{
+						identity.ServiceIdentity("sa-2.ns-2"),
+					},
+					TCPRouteMatches: []trafficpolicy.TCPRouteMatch{
+						{
+							Ports: []uint16{3306},
+						},
+					},
+				},
+			},
+
+			expectError: false, // no errors expected
+		},
+		// Test case 5 end ------------------------------------
 	}
 
 	for i, tc := range testCases {
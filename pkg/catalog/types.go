@@ -5,6 +5,8 @@
 package catalog
 
 import (
+	spec "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/specs/v1alpha4"
+
 	"github.com/openservicemesh/osm/pkg/certificate"
 	"github.com/openservicemesh/osm/pkg/compute"
 	"github.com/openservicemesh/osm/pkg/endpoint"
@@ -21,7 +23,18 @@ var (
 // MeshCatalog is the struct for the service catalog
 type MeshCatalog struct {
 	compute.Interface
-	certManager *certificate.Manager
+	certManager          *certificate.Manager
+	clusterNameFormatter ClusterNameFormatter
+
+	// inboundPolicyCache caches the inbound HTTP route configs per proxy, the cache is disabled when nil
+	inboundPolicyCache *inboundPolicyCache
+}
+
+// ClusterNameFormatter is the mechanism by which the names of the local clusters for upstream services are formatted.
+// The same name is used by the cluster configs and the routes referencing them.
+type ClusterNameFormatter interface {
+	// LocalClusterName returns the name of the local cluster for the given upstream service
+	LocalClusterName(service.MeshService) string
 }
 
 // MeshCataloger is the mechanism by which the Service Mesh controller discovers all Envoy proxies connected to the catalog.
@@ -31,6 +44,9 @@ type MeshCataloger interface {
 	// ListOutboundServicesForIdentity list the services the given service identity is allowed to initiate outbound connections to
 	ListOutboundServicesForIdentity(identity.ServiceIdentity) []service.MeshService
 
+	// GetOutboundAllowedServices returns the services the given downstream identity is allowed to reach, including TrafficSplit apex services
+	GetOutboundAllowedServices(downstreamIdentity identity.ServiceIdentity) ([]service.MeshService, error)
+
 	// ListInboundServiceIdentities lists the downstream service identities that are allowed to connect to the given service identity
 	ListInboundServiceIdentities(identity.ServiceIdentity) []identity.ServiceIdentity
 
@@ -41,27 +57,66 @@ type MeshCataloger interface {
 	// is allowed access the upstream service
 	ListAllowedUpstreamEndpointsForService(identity.ServiceIdentity, service.MeshService) []endpoint.Endpoint
 
+	// GetIdentityZone returns the zone of the endpoints of the given service identity, or an empty string if it is not known
+	GetIdentityZone(identity.ServiceIdentity) string
+
 	// ListInboundTrafficTargetsWithRoutes returns a list traffic target objects composed of its routes for the given destination service identity
 	ListInboundTrafficTargetsWithRoutes(identity.ServiceIdentity) ([]trafficpolicy.TrafficTargetWithRoutes, error)
 
-	// GetInboundMeshClusterConfigs returns the cluster configs for the inbound mesh traffic policy for the given upstream services
-	GetInboundMeshClusterConfigs([]service.MeshService) []*trafficpolicy.MeshClusterConfig
+	// GetInboundMeshClusterConfigs returns the cluster configs for the inbound mesh traffic policy for the given upstream identity and services
+	GetInboundMeshClusterConfigs(identity.ServiceIdentity, []service.MeshService) []*trafficpolicy.MeshClusterConfig
+
+	// GetInboundMeshTrafficMatches returns the traffic matches for the inbound mesh traffic policy for the given upstream identity and services
+	GetInboundMeshTrafficMatches(identity.ServiceIdentity, []service.MeshService) []*trafficpolicy.TrafficMatch
 
-	// GetInboundMeshTrafficMatches returns the traffic matches for the inbound mesh traffic policy for the given upstream services
-	GetInboundMeshTrafficMatches([]service.MeshService) []*trafficpolicy.TrafficMatch
+	// GetServerNamesForService returns the server names accepted by the inbound traffic match of the given upstream service
+	GetServerNamesForService(service.MeshService) []string
 
 	// GetInboundMeshHTTPRouteConfigsPerPort returns a map of the given inbound traffic policy per port for the given upstream identity and services
 	GetInboundMeshHTTPRouteConfigsPerPort(identity.ServiceIdentity, []service.MeshService) map[int][]*trafficpolicy.InboundTrafficPolicy
 
+	// GetInboundMeshHTTPRouteConfigsPerPortWithError returns a map of the given inbound traffic policy per port for the given upstream
+	// identity and services, along with an error if the policies could only be partially built
+	GetInboundMeshHTTPRouteConfigsPerPortWithError(identity.ServiceIdentity, []service.MeshService) (map[int][]*trafficpolicy.InboundTrafficPolicy, error)
+
+	// RecordInboundRulesPerPolicy records the number of rules in the given inbound traffic policies, built for the given upstream services, per service
+	RecordInboundRulesPerPolicy([]service.MeshService, map[int][]*trafficpolicy.InboundTrafficPolicy)
+
+	// BuildInboundPolicyWithTrustDomains returns a map of the given inbound traffic policy per port for the given upstream identity and services,
+	// with the downstream principals built for the given trust domains instead of the trust domains of the configured issuers
+	BuildInboundPolicyWithTrustDomains(upstreamIdentity identity.ServiceIdentity, upstreamServices []service.MeshService, trustDomains []string, spiffeEnabled bool) map[int][]*trafficpolicy.InboundTrafficPolicy
+
+	// PreviewHTTPRouteGroupChange returns a map of the inbound traffic policy per port for the given upstream identity and services,
+	// computed as if the given HTTPRouteGroup was applied
+	PreviewHTTPRouteGroupChange(upstreamIdentity identity.ServiceIdentity, upstreamServices []service.MeshService, proposed *spec.HTTPRouteGroup) (map[int][]*trafficpolicy.InboundTrafficPolicy, error)
+
+	// GetRulePrincipals returns the principals allowed and denied per route by the inbound traffic policy for the given
+	// upstream identity and service on the given port
+	GetRulePrincipals(upstreamIdentity identity.ServiceIdentity, svc service.MeshService, port int) (map[RouteKey]RulePrincipals, error)
+
+	// GetAllowedMethods returns the HTTP methods allowed on the given path of the service reachable using the given host
+	GetAllowedMethods(host string, path string) ([]string, error)
+
+	// GetInvalidPolicyResources returns the validation errors for the policy resources that fail validation
+	GetInvalidPolicyResources() ([]PolicyValidationError, error)
+
 	// GetOutboundMeshClusterConfigs returns the cluster configs for the outbound mesh traffic policy for the given downstream identity
 	GetOutboundMeshClusterConfigs(identity.ServiceIdentity) []*trafficpolicy.MeshClusterConfig
 
+	// GetOutboundExternalClusterConfigs returns the cluster configs for the external hosts referenced as TrafficSplit backends
+	// by the services the given downstream identity is allowed to reach
+	GetOutboundExternalClusterConfigs(identity.ServiceIdentity) []*trafficpolicy.EgressClusterConfig
+
 	// GetOutboundMeshTrafficMatches returns the traffic matches for the outbound mesh traffic policy for the given downstream identity
 	GetOutboundMeshTrafficMatches(identity.ServiceIdentity) []*trafficpolicy.TrafficMatch
 
 	// GetOutboundMeshHTTPRouteConfigsPerPort returns a map of the given outbound traffic policy per port for the given downstream identity
 	GetOutboundMeshHTTPRouteConfigsPerPort(identity.ServiceIdentity) map[int][]*trafficpolicy.OutboundTrafficPolicy
 
+	// GetOutboundRouteConfigsForDestination returns a map of the outbound traffic policy per port the given source identity uses
+	// to reach the given destination service
+	GetOutboundRouteConfigsForDestination(source identity.ServiceIdentity, dest service.MeshService) map[int][]*trafficpolicy.OutboundTrafficPolicy
+
 	// GetEgressClusterConfigs returns the cluster configs for the egress traffic policy associated with the given service identity.
 	GetEgressClusterConfigs(identity.ServiceIdentity) ([]*trafficpolicy.EgressClusterConfig, error)
 
@@ -82,6 +137,9 @@ type MeshCataloger interface {
 
 	// GetIngressHTTPRoutePolicies returns the ingress traffic matches for the ingress traffic policy for the given mesh service
 	GetIngressTrafficMatches([]service.MeshService) [][]*trafficpolicy.IngressTrafficMatch
+
+	// GetServicesAffectedByMRCRemoval returns the services whose inbound principals include the trust domain of the given MeshRootCertificate
+	GetServicesAffectedByMRCRemoval(mrcName string) ([]service.MeshService, error)
 }
 
 type trafficDirection string
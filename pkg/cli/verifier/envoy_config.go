@@ -10,6 +10,7 @@ import (
 	xds_cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	xds_listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	xds_route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	xds_tcp_proxy "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	xds_secret "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -208,7 +209,7 @@ func (v *EnvoyConfigVerifier) verifySource() Result {
 			return result
 		}
 
-		if err := v.findClusterForService(svc, clusters, true); err != nil {
+		if err := v.findClusterForService(svc, clusters); err != nil {
 			result.Status = Failure
 			result.Reason = fmt.Sprintf("Did not find matching outbound cluster for service %q: %s", dst, err)
 			return result
@@ -434,7 +435,7 @@ func (v *EnvoyConfigVerifier) verifyDestination() Result {
 			result.Reason = fmt.Sprintf("Did not find matching inbound route configuration for service %q: %s", dst, err)
 			return result
 		}
-		if err := v.findClusterForService(svc, clusters, false); err != nil {
+		if err := v.findInboundClusterForService(svc, inboundListener.FilterChains, routeConfigs, clusters); err != nil {
 			result.Status = Failure
 			result.Reason = fmt.Sprintf("Did not find matching inbound cluster for service %q: %s", dst, err)
 			return result
@@ -651,7 +652,7 @@ func (v *EnvoyConfigVerifier) findHTTPRouteForService(svc *corev1.Service, route
 	return nil
 }
 
-func (v *EnvoyConfigVerifier) findClusterForService(svc *corev1.Service, clusters []*xds_cluster.Cluster, isOutbound bool) error {
+func (v *EnvoyConfigVerifier) findClusterForService(svc *corev1.Service, clusters []*xds_cluster.Cluster) error {
 	if svc == nil {
 		return nil
 	}
@@ -662,21 +663,95 @@ func (v *EnvoyConfigVerifier) findClusterForService(svc *corev1.Service, cluster
 	}
 
 	for _, meshSvc := range meshServices {
-		var desiredClusterName string
-		if isOutbound {
-			desiredClusterName = meshSvc.EnvoyClusterName()
-		} else {
-			desiredClusterName = meshSvc.EnvoyLocalClusterName()
+		if err := findCluster(clusters, meshSvc.EnvoyClusterName()); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
+
+// findInboundClusterForService verifies the local clusters of the given service exist. The names of the local clusters
+// are read from the inbound filter chains and routes referencing them, as the control plane may be configured to name
+// them differently than the default naming scheme.
+func (v *EnvoyConfigVerifier) findInboundClusterForService(svc *corev1.Service, filterChains []*xds_listener.FilterChain,
+	routeConfigs []*xds_route.RouteConfiguration, clusters []*xds_cluster.Cluster) error {
+	if svc == nil {
+		return nil
+	}
 
-		if err := findCluster(clusters, desiredClusterName); err != nil {
+	meshServices, err := v.getDstMeshServicesForK8sSvc(*svc)
+	if len(meshServices) == 0 || err != nil {
+		return fmt.Errorf("endpoints not found for service %s/%s, err: %w", svc.Namespace, svc.Name, err)
+	}
+
+	for _, meshSvc := range meshServices {
+		clusterNames, err := getInboundLocalClusterNames(meshSvc, filterChains, routeConfigs)
+		if err != nil {
 			return err
 		}
+		for _, clusterName := range clusterNames {
+			if err := findCluster(clusters, clusterName); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
+// getInboundLocalClusterNames returns the names of the local clusters the inbound traffic of the given service is
+// routed to, referenced by the routes of its inbound virtual host for HTTP services, or by the TCP proxy filter of its
+// inbound filter chain otherwise
+func getInboundLocalClusterNames(meshSvc service.MeshService, filterChains []*xds_listener.FilterChain,
+	routeConfigs []*xds_route.RouteConfiguration) ([]string, error) {
+	var clusterNames []string
+
+	if getFilterForProtocol(meshSvc.Protocol) == envoy.HTTPConnectionManagerFilterName {
+		virtualHost, err := getVirtualHost(routeConfigs, rds.GetInboundMeshRouteConfigNameForPort(int(meshSvc.TargetPort)), meshSvc.FQDN())
+		if err != nil {
+			return nil, err
+		}
+		for _, route := range virtualHost.Routes {
+			if cluster := route.GetRoute().GetCluster(); cluster != "" {
+				clusterNames = append(clusterNames, cluster)
+			}
+			for _, weightedCluster := range route.GetRoute().GetWeightedClusters().GetClusters() {
+				clusterNames = append(clusterNames, weightedCluster.Name)
+			}
+		}
+		if len(clusterNames) == 0 {
+			return nil, fmt.Errorf("no route to a local cluster found for virtual host %s", virtualHost.Name)
+		}
+		return clusterNames, nil
+	}
+
+	for _, fc := range filterChains {
+		if fc.Name != meshSvc.InboundTrafficMatchName() {
+			continue
+		}
+		for _, filter := range fc.Filters {
+			if filter.Name != envoy.TCPProxyFilterName {
+				continue
+			}
+			tcpProxy := &xds_tcp_proxy.TcpProxy{}
+			if err := filter.GetTypedConfig().UnmarshalTo(tcpProxy); err != nil {
+				return nil, fmt.Errorf("error parsing filter %s of filter chain %s: %w", filter.Name, fc.Name, err)
+			}
+			if cluster := tcpProxy.GetCluster(); cluster != "" {
+				clusterNames = append(clusterNames, cluster)
+			}
+			for _, weightedCluster := range tcpProxy.GetWeightedClusters().GetClusters() {
+				clusterNames = append(clusterNames, weightedCluster.Name)
+			}
+		}
+	}
+	if len(clusterNames) == 0 {
+		return nil, fmt.Errorf("no TCP proxy to a local cluster found for filter chain %s", meshSvc.InboundTrafficMatchName())
+	}
+	return clusterNames, nil
+}
+
 func findCluster(clusters []*xds_cluster.Cluster, name string) error {
 	for _, c := range clusters {
 		if c.Name == name {
@@ -688,6 +763,12 @@ func findCluster(clusters []*xds_cluster.Cluster, name string) error {
 }
 
 func findHTTPRouteConfig(routeConfigs []*xds_route.RouteConfiguration, desireConfigName string, desiredDomain string) error {
+	_, err := getVirtualHost(routeConfigs, desireConfigName, desiredDomain)
+	return err
+}
+
+// getVirtualHost returns the virtual host for the given domain in the route configuration with the given name
+func getVirtualHost(routeConfigs []*xds_route.RouteConfiguration, desireConfigName string, desiredDomain string) (*xds_route.VirtualHost, error) {
 	var config *xds_route.RouteConfiguration
 
 	for _, c := range routeConfigs {
@@ -698,7 +779,7 @@ func findHTTPRouteConfig(routeConfigs []*xds_route.RouteConfiguration, desireCon
 	}
 
 	if config == nil {
-		return fmt.Errorf("route configuration %s not found", desireConfigName)
+		return nil, fmt.Errorf("route configuration %s not found", desireConfigName)
 	}
 
 	// Look for the FQDN in the virtual hosts
@@ -713,10 +794,10 @@ func findHTTPRouteConfig(routeConfigs []*xds_route.RouteConfiguration, desireCon
 	}
 
 	if virtualHost == nil {
-		return fmt.Errorf("virtual host for domain %s not found", desiredDomain)
+		return nil, fmt.Errorf("virtual host for domain %s not found", desiredDomain)
 	}
 
-	return nil
+	return virtualHost, nil
 }
 
 func (v *EnvoyConfigVerifier) findTLSSecretsOnSource(secrets []*xds_secret.Secret) error {
@@ -0,0 +1,73 @@
+package verifier
+
+import (
+	"testing"
+
+	xds_listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	xds_route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	xds_tcp_proxy "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	tassert "github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/openservicemesh/osm/pkg/envoy"
+	"github.com/openservicemesh/osm/pkg/envoy/generator/rds"
+	"github.com/openservicemesh/osm/pkg/service"
+)
+
+func TestGetInboundLocalClusterNames(t *testing.T) {
+	assert := tassert.New(t)
+
+	httpSvc := service.MeshService{Name: "s1", Namespace: "ns1", Port: 80, TargetPort: 8080, Protocol: "http"}
+	tcpSvc := service.MeshService{Name: "s2", Namespace: "ns1", Port: 90, TargetPort: 9090, Protocol: "tcp"}
+	unknownSvc := service.MeshService{Name: "s3", Namespace: "ns1", Port: 91, TargetPort: 9091, Protocol: "tcp"}
+
+	// The local clusters are named by a custom naming scheme
+	tcpProxy, err := anypb.New(&xds_tcp_proxy.TcpProxy{
+		ClusterSpecifier: &xds_tcp_proxy.TcpProxy_Cluster{Cluster: "local-ns1-s2-9090"},
+	})
+	assert.Nil(err)
+	filterChains := []*xds_listener.FilterChain{
+		{
+			Name: tcpSvc.InboundTrafficMatchName(),
+			Filters: []*xds_listener.Filter{
+				{Name: envoy.TCPProxyFilterName, ConfigType: &xds_listener.Filter_TypedConfig{TypedConfig: tcpProxy}},
+			},
+		},
+	}
+	routeConfigs := []*xds_route.RouteConfiguration{
+		{
+			Name: rds.GetInboundMeshRouteConfigNameForPort(int(httpSvc.TargetPort)),
+			VirtualHosts: []*xds_route.VirtualHost{
+				{
+					Name:    "inbound_virtual-host|s1.ns1.svc.cluster.local",
+					Domains: []string{httpSvc.FQDN()},
+					Routes: []*xds_route.Route{
+						{
+							Action: &xds_route.Route_Route{
+								Route: &xds_route.RouteAction{
+									ClusterSpecifier: &xds_route.RouteAction_WeightedClusters{
+										WeightedClusters: &xds_route.WeightedCluster{
+											Clusters: []*xds_route.WeightedCluster_ClusterWeight{{Name: "local-ns1-s1-8080"}},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	clusterNames, err := getInboundLocalClusterNames(httpSvc, filterChains, routeConfigs)
+	assert.Nil(err)
+	assert.Equal([]string{"local-ns1-s1-8080"}, clusterNames)
+
+	clusterNames, err = getInboundLocalClusterNames(tcpSvc, filterChains, routeConfigs)
+	assert.Nil(err)
+	assert.Equal([]string{"local-ns1-s2-9090"}, clusterNames)
+
+	clusterNames, err = getInboundLocalClusterNames(unknownSvc, filterChains, routeConfigs)
+	assert.NotNil(err)
+	assert.Nil(clusterNames)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -63,6 +64,9 @@ func (c *client) ListEndpointsForService(svc service.MeshService) []endpoint.End
 	}
 
 	var endpoints []endpoint.Endpoint
+	// The zones of the endpoints are only needed for locality aware load balancing, the MeshConfig is only read
+	// once an endpoint references a pod
+	var readZones *bool
 	for _, kubernetesEndpoint := range kubernetesEndpoints.Subsets {
 		for _, port := range kubernetesEndpoint.Ports {
 			// If a TargetPort is specified for the service, filter the endpoint by this port.
@@ -86,6 +90,15 @@ func (c *client) ListEndpointsForService(svc service.MeshService) []endpoint.End
 					IP:   ip,
 					Port: endpoint.Port(port.Port),
 				}
+				if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+					if readZones == nil {
+						enabled := c.kubeController.GetMeshConfig().Spec.Traffic.EnableLocalityAwareLoadBalancing
+						readZones = &enabled
+					}
+					if *readZones {
+						ept.Zone = c.getPodZone(address.TargetRef, svc.Namespace)
+					}
+				}
 				endpoints = append(endpoints, ept)
 			}
 		}
@@ -96,6 +109,19 @@ func (c *client) ListEndpointsForService(svc service.MeshService) []endpoint.End
 	return endpoints
 }
 
+// getPodZone returns the zone of the pod referenced by an endpoint of a service in the given namespace, as specified
+// by the topology.kubernetes.io/zone label on the pod, or an empty string if the pod is not found or has no zone label
+func (c *client) getPodZone(podRef *corev1.ObjectReference, namespace string) string {
+	if podRef.Namespace != "" {
+		namespace = podRef.Namespace
+	}
+	pod := c.kubeController.GetPod(podRef.Name, namespace)
+	if pod == nil {
+		return ""
+	}
+	return pod.Labels[corev1.LabelTopologyZone]
+}
+
 // ListEndpointsForIdentity retrieves the list of IP addresses for the given service account
 // Note: ServiceIdentity must be in the format "name.namespace" [https://github.com/openservicemesh/osm/issues/3188]
 func (c *client) ListEndpointsForIdentity(serviceIdentity identity.ServiceIdentity) []endpoint.Endpoint {
@@ -117,7 +143,7 @@ func (c *client) ListEndpointsForIdentity(serviceIdentity identity.ServiceIdenti
 				log.Error().Msgf("Error parsing IP address %s", podIP.IP)
 				break
 			}
-			ept := endpoint.Endpoint{IP: ip}
+			ept := endpoint.Endpoint{IP: ip, Zone: pod.Labels[corev1.LabelTopologyZone]}
 			endpoints = append(endpoints, ept)
 		}
 	}
@@ -162,6 +188,37 @@ func (c *client) ListServicesForProxy(p *models.Proxy) ([]service.MeshService, e
 	return c.listServicesForPod(pod), nil
 }
 
+// ListSubdomainsForService returns the sorted subdomains of the pods backing the given headless service, derived from the
+// hostnames of the service's endpoints. Nil is returned if the service is not headless or is already specific to a pod.
+func (c *client) ListSubdomainsForService(svc service.MeshService) []string {
+	if svc.Subdomain != "" {
+		return nil
+	}
+
+	k8sSvc := c.kubeController.GetService(svc.Name, svc.Namespace)
+	if k8sSvc == nil || !k8s.IsHeadlessService(*k8sSvc) {
+		return nil
+	}
+
+	endpoints, err := c.kubeController.GetEndpoints(svc.Name, svc.Namespace)
+	if err != nil || endpoints == nil {
+		return nil
+	}
+
+	subdomainSet := mapset.NewSet()
+	var subdomains []string
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if address.Hostname != "" && subdomainSet.Add(address.Hostname) {
+				subdomains = append(subdomains, address.Hostname)
+			}
+		}
+	}
+	sort.Strings(subdomains)
+
+	return subdomains
+}
+
 func (c *client) listServicesForPod(pod *corev1.Pod) []service.MeshService {
 	var meshServices []service.MeshService
 	for _, svc := range c.getServicesByLabels(pod.ObjectMeta.Labels, pod.Namespace) {
@@ -284,29 +341,60 @@ func (c *client) IsMetricsEnabled(proxy *models.Proxy) (bool, error) {
 	return strconv.ParseBool(val)
 }
 
-// GetHostnamesForService returns the hostnames over which the service is accessible
+// GetHostnamesForService returns the hostnames over which the service is accessible.
+// Only the FQDN variants are returned if configured by the MeshConfig, and the port-suffixed
+// variants are omitted if disabled by the MeshConfig.
 func (c *client) GetHostnamesForService(svc service.MeshService, localNamespace bool) []string {
 	var hostnames []string
 
-	if localNamespace {
+	trafficSpec := c.GetMeshConfig().Spec.Traffic
+
+	clusterDomain := trafficSpec.ClusterDomain
+	if clusterDomain == "" {
+		clusterDomain = constants.DefaultClusterDomain
+	}
+
+	if trafficSpec.HostnameVariants == configv1alpha2.HostnameVariantsFQDN {
+		hostnames = []string{
+			fmt.Sprintf("%s.%s.svc.%s", svc.Name, svc.Namespace, clusterDomain),              // service.namespace.svc.cluster.local
+			fmt.Sprintf("%s.%s.svc.%s:%d", svc.Name, svc.Namespace, clusterDomain, svc.Port), // service.namespace.svc.cluster.local:port
+		}
+	} else {
+		if localNamespace {
+			hostnames = append(hostnames, []string{
+				svc.Name,                                 // service
+				fmt.Sprintf("%s:%d", svc.Name, svc.Port), // service:port
+			}...)
+		}
+
 		hostnames = append(hostnames, []string{
-			svc.Name,                                 // service
-			fmt.Sprintf("%s:%d", svc.Name, svc.Port), // service:port
+			fmt.Sprintf("%s.%s", svc.Name, svc.Namespace),                  // service.namespace
+			fmt.Sprintf("%s.%s:%d", svc.Name, svc.Namespace, svc.Port),     // service.namespace:port
+			fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace),              // service.namespace.svc
+			fmt.Sprintf("%s.%s.svc:%d", svc.Name, svc.Namespace, svc.Port), // service.namespace.svc:port
 		}...)
+
+		// Each partial cluster domain is a variant, e.g. service.namespace.svc.cluster[:port] and
+		// service.namespace.svc.cluster.local[:port] for the cluster domain cluster.local
+		hostname := fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace)
+		for _, label := range strings.Split(clusterDomain, ".") {
+			hostname = fmt.Sprintf("%s.%s", hostname, label)
+			hostnames = append(hostnames, hostname, fmt.Sprintf("%s:%d", hostname, svc.Port))
+		}
 	}
 
-	hostnames = append(hostnames, []string{
-		fmt.Sprintf("%s.%s", svc.Name, svc.Namespace),                                // service.namespace
-		fmt.Sprintf("%s.%s:%d", svc.Name, svc.Namespace, svc.Port),                   // service.namespace:port
-		fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace),                            // service.namespace.svc
-		fmt.Sprintf("%s.%s.svc:%d", svc.Name, svc.Namespace, svc.Port),               // service.namespace.svc:port
-		fmt.Sprintf("%s.%s.svc.cluster", svc.Name, svc.Namespace),                    // service.namespace.svc.cluster
-		fmt.Sprintf("%s.%s.svc.cluster:%d", svc.Name, svc.Namespace, svc.Port),       // service.namespace.svc.cluster:port
-		fmt.Sprintf("%s.%s.svc.cluster.local", svc.Name, svc.Namespace),              // service.namespace.svc.cluster.local
-		fmt.Sprintf("%s.%s.svc.cluster.local:%d", svc.Name, svc.Namespace, svc.Port), // service.namespace.svc.cluster.local:port
-	}...)
+	if !trafficSpec.DisablePortSuffixedHostnames {
+		return hostnames
+	}
 
-	return hostnames
+	portSuffix := fmt.Sprintf(":%d", svc.Port)
+	var bareHostnames []string
+	for _, hostname := range hostnames {
+		if !strings.HasSuffix(hostname, portSuffix) {
+			bareHostnames = append(bareHostnames, hostname)
+		}
+	}
+	return bareHostnames
 }
 
 // ListEgressPoliciesForServiceAccount lists the Egress policies for the given source identity based on service accounts
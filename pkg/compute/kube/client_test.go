@@ -140,6 +140,98 @@ var _ = Describe("Test Kube client Provider (w/o kubecontroller)", func() {
 		}))
 	})
 
+	It("should set the zone of the endpoints from the topology label of their pods", func() {
+		mockKubeController.EXPECT().GetEndpoints(meshSvc.Name, meshSvc.Namespace).Return(&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: meshSvc.Namespace,
+			},
+			Subsets: []corev1.EndpointSubset{
+				{
+					Addresses: []corev1.EndpointAddress{
+						{
+							IP:        "1.1.1.1",
+							TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "pod-a", Namespace: meshSvc.Namespace},
+						},
+						{
+							IP:        "2.2.2.2",
+							TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "pod-b", Namespace: meshSvc.Namespace},
+						},
+						{
+							IP:        "3.3.3.3",
+							TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "pod-c", Namespace: meshSvc.Namespace},
+						},
+					},
+					Ports: []corev1.EndpointPort{
+						{
+							Port: int32(meshSvc.TargetPort),
+						},
+					},
+				},
+			},
+		}, nil)
+		mockKubeController.EXPECT().GetMeshConfig().Return(configv1alpha2.MeshConfig{
+			Spec: configv1alpha2.MeshConfigSpec{
+				Traffic: configv1alpha2.TrafficSpec{EnableLocalityAwareLoadBalancing: true},
+			},
+		})
+		mockKubeController.EXPECT().GetPod("pod-a", meshSvc.Namespace).Return(
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-a", Namespace: meshSvc.Namespace, Labels: map[string]string{corev1.LabelTopologyZone: "zone-a"}}})
+		mockKubeController.EXPECT().GetPod("pod-b", meshSvc.Namespace).Return(
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-b", Namespace: meshSvc.Namespace, Labels: map[string]string{corev1.LabelTopologyZone: "zone-b"}}})
+		mockKubeController.EXPECT().GetPod("pod-c", meshSvc.Namespace).Return(
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-c", Namespace: meshSvc.Namespace}})
+
+		Expect(c.ListEndpointsForService(meshSvc)).To(Equal([]endpoint.Endpoint{
+			{
+				IP:   net.IPv4(1, 1, 1, 1),
+				Port: endpoint.Port(meshSvc.TargetPort),
+				Zone: "zone-a",
+			},
+			{
+				IP:   net.IPv4(2, 2, 2, 2),
+				Port: endpoint.Port(meshSvc.TargetPort),
+				Zone: "zone-b",
+			},
+			{
+				IP:   net.IPv4(3, 3, 3, 3),
+				Port: endpoint.Port(meshSvc.TargetPort),
+			},
+		}))
+	})
+
+	It("should not look up the pods of the endpoints when locality aware load balancing is disabled", func() {
+		mockKubeController.EXPECT().GetEndpoints(meshSvc.Name, meshSvc.Namespace).Return(&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: meshSvc.Namespace,
+			},
+			Subsets: []corev1.EndpointSubset{
+				{
+					Addresses: []corev1.EndpointAddress{
+						{
+							IP:        "1.1.1.1",
+							TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "pod-a", Namespace: meshSvc.Namespace},
+						},
+					},
+					Ports: []corev1.EndpointPort{
+						{
+							Port: int32(meshSvc.TargetPort),
+						},
+					},
+				},
+			},
+		}, nil)
+		mockKubeController.EXPECT().GetMeshConfig().Return(configv1alpha2.MeshConfig{})
+		mockKubeController.EXPECT().GetPod(gomock.Any(), gomock.Any()).Times(0)
+		mockKubeController.EXPECT().ListPods().Times(0)
+
+		Expect(c.ListEndpointsForService(meshSvc)).To(Equal([]endpoint.Endpoint{
+			{
+				IP:   net.IPv4(1, 1, 1, 1),
+				Port: endpoint.Port(meshSvc.TargetPort),
+			},
+		}))
+	})
+
 	It("should not filter the endpoints of a MeshService whose TargetPort is not known", func() {
 		svc := service.MeshService{
 			Name:      "test",
@@ -512,6 +604,9 @@ func TestGetHostnamesForServicePort(t *testing.T) {
 		name              string
 		service           service.MeshService
 		localNamespace    bool
+		hostnameVariants  configv1alpha2.HostnameVariantsMode
+		disablePortSuffix bool
+		clusterDomain     string
 		expectedHostnames []string
 	}{
 		{
@@ -546,13 +641,115 @@ func TestGetHostnamesForServicePort(t *testing.T) {
 				"s1.ns1.svc.cluster.local:90",
 			},
 		},
+		{
+			name:             "all hostnames corresponding to a service in the same namespace",
+			service:          service.MeshService{Namespace: "ns1", Name: "s1", Port: 90},
+			localNamespace:   true,
+			hostnameVariants: configv1alpha2.HostnameVariantsAll,
+			expectedHostnames: []string{
+				"s1",
+				"s1:90",
+				"s1.ns1",
+				"s1.ns1:90",
+				"s1.ns1.svc",
+				"s1.ns1.svc:90",
+				"s1.ns1.svc.cluster",
+				"s1.ns1.svc.cluster:90",
+				"s1.ns1.svc.cluster.local",
+				"s1.ns1.svc.cluster.local:90",
+			},
+		},
+		{
+			name:             "FQDN hostnames corresponding to a service in the same namespace",
+			service:          service.MeshService{Namespace: "ns1", Name: "s1", Port: 90},
+			localNamespace:   true,
+			hostnameVariants: configv1alpha2.HostnameVariantsFQDN,
+			expectedHostnames: []string{
+				"s1.ns1.svc.cluster.local",
+				"s1.ns1.svc.cluster.local:90",
+			},
+		},
+		{
+			name:             "FQDN hostnames corresponding to a service in different namespace",
+			service:          service.MeshService{Namespace: "ns1", Name: "s1", Port: 90},
+			localNamespace:   false,
+			hostnameVariants: configv1alpha2.HostnameVariantsFQDN,
+			expectedHostnames: []string{
+				"s1.ns1.svc.cluster.local",
+				"s1.ns1.svc.cluster.local:90",
+			},
+		},
+		{
+			name:              "hostnames without the port suffix corresponding to a service in the same namespace",
+			service:           service.MeshService{Namespace: "ns1", Name: "s1", Port: 90},
+			localNamespace:    true,
+			disablePortSuffix: true,
+			expectedHostnames: []string{
+				"s1",
+				"s1.ns1",
+				"s1.ns1.svc",
+				"s1.ns1.svc.cluster",
+				"s1.ns1.svc.cluster.local",
+			},
+		},
+		{
+			name:              "FQDN hostnames without the port suffix corresponding to a service in different namespace",
+			service:           service.MeshService{Namespace: "ns1", Name: "s1", Port: 90},
+			localNamespace:    false,
+			hostnameVariants:  configv1alpha2.HostnameVariantsFQDN,
+			disablePortSuffix: true,
+			expectedHostnames: []string{
+				"s1.ns1.svc.cluster.local",
+			},
+		},
+		{
+			name:           "hostnames with a custom cluster domain corresponding to a service in the same namespace",
+			service:        service.MeshService{Namespace: "ns1", Name: "s1", Port: 90},
+			localNamespace: true,
+			clusterDomain:  "cluster.internal",
+			expectedHostnames: []string{
+				"s1",
+				"s1:90",
+				"s1.ns1",
+				"s1.ns1:90",
+				"s1.ns1.svc",
+				"s1.ns1.svc:90",
+				"s1.ns1.svc.cluster",
+				"s1.ns1.svc.cluster:90",
+				"s1.ns1.svc.cluster.internal",
+				"s1.ns1.svc.cluster.internal:90",
+			},
+		},
+		{
+			name:             "FQDN hostnames with a custom cluster domain",
+			service:          service.MeshService{Namespace: "ns1", Name: "s1", Port: 90},
+			hostnameVariants: configv1alpha2.HostnameVariantsFQDN,
+			clusterDomain:    "example.com",
+			expectedHostnames: []string{
+				"s1.ns1.svc.example.com",
+				"s1.ns1.svc.example.com:90",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			assert := tassert.New(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
 
-			actual := (&client{}).GetHostnamesForService(tc.service, tc.localNamespace)
+			mockKubeController := k8s.NewMockController(mockCtrl)
+			mockKubeController.EXPECT().GetMeshConfig().Return(configv1alpha2.MeshConfig{
+				Spec: configv1alpha2.MeshConfigSpec{
+					Traffic: configv1alpha2.TrafficSpec{
+						HostnameVariants:             tc.hostnameVariants,
+						DisablePortSuffixedHostnames: tc.disablePortSuffix,
+						ClusterDomain:                tc.clusterDomain,
+					},
+				},
+			}).AnyTimes()
+
+			actual := NewClient(mockKubeController).GetHostnamesForService(tc.service, tc.localNamespace)
 			assert.ElementsMatch(actual, tc.expectedHostnames)
 			assert.Len(actual, len(tc.expectedHostnames))
 		})
@@ -2867,6 +3064,78 @@ func TestGetMeshService(t *testing.T) {
 		})
 	}
 }
+
+func TestListSubdomainsForService(t *testing.T) {
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "ns1"},
+		Subsets: []corev1.EndpointSubset{
+			{
+				Addresses: []corev1.EndpointAddress{
+					{IP: "10.0.0.2", Hostname: "mysql-1"},
+					{IP: "10.0.0.1", Hostname: "mysql-0"},
+					{IP: "10.0.0.3"},
+				},
+				Ports: []corev1.EndpointPort{{Name: "tcp-mysql", Port: 3306}},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name               string
+		clusterIP          string
+		svc                service.MeshService
+		expectedSubdomains []string
+	}{
+		{
+			name:               "headless service",
+			clusterIP:          corev1.ClusterIPNone,
+			svc:                service.MeshService{Name: "mysql", Namespace: "ns1", Port: 3306},
+			expectedSubdomains: []string{"mysql-0", "mysql-1"},
+		},
+		{
+			name:               "service specific to a pod",
+			clusterIP:          corev1.ClusterIPNone,
+			svc:                service.MeshService{Name: "mysql", Namespace: "ns1", Subdomain: "mysql-0", Port: 3306},
+			expectedSubdomains: nil,
+		},
+		{
+			name:               "service with a cluster IP",
+			clusterIP:          "10.10.10.10",
+			svc:                service.MeshService{Name: "mysql", Namespace: "ns1", Port: 3306},
+			expectedSubdomains: nil,
+		},
+		{
+			name:               "service does not exist",
+			clusterIP:          corev1.ClusterIPNone,
+			svc:                service.MeshService{Name: "invalid", Namespace: "ns1", Port: 3306},
+			expectedSubdomains: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := tassert.New(t)
+
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "ns1"},
+				Spec: corev1.ServiceSpec{
+					ClusterIP: tc.clusterIP,
+					Ports:     []corev1.ServicePort{{Name: "tcp-mysql", Port: 3306}},
+				},
+			}
+
+			stop := make(chan struct{})
+			defer close(stop)
+			k8sClient, err := k8s.NewClient("osm", tests.OsmMeshConfigName, messaging.NewBroker(stop),
+				k8s.WithKubeClient(testclient.NewSimpleClientset(svc, endpoints), "test-mesh"),
+			)
+			a.NoError(err)
+			c := NewClient(k8sClient)
+
+			a.Equal(tc.expectedSubdomains, c.ListSubdomainsForService(tc.svc))
+		})
+	}
+}
 func TestGetSecret(t *testing.T) {
 	testCases := []struct {
 		name       string
@@ -571,6 +571,20 @@ func (mr *MockInterfaceMockRecorder) ListServicesForProxy(arg0 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListServicesForProxy", reflect.TypeOf((*MockInterface)(nil).ListServicesForProxy), arg0)
 }
 
+// ListSubdomainsForService mocks base method.
+func (m *MockInterface) ListSubdomainsForService(arg0 service.MeshService) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubdomainsForService", arg0)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// ListSubdomainsForService indicates an expected call of ListSubdomainsForService.
+func (mr *MockInterfaceMockRecorder) ListSubdomainsForService(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubdomainsForService", reflect.TypeOf((*MockInterface)(nil).ListSubdomainsForService), arg0)
+}
+
 // ListTCPTrafficSpecs mocks base method.
 func (m *MockInterface) ListTCPTrafficSpecs() []*v1alpha4.TCPRoute {
 	m.ctrl.T.Helper()
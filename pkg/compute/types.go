@@ -58,6 +58,10 @@ type Interface interface {
 	// ListServicesForProxy gets the services that map to the given proxy.
 	ListServicesForProxy(p *models.Proxy) ([]service.MeshService, error)
 
+	// ListSubdomainsForService returns the subdomains of the pods backing the given headless service, which
+	// form the stable DNS names of the pods.
+	ListSubdomainsForService(svc service.MeshService) []string
+
 	// ListEgressPoliciesForServiceAccount lists the Egress policies for the given source identity based on service accounts
 	ListEgressPoliciesForServiceAccount(sa identity.K8sServiceAccount) []*policyv1alpha1.Egress
 
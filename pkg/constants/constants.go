@@ -58,6 +58,9 @@ const (
 	// DefaultOSMLogLevel is the default OSM log level if none is specified
 	DefaultOSMLogLevel = "info"
 
+	// DefaultClusterDomain is the default DNS domain of the cluster if none is specified in the MeshConfig
+	DefaultClusterDomain = "cluster.local"
+
 	// EnvoyPrometheusInboundListenerPort is Envoy's inbound listener port number for prometheus
 	EnvoyPrometheusInboundListenerPort = 15010
 
@@ -109,6 +112,12 @@ const (
 	// WildcardHTTPMethod is a wildcard for all HTTP methods
 	WildcardHTTPMethod = "*"
 
+	// HTTPMethodOptions is the HTTP method used by CORS preflight requests
+	HTTPMethodOptions = "OPTIONS"
+
+	// WildcardHostname is a wildcard for all hostnames
+	WildcardHostname = "*"
+
 	// OSMKubeResourceMonitorAnnotation is the key of the annotation used to monitor a K8s resource
 	OSMKubeResourceMonitorAnnotation = "openservicemesh.io/monitored-by"
 
@@ -189,6 +198,19 @@ const (
 
 	// MetricsAnnotation is the annotation used for enabling/disabling metrics
 	MetricsAnnotation = "openservicemesh.io/metrics"
+
+	// TrafficSplitBackendRequestHeadersAnnotationPrefix is the prefix of the TrafficSplit annotations used to add
+	// request headers to the requests routed to a backend, of the form <prefix>/<backend>: <name>=<value>,...
+	TrafficSplitBackendRequestHeadersAnnotationPrefix = "request-headers.openservicemesh.io"
+
+	// TrafficSplitBackendMirrorAnnotationPrefix is the prefix of the TrafficSplit annotations used to mirror a percentage
+	// of the requests to the apex service to a backend, of the form <prefix>/<backend>: <percentage>. A mirror backend
+	// is excluded from the weight distribution of the TrafficSplit.
+	TrafficSplitBackendMirrorAnnotationPrefix = "mirror.openservicemesh.io"
+
+	// TrafficTargetRequireRouteHeadersAnnotation is the TrafficTarget annotation used to require the headers of its
+	// HTTPRouteGroup matches in the RBAC policy of the corresponding routes, in addition to the allowed sources
+	TrafficTargetRequireRouteHeadersAnnotation = "openservicemesh.io/require-route-headers"
 )
 
 // Labels used by the control plane
@@ -241,6 +263,10 @@ const (
 	// Ex. MySQL, SMTP, PostgreSQL etc. where the server initiates the first
 	// byte in a TCP connection.
 	ProtocolTCPServerFirst = "tcp-server-first"
+
+	// ProtocolTLSPassthrough implies TLS traffic terminated by the application,
+	// where the encrypted bytes are proxied without mTLS termination by the sidecar.
+	ProtocolTLSPassthrough = "tls-passthrough"
 )
 
 // HTTPProtocolVersion defines the HTTP protocol version to use
@@ -302,5 +328,5 @@ const (
 
 var (
 	// SupportedProtocolsInMesh is a list of the protocols OSM supports for in-mesh traffic
-	SupportedProtocolsInMesh = []string{ProtocolTCPServerFirst, ProtocolHTTP, ProtocolTCP, ProtocolGRPC}
+	SupportedProtocolsInMesh = []string{ProtocolTCPServerFirst, ProtocolHTTP, ProtocolTCP, ProtocolGRPC, ProtocolTLSPassthrough}
 )
@@ -27,14 +27,26 @@ func (g *EnvoyConfigGenerator) generateCDS(ctx context.Context, proxy *models.Pr
 		return nil, err
 	}
 
-	inboundMeshClusterConfigs := g.catalog.GetInboundMeshClusterConfigs(proxyServices)
+	inboundMeshClusterConfigs := g.catalog.GetInboundMeshClusterConfigs(proxy.Identity, proxyServices)
 	cb.SetInboundMeshTrafficClusterConfigs(inboundMeshClusterConfigs)
 
-	if egressClusterConfigs, err := g.catalog.GetEgressClusterConfigs(proxy.Identity); err != nil {
+	egressClusterConfigs, err := g.catalog.GetEgressClusterConfigs(proxy.Identity)
+	if err != nil {
 		log.Error().Err(err).Msgf("Error retrieving egress cluster configs for proxy with identity %s, skipping egress clusters", proxy.Identity)
-	} else {
-		cb.SetEgressTrafficClusterConfigs(egressClusterConfigs)
+		egressClusterConfigs = nil
+	}
+	// External TrafficSplit backends are resolved using the same DNS clusters as egress destinations,
+	// skipping the clusters already generated for an Egress policy to the same host and port
+	egressClusterNames := make(map[string]bool)
+	for _, config := range egressClusterConfigs {
+		egressClusterNames[config.Name] = true
+	}
+	for _, config := range g.catalog.GetOutboundExternalClusterConfigs(proxy.Identity) {
+		if !egressClusterNames[config.Name] {
+			egressClusterConfigs = append(egressClusterConfigs, config)
+		}
 	}
+	cb.SetEgressTrafficClusterConfigs(egressClusterConfigs)
 
 	if enabled, err := g.catalog.IsMetricsEnabled(proxy); err != nil {
 		log.Warn().Str("proxy", proxy.String()).Msg("Could not find pod for connecting proxy, no metadata was recorded")
@@ -177,11 +177,29 @@ func getUpstreamServiceCluster(downstreamIdentity identity.ServiceIdentity, conf
 	upstreamCluster.ClusterDiscoveryType = &xds_cluster.Cluster_Type{Type: xds_cluster.Cluster_EDS}
 	upstreamCluster.EdsClusterConfig = &xds_cluster.Cluster_EdsClusterConfig{EdsConfig: envoy.GetADSConfigSource()}
 	upstreamCluster.LbPolicy = xds_cluster.Cluster_ROUND_ROBIN
+	if config.LoadBalancer != nil {
+		upstreamCluster.LbPolicy = getLbPolicy(config.LoadBalancer.Type)
+	}
+
+	if len(config.LocalityWeights) > 0 {
+		// The weights of the localities are set on the ClusterLoadAssignment by EDS
+		upstreamCluster.CommonLbConfig = &xds_cluster.Cluster_CommonLbConfig{
+			LocalityConfigSpecifier: &xds_cluster.Cluster_CommonLbConfig_LocalityWeightedLbConfig_{
+				LocalityWeightedLbConfig: &xds_cluster.Cluster_CommonLbConfig_LocalityWeightedLbConfig{},
+			},
+		}
+	}
 
-	if config.EnableEnvoyActiveHealthChecks {
+	if config.HealthCheck != nil {
+		upstreamCluster.HealthChecks = []*xds_core.HealthCheck{getHealthCheck(config.HealthCheck, config.Service)}
+	} else if config.EnableEnvoyActiveHealthChecks {
 		enableHealthChecksOnCluster(upstreamCluster, config.Service)
 	}
 
+	if config.OutlierDetection != nil {
+		upstreamCluster.OutlierDetection = getOutlierDetection(config.OutlierDetection)
+	}
+
 	if config.UpstreamTrafficSetting != nil {
 		applyUpstreamConnectionSettings(config.UpstreamTrafficSetting.Spec.ConnectionSettings, upstreamCluster, httpProtocolOptions)
 	} else {
@@ -227,22 +245,111 @@ func enableHealthChecksOnCluster(cluster *xds_cluster.Cluster, upstreamSvc servi
 	}
 }
 
+// getLbPolicy returns the Envoy load balancing policy corresponding to the given load balancing algorithm,
+// defaulting to round robin
+func getLbPolicy(lbPolicyType policyv1alpha1.LBPolicyType) xds_cluster.Cluster_LbPolicy {
+	switch lbPolicyType {
+	case policyv1alpha1.LBPolicyLeastRequest:
+		return xds_cluster.Cluster_LEAST_REQUEST
+	case policyv1alpha1.LBPolicyRingHash:
+		return xds_cluster.Cluster_RING_HASH
+	case policyv1alpha1.LBPolicyMaglev:
+		return xds_cluster.Cluster_MAGLEV
+	default:
+		return xds_cluster.Cluster_ROUND_ROBIN
+	}
+}
+
+// getHealthCheck returns the active health check of the endpoints of the given upstream service. Endpoints of HTTP
+// and gRPC services are health checked with HTTP requests, while endpoints of other services are health checked by
+// establishing a TCP connection.
+func getHealthCheck(healthCheck *policyv1alpha1.HealthCheckSpec, upstreamSvc service.MeshService) *xds_core.HealthCheck {
+	hc := &xds_core.HealthCheck{
+		Timeout:            durationpb.New(1 * time.Second),
+		Interval:           durationpb.New(10 * time.Second),
+		HealthyThreshold:   wrapperspb.UInt32(1),
+		UnhealthyThreshold: wrapperspb.UInt32(3),
+	}
+	if healthCheck.Timeout != nil {
+		hc.Timeout = durationpb.New(healthCheck.Timeout.Duration)
+	}
+	if healthCheck.Interval != nil {
+		hc.Interval = durationpb.New(healthCheck.Interval.Duration)
+	}
+	if healthCheck.HealthyThreshold != nil {
+		hc.HealthyThreshold = wrapperspb.UInt32(*healthCheck.HealthyThreshold)
+	}
+	if healthCheck.UnhealthyThreshold != nil {
+		hc.UnhealthyThreshold = wrapperspb.UInt32(*healthCheck.UnhealthyThreshold)
+	}
+
+	if upstreamSvc.Protocol != constants.ProtocolHTTP && upstreamSvc.Protocol != constants.ProtocolGRPC {
+		hc.HealthChecker = &xds_core.HealthCheck_TcpHealthCheck_{
+			TcpHealthCheck: &xds_core.HealthCheck_TcpHealthCheck{},
+		}
+		return hc
+	}
+
+	path := healthCheck.Path
+	if path == "" {
+		path = "/"
+	}
+	hc.HealthChecker = &xds_core.HealthCheck_HttpHealthCheck_{
+		HttpHealthCheck: &xds_core.HealthCheck_HttpHealthCheck{
+			Host: upstreamSvc.ServerName(),
+			Path: path,
+		},
+	}
+	return hc
+}
+
+// getOutlierDetection returns the outlier detection for the given spec, leaving the unspecified fields to their
+// Envoy defaults
+func getOutlierDetection(outlierDetection *policyv1alpha1.OutlierDetectionSpec) *xds_cluster.OutlierDetection {
+	od := &xds_cluster.OutlierDetection{}
+	if outlierDetection.Consecutive5xx != nil {
+		od.Consecutive_5Xx = wrapperspb.UInt32(*outlierDetection.Consecutive5xx)
+	}
+	if outlierDetection.Interval != nil {
+		od.Interval = durationpb.New(outlierDetection.Interval.Duration)
+	}
+	if outlierDetection.BaseEjectionTime != nil {
+		od.BaseEjectionTime = durationpb.New(outlierDetection.BaseEjectionTime.Duration)
+	}
+	if outlierDetection.MaxEjectionPercent != nil {
+		od.MaxEjectionPercent = wrapperspb.UInt32(*outlierDetection.MaxEjectionPercent)
+	}
+	return od
+}
+
 // getLocalServiceCluster returns an Envoy Cluster corresponding to the local service
 func getLocalServiceCluster(config trafficpolicy.MeshClusterConfig) *xds_cluster.Cluster {
-	typedHTTPProtocolOptions, err := GetTypedHTTPProtocolOptions(GetHTTPProtocolOptions(config.Protocol))
+	protocol := config.Protocol
+	if config.UpstreamProtocol != "" {
+		protocol = config.UpstreamProtocol
+	}
+	typedHTTPProtocolOptions, err := GetTypedHTTPProtocolOptions(GetHTTPProtocolOptions(protocol))
 	if err != nil {
 		log.Error().Err(err).Msgf("Error getting typed HTTP protocol options for local cluster %s", config.Name)
 		return nil
 	}
 
-	return &xds_cluster.Cluster{
+	address := envoy.GetAddress(config.Address, config.Port)
+	discoveryType := xds_cluster.Cluster_STRICT_DNS
+	if strings.HasPrefix(config.Address, envoy.UnixDomainSocketAddressPrefix) {
+		// Unix domain socket addresses are not resolved, and require a static cluster
+		address = envoy.GetPipeAddress(strings.TrimPrefix(config.Address, envoy.UnixDomainSocketAddressPrefix))
+		discoveryType = xds_cluster.Cluster_STATIC
+	}
+
+	localCluster := &xds_cluster.Cluster{
 		// The name must match the domain being cURLed in the demo
 		Name:          config.Name,
 		AltStatName:   config.Name,
 		LbPolicy:      xds_cluster.Cluster_ROUND_ROBIN,
 		RespectDnsTtl: true,
 		ClusterDiscoveryType: &xds_cluster.Cluster_Type{
-			Type: xds_cluster.Cluster_STRICT_DNS,
+			Type: discoveryType,
 		},
 		DnsLookupFamily: xds_cluster.Cluster_V4_ONLY,
 		LoadAssignment: &xds_endpoint.ClusterLoadAssignment{
@@ -257,7 +364,7 @@ func getLocalServiceCluster(config trafficpolicy.MeshClusterConfig) *xds_cluster
 					LbEndpoints: []*xds_endpoint.LbEndpoint{{
 						HostIdentifier: &xds_endpoint.LbEndpoint_Endpoint{
 							Endpoint: &xds_endpoint.Endpoint{
-								Address: envoy.GetAddress(config.Address, config.Port),
+								Address: address,
 							},
 						},
 						LoadBalancingWeight: &wrappers.UInt32Value{
@@ -269,6 +376,43 @@ func getLocalServiceCluster(config trafficpolicy.MeshClusterConfig) *xds_cluster
 		},
 		TypedExtensionProtocolOptions: typedHTTPProtocolOptions,
 	}
+
+	if config.ConnectionSettings != nil {
+		// Apply Circuit Breaker threshold for the traffic accepted by the local service
+		localCluster.CircuitBreakers = &xds_cluster.CircuitBreakers{
+			Thresholds: []*xds_cluster.CircuitBreakers_Thresholds{getCircuitBreakerThreshold(config.ConnectionSettings)},
+		}
+	}
+
+	localCluster.LbSubsetConfig = getLbSubsetConfig(config.SubsetSelectors)
+
+	return localCluster
+}
+
+// getLbSubsetConfig returns the subset load balancer config for the given endpoint subset label selectors, or nil if
+// there are none. Requests not matching any subset are load balanced across all the endpoints.
+func getLbSubsetConfig(selectors []service.LabelSelector) *xds_cluster.Cluster_LbSubsetConfig {
+	keySet := mapset.NewSet()
+	var subsetSelectors []*xds_cluster.Cluster_LbSubsetConfig_LbSubsetSelector
+	for _, selector := range selectors {
+		keys := selector.Keys()
+		if len(keys) == 0 {
+			continue
+		}
+		// Selectors with the same keys select their subsets using the same subset selector
+		if newlyAdded := keySet.Add(strings.Join(keys, ",")); !newlyAdded {
+			continue
+		}
+		subsetSelectors = append(subsetSelectors, &xds_cluster.Cluster_LbSubsetConfig_LbSubsetSelector{Keys: keys})
+	}
+	if len(subsetSelectors) == 0 {
+		return nil
+	}
+
+	return &xds_cluster.Cluster_LbSubsetConfig{
+		FallbackPolicy:  xds_cluster.Cluster_LbSubsetConfig_ANY_ENDPOINT,
+		SubsetSelectors: subsetSelectors,
+	}
 }
 
 // GetHTTPProtocolOptions returns the HttpProtocolOptions for the given protocol.
@@ -517,15 +661,40 @@ func GetDefaultCircuitBreakerThreshold() *xds_cluster.CircuitBreakers_Thresholds
 	}
 }
 
+// getCircuitBreakerThreshold returns the XDS Circuit Breaker thresholds for the given connection settings,
+// with the thresholds not set by the connection settings at their max value
+func getCircuitBreakerThreshold(connectionSettings *policyv1alpha1.ConnectionSettingsSpec) *xds_cluster.CircuitBreakers_Thresholds {
+	threshold := GetDefaultCircuitBreakerThreshold()
+	if connectionSettings == nil {
+		return threshold
+	}
+
+	if connectionSettings.TCP != nil && connectionSettings.TCP.MaxConnections != nil {
+		threshold.MaxConnections = wrapperspb.UInt32(*connectionSettings.TCP.MaxConnections)
+	}
+	if connectionSettings.HTTP != nil {
+		if connectionSettings.HTTP.MaxRequests != nil {
+			threshold.MaxRequests = wrapperspb.UInt32(*connectionSettings.HTTP.MaxRequests)
+		}
+		if connectionSettings.HTTP.MaxPendingRequests != nil {
+			threshold.MaxPendingRequests = wrapperspb.UInt32(*connectionSettings.HTTP.MaxPendingRequests)
+		}
+		if connectionSettings.HTTP.MaxRetries != nil {
+			threshold.MaxRetries = wrapperspb.UInt32(*connectionSettings.HTTP.MaxRetries)
+		}
+	}
+
+	return threshold
+}
+
 // applyUpstreamConnectionSettings updates the given upstream cluster and HTTP protocol options based on the
 // upstream traffic setting provided.
 // It applies the default circuit breaker thresholds to the upstream cluster.
 func applyUpstreamConnectionSettings(upstreamConnectionSettings *policyv1alpha1.ConnectionSettingsSpec, upstreamCluster *xds_cluster.Cluster,
 	httpProtocolOptions *extensions_upstream_http.HttpProtocolOptions) {
 	// Apply Circuit Breaker threshold
-	threshold := GetDefaultCircuitBreakerThreshold()
 	upstreamCluster.CircuitBreakers = &xds_cluster.CircuitBreakers{
-		Thresholds: []*xds_cluster.CircuitBreakers_Thresholds{threshold},
+		Thresholds: []*xds_cluster.CircuitBreakers_Thresholds{getCircuitBreakerThreshold(upstreamConnectionSettings)},
 	}
 
 	if upstreamConnectionSettings == nil {
@@ -537,22 +706,25 @@ func applyUpstreamConnectionSettings(upstreamConnectionSettings *policyv1alpha1.
 		if upstreamConnectionSettings.TCP.ConnectTimeout != nil {
 			upstreamCluster.ConnectTimeout = durationpb.New(upstreamConnectionSettings.TCP.ConnectTimeout.Duration)
 		}
-		if upstreamConnectionSettings.TCP.MaxConnections != nil {
-			threshold.MaxConnections = wrapperspb.UInt32(*upstreamConnectionSettings.TCP.MaxConnections)
+		if keepalive := upstreamConnectionSettings.TCP.TCPKeepalive; keepalive != nil {
+			tcpKeepalive := &xds_core.TcpKeepalive{}
+			if keepalive.Probes != nil {
+				tcpKeepalive.KeepaliveProbes = wrapperspb.UInt32(*keepalive.Probes)
+			}
+			if keepalive.Time != nil {
+				tcpKeepalive.KeepaliveTime = wrapperspb.UInt32(uint32(keepalive.Time.Seconds()))
+			}
+			if keepalive.Interval != nil {
+				tcpKeepalive.KeepaliveInterval = wrapperspb.UInt32(uint32(keepalive.Interval.Seconds()))
+			}
+			upstreamCluster.UpstreamConnectionOptions = &xds_cluster.UpstreamConnectionOptions{
+				TcpKeepalive: tcpKeepalive,
+			}
 		}
 	}
 
 	// Apply HTTP connection settings
 	if upstreamConnectionSettings.HTTP != nil {
-		if upstreamConnectionSettings.HTTP.MaxRequests != nil {
-			threshold.MaxRequests = wrapperspb.UInt32(*upstreamConnectionSettings.HTTP.MaxRequests)
-		}
-		if upstreamConnectionSettings.HTTP.MaxPendingRequests != nil {
-			threshold.MaxPendingRequests = wrapperspb.UInt32(*upstreamConnectionSettings.HTTP.MaxPendingRequests)
-		}
-		if upstreamConnectionSettings.HTTP.MaxRetries != nil {
-			threshold.MaxRetries = wrapperspb.UInt32(*upstreamConnectionSettings.HTTP.MaxRetries)
-		}
 		if upstreamConnectionSettings.HTTP.MaxRequestsPerConnection != nil {
 			// TODO(#4500): When Envoy is upgraded to v1.20+, MaxRequestsPerConnection must be set
 			// via the HttpProtocolOptions extensions field instead (commented below), as setting this
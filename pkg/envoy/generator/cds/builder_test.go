@@ -11,9 +11,11 @@ import (
 	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
 	"github.com/golang/protobuf/ptypes/wrappers"
 	tassert "github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 
 	configv1alpha2 "github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
 	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
@@ -36,9 +38,11 @@ func TestGetUpstreamServiceCluster(t *testing.T) {
 		Port:      14001,
 	}
 	testCases := []struct {
-		name                            string
-		clusterConfig                   trafficpolicy.MeshClusterConfig
-		expectedCircuitBreakerThreshold *xds_cluster.CircuitBreakers
+		name                             string
+		clusterConfig                    trafficpolicy.MeshClusterConfig
+		expectedCircuitBreakerThreshold  *xds_cluster.CircuitBreakers
+		expectedMaxRequestsPerConnection *wrapperspb.UInt32Value
+		expectedConnectionOptions        *xds_cluster.UpstreamConnectionOptions
 	}{
 		{
 			name: "EDS based cluster adds health checks when configured",
@@ -89,6 +93,85 @@ func TestGetUpstreamServiceCluster(t *testing.T) {
 					},
 				},
 			},
+			expectedMaxRequestsPerConnection: wrapperspb.UInt32(thresholdUintVal),
+		},
+		{
+			name: "HTTP/1 cluster with max requests per connection",
+			clusterConfig: trafficpolicy.MeshClusterConfig{
+				Name:    "default/bookstore-v1_14001",
+				Service: service.MeshService{Namespace: "default", Name: "bookstore-v1", Port: 14001, Protocol: constants.ProtocolHTTP},
+				UpstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						ConnectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+							HTTP: &policyv1alpha1.HTTPConnectionSettings{
+								MaxRequestsPerConnection: &thresholdUintVal,
+							},
+						},
+					},
+				},
+			},
+			expectedMaxRequestsPerConnection: wrapperspb.UInt32(thresholdUintVal),
+		},
+		{
+			name: "TCP cluster with TCP keep-alive",
+			clusterConfig: trafficpolicy.MeshClusterConfig{
+				Name:    "default/bookstore-v1_14001",
+				Service: service.MeshService{Namespace: "default", Name: "bookstore-v1", Port: 14001, Protocol: constants.ProtocolTCP},
+				UpstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						ConnectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+							TCP: &policyv1alpha1.TCPConnectionSettings{
+								TCPKeepalive: &policyv1alpha1.TCPKeepaliveSpec{
+									Probes:   &thresholdUintVal,
+									Time:     &metav1.Duration{Duration: 60 * time.Second},
+									Interval: thresholdDuration,
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedConnectionOptions: &xds_cluster.UpstreamConnectionOptions{
+				TcpKeepalive: &xds_core.TcpKeepalive{
+					KeepaliveProbes:   wrapperspb.UInt32(thresholdUintVal),
+					KeepaliveTime:     wrapperspb.UInt32(60),
+					KeepaliveInterval: wrapperspb.UInt32(1),
+				},
+			},
+		},
+		{
+			name: "TCP cluster with partial TCP keep-alive settings",
+			clusterConfig: trafficpolicy.MeshClusterConfig{
+				Name:    "default/bookstore-v1_14001",
+				Service: service.MeshService{Namespace: "default", Name: "bookstore-v1", Port: 14001, Protocol: constants.ProtocolTCP},
+				UpstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+					Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+						ConnectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+							TCP: &policyv1alpha1.TCPConnectionSettings{
+								TCPKeepalive: &policyv1alpha1.TCPKeepaliveSpec{
+									Time: &metav1.Duration{Duration: 90 * time.Second},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedConnectionOptions: &xds_cluster.UpstreamConnectionOptions{
+				TcpKeepalive: &xds_core.TcpKeepalive{
+					KeepaliveTime: wrapperspb.UInt32(90),
+				},
+			},
+		},
+		{
+			name: "EDS based cluster uses locality weighted load balancing when locality weights are configured",
+			clusterConfig: trafficpolicy.MeshClusterConfig{
+				Name:    "default/bookstore-v1_14001",
+				Service: upstreamSvc,
+				LocalityWeights: []trafficpolicy.LocalityWeight{
+					{Zone: "zone-a", Weight: 2},
+					{Zone: "zone-b", Weight: 1, Priority: 1},
+				},
+			},
 		},
 		{
 			name: "Cluster without circuit breaker but with valid UpstreamTrafficSetting should not error/panic",
@@ -118,6 +201,218 @@ func TestGetUpstreamServiceCluster(t *testing.T) {
 			if tc.expectedCircuitBreakerThreshold != nil {
 				assert.Equal(tc.expectedCircuitBreakerThreshold, remoteCluster.CircuitBreakers)
 			}
+
+			// MaxRequestsPerConnection is left unset unless configured, to preserve the Envoy default
+			assert.Equal(tc.expectedMaxRequestsPerConnection, remoteCluster.MaxRequestsPerConnection)
+
+			// TCP keep-alive is disabled unless configured
+			assert.Equal(tc.expectedConnectionOptions, remoteCluster.UpstreamConnectionOptions)
+
+			// Locality weighted load balancing is only enabled with locality weights
+			if len(tc.clusterConfig.LocalityWeights) > 0 {
+				assert.NotNil(remoteCluster.GetCommonLbConfig().GetLocalityWeightedLbConfig())
+			} else {
+				assert.Nil(remoteCluster.CommonLbConfig)
+			}
+		})
+	}
+}
+
+func TestGetUpstreamServiceClusterWithHealthCheck(t *testing.T) {
+	httpSvc := service.MeshService{Namespace: "default", Name: "bookstore-v1", Port: 14001, Protocol: "http"}
+	tcpSvc := service.MeshService{Namespace: "default", Name: "mysql", Port: 3306, Protocol: "tcp"}
+
+	testCases := []struct {
+		name                string
+		clusterConfig       trafficpolicy.MeshClusterConfig
+		expectedHealthCheck *xds_core.HealthCheck
+	}{
+		{
+			name: "HTTP health check",
+			clusterConfig: trafficpolicy.MeshClusterConfig{
+				Name:    "default/bookstore-v1_14001",
+				Service: httpSvc,
+				HealthCheck: &policyv1alpha1.HealthCheckSpec{
+					Path:               "/healthz",
+					Interval:           &metav1.Duration{Duration: 5 * time.Second},
+					UnhealthyThreshold: pointer.Uint32(5),
+				},
+				// The health check of the UpstreamTrafficSetting takes precedence
+				EnableEnvoyActiveHealthChecks: true,
+			},
+			expectedHealthCheck: &xds_core.HealthCheck{
+				Timeout:            durationpb.New(1 * time.Second),
+				Interval:           durationpb.New(5 * time.Second),
+				HealthyThreshold:   wrapperspb.UInt32(1),
+				UnhealthyThreshold: wrapperspb.UInt32(5),
+				HealthChecker: &xds_core.HealthCheck_HttpHealthCheck_{
+					HttpHealthCheck: &xds_core.HealthCheck_HttpHealthCheck{
+						Host: httpSvc.ServerName(),
+						Path: "/healthz",
+					},
+				},
+			},
+		},
+		{
+			name: "TCP health check",
+			clusterConfig: trafficpolicy.MeshClusterConfig{
+				Name:    "default/mysql_3306",
+				Service: tcpSvc,
+				HealthCheck: &policyv1alpha1.HealthCheckSpec{
+					Path:    "/ignored",
+					Timeout: &metav1.Duration{Duration: 2 * time.Second},
+				},
+			},
+			expectedHealthCheck: &xds_core.HealthCheck{
+				Timeout:            durationpb.New(2 * time.Second),
+				Interval:           durationpb.New(10 * time.Second),
+				HealthyThreshold:   wrapperspb.UInt32(1),
+				UnhealthyThreshold: wrapperspb.UInt32(3),
+				HealthChecker: &xds_core.HealthCheck_TcpHealthCheck_{
+					TcpHealthCheck: &xds_core.HealthCheck_TcpHealthCheck{},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			remoteCluster := getUpstreamServiceCluster(tests.BookbuyerServiceIdentity, tc.clusterConfig, configv1alpha2.SidecarSpec{})
+			assert.NotNil(remoteCluster)
+			assert.Len(remoteCluster.HealthChecks, 1)
+			assert.True(proto.Equal(tc.expectedHealthCheck, remoteCluster.HealthChecks[0]))
+		})
+	}
+}
+
+func TestGetUpstreamServiceClusterWithOutlierDetection(t *testing.T) {
+	assert := tassert.New(t)
+
+	clusterConfig := trafficpolicy.MeshClusterConfig{
+		Name:    "default/bookstore-v1_14001",
+		Service: service.MeshService{Namespace: "default", Name: "bookstore-v1", Port: 14001, Protocol: "http"},
+	}
+
+	// Outlier detection is disabled unless configured
+	remoteCluster := getUpstreamServiceCluster(tests.BookbuyerServiceIdentity, clusterConfig, configv1alpha2.SidecarSpec{})
+	assert.NotNil(remoteCluster)
+	assert.Nil(remoteCluster.OutlierDetection)
+
+	clusterConfig.OutlierDetection = &policyv1alpha1.OutlierDetectionSpec{
+		Consecutive5xx:   pointer.Uint32(3),
+		BaseEjectionTime: &metav1.Duration{Duration: time.Minute},
+	}
+	remoteCluster = getUpstreamServiceCluster(tests.BookbuyerServiceIdentity, clusterConfig, configv1alpha2.SidecarSpec{})
+	assert.NotNil(remoteCluster)
+	assert.True(proto.Equal(&xds_cluster.OutlierDetection{
+		Consecutive_5Xx:  wrapperspb.UInt32(3),
+		BaseEjectionTime: durationpb.New(time.Minute),
+	}, remoteCluster.OutlierDetection))
+}
+
+func TestGetUpstreamServiceClusterWithLBPolicy(t *testing.T) {
+	testCases := []struct {
+		name             string
+		loadBalancer     *policyv1alpha1.LBPolicySpec
+		expectedLbPolicy xds_cluster.Cluster_LbPolicy
+	}{
+		{
+			name:             "default",
+			loadBalancer:     nil,
+			expectedLbPolicy: xds_cluster.Cluster_ROUND_ROBIN,
+		},
+		{
+			name:             "round robin",
+			loadBalancer:     &policyv1alpha1.LBPolicySpec{Type: policyv1alpha1.LBPolicyRoundRobin},
+			expectedLbPolicy: xds_cluster.Cluster_ROUND_ROBIN,
+		},
+		{
+			name:             "least request",
+			loadBalancer:     &policyv1alpha1.LBPolicySpec{Type: policyv1alpha1.LBPolicyLeastRequest},
+			expectedLbPolicy: xds_cluster.Cluster_LEAST_REQUEST,
+		},
+		{
+			name:             "ring hash with a hash key header",
+			loadBalancer:     &policyv1alpha1.LBPolicySpec{Type: policyv1alpha1.LBPolicyRingHash, HashKeyHeader: "x-user-id"},
+			expectedLbPolicy: xds_cluster.Cluster_RING_HASH,
+		},
+		{
+			name:             "maglev",
+			loadBalancer:     &policyv1alpha1.LBPolicySpec{Type: policyv1alpha1.LBPolicyMaglev},
+			expectedLbPolicy: xds_cluster.Cluster_MAGLEV,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			clusterConfig := trafficpolicy.MeshClusterConfig{
+				Name:         "default/bookstore-v1_14001",
+				Service:      service.MeshService{Namespace: "default", Name: "bookstore-v1", Port: 14001, Protocol: "http"},
+				LoadBalancer: tc.loadBalancer,
+			}
+			remoteCluster := getUpstreamServiceCluster(tests.BookbuyerServiceIdentity, clusterConfig, configv1alpha2.SidecarSpec{})
+			assert.NotNil(remoteCluster)
+			assert.Equal(tc.expectedLbPolicy, remoteCluster.LbPolicy)
+		})
+	}
+}
+
+func TestApplyUpstreamConnectionSettingsThresholds(t *testing.T) {
+	testCases := []struct {
+		name               string
+		connectionSettings *policyv1alpha1.ConnectionSettingsSpec
+		expectedThreshold  func(*xds_cluster.CircuitBreakers_Thresholds)
+	}{
+		{
+			name:               "no connection settings",
+			connectionSettings: nil,
+			expectedThreshold:  func(*xds_cluster.CircuitBreakers_Thresholds) {},
+		},
+		{
+			name: "max connections",
+			connectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+				TCP: &policyv1alpha1.TCPConnectionSettings{MaxConnections: pointer.Uint32(1)},
+			},
+			expectedThreshold: func(th *xds_cluster.CircuitBreakers_Thresholds) { th.MaxConnections = wrapperspb.UInt32(1) },
+		},
+		{
+			name: "max pending requests",
+			connectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+				HTTP: &policyv1alpha1.HTTPConnectionSettings{MaxPendingRequests: pointer.Uint32(2)},
+			},
+			expectedThreshold: func(th *xds_cluster.CircuitBreakers_Thresholds) { th.MaxPendingRequests = wrapperspb.UInt32(2) },
+		},
+		{
+			name: "max requests",
+			connectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+				HTTP: &policyv1alpha1.HTTPConnectionSettings{MaxRequests: pointer.Uint32(3)},
+			},
+			expectedThreshold: func(th *xds_cluster.CircuitBreakers_Thresholds) { th.MaxRequests = wrapperspb.UInt32(3) },
+		},
+		{
+			name: "max retries",
+			connectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+				HTTP: &policyv1alpha1.HTTPConnectionSettings{MaxRetries: pointer.Uint32(4)},
+			},
+			expectedThreshold: func(th *xds_cluster.CircuitBreakers_Thresholds) { th.MaxRetries = wrapperspb.UInt32(4) },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			cluster := &xds_cluster.Cluster{}
+			applyUpstreamConnectionSettings(tc.connectionSettings, cluster, GetHTTPProtocolOptions(""))
+
+			// Thresholds that are not configured retain their defaults
+			expected := GetDefaultCircuitBreakerThreshold()
+			tc.expectedThreshold(expected)
+			assert.Equal(&xds_cluster.CircuitBreakers{Thresholds: []*xds_cluster.CircuitBreakers_Thresholds{expected}}, cluster.CircuitBreakers)
 		})
 	}
 }
@@ -130,6 +425,8 @@ func TestGetLocalServiceCluster(t *testing.T) {
 		expectedLbPolicy                 xds_cluster.Cluster_LbPolicy
 		expectedProtocolSelection        xds_cluster.Cluster_ClusterProtocolSelection
 		expectedPortToProtocolMappingErr bool
+		expectedCircuitBreakers          *xds_cluster.CircuitBreakers
+		expectedDiscoveryType            xds_cluster.Cluster_DiscoveryType
 		expectedErr                      bool
 	}{
 		{
@@ -157,7 +454,79 @@ func TestGetLocalServiceCluster(t *testing.T) {
 					}},
 				},
 			},
-			expectedErr: false,
+			expectedDiscoveryType: xds_cluster.Cluster_STRICT_DNS,
+			expectedErr:           false,
+		},
+		{
+			name: "Local service cluster with a unix domain socket address",
+			clusterConfig: trafficpolicy.MeshClusterConfig{
+				Name:    "ns/foo|90|local",
+				Service: service.MeshService{Namespace: "ns", Name: "foo"},
+				Port:    90,
+				Address: "unix:///var/run/app.sock",
+			},
+			expectedLocalityLbEndpoints: []*xds_endpoint.LocalityLbEndpoints{
+				{
+					Locality: &xds_core.Locality{
+						Zone: "zone",
+					},
+					LbEndpoints: []*xds_endpoint.LbEndpoint{{
+						HostIdentifier: &xds_endpoint.LbEndpoint_Endpoint{
+							Endpoint: &xds_endpoint.Endpoint{
+								Address: envoy.GetPipeAddress("/var/run/app.sock"),
+							},
+						},
+						LoadBalancingWeight: &wrappers.UInt32Value{
+							Value: constants.ClusterWeightAcceptAll, // Local cluster accepts all traffic
+						},
+					}},
+				},
+			},
+			expectedDiscoveryType: xds_cluster.Cluster_STATIC,
+			expectedErr:           false,
+		},
+		{
+			name: "Local service cluster with connection settings",
+			clusterConfig: trafficpolicy.MeshClusterConfig{
+				Name:    "ns/foo|90|local",
+				Service: service.MeshService{Namespace: "ns", Name: "foo"},
+				Port:    90,
+				Address: "127.0.0.1",
+				ConnectionSettings: &policyv1alpha1.ConnectionSettingsSpec{
+					TCP:  &policyv1alpha1.TCPConnectionSettings{MaxConnections: pointer.Uint32(10)},
+					HTTP: &policyv1alpha1.HTTPConnectionSettings{MaxPendingRequests: pointer.Uint32(5)},
+				},
+			},
+			expectedLocalityLbEndpoints: []*xds_endpoint.LocalityLbEndpoints{
+				{
+					Locality: &xds_core.Locality{
+						Zone: "zone",
+					},
+					LbEndpoints: []*xds_endpoint.LbEndpoint{{
+						HostIdentifier: &xds_endpoint.LbEndpoint_Endpoint{
+							Endpoint: &xds_endpoint.Endpoint{
+								Address: envoy.GetAddress("127.0.0.1", uint32(90)),
+							},
+						},
+						LoadBalancingWeight: &wrappers.UInt32Value{
+							Value: constants.ClusterWeightAcceptAll, // Local cluster accepts all traffic
+						},
+					}},
+				},
+			},
+			expectedCircuitBreakers: &xds_cluster.CircuitBreakers{
+				Thresholds: []*xds_cluster.CircuitBreakers_Thresholds{
+					{
+						MaxConnections:     wrapperspb.UInt32(10),
+						MaxRequests:        wrapperspb.UInt32(math.MaxUint32),
+						MaxPendingRequests: wrapperspb.UInt32(5),
+						MaxRetries:         wrapperspb.UInt32(math.MaxUint32),
+						TrackRemaining:     true,
+					},
+				},
+			},
+			expectedDiscoveryType: xds_cluster.Cluster_STRICT_DNS,
+			expectedErr:           false,
 		},
 	}
 
@@ -173,16 +542,95 @@ func TestGetLocalServiceCluster(t *testing.T) {
 				assert.Equal(tc.clusterConfig.Name, cluster.Name)
 				assert.Equal(tc.clusterConfig.Name, cluster.AltStatName)
 				assert.Equal(xds_cluster.Cluster_ROUND_ROBIN, cluster.LbPolicy)
-				assert.Equal(&xds_cluster.Cluster_Type{Type: xds_cluster.Cluster_STRICT_DNS}, cluster.ClusterDiscoveryType)
+				assert.Equal(&xds_cluster.Cluster_Type{Type: tc.expectedDiscoveryType}, cluster.ClusterDiscoveryType)
 				assert.Equal(true, cluster.RespectDnsTtl)
 				assert.Equal(xds_cluster.Cluster_V4_ONLY, cluster.DnsLookupFamily)
 				assert.Equal(len(tc.expectedLocalityLbEndpoints), len(cluster.LoadAssignment.Endpoints))
 				assert.ElementsMatch(tc.expectedLocalityLbEndpoints, cluster.LoadAssignment.Endpoints)
+				assert.Equal(tc.expectedCircuitBreakers, cluster.CircuitBreakers)
 			}
 		})
 	}
 }
 
+func TestGetLocalServiceClusterUpstreamProtocol(t *testing.T) {
+	testCases := []struct {
+		name             string
+		protocol         string
+		upstreamProtocol string
+		expectedProtocol string
+	}{
+		{
+			name:             "downstream protocol by default",
+			expectedProtocol: "",
+		},
+		{
+			name:             "h2c upstream protocol",
+			upstreamProtocol: constants.ProtocolH2C,
+			expectedProtocol: constants.ProtocolH2C,
+		},
+		{
+			name:             "upstream protocol takes precedence over protocol",
+			protocol:         constants.ProtocolHTTP1,
+			upstreamProtocol: constants.ProtocolHTTP2,
+			expectedProtocol: constants.ProtocolHTTP2,
+		},
+		{
+			name:             "protocol used without upstream protocol",
+			protocol:         constants.ProtocolHTTP1,
+			expectedProtocol: constants.ProtocolHTTP1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			expected, err := GetTypedHTTPProtocolOptions(GetHTTPProtocolOptions(tc.expectedProtocol))
+			assert.Nil(err)
+
+			actual := getLocalServiceCluster(trafficpolicy.MeshClusterConfig{
+				Name:             "ns/foo|90|local",
+				Service:          service.MeshService{Namespace: "ns", Name: "foo"},
+				Port:             90,
+				Address:          "127.0.0.1",
+				Protocol:         tc.protocol,
+				UpstreamProtocol: tc.upstreamProtocol,
+			})
+			assert.Equal(expected, actual.TypedExtensionProtocolOptions)
+		})
+	}
+}
+
+func TestGetLocalServiceClusterWithSubsetSelectors(t *testing.T) {
+	assert := tassert.New(t)
+
+	config := trafficpolicy.MeshClusterConfig{
+		Name:    "ns/foo|90|local",
+		Service: service.MeshService{Namespace: "ns", Name: "foo"},
+		Port:    90,
+		Address: "127.0.0.1",
+		SubsetSelectors: []service.LabelSelector{
+			service.NewLabelSelector(map[string]string{"version": "v1"}),
+			service.NewLabelSelector(map[string]string{"version": "v2"}),
+			service.NewLabelSelector(map[string]string{"version": "v2", "track": "canary"}),
+		},
+	}
+
+	// Selectors with the same label keys share a subset selector
+	actual := getLocalServiceCluster(config)
+	assert.Equal(&xds_cluster.Cluster_LbSubsetConfig{
+		FallbackPolicy: xds_cluster.Cluster_LbSubsetConfig_ANY_ENDPOINT,
+		SubsetSelectors: []*xds_cluster.Cluster_LbSubsetConfig_LbSubsetSelector{
+			{Keys: []string{"version"}},
+			{Keys: []string{"track", "version"}},
+		},
+	}, actual.LbSubsetConfig)
+
+	config.SubsetSelectors = nil
+	assert.Nil(getLocalServiceCluster(config).LbSubsetConfig)
+}
+
 func TestGetPrometheusCluster(t *testing.T) {
 	assert := tassert.New(t)
 
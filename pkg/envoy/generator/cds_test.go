@@ -85,7 +85,7 @@ func TestGenerateCDS(t *testing.T) {
 					Namespace: "foo",
 					Name:      "bar",
 				}},
-		})
+		}).AnyTimes()
 	mockComputeInterface.EXPECT().ListTrafficTargets().Return([]*access.TrafficTarget{&tests.TrafficTarget, &tests.BookstoreV2TrafficTarget}).AnyTimes()
 	mockComputeInterface.EXPECT().GetServicesForServiceIdentity(gomock.Any()).Return([]service.MeshService{
 		tests.BookstoreV1Service, tests.BookstoreV2Service,
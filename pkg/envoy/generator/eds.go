@@ -5,6 +5,7 @@ import (
 
 	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
 
+	"github.com/openservicemesh/osm/pkg/catalog"
 	"github.com/openservicemesh/osm/pkg/endpoint"
 	"github.com/openservicemesh/osm/pkg/envoy/generator/eds"
 	"github.com/openservicemesh/osm/pkg/models"
@@ -16,11 +17,22 @@ func (g *EnvoyConfigGenerator) generateEDS(ctx context.Context, proxy *models.Pr
 	meshSvcEndpoints := make(map[service.MeshService][]endpoint.Endpoint)
 	builder := eds.NewEndpointsBuilder()
 
+	// The locality weights must match the locality weighted load balancing configured on the clusters by CDS,
+	// they are computed from the same endpoints as the endpoints programmed for the clusters
+	localityAware := g.catalog.GetMeshConfig().Spec.Traffic.EnableLocalityAwareLoadBalancing
+	var downstreamZone string
+	if localityAware {
+		downstreamZone = g.catalog.GetIdentityZone(proxy.Identity)
+	}
+
 	for _, dstSvc := range g.catalog.ListOutboundServicesForIdentity(proxy.Identity) {
-		builder.AddEndpoints(
-			dstSvc,
-			g.catalog.ListAllowedUpstreamEndpointsForService(proxy.Identity, dstSvc),
-		)
+		endpoints := g.catalog.ListAllowedUpstreamEndpointsForService(proxy.Identity, dstSvc)
+		builder.AddEndpoints(dstSvc, endpoints)
+		if localityAware {
+			if localityWeights := catalog.GetLocalityWeights(endpoints, downstreamZone); len(localityWeights) > 0 {
+				builder.AddLocalityWeights(dstSvc, localityWeights)
+			}
+		}
 
 		log.Trace().Msgf("Allowed outbound service endpoints for proxy with identity %s: %v", proxy.Identity, meshSvcEndpoints)
 	}
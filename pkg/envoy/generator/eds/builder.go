@@ -10,6 +10,7 @@ import (
 	"github.com/openservicemesh/osm/pkg/endpoint"
 	"github.com/openservicemesh/osm/pkg/envoy"
 	"github.com/openservicemesh/osm/pkg/service"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
 )
 
 const (
@@ -20,13 +21,15 @@ const (
 
 // EndpointsBuilder is a helper struct to build Envoy endpoint resources
 type EndpointsBuilder struct {
-	upstreamSvcEndpoints map[service.MeshService][]endpoint.Endpoint
+	upstreamSvcEndpoints       map[service.MeshService][]endpoint.Endpoint
+	upstreamSvcLocalityWeights map[service.MeshService][]trafficpolicy.LocalityWeight
 }
 
 // NewEndpointsBuilder creates a new EndpointsBuilder
 func NewEndpointsBuilder() *EndpointsBuilder {
 	return &EndpointsBuilder{
-		upstreamSvcEndpoints: make(map[service.MeshService][]endpoint.Endpoint),
+		upstreamSvcEndpoints:       make(map[service.MeshService][]endpoint.Endpoint),
+		upstreamSvcLocalityWeights: make(map[service.MeshService][]trafficpolicy.LocalityWeight),
 	}
 }
 
@@ -35,18 +38,26 @@ func (b *EndpointsBuilder) AddEndpoints(svc service.MeshService, endpoints []end
 	b.upstreamSvcEndpoints[svc] = endpoints
 }
 
+// AddLocalityWeights adds the given locality weights to the EndpointsBuilder for the provided service.
+// The local endpoints of the service are grouped per zone using the weight and priority of their zone.
+func (b *EndpointsBuilder) AddLocalityWeights(svc service.MeshService, localityWeights []trafficpolicy.LocalityWeight) {
+	b.upstreamSvcLocalityWeights[svc] = localityWeights
+}
+
 // Build generate Envoy endpoint resources based on stored endpoints
 func (b *EndpointsBuilder) Build() []types.Resource {
 	var edsResources []types.Resource
 
 	for svc, endpoints := range b.upstreamSvcEndpoints {
-		edsResources = append(edsResources, newClusterLoadAssignment(svc, endpoints))
+		edsResources = append(edsResources, newClusterLoadAssignment(svc, endpoints, b.upstreamSvcLocalityWeights[svc]))
 	}
 	return edsResources
 }
 
-// newClusterLoadAssignment returns the cluster load assignments for the given service and its endpoints
-func newClusterLoadAssignment(svc service.MeshService, serviceEndpoints []endpoint.Endpoint) *xds_endpoint.ClusterLoadAssignment {
+// newClusterLoadAssignment returns the cluster load assignments for the given service and its endpoints.
+// If locality weights are given, the local endpoints are grouped per zone with the weight and priority of their zone.
+func newClusterLoadAssignment(svc service.MeshService, serviceEndpoints []endpoint.Endpoint,
+	localityWeights []trafficpolicy.LocalityWeight) *xds_endpoint.ClusterLoadAssignment {
 	localLbEndpoints := &xds_endpoint.LocalityLbEndpoints{
 		Locality: &xds_core.Locality{
 			Zone: localZone,
@@ -60,6 +71,24 @@ func newClusterLoadAssignment(svc service.MeshService, serviceEndpoints []endpoi
 		Endpoints:   []*xds_endpoint.LocalityLbEndpoints{localLbEndpoints},
 	}
 
+	zoneLbEndpoints := make(map[string]*xds_endpoint.LocalityLbEndpoints)
+	if len(localityWeights) > 0 {
+		cla.Endpoints = nil
+		for _, localityWeight := range localityWeights {
+			zoneLbEndpoints[localityWeight.Zone] = &xds_endpoint.LocalityLbEndpoints{
+				Locality: &xds_core.Locality{
+					Zone: localityWeight.Zone,
+				},
+				LbEndpoints: []*xds_endpoint.LbEndpoint{},
+				Priority:    localityWeight.Priority,
+				LoadBalancingWeight: &wrappers.UInt32Value{
+					Value: localityWeight.Weight,
+				},
+			}
+			cla.Endpoints = append(cla.Endpoints, zoneLbEndpoints[localityWeight.Zone])
+		}
+	}
+
 	// If there are no service endpoints corresponding to this service, we
 	// return a ClusterLoadAssignment without any endpoints.
 	// Envoy will correctly handle this response.
@@ -80,6 +109,17 @@ func newClusterLoadAssignment(svc service.MeshService, serviceEndpoints []endpoi
 
 		// Endpoint without a weight set implies it belongs to the local cluster
 		if meshEndpoint.Weight == 0 {
+			if len(zoneLbEndpoints) > 0 {
+				lbEndpoints, ok := zoneLbEndpoints[meshEndpoint.Zone]
+				if !ok {
+					// A locality without a weight does not receive traffic with locality weighted load balancing
+					log.Warn().Msgf("Skipping local endpoint without a locality weight for its zone: cluster=%s, endpoint=%s, zone=%s", svc, meshEndpoint, meshEndpoint.Zone)
+					continue
+				}
+				lbEndpoints.LbEndpoints = append(lbEndpoints.LbEndpoints, lbEpt)
+				log.Trace().Msgf("Adding local endpoint: cluster=%s, endpoint=%s, zone=%s", svc, meshEndpoint, meshEndpoint.Zone)
+				continue
+			}
 			localLbEndpoints.LbEndpoints = append(localLbEndpoints.LbEndpoints, lbEpt)
 			log.Trace().Msgf("Adding local endpoint: cluster=%s, endpoint=%s", svc, meshEndpoint)
 			continue
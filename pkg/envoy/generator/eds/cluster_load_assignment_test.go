@@ -6,6 +6,7 @@ import (
 
 	xds_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	xds_endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/google/go-cmp/cmp"
 	tassert "github.com/stretchr/testify/assert"
 	"google.golang.org/protobuf/testing/protocmp"
@@ -13,14 +14,16 @@ import (
 	"github.com/openservicemesh/osm/pkg/endpoint"
 	"github.com/openservicemesh/osm/pkg/envoy"
 	"github.com/openservicemesh/osm/pkg/service"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
 )
 
 func TestNewClusterLoadAssignment(t *testing.T) {
 	testCases := []struct {
-		name      string
-		svc       service.MeshService
-		endpoints []endpoint.Endpoint
-		expected  *xds_endpoint.ClusterLoadAssignment
+		name            string
+		svc             service.MeshService
+		endpoints       []endpoint.Endpoint
+		localityWeights []trafficpolicy.LocalityWeight
+		expected        *xds_endpoint.ClusterLoadAssignment
 	}{
 		{
 			name: "multiple endpoints per cluster within the same locality",
@@ -56,6 +59,63 @@ func TestNewClusterLoadAssignment(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "endpoints grouped per zone with locality weights",
+			svc:  service.MeshService{Namespace: "ns1", Name: "bookstore-1", TargetPort: 80},
+			endpoints: []endpoint.Endpoint{
+				{IP: net.ParseIP("1.1.1.1"), Port: 80, Zone: "zone-a"},
+				{IP: net.ParseIP("2.2.2.2"), Port: 80, Zone: "zone-b"},
+				{IP: net.ParseIP("3.3.3.3"), Port: 80, Zone: "zone-a"},
+			},
+			localityWeights: []trafficpolicy.LocalityWeight{
+				{Zone: "zone-a", Weight: 2, Priority: 0},
+				{Zone: "zone-b", Weight: 1, Priority: 1},
+			},
+			expected: &xds_endpoint.ClusterLoadAssignment{
+				ClusterName: "ns1/bookstore-1|80",
+				Endpoints: []*xds_endpoint.LocalityLbEndpoints{
+					{
+						Locality: &xds_core.Locality{
+							Zone: "zone-a",
+						},
+						LbEndpoints: []*xds_endpoint.LbEndpoint{
+							{
+								HostIdentifier: &xds_endpoint.LbEndpoint_Endpoint{
+									Endpoint: &xds_endpoint.Endpoint{
+										Address: envoy.GetAddress("1.1.1.1", 80),
+									},
+								},
+							},
+							{
+								HostIdentifier: &xds_endpoint.LbEndpoint_Endpoint{
+									Endpoint: &xds_endpoint.Endpoint{
+										Address: envoy.GetAddress("3.3.3.3", 80),
+									},
+								},
+							},
+						},
+						Priority:            0,
+						LoadBalancingWeight: &wrappers.UInt32Value{Value: 2},
+					},
+					{
+						Locality: &xds_core.Locality{
+							Zone: "zone-b",
+						},
+						LbEndpoints: []*xds_endpoint.LbEndpoint{
+							{
+								HostIdentifier: &xds_endpoint.LbEndpoint_Endpoint{
+									Endpoint: &xds_endpoint.Endpoint{
+										Address: envoy.GetAddress("2.2.2.2", 80),
+									},
+								},
+							},
+						},
+						Priority:            1,
+						LoadBalancingWeight: &wrappers.UInt32Value{Value: 1},
+					},
+				},
+			},
+		},
 		{
 			name:      "no endpoints for cluster",
 			svc:       service.MeshService{Namespace: "ns1", Name: "bookstore-1", TargetPort: 80},
@@ -77,7 +137,7 @@ func TestNewClusterLoadAssignment(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			assert := tassert.New(t)
-			actual := newClusterLoadAssignment(tc.svc, tc.endpoints)
+			actual := newClusterLoadAssignment(tc.svc, tc.endpoints, tc.localityWeights)
 			assert.True(cmp.Equal(tc.expected, actual, protocmp.Transform()), cmp.Diff(tc.expected, actual, protocmp.Transform()))
 		})
 	}
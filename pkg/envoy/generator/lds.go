@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	xds_accesslog "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
 	xds_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/openservicemesh/osm/pkg/envoy/generator/lds"
 	"github.com/openservicemesh/osm/pkg/errcode"
 	"github.com/openservicemesh/osm/pkg/models"
+	"github.com/openservicemesh/osm/pkg/trafficpolicy"
 	"github.com/openservicemesh/osm/pkg/utils"
 )
 
@@ -39,7 +41,8 @@ func (g *EnvoyConfigGenerator) generateLDS(ctx context.Context, proxy *models.Pr
 		}
 	}
 
-	accessLogs, err := lds.BuildAccessLogs(proxy.String(), g.catalog.GetTelemetryConfig(proxy))
+	telemetryConfig := g.catalog.GetTelemetryConfig(proxy)
+	accessLogs, err := lds.BuildAccessLogs(proxy.String(), telemetryConfig)
 	if err != nil {
 		log.Error().Err(err).Msgf("Error building access log config for proxy %s", proxy)
 		return nil, err
@@ -85,6 +88,7 @@ func (g *EnvoyConfigGenerator) generateLDS(ctx context.Context, proxy *models.Pr
 	}
 
 	// --- INBOUND -------------------
+	inboundMeshTrafficMatches := g.catalog.GetInboundMeshTrafficMatches(proxy.Identity, svcList)
 	inboundLis := lds.ListenerBuilder().
 		Name(lds.InboundListenerName).
 		ProxyIdentity(proxy.Identity).
@@ -93,10 +97,11 @@ func (g *EnvoyConfigGenerator) generateLDS(ctx context.Context, proxy *models.Pr
 		TrafficDirection(xds_core.TrafficDirection_INBOUND).
 		DefaultInboundListenerFilters().
 		PermissiveMesh(meshConfig.Spec.Traffic.EnablePermissiveTrafficPolicyMode).
-		InboundMeshTrafficMatches(g.catalog.GetInboundMeshTrafficMatches(svcList)).
+		InboundMeshTrafficMatches(inboundMeshTrafficMatches).
 		ActiveHealthCheck(meshConfig.Spec.FeatureFlags.EnableEnvoyActiveHealthChecks).
 		SidecarSpec(meshConfig.Spec.Sidecar).
-		AccessLogs(accessLogs)
+		AccessLogs(accessLogs).
+		AccessLogsPerFormat(buildAccessLogsPerFormat(proxy, telemetryConfig, inboundMeshTrafficMatches))
 
 	trafficTargets, err := g.catalog.ListInboundTrafficTargetsWithRoutes(proxy.Identity)
 	if err != nil {
@@ -107,6 +112,10 @@ func (g *EnvoyConfigGenerator) generateLDS(ctx context.Context, proxy *models.Pr
 	ingressTrafficMatches := g.catalog.GetIngressTrafficMatches(svcList)
 	inboundLis.IngressTrafficMatches(ingressTrafficMatches)
 
+	// Errors building the inbound HTTP traffic policies are reported when generating the route configs from the same policies
+	inboundMeshHTTPTrafficPolicies, _ := g.catalog.GetInboundMeshHTTPRouteConfigsPerPortWithError(proxy.Identity, svcList)
+	inboundLis.InboundMeshHTTPTrafficPolicies(inboundMeshHTTPTrafficPolicies)
+
 	if meshConfig.Spec.Observability.Tracing.Enable {
 		inboundLis.TracingEndpoint(utils.GetTracingEndpoint(meshConfig))
 	}
@@ -138,3 +147,27 @@ func (g *EnvoyConfigGenerator) generateLDS(ctx context.Context, proxy *models.Pr
 
 	return ldsResources, nil
 }
+
+// buildAccessLogsPerFormat returns the access logs, keyed by access log format, for the access log formats
+// of the given inbound traffic matches overriding the access log format of the proxy. A traffic match whose
+// access logs cannot be built for its format falls back to the access logs of the proxy.
+func buildAccessLogsPerFormat(proxy *models.Proxy, telemetryConfig models.TelemetryConfig,
+	trafficMatches []*trafficpolicy.TrafficMatch) map[string][]*xds_accesslog.AccessLog {
+	accessLogsPerFormat := make(map[string][]*xds_accesslog.AccessLog)
+	for _, trafficMatch := range trafficMatches {
+		format := trafficMatch.AccessLogFormat
+		if format == "" {
+			continue
+		}
+		if _, ok := accessLogsPerFormat[format]; ok {
+			continue
+		}
+		accessLogs, err := lds.BuildAccessLogsWithFormat(proxy.String(), telemetryConfig, format)
+		if err != nil {
+			log.Error().Err(err).Str("proxy", proxy.String()).Msgf("Error building access log config for traffic match %s", trafficMatch.Name)
+			continue
+		}
+		accessLogsPerFormat[format] = accessLogs
+	}
+	return accessLogsPerFormat
+}
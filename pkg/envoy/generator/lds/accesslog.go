@@ -250,6 +250,12 @@ func isJSONStr(str string) bool {
 
 // BuildAccessLogs builds the access log config from the given telemetry config
 func BuildAccessLogs(name string, telemetryConfig models.TelemetryConfig) ([]*xds_accesslog.AccessLog, error) {
+	return BuildAccessLogsWithFormat(name, telemetryConfig, "")
+}
+
+// BuildAccessLogsWithFormat builds the access log config from the given telemetry config, with the given
+// format overriding the access log format of the telemetry config if not empty
+func BuildAccessLogsWithFormat(name string, telemetryConfig models.TelemetryConfig, format string) ([]*xds_accesslog.AccessLog, error) {
 	ab := NewAccessLogBuilder().Name(name)
 
 	if telemetryConfig.Policy != nil {
@@ -260,6 +266,9 @@ func BuildAccessLogs(name string, telemetryConfig models.TelemetryConfig) ([]*xd
 				OpenTelemetryAttributes(telemetryConfig.Policy.Spec.AccessLog.OpenTelemetry.Attributes)
 		}
 	}
+	if format != "" {
+		ab.Format(format)
+	}
 
 	return ab.Build()
 }
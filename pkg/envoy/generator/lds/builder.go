@@ -3,16 +3,19 @@ package lds
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	xds_accesslog "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
 	xds_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	xds_listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	xds_http_fault "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/fault/v3"
 	xds_http_local_ratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
 	xds_hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	xds_tcp_proxy "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/golang/protobuf/ptypes/wrappers"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	configv1alpha2 "github.com/openservicemesh/osm/pkg/apis/config/v1alpha2"
 	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
@@ -64,6 +67,13 @@ func (lb *listenerBuilder) InboundMeshTrafficMatches(t []*trafficpolicy.TrafficM
 	return lb
 }
 
+// InboundMeshHTTPTrafficPolicies sets the inbound HTTP traffic policies per port, used to build the HTTP filters
+// configured per host on the inbound HTTP filter chains
+func (lb *listenerBuilder) InboundMeshHTTPTrafficPolicies(policiesPerPort map[int][]*trafficpolicy.InboundTrafficPolicy) *listenerBuilder {
+	lb.inboundMeshHTTPTrafficPolicies = policiesPerPort
+	return lb
+}
+
 func (lb *listenerBuilder) EgressTrafficMatches(t []*trafficpolicy.TrafficMatch) *listenerBuilder {
 	lb.egressTrafficMatches = t
 	return lb
@@ -89,6 +99,13 @@ func (lb *listenerBuilder) AccessLogs(accessLogs []*xds_accesslog.AccessLog) *li
 	return lb
 }
 
+// AccessLogsPerFormat sets the access logs, keyed by access log format, used by the inbound HTTP
+// filter chains whose TrafficMatch overrides the access log format of the proxy
+func (lb *listenerBuilder) AccessLogsPerFormat(accessLogsPerFormat map[string][]*xds_accesslog.AccessLog) *listenerBuilder {
+	lb.accessLogsPerFormat = accessLogsPerFormat
+	return lb
+}
+
 func (lb *listenerBuilder) getFilterBuilder() *filterBuilder {
 	if lb.filBuilder == nil {
 		lb.filBuilder = getFilterBuilder()
@@ -334,6 +351,20 @@ func (fb *filterBuilder) WithRBAC(t []trafficpolicy.TrafficTargetWithRoutes, iss
 	return fb
 }
 
+// WithPrincipalRBAC sets the RBAC properties used to build the filter from the allowed principals per policy name
+func (fb *filterBuilder) WithPrincipalRBAC(principalsPerPolicy map[string][]string) *filterBuilder {
+	fb.withRBAC = true
+	fb.principalsPerPolicy = principalsPerPolicy
+	return fb
+}
+
+// WithAllowedSourceRanges sets the source IP ranges, in CIDR notation, the principals allowed by the RBAC policies of the
+// filter must connect from. The ranges only restrict the RBAC policies set with WithRBAC or WithPrincipalRBAC.
+func (fb *filterBuilder) WithAllowedSourceRanges(sourceRanges []string) *filterBuilder {
+	fb.allowedSourceRanges = sourceRanges
+	return fb
+}
+
 func (fb *filterBuilder) TCPLocalRateLimit(rl *policyv1alpha1.TCPLocalRateLimitSpec) *filterBuilder {
 	fb.tcpLocalRateLimit = rl
 	return fb
@@ -427,6 +458,16 @@ func (hb *httpConnManagerBuilder) RouteConfigName(name string) *httpConnManagerB
 // defaultFilters sets the default HTTP filters on the builder
 func (hb *httpConnManagerBuilder) defaultFilters() []*xds_hcm.HttpFilter {
 	return []*xds_hcm.HttpFilter{
+		{
+			// HTTP CORS filter - required to apply the CORS policy configured on a VirtualHost.
+			// Placed first so that preflight requests are answered before other filters apply.
+			Name: envoy.HTTPCORSFilterName,
+			ConfigType: &xds_hcm.HttpFilter_TypedConfig{
+				TypedConfig: &any.Any{
+					TypeUrl: envoy.HTTPCORSFilterTypeURL,
+				},
+			},
+		},
 		{
 			// HTTP RBAC filter - required to perform HTTP based RBAC per route
 			Name: envoy.HTTPRBACFilterName,
@@ -451,6 +492,16 @@ func (hb *httpConnManagerBuilder) defaultFilters() []*xds_hcm.HttpFilter {
 				),
 			},
 		},
+		{
+			// HTTP fault filter - required to inject faults per route
+			Name: envoy.HTTPFaultFilterName,
+			ConfigType: &xds_hcm.HttpFilter_TypedConfig{
+				// Since no fault is defined here, the filter is a no-op at the
+				// listener level. Faults are only injected on the routes that
+				// override this config.
+				TypedConfig: protobuf.MustMarshalAny(&xds_http_fault.HTTPFault{}),
+			},
+		},
 	}
 }
 
@@ -571,6 +622,12 @@ func (tb *tcpProxyBuilder) WeightedClusters(wc []service.WeightedCluster) *tcpPr
 	return tb
 }
 
+// IdleTimeout sets the idle timeout to use for the TCP proxy filter, a timeout of 0 disables the idle timeout
+func (tb *tcpProxyBuilder) IdleTimeout(idleTimeout *time.Duration) *tcpProxyBuilder {
+	tb.idleTimeout = idleTimeout
+	return tb
+}
+
 // Build builds the TCP proxy filter
 func (tb *tcpProxyBuilder) Build() (*xds_listener.Filter, error) {
 	if tb.cluster != "" && len(tb.weightedClusters) > 0 {
@@ -583,6 +640,10 @@ func (tb *tcpProxyBuilder) Build() (*xds_listener.Filter, error) {
 		StatPrefix: tb.statsPrefix,
 	}
 
+	if tb.idleTimeout != nil {
+		tcpProxy.IdleTimeout = durationpb.New(*tb.idleTimeout)
+	}
+
 	if tb.cluster != "" {
 		tcpProxy.ClusterSpecifier = &xds_tcp_proxy.TcpProxy_Cluster{Cluster: tb.cluster}
 	} else if len(tb.weightedClusters) == 1 {
@@ -2,11 +2,14 @@ package lds
 
 import (
 	"testing"
+	"time"
 
 	xds_listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	xds_hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	xds_tcp_proxy "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/openservicemesh/osm/pkg/certificate"
 	"github.com/openservicemesh/osm/pkg/envoy"
@@ -45,8 +48,10 @@ func TestHTTPConnManagerBuilder(t *testing.T) {
 			assertFunc: func(a *assert.Assertions, hcm *xds_hcm.HttpConnectionManager) {
 				a.Equal("foo", hcm.StatPrefix)
 				a.Equal("bar", hcm.GetRds().RouteConfigName)
+				a.True(contains(hcm.HttpFilters, envoy.HTTPCORSFilterName))
 				a.True(contains(hcm.HttpFilters, envoy.HTTPRBACFilterName))
 				a.True(contains(hcm.HttpFilters, envoy.HTTPLocalRateLimitFilterName))
+				a.True(contains(hcm.HttpFilters, envoy.HTTPFaultFilterName))
 				a.True(contains(hcm.HttpFilters, "f1"))
 				a.True(contains(hcm.HttpFilters, "f2"))
 				a.ElementsMatch(&xds_hcm.LocalReplyConfig{}, hcm.LocalReplyConfig)
@@ -190,6 +195,32 @@ func TestFilterBuilder(t *testing.T) {
 			},
 			expectedNetworkFilters: []string{envoy.TCPProxyFilterName},
 		},
+		{
+			name: "TCP proxy with an idle timeout",
+			prep: func(fb *filterBuilder) {
+				idleTimeout := time.Hour
+				fb.TCPProxy().StatsPrefix("test").Cluster("foo").IdleTimeout(&idleTimeout)
+			},
+			expectedTCPProxy: &xds_tcp_proxy.TcpProxy{
+				StatPrefix:       "test",
+				ClusterSpecifier: &xds_tcp_proxy.TcpProxy_Cluster{Cluster: "foo"},
+				IdleTimeout:      durationpb.New(time.Hour),
+			},
+			expectedNetworkFilters: []string{envoy.TCPProxyFilterName},
+		},
+		{
+			name: "TCP proxy with an idle timeout of 0 disabling the idle timeout",
+			prep: func(fb *filterBuilder) {
+				idleTimeout := time.Duration(0)
+				fb.TCPProxy().StatsPrefix("test").Cluster("foo").IdleTimeout(&idleTimeout)
+			},
+			expectedTCPProxy: &xds_tcp_proxy.TcpProxy{
+				StatPrefix:       "test",
+				ClusterSpecifier: &xds_tcp_proxy.TcpProxy_Cluster{Cluster: "foo"},
+				IdleTimeout:      durationpb.New(0),
+			},
+			expectedNetworkFilters: []string{envoy.TCPProxyFilterName},
+		},
 		{
 			name: "TCP proxy to multiple upstream clusters",
 			prep: func(fb *filterBuilder) {
@@ -260,7 +291,7 @@ func TestFilterBuilder(t *testing.T) {
 				}).httpConnManager()
 			},
 			expectedNetworkFilters: []string{envoy.L4RBACFilterName},
-			expectedHTTPFilters:    []string{envoy.HTTPRBACFilterName, envoy.HTTPLocalRateLimitFilterName, envoy.HTTPRouterFilterName},
+			expectedHTTPFilters:    []string{envoy.HTTPCORSFilterName, envoy.HTTPRBACFilterName, envoy.HTTPLocalRateLimitFilterName, envoy.HTTPFaultFilterName, envoy.HTTPRouterFilterName},
 		},
 	}
 
@@ -318,6 +349,7 @@ func TestFilterBuilder(t *testing.T) {
 				a.NotNil(actual)
 				a.Equal(tc.expectedTCPProxy.StatPrefix, actual.StatPrefix)
 				a.Equal(tc.expectedTCPProxy.ClusterSpecifier, actual.ClusterSpecifier)
+				a.True(proto.Equal(tc.expectedTCPProxy.IdleTimeout, actual.IdleTimeout))
 			}
 
 			for _, expectedFilter := range tc.expectedNetworkFilters {
@@ -0,0 +1,20 @@
+package lds
+
+import (
+	xds_grpc_web "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/grpc_web/v3"
+	xds_hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+
+	"github.com/openservicemesh/osm/pkg/envoy"
+	"github.com/openservicemesh/osm/pkg/protobuf"
+)
+
+// buildGRPCWebFilter returns the HTTP filter translating gRPC-Web requests from browser clients into gRPC
+// requests to the upstream
+func buildGRPCWebFilter() *xds_hcm.HttpFilter {
+	return &xds_hcm.HttpFilter{
+		Name: envoy.HTTPGRPCWebFilterName,
+		ConfigType: &xds_hcm.HttpFilter_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&xds_grpc_web.GrpcWeb{}),
+		},
+	}
+}
@@ -0,0 +1,35 @@
+package lds
+
+import (
+	xds_header_to_metadata "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/header_to_metadata/v3"
+	xds_hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+
+	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
+	"github.com/openservicemesh/osm/pkg/envoy"
+	"github.com/openservicemesh/osm/pkg/protobuf"
+)
+
+// buildHeaderToMetadataFilter returns the HTTP filter copying the values of request headers into the dynamic
+// metadata of the requests as per the given rules
+func buildHeaderToMetadataFilter(rules []policyv1alpha1.HeaderToMetadataSpec) *xds_hcm.HttpFilter {
+	config := &xds_header_to_metadata.Config{}
+	for _, rule := range rules {
+		config.RequestRules = append(config.RequestRules, &xds_header_to_metadata.Config_Rule{
+			Header: rule.Header,
+			// The header value is copied as is when no value is specified
+			OnHeaderPresent: &xds_header_to_metadata.Config_KeyValuePair{
+				MetadataNamespace: rule.MetadataNamespace,
+				Key:               rule.MetadataKey,
+				Type:              xds_header_to_metadata.Config_STRING,
+			},
+			Remove: rule.Remove,
+		})
+	}
+
+	return &xds_hcm.HttpFilter{
+		Name: envoy.HTTPHeaderToMetadataFilterName,
+		ConfigType: &xds_hcm.HttpFilter_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(config),
+		},
+	}
+}
@@ -4,20 +4,24 @@ import (
 	"fmt"
 	"strings"
 
+	xds_accesslog "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
 	xds_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	xds_listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
+	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
 	"github.com/openservicemesh/osm/pkg/constants"
 	"github.com/openservicemesh/osm/pkg/envoy"
 	"github.com/openservicemesh/osm/pkg/envoy/generator/rds"
 	"github.com/openservicemesh/osm/pkg/errcode"
+	"github.com/openservicemesh/osm/pkg/identity"
 	"github.com/openservicemesh/osm/pkg/trafficpolicy"
 )
 
 func (lb *listenerBuilder) buildInboundMeshFilterChains() []*xds_listener.FilterChain {
 	var filterChains []*xds_listener.FilterChain
+	identityScopedFilterChains := make(map[string]bool)
 
 	for _, match := range lb.inboundMeshTrafficMatches {
 		// Create protocol specific inbound filter chains for MeshService's TargetPort
@@ -32,6 +36,13 @@ func (lb *listenerBuilder) buildInboundMeshFilterChains() []*xds_listener.Filter
 			}
 
 		case constants.ProtocolTCP, constants.ProtocolTCPServerFirst:
+			if len(match.AllowedPrincipals) > 0 {
+				// Identity-scoped traffic matches with the same name share a filter chain
+				if identityScopedFilterChains[match.Name] {
+					continue
+				}
+				identityScopedFilterChains[match.Name] = true
+			}
 			filterChainForPort, err := lb.buildInboundTCPFilterChain(match)
 			if err != nil {
 				log.Error().Err(err).Msgf("Error building inbound TCP filter chain for traffic match %s", match.Name)
@@ -39,6 +50,14 @@ func (lb *listenerBuilder) buildInboundMeshFilterChains() []*xds_listener.Filter
 				filterChains = append(filterChains, filterChainForPort)
 			}
 
+		case constants.ProtocolTLSPassthrough:
+			filterChainForPort, err := lb.buildInboundTLSPassthroughFilterChain(match)
+			if err != nil {
+				log.Error().Err(err).Msgf("Error building inbound TLS passthrough filter chain for traffic match %s", match.Name)
+			} else {
+				filterChains = append(filterChains, filterChainForPort)
+			}
+
 		default:
 			log.Error().Msgf("Cannot build inbound filter chain, unsupported protocol %s for traffic match %s", match.DestinationProtocol, match.Name)
 		}
@@ -71,10 +90,10 @@ func (lb *listenerBuilder) buildInboundHTTPFilterChain(trafficMatch *trafficpoli
 		fb.TCPGlobalRateLimit(trafficMatch.RateLimit.Global.TCP)
 	}
 
-	routeCfgName := rds.GetInboundMeshRouteConfigNameForPort(trafficMatch.DestinationPort)
+	routeCfgName := rds.GetInboundMeshRouteConfigNameForServerName(trafficMatch.DestinationPort, trafficMatch.RequiredServerName)
 	fb.httpConnManager().StatsPrefix(routeCfgName).
 		RouteConfigName(routeCfgName).
-		AccessLogs(lb.accessLogs)
+		AccessLogs(lb.getInboundAccessLogs(trafficMatch))
 
 	if lb.httpTracingEndpoint != "" {
 		tracing, err := getHTTPTracingConfig(lb.httpTracingEndpoint)
@@ -83,6 +102,10 @@ func (lb *listenerBuilder) buildInboundHTTPFilterChain(trafficMatch *trafficpoli
 		}
 		fb.httpConnManager().Tracing(tracing)
 	}
+	// Header-to-metadata precedes the other HTTP filters so that they can make use of the metadata
+	if headerToMetadata := lb.getInboundHeaderToMetadata(trafficMatch); len(headerToMetadata) > 0 {
+		fb.httpConnManager().AddFilter(buildHeaderToMetadataFilter(headerToMetadata))
+	}
 	if lb.extAuthzConfig != nil && lb.extAuthzConfig.Enable {
 		fb.httpConnManager().AddFilter(getExtAuthzHTTPFilter(lb.extAuthzConfig))
 	}
@@ -107,6 +130,10 @@ func (lb *listenerBuilder) buildInboundHTTPFilterChain(trafficMatch *trafficpoli
 		}
 		fb.httpConnManager().AddFilter(healthCheckFilter)
 	}
+	// gRPC-Web translation is the last filter before the router so that the preceding filters see the original request
+	if lb.isInboundGRPCWebEnabled(trafficMatch) {
+		fb.httpConnManager().AddFilter(buildGRPCWebFilter())
+	}
 
 	// Build the inbound filters
 	filters, err := fb.Build()
@@ -155,6 +182,55 @@ func (lb *listenerBuilder) buildInboundHTTPFilterChain(trafficMatch *trafficpoli
 	return filterChain, nil
 }
 
+// getInboundAccessLogs returns the access logs for the given inbound TrafficMatch, which are the access logs
+// built for its access log format if it overrides the access log format of the proxy
+func (lb *listenerBuilder) getInboundAccessLogs(trafficMatch *trafficpolicy.TrafficMatch) []*xds_accesslog.AccessLog {
+	if trafficMatch.AccessLogFormat == "" {
+		return lb.accessLogs
+	}
+	if accessLogs, ok := lb.accessLogsPerFormat[trafficMatch.AccessLogFormat]; ok {
+		return accessLogs
+	}
+	log.Warn().Msgf("No access logs found for the access log format of traffic match %s, using the proxy's access logs", trafficMatch.Name)
+	return lb.accessLogs
+}
+
+// getInboundHTTPTrafficPolicy returns the inbound HTTP traffic policy for the host the given TrafficMatch accepts
+// traffic for, or nil if there is none
+func (lb *listenerBuilder) getInboundHTTPTrafficPolicy(trafficMatch *trafficpolicy.TrafficMatch) *trafficpolicy.InboundTrafficPolicy {
+	for _, policy := range lb.inboundMeshHTTPTrafficPolicies[trafficMatch.DestinationPort] {
+		if policy.ServerName != trafficMatch.RequiredServerName {
+			continue
+		}
+		if policy.ServerName != "" {
+			// The policy applies to connections negotiated with the SNI required by the TrafficMatch
+			return policy
+		}
+		for _, hostname := range policy.Hostnames {
+			if len(trafficMatch.ServerNames) > 0 && hostname == trafficMatch.ServerNames[0] {
+				return policy
+			}
+		}
+	}
+	return nil
+}
+
+// getInboundHeaderToMetadata returns the header-to-metadata rules of the inbound HTTP traffic policy for the host
+// the given TrafficMatch accepts traffic for
+func (lb *listenerBuilder) getInboundHeaderToMetadata(trafficMatch *trafficpolicy.TrafficMatch) []policyv1alpha1.HeaderToMetadataSpec {
+	if policy := lb.getInboundHTTPTrafficPolicy(trafficMatch); policy != nil {
+		return policy.HeaderToMetadata
+	}
+	return nil
+}
+
+// isInboundGRPCWebEnabled returns a boolean indicating if gRPC-Web translation is enabled for the host the given
+// TrafficMatch accepts traffic for
+func (lb *listenerBuilder) isInboundGRPCWebEnabled(trafficMatch *trafficpolicy.TrafficMatch) bool {
+	policy := lb.getInboundHTTPTrafficPolicy(trafficMatch)
+	return policy != nil && policy.EnableGRPCWeb
+}
+
 func (lb *listenerBuilder) buildInboundTCPFilterChain(trafficMatch *trafficpolicy.TrafficMatch) (*xds_listener.FilterChain, error) {
 	if trafficMatch == nil {
 		return nil, nil
@@ -166,12 +242,21 @@ func (lb *listenerBuilder) buildInboundTCPFilterChain(trafficMatch *trafficpolic
 
 	fb.TCPProxy().
 		StatsPrefix(trafficMatch.Name).
-		Cluster(trafficMatch.Cluster)
+		Cluster(trafficMatch.Cluster).
+		IdleTimeout(trafficMatch.IdleTimeout)
 
 	// Network RBAC
-	if !lb.permissiveMesh && len(lb.trafficTargets) > 0 {
-		fb.WithRBAC(lb.trafficTargets, lb.issuers)
+	if !lb.permissiveMesh {
+		if len(trafficMatch.AllowedPrincipals) > 0 {
+			fb.WithPrincipalRBAC(lb.getAllowedPrincipalsPerIdentity(trafficMatch.Name))
+		} else if len(lb.trafficTargets) > 0 {
+			fb.WithRBAC(lb.trafficTargets, lb.issuers)
+		}
+	} else if len(trafficMatch.AllowedSourceRanges) > 0 {
+		// All the principals are allowed in permissive mode, provided they connect from the allowed source ranges
+		fb.WithPrincipalRBAC(map[string][]string{allowedSourceRangesPolicyName: {identity.WildcardPrincipal}})
 	}
+	fb.WithAllowedSourceRanges(trafficMatch.AllowedSourceRanges)
 
 	// TCP local rate limit
 	if trafficMatch.RateLimit != nil && trafficMatch.RateLimit.Local != nil && trafficMatch.RateLimit.Local.TCP != nil {
@@ -225,6 +310,72 @@ func (lb *listenerBuilder) buildInboundTCPFilterChain(trafficMatch *trafficpolic
 	}, nil
 }
 
+// buildInboundTLSPassthroughFilterChain builds a filter chain that proxies the TLS connections terminated by
+// the application. Connections are matched on the SNI, without TLS termination or identity based RBAC.
+func (lb *listenerBuilder) buildInboundTLSPassthroughFilterChain(trafficMatch *trafficpolicy.TrafficMatch) (*xds_listener.FilterChain, error) {
+	if trafficMatch == nil {
+		return nil, nil
+	}
+
+	// Build filters
+	fb := getFilterBuilder().
+		StatsPrefix(trafficMatch.Name)
+
+	fb.TCPProxy().
+		StatsPrefix(trafficMatch.Name).
+		Cluster(trafficMatch.Cluster).
+		IdleTimeout(trafficMatch.IdleTimeout)
+
+	// Network RBAC, the connections are not authenticated so only their source address can be checked
+	if len(trafficMatch.AllowedSourceRanges) > 0 {
+		fb.WithPrincipalRBAC(map[string][]string{allowedSourceRangesPolicyName: {identity.WildcardPrincipal}}).
+			WithAllowedSourceRanges(trafficMatch.AllowedSourceRanges)
+	}
+
+	// TCP local rate limit
+	if trafficMatch.RateLimit != nil && trafficMatch.RateLimit.Local != nil && trafficMatch.RateLimit.Local.TCP != nil {
+		fb.TCPLocalRateLimit(trafficMatch.RateLimit.Local.TCP)
+	}
+
+	// TCP global rate limit
+	if trafficMatch.RateLimit != nil && trafficMatch.RateLimit.Global != nil && trafficMatch.RateLimit.Global.TCP != nil {
+		fb.TCPGlobalRateLimit(trafficMatch.RateLimit.Global.TCP)
+	}
+
+	filters, err := fb.Build()
+	if err != nil {
+		return nil, fmt.Errorf("error building inbound TLS passthrough filters: %w", err)
+	}
+
+	return &xds_listener.FilterChain{
+		Name: trafficMatch.Name,
+		FilterChainMatch: &xds_listener.FilterChainMatch{
+			DestinationPort: &wrapperspb.UInt32Value{
+				Value: uint32(trafficMatch.DestinationPort),
+			},
+
+			// The ServerName is the SNI set by the client terminating TLS with the application
+			ServerNames: trafficMatch.ServerNames,
+
+			// Only match when transport protocol is TLS
+			TransportProtocol: envoy.TransportProtocolTLS,
+		},
+		Filters: filters,
+	}, nil
+}
+
+// getAllowedPrincipalsPerIdentity returns the allowed principals of the identity-scoped inbound traffic matches
+// with the given name, keyed by the first principal of each traffic match
+func (lb *listenerBuilder) getAllowedPrincipalsPerIdentity(trafficMatchName string) map[string][]string {
+	principalsPerIdentity := make(map[string][]string)
+	for _, match := range lb.inboundMeshTrafficMatches {
+		if match.Name == trafficMatchName && len(match.AllowedPrincipals) > 0 {
+			principalsPerIdentity[match.AllowedPrincipals[0]] = match.AllowedPrincipals
+		}
+	}
+	return principalsPerIdentity
+}
+
 // buildOutboundFilterChainMatch builds a filter chain to match the HTTP or TCP based destination traffic.
 // Filter Chain currently matches on the following:
 // 1. Destination IP of service endpoints
@@ -4,9 +4,13 @@ import (
 	"fmt"
 	"testing"
 
+	xds_accesslog "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
 	xds_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	xds_listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	xds_accesslog_stream "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/stream/v3"
+	xds_header_to_metadata "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/header_to_metadata/v3"
 	xds_hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	xds_network_rbac "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/rbac/v3"
 	"github.com/stretchr/testify/assert"
 	tassert "github.com/stretchr/testify/assert"
 	"google.golang.org/protobuf/types/known/wrapperspb"
@@ -16,6 +20,7 @@ import (
 	"github.com/openservicemesh/osm/pkg/envoy"
 
 	"github.com/openservicemesh/osm/pkg/identity"
+	"github.com/openservicemesh/osm/pkg/models"
 	"github.com/openservicemesh/osm/pkg/service"
 	"github.com/openservicemesh/osm/pkg/tests"
 	"github.com/openservicemesh/osm/pkg/trafficpolicy"
@@ -515,6 +520,39 @@ func TestBuildInboundTCPFilterChain(t *testing.T) {
 			expectedFilterNames: []string{envoy.L4GlobalRateLimitFilterName, envoy.TCPProxyFilterName},
 			expectError:         false,
 		},
+		{
+			name:           "inbound TCP filter chain with allowed source ranges and permissive mode enabled",
+			permissiveMode: true,
+			trafficMatch: &trafficpolicy.TrafficMatch{
+				Name:                "inbound_ns1/svc1_90_http",
+				Cluster:             "ns1/svc1_90_http",
+				DestinationPort:     90,
+				DestinationProtocol: "tcp",
+				ServerNames:         []string{"svc1.ns1.svc.cluster.local"},
+				AllowedSourceRanges: []string{"10.0.0.0/8"},
+			},
+			expectedFilterChainMatch: &xds_listener.FilterChainMatch{
+				DestinationPort:      &wrapperspb.UInt32Value{Value: 90},
+				ServerNames:          []string{"svc1.ns1.svc.cluster.local"},
+				TransportProtocol:    "tls",
+				ApplicationProtocols: []string{"osm"},
+			},
+			expectedFilterNames: []string{envoy.L4RBACFilterName, envoy.TCPProxyFilterName},
+			expectError:         false,
+		},
+		{
+			name:           "inbound TCP filter chain with an invalid allowed source range",
+			permissiveMode: false,
+			trafficMatch: &trafficpolicy.TrafficMatch{
+				Name:                "inbound_ns1/svc1_90_http",
+				Cluster:             "ns1/svc1_90_http",
+				DestinationPort:     90,
+				DestinationProtocol: "tcp",
+				ServerNames:         []string{"svc1.ns1.svc.cluster.local"},
+				AllowedSourceRanges: []string{"10.0.0.0"},
+			},
+			expectError: true,
+		},
 	}
 
 	trafficTargets := []trafficpolicy.TrafficTargetWithRoutes{
@@ -541,6 +579,9 @@ func TestBuildInboundTCPFilterChain(t *testing.T) {
 			filterChain, err := lb.buildInboundTCPFilterChain(tc.trafficMatch)
 
 			assert.Equal(err != nil, tc.expectError, err)
+			if tc.expectError {
+				return
+			}
 			assert.Equal(filterChain.FilterChainMatch, tc.expectedFilterChainMatch)
 			assert.Len(filterChain.Filters, len(tc.expectedFilterNames))
 			for i, filter := range filterChain.Filters {
@@ -550,7 +591,288 @@ func TestBuildInboundTCPFilterChain(t *testing.T) {
 	}
 }
 
+func TestBuildInboundMeshFilterChainsWithAllowedSourceRanges(t *testing.T) {
+	trafficTargets := []trafficpolicy.TrafficTargetWithRoutes{
+		{
+			Name:        "ns1/test",
+			Destination: identity.ServiceIdentity("sa-1.ns1"),
+			Sources:     []identity.ServiceIdentity{identity.ServiceIdentity("sa-2.ns2")},
+		},
+	}
+
+	testCases := []struct {
+		name                 string
+		permissiveMode       bool
+		destinationProtocol  string
+		expectedPolicyName   string
+		expectedAnyPrincipal bool
+	}{
+		{
+			name:                 "TCP service in permissive mode",
+			permissiveMode:       true,
+			destinationProtocol:  "tcp",
+			expectedPolicyName:   allowedSourceRangesPolicyName,
+			expectedAnyPrincipal: true,
+		},
+		{
+			name:                 "TCP service with SMI traffic policies",
+			permissiveMode:       false,
+			destinationProtocol:  "tcp",
+			expectedPolicyName:   "ns1/test",
+			expectedAnyPrincipal: false,
+		},
+		{
+			name:                 "TLS passthrough service",
+			permissiveMode:       false,
+			destinationProtocol:  "tls-passthrough",
+			expectedPolicyName:   allowedSourceRangesPolicyName,
+			expectedAnyPrincipal: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			lb := &listenerBuilder{
+				proxyIdentity:  tests.BookstoreServiceIdentity,
+				permissiveMesh: tc.permissiveMode,
+				trafficTargets: trafficTargets,
+				inboundMeshTrafficMatches: []*trafficpolicy.TrafficMatch{
+					{
+						Name:                "inbound_ns1/svc1_3306",
+						Cluster:             "ns1/svc1|3306|local",
+						DestinationPort:     3306,
+						DestinationProtocol: tc.destinationProtocol,
+						ServerNames:         []string{"svc1.ns1.svc.cluster.local"},
+						AllowedSourceRanges: []string{"10.0.0.0/8"},
+					},
+				},
+			}
+
+			filterChains := lb.buildInboundMeshFilterChains()
+			assert.Len(filterChains, 1)
+			assert.Len(filterChains[0].Filters, 2)
+			assert.Equal(envoy.L4RBACFilterName, filterChains[0].Filters[0].Name)
+
+			// The allowed principals must connect from the allowed source ranges
+			networkRBAC := &xds_network_rbac.RBAC{}
+			assert.Nil(filterChains[0].Filters[0].GetTypedConfig().UnmarshalTo(networkRBAC))
+			assert.Len(networkRBAC.Rules.Policies, 1)
+			policy, ok := networkRBAC.Rules.Policies[tc.expectedPolicyName]
+			assert.True(ok)
+			assert.Len(policy.Principals, 1)
+			ids := policy.Principals[0].GetAndIds().GetIds()
+			assert.Len(ids, 2)
+			assert.Equal(tc.expectedAnyPrincipal, ids[0].GetOrIds().GetIds()[0].GetAny())
+			assert.Equal("10.0.0.0", ids[1].GetOrIds().GetIds()[0].GetDirectRemoteIp().GetAddressPrefix())
+		})
+	}
+}
+
 // Tests buildOutboundFilterChainMatch and ensures the filter chain match returned is as expected
+func TestBuildInboundMeshFilterChainsPerIdentity(t *testing.T) {
+	assert := tassert.New(t)
+
+	newTrafficMatch := func(principal string) *trafficpolicy.TrafficMatch {
+		return &trafficpolicy.TrafficMatch{
+			Name:                "inbound_ns1/svc1_3306_tcp",
+			Cluster:             "ns1/svc1|3306|local",
+			DestinationPort:     3306,
+			DestinationProtocol: "tcp",
+			ServerNames:         []string{"svc1.ns1.svc.cluster.local"},
+			AllowedPrincipals:   []string{principal},
+		}
+	}
+
+	lb := &listenerBuilder{
+		proxyIdentity: tests.BookstoreServiceIdentity,
+		inboundMeshTrafficMatches: []*trafficpolicy.TrafficMatch{
+			newTrafficMatch("sa2.ns2.cluster.local"),
+			newTrafficMatch("sa3.ns3.cluster.local"),
+		},
+	}
+
+	// Identity-scoped traffic matches for the same service share a filter chain
+	filterChains := lb.buildInboundMeshFilterChains()
+	assert.Len(filterChains, 1)
+	assert.Equal("inbound_ns1/svc1_3306_tcp", filterChains[0].Name)
+	assert.Len(filterChains[0].Filters, 2)
+	assert.Equal(envoy.L4RBACFilterName, filterChains[0].Filters[0].Name)
+	assert.Equal(envoy.TCPProxyFilterName, filterChains[0].Filters[1].Name)
+
+	// The network RBAC filter has a policy per downstream identity
+	networkRBAC := &xds_network_rbac.RBAC{}
+	assert.Nil(filterChains[0].Filters[0].GetTypedConfig().UnmarshalTo(networkRBAC))
+	assert.Len(networkRBAC.Rules.Policies, 2)
+	for _, principal := range []string{"sa2.ns2.cluster.local", "sa3.ns3.cluster.local"} {
+		policy, ok := networkRBAC.Rules.Policies[principal]
+		assert.True(ok)
+		assert.Len(policy.Principals, 1)
+		assert.Equal(principal, policy.Principals[0].GetAuthenticated().GetPrincipalName().GetExact())
+	}
+}
+
+func TestBuildInboundMeshFilterChainsForTLSPassthrough(t *testing.T) {
+	assert := tassert.New(t)
+
+	lb := &listenerBuilder{
+		proxyIdentity: tests.BookstoreServiceIdentity,
+		inboundMeshTrafficMatches: []*trafficpolicy.TrafficMatch{
+			{
+				Name:                "inbound_ns1/svc1_8443_tls-passthrough",
+				Cluster:             "ns1/svc1|8443|local",
+				DestinationPort:     8443,
+				DestinationProtocol: "tls-passthrough",
+				ServerNames:         []string{"svc1.ns1.svc.cluster.local"},
+			},
+		},
+		trafficTargets: []trafficpolicy.TrafficTargetWithRoutes{
+			{
+				Name:        "ns1/test",
+				Destination: identity.ServiceIdentity("sa-1.ns1"),
+				Sources:     []identity.ServiceIdentity{identity.ServiceIdentity("sa-2.ns2")},
+			},
+		},
+	}
+
+	filterChains := lb.buildInboundMeshFilterChains()
+	assert.Len(filterChains, 1)
+	filterChain := filterChains[0]
+	assert.Equal("inbound_ns1/svc1_8443_tls-passthrough", filterChain.Name)
+
+	// The connection is routed by SNI without TLS termination or RBAC
+	assert.Equal(&wrapperspb.UInt32Value{Value: 8443}, filterChain.FilterChainMatch.DestinationPort)
+	assert.Equal([]string{"svc1.ns1.svc.cluster.local"}, filterChain.FilterChainMatch.ServerNames)
+	assert.Equal(envoy.TransportProtocolTLS, filterChain.FilterChainMatch.TransportProtocol)
+	assert.Empty(filterChain.FilterChainMatch.ApplicationProtocols)
+	assert.Nil(filterChain.TransportSocket)
+	assert.Len(filterChain.Filters, 1)
+	assert.Equal(envoy.TCPProxyFilterName, filterChain.Filters[0].Name)
+}
+
+func TestBuildInboundMeshFilterChainsWithAccessLogFormats(t *testing.T) {
+	assert := tassert.New(t)
+
+	textFormat := "[%START_TIME%] %REQ(:METHOD)% %RESPONSE_CODE%"
+	jsonFormat := `{"method": "%REQ(:METHOD)%", "response_code": "%RESPONSE_CODE%"}`
+
+	accessLogsPerFormat := make(map[string][]*xds_accesslog.AccessLog)
+	for _, format := range []string{textFormat, jsonFormat} {
+		accessLogs, err := BuildAccessLogsWithFormat("proxy", models.TelemetryConfig{}, format)
+		assert.Nil(err)
+		accessLogsPerFormat[format] = accessLogs
+	}
+
+	lb := &listenerBuilder{
+		proxyIdentity: tests.BookstoreServiceIdentity,
+		inboundMeshTrafficMatches: []*trafficpolicy.TrafficMatch{
+			{
+				Name:                "inbound_ns1/svc1_80_http",
+				DestinationPort:     80,
+				DestinationProtocol: "http",
+				ServerNames:         []string{"svc1.ns1.svc.cluster.local"},
+				AccessLogFormat:     textFormat,
+			},
+			{
+				Name:                "inbound_ns1/svc2_90_http",
+				DestinationPort:     90,
+				DestinationProtocol: "http",
+				ServerNames:         []string{"svc2.ns1.svc.cluster.local"},
+				AccessLogFormat:     jsonFormat,
+			},
+		},
+		permissiveMesh:      true,
+		accessLogsPerFormat: accessLogsPerFormat,
+	}
+
+	filterChains := lb.buildInboundMeshFilterChains()
+	assert.Len(filterChains, 2)
+
+	getStdoutAccessLog := func(filterChain *xds_listener.FilterChain) *xds_accesslog_stream.StdoutAccessLog {
+		hcm := &xds_hcm.HttpConnectionManager{}
+		assert.Nil(filterChain.Filters[len(filterChain.Filters)-1].GetTypedConfig().UnmarshalTo(hcm))
+		assert.Len(hcm.AccessLog, 1)
+		stdoutAccessLog := &xds_accesslog_stream.StdoutAccessLog{}
+		assert.Nil(hcm.AccessLog[0].GetTypedConfig().UnmarshalTo(stdoutAccessLog))
+		return stdoutAccessLog
+	}
+
+	// Each filter chain uses the access log format of its host
+	assert.Equal(textFormat, getStdoutAccessLog(filterChains[0]).GetLogFormat().GetTextFormatSource().GetInlineString())
+	jsonFields := getStdoutAccessLog(filterChains[1]).GetLogFormat().GetJsonFormat().GetFields()
+	assert.Len(jsonFields, 2)
+	assert.Equal("%REQ(:METHOD)%", jsonFields["method"].GetStringValue())
+	assert.Equal("%RESPONSE_CODE%", jsonFields["response_code"].GetStringValue())
+}
+
+func TestBuildInboundMeshFilterChainsWithHeaderToMetadata(t *testing.T) {
+	assert := tassert.New(t)
+
+	headerToMetadata := []policyv1alpha1.HeaderToMetadataSpec{
+		{Header: "x-tenant-id", MetadataNamespace: "osm.tenant", MetadataKey: "tenant", Remove: true},
+	}
+
+	lb := &listenerBuilder{
+		proxyIdentity: tests.BookstoreServiceIdentity,
+		inboundMeshTrafficMatches: []*trafficpolicy.TrafficMatch{
+			{
+				Name:                "inbound_ns1/svc1_80_http",
+				DestinationPort:     80,
+				DestinationProtocol: "http",
+				ServerNames:         []string{"svc1.ns1.svc.cluster.local"},
+			},
+			{
+				Name:                "inbound_ns1/svc2_80_http",
+				DestinationPort:     80,
+				DestinationProtocol: "http",
+				ServerNames:         []string{"svc2.ns1.svc.cluster.local"},
+			},
+		},
+		inboundMeshHTTPTrafficPolicies: map[int][]*trafficpolicy.InboundTrafficPolicy{
+			80: {
+				{
+					Name:             "svc1.ns1.svc.cluster.local",
+					Hostnames:        []string{"svc1", "svc1.ns1.svc.cluster.local"},
+					HeaderToMetadata: headerToMetadata,
+				},
+				{
+					Name:      "svc2.ns1.svc.cluster.local",
+					Hostnames: []string{"svc2", "svc2.ns1.svc.cluster.local"},
+				},
+			},
+		},
+		permissiveMesh: true,
+	}
+
+	filterChains := lb.buildInboundMeshFilterChains()
+	assert.Len(filterChains, 2)
+
+	getHeaderToMetadataFilter := func(filterChain *xds_listener.FilterChain) *xds_hcm.HttpFilter {
+		hcm := &xds_hcm.HttpConnectionManager{}
+		assert.Nil(filterChain.Filters[len(filterChain.Filters)-1].GetTypedConfig().UnmarshalTo(hcm))
+		for _, f := range hcm.HttpFilters {
+			if f.Name == envoy.HTTPHeaderToMetadataFilterName {
+				return f
+			}
+		}
+		return nil
+	}
+
+	// The header-to-metadata filter is only configured on the filter chain of the host with the mapping
+	filter := getHeaderToMetadataFilter(filterChains[0])
+	assert.NotNil(filter)
+	config := &xds_header_to_metadata.Config{}
+	assert.Nil(filter.GetTypedConfig().UnmarshalTo(config))
+	assert.Len(config.RequestRules, 1)
+	assert.Equal("x-tenant-id", config.RequestRules[0].Header)
+	assert.Equal("osm.tenant", config.RequestRules[0].OnHeaderPresent.MetadataNamespace)
+	assert.Equal("tenant", config.RequestRules[0].OnHeaderPresent.Key)
+	assert.True(config.RequestRules[0].Remove)
+
+	assert.Nil(getHeaderToMetadataFilter(filterChains[1]))
+}
+
 func TestBuildOutboundFilterChainMatch(t *testing.T) {
 	testCases := []struct {
 		name                     string
@@ -725,3 +1047,61 @@ func TestBuildOutboundFilterChains(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildInboundMeshFilterChainsWithGRPCWeb(t *testing.T) {
+	assert := tassert.New(t)
+
+	lb := &listenerBuilder{
+		proxyIdentity: tests.BookstoreServiceIdentity,
+		inboundMeshTrafficMatches: []*trafficpolicy.TrafficMatch{
+			{
+				Name:                "inbound_ns1/svc1_80_http",
+				DestinationPort:     80,
+				DestinationProtocol: "http",
+				ServerNames:         []string{"svc1.ns1.svc.cluster.local"},
+			},
+			{
+				Name:                "inbound_ns1/svc2_80_http",
+				DestinationPort:     80,
+				DestinationProtocol: "http",
+				ServerNames:         []string{"svc2.ns1.svc.cluster.local"},
+			},
+		},
+		inboundMeshHTTPTrafficPolicies: map[int][]*trafficpolicy.InboundTrafficPolicy{
+			80: {
+				{
+					Name:          "svc1.ns1.svc.cluster.local",
+					Hostnames:     []string{"svc1", "svc1.ns1.svc.cluster.local"},
+					EnableGRPCWeb: true,
+				},
+				{
+					Name:      "svc2.ns1.svc.cluster.local",
+					Hostnames: []string{"svc2", "svc2.ns1.svc.cluster.local"},
+				},
+			},
+		},
+		permissiveMesh:    true,
+		activeHealthCheck: true,
+	}
+
+	filterChains := lb.buildInboundMeshFilterChains()
+	assert.Len(filterChains, 2)
+
+	getHTTPFilterNames := func(filterChain *xds_listener.FilterChain) []string {
+		hcm := &xds_hcm.HttpConnectionManager{}
+		assert.Nil(filterChain.Filters[len(filterChain.Filters)-1].GetTypedConfig().UnmarshalTo(hcm))
+		var names []string
+		for _, f := range hcm.HttpFilters {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+
+	// The gRPC-Web filter immediately precedes the router on the filter chain of the host enabling it
+	names := getHTTPFilterNames(filterChains[0])
+	assert.GreaterOrEqual(len(names), 2)
+	assert.Equal(envoy.HTTPGRPCWebFilterName, names[len(names)-2])
+	assert.Equal(envoy.HTTPRouterFilterName, names[len(names)-1])
+
+	assert.NotContains(getHTTPFilterNames(filterChains[1]), envoy.HTTPGRPCWebFilterName)
+}
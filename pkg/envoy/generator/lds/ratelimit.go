@@ -40,6 +40,9 @@ func buildTCPLocalRateLimitFilter(config *policyv1alpha1.TCPLocalRateLimitSpec,
 	default:
 		return nil, fmt.Errorf("invalid unit %q for TCP connection rate limiting", config.Unit)
 	}
+	if config.FillInterval != nil {
+		fillInterval = config.FillInterval.Duration
+	}
 
 	rateLimit := &xds_network_local_ratelimit.LocalRateLimit{
 		StatPrefix: statPrefix,
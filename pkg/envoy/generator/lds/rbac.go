@@ -1,6 +1,8 @@
 package lds
 
 import (
+	"fmt"
+
 	xds_listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	xds_rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
 	xds_network_rbac "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/rbac/v3"
@@ -11,6 +13,12 @@ import (
 	"github.com/openservicemesh/osm/pkg/trafficpolicy"
 )
 
+const (
+	// allowedSourceRangesPolicyName is the name of the RBAC policy allowing the connections from the allowed
+	// source ranges when the downstream principals are not restricted
+	allowedSourceRangesPolicyName = "allowed-source-ranges"
+)
+
 // buildRBACFilter builds an RBAC filter based on SMI TrafficTarget policies.
 // The returned RBAC filter has policies that gives downstream principals full access to the local service.
 func (fb *filterBuilder) buildRBACFilter() (*xds_listener.Filter, error) {
@@ -37,7 +45,22 @@ func (fb *filterBuilder) buildInboundRBACPolicies() (*xds_network_rbac.RBAC, err
 	rbacPolicies := make(map[string]*xds_rbac.Policy)
 	// Build an RBAC policies based on SMI TrafficTarget policies
 	for _, targetPolicy := range fb.trafficTargets {
-		rbacPolicies[targetPolicy.Name] = fb.buildRBACPolicyFromTrafficTarget(targetPolicy)
+		policy, err := fb.buildRBACPolicyFromTrafficTarget(targetPolicy)
+		if err != nil {
+			return nil, err
+		}
+		rbacPolicies[targetPolicy.Name] = policy
+	}
+	// Build an RBAC policy per set of allowed principals
+	for policyName, principals := range fb.principalsPerPolicy {
+		pb := &rbac.PolicyBuilder{}
+		for _, principal := range principals {
+			pb.AddPrincipal(principal)
+		}
+		if err := fb.addAllowedSourceRanges(pb); err != nil {
+			return nil, err
+		}
+		rbacPolicies[policyName] = pb.Build()
 	}
 
 	// Create an inbound RBAC policy that denies a request by default, unless a policy explicitly allows it
@@ -53,7 +76,7 @@ func (fb *filterBuilder) buildInboundRBACPolicies() (*xds_network_rbac.RBAC, err
 }
 
 // buildRBACPolicyFromTrafficTarget creates an XDS RBAC policy from the given traffic target policy
-func (fb *filterBuilder) buildRBACPolicyFromTrafficTarget(trafficTarget trafficpolicy.TrafficTargetWithRoutes) *xds_rbac.Policy {
+func (fb *filterBuilder) buildRBACPolicyFromTrafficTarget(trafficTarget trafficpolicy.TrafficTargetWithRoutes) (*xds_rbac.Policy, error) {
 	pb := &rbac.PolicyBuilder{}
 
 	// Create the list of identities for this policy
@@ -70,6 +93,20 @@ func (fb *filterBuilder) buildRBACPolicyFromTrafficTarget(trafficTarget trafficp
 			pb.AddAllowedDestinationPort(port)
 		}
 	}
+	if err := fb.addAllowedSourceRanges(pb); err != nil {
+		return nil, err
+	}
+
+	return pb.Build(), nil
+}
 
-	return pb.Build()
+// addAllowedSourceRanges requires the principals allowed by the given RBAC policy to connect from one of the
+// source IP ranges allowed by the filter, if any
+func (fb *filterBuilder) addAllowedSourceRanges(pb *rbac.PolicyBuilder) error {
+	for _, sourceRange := range fb.allowedSourceRanges {
+		if err := pb.AddAllowedSourceRange(sourceRange); err != nil {
+			return fmt.Errorf("invalid allowed source range %q: %w", sourceRange, err)
+		}
+	}
+	return nil
 }
@@ -148,8 +148,9 @@ func TestBuildRBACPolicyFromTrafficTarget(t *testing.T) {
 				issuers: tc.configuredIssuers,
 			}
 
-			policy := fb.buildRBACPolicyFromTrafficTarget(tc.trafficTarget)
+			policy, err := fb.buildRBACPolicyFromTrafficTarget(tc.trafficTarget)
 
+			assert.NoError(err)
 			assert.Equal(tc.expectedPolicy, policy)
 		})
 	}
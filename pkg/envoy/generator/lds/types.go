@@ -2,6 +2,8 @@
 package lds
 
 import (
+	"time"
+
 	xds_accesslog "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
 	xds_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	xds_listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
@@ -42,8 +44,12 @@ type listenerBuilder struct {
 	sidecarSpec                configv1alpha2.SidecarSpec
 	filBuilder                 *filterBuilder
 
-	listenerFilters []*xds_listener.ListenerFilter
-	accessLogs      []*xds_accesslog.AccessLog
+	listenerFilters     []*xds_listener.ListenerFilter
+	accessLogs          []*xds_accesslog.AccessLog
+	accessLogsPerFormat map[string][]*xds_accesslog.AccessLog
+
+	// inboundMeshHTTPTrafficPolicies maps ports to the inbound HTTP traffic policies of the hosts on the port
+	inboundMeshHTTPTrafficPolicies map[int][]*trafficpolicy.InboundTrafficPolicy
 }
 
 type httpConnManagerBuilder struct {
@@ -61,15 +67,20 @@ type tcpProxyBuilder struct {
 	statsPrefix      string
 	cluster          string
 	weightedClusters []service.WeightedCluster
+	idleTimeout      *time.Duration
 }
 
 type filterBuilder struct {
-	statsPrefix        string
-	withRBAC           bool
-	issuers            certificate.IssuerInfo
-	trafficTargets     []trafficpolicy.TrafficTargetWithRoutes
-	tcpLocalRateLimit  *policyv1alpha1.TCPLocalRateLimitSpec
-	tcpGlobalRateLimit *policyv1alpha1.TCPGlobalRateLimitSpec
-	hcmBuilder         *httpConnManagerBuilder
-	tcpProxyBuilder    *tcpProxyBuilder
+	statsPrefix    string
+	withRBAC       bool
+	issuers        certificate.IssuerInfo
+	trafficTargets []trafficpolicy.TrafficTargetWithRoutes
+	// principalsPerPolicy maps RBAC policy names to their allowed principals, in addition to the trafficTargets
+	principalsPerPolicy map[string][]string
+	// allowedSourceRanges are the source IP ranges the principals allowed by the RBAC policies must connect from
+	allowedSourceRanges []string
+	tcpLocalRateLimit   *policyv1alpha1.TCPLocalRateLimitSpec
+	tcpGlobalRateLimit  *policyv1alpha1.TCPGlobalRateLimitSpec
+	hcmBuilder          *httpConnManagerBuilder
+	tcpProxyBuilder     *tcpProxyBuilder
 }
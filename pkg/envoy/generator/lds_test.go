@@ -73,6 +73,7 @@ func TestGenerateLDS(t *testing.T) {
 	}).AnyTimes()
 	provider.EXPECT().GetResolvableEndpointsForService(gomock.Any()).Return([]endpoint.Endpoint{tests.Endpoint}).AnyTimes()
 	provider.EXPECT().GetHostnamesForService(gomock.Any(), gomock.Any()).Return([]string{"dummy-hostname"}).AnyTimes()
+	provider.EXPECT().ListSubdomainsForService(gomock.Any()).Return(nil).AnyTimes()
 	provider.EXPECT().IsMetricsEnabled(gomock.Any()).Return(true, nil).AnyTimes()
 	provider.EXPECT().GetMeshConfig().Return(configv1alpha2.MeshConfig{
 		Spec: configv1alpha2.MeshConfigSpec{
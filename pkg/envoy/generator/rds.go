@@ -32,8 +32,15 @@ func (g *EnvoyConfigGenerator) generateRDS(ctx context.Context, proxy *models.Pr
 		Proxy(proxy).
 		StatsHeaders(statsHeaders)
 
-	// Get HTTP route configs per port from inbound mesh traffic policy and pass to builder
-	routesBuilder.InboundPortSpecificRouteConfigs(g.catalog.GetInboundMeshHTTPRouteConfigsPerPort(proxy.Identity, proxyServices))
+	// Get HTTP route configs per port from inbound mesh traffic policy and pass to builder.
+	// The route configs that could be built are still programmed if some of the referenced policies are missing.
+	inboundRouteConfigsPerPort, err := g.catalog.GetInboundMeshHTTPRouteConfigsPerPortWithError(proxy.Identity, proxyServices)
+	if err != nil {
+		log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrFetchingSMIHTTPRouteGroupForTrafficTarget)).
+			Msgf("Error building inbound HTTP route configs for proxy %s, the route configs are partially built", proxy)
+	}
+	g.catalog.RecordInboundRulesPerPolicy(proxyServices, inboundRouteConfigsPerPort)
+	routesBuilder.InboundPortSpecificRouteConfigs(inboundRouteConfigsPerPort)
 
 	// Get HTTP route configs per port from outbound mesh traffic policy and pass to builder
 	routesBuilder.OutboundPortSpecificRouteConfigs(g.catalog.GetOutboundMeshHTTPRouteConfigsPerPort(proxy.Identity))
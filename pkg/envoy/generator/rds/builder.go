@@ -73,23 +73,35 @@ func (b *routesBuilder) buildInboundMeshRouteConfiguration() []*xds_route.RouteC
 	// An Envoy RouteConfiguration will exist for each HTTP upstream port.
 	// This is required to avoid route conflicts that can arise when the same host header
 	// has different routes on different destination ports for that host.
+	// Policies that require an SNI are programmed on a RouteConfiguration specific to the
+	// port and SNI, so that their routes only apply to connections negotiated with that SNI.
 	for port, configs := range b.inboundPortSpecificRouteConfigs {
-		routeConfig := newRouteConfigurationStub(GetInboundMeshRouteConfigNameForPort(port))
+		routeConfigPerServerName := make(map[string]*xds_route.RouteConfiguration)
+		var serverNames []string
 		for _, config := range configs {
+			routeConfig, ok := routeConfigPerServerName[config.ServerName]
+			if !ok {
+				routeConfig = newRouteConfigurationStub(GetInboundMeshRouteConfigNameForServerName(port, config.ServerName))
+				routeConfigPerServerName[config.ServerName] = routeConfig
+				serverNames = append(serverNames, config.ServerName)
+			}
 			virtualHost := buildVirtualHostStub(inboundVirtualHost, config.Name, config.Hostnames)
 			virtualHost.Routes = buildInboundRoutes(config.Rules)
 			applyInboundVirtualHostConfig(virtualHost, config)
 			routeConfig.VirtualHosts = append(routeConfig.VirtualHosts, virtualHost)
 		}
-		for k, v := range b.statsHeaders {
-			routeConfig.ResponseHeadersToAdd = append(routeConfig.ResponseHeadersToAdd, &core.HeaderValueOption{
-				Header: &core.HeaderValue{
-					Key:   k,
-					Value: v,
-				},
-			})
+		for _, serverName := range serverNames {
+			routeConfig := routeConfigPerServerName[serverName]
+			for k, v := range b.statsHeaders {
+				routeConfig.ResponseHeadersToAdd = append(routeConfig.ResponseHeadersToAdd, &core.HeaderValueOption{
+					Header: &core.HeaderValue{
+						Key:   k,
+						Value: v,
+					},
+				})
+			}
+			routeConfigs = append(routeConfigs, routeConfig)
 		}
-		routeConfigs = append(routeConfigs, routeConfig)
 	}
 
 	return routeConfigs
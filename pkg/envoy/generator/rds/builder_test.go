@@ -187,6 +187,111 @@ func TestBuildInboundMeshRouteConfiguration(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "inbound policy with VirtualHost level CORS policy and local rate limiting",
+			InboundMeshHTTPRouteConfigsPerPort: map[int][]*trafficpolicy.InboundTrafficPolicy{
+				80: {
+					{
+						Name:      "bookstore-v1-default",
+						Hostnames: []string{"bookstore-v1.default.svc.cluster.local"},
+						Rules: []*trafficpolicy.Rule{
+							{
+								Route: trafficpolicy.RouteWeightedClusters{
+									HTTPRouteMatch:   tests.BookstoreBuyHTTPRoute,
+									WeightedClusters: mapset.NewSet(tests.BookstoreV1DefaultWeightedCluster),
+								},
+								AllowedPrincipals: mapset.NewSet(identity.WildcardPrincipal),
+							},
+						},
+						RateLimit: &policyv1alpha1.RateLimitSpec{
+							Local: &policyv1alpha1.LocalRateLimitSpec{
+								HTTP: &policyv1alpha1.HTTPLocalRateLimitSpec{
+									Requests: 100,
+									Unit:     "minute",
+								},
+							},
+						},
+						Cors: &policyv1alpha1.CorsSpec{
+							AllowOrigins: []string{"https://foo.com", "https://bar.com"},
+							AllowMethods: []string{"GET", "POST"},
+						},
+					},
+				},
+			},
+			expectedRouteConfigFields: &xds_route.RouteConfiguration{
+				Name: "rds-inbound.80",
+				VirtualHosts: []*xds_route.VirtualHost{
+					{
+						Name: "inbound_virtual-host|bookstore-v1.default.svc.cluster.local",
+						Routes: []*xds_route.Route{
+							{
+								// corresponds to ingressPolicies[0].Rules[0]
+
+								// Only the filter name is matched, not the marshalled config
+								TypedPerFilterConfig: map[string]*any.Any{
+									envoy.HTTPRBACFilterName: nil,
+								},
+							},
+						},
+						// Only the filter name is matched, not the marshalled config
+						TypedPerFilterConfig: map[string]*any.Any{
+							envoy.HTTPLocalRateLimitFilterName: nil,
+						},
+						// Only the allowed methods and the number of allowed origins are matched
+						Cors: &xds_route.CorsPolicy{
+							AllowMethods:           "GET,POST",
+							AllowOriginStringMatch: []*xds_matcher.StringMatcher{nil, nil},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "inbound policy with VirtualHost level retry policy",
+			InboundMeshHTTPRouteConfigsPerPort: map[int][]*trafficpolicy.InboundTrafficPolicy{
+				80: {
+					{
+						Name:      "bookstore-v1-default",
+						Hostnames: []string{"bookstore-v1.default.svc.cluster.local"},
+						Rules: []*trafficpolicy.Rule{
+							{
+								Route: trafficpolicy.RouteWeightedClusters{
+									HTTPRouteMatch:   tests.BookstoreBuyHTTPRoute,
+									WeightedClusters: mapset.NewSet(tests.BookstoreV1DefaultWeightedCluster),
+								},
+								AllowedPrincipals: mapset.NewSet(identity.WildcardPrincipal),
+							},
+						},
+						RetryPolicy: &policyv1alpha1.RetryPolicySpec{
+							RetryOn:    "5xx",
+							NumRetries: pointer.Uint32Ptr(3),
+						},
+					},
+				},
+			},
+			expectedRouteConfigFields: &xds_route.RouteConfiguration{
+				Name: "rds-inbound.80",
+				VirtualHosts: []*xds_route.VirtualHost{
+					{
+						Name: "inbound_virtual-host|bookstore-v1.default.svc.cluster.local",
+						Routes: []*xds_route.Route{
+							{
+								// corresponds to ingressPolicies[0].Rules[0]
+
+								// Only the filter name is matched, not the marshalled config
+								TypedPerFilterConfig: map[string]*any.Any{
+									envoy.HTTPRBACFilterName: nil,
+								},
+							},
+						},
+						RetryPolicy: &xds_route.RetryPolicy{
+							RetryOn:    "5xx",
+							NumRetries: &wrappers.UInt32Value{Value: 3},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "inbound policy with VirtualHost and Route level global rate limiting",
 			InboundMeshHTTPRouteConfigsPerPort: map[int][]*trafficpolicy.InboundTrafficPolicy{
@@ -329,6 +434,10 @@ func TestBuildInboundMeshRouteConfiguration(t *testing.T) {
 				for i, vh := range routeConfig.VirtualHosts {
 					assert.Len(vh.Routes, len(tc.expectedRouteConfigFields.VirtualHosts[i].Routes))
 					assert.Len(vh.RateLimits, len(tc.expectedRouteConfigFields.VirtualHosts[i].RateLimits))
+					assert.Equal(tc.expectedRouteConfigFields.VirtualHosts[i].GetRetryPolicy().GetRetryOn(), vh.GetRetryPolicy().GetRetryOn())
+					assert.Equal(tc.expectedRouteConfigFields.VirtualHosts[i].GetRetryPolicy().GetNumRetries().GetValue(), vh.GetRetryPolicy().GetNumRetries().GetValue())
+					assert.Equal(tc.expectedRouteConfigFields.VirtualHosts[i].GetCors().GetAllowMethods(), vh.GetCors().GetAllowMethods())
+					assert.Len(vh.GetCors().GetAllowOriginStringMatch(), len(tc.expectedRouteConfigFields.VirtualHosts[i].GetCors().GetAllowOriginStringMatch()))
 
 					// Verify that the expected typed filters on the VirtualHost are present
 					for filter := range tc.expectedRouteConfigFields.VirtualHosts[i].TypedPerFilterConfig {
@@ -406,6 +515,55 @@ func TestBuildInboundMeshRouteConfiguration(t *testing.T) {
 	}
 }
 
+func TestBuildInboundMeshRouteConfigurationPerServerName(t *testing.T) {
+	assert := tassert.New(t)
+
+	newPolicy := func(name string, serverName string) *trafficpolicy.InboundTrafficPolicy {
+		return &trafficpolicy.InboundTrafficPolicy{
+			Name:       name,
+			Hostnames:  []string{name},
+			ServerName: serverName,
+			Rules: []*trafficpolicy.Rule{
+				{
+					Route: trafficpolicy.RouteWeightedClusters{
+						HTTPRouteMatch:   tests.BookstoreBuyHTTPRoute,
+						WeightedClusters: mapset.NewSet(tests.BookstoreV1DefaultWeightedCluster),
+					},
+					AllowedPrincipals: mapset.NewSet(identity.WildcardPrincipal),
+				},
+			},
+		}
+	}
+
+	rb := &routesBuilder{
+		inboundPortSpecificRouteConfigs: map[int][]*trafficpolicy.InboundTrafficPolicy{
+			8080: {
+				newPolicy("tenant-a", "tenant-a.example.com"),
+				newPolicy("tenant-b", "tenant-b.example.com"),
+				newPolicy("no-sni", ""),
+			},
+		},
+		proxy: &models.Proxy{},
+	}
+	actual := rb.buildInboundMeshRouteConfiguration()
+
+	// Policies requiring different SNIs on the same port are programmed on separate route configurations
+	routeConfigVirtualHosts := make(map[string][]string)
+	for _, routeConfig := range actual {
+		for _, virtualHost := range routeConfig.VirtualHosts {
+			routeConfigVirtualHosts[routeConfig.Name] = append(routeConfigVirtualHosts[routeConfig.Name], virtualHost.Name)
+		}
+	}
+	assert.Equal(map[string][]string{
+		"rds-inbound.8080.tenant-a.example.com": {"inbound_virtual-host|tenant-a"},
+		"rds-inbound.8080.tenant-b.example.com": {"inbound_virtual-host|tenant-b"},
+		"rds-inbound.8080":                      {"inbound_virtual-host|no-sni"},
+	}, routeConfigVirtualHosts)
+
+	assert.Equal("rds-inbound.8080", GetInboundMeshRouteConfigNameForServerName(8080, ""))
+	assert.Equal("rds-inbound.8080.tenant-a.example.com", GetInboundMeshRouteConfigNameForServerName(8080, "tenant-a.example.com"))
+}
+
 func TestBuildIngressRouteConfiguration(t *testing.T) {
 	testCases := []struct {
 		name                      string
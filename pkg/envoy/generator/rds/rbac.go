@@ -2,6 +2,8 @@ package rds
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 
 	xds_rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
 	xds_http_rbac "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
@@ -18,7 +20,9 @@ const (
 
 // buildInboundRBACFilterForRule builds an HTTP RBAC per route filter based on the given traffic policy rule.
 // The principals in the RBAC policy are derived from the allowed service accounts specified in the given rule.
-// The permissions in the RBAC policy are implicitly set to ANY (all permissions).
+// The allowed principals must connect from one of the source ranges allowed by the rule, if any, and the
+// principals denied by the rule are denied even if they are allowed. The permissions in the RBAC policy are
+// implicitly set to ANY (all permissions), unless the rule requires headers, in which case every required header must match.
 func buildInboundRBACFilterForRule(rule *trafficpolicy.Rule) (*any.Any, error) {
 	if rule.AllowedPrincipals == nil {
 		return nil, errors.New("traffipolicy.Rule.AllowedPrincipals not set")
@@ -31,6 +35,39 @@ func buildInboundRBACFilterForRule(rule *trafficpolicy.Rule) (*any.Any, error) {
 		pb.AddPrincipal(downstream.(string))
 	}
 
+	for _, sourceRange := range rule.AllowedSourceRanges {
+		if err := pb.AddAllowedSourceRange(sourceRange); err != nil {
+			return nil, fmt.Errorf("invalid allowed source range %q: %w", sourceRange, err)
+		}
+	}
+
+	// Deny the denied principals in a deterministic order, denials take precedence over the allowed principals
+	if rule.DeniedPrincipals != nil {
+		deniedPrincipals := make([]string, 0, rule.DeniedPrincipals.Cardinality())
+		for downstream := range rule.DeniedPrincipals.Iter() {
+			deniedPrincipals = append(deniedPrincipals, downstream.(string))
+		}
+		sort.Strings(deniedPrincipals)
+		for _, downstream := range deniedPrincipals {
+			pb.AddDeniedPrincipal(downstream)
+		}
+	}
+
+	// Require the headers in a deterministic order
+	headerNames := make([]string, 0, len(rule.RequiredHeaders))
+	for name := range rule.RequiredHeaders {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	for _, name := range headerNames {
+		headerKey := name
+		if name == httpHostHeaderKey {
+			// The host header is matched using the :authority pseudo-header, as done for routes
+			headerKey = authorityHeaderKey
+		}
+		pb.AddRequiredHeader(headerKey, rule.RequiredHeaders[name])
+	}
+
 	// A single RBAC policy per route
 	rbacPolicyMap := map[string]*xds_rbac.Policy{rbacPerRoutePolicyName: pb.Build()}
 
@@ -5,9 +5,13 @@ import (
 	"testing"
 
 	mapset "github.com/deckarep/golang-set"
+	xds_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	xds_rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	xds_route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	xds_http_rbac "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
+	xds_matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	tassert "github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"github.com/openservicemesh/osm/pkg/envoy/rbac"
 	"github.com/openservicemesh/osm/pkg/identity"
@@ -72,6 +76,156 @@ func TestBuildInboundRBACFilterForRule(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "valid trafficpolicy rule which allows all downstream identities except denied ones",
+			rule: &trafficpolicy.Rule{
+				Route: trafficpolicy.RouteWeightedClusters{
+					HTTPRouteMatch:   tests.BookstoreBuyHTTPRoute,
+					WeightedClusters: mapset.NewSet(tests.BookstoreV1DefaultWeightedCluster),
+				},
+				AllowedPrincipals: mapset.NewSet(identity.WildcardPrincipal),
+				DeniedPrincipals: mapset.NewSet(
+					identity.K8sServiceAccount{Name: "foo", Namespace: "ns-1"}.AsPrincipal("cluster.local", false),
+				),
+			},
+			expectedRBACPolicy: &xds_rbac.Policy{
+				Principals: []*xds_rbac.Principal{
+					{
+						Identifier: &xds_rbac.Principal_AndIds{
+							AndIds: &xds_rbac.Principal_Set{
+								Ids: []*xds_rbac.Principal{
+									{
+										Identifier: &xds_rbac.Principal_Any{Any: true},
+									},
+									{
+										Identifier: &xds_rbac.Principal_NotId{
+											NotId: rbac.GetAuthenticatedPrincipal("foo.ns-1.cluster.local"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				Permissions: []*xds_rbac.Permission{
+					{
+						Rule: &xds_rbac.Permission_Any{Any: true},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "valid trafficpolicy rule with restricted downstream identities and source ranges",
+			rule: &trafficpolicy.Rule{
+				Route: trafficpolicy.RouteWeightedClusters{
+					HTTPRouteMatch:   tests.BookstoreBuyHTTPRoute,
+					WeightedClusters: mapset.NewSet(tests.BookstoreV1DefaultWeightedCluster),
+				},
+				AllowedPrincipals: mapset.NewSet(
+					identity.K8sServiceAccount{Name: "foo", Namespace: "ns-1"}.AsPrincipal("cluster.local", false),
+				),
+				AllowedSourceRanges: []string{"10.0.0.0/24"},
+			},
+			expectedRBACPolicy: &xds_rbac.Policy{
+				Principals: []*xds_rbac.Principal{
+					{
+						Identifier: &xds_rbac.Principal_AndIds{
+							AndIds: &xds_rbac.Principal_Set{
+								Ids: []*xds_rbac.Principal{
+									rbac.GetAuthenticatedPrincipal("foo.ns-1.cluster.local"),
+									{
+										Identifier: &xds_rbac.Principal_DirectRemoteIp{
+											DirectRemoteIp: &xds_core.CidrRange{AddressPrefix: "10.0.0.0", PrefixLen: wrapperspb.UInt32(24)},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				Permissions: []*xds_rbac.Permission{
+					{
+						Rule: &xds_rbac.Permission_Any{Any: true},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid trafficpolicy rule with an invalid source range",
+			rule: &trafficpolicy.Rule{
+				Route: trafficpolicy.RouteWeightedClusters{
+					HTTPRouteMatch:   tests.BookstoreBuyHTTPRoute,
+					WeightedClusters: mapset.NewSet(tests.BookstoreV1DefaultWeightedCluster),
+				},
+				AllowedPrincipals:   mapset.NewSet(identity.WildcardPrincipal),
+				AllowedSourceRanges: []string{"10.0.0.1"},
+			},
+			expectedRBACPolicy: nil,
+			expectError:        true,
+		},
+		{
+			name: "valid trafficpolicy rule with required headers",
+			rule: &trafficpolicy.Rule{
+				Route: trafficpolicy.RouteWeightedClusters{
+					HTTPRouteMatch:   tests.BookstoreBuyHTTPRoute,
+					WeightedClusters: mapset.NewSet(tests.BookstoreV1DefaultWeightedCluster),
+				},
+				AllowedPrincipals: mapset.NewSet(
+					identity.K8sServiceAccount{Name: "foo", Namespace: "ns-1"}.AsPrincipal("cluster.local", false),
+				),
+				RequiredHeaders: map[string]string{
+					"user-agent": "test-agent",
+					"host":       "bookstore.*",
+				},
+			},
+			expectedRBACPolicy: &xds_rbac.Policy{
+				Principals: []*xds_rbac.Principal{
+					rbac.GetAuthenticatedPrincipal("foo.ns-1.cluster.local"),
+				},
+				Permissions: []*xds_rbac.Permission{
+					{
+						Rule: &xds_rbac.Permission_AndRules{
+							AndRules: &xds_rbac.Permission_Set{
+								Rules: []*xds_rbac.Permission{
+									{
+										Rule: &xds_rbac.Permission_Any{Any: true},
+									},
+									{
+										Rule: &xds_rbac.Permission_Header{
+											Header: &xds_route.HeaderMatcher{
+												Name: ":authority",
+												HeaderMatchSpecifier: &xds_route.HeaderMatcher_SafeRegexMatch{
+													SafeRegexMatch: &xds_matcher.RegexMatcher{
+														EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
+														Regex:      "bookstore.*",
+													},
+												},
+											},
+										},
+									},
+									{
+										Rule: &xds_rbac.Permission_Header{
+											Header: &xds_route.HeaderMatcher{
+												Name: "user-agent",
+												HeaderMatchSpecifier: &xds_route.HeaderMatcher_SafeRegexMatch{
+													SafeRegexMatch: &xds_matcher.RegexMatcher{
+														EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
+														Regex:      "test-agent",
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
 		{
 			name: "invalid trafficpolicy rule with Rule.AllowedPrincipals not specified",
 			rule: &trafficpolicy.Rule{
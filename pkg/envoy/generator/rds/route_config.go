@@ -3,11 +3,15 @@ package rds
 import (
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	mapset "github.com/deckarep/golang-set"
 	xds_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	xds_route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	xds_fault "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/common/fault/v3"
+	xds_http_fault "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/fault/v3"
 	xds_http_local_ratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
 	xds_matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	xds_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
@@ -16,6 +20,7 @@ import (
 	"github.com/golang/protobuf/ptypes/wrappers"
 	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
@@ -49,7 +54,25 @@ const (
 	// authorityHeaderKey is the key corresponding to the HTTP Host/Authority header programmed as a header matcher in an Envoy route
 	authorityHeaderKey = ":authority"
 
+	// pathHeaderKey is the key of the pseudo-header for the HTTP request path
+	pathHeaderKey = ":path"
+
+	// defaultPathDescriptorKey is the default key of the rate limit descriptor entry generated for the request path
+	defaultPathDescriptorKey = "path"
+
+	// wildcardCorsOrigin is the CORS origin that allows all origins
+	wildcardCorsOrigin = "*"
+
+	// websocketUpgradeType is the upgrade type for WebSocket connections
+	websocketUpgradeType = "websocket"
+
+	// caseInsensitiveRegexFlag is the RE2 flag that makes a regex case insensitive
+	caseInsensitiveRegexFlag = "(?i)"
+
 	httpLocalRateLimiterStatsPrefix = "http_local_rate_limiter"
+
+	// lbSubsetMetadataNamespace is the filter metadata namespace of the endpoint metadata matched by Envoy's subset load balancer
+	lbSubsetMetadataNamespace = "envoy.lb"
 )
 
 // applyInboundVirtualHostConfig updates the VirtualHost configuration based on the given policy
@@ -73,6 +96,27 @@ func applyInboundVirtualHostConfig(vhost *xds_route.VirtualHost, policy *traffic
 		vhost.RateLimits = getGlobalRateLimitConfig(policy.RateLimit.Global.HTTP.Descriptors)
 	}
 
+	// Apply VirtualHost level retry policy, routes without a retry policy inherit it
+	vhost.RetryPolicy = buildRetryPolicy(policy.RetryPolicy)
+
+	// Apply VirtualHost level CORS policy
+	vhost.Cors = buildCorsPolicy(policy.Cors)
+
+	// Apply VirtualHost level response header manipulation, Envoy removes headers before adding them
+	vhost.ResponseHeadersToRemove = policy.ResponseHeadersToRemove
+	vhost.ResponseHeadersToAdd = getResponseHeaderValueOptions(policy.ResponseHeadersToAdd)
+
+	// Allow WebSocket upgrades on all routes of the VirtualHost
+	if policy.AllowWebSocketUpgrade {
+		for _, route := range vhost.Routes {
+			if routeAction := route.GetRoute(); routeAction != nil {
+				routeAction.UpgradeConfigs = []*xds_route.RouteAction_UpgradeConfig{
+					{UpgradeType: websocketUpgradeType},
+				}
+			}
+		}
+	}
+
 	vhost.TypedPerFilterConfig = config
 }
 
@@ -93,6 +137,9 @@ func getLocalRateLimitFilterConfig(config *policyv1alpha1.HTTPLocalRateLimitSpec
 	default:
 		return nil, fmt.Errorf("invalid unit %q for HTTP request rate limiting", config.Unit)
 	}
+	if config.FillInterval != nil {
+		fillInterval = config.FillInterval.Duration
+	}
 
 	rl := &xds_http_local_ratelimit.LocalRateLimit{
 		StatPrefix: httpLocalRateLimiterStatsPrefix,
@@ -116,10 +163,12 @@ func getLocalRateLimitFilterConfig(config *policyv1alpha1.HTTPLocalRateLimitSpec
 		},
 	}
 
-	// Set the response status code if not specified. Envoy defaults to 429 (Too Many Requests).
+	// Rate limited requests are responded to with 429 (Too Many Requests) unless a status code is specified
+	statusCode := xds_type.StatusCode_TooManyRequests
 	if config.ResponseStatusCode > 0 {
-		rl.Status = &xds_type.HttpStatus{Code: xds_type.StatusCode(config.ResponseStatusCode)}
+		statusCode = xds_type.StatusCode(config.ResponseStatusCode)
 	}
+	rl.Status = &xds_type.HttpStatus{Code: statusCode}
 
 	marshalled, err := anypb.New(rl)
 	if err != nil {
@@ -179,6 +228,20 @@ func getGlobalRateLimitConfig(descriptors []policyv1alpha1.HTTPGlobalRateLimitDe
 						},
 					},
 				})
+
+			case entry.RequestPath != nil:
+				descriptorKey := entry.RequestPath.Key
+				if descriptorKey == "" {
+					descriptorKey = defaultPathDescriptorKey
+				}
+				rl.Actions = append(rl.Actions, &xds_route.RateLimit_Action{
+					ActionSpecifier: &xds_route.RateLimit_Action_RequestHeaders_{
+						RequestHeaders: &xds_route.RateLimit_Action_RequestHeaders{
+							HeaderName:    pathHeaderKey,
+							DescriptorKey: descriptorKey,
+						},
+					},
+				})
 			}
 		}
 
@@ -248,6 +311,32 @@ func getHeaderMatchers(headers []policyv1alpha1.HTTPHeaderMatcher) []*xds_route.
 	return headerMatchers
 }
 
+// getRequestHeaderValueOptions returns a list of HeaderValueOption objects, sorted by header name, corresponding
+// to the given request headers
+func getRequestHeaderValueOptions(headers service.RequestHeaders) []*xds_core.HeaderValueOption {
+	headerMap := headers.Map()
+	names := make([]string, 0, len(headerMap))
+	for name := range headerMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var hvOptions []*xds_core.HeaderValueOption
+	for _, name := range names {
+		hvOptions = append(hvOptions, &xds_core.HeaderValueOption{
+			Header: &xds_core.HeaderValue{
+				Key:   name,
+				Value: headerMap[name],
+			},
+			Append: &wrappers.BoolValue{
+				Value: false,
+			},
+		})
+	}
+
+	return hvOptions
+}
+
 // getRateLimitHeaderValueOptions returns a list of HeaderValueOption objects corresponding
 // to the given list of rate limiting HTTPHeaderValue objects
 func getRateLimitHeaderValueOptions(headerValues []policyv1alpha1.HTTPHeaderValue) []*xds_core.HeaderValueOption {
@@ -268,6 +357,28 @@ func getRateLimitHeaderValueOptions(headerValues []policyv1alpha1.HTTPHeaderValu
 	return hvOptions
 }
 
+// getResponseHeaderValueOptions returns the header value options adding the given headers to the responses.
+// Repeated headers are appended so that each of their values is added.
+func getResponseHeaderValueOptions(headerValues []policyv1alpha1.HTTPHeaderValue) []*xds_core.HeaderValueOption {
+	var hvOptions []*xds_core.HeaderValueOption
+	headerNames := mapset.NewSet()
+
+	for _, hv := range headerValues {
+		hvOptions = append(hvOptions, &xds_core.HeaderValueOption{
+			Header: &xds_core.HeaderValue{
+				Key:   hv.Name,
+				Value: hv.Value,
+			},
+			// The first value of a header overwrites the upstream's value
+			Append: &wrappers.BoolValue{
+				Value: !headerNames.Add(hv.Name),
+			},
+		})
+	}
+
+	return hvOptions
+}
+
 // newRouteConfigurationStub creates the route configuration placeholder
 func newRouteConfigurationStub(routeConfigName string) *xds_route.RouteConfiguration {
 	routeConfiguration := xds_route.RouteConfiguration{
@@ -311,14 +422,15 @@ func buildInboundRoutes(rules []*trafficpolicy.Rule) []*xds_route.Route {
 		// Each HTTP method corresponds to a separate route
 		for _, method := range allowedMethods {
 			route := buildRoute(rule.Route, method)
-			applyInboundRouteConfig(route, rbacConfig, rule.Route.RateLimit)
+			applyInboundRouteConfig(route, rbacConfig, rule.Route.RateLimit, rule.Route.FaultInjection)
 			routes = append(routes, route)
 		}
 	}
 	return routes
 }
 
-func applyInboundRouteConfig(route *xds_route.Route, rbacConfig *any.Any, rateLimit *policyv1alpha1.HTTPPerRouteRateLimitSpec) {
+func applyInboundRouteConfig(route *xds_route.Route, rbacConfig *any.Any, rateLimit *policyv1alpha1.HTTPPerRouteRateLimitSpec,
+	faultInjection *policyv1alpha1.HTTPFaultInjectionSpec) {
 	if route == nil {
 		return
 	}
@@ -337,9 +449,50 @@ func applyInboundRouteConfig(route *xds_route.Route, rbacConfig *any.Any, rateLi
 		}
 	}
 
+	// Apply fault injection policy, scoped to this route only
+	if faultInjection != nil {
+		if filter, err := getFaultFilterConfig(faultInjection); err != nil {
+			log.Error().Err(err).Msgf("Error applying fault injection config for route path %s, ignoring it", route.GetMatch().GetPath())
+		} else {
+			perFilterConfig[envoy.HTTPFaultFilterName] = filter
+		}
+	}
+
 	route.TypedPerFilterConfig = perFilterConfig
 }
 
+// getFaultFilterConfig returns the marshalled HTTP fault filter config for the given fault injection policy
+func getFaultFilterConfig(faultInjection *policyv1alpha1.HTTPFaultInjectionSpec) (*any.Any, error) {
+	if faultInjection.Abort == nil && faultInjection.Delay == nil {
+		return nil, fmt.Errorf("fault injection must specify an abort or a delay fault")
+	}
+
+	percentage := &xds_type.FractionalPercent{
+		Numerator:   faultInjection.Percentage,
+		Denominator: xds_type.FractionalPercent_HUNDRED,
+	}
+
+	fault := &xds_http_fault.HTTPFault{}
+	if faultInjection.Abort != nil {
+		fault.Abort = &xds_http_fault.FaultAbort{
+			ErrorType: &xds_http_fault.FaultAbort_HttpStatus{
+				HttpStatus: faultInjection.Abort.HTTPStatus,
+			},
+			Percentage: percentage,
+		}
+	}
+	if faultInjection.Delay != nil {
+		fault.Delay = &xds_fault.FaultDelay{
+			FaultDelaySecifier: &xds_fault.FaultDelay_FixedDelay{
+				FixedDelay: durationpb.New(faultInjection.Delay.FixedDelay.Duration),
+			},
+			Percentage: percentage,
+		}
+	}
+
+	return anypb.New(fault)
+}
+
 // buildOutboundRoutes takes route information from the given outbound traffic policy and returns a list of xds routes
 func buildOutboundRoutes(outRoutes []*trafficpolicy.RouteWeightedClusters) []*xds_route.Route {
 	var routes []*xds_route.Route
@@ -383,9 +536,35 @@ func buildRoute(weightedClusters trafficpolicy.RouteWeightedClusters, method str
 
 	route := xds_route.Route{
 		Match: &xds_route.RouteMatch{
-			Headers: getHeadersForRoute(method, weightedClusters.HTTPRouteMatch.Headers),
+			Headers:         getHeadersForRoute(method, weightedClusters.HTTPRouteMatch.Headers, weightedClusters.HTTPRouteMatch.HeaderMatchType),
+			QueryParameters: getQueryParametersForRoute(weightedClusters.HTTPRouteMatch.QueryParams),
 		},
-		Action: &xds_route.Route_Route{
+		StatPrefix: weightedClusters.StatPrefix,
+	}
+
+	if authority := weightedClusters.HTTPRouteMatch.Authority; authority != "" {
+		// The authority of the route takes precedence over a host header match
+		var headers []*xds_route.HeaderMatcher
+		for _, header := range route.Match.Headers {
+			if header.Name != authorityHeaderKey {
+				headers = append(headers, header)
+			}
+		}
+		route.Match.Headers = append(headers, getRouteHeaderMatcher(authorityHeaderKey, authority, trafficpolicy.HeaderMatchExact))
+	}
+
+	if weightedClusters.Redirect != nil {
+		// Requests matching the route are redirected instead of being routed to the clusters
+		route.Action = &xds_route.Route_Redirect{
+			Redirect: buildRedirectAction(weightedClusters.Redirect),
+		}
+	} else if weightedClusters.DirectResponse != nil {
+		// Requests matching the route are answered with a fixed response instead of being routed to the clusters
+		route.Action = &xds_route.Route_DirectResponse{
+			DirectResponse: buildDirectResponseAction(weightedClusters.DirectResponse),
+		}
+	} else {
+		route.Action = &xds_route.Route_Route{
 			Route: &xds_route.RouteAction{
 				ClusterSpecifier: &xds_route.RouteAction_WeightedClusters{
 					WeightedClusters: buildWeightedCluster(weightedClusters.WeightedClusters),
@@ -396,15 +575,74 @@ func buildRoute(weightedClusters trafficpolicy.RouteWeightedClusters, method str
 				RetryPolicy: buildRetryPolicy(weightedClusters.RetryPolicy),
 				RateLimits:  getGlobalRateLimitConfig(getPerRouteRateLimitDescriptors(weightedClusters.RateLimit)),
 			},
-		},
+		}
+
+		if weightedClusters.Timeout != nil {
+			// The route timeout overrides the mesh default, a timeout of 0 disables the timeout
+			route.GetRoute().Timeout = durationpb.New(*weightedClusters.Timeout)
+		}
+
+		if len(weightedClusters.RequestMirrorPolicies) > 0 {
+			// Mirrored requests are not counted in the weight distribution of the weighted clusters
+			route.GetRoute().RequestMirrorPolicies = buildRequestMirrorPolicies(weightedClusters.RequestMirrorPolicies)
+		}
+
+		if weightedClusters.RewritePrefix != "" {
+			route.GetRoute().PrefixRewrite = weightedClusters.RewritePrefix
+		}
+
+		if hostRewrite := weightedClusters.HostRewrite; hostRewrite != nil {
+			if hostRewrite.Literal != "" {
+				route.GetRoute().HostRewriteSpecifier = &xds_route.RouteAction_HostRewriteLiteral{
+					HostRewriteLiteral: hostRewrite.Literal,
+				}
+			} else if hostRewrite.AutoToUpstream {
+				route.GetRoute().HostRewriteSpecifier = &xds_route.RouteAction_AutoHostRewrite{
+					AutoHostRewrite: &wrappers.BoolValue{Value: true},
+				}
+			}
+		}
+
+		// Requests with the same hash keys are sent to the same endpoint of a consistent hashing cluster
+		route.GetRoute().HashPolicy = buildHashPolicy(weightedClusters.HashKeyHeader, weightedClusters.HashKeyCookie)
+
+		if weightedClusters.RuntimeKeyPrefix != "" {
+			// Allow the weights of the clusters to be overridden at runtime, defaulting to the configured weights
+			if wc := route.GetRoute().GetWeightedClusters(); wc != nil {
+				wc.RuntimeKeyPrefix = weightedClusters.RuntimeKeyPrefix
+			}
+		}
+	}
+
+	if weightedClusters.RequireClientCertificate {
+		// Only match requests over connections with a client certificate that was presented and validated
+		route.Match.TlsContext = &xds_route.RouteMatch_TlsContextMatchOptions{
+			Presented: wrapperspb.Bool(true),
+			Validated: wrapperspb.Bool(true),
+		}
+	}
+
+	if weightedClusters.HTTPRouteMatch.GRPC {
+		// Only match gRPC requests, i.e. requests with a gRPC content-type
+		route.Match.Grpc = &xds_route.RouteMatch_GrpcRouteMatchOptions{}
+	}
+
+	caseSensitive := weightedClusters.HTTPRouteMatch.IsCaseSensitive()
+	if !caseSensitive {
+		route.Match.CaseSensitive = wrapperspb.Bool(false)
 	}
 
 	switch weightedClusters.HTTPRouteMatch.PathMatchType {
 	case trafficpolicy.PathMatchRegex:
+		regex := weightedClusters.HTTPRouteMatch.Path
+		if !caseSensitive {
+			// Envoy ignores case_sensitive for regex paths, the regex is made case insensitive instead
+			regex = caseInsensitiveRegexFlag + regex
+		}
 		route.Match.PathSpecifier = &xds_route.RouteMatch_SafeRegex{
 			SafeRegex: &xds_matcher.RegexMatcher{
 				EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
-				Regex:      weightedClusters.HTTPRouteMatch.Path,
+				Regex:      regex,
 			},
 		}
 
@@ -422,15 +660,67 @@ func buildRoute(weightedClusters trafficpolicy.RouteWeightedClusters, method str
 	return &route
 }
 
+// buildRedirectAction returns the xds redirect action for the given route redirect
+func buildRedirectAction(redirect *trafficpolicy.RouteRedirect) *xds_route.RedirectAction {
+	redirectAction := &xds_route.RedirectAction{}
+	if redirect.HTTPSRedirect {
+		redirectAction.SchemeRewriteSpecifier = &xds_route.RedirectAction_HttpsRedirect{HttpsRedirect: true}
+	}
+	return redirectAction
+}
+
+// buildDirectResponseAction returns the xds direct response action for the given direct response
+func buildDirectResponseAction(directResponse *policyv1alpha1.DirectResponseSpec) *xds_route.DirectResponseAction {
+	directResponseAction := &xds_route.DirectResponseAction{
+		Status: directResponse.StatusCode,
+	}
+	if directResponse.Body != "" {
+		directResponseAction.Body = &xds_core.DataSource{
+			Specifier: &xds_core.DataSource_InlineString{InlineString: directResponse.Body},
+		}
+	}
+	return directResponseAction
+}
+
+// buildRequestMirrorPolicies returns the request mirror policies of a route mirroring a percentage of its requests
+// to the clusters of the given policies
+func buildRequestMirrorPolicies(policies []trafficpolicy.RequestMirrorPolicy) []*xds_route.RouteAction_RequestMirrorPolicy {
+	var requestMirrorPolicies []*xds_route.RouteAction_RequestMirrorPolicy
+	for _, policy := range policies {
+		requestMirrorPolicies = append(requestMirrorPolicies, &xds_route.RouteAction_RequestMirrorPolicy{
+			Cluster: policy.ClusterName.String(),
+			RuntimeFraction: &xds_core.RuntimeFractionalPercent{
+				DefaultValue: &xds_type.FractionalPercent{
+					Numerator:   policy.Percentage,
+					Denominator: xds_type.FractionalPercent_HUNDRED,
+				},
+			},
+		})
+	}
+	return requestMirrorPolicies
+}
+
 func buildWeightedCluster(weightedClusters mapset.Set) *xds_route.WeightedCluster {
 	var wc xds_route.WeightedCluster
 	var total int
+
+	var clusters []service.WeightedCluster
 	for clusterInterface := range weightedClusters.Iter() {
-		cluster := clusterInterface.(service.WeightedCluster)
+		clusters = append(clusters, clusterInterface.(service.WeightedCluster))
+	}
+	// Subsets of the same cluster with the same weight are ordered by their label selectors, which is
+	// preserved by the stable sort by name and weight below
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].LabelSelector < clusters[j].LabelSelector
+	})
+
+	for _, cluster := range clusters {
 		total += cluster.Weight
 		wc.Clusters = append(wc.Clusters, &xds_route.WeightedCluster_ClusterWeight{
-			Name:   cluster.ClusterName.String(),
-			Weight: &wrappers.UInt32Value{Value: uint32(cluster.Weight)},
+			Name:                cluster.ClusterName.String(),
+			Weight:              &wrappers.UInt32Value{Value: uint32(cluster.Weight)},
+			RequestHeadersToAdd: getRequestHeaderValueOptions(cluster.RequestHeadersToAdd),
+			MetadataMatch:       getSubsetMetadataMatch(cluster.LabelSelector),
 		})
 	}
 
@@ -444,6 +734,54 @@ func buildWeightedCluster(weightedClusters mapset.Set) *xds_route.WeightedCluste
 	return &wc
 }
 
+// getSubsetMetadataMatch returns the metadata matching the endpoints of the subset selected by the given label selector,
+// or nil if the label selector is empty
+func getSubsetMetadataMatch(selector service.LabelSelector) *xds_core.Metadata {
+	labels := selector.Map()
+	if len(labels) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]*structpb.Value, len(labels))
+	for key, value := range labels {
+		fields[key] = structpb.NewStringValue(value)
+	}
+	return &xds_core.Metadata{
+		FilterMetadata: map[string]*structpb.Struct{
+			lbSubsetMetadataNamespace: {Fields: fields},
+		},
+	}
+}
+
+// buildHashPolicy returns the hash policy hashing the given header and cookie, or nil if neither is set
+func buildHashPolicy(header string, cookie *policyv1alpha1.HashKeyCookieSpec) []*xds_route.RouteAction_HashPolicy {
+	var hashPolicy []*xds_route.RouteAction_HashPolicy
+	if header != "" {
+		hashPolicy = append(hashPolicy, &xds_route.RouteAction_HashPolicy{
+			PolicySpecifier: &xds_route.RouteAction_HashPolicy_Header_{
+				Header: &xds_route.RouteAction_HashPolicy_Header{
+					HeaderName: header,
+				},
+			},
+		})
+	}
+	if cookie != nil {
+		hashCookie := &xds_route.RouteAction_HashPolicy_Cookie{
+			Name: cookie.Name,
+		}
+		if cookie.TTL != nil {
+			// Envoy generates the cookie for requests without it
+			hashCookie.Ttl = durationpb.New(cookie.TTL.Duration)
+		}
+		hashPolicy = append(hashPolicy, &xds_route.RouteAction_HashPolicy{
+			PolicySpecifier: &xds_route.RouteAction_HashPolicy_Cookie_{
+				Cookie: hashCookie,
+			},
+		})
+	}
+	return hashPolicy
+}
+
 // TODO: Add validation webhook for retry policy
 // Remove checks when validation webhook is implemented
 func buildRetryPolicy(retry *policyv1alpha1.RetryPolicySpec) *xds_route.RetryPolicy {
@@ -475,6 +813,48 @@ func buildRetryPolicy(retry *policyv1alpha1.RetryPolicySpec) *xds_route.RetryPol
 	return rp
 }
 
+// buildCorsPolicy returns the Envoy CORS policy corresponding to the given CorsSpec
+func buildCorsPolicy(cors *policyv1alpha1.CorsSpec) *xds_route.CorsPolicy {
+	if cors == nil {
+		return nil
+	}
+
+	cp := &xds_route.CorsPolicy{
+		AllowMethods: strings.Join(cors.AllowMethods, ","),
+		AllowHeaders: strings.Join(cors.AllowHeaders, ","),
+	}
+
+	for _, origin := range cors.AllowOrigins {
+		if origin == wildcardCorsOrigin {
+			cp.AllowOriginStringMatch = append(cp.AllowOriginStringMatch, &xds_matcher.StringMatcher{
+				MatchPattern: &xds_matcher.StringMatcher_SafeRegex{
+					SafeRegex: &xds_matcher.RegexMatcher{
+						EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
+						Regex:      constants.RegexMatchAll,
+					},
+				},
+			})
+			continue
+		}
+		cp.AllowOriginStringMatch = append(cp.AllowOriginStringMatch, &xds_matcher.StringMatcher{
+			MatchPattern: &xds_matcher.StringMatcher_Exact{
+				Exact: origin,
+			},
+		})
+	}
+
+	// MaxAge is expressed in seconds
+	if cors.MaxAge != nil {
+		cp.MaxAge = strconv.FormatInt(int64(cors.MaxAge.Duration.Seconds()), 10)
+	}
+
+	if cors.AllowCredentials {
+		cp.AllowCredentials = wrapperspb.Bool(true)
+	}
+
+	return cp
+}
+
 // sanitizeHTTPMethods takes in a list of HTTP methods including a wildcard (*) and returns a wildcard if any of
 // the methods is a wildcard or sanitizes the input list to avoid duplicates.
 func sanitizeHTTPMethods(allowedMethods []string) []string {
@@ -505,7 +885,7 @@ func (c clusterWeightByName) Less(i, j int) bool {
 	return c[i].Name < c[j].Name
 }
 
-func getHeadersForRoute(method string, headersMap map[string]string) []*xds_route.HeaderMatcher {
+func getHeadersForRoute(method string, headersMap map[string]string, matchType trafficpolicy.HeaderMatchType) []*xds_route.HeaderMatcher {
 	var headers []*xds_route.HeaderMatcher
 
 	// add methods header
@@ -522,16 +902,7 @@ func getHeadersForRoute(method string, headersMap map[string]string) []*xds_rout
 
 	// add host headers
 	if hostHeaderValue, ok := headersMap[httpHostHeaderKey]; ok {
-		hostHeader := &xds_route.HeaderMatcher{
-			Name: authorityHeaderKey,
-			HeaderMatchSpecifier: &xds_route.HeaderMatcher_SafeRegexMatch{
-				SafeRegexMatch: &xds_matcher.RegexMatcher{
-					EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
-					Regex:      hostHeaderValue,
-				},
-			},
-		}
-		headers = append(headers, hostHeader)
+		headers = append(headers, getRouteHeaderMatcher(authorityHeaderKey, hostHeaderValue, matchType))
 	}
 
 	// add all other custom headers
@@ -540,18 +911,63 @@ func getHeadersForRoute(method string, headersMap map[string]string) []*xds_rout
 		if headerKey == httpHostHeaderKey {
 			continue
 		}
-		header := xds_route.HeaderMatcher{
-			Name: headerKey,
+		headers = append(headers, getRouteHeaderMatcher(headerKey, headerValue, matchType))
+	}
+	return headers
+}
+
+// getRouteHeaderMatcher returns a HeaderMatcher for the given header name, matching the header value based on the given match type
+func getRouteHeaderMatcher(name string, value string, matchType trafficpolicy.HeaderMatchType) *xds_route.HeaderMatcher {
+	if matchType == trafficpolicy.HeaderMatchRegex {
+		return &xds_route.HeaderMatcher{
+			Name: name,
 			HeaderMatchSpecifier: &xds_route.HeaderMatcher_SafeRegexMatch{
 				SafeRegexMatch: &xds_matcher.RegexMatcher{
 					EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
-					Regex:      headerValue,
+					Regex:      value,
 				},
 			},
 		}
-		headers = append(headers, &header)
 	}
-	return headers
+
+	return &xds_route.HeaderMatcher{
+		Name: name,
+		HeaderMatchSpecifier: &xds_route.HeaderMatcher_StringMatch{
+			StringMatch: &xds_matcher.StringMatcher{
+				MatchPattern: &xds_matcher.StringMatcher_Exact{
+					Exact: value,
+				},
+			},
+		},
+	}
+}
+
+// getQueryParametersForRoute returns the query parameter matchers for the given query parameters, sorted by name
+func getQueryParametersForRoute(queryParams map[string]string) []*xds_route.QueryParameterMatcher {
+	if len(queryParams) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(queryParams))
+	for name := range queryParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matchers := make([]*xds_route.QueryParameterMatcher, 0, len(names))
+	for _, name := range names {
+		matchers = append(matchers, &xds_route.QueryParameterMatcher{
+			Name: name,
+			QueryParameterMatchSpecifier: &xds_route.QueryParameterMatcher_StringMatch{
+				StringMatch: &xds_matcher.StringMatcher{
+					MatchPattern: &xds_matcher.StringMatcher_Exact{
+						Exact: queryParams[name],
+					},
+				},
+			},
+		})
+	}
+	return matchers
 }
 
 func getRegexForMethod(httpMethod string) string {
@@ -576,3 +992,12 @@ func GetOutboundMeshRouteConfigNameForPort(port int) string {
 func GetInboundMeshRouteConfigNameForPort(port int) string {
 	return fmt.Sprintf("%s.%d", InboundRouteConfigName, port)
 }
+
+// GetInboundMeshRouteConfigNameForServerName returns the inbound mesh route configuration object's name given the port
+// it is targeted to and the SNI required on the TLS handshake. An empty server name refers to the port's route configuration.
+func GetInboundMeshRouteConfigNameForServerName(port int, serverName string) string {
+	if serverName == "" {
+		return GetInboundMeshRouteConfigNameForPort(port)
+	}
+	return fmt.Sprintf("%s.%d.%s", InboundRouteConfigName, port, serverName)
+}
@@ -6,18 +6,28 @@ import (
 	"time"
 
 	mapset "github.com/deckarep/golang-set"
+	xds_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	xds_route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	xds_fault "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/common/fault/v3"
+	xds_http_fault "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/fault/v3"
+	xds_http_local_ratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
 	xds_matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	xds_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/google/go-cmp/cmp"
 	tassert "github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
 
 	"github.com/openservicemesh/osm/pkg/constants"
+	"github.com/openservicemesh/osm/pkg/envoy"
+	"github.com/openservicemesh/osm/pkg/identity"
 	"github.com/openservicemesh/osm/pkg/service"
 	"github.com/openservicemesh/osm/pkg/tests"
 	"github.com/openservicemesh/osm/pkg/trafficpolicy"
@@ -130,6 +140,204 @@ func TestBuildInboundRoutes(t *testing.T) {
 	}
 }
 
+func TestBuildInboundRoutesWithFaultInjection(t *testing.T) {
+	testWeightedCluster := service.WeightedCluster{
+		ClusterName: "default/testCluster|80|local",
+		Weight:      100,
+	}
+	fiveSeconds := 5 * time.Second
+
+	testCases := []struct {
+		name           string
+		faultInjection *policyv1alpha1.HTTPFaultInjectionSpec
+		expectedFault  *xds_http_fault.HTTPFault
+	}{
+		{
+			name: "abort fault",
+			faultInjection: &policyv1alpha1.HTTPFaultInjectionSpec{
+				Percentage: 10,
+				Abort:      &policyv1alpha1.HTTPFaultAbortSpec{HTTPStatus: 503},
+			},
+			expectedFault: &xds_http_fault.HTTPFault{
+				Abort: &xds_http_fault.FaultAbort{
+					ErrorType:  &xds_http_fault.FaultAbort_HttpStatus{HttpStatus: 503},
+					Percentage: &xds_type.FractionalPercent{Numerator: 10, Denominator: xds_type.FractionalPercent_HUNDRED},
+				},
+			},
+		},
+		{
+			name: "delay fault",
+			faultInjection: &policyv1alpha1.HTTPFaultInjectionSpec{
+				Percentage: 20,
+				Delay:      &policyv1alpha1.HTTPFaultDelaySpec{FixedDelay: metav1.Duration{Duration: fiveSeconds}},
+			},
+			expectedFault: &xds_http_fault.HTTPFault{
+				Delay: &xds_fault.FaultDelay{
+					FaultDelaySecifier: &xds_fault.FaultDelay_FixedDelay{FixedDelay: durationpb.New(fiveSeconds)},
+					Percentage:         &xds_type.FractionalPercent{Numerator: 20, Denominator: xds_type.FractionalPercent_HUNDRED},
+				},
+			},
+		},
+		{
+			name: "abort and delay faults",
+			faultInjection: &policyv1alpha1.HTTPFaultInjectionSpec{
+				Percentage: 100,
+				Abort:      &policyv1alpha1.HTTPFaultAbortSpec{HTTPStatus: 500},
+				Delay:      &policyv1alpha1.HTTPFaultDelaySpec{FixedDelay: metav1.Duration{Duration: fiveSeconds}},
+			},
+			expectedFault: &xds_http_fault.HTTPFault{
+				Abort: &xds_http_fault.FaultAbort{
+					ErrorType:  &xds_http_fault.FaultAbort_HttpStatus{HttpStatus: 500},
+					Percentage: &xds_type.FractionalPercent{Numerator: 100, Denominator: xds_type.FractionalPercent_HUNDRED},
+				},
+				Delay: &xds_fault.FaultDelay{
+					FaultDelaySecifier: &xds_fault.FaultDelay_FixedDelay{FixedDelay: durationpb.New(fiveSeconds)},
+					Percentage:         &xds_type.FractionalPercent{Numerator: 100, Denominator: xds_type.FractionalPercent_HUNDRED},
+				},
+			},
+		},
+		{
+			name:           "fault injection without an abort or delay fault is ignored",
+			faultInjection: &policyv1alpha1.HTTPFaultInjectionSpec{Percentage: 100},
+			expectedFault:  nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			rules := []*trafficpolicy.Rule{
+				{
+					Route: trafficpolicy.RouteWeightedClusters{
+						HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+							Path:          "/faulty",
+							PathMatchType: trafficpolicy.PathMatchExact,
+							Methods:       []string{"GET"},
+						},
+						WeightedClusters: mapset.NewSet(testWeightedCluster),
+						FaultInjection:   tc.faultInjection,
+					},
+					AllowedPrincipals: mapset.NewSet(identity.WildcardPrincipal),
+				},
+				{
+					Route: trafficpolicy.RouteWeightedClusters{
+						HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+							Path:          "/healthy",
+							PathMatchType: trafficpolicy.PathMatchExact,
+							Methods:       []string{"GET"},
+						},
+						WeightedClusters: mapset.NewSet(testWeightedCluster),
+					},
+					AllowedPrincipals: mapset.NewSet(identity.WildcardPrincipal),
+				},
+			}
+
+			routes := buildInboundRoutes(rules)
+			assert.Len(routes, 2)
+
+			// The fault is only applied to the route it is configured on
+			assert.Equal("/faulty", routes[0].GetMatch().GetPath())
+			faultConfig, ok := routes[0].TypedPerFilterConfig[envoy.HTTPFaultFilterName]
+			if tc.expectedFault == nil {
+				assert.False(ok)
+			} else {
+				assert.True(ok)
+				actual := &xds_http_fault.HTTPFault{}
+				assert.Nil(faultConfig.UnmarshalTo(actual))
+				assert.Truef(cmp.Equal(tc.expectedFault, actual, protocmp.Transform()), cmp.Diff(tc.expectedFault, actual, protocmp.Transform()))
+			}
+
+			assert.Equal("/healthy", routes[1].GetMatch().GetPath())
+			assert.NotContains(routes[1].TypedPerFilterConfig, envoy.HTTPFaultFilterName)
+		})
+	}
+}
+
+func TestApplyInboundVirtualHostConfigResponseHeaders(t *testing.T) {
+	assert := tassert.New(t)
+
+	vhost := buildVirtualHostStub("inbound_virtual-host", "foo", []string{"foo.com"})
+	applyInboundVirtualHostConfig(vhost, &trafficpolicy.InboundTrafficPolicy{
+		Name:      "foo",
+		Hostnames: []string{"foo.com"},
+		ResponseHeadersToAdd: []policyv1alpha1.HTTPHeaderValue{
+			{Name: "Cache-Control", Value: "no-store"},
+			{Name: "Cache-Control", Value: "no-cache"},
+			{Name: "X-Frame-Options", Value: "DENY"},
+		},
+		ResponseHeadersToRemove: []string{"Server", "X-Powered-By"},
+	})
+
+	assert.Equal([]string{"Server", "X-Powered-By"}, vhost.ResponseHeadersToRemove)
+	assert.Len(vhost.ResponseHeadersToAdd, 3)
+	expected := []struct {
+		key    string
+		value  string
+		append bool
+	}{
+		{key: "Cache-Control", value: "no-store", append: false},
+		{key: "Cache-Control", value: "no-cache", append: true},
+		{key: "X-Frame-Options", value: "DENY", append: false},
+	}
+	for i, e := range expected {
+		assert.Equal(e.key, vhost.ResponseHeadersToAdd[i].Header.Key)
+		assert.Equal(e.value, vhost.ResponseHeadersToAdd[i].Header.Value)
+		assert.Equal(e.append, vhost.ResponseHeadersToAdd[i].Append.GetValue())
+	}
+}
+
+func TestApplyInboundVirtualHostConfigWebSocketUpgrade(t *testing.T) {
+	rules := []*trafficpolicy.Rule{
+		{
+			Route: trafficpolicy.RouteWeightedClusters{
+				HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+					Path:          "/ws",
+					PathMatchType: trafficpolicy.PathMatchRegex,
+					Methods:       []string{"GET"},
+				},
+				WeightedClusters: mapset.NewSet(service.WeightedCluster{ClusterName: "default/testCluster|80|local", Weight: 100}),
+			},
+			AllowedPrincipals: mapset.NewSet("foo.bar.cluster.local"),
+		},
+	}
+
+	testCases := []struct {
+		name                   string
+		allowWebSocketUpgrade  bool
+		expectedUpgradeConfigs []*xds_route.RouteAction_UpgradeConfig
+	}{
+		{
+			name:                   "WebSocket upgrades not allowed",
+			allowWebSocketUpgrade:  false,
+			expectedUpgradeConfigs: nil,
+		},
+		{
+			name:                   "WebSocket upgrades allowed",
+			allowWebSocketUpgrade:  true,
+			expectedUpgradeConfigs: []*xds_route.RouteAction_UpgradeConfig{{UpgradeType: "websocket"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			vhost := buildVirtualHostStub("inbound_virtual-host", "foo", []string{"foo.com"})
+			vhost.Routes = buildInboundRoutes(rules)
+			applyInboundVirtualHostConfig(vhost, &trafficpolicy.InboundTrafficPolicy{
+				Name:                  "foo",
+				Hostnames:             []string{"foo.com"},
+				Rules:                 rules,
+				AllowWebSocketUpgrade: tc.allowWebSocketUpgrade,
+			})
+
+			assert.Len(vhost.Routes, 1)
+			assert.Equal(tc.expectedUpgradeConfigs, vhost.Routes[0].GetRoute().GetUpgradeConfigs())
+		})
+	}
+}
+
 func TestBuildOutboundRoutes(t *testing.T) {
 	assert := tassert.New(t)
 
@@ -221,19 +429,21 @@ func TestBuildRoute(t *testing.T) {
 						},
 						{
 							Name: "header1",
-							HeaderMatchSpecifier: &xds_route.HeaderMatcher_SafeRegexMatch{
-								SafeRegexMatch: &xds_matcher.RegexMatcher{
-									EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
-									Regex:      "header1-val",
+							HeaderMatchSpecifier: &xds_route.HeaderMatcher_StringMatch{
+								StringMatch: &xds_matcher.StringMatcher{
+									MatchPattern: &xds_matcher.StringMatcher_Exact{
+										Exact: "header1-val",
+									},
 								},
 							},
 						},
 						{
 							Name: "header2",
-							HeaderMatchSpecifier: &xds_route.HeaderMatcher_SafeRegexMatch{
-								SafeRegexMatch: &xds_matcher.RegexMatcher{
-									EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
-									Regex:      "header2-val",
+							HeaderMatchSpecifier: &xds_route.HeaderMatcher_StringMatch{
+								StringMatch: &xds_matcher.StringMatcher{
+									MatchPattern: &xds_matcher.StringMatcher_Exact{
+										Exact: "header2-val",
+									},
 								},
 							},
 						},
@@ -273,9 +483,10 @@ func TestBuildRoute(t *testing.T) {
 			name: "inbound route for regex path match",
 			route: trafficpolicy.RouteWeightedClusters{
 				HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
-					PathMatchType: trafficpolicy.PathMatchRegex,
-					Path:          "/somepath",
-					Headers:       map[string]string{"header1": "header1-val", "header2": "header2-val"},
+					PathMatchType:   trafficpolicy.PathMatchRegex,
+					Path:            "/somepath",
+					Headers:         map[string]string{"header1": "header1-val", "header2": "header2-val"},
+					HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 				},
 				WeightedClusters: mapset.NewSetFromSlice([]interface{}{
 					service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-1|80|local"), Weight: 100}}),
@@ -455,29 +666,21 @@ func TestBuildRoute(t *testing.T) {
 	}
 }
 
-func TestBuildWeightedCluster(t *testing.T) {
+func TestBuildRouteRequireClientCertificate(t *testing.T) {
 	testCases := []struct {
-		name                string
-		weightedClusters    mapset.Set
-		expectedClusters    int
-		expectedTotalWeight int
+		name               string
+		requireClientCert  bool
+		expectedTLSContext *xds_route.RouteMatch_TlsContextMatchOptions
 	}{
 		{
-			name: "multiple valid clusters",
-			weightedClusters: mapset.NewSetFromSlice([]interface{}{
-				service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-1|80|local"), Weight: 30},
-				service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-2|80|local"), Weight: 70},
-			}),
-			expectedClusters:    2,
-			expectedTotalWeight: 100,
+			name:               "route requires a validated client certificate",
+			requireClientCert:  true,
+			expectedTLSContext: &xds_route.RouteMatch_TlsContextMatchOptions{Presented: wrapperspb.Bool(true), Validated: wrapperspb.Bool(true)},
 		},
 		{
-			name: "total cluster weight is invalid (< 1)",
-			weightedClusters: mapset.NewSetFromSlice([]interface{}{
-				service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-1|80|local"), Weight: 0},
-				service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-2|80|local"), Weight: 0},
-			}),
-			expectedClusters: 0,
+			name:               "route does not require a client certificate",
+			requireClientCert:  false,
+			expectedTLSContext: nil,
 		},
 	}
 
@@ -485,56 +688,37 @@ func TestBuildWeightedCluster(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			assert := tassert.New(t)
 
-			actual := buildWeightedCluster(tc.weightedClusters)
-			if tc.expectedClusters == 0 {
-				assert.Nil(actual)
-				return
+			route := trafficpolicy.RouteWeightedClusters{
+				HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+					PathMatchType: trafficpolicy.PathMatchRegex,
+					Path:          "/somepath",
+				},
+				WeightedClusters: mapset.NewSetFromSlice([]interface{}{
+					service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-1|80|local"), Weight: 100}}),
+				RequireClientCertificate: tc.requireClientCert,
 			}
 
-			assert.Len(actual.Clusters, tc.expectedClusters)
-			assert.EqualValues(tc.expectedTotalWeight, actual.TotalWeight.GetValue())
+			actual := buildRoute(route, "GET")
+			assert.Equal(tc.expectedTLSContext, actual.Match.TlsContext)
 		})
 	}
 }
 
-func TestBuildRetryPolicy(t *testing.T) {
+func TestBuildRouteGRPC(t *testing.T) {
 	testCases := []struct {
-		name        string
-		retryPolicy *policyv1alpha1.RetryPolicySpec
-		expRetry    *xds_route.RetryPolicy
+		name         string
+		grpc         bool
+		expectedGRPC *xds_route.RouteMatch_GrpcRouteMatchOptions
 	}{
 		{
-			name:        "no retry",
-			retryPolicy: nil,
-			expRetry:    nil,
-		},
-		{
-			name: "valid retry policy",
-			retryPolicy: &policyv1alpha1.RetryPolicySpec{
-				RetryOn: "2xx",
-			},
-			expRetry: &xds_route.RetryPolicy{
-				RetryOn: "2xx",
-			},
+			name:         "route only matches gRPC requests",
+			grpc:         true,
+			expectedGRPC: &xds_route.RouteMatch_GrpcRouteMatchOptions{},
 		},
 		{
-			name: "valid retry policy with all fields",
-			retryPolicy: &policyv1alpha1.RetryPolicySpec{
-				RetryOn:                  "2xx",
-				PerTryTimeout:            &thresholdTimeoutDuration,
-				NumRetries:               &thresholdUintVal,
-				RetryBackoffBaseInterval: &thresholdBackoffDuration,
-			},
-			expRetry: &xds_route.RetryPolicy{
-				RetryOn:       "2xx",
-				PerTryTimeout: durationpb.New(thresholdTimeoutDuration.Duration),
-				NumRetries: &wrapperspb.UInt32Value{
-					Value: thresholdUintVal,
-				},
-				RetryBackOff: &xds_route.RetryPolicy_RetryBackOff{
-					BaseInterval: durationpb.New(thresholdBackoffDuration.Duration),
-				},
-			},
+			name:         "route matches all requests",
+			grpc:         false,
+			expectedGRPC: nil,
 		},
 	}
 
@@ -542,27 +726,84 @@ func TestBuildRetryPolicy(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			assert := tassert.New(t)
 
-			actual := buildRetryPolicy(tc.retryPolicy)
-			assert.Equal(tc.expRetry, actual)
+			route := trafficpolicy.RouteWeightedClusters{
+				HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+					PathMatchType: trafficpolicy.PathMatchExact,
+					Path:          "/helloworld.Greeter/SayHello",
+					GRPC:          tc.grpc,
+				},
+				WeightedClusters: mapset.NewSetFromSlice([]interface{}{
+					service.WeightedCluster{ClusterName: service.ClusterName("osm/greeter-1|80|local"), Weight: 100}}),
+			}
+
+			actual := buildRoute(route, "POST")
+			assert.Equal(tc.expectedGRPC, actual.Match.Grpc)
+			assert.Equal("/helloworld.Greeter/SayHello", actual.Match.GetPath())
 		})
 	}
 }
 
-func TestSanitizeHTTPMethods(t *testing.T) {
+func TestBuildRouteCaseSensitivity(t *testing.T) {
+	caseSensitive := true
+	caseInsensitive := false
+
 	testCases := []struct {
-		name                   string
-		allowedMethods         []string
-		expectedAllowedMethods []string
+		name                  string
+		pathMatchType         trafficpolicy.PathMatchType
+		caseSensitive         *bool
+		expectedCaseSensitive *wrapperspb.BoolValue
+		expectedPathMatch     *xds_route.RouteMatch
 	}{
 		{
-			name:                   "returns unique list of allowed methods",
-			allowedMethods:         []string{"GET", "POST", "PUT", "POST", "GET", "GET"},
-			expectedAllowedMethods: []string{"GET", "POST", "PUT"},
+			name:                  "regex path is case sensitive by default",
+			pathMatchType:         trafficpolicy.PathMatchRegex,
+			caseSensitive:         nil,
+			expectedCaseSensitive: nil,
+			expectedPathMatch: &xds_route.RouteMatch{PathSpecifier: &xds_route.RouteMatch_SafeRegex{SafeRegex: &xds_matcher.RegexMatcher{
+				EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
+				Regex:      "/api",
+			}}},
 		},
 		{
-			name:                   "returns wildcard allowed method (*)",
-			allowedMethods:         []string{"GET", "POST", "PUT", "POST", "GET", "GET", "*"},
-			expectedAllowedMethods: []string{"*"},
+			name:                  "case sensitive regex path",
+			pathMatchType:         trafficpolicy.PathMatchRegex,
+			caseSensitive:         &caseSensitive,
+			expectedCaseSensitive: nil,
+			expectedPathMatch: &xds_route.RouteMatch{PathSpecifier: &xds_route.RouteMatch_SafeRegex{SafeRegex: &xds_matcher.RegexMatcher{
+				EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
+				Regex:      "/api",
+			}}},
+		},
+		{
+			name:                  "case insensitive regex path",
+			pathMatchType:         trafficpolicy.PathMatchRegex,
+			caseSensitive:         &caseInsensitive,
+			expectedCaseSensitive: wrapperspb.Bool(false),
+			expectedPathMatch: &xds_route.RouteMatch{PathSpecifier: &xds_route.RouteMatch_SafeRegex{SafeRegex: &xds_matcher.RegexMatcher{
+				EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
+				Regex:      "(?i)/api",
+			}}},
+		},
+		{
+			name:                  "exact path is case sensitive by default",
+			pathMatchType:         trafficpolicy.PathMatchExact,
+			caseSensitive:         nil,
+			expectedCaseSensitive: nil,
+			expectedPathMatch:     &xds_route.RouteMatch{PathSpecifier: &xds_route.RouteMatch_Path{Path: "/api"}},
+		},
+		{
+			name:                  "case insensitive exact path",
+			pathMatchType:         trafficpolicy.PathMatchExact,
+			caseSensitive:         &caseInsensitive,
+			expectedCaseSensitive: wrapperspb.Bool(false),
+			expectedPathMatch:     &xds_route.RouteMatch{PathSpecifier: &xds_route.RouteMatch_Path{Path: "/api"}},
+		},
+		{
+			name:                  "case insensitive prefix path",
+			pathMatchType:         trafficpolicy.PathMatchPrefix,
+			caseSensitive:         &caseInsensitive,
+			expectedCaseSensitive: wrapperspb.Bool(false),
+			expectedPathMatch:     &xds_route.RouteMatch{PathSpecifier: &xds_route.RouteMatch_Prefix{Prefix: "/api"}},
 		},
 	}
 
@@ -570,38 +811,51 @@ func TestSanitizeHTTPMethods(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			assert := tassert.New(t)
 
-			actual := sanitizeHTTPMethods(tc.allowedMethods)
-			assert.Equal(tc.expectedAllowedMethods, actual)
+			route := trafficpolicy.RouteWeightedClusters{
+				HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+					PathMatchType: tc.pathMatchType,
+					Path:          "/api",
+					CaseSensitive: tc.caseSensitive,
+				},
+				WeightedClusters: mapset.NewSetFromSlice([]interface{}{
+					service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-1|80|local"), Weight: 100}}),
+			}
+
+			actual := buildRoute(route, "GET")
+			assert.Equal(tc.expectedCaseSensitive, actual.Match.CaseSensitive)
+			assert.Equal(tc.expectedPathMatch.PathSpecifier, actual.Match.PathSpecifier)
 		})
 	}
 }
 
-func TestNewRouteConfigurationStub(t *testing.T) {
-	assert := tassert.New(t)
-
-	testName := "testing"
-	actual := newRouteConfigurationStub(testName)
-
-	assert.Equal(testName, actual.Name)
-	assert.Nil(actual.VirtualHosts)
-	assert.False(actual.ValidateClusters.Value)
-}
-
-func TestGetRegexForMethod(t *testing.T) {
+func TestBuildRouteQueryParameters(t *testing.T) {
 	testCases := []struct {
-		name     string
-		input    string
-		expected string
+		name                    string
+		queryParams             map[string]string
+		expectedQueryParameters []*xds_route.QueryParameterMatcher
 	}{
 		{
-			name:     "wildcard HTTP method correctly translates to a match all regex",
-			input:    "*",
-			expected: constants.RegexMatchAll,
+			name:                    "no query parameters",
+			queryParams:             nil,
+			expectedQueryParameters: nil,
 		},
 		{
-			name:     "non wildcard HTTP method correctly translates to its corresponding regex",
-			input:    "GET",
-			expected: "GET",
+			name:        "query parameters sorted by name",
+			queryParams: map[string]string{"version": "v2", "user": "alice"},
+			expectedQueryParameters: []*xds_route.QueryParameterMatcher{
+				{
+					Name: "user",
+					QueryParameterMatchSpecifier: &xds_route.QueryParameterMatcher_StringMatch{
+						StringMatch: &xds_matcher.StringMatcher{MatchPattern: &xds_matcher.StringMatcher_Exact{Exact: "alice"}},
+					},
+				},
+				{
+					Name: "version",
+					QueryParameterMatchSpecifier: &xds_route.QueryParameterMatcher_StringMatch{
+						StringMatch: &xds_matcher.StringMatcher{MatchPattern: &xds_matcher.StringMatcher_Exact{Exact: "v2"}},
+					},
+				},
+			},
 		},
 	}
 
@@ -609,18 +863,832 @@ func TestGetRegexForMethod(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			assert := tassert.New(t)
 
-			actual := getRegexForMethod(tc.input)
-			assert.Equal(tc.expected, actual)
+			route := trafficpolicy.RouteWeightedClusters{
+				HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+					PathMatchType: trafficpolicy.PathMatchRegex,
+					Path:          "/somepath",
+					QueryParams:   tc.queryParams,
+				},
+				WeightedClusters: mapset.NewSetFromSlice([]interface{}{
+					service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-1|80|local"), Weight: 100}}),
+			}
+
+			actual := buildRoute(route, "GET")
+			assert.Equal(tc.expectedQueryParameters, actual.Match.QueryParameters)
 		})
 	}
 }
 
-func TestGetHeadersForRoute(t *testing.T) {
-	assert := tassert.New(t)
+func TestBuildRouteTimeout(t *testing.T) {
+	tenSeconds := 10 * time.Second
+	noTimeout := time.Duration(0)
 
-	userAgentHeader := "user-agent"
+	testCases := []struct {
+		name            string
+		timeout         *time.Duration
+		expectedTimeout time.Duration
+	}{
+		{
+			name:            "mesh default timeout is disabled",
+			timeout:         nil,
+			expectedTimeout: 0,
+		},
+		{
+			name:            "route timeout",
+			timeout:         &tenSeconds,
+			expectedTimeout: 10 * time.Second,
+		},
+		{
+			name:            "route timeout of 0 disables the timeout",
+			timeout:         &noTimeout,
+			expectedTimeout: 0,
+		},
+	}
 
-	// Returns a list of HeaderMatcher for a route
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			route := trafficpolicy.RouteWeightedClusters{
+				HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+					PathMatchType: trafficpolicy.PathMatchRegex,
+					Path:          "/somepath",
+				},
+				WeightedClusters: mapset.NewSetFromSlice([]interface{}{
+					service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-1|80|local"), Weight: 100}}),
+				Timeout: tc.timeout,
+			}
+
+			actual := buildRoute(route, "GET")
+			assert.NotNil(actual.GetRoute().Timeout)
+			assert.Equal(tc.expectedTimeout, actual.GetRoute().Timeout.AsDuration())
+		})
+	}
+}
+
+func TestBuildRouteRuntimeKeyPrefix(t *testing.T) {
+	testCases := []struct {
+		name                     string
+		runtimeKeyPrefix         string
+		expectedRuntimeKeyPrefix string
+	}{
+		{
+			name:                     "weights are backed by runtime keys",
+			runtimeKeyPrefix:         "osm.weights.bookstore.osm.svc.cluster.local",
+			expectedRuntimeKeyPrefix: "osm.weights.bookstore.osm.svc.cluster.local",
+		},
+		{
+			name:                     "weights are not backed by runtime keys",
+			runtimeKeyPrefix:         "",
+			expectedRuntimeKeyPrefix: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			route := trafficpolicy.RouteWeightedClusters{
+				HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+					PathMatchType: trafficpolicy.PathMatchRegex,
+					Path:          "/somepath",
+				},
+				WeightedClusters: mapset.NewSetFromSlice([]interface{}{
+					service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-1|80"), Weight: 70},
+					service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-2|80"), Weight: 30}}),
+				RuntimeKeyPrefix: tc.runtimeKeyPrefix,
+			}
+
+			actual := buildRoute(route, "GET").GetRoute().GetWeightedClusters()
+			assert.Equal(tc.expectedRuntimeKeyPrefix, actual.RuntimeKeyPrefix)
+
+			// The configured weights are the defaults when the runtime keys are not set
+			weights := make(map[string]uint32)
+			for _, cluster := range actual.Clusters {
+				weights[cluster.Name] = cluster.Weight.GetValue()
+			}
+			assert.Equal(map[string]uint32{"osm/bookstore-1|80": 70, "osm/bookstore-2|80": 30}, weights)
+		})
+	}
+}
+
+func TestBuildRouteRedirect(t *testing.T) {
+	assert := tassert.New(t)
+
+	// Route settings only applicable to routed requests are ignored
+	timeout := time.Second
+	route := trafficpolicy.RouteWeightedClusters{
+		HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+			PathMatchType: trafficpolicy.PathMatchRegex,
+			Path:          "/somepath",
+		},
+		WeightedClusters: mapset.NewSet(),
+		Timeout:          &timeout,
+		Redirect:         &trafficpolicy.RouteRedirect{HTTPSRedirect: true},
+	}
+
+	actual := buildRoute(route, "GET")
+
+	// Requests are redirected to HTTPS and not routed to any cluster
+	assert.Nil(actual.GetRoute())
+	assert.Equal(&xds_route.RedirectAction{
+		SchemeRewriteSpecifier: &xds_route.RedirectAction_HttpsRedirect{HttpsRedirect: true},
+	}, actual.GetRedirect())
+	assert.Equal(&xds_route.RouteMatch_SafeRegex{
+		SafeRegex: &xds_matcher.RegexMatcher{
+			EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
+			Regex:      "/somepath",
+		},
+	}, actual.Match.PathSpecifier)
+}
+
+func TestBuildRouteDirectResponse(t *testing.T) {
+	assert := tassert.New(t)
+
+	route := trafficpolicy.RouteWeightedClusters{
+		HTTPRouteMatch:   trafficpolicy.WildCardRouteMatch,
+		WeightedClusters: mapset.NewSet(),
+		DirectResponse:   &policyv1alpha1.DirectResponseSpec{StatusCode: 503, Body: "down for maintenance"},
+	}
+
+	actual := buildRoute(route, "GET")
+
+	// Requests are answered with the direct response and not routed to any cluster
+	assert.Nil(actual.GetRoute())
+	assert.Equal(&xds_route.DirectResponseAction{
+		Status: 503,
+		Body: &xds_core.DataSource{
+			Specifier: &xds_core.DataSource_InlineString{InlineString: "down for maintenance"},
+		},
+	}, actual.GetDirectResponse())
+
+	// The body is optional
+	route.DirectResponse = &policyv1alpha1.DirectResponseSpec{StatusCode: 503}
+	actual = buildRoute(route, "GET")
+	assert.Equal(&xds_route.DirectResponseAction{Status: 503}, actual.GetDirectResponse())
+}
+
+func TestBuildRouteWithRequestMirrorPolicies(t *testing.T) {
+	assert := tassert.New(t)
+
+	route := trafficpolicy.RouteWeightedClusters{
+		HTTPRouteMatch:   trafficpolicy.WildCardRouteMatch,
+		WeightedClusters: mapset.NewSet(service.WeightedCluster{ClusterName: "ns1/s1-v1|80", Weight: 100}),
+		RequestMirrorPolicies: []trafficpolicy.RequestMirrorPolicy{
+			{ClusterName: "ns1/s1-shadow|80", Percentage: 25},
+		},
+	}
+
+	actual := buildRoute(route, "GET")
+
+	// The mirror cluster is not part of the weighted clusters
+	weightedClusters := actual.GetRoute().GetWeightedClusters()
+	assert.Len(weightedClusters.Clusters, 1)
+	assert.Equal("ns1/s1-v1|80", weightedClusters.Clusters[0].Name)
+	assert.Equal(uint32(100), weightedClusters.TotalWeight.GetValue())
+
+	assert.Equal([]*xds_route.RouteAction_RequestMirrorPolicy{
+		{
+			Cluster: "ns1/s1-shadow|80",
+			RuntimeFraction: &xds_core.RuntimeFractionalPercent{
+				DefaultValue: &xds_type.FractionalPercent{Numerator: 25, Denominator: xds_type.FractionalPercent_HUNDRED},
+			},
+		},
+	}, actual.GetRoute().RequestMirrorPolicies)
+
+	// No requests are mirrored without request mirror policies
+	route.RequestMirrorPolicies = nil
+	assert.Nil(buildRoute(route, "GET").GetRoute().RequestMirrorPolicies)
+}
+
+func TestBuildRouteWithHashKeyHeader(t *testing.T) {
+	assert := tassert.New(t)
+
+	route := trafficpolicy.RouteWeightedClusters{
+		HTTPRouteMatch:   trafficpolicy.WildCardRouteMatch,
+		WeightedClusters: mapset.NewSet(service.WeightedCluster{ClusterName: "ns1/s1|80", Weight: 100}),
+		HashKeyHeader:    "x-user-id",
+	}
+
+	actual := buildRoute(route, "GET")
+	assert.Equal([]*xds_route.RouteAction_HashPolicy{
+		{
+			PolicySpecifier: &xds_route.RouteAction_HashPolicy_Header_{
+				Header: &xds_route.RouteAction_HashPolicy_Header{HeaderName: "x-user-id"},
+			},
+		},
+	}, actual.GetRoute().HashPolicy)
+
+	// Requests are not hashed without a hash key header
+	route.HashKeyHeader = ""
+	assert.Nil(buildRoute(route, "GET").GetRoute().HashPolicy)
+}
+
+func TestBuildRouteWithHashKeyCookie(t *testing.T) {
+	assert := tassert.New(t)
+
+	route := trafficpolicy.RouteWeightedClusters{
+		HTTPRouteMatch:   trafficpolicy.WildCardRouteMatch,
+		WeightedClusters: mapset.NewSet(service.WeightedCluster{ClusterName: "ns1/s1|80", Weight: 100}),
+		HashKeyCookie: &policyv1alpha1.HashKeyCookieSpec{
+			Name: "session",
+			TTL:  &metav1.Duration{Duration: time.Hour},
+		},
+	}
+
+	actual := buildRoute(route, "GET")
+	assert.Equal([]*xds_route.RouteAction_HashPolicy{
+		{
+			PolicySpecifier: &xds_route.RouteAction_HashPolicy_Cookie_{
+				Cookie: &xds_route.RouteAction_HashPolicy_Cookie{
+					Name: "session",
+					Ttl:  durationpb.New(time.Hour),
+				},
+			},
+		},
+	}, actual.GetRoute().HashPolicy)
+}
+
+func TestBuildRouteWithRewritePrefix(t *testing.T) {
+	assert := tassert.New(t)
+
+	route := trafficpolicy.RouteWeightedClusters{
+		HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+			Path:          "/old",
+			PathMatchType: trafficpolicy.PathMatchExact,
+			Methods:       []string{constants.WildcardHTTPMethod},
+		},
+		WeightedClusters: mapset.NewSet(service.WeightedCluster{ClusterName: "ns1/s1|80", Weight: 100}),
+		RewritePrefix:    "/new",
+	}
+
+	actual := buildRoute(route, constants.WildcardHTTPMethod)
+	assert.Equal("/old", actual.Match.GetPath())
+	assert.Equal("/new", actual.GetRoute().PrefixRewrite)
+
+	// The path is forwarded as is without a rewrite prefix
+	route.RewritePrefix = ""
+	assert.Empty(buildRoute(route, constants.WildcardHTTPMethod).GetRoute().PrefixRewrite)
+}
+
+func TestBuildRouteWithHostRewrite(t *testing.T) {
+	assert := tassert.New(t)
+
+	route := trafficpolicy.RouteWeightedClusters{
+		HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+			Path:          "/api",
+			PathMatchType: trafficpolicy.PathMatchPrefix,
+			Methods:       []string{constants.WildcardHTTPMethod},
+		},
+		WeightedClusters: mapset.NewSet(service.WeightedCluster{ClusterName: "ns1/s1|80", Weight: 100}),
+		HostRewrite:      &policyv1alpha1.HostRewriteSpec{Literal: "api.example.com"},
+	}
+
+	actual := buildRoute(route, constants.WildcardHTTPMethod)
+	assert.Equal("api.example.com", actual.GetRoute().GetHostRewriteLiteral())
+	assert.Nil(actual.GetRoute().GetAutoHostRewrite())
+
+	route.HostRewrite = &policyv1alpha1.HostRewriteSpec{AutoToUpstream: true}
+	actual = buildRoute(route, constants.WildcardHTTPMethod)
+	assert.Empty(actual.GetRoute().GetHostRewriteLiteral())
+	assert.True(actual.GetRoute().GetAutoHostRewrite().GetValue())
+
+	// The Host header is forwarded as is without a host rewrite
+	route.HostRewrite = nil
+	assert.Nil(buildRoute(route, constants.WildcardHTTPMethod).GetRoute().HostRewriteSpecifier)
+}
+
+func TestBuildRouteWithAuthority(t *testing.T) {
+	assert := tassert.New(t)
+
+	route := trafficpolicy.RouteWeightedClusters{
+		HTTPRouteMatch: trafficpolicy.HTTPRouteMatch{
+			Path:          "/api",
+			PathMatchType: trafficpolicy.PathMatchPrefix,
+			Methods:       []string{constants.WildcardHTTPMethod},
+			Headers:       map[string]string{"host": "other.example.com", "user-agent": "test"},
+			Authority:     "api.example.com",
+		},
+		WeightedClusters: mapset.NewSet(service.WeightedCluster{ClusterName: "ns1/s1|80|local", Weight: 100}),
+	}
+
+	actual := buildRoute(route, constants.WildcardHTTPMethod)
+
+	// The path match is kept alongside the authority match
+	assert.Equal("/api", actual.Match.GetPrefix())
+
+	// The authority of the route replaces the host header match
+	var authorityMatchers []*xds_route.HeaderMatcher
+	for _, header := range actual.Match.Headers {
+		if header.Name == authorityHeaderKey {
+			authorityMatchers = append(authorityMatchers, header)
+		}
+	}
+	assert.Len(authorityMatchers, 1)
+	assert.Equal("api.example.com", authorityMatchers[0].GetStringMatch().GetExact())
+	assert.Len(actual.Match.Headers, 3) // method, authority and user-agent
+
+	// The host header is matched when the route has no authority
+	route.HTTPRouteMatch.Authority = ""
+	actual = buildRoute(route, constants.WildcardHTTPMethod)
+	for _, header := range actual.Match.Headers {
+		if header.Name == authorityHeaderKey {
+			assert.Equal("other.example.com", header.GetStringMatch().GetExact())
+		}
+	}
+}
+
+func TestBuildWeightedCluster(t *testing.T) {
+	testCases := []struct {
+		name                string
+		weightedClusters    mapset.Set
+		expectedClusters    int
+		expectedTotalWeight int
+		expectedHeaders     map[string][]*xds_core.HeaderValueOption
+	}{
+		{
+			name: "multiple valid clusters",
+			weightedClusters: mapset.NewSetFromSlice([]interface{}{
+				service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-1|80|local"), Weight: 30},
+				service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-2|80|local"), Weight: 70},
+			}),
+			expectedClusters:    2,
+			expectedTotalWeight: 100,
+		},
+		{
+			name: "clusters with request headers to add",
+			weightedClusters: mapset.NewSetFromSlice([]interface{}{
+				service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-1|80|local"), Weight: 30,
+					RequestHeadersToAdd: service.NewRequestHeaders(map[string]string{"x-version": "v1", "x-canary": "false"})},
+				service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-2|80|local"), Weight: 70},
+			}),
+			expectedClusters:    2,
+			expectedTotalWeight: 100,
+			expectedHeaders: map[string][]*xds_core.HeaderValueOption{
+				"osm/bookstore-1|80|local": {
+					{Header: &xds_core.HeaderValue{Key: "x-canary", Value: "false"}, Append: &wrappers.BoolValue{Value: false}},
+					{Header: &xds_core.HeaderValue{Key: "x-version", Value: "v1"}, Append: &wrappers.BoolValue{Value: false}},
+				},
+				"osm/bookstore-2|80|local": nil,
+			},
+		},
+		{
+			name: "total cluster weight is invalid (< 1)",
+			weightedClusters: mapset.NewSetFromSlice([]interface{}{
+				service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-1|80|local"), Weight: 0},
+				service.WeightedCluster{ClusterName: service.ClusterName("osm/bookstore-2|80|local"), Weight: 0},
+			}),
+			expectedClusters: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			actual := buildWeightedCluster(tc.weightedClusters)
+			if tc.expectedClusters == 0 {
+				assert.Nil(actual)
+				return
+			}
+
+			assert.Len(actual.Clusters, tc.expectedClusters)
+			assert.EqualValues(tc.expectedTotalWeight, actual.TotalWeight.GetValue())
+			for _, cluster := range actual.Clusters {
+				if expectedHeaders, ok := tc.expectedHeaders[cluster.Name]; ok {
+					assert.Equal(expectedHeaders, cluster.RequestHeadersToAdd)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildWeightedClusterWithLabelSubsets(t *testing.T) {
+	assert := tassert.New(t)
+
+	// Two subsets of the same local cluster, selected by different endpoint labels
+	weightedClusters := mapset.NewSetFromSlice([]interface{}{
+		service.WeightedCluster{ClusterName: "ns1/s1|8080|local", Weight: 90,
+			LabelSelector: service.NewLabelSelector(map[string]string{"version": "v1"})},
+		service.WeightedCluster{ClusterName: "ns1/s1|8080|local", Weight: 10,
+			LabelSelector: service.NewLabelSelector(map[string]string{"version": "v2", "track": "canary"})},
+	})
+
+	actual := buildWeightedCluster(weightedClusters)
+	assert.Len(actual.Clusters, 2)
+	assert.EqualValues(100, actual.TotalWeight.GetValue())
+
+	assert.Equal("ns1/s1|8080|local", actual.Clusters[0].Name)
+	assert.EqualValues(10, actual.Clusters[0].Weight.GetValue())
+	assert.Equal(&xds_core.Metadata{
+		FilterMetadata: map[string]*structpb.Struct{
+			"envoy.lb": {Fields: map[string]*structpb.Value{
+				"track":   structpb.NewStringValue("canary"),
+				"version": structpb.NewStringValue("v2"),
+			}},
+		},
+	}, actual.Clusters[0].MetadataMatch)
+
+	assert.Equal("ns1/s1|8080|local", actual.Clusters[1].Name)
+	assert.EqualValues(90, actual.Clusters[1].Weight.GetValue())
+	assert.Equal(&xds_core.Metadata{
+		FilterMetadata: map[string]*structpb.Struct{
+			"envoy.lb": {Fields: map[string]*structpb.Value{
+				"version": structpb.NewStringValue("v1"),
+			}},
+		},
+	}, actual.Clusters[1].MetadataMatch)
+
+	// Clusters without a label selector do not match on endpoint metadata
+	actual = buildWeightedCluster(mapset.NewSet(service.WeightedCluster{ClusterName: "ns1/s1|8080|local", Weight: 100}))
+	assert.Nil(actual.Clusters[0].MetadataMatch)
+}
+
+func TestBuildRetryPolicy(t *testing.T) {
+	testCases := []struct {
+		name        string
+		retryPolicy *policyv1alpha1.RetryPolicySpec
+		expRetry    *xds_route.RetryPolicy
+	}{
+		{
+			name:        "no retry",
+			retryPolicy: nil,
+			expRetry:    nil,
+		},
+		{
+			name: "valid retry policy",
+			retryPolicy: &policyv1alpha1.RetryPolicySpec{
+				RetryOn: "2xx",
+			},
+			expRetry: &xds_route.RetryPolicy{
+				RetryOn: "2xx",
+			},
+		},
+		{
+			name: "valid retry policy with all fields",
+			retryPolicy: &policyv1alpha1.RetryPolicySpec{
+				RetryOn:                  "2xx",
+				PerTryTimeout:            &thresholdTimeoutDuration,
+				NumRetries:               &thresholdUintVal,
+				RetryBackoffBaseInterval: &thresholdBackoffDuration,
+			},
+			expRetry: &xds_route.RetryPolicy{
+				RetryOn:       "2xx",
+				PerTryTimeout: durationpb.New(thresholdTimeoutDuration.Duration),
+				NumRetries: &wrapperspb.UInt32Value{
+					Value: thresholdUintVal,
+				},
+				RetryBackOff: &xds_route.RetryPolicy_RetryBackOff{
+					BaseInterval: durationpb.New(thresholdBackoffDuration.Duration),
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			actual := buildRetryPolicy(tc.retryPolicy)
+			assert.Equal(tc.expRetry, actual)
+		})
+	}
+}
+
+func TestBuildCorsPolicy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cors     *policyv1alpha1.CorsSpec
+		expected *xds_route.CorsPolicy
+	}{
+		{
+			name:     "no CORS policy",
+			cors:     nil,
+			expected: nil,
+		},
+		{
+			name: "CORS policy with all fields",
+			cors: &policyv1alpha1.CorsSpec{
+				AllowOrigins:     []string{"https://foo.com", "*"},
+				AllowMethods:     []string{"GET", "POST"},
+				AllowHeaders:     []string{"x-foo", "x-bar"},
+				MaxAge:           &metav1.Duration{Duration: 10 * time.Minute},
+				AllowCredentials: true,
+			},
+			expected: &xds_route.CorsPolicy{
+				AllowOriginStringMatch: []*xds_matcher.StringMatcher{
+					{
+						MatchPattern: &xds_matcher.StringMatcher_Exact{
+							Exact: "https://foo.com",
+						},
+					},
+					{
+						MatchPattern: &xds_matcher.StringMatcher_SafeRegex{
+							SafeRegex: &xds_matcher.RegexMatcher{
+								EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
+								Regex:      ".*",
+							},
+						},
+					},
+				},
+				AllowMethods:     "GET,POST",
+				AllowHeaders:     "x-foo,x-bar",
+				MaxAge:           "600",
+				AllowCredentials: wrapperspb.Bool(true),
+			},
+		},
+		{
+			name: "CORS policy with only allowed origins",
+			cors: &policyv1alpha1.CorsSpec{
+				AllowOrigins: []string{"https://foo.com"},
+			},
+			expected: &xds_route.CorsPolicy{
+				AllowOriginStringMatch: []*xds_matcher.StringMatcher{
+					{
+						MatchPattern: &xds_matcher.StringMatcher_Exact{
+							Exact: "https://foo.com",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			actual := buildCorsPolicy(tc.cors)
+			assert.Equal(tc.expected, actual)
+		})
+	}
+}
+
+func TestGetLocalRateLimitFilterConfig(t *testing.T) {
+	testCases := []struct {
+		name               string
+		config             *policyv1alpha1.HTTPLocalRateLimitSpec
+		expectedStatusCode xds_type.StatusCode
+		expectedHeaders    []*xds_core.HeaderValueOption
+	}{
+		{
+			name: "default response",
+			config: &policyv1alpha1.HTTPLocalRateLimitSpec{
+				Requests: 10,
+				Unit:     "second",
+			},
+			expectedStatusCode: xds_type.StatusCode_TooManyRequests,
+			expectedHeaders:    nil,
+		},
+		{
+			name: "custom response status code and headers",
+			config: &policyv1alpha1.HTTPLocalRateLimitSpec{
+				Requests:           10,
+				Unit:               "second",
+				ResponseStatusCode: 503,
+				ResponseHeadersToAdd: []policyv1alpha1.HTTPHeaderValue{
+					{Name: "Retry-After", Value: "60"},
+				},
+			},
+			expectedStatusCode: xds_type.StatusCode_ServiceUnavailable,
+			expectedHeaders: []*xds_core.HeaderValueOption{
+				{
+					Header: &xds_core.HeaderValue{Key: "Retry-After", Value: "60"},
+					Append: &wrappers.BoolValue{Value: false},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			marshalled, err := getLocalRateLimitFilterConfig(tc.config)
+			assert.Nil(err)
+
+			rl := &xds_http_local_ratelimit.LocalRateLimit{}
+			assert.Nil(marshalled.UnmarshalTo(rl))
+			assert.Equal(tc.expectedStatusCode, rl.Status.Code)
+			assert.Empty(cmp.Diff(tc.expectedHeaders, rl.ResponseHeadersToAdd, protocmp.Transform()))
+		})
+	}
+}
+
+func TestGetLocalRateLimitFilterConfigTokenBucket(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		config                *policyv1alpha1.HTTPLocalRateLimitSpec
+		expectedMaxTokens     uint32
+		expectedTokensPerFill uint32
+		expectedFillInterval  time.Duration
+	}{
+		{
+			name: "requests per unit with burst",
+			config: &policyv1alpha1.HTTPLocalRateLimitSpec{
+				Requests: 100,
+				Unit:     "minute",
+				Burst:    200,
+			},
+			expectedMaxTokens:     300,
+			expectedTokensPerFill: 100,
+			expectedFillInterval:  time.Minute,
+		},
+		{
+			name: "fill interval overrides unit",
+			config: &policyv1alpha1.HTTPLocalRateLimitSpec{
+				Requests:     100,
+				Unit:         "minute",
+				Burst:        200,
+				FillInterval: &metav1.Duration{Duration: 10 * time.Second},
+			},
+			expectedMaxTokens:     300,
+			expectedTokensPerFill: 100,
+			expectedFillInterval:  10 * time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			marshalled, err := getLocalRateLimitFilterConfig(tc.config)
+			assert.Nil(err)
+
+			rl := &xds_http_local_ratelimit.LocalRateLimit{}
+			assert.Nil(marshalled.UnmarshalTo(rl))
+			assert.Equal(tc.expectedMaxTokens, rl.TokenBucket.MaxTokens)
+			assert.Equal(tc.expectedTokensPerFill, rl.TokenBucket.TokensPerFill.GetValue())
+			assert.Equal(tc.expectedFillInterval, rl.TokenBucket.FillInterval.AsDuration())
+		})
+	}
+}
+
+func TestGetGlobalRateLimitConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		descriptors []policyv1alpha1.HTTPGlobalRateLimitDescriptor
+		expected    []*xds_route.RateLimit
+	}{
+		{
+			name:        "no descriptors",
+			descriptors: nil,
+			expected:    nil,
+		},
+		{
+			name: "request path, request header and remote address entries",
+			descriptors: []policyv1alpha1.HTTPGlobalRateLimitDescriptor{
+				{
+					Entries: []policyv1alpha1.HTTPGlobalRateLimitDescriptorEntry{
+						{
+							RequestPath: &policyv1alpha1.RequestPathDescriptorEntry{},
+						},
+						{
+							RequestHeader: &policyv1alpha1.RequestHeaderDescriptorEntry{
+								Name: "x-user",
+								Key:  "user",
+							},
+						},
+					},
+				},
+				{
+					Entries: []policyv1alpha1.HTTPGlobalRateLimitDescriptorEntry{
+						{
+							RequestPath: &policyv1alpha1.RequestPathDescriptorEntry{
+								Key: "custom_path",
+							},
+						},
+						{
+							RemoteAddress: &policyv1alpha1.RemoteAddressDescriptorEntry{},
+						},
+					},
+				},
+			},
+			expected: []*xds_route.RateLimit{
+				{
+					Actions: []*xds_route.RateLimit_Action{
+						{
+							ActionSpecifier: &xds_route.RateLimit_Action_RequestHeaders_{
+								RequestHeaders: &xds_route.RateLimit_Action_RequestHeaders{
+									HeaderName:    ":path",
+									DescriptorKey: "path",
+								},
+							},
+						},
+						{
+							ActionSpecifier: &xds_route.RateLimit_Action_RequestHeaders_{
+								RequestHeaders: &xds_route.RateLimit_Action_RequestHeaders{
+									HeaderName:    "x-user",
+									DescriptorKey: "user",
+								},
+							},
+						},
+					},
+				},
+				{
+					Actions: []*xds_route.RateLimit_Action{
+						{
+							ActionSpecifier: &xds_route.RateLimit_Action_RequestHeaders_{
+								RequestHeaders: &xds_route.RateLimit_Action_RequestHeaders{
+									HeaderName:    ":path",
+									DescriptorKey: "custom_path",
+								},
+							},
+						},
+						{
+							ActionSpecifier: &xds_route.RateLimit_Action_RemoteAddress_{
+								RemoteAddress: &xds_route.RateLimit_Action_RemoteAddress{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			actual := getGlobalRateLimitConfig(tc.descriptors)
+			assert.Equal(tc.expected, actual)
+		})
+	}
+}
+
+func TestSanitizeHTTPMethods(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		allowedMethods         []string
+		expectedAllowedMethods []string
+	}{
+		{
+			name:                   "returns unique list of allowed methods",
+			allowedMethods:         []string{"GET", "POST", "PUT", "POST", "GET", "GET"},
+			expectedAllowedMethods: []string{"GET", "POST", "PUT"},
+		},
+		{
+			name:                   "returns wildcard allowed method (*)",
+			allowedMethods:         []string{"GET", "POST", "PUT", "POST", "GET", "GET", "*"},
+			expectedAllowedMethods: []string{"*"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			actual := sanitizeHTTPMethods(tc.allowedMethods)
+			assert.Equal(tc.expectedAllowedMethods, actual)
+		})
+	}
+}
+
+func TestNewRouteConfigurationStub(t *testing.T) {
+	assert := tassert.New(t)
+
+	testName := "testing"
+	actual := newRouteConfigurationStub(testName)
+
+	assert.Equal(testName, actual.Name)
+	assert.Nil(actual.VirtualHosts)
+	assert.False(actual.ValidateClusters.Value)
+}
+
+func TestGetRegexForMethod(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "wildcard HTTP method correctly translates to a match all regex",
+			input:    "*",
+			expected: constants.RegexMatchAll,
+		},
+		{
+			name:     "non wildcard HTTP method correctly translates to its corresponding regex",
+			input:    "GET",
+			expected: "GET",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			actual := getRegexForMethod(tc.input)
+			assert.Equal(tc.expected, actual)
+		})
+	}
+}
+
+func TestGetHeadersForRoute(t *testing.T) {
+	assert := tassert.New(t)
+
+	userAgentHeader := "user-agent"
+
+	// Returns a list of HeaderMatcher for a route
 	routePolicy := trafficpolicy.HTTPRouteMatch{
 		Path:          "/books-bought",
 		PathMatchType: trafficpolicy.PathMatchRegex,
@@ -628,8 +1696,9 @@ func TestGetHeadersForRoute(t *testing.T) {
 		Headers: map[string]string{
 			userAgentHeader: "This is a test header",
 		},
+		HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 	}
-	actual := getHeadersForRoute(routePolicy.Methods[0], routePolicy.Headers)
+	actual := getHeadersForRoute(routePolicy.Methods[0], routePolicy.Headers, routePolicy.HeaderMatchType)
 	assert.Equal(2, len(actual))
 	assert.Equal(methodHeaderKey, actual[0].Name)
 	assert.Equal(routePolicy.Methods[0], actual[0].GetSafeRegexMatch().Regex)
@@ -642,7 +1711,7 @@ func TestGetHeadersForRoute(t *testing.T) {
 		PathMatchType: trafficpolicy.PathMatchRegex,
 		Methods:       []string{"GET", "POST"},
 	}
-	actual = getHeadersForRoute(routePolicy.Methods[1], routePolicy.Headers)
+	actual = getHeadersForRoute(routePolicy.Methods[1], routePolicy.Headers, routePolicy.HeaderMatchType)
 	assert.Equal(1, len(actual))
 	assert.Equal(methodHeaderKey, actual[0].Name)
 	assert.Equal(routePolicy.Methods[1], actual[0].GetSafeRegexMatch().Regex)
@@ -655,13 +1724,43 @@ func TestGetHeadersForRoute(t *testing.T) {
 		Headers: map[string]string{
 			"host": tests.HTTPHostHeader,
 		},
+		HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 	}
-	actual = getHeadersForRoute(routePolicy.Methods[0], routePolicy.Headers)
+	actual = getHeadersForRoute(routePolicy.Methods[0], routePolicy.Headers, routePolicy.HeaderMatchType)
 	assert.Equal(2, len(actual))
 	assert.Equal(methodHeaderKey, actual[0].Name)
 	assert.Equal(routePolicy.Methods[0], actual[0].GetSafeRegexMatch().Regex)
 	assert.Equal(authorityHeaderKey, actual[1].Name)
 	assert.Equal(tests.HTTPHostHeader, actual[1].GetSafeRegexMatch().Regex)
+
+	// Returns a HeaderMatcher with an exact match when the header match type is not specified
+	routePolicy = trafficpolicy.HTTPRouteMatch{
+		Path:          "/books-bought",
+		PathMatchType: trafficpolicy.PathMatchRegex,
+		Methods:       []string{"GET", "POST"},
+		Headers: map[string]string{
+			userAgentHeader: ".*Chrome.*",
+			"host":          tests.HTTPHostHeader,
+		},
+	}
+	actual = getHeadersForRoute(routePolicy.Methods[0], routePolicy.Headers, routePolicy.HeaderMatchType)
+	assert.Equal(3, len(actual))
+	assert.Equal(methodHeaderKey, actual[0].Name)
+	assert.Equal(routePolicy.Methods[0], actual[0].GetSafeRegexMatch().Regex)
+	assert.Equal(authorityHeaderKey, actual[1].Name)
+	assert.Equal(tests.HTTPHostHeader, actual[1].GetStringMatch().GetExact())
+	assert.Nil(actual[1].GetSafeRegexMatch())
+	assert.Equal(userAgentHeader, actual[2].Name)
+	assert.Equal(".*Chrome.*", actual[2].GetStringMatch().GetExact())
+	assert.Nil(actual[2].GetSafeRegexMatch())
+
+	// Returns a HeaderMatcher with a regex match when the header match type is regex
+	routePolicy.HeaderMatchType = trafficpolicy.HeaderMatchRegex
+	actual = getHeadersForRoute(routePolicy.Methods[0], routePolicy.Headers, routePolicy.HeaderMatchType)
+	assert.Equal(3, len(actual))
+	assert.Equal(tests.HTTPHostHeader, actual[1].GetSafeRegexMatch().GetRegex())
+	assert.Equal(".*Chrome.*", actual[2].GetSafeRegexMatch().GetRegex())
+	assert.Nil(actual[2].GetStringMatch())
 }
 
 func TestLen(t *testing.T) {
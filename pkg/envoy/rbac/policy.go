@@ -1,9 +1,14 @@
 package rbac
 
 import (
+	"regexp"
+	"strings"
+
 	xds_rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	xds_route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	xds_matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 
+	"github.com/openservicemesh/osm/pkg/envoy"
 	"github.com/openservicemesh/osm/pkg/identity"
 )
 
@@ -13,6 +18,14 @@ type PolicyBuilder struct {
 	allowedPrincipals  []string
 	allowAllPrincipals bool
 
+	// deniedPrincipals are the principals denied the permissions, even if they are allowed
+	deniedPrincipals []string
+	// allowedSourceRanges are the source IP ranges the allowed principals must connect from
+	allowedSourceRanges []*xds_rbac.Principal
+
+	// requiredHeaders are the headers a request must match, in addition to the other permissions
+	requiredHeaders []*xds_route.HeaderMatcher
+
 	// All permissions are applied using OR semantics by default. If applyPermissionsAsAnd is set to true, then
 	// permissions are applied using AND semantics.
 	applyPermissionsAsAnd bool
@@ -32,6 +45,26 @@ func (p *PolicyBuilder) Build() *xds_rbac.Policy {
 		prinicipals = []*xds_rbac.Principal{getAnyPrincipal()}
 	}
 
+	if len(p.allowedSourceRanges) > 0 {
+		// The allowed principals must also connect from one of the allowed source ranges
+		prinicipals = []*xds_rbac.Principal{andPrincipal([]*xds_rbac.Principal{
+			orPrincipal(prinicipals),
+			orPrincipal(p.allowedSourceRanges),
+		})}
+	}
+
+	if len(p.deniedPrincipals) > 0 {
+		// Denied principals take precedence over allowed principals: a principal must be allowed and not denied
+		deniedPrincipals := make([]*xds_rbac.Principal, 0, len(p.deniedPrincipals))
+		for _, principal := range p.deniedPrincipals {
+			deniedPrincipals = append(deniedPrincipals, GetAuthenticatedPrincipal(principal))
+		}
+		prinicipals = []*xds_rbac.Principal{andPrincipal([]*xds_rbac.Principal{
+			orPrincipal(prinicipals),
+			notPrincipal(orPrincipal(deniedPrincipals)),
+		})}
+	}
+
 	// Policies are applied with OR semantics.
 	// See comments on the xds_rbac.Policy.Permissions field for more details.
 	policy.Principals = prinicipals
@@ -56,6 +89,19 @@ func (p *PolicyBuilder) Build() *xds_rbac.Policy {
 		policy.Permissions = permissions
 	}
 
+	if len(p.requiredHeaders) > 0 {
+		// Every required header must match in addition to the permissions above
+		rules := []*xds_rbac.Permission{orPermission(policy.Permissions)}
+		for _, header := range p.requiredHeaders {
+			rules = append(rules, &xds_rbac.Permission{
+				Rule: &xds_rbac.Permission_Header{
+					Header: header,
+				},
+			})
+		}
+		policy.Permissions = []*xds_rbac.Permission{andPermission(rules)}
+	}
+
 	return policy
 }
 
@@ -76,6 +122,23 @@ func (p *PolicyBuilder) AddPrincipal(principal string) {
 	}
 }
 
+// AddDeniedPrincipal adds a principal to the list of denied principals. A denied principal is denied the
+// permissions even if it is allowed, including when any principal is allowed.
+func (p *PolicyBuilder) AddDeniedPrincipal(principal string) {
+	p.deniedPrincipals = append(p.deniedPrincipals, principal)
+}
+
+// AddAllowedSourceRange adds a source IP range, in CIDR notation, to the list of ranges the allowed principals must
+// connect from. The principals are allowed from any source address if no range is added.
+func (p *PolicyBuilder) AddAllowedSourceRange(cidr string) error {
+	principal, err := GetSourceIPPrincipal(cidr)
+	if err != nil {
+		return err
+	}
+	p.allowedSourceRanges = append(p.allowedSourceRanges, principal)
+	return nil
+}
+
 // AllowAnyPrincipal allows any principal to access the permissions.
 func (p *PolicyBuilder) AllowAnyPrincipal() {
 	p.allowedPrincipals = nil
@@ -88,21 +151,95 @@ func (p *PolicyBuilder) AddAllowedDestinationPort(port uint16) {
 	p.allowedPorts = append(p.allowedPorts, uint32(port))
 }
 
+// AddRequiredHeader adds a header that must be present in the request with a value matching the given regex.
+func (p *PolicyBuilder) AddRequiredHeader(name string, regex string) {
+	p.requiredHeaders = append(p.requiredHeaders, &xds_route.HeaderMatcher{
+		Name: name,
+		HeaderMatchSpecifier: &xds_route.HeaderMatcher_SafeRegexMatch{
+			SafeRegexMatch: &xds_matcher.RegexMatcher{
+				EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
+				Regex:      regex,
+			},
+		},
+	})
+}
+
 // GetAuthenticatedPrincipal returns an authenticated RBAC principal object for the given principal
 func GetAuthenticatedPrincipal(principalName string) *xds_rbac.Principal {
 	return &xds_rbac.Principal{
 		Identifier: &xds_rbac.Principal_Authenticated_{
 			Authenticated: &xds_rbac.Principal_Authenticated{
-				PrincipalName: &xds_matcher.StringMatcher{
-					MatchPattern: &xds_matcher.StringMatcher_Exact{
-						Exact: principalName,
-					},
-				},
+				PrincipalName: getPrincipalMatcher(principalName),
 			},
 		},
 	}
 }
 
+// GetSourceIPPrincipal returns an RBAC principal matching the downstream connections from the given IP range in CIDR notation
+func GetSourceIPPrincipal(cidr string) (*xds_rbac.Principal, error) {
+	cidrRange, err := envoy.GetCIDRRangeFromStr(cidr)
+	if err != nil {
+		return nil, err
+	}
+	return &xds_rbac.Principal{
+		Identifier: &xds_rbac.Principal_DirectRemoteIp{
+			DirectRemoteIp: cidrRange,
+		},
+	}, nil
+}
+
+// namespaceRegex matches a single namespace in a principal
+const namespaceRegex = `[^./]+`
+
+// getPrincipalMatcher returns the string matcher for the given principal. A principal matching all the service
+// identities in a trust domain, as returned by identity.TrustDomainPrincipal, matches the principals in the trust domain.
+// A principal matching a service account in all namespaces, as returned by identity.WildcardNamespacePrincipal,
+// matches the principals of the service account in any namespace.
+func getPrincipalMatcher(principalName string) *xds_matcher.StringMatcher {
+	switch {
+	case identity.IsWildcardNamespacePrincipal(principalName):
+		// <name>.*.<trust-domain> and spiffe://<trust-domain>/<name>/* principals of the service account in any namespace.
+		// Only the namespace is matched by the regex, the service account name and trust domain are matched literally.
+		return &xds_matcher.StringMatcher{
+			MatchPattern: &xds_matcher.StringMatcher_SafeRegex{
+				SafeRegex: &xds_matcher.RegexMatcher{
+					EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
+					Regex:      strings.Replace(regexp.QuoteMeta(principalName), regexp.QuoteMeta(identity.WildcardNamespace), namespaceRegex, 1),
+				},
+			},
+		}
+
+	case strings.HasPrefix(principalName, identity.WildcardPrincipal+".") &&
+		!strings.Contains(strings.TrimPrefix(principalName, identity.WildcardPrincipal+"."), identity.WildcardPrincipal):
+		// <name>.<namespace>.<trust-domain> principals in the trust domain. The name and namespace are matched by the
+		// regex so that the principals of a nested trust domain, ex. <name>.<namespace>.nested.<trust-domain>, do not match.
+		trustDomain := strings.TrimPrefix(principalName, identity.WildcardPrincipal+".")
+		return &xds_matcher.StringMatcher{
+			MatchPattern: &xds_matcher.StringMatcher_SafeRegex{
+				SafeRegex: &xds_matcher.RegexMatcher{
+					EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
+					Regex:      "^" + namespaceRegex + `\.` + namespaceRegex + `\.` + regexp.QuoteMeta(trustDomain) + "$",
+				},
+			},
+		}
+
+	case strings.HasSuffix(principalName, "/"+identity.WildcardPrincipal):
+		// spiffe://<trust-domain>/<identity> principals in the trust domain
+		return &xds_matcher.StringMatcher{
+			MatchPattern: &xds_matcher.StringMatcher_Prefix{
+				Prefix: strings.TrimSuffix(principalName, identity.WildcardPrincipal),
+			},
+		}
+
+	default:
+		return &xds_matcher.StringMatcher{
+			MatchPattern: &xds_matcher.StringMatcher_Exact{
+				Exact: principalName,
+			},
+		}
+	}
+}
+
 func getAnyPrincipal() *xds_rbac.Principal {
 	return &xds_rbac.Principal{
 		Identifier: &xds_rbac.Principal_Any{Any: true},
@@ -115,6 +252,40 @@ func getAnyPermission() *xds_rbac.Permission {
 	}
 }
 
+// andPrincipal returns a principal that matches if all the given principals match
+func andPrincipal(principals []*xds_rbac.Principal) *xds_rbac.Principal {
+	return &xds_rbac.Principal{
+		Identifier: &xds_rbac.Principal_AndIds{
+			AndIds: &xds_rbac.Principal_Set{
+				Ids: principals,
+			},
+		},
+	}
+}
+
+// orPrincipal returns a principal that matches if any of the given principals match
+func orPrincipal(principals []*xds_rbac.Principal) *xds_rbac.Principal {
+	if len(principals) == 1 {
+		return principals[0]
+	}
+	return &xds_rbac.Principal{
+		Identifier: &xds_rbac.Principal_OrIds{
+			OrIds: &xds_rbac.Principal_Set{
+				Ids: principals,
+			},
+		},
+	}
+}
+
+// notPrincipal returns a principal that matches if the given principal does not match
+func notPrincipal(principal *xds_rbac.Principal) *xds_rbac.Principal {
+	return &xds_rbac.Principal{
+		Identifier: &xds_rbac.Principal_NotId{
+			NotId: principal,
+		},
+	}
+}
+
 func andPermission(permissions []*xds_rbac.Permission) *xds_rbac.Permission {
 	return &xds_rbac.Permission{
 		Rule: &xds_rbac.Permission_AndRules{
@@ -125,6 +296,20 @@ func andPermission(permissions []*xds_rbac.Permission) *xds_rbac.Permission {
 	}
 }
 
+// orPermission returns a permission that matches if any of the given permissions match
+func orPermission(permissions []*xds_rbac.Permission) *xds_rbac.Permission {
+	if len(permissions) == 1 {
+		return permissions[0]
+	}
+	return &xds_rbac.Permission{
+		Rule: &xds_rbac.Permission_OrRules{
+			OrRules: &xds_rbac.Permission_Set{
+				Rules: permissions,
+			},
+		},
+	}
+}
+
 // GetDestinationPortPermission returns an RBAC permission for the given destination port
 func GetDestinationPortPermission(port uint32) *xds_rbac.Permission {
 	return &xds_rbac.Permission{
@@ -2,12 +2,16 @@ package rbac
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	tassert "github.com/stretchr/testify/assert"
 
+	xds_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	xds_rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	xds_route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	xds_matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestBuild(t *testing.T) {
@@ -186,6 +190,44 @@ func TestBuild(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:       "testing rule for principals matching a trust domain",
+			principals: []string{"*.cluster.local", "spiffe://cluster.new/*"},
+			expectedPolicy: &xds_rbac.Policy{
+				Principals: []*xds_rbac.Principal{
+					{
+						Identifier: &xds_rbac.Principal_Authenticated_{
+							Authenticated: &xds_rbac.Principal_Authenticated{
+								PrincipalName: &xds_matcher.StringMatcher{
+									MatchPattern: &xds_matcher.StringMatcher_SafeRegex{
+										SafeRegex: &xds_matcher.RegexMatcher{
+											EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
+											Regex:      `^[^./]+\.[^./]+\.cluster\.local$`,
+										},
+									},
+								},
+							},
+						},
+					},
+					{
+						Identifier: &xds_rbac.Principal_Authenticated_{
+							Authenticated: &xds_rbac.Principal_Authenticated{
+								PrincipalName: &xds_matcher.StringMatcher{
+									MatchPattern: &xds_matcher.StringMatcher_Prefix{
+										Prefix: "spiffe://cluster.new/",
+									},
+								},
+							},
+						},
+					},
+				},
+				Permissions: []*xds_rbac.Permission{
+					{
+						Rule: &xds_rbac.Permission_Any{Any: true},
+					},
+				},
+			},
+		},
 	}
 
 	for i, tc := range testCases {
@@ -208,3 +250,175 @@ func TestBuild(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildWithRequiredHeaders(t *testing.T) {
+	assert := tassert.New(t)
+
+	pb := &PolicyBuilder{}
+	pb.AddPrincipal("foo.domain.cluster.local")
+	pb.AddAllowedDestinationPort(80)
+	pb.AddAllowedDestinationPort(443)
+	pb.AddRequiredHeader("x-tenant", "tenant-1")
+
+	expectedHeaderPermission := &xds_rbac.Permission{
+		Rule: &xds_rbac.Permission_Header{
+			Header: &xds_route.HeaderMatcher{
+				Name: "x-tenant",
+				HeaderMatchSpecifier: &xds_route.HeaderMatcher_SafeRegexMatch{
+					SafeRegexMatch: &xds_matcher.RegexMatcher{
+						EngineType: &xds_matcher.RegexMatcher_GoogleRe2{GoogleRe2: &xds_matcher.RegexMatcher_GoogleRE2{}},
+						Regex:      "tenant-1",
+					},
+				},
+			},
+		},
+	}
+
+	policy := pb.Build()
+	assert.Equal([]*xds_rbac.Principal{GetAuthenticatedPrincipal("foo.domain.cluster.local")}, policy.Principals)
+	assert.Equal([]*xds_rbac.Permission{
+		andPermission([]*xds_rbac.Permission{
+			orPermission([]*xds_rbac.Permission{
+				GetDestinationPortPermission(80),
+				GetDestinationPortPermission(443),
+			}),
+			expectedHeaderPermission,
+		}),
+	}, policy.Permissions)
+
+	// Without other permissions, only the required headers need to match
+	pb = &PolicyBuilder{}
+	pb.AddRequiredHeader("x-tenant", "tenant-1")
+
+	policy = pb.Build()
+	assert.Equal([]*xds_rbac.Permission{
+		andPermission([]*xds_rbac.Permission{
+			getAnyPermission(),
+			expectedHeaderPermission,
+		}),
+	}, policy.Permissions)
+}
+
+func TestBuildWithDeniedPrincipals(t *testing.T) {
+	assert := tassert.New(t)
+
+	pb := &PolicyBuilder{}
+	pb.AddPrincipal("foo.domain.cluster.local")
+	pb.AddPrincipal("bar.domain.cluster.local")
+	pb.AddDeniedPrincipal("bar.domain.cluster.local")
+
+	policy := pb.Build()
+	assert.Equal([]*xds_rbac.Principal{
+		andPrincipal([]*xds_rbac.Principal{
+			orPrincipal([]*xds_rbac.Principal{
+				GetAuthenticatedPrincipal("foo.domain.cluster.local"),
+				GetAuthenticatedPrincipal("bar.domain.cluster.local"),
+			}),
+			notPrincipal(GetAuthenticatedPrincipal("bar.domain.cluster.local")),
+		}),
+	}, policy.Principals)
+	assert.Equal([]*xds_rbac.Permission{getAnyPermission()}, policy.Permissions)
+
+	// Denied principals are denied even if any principal is allowed
+	pb = &PolicyBuilder{}
+	pb.AllowAnyPrincipal()
+	pb.AddDeniedPrincipal("foo.domain.cluster.local")
+	pb.AddDeniedPrincipal("bar.domain.cluster.local")
+
+	policy = pb.Build()
+	assert.Equal([]*xds_rbac.Principal{
+		andPrincipal([]*xds_rbac.Principal{
+			getAnyPrincipal(),
+			notPrincipal(orPrincipal([]*xds_rbac.Principal{
+				GetAuthenticatedPrincipal("foo.domain.cluster.local"),
+				GetAuthenticatedPrincipal("bar.domain.cluster.local"),
+			})),
+		}),
+	}, policy.Principals)
+}
+
+func TestBuildWithAllowedSourceRanges(t *testing.T) {
+	assert := tassert.New(t)
+
+	pb := &PolicyBuilder{}
+	pb.AddPrincipal("foo.domain.cluster.local")
+	assert.Nil(pb.AddAllowedSourceRange("10.0.0.0/8"))
+	assert.Nil(pb.AddAllowedSourceRange("fd00::/8"))
+	assert.NotNil(pb.AddAllowedSourceRange("10.0.0.1"))
+
+	// The allowed principals must connect from one of the allowed source ranges
+	policy := pb.Build()
+	assert.Equal([]*xds_rbac.Principal{
+		andPrincipal([]*xds_rbac.Principal{
+			GetAuthenticatedPrincipal("foo.domain.cluster.local"),
+			orPrincipal([]*xds_rbac.Principal{
+				{
+					Identifier: &xds_rbac.Principal_DirectRemoteIp{
+						DirectRemoteIp: &xds_core.CidrRange{AddressPrefix: "10.0.0.0", PrefixLen: wrapperspb.UInt32(8)},
+					},
+				},
+				{
+					Identifier: &xds_rbac.Principal_DirectRemoteIp{
+						DirectRemoteIp: &xds_core.CidrRange{AddressPrefix: "fd00::", PrefixLen: wrapperspb.UInt32(8)},
+					},
+				},
+			}),
+		}),
+	}, policy.Principals)
+	assert.Equal([]*xds_rbac.Permission{getAnyPermission()}, policy.Permissions)
+}
+
+func TestGetPrincipalMatcherWildcardNamespace(t *testing.T) {
+	testCases := []struct {
+		name          string
+		principal     string
+		expectedRegex string
+		matching      []string
+		notMatching   []string
+	}{
+		{
+			name:          "principal with a wildcard namespace",
+			principal:     "prometheus.*.cluster.local",
+			expectedRegex: `prometheus\.[^./]+\.cluster\.local`,
+			matching:      []string{"prometheus.ns1.cluster.local", "prometheus.ns2.cluster.local"},
+			notMatching:   []string{"grafana.ns1.cluster.local", "prometheus.ns1.cluster.localhost", "prometheus.ns1.ns2.cluster.local", "prometheus..cluster.local"},
+		},
+		{
+			name:          "principal matching a trust domain",
+			principal:     "*.cluster.local",
+			expectedRegex: `^[^./]+\.[^./]+\.cluster\.local$`,
+			matching:      []string{"prometheus.ns1.cluster.local", "grafana.ns2.cluster.local"},
+			notMatching:   []string{"sa.ns.evil.cluster.local", "prometheus.ns1.cluster.localhost", "prometheus.ns1.xcluster.local"},
+		},
+		{
+			name:          "SPIFFE principal with a wildcard namespace",
+			principal:     "spiffe://cluster.local/prometheus/*",
+			expectedRegex: `spiffe://cluster\.local/prometheus/[^./]+`,
+			matching:      []string{"spiffe://cluster.local/prometheus/ns1", "spiffe://cluster.local/prometheus/ns2"},
+			notMatching:   []string{"spiffe://cluster.local/grafana/ns1", "spiffe://cluster.local/prometheus/ns1/extra"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			regex := getPrincipalMatcher(tc.principal).GetSafeRegex().GetRegex()
+			assert.Equal(tc.expectedRegex, regex)
+
+			// Envoy requires the regex to match the full principal
+			re := regexp.MustCompile("^" + regex + "$")
+			for _, principal := range tc.matching {
+				assert.True(re.MatchString(principal), principal)
+			}
+			for _, principal := range tc.notMatching {
+				assert.False(re.MatchString(principal), principal)
+			}
+		})
+	}
+
+	// A wildcard service account name is not loosened into a regex
+	assert := tassert.New(t)
+	assert.Nil(getPrincipalMatcher("*.*.cluster.local").GetSafeRegex())
+	assert.Nil(getPrincipalMatcher("spiffe://cluster.local/*/*").GetSafeRegex())
+}
@@ -92,9 +92,12 @@ const (
 	HTTPConnectionManagerFilterName = wellknown.HTTPConnectionManager
 	HTTPRouterFilterName            = "http_router"
 	HTTPLuaFilterName               = "http_lua"
+	HTTPCORSFilterName              = "http_cors"
 
-	HTTPExtAuthzFilterName    = "http_external_authz"
-	HTTPHealthCheckFilterName = "http_health_check"
+	HTTPExtAuthzFilterName         = "http_external_authz"
+	HTTPHealthCheckFilterName      = "http_health_check"
+	HTTPHeaderToMetadataFilterName = "http_header_to_metadata"
+	HTTPGRPCWebFilterName          = "http_grpc_web"
 
 	// The HTTP typed filters referenced in the RDS configuration still need to
 	// use wellknown names. These filters are configured as a map where the key is
@@ -103,6 +106,7 @@ const (
 	HTTPRBACFilterName            = "envoy.filters.http.rbac"
 	HTTPLocalRateLimitFilterName  = "envoy.filters.http.local_ratelimit"
 	HTTPGlobalRateLimitFilterName = "envoy.filters.http.ratelimit"
+	HTTPFaultFilterName           = "envoy.filters.http.fault"
 
 	// Network (L4) filters
 	TCPProxyFilterName          = "tcp_proxy"
@@ -120,6 +124,7 @@ const (
 const (
 	HTTPRouterFilterTypeURL    = "type.googleapis.com/envoy.extensions.filters.http.router.v3.Router"
 	HTTPRBACFilterTypeURL      = "type.googleapis.com/envoy.extensions.filters.http.rbac.v3.RBAC"
+	HTTPCORSFilterTypeURL      = "type.googleapis.com/envoy.extensions.filters.http.cors.v3.Cors"
 	OriginalDstFilterTypeURL   = "type.googleapis.com/envoy.extensions.filters.listener.original_dst.v3.OriginalDst"
 	TLSInspectorFilterTypeURL  = "type.googleapis.com/envoy.extensions.filters.listener.tls_inspector.v3.TlsInspector"
 	HTTPInspectorFilterTypeURL = "type.googleapis.com/envoy.extensions.filters.listener.http_inspector.v3.HttpInspector"
@@ -24,6 +24,9 @@ const (
 
 	// StreamAccessLoggerName is name used for the envoy stream access logger
 	StreamAccessLoggerName = "envoy.access_loggers.stream"
+
+	// UnixDomainSocketAddressPrefix is the prefix of an address referring to a unix domain socket path
+	UnixDomainSocketAddressPrefix = "unix://"
 )
 
 // ALPNInMesh indicates that the proxy is connecting to an in-mesh destination.
@@ -45,6 +48,17 @@ func GetAddress(address string, port uint32) *xds_core.Address {
 	}
 }
 
+// GetPipeAddress creates an Envoy Address struct for the given unix domain socket path.
+func GetPipeAddress(path string) *xds_core.Address {
+	return &xds_core.Address{
+		Address: &xds_core.Address_Pipe{
+			Pipe: &xds_core.Pipe{
+				Path: path,
+			},
+		},
+	}
+}
+
 // GetTLSParams creates Envoy TlsParameters struct.
 func GetTLSParams(sidecarSpec configv1alpha2.SidecarSpec) *xds_auth.TlsParameters {
 	minVersionInt := xds_auth.TlsParameters_TlsProtocol_value[sidecarSpec.TLSMinProtocolVersion]
@@ -78,6 +78,12 @@ const (
 
 	// ErrInvalidSourceKind	indicated an applied SMI TrafficTarget policy has an invalid source kind
 	ErrInvalidSourceKind
+
+	// ErrConflictingPortProtocols indicates multiple upstream services declare different protocols for the same port
+	ErrConflictingPortProtocols
+
+	// ErrInvalidUpstreamTrafficSetting indicates an UpstreamTrafficSetting resource has an invalid configuration
+	ErrInvalidUpstreamTrafficSetting
 )
 
 // Range 3000-3500 is reserved for errors related to k8s constructs (service accounts, namespaces, etc.)
@@ -437,6 +443,12 @@ An invalid IP address range was specified in the egress policy. The IP address r
 must be specified as as a CIDR notation IP address and prefix length, like "192.0.2.0/24",
 as defined in RFC 4632.
 The invalid IP address range was ignored by the system.
+`,
+
+	ErrInvalidUpstreamTrafficSetting: `
+An UpstreamTrafficSetting resource has an invalid rate limiting configuration.
+The UpstreamTrafficSetting resource was ignored by the system while building the inbound
+traffic policies for the upstream service it applies to.
 `,
 
 	ErrInvalidEgressMatches: `
@@ -487,6 +499,14 @@ An applied SMI TrafficTarget policy has an invalid source kind.
 	ErrGettingInboundTrafficTargets: `
 The inbound TrafficTargets composed of their routes for a given destination
 ServiceIdentity could not be configured.
+`,
+
+	ErrConflictingPortProtocols: `
+Multiple services backed by the same workload declare different application protocols
+for the same target port.
+The protocol with the highest precedence (http, grpc, tcp, tcp-server-first) is used
+for the port, and the services declaring a conflicting protocol for the port are
+ignored by the system.
 `,
 
 	//
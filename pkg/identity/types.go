@@ -26,6 +26,37 @@ const WildcardServiceIdentity ServiceIdentity = "*"
 // WildcardPrincipal is a wildcard to match all principals. A principal is a service identity with a trust domain.
 const WildcardPrincipal = "*"
 
+// TrustDomainPrincipal returns a principal matching all the service identities in the given trust domain.
+// If identity is Spiffe ID is enabled then it will return the value in Spiffe format
+func TrustDomainPrincipal(trustDomain string, spiffeEnabled bool) string {
+	if spiffeEnabled {
+		return fmt.Sprintf("spiffe://%s/%s", trustDomain, WildcardPrincipal)
+	}
+	return fmt.Sprintf("%s.%s", WildcardPrincipal, trustDomain)
+}
+
+// WildcardNamespace is a wildcard namespace to match the service accounts with a given name in all namespaces
+const WildcardNamespace = "*"
+
+// WildcardNamespacePrincipal returns a principal matching the service accounts with the given name in all the
+// namespaces of the given trust domain. If identity is Spiffe ID is enabled then it will return the value in Spiffe format
+func WildcardNamespacePrincipal(name, trustDomain string, spiffeEnabled bool) string {
+	return K8sServiceAccount{Name: name, Namespace: WildcardNamespace}.AsPrincipal(trustDomain, spiffeEnabled)
+}
+
+// IsWildcardNamespacePrincipal returns true if the given principal, as returned by WildcardNamespacePrincipal, matches
+// a service account name in all namespaces. Principals with a wildcard service account name are not matched.
+func IsWildcardNamespacePrincipal(principal string) bool {
+	if spiffeID := strings.TrimPrefix(principal, "spiffe://"); spiffeID != principal {
+		// spiffe://<trust-domain>/<name>/<namespace>
+		chunks := strings.Split(spiffeID, "/")
+		return len(chunks) == 3 && chunks[1] != WildcardPrincipal && chunks[2] == WildcardNamespace
+	}
+	// <name>.<namespace>.<trust-domain>
+	chunks := strings.SplitN(principal, ".", 3)
+	return len(chunks) == 3 && chunks[0] != WildcardPrincipal && chunks[1] == WildcardNamespace
+}
+
 // String returns the ServiceIdentity as a string
 func (si ServiceIdentity) String() string {
 	return string(si)
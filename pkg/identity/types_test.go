@@ -103,3 +103,28 @@ func TestServiceIdentity_AsPrincipal(t *testing.T) {
 		})
 	}
 }
+
+func TestTrustDomainPrincipal(t *testing.T) {
+	assert := tassert.New(t)
+
+	assert.Equal("*.cluster.local", TrustDomainPrincipal("cluster.local", false))
+	assert.Equal("spiffe://cluster.local/*", TrustDomainPrincipal("cluster.local", true))
+}
+
+func TestWildcardNamespacePrincipal(t *testing.T) {
+	assert := tassert.New(t)
+
+	assert.Equal("prometheus.*.cluster.local", WildcardNamespacePrincipal("prometheus", "cluster.local", false))
+	assert.Equal("spiffe://cluster.local/prometheus/*", WildcardNamespacePrincipal("prometheus", "cluster.local", true))
+
+	assert.True(IsWildcardNamespacePrincipal("prometheus.*.cluster.local"))
+	assert.True(IsWildcardNamespacePrincipal("spiffe://cluster.local/prometheus/*"))
+
+	// Only the namespace may be a wildcard
+	assert.False(IsWildcardNamespacePrincipal("prometheus.monitoring.cluster.local"))
+	assert.False(IsWildcardNamespacePrincipal("spiffe://cluster.local/prometheus/monitoring"))
+	assert.False(IsWildcardNamespacePrincipal(TrustDomainPrincipal("cluster.local", false)))
+	assert.False(IsWildcardNamespacePrincipal(TrustDomainPrincipal("cluster.local", true)))
+	assert.False(IsWildcardNamespacePrincipal("*.*.cluster.local"))
+	assert.False(IsWildcardNamespacePrincipal("spiffe://cluster.local/*/*"))
+}
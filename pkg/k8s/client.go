@@ -181,6 +181,18 @@ func (c *Client) ListPods() []*corev1.Pod {
 	return pods
 }
 
+// GetPod returns the pod with the given name and namespace if it is part of the mesh, otherwise returns nil
+func (c *Client) GetPod(name, namespace string) *corev1.Pod {
+	if !c.IsMonitoredNamespace(namespace) {
+		return nil
+	}
+	podIf, exists, err := c.getByKey(informerKeyPod, key(name, namespace))
+	if exists && err == nil {
+		return podIf.(*corev1.Pod)
+	}
+	return nil
+}
+
 // GetEndpoints returns the endpoint for a given service, otherwise returns nil if not found
 // or error if the API errored out.
 func (c *Client) GetEndpoints(name, namespace string) (*corev1.Endpoints, error) {
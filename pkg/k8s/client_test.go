@@ -605,6 +605,60 @@ func TestListPods(t *testing.T) {
 	}
 }
 
+func TestGetPod(t *testing.T) {
+	monitoredNamespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "ns1",
+			Labels: map[string]string{
+				constants.OSMKubeResourceMonitorAnnotation: testMeshName,
+			},
+		},
+	}
+	pods := []runtime.Object{
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "p1"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns2", Name: "p1"}},
+	}
+
+	testCases := []struct {
+		name         string
+		podName      string
+		podNamespace string
+		expected     *corev1.Pod
+	}{
+		{
+			name:         "gets the pod from the cache given its key",
+			podName:      "p1",
+			podNamespace: "ns1",
+			expected:     &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "p1"}},
+		},
+		{
+			name:         "returns nil if the pod is not found in the cache",
+			podName:      "invalid",
+			podNamespace: "ns1",
+			expected:     nil,
+		},
+		{
+			name:         "returns nil if the namespace of the pod is not monitored",
+			podName:      "p1",
+			podNamespace: "ns2",
+			expected:     nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := tassert.New(t)
+			stop := make(chan struct{})
+			broker := messaging.NewBroker(stop)
+
+			c, err := NewClient("osm", tests.OsmMeshConfigName, broker, WithKubeClient(fake.NewSimpleClientset(append([]runtime.Object{monitoredNamespace}, pods...)...), testMeshName))
+			a.NoError(err)
+
+			a.Equal(tc.expected, c.GetPod(tc.podName, tc.podNamespace))
+		})
+	}
+}
+
 func TestGetEndpoints(t *testing.T) {
 	testCases := []struct {
 		name         string
@@ -155,6 +155,20 @@ func (mr *MockControllerMockRecorder) GetOSMNamespace() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOSMNamespace", reflect.TypeOf((*MockController)(nil).GetOSMNamespace))
 }
 
+// GetPod mocks base method.
+func (m *MockController) GetPod(arg0, arg1 string) *v1.Pod {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPod", arg0, arg1)
+	ret0, _ := ret[0].(*v1.Pod)
+	return ret0
+}
+
+// GetPod indicates an expected call of GetPod.
+func (mr *MockControllerMockRecorder) GetPod(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPod", reflect.TypeOf((*MockController)(nil).GetPod), arg0, arg1)
+}
+
 // GetSecret mocks base method.
 func (m *MockController) GetSecret(arg0, arg1 string) *models.Secret {
 	m.ctrl.T.Helper()
@@ -93,6 +93,9 @@ type Controller interface {
 	// ListPods returns a list of pods part of the mesh
 	ListPods() []*corev1.Pod
 
+	// GetPod returns the pod with the given name and namespace part of the mesh, if found
+	GetPod(name, namespace string) *corev1.Pod
+
 	// GetEndpoints returns the endpoints for a given service, if found
 	GetEndpoints(name, namespace string) (*corev1.Endpoints, error)
 
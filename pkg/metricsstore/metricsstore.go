@@ -99,6 +99,12 @@ type MetricsStore struct {
 	// ReconciliationTotal counts the number of resource reconciliations invoked
 	ReconciliationTotal *prometheus.CounterVec
 
+	/*
+	 * Catalog metrics
+	 */
+	// InboundRulesPerPolicy is the number of rules in the inbound traffic policies of each service
+	InboundRulesPerPolicy *prometheus.GaugeVec
+
 	/*
 	 * MetricsStore internals should be defined below --------------
 	 */
@@ -275,6 +281,15 @@ func init() {
 		Help:      "Counter of resource reconciliations invoked",
 	}, []string{"kind"})
 
+	/*
+	 * Catalog metrics
+	 */
+	defaultMetricsStore.InboundRulesPerPolicy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsRootNamespace,
+		Name:      "inbound_rules_per_policy",
+		Help:      "Represents the number of rules in the inbound traffic policies of a service",
+	}, []string{"namespace", "service"})
+
 	defaultMetricsStore.registry = prometheus.NewRegistry()
 }
 
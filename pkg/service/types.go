@@ -3,6 +3,8 @@ package service
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
 )
@@ -107,4 +109,80 @@ func (c ClusterName) String() string {
 type WeightedCluster struct {
 	ClusterName ClusterName `json:"cluster_name:omitempty"`
 	Weight      int         `json:"weight:omitempty"`
+
+	// RequestHeadersToAdd defines the headers added to the requests routed to the cluster
+	// +optional
+	RequestHeadersToAdd RequestHeaders `json:"request_headers_to_add:omitempty"`
+
+	// LabelSelector selects the subset of the cluster's endpoints, by endpoint labels, the traffic to the
+	// cluster is routed to. All endpoints are selected if unset.
+	// +optional
+	LabelSelector LabelSelector `json:"label_selector:omitempty"`
+}
+
+// LabelSelector is a set of endpoint labels in a canonical form, comparable so that a WeightedCluster can be
+// used as a set element. It is of the form <key>=<value>,... with the labels sorted by key.
+type LabelSelector string
+
+// NewLabelSelector returns the LabelSelector for the given map of label keys to values
+func NewLabelSelector(labels map[string]string) LabelSelector {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(pairs)
+	return LabelSelector(strings.Join(pairs, ","))
+}
+
+// Map returns the map of label keys to values of the LabelSelector
+func (s LabelSelector) Map() map[string]string {
+	if s == "" {
+		return nil
+	}
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(string(s), ",") {
+		key, value, _ := strings.Cut(pair, "=")
+		labels[key] = value
+	}
+	return labels
+}
+
+// Keys returns the sorted label keys of the LabelSelector
+func (s LabelSelector) Keys() []string {
+	if s == "" {
+		return nil
+	}
+	var keys []string
+	for _, pair := range strings.Split(string(s), ",") {
+		key, _, _ := strings.Cut(pair, "=")
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// RequestHeaders is a set of HTTP request headers in a canonical form, comparable so that a WeightedCluster
+// can be used as a set element. It is of the form <name>=<value>,... with the headers sorted by name.
+type RequestHeaders string
+
+// NewRequestHeaders returns the RequestHeaders for the given map of header names to values
+func NewRequestHeaders(headers map[string]string) RequestHeaders {
+	pairs := make([]string, 0, len(headers))
+	for name, value := range headers {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(pairs)
+	return RequestHeaders(strings.Join(pairs, ","))
+}
+
+// Map returns the map of header names to values of the RequestHeaders
+func (h RequestHeaders) Map() map[string]string {
+	if h == "" {
+		return nil
+	}
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(string(h), ",") {
+		name, value, _ := strings.Cut(pair, "=")
+		headers[name] = value
+	}
+	return headers
 }
@@ -78,3 +78,31 @@ func TestMeshServiceCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestHeaders(t *testing.T) {
+	assert := tassert.New(t)
+
+	headers := NewRequestHeaders(map[string]string{"x-version": "v2", "x-canary": "true"})
+	assert.Equal(RequestHeaders("x-canary=true,x-version=v2"), headers)
+	assert.Equal(map[string]string{"x-version": "v2", "x-canary": "true"}, headers.Map())
+
+	// WeightedClusters with the same headers are equal, so they can be used as set elements
+	assert.Equal(WeightedCluster{ClusterName: "c1", RequestHeadersToAdd: headers},
+		WeightedCluster{ClusterName: "c1", RequestHeadersToAdd: NewRequestHeaders(map[string]string{"x-canary": "true", "x-version": "v2"})})
+
+	assert.Equal(RequestHeaders(""), NewRequestHeaders(nil))
+	assert.Nil(RequestHeaders("").Map())
+}
+
+func TestLabelSelector(t *testing.T) {
+	assert := tassert.New(t)
+
+	selector := NewLabelSelector(map[string]string{"version": "v2", "app": "bookstore"})
+	assert.Equal(LabelSelector("app=bookstore,version=v2"), selector)
+	assert.Equal(map[string]string{"version": "v2", "app": "bookstore"}, selector.Map())
+	assert.Equal([]string{"app", "version"}, selector.Keys())
+
+	assert.Equal(LabelSelector(""), NewLabelSelector(nil))
+	assert.Nil(LabelSelector("").Map())
+	assert.Nil(LabelSelector("").Keys())
+}
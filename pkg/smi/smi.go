@@ -1,8 +1,12 @@
 package smi
 
 import (
+	"strings"
+
 	smiAccess "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/access/v1alpha3"
+	smiSpecs "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/specs/v1alpha4"
 	smiSplit "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/split/v1alpha2"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // FilterTrafficSplit applies the given TrafficSplitListOption filter on the given TrafficSplit object
@@ -24,13 +28,10 @@ func FilterTrafficSplit(trafficSplit *smiSplit.TrafficSplit, options ...TrafficS
 
 	// If backend service filter option is set, ignore traffic splits whose backend service does not match
 	if o.BackendService.Name != "" {
-		if trafficSplit.Namespace != o.BackendService.Namespace {
-			return nil
-		}
-
 		backendFound := false
 		for _, backend := range trafficSplit.Spec.Backends {
-			if backend.Service == o.BackendService.Name {
+			name, namespace := GetTrafficSplitBackendService(trafficSplit, backend.Service)
+			if name == o.BackendService.Name && namespace == o.BackendService.Namespace {
 				backendFound = true
 				break
 			}
@@ -43,6 +44,30 @@ func FilterTrafficSplit(trafficSplit *smiSplit.TrafficSplit, options ...TrafficS
 	return trafficSplit
 }
 
+// TrafficSplitExternalBackendPrefix is the prefix of a TrafficSplit backend that references a host external to the
+// mesh instead of a service, ex. "external:legacy.example.com"
+const TrafficSplitExternalBackendPrefix = "external:"
+
+// GetTrafficSplitExternalBackendHost returns the external host referenced by the given backend of a TrafficSplit, and a
+// boolean indicating if the backend references an external host
+func GetTrafficSplitExternalBackendHost(backend string) (string, bool) {
+	if !strings.HasPrefix(backend, TrafficSplitExternalBackendPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(backend, TrafficSplitExternalBackendPrefix), true
+}
+
+// GetTrafficSplitBackendService returns the name and namespace of the service referenced by the given backend of the
+// given TrafficSplit. A backend can reference a service in another namespace in the form "name.namespace", otherwise
+// the service is in the namespace of the TrafficSplit.
+func GetTrafficSplitBackendService(trafficSplit *smiSplit.TrafficSplit, backend string) (string, string) {
+	if strings.Count(backend, ".") == 1 {
+		name, namespace, _ := strings.Cut(backend, ".")
+		return name, namespace
+	}
+	return backend, trafficSplit.Namespace
+}
+
 // FilterTrafficTarget applies the given TrafficTargetListOption filter on the given TrafficTarget object
 func FilterTrafficTarget(trafficTarget *smiAccess.TrafficTarget, options ...TrafficTargetListOption) *smiAccess.TrafficTarget {
 	if trafficTarget == nil {
@@ -82,14 +107,34 @@ func HasValidRules(rules []smiAccess.TrafficTargetRule) bool {
 		return false
 	}
 	for _, rule := range rules {
-		switch rule.Kind {
-		case HTTPRouteGroupKind, TCPRouteKind:
-			// valid Kind for rules
-
-		default:
+		if _, ok := ParseRouteKind(rule.Kind); !ok {
 			log.Error().Msgf("Invalid Kind for rule %s in TrafficTarget policy %s", rule.Name, rule.Kind)
 			return false
 		}
 	}
 	return true
 }
+
+// ParseRouteKind returns the SMI route kind referenced by the given TrafficTarget rule kind, and whether it is a known
+// SMI route kind. The rule kind may be unqualified, e.g. HTTPRouteGroup, qualified with the SMI specs API group, e.g.
+// HTTPRouteGroup.specs.smi-spec.io, or qualified with a version and the SMI specs API group, e.g.
+// HTTPRouteGroup.v1alpha4.specs.smi-spec.io.
+func ParseRouteKind(ruleKind string) (string, bool) {
+	gvk, gk := schema.ParseKindArg(ruleKind)
+	switch {
+	case gk.Group == "" || gk.Group == smiSpecs.SchemeGroupVersion.Group:
+		// Unqualified kind, or kind qualified with the API group
+	case gvk != nil && gvk.Group == smiSpecs.SchemeGroupVersion.Group:
+		// Kind qualified with a version and the API group
+		gk = gvk.GroupKind()
+	default:
+		return "", false
+	}
+
+	switch gk.Kind {
+	case HTTPRouteGroupKind, TCPRouteKind:
+		return gk.Kind, true
+	default:
+		return "", false
+	}
+}
@@ -13,6 +13,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	smiAccess "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/access/v1alpha3"
+	smiSplit "github.com/servicemeshinterface/smi-sdk-go/pkg/apis/split/v1alpha2"
 
 	"github.com/openservicemesh/osm/pkg/constants"
 )
@@ -132,6 +133,80 @@ func TestHasValidRules(t *testing.T) {
 	}
 }
 
+func TestParseRouteKind(t *testing.T) {
+	testCases := []struct {
+		ruleKind     string
+		expectedKind string
+		expectedOk   bool
+	}{
+		{ruleKind: HTTPRouteGroupKind, expectedKind: HTTPRouteGroupKind, expectedOk: true},
+		{ruleKind: TCPRouteKind, expectedKind: TCPRouteKind, expectedOk: true},
+		{ruleKind: "HTTPRouteGroup.specs.smi-spec.io", expectedKind: HTTPRouteGroupKind, expectedOk: true},
+		{ruleKind: "TCPRoute.specs.smi-spec.io", expectedKind: TCPRouteKind, expectedOk: true},
+		{ruleKind: "HTTPRouteGroup.v1alpha4.specs.smi-spec.io", expectedKind: HTTPRouteGroupKind, expectedOk: true},
+		{ruleKind: "HTTPRouteGroup.example.com", expectedKind: "", expectedOk: false},
+		{ruleKind: "HTTPRouteGroup.v1alpha4.example.com", expectedKind: "", expectedOk: false},
+		{ruleKind: "HTTPRoutGroup", expectedKind: "", expectedOk: false},
+		{ruleKind: "UDPRoute.specs.smi-spec.io", expectedKind: "", expectedOk: false},
+		{ruleKind: "", expectedKind: "", expectedOk: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ruleKind, func(t *testing.T) {
+			a := assert.New(t)
+			kind, ok := ParseRouteKind(tc.ruleKind)
+			a.Equal(tc.expectedKind, kind)
+			a.Equal(tc.expectedOk, ok)
+		})
+	}
+}
+
+func TestGetTrafficSplitBackendService(t *testing.T) {
+	trafficSplit := &smiSplit.TrafficSplit{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "split1"}}
+
+	testCases := []struct {
+		backend           string
+		expectedName      string
+		expectedNamespace string
+	}{
+		{backend: "s1", expectedName: "s1", expectedNamespace: "ns1"},
+		{backend: "s1.ns2", expectedName: "s1", expectedNamespace: "ns2"},
+		{backend: "legacy.example.com", expectedName: "legacy.example.com", expectedNamespace: "ns1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.backend, func(t *testing.T) {
+			a := assert.New(t)
+			name, namespace := GetTrafficSplitBackendService(trafficSplit, tc.backend)
+			a.Equal(tc.expectedName, name)
+			a.Equal(tc.expectedNamespace, namespace)
+		})
+	}
+}
+
+func TestGetTrafficSplitExternalBackendHost(t *testing.T) {
+	testCases := []struct {
+		backend            string
+		expectedHost       string
+		expectedIsExternal bool
+	}{
+		{backend: "s1", expectedHost: "", expectedIsExternal: false},
+		{backend: "s1.ns2", expectedHost: "", expectedIsExternal: false},
+		{backend: "example.com", expectedHost: "", expectedIsExternal: false},
+		{backend: "external:example.com", expectedHost: "example.com", expectedIsExternal: true},
+		{backend: "external:legacy.example.com", expectedHost: "legacy.example.com", expectedIsExternal: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.backend, func(t *testing.T) {
+			a := assert.New(t)
+			host, isExternal := GetTrafficSplitExternalBackendHost(tc.backend)
+			a.Equal(tc.expectedHost, host)
+			a.Equal(tc.expectedIsExternal, isExternal)
+		})
+	}
+}
+
 func TestIsValidTrafficTarget(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -291,32 +291,35 @@ var (
 	// BookstoreBuyHTTPRoute is an HTTP route to buy books
 	BookstoreBuyHTTPRoute = trafficpolicy.HTTPRouteMatch{
 		Path:          BookstoreBuyPath,
-		PathMatchType: trafficpolicy.PathMatchRegex,
+		PathMatchType: trafficpolicy.PathMatchExact,
 		Methods:       []string{"GET"},
 		Headers: map[string]string{
 			"user-agent": HTTPUserAgent,
 		},
+		HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 	}
 
 	// BookstoreBuyHTTPRouteWithHost is an HTTP route to buy books
 	BookstoreBuyHTTPRouteWithHost = trafficpolicy.HTTPRouteMatch{
 		Path:          BookstoreBuyPath,
-		PathMatchType: trafficpolicy.PathMatchRegex,
+		PathMatchType: trafficpolicy.PathMatchExact,
 		Methods:       []string{"GET"},
 		Headers: map[string]string{
 			"user-agent": HTTPUserAgent,
 			"host":       HTTPHostHeader,
 		},
+		HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 	}
 
 	// BookstoreSellHTTPRoute is an HTTP route to sell books
 	BookstoreSellHTTPRoute = trafficpolicy.HTTPRouteMatch{
 		Path:          BookstoreSellPath,
-		PathMatchType: trafficpolicy.PathMatchRegex,
+		PathMatchType: trafficpolicy.PathMatchExact,
 		Methods:       []string{"GET"},
 		Headers: map[string]string{
 			"user-agent": HTTPUserAgent,
 		},
+		HeaderMatchType: trafficpolicy.HeaderMatchRegex,
 	}
 
 	// Endpoint is an endpoint object.
@@ -3,6 +3,7 @@ package trafficpolicy
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"sort"
 
 	mapset "github.com/deckarep/golang-set"
@@ -28,6 +29,13 @@ func NewRouteWeightedCluster(route HTTPRouteMatch, weightedClusters []service.We
 		weightedClusterSet.Add(wc)
 	}
 
+	return NewRouteWeightedClusterWithSet(route, weightedClusterSet, upstreamTrafficSetting)
+}
+
+// NewRouteWeightedClusterWithSet takes a route, a set of weighted clusters and an UpstreamTrafficSetting, and returns a *RouteWeightedClusters.
+// The returned RouteWeightedClusters references the given set, so that routes to identical clusters can share a single set.
+// The set must not be modified in place once it is shared.
+func NewRouteWeightedClusterWithSet(route HTTPRouteMatch, weightedClusterSet mapset.Set, upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) *RouteWeightedClusters {
 	routeWC := &RouteWeightedClusters{
 		HTTPRouteMatch:   route,
 		WeightedClusters: weightedClusterSet,
@@ -37,20 +45,85 @@ func NewRouteWeightedCluster(route HTTPRouteMatch, weightedClusters []service.We
 		return routeWC
 	}
 
-	// Apply the corresponding per route rate limit policy for the given
+	if timeout := upstreamTrafficSetting.Spec.HTTPRequestTimeout; timeout != nil {
+		routeWC.Timeout = &timeout.Duration
+	}
+
+	if upstreamTrafficSetting.Spec.CaseInsensitivePathMatch {
+		caseSensitive := false
+		routeWC.HTTPRouteMatch.CaseSensitive = &caseSensitive
+	}
+
+	routeWC.HostRewrite = upstreamTrafficSetting.Spec.HostRewrite
+
+	// The direct response of the upstream host applies to all its routes, unless overridden per route below
+	directResponse := upstreamTrafficSetting.Spec.DirectResponse
+
+	if upstreamTrafficSetting.Spec.RequireTLS {
+		// Plaintext requests are redirected to HTTPS instead of being routed to the clusters
+		routeWC.Redirect = &RouteRedirect{HTTPSRedirect: true}
+		routeWC.WeightedClusters = mapset.NewSet()
+	}
+
+	// Apply the corresponding per route settings for the given
 	// HTTPRouteMatch's path
-	var perRouteRateLimit *policyv1alpha1.HTTPPerRouteRateLimitSpec
-	for _, httpRoute := range upstreamTrafficSetting.Spec.HTTPRoutes {
-		if httpRoute.Path == route.Path {
-			perRouteRateLimit = httpRoute.RateLimit
-			break
+	if httpRoute := getHTTPRouteSpecForPath(upstreamTrafficSetting.Spec.HTTPRoutes, route.Path); httpRoute != nil {
+		routeWC.RateLimit = httpRoute.RateLimit
+		routeWC.RequireClientCertificate = httpRoute.RequireClientCertificate
+		routeWC.FaultInjection = httpRoute.FaultInjection
+		if len(httpRoute.QueryParams) > 0 {
+			routeWC.HTTPRouteMatch.QueryParams = httpRoute.QueryParams
 		}
+		if httpRoute.Authority != "" {
+			routeWC.HTTPRouteMatch.Authority = httpRoute.Authority
+		}
+		// The per route timeout takes precedence over the timeout of the upstream host
+		if httpRoute.Timeout != nil {
+			routeWC.Timeout = &httpRoute.Timeout.Duration
+		}
+		if httpRoute.DirectResponse != nil {
+			directResponse = httpRoute.DirectResponse
+		}
+		// A regex path match cannot be rewritten by prefix
+		if httpRoute.RewritePrefix != "" && routeWC.HTTPRouteMatch.PathMatchType != PathMatchRegex {
+			routeWC.RewritePrefix = httpRoute.RewritePrefix
+		}
+	}
+
+	if directResponse != nil {
+		// Requests are answered with the direct response instead of being routed to the clusters
+		routeWC.DirectResponse = directResponse
+		routeWC.WeightedClusters = mapset.NewSet()
 	}
-	routeWC.RateLimit = perRouteRateLimit
 
 	return routeWC
 }
 
+// getHTTPRouteSpecForPath returns the HTTPRouteSpec that applies to the given path, or nil if none applies.
+// When multiple HTTPRouteSpec paths match the given path, the most specific one is picked deterministically:
+// 1. A path that is identical to the given path
+// 2. The longest path regex that fully matches the given path
+// 3. The path listed first in the given HTTPRouteSpecs, among paths of equal length
+func getHTTPRouteSpecForPath(httpRoutes []policyv1alpha1.HTTPRouteSpec, path string) *policyv1alpha1.HTTPRouteSpec {
+	var matched *policyv1alpha1.HTTPRouteSpec
+	for i := range httpRoutes {
+		httpRoute := &httpRoutes[i]
+		if httpRoute.Path == path {
+			return httpRoute
+		}
+
+		pathRegex, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", httpRoute.Path))
+		if err != nil || !pathRegex.MatchString(path) {
+			continue
+		}
+		if matched == nil || len(httpRoute.Path) > len(matched.Path) {
+			matched = httpRoute
+		}
+	}
+
+	return matched
+}
+
 // NewInboundTrafficPolicy takes a name, list of hostnames, UpstreamTrafficSetting, and returns an *InboundTrafficPolicy
 func NewInboundTrafficPolicy(name string, hostnames []string, upstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting) *InboundTrafficPolicy {
 	policy := &InboundTrafficPolicy{
@@ -60,11 +133,49 @@ func NewInboundTrafficPolicy(name string, hostnames []string, upstreamTrafficSet
 
 	if upstreamTrafficSetting != nil {
 		policy.RateLimit = upstreamTrafficSetting.Spec.RateLimit
+		policy.RetryPolicy = upstreamTrafficSetting.Spec.RetryPolicy
+		policy.Cors = upstreamTrafficSetting.Spec.Cors
+		policy.AllowWebSocketUpgrade = upstreamTrafficSetting.Spec.EnableWebSocket
+		policy.ServerName = upstreamTrafficSetting.Spec.ServerName
+		policy.HeaderToMetadata = upstreamTrafficSetting.Spec.HeaderToMetadata
+		policy.ResponseHeadersToAdd = sortResponseHeadersToAdd(upstreamTrafficSetting.Spec.ResponseHeadersToAdd)
+		policy.ResponseHeadersToRemove = sortResponseHeadersToRemove(upstreamTrafficSetting.Spec.ResponseHeadersToRemove)
+		policy.EnableGRPCWeb = upstreamTrafficSetting.Spec.EnableGRPCWeb
 	}
 
 	return policy
 }
 
+// sortResponseHeadersToAdd returns a copy of the given headers ordered by name. Headers with the same name
+// keep their relative order so that the values of a repeated header are added in the configured order.
+func sortResponseHeadersToAdd(headers []policyv1alpha1.HTTPHeaderValue) []policyv1alpha1.HTTPHeaderValue {
+	if len(headers) == 0 {
+		return nil
+	}
+	sorted := make([]policyv1alpha1.HTTPHeaderValue, len(headers))
+	copy(sorted, headers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// sortResponseHeadersToRemove returns a sorted copy of the given header names without duplicates
+func sortResponseHeadersToRemove(headers []string) []string {
+	if len(headers) == 0 {
+		return nil
+	}
+	names := mapset.NewSet()
+	var sorted []string
+	for _, header := range headers {
+		if names.Add(header) {
+			sorted = append(sorted, header)
+		}
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
 // NewOutboundTrafficPolicy takes a name and list of hostnames and returns an *OutboundTrafficPolicy
 func NewOutboundTrafficPolicy(name string, hostnames []string) *OutboundTrafficPolicy {
 	return &OutboundTrafficPolicy{
@@ -128,6 +239,22 @@ func MergeInboundPolicies(original []*InboundTrafficPolicy, latest ...*InboundTr
 				or.Hostnames = hostsUnion
 				foundHostnames = true
 				or.Rules = MergeRules(or.Rules, l.Rules)
+				// The retry, CORS, header-to-metadata and response header policies of the original policy take precedence
+				if or.RetryPolicy == nil {
+					or.RetryPolicy = l.RetryPolicy
+				}
+				if or.Cors == nil {
+					or.Cors = l.Cors
+				}
+				if or.HeaderToMetadata == nil {
+					or.HeaderToMetadata = l.HeaderToMetadata
+				}
+				if or.ResponseHeadersToAdd == nil && or.ResponseHeadersToRemove == nil {
+					or.ResponseHeadersToAdd = l.ResponseHeadersToAdd
+					or.ResponseHeadersToRemove = l.ResponseHeadersToRemove
+				}
+				or.AllowWebSocketUpgrade = or.AllowWebSocketUpgrade || l.AllowWebSocketUpgrade
+				or.EnableGRPCWeb = or.EnableGRPCWeb || l.EnableGRPCWeb
 			}
 		}
 		if !foundHostnames {
@@ -146,6 +273,16 @@ func MergeRules(originalRules, latestRules []*Rule) []*Rule {
 			if reflect.DeepEqual(latest.Route, original.Route) {
 				foundRoute = true
 				original.AllowedPrincipals = original.AllowedPrincipals.Union(latest.AllowedPrincipals)
+				if original.DeniedPrincipals == nil {
+					original.DeniedPrincipals = latest.DeniedPrincipals
+				} else if latest.DeniedPrincipals != nil {
+					original.DeniedPrincipals = original.DeniedPrincipals.Union(latest.DeniedPrincipals)
+				}
+				// Required headers are derived from the route's headers, so requiring them when
+				// any of the merged rules does doesn't restrict the principals of the other rules
+				if original.RequiredHeaders == nil {
+					original.RequiredHeaders = latest.RequiredHeaders
+				}
 				break
 			}
 		}
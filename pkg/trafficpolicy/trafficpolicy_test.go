@@ -235,6 +235,8 @@ func TestMergeInboundPoliciesWithPartialHostnames(t *testing.T) {
 			WeightedClusters: mapset.NewSet(testWeightedCluster),
 		},
 	}
+	retryPolicy1 := &policyv1alpha1.RetryPolicySpec{RetryOn: "5xx"}
+	retryPolicy2 := &policyv1alpha1.RetryPolicySpec{RetryOn: "reset"}
 	testCases := []struct {
 		name            string
 		originalInbound []*InboundTrafficPolicy
@@ -283,6 +285,53 @@ func TestMergeInboundPoliciesWithPartialHostnames(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "retry policy is inherited when the original policy has none",
+			originalInbound: []*InboundTrafficPolicy{
+				{
+					Hostnames: testHostnames,
+					Rules:     []*Rule{&testRule1},
+				},
+			},
+			newInbound: []*InboundTrafficPolicy{
+				{
+					Hostnames:   []string{"testHostname1"},
+					Rules:       []*Rule{&testRule2},
+					RetryPolicy: retryPolicy1,
+				},
+			},
+			expectedInbound: []*InboundTrafficPolicy{
+				{
+					Hostnames:   testHostnames,
+					Rules:       []*Rule{&testRule1, &testRule2},
+					RetryPolicy: retryPolicy1,
+				},
+			},
+		},
+		{
+			name: "retry policy of the original policy takes precedence",
+			originalInbound: []*InboundTrafficPolicy{
+				{
+					Hostnames:   testHostnames,
+					Rules:       []*Rule{&testRule1},
+					RetryPolicy: retryPolicy1,
+				},
+			},
+			newInbound: []*InboundTrafficPolicy{
+				{
+					Hostnames:   []string{"testHostname1"},
+					Rules:       []*Rule{&testRule2},
+					RetryPolicy: retryPolicy2,
+				},
+			},
+			expectedInbound: []*InboundTrafficPolicy{
+				{
+					Hostnames:   testHostnames,
+					Rules:       []*Rule{&testRule1, &testRule2},
+					RetryPolicy: retryPolicy1,
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -344,6 +393,29 @@ func TestMergeRules(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "routes match and only the new rule requires headers",
+			originalRules: []*Rule{
+				{
+					Route:             testRoute,
+					AllowedPrincipals: mapset.NewSet(testServiceAccount1.AsPrincipal("cluster.local", false)),
+				},
+			},
+			newRules: []*Rule{
+				{
+					Route:             testRoute,
+					AllowedPrincipals: mapset.NewSet(testServiceAccount2.AsPrincipal("cluster.local", false)),
+					RequiredHeaders:   testRoute.HTTPRouteMatch.Headers,
+				},
+			},
+			expectedRules: []*Rule{
+				{
+					Route:             testRoute,
+					AllowedPrincipals: mapset.NewSetWith(testServiceAccount1.AsPrincipal("cluster.local", false), testServiceAccount2.AsPrincipal("cluster.local", false)),
+					RequiredHeaders:   testRoute.HTTPRouteMatch.Headers,
+				},
+			},
+		},
 		{
 			name: "routes don't match, add rule",
 			originalRules: []*Rule{
@@ -426,6 +498,12 @@ func TestNewInboundTrafficPolicy(t *testing.T) {
 	rateLimitSpec := &policyv1alpha1.RateLimitSpec{
 		Local: &policyv1alpha1.LocalRateLimitSpec{},
 	}
+	retryPolicySpec := &policyv1alpha1.RetryPolicySpec{
+		RetryOn: "5xx",
+	}
+	corsSpec := &policyv1alpha1.CorsSpec{
+		AllowOrigins: []string{"https://foo.com"},
+	}
 
 	testCases := []struct {
 		name                   string
@@ -458,6 +536,53 @@ func TestNewInboundTrafficPolicy(t *testing.T) {
 				RateLimit: rateLimitSpec,
 			},
 		},
+		{
+			name:       "inbound policy with retry policy configured",
+			policyName: "foo",
+			hostnames:  []string{"foo.com", "bar.com"},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					RetryPolicy: retryPolicySpec,
+				},
+			},
+			expected: &InboundTrafficPolicy{
+				Name:        "foo",
+				Hostnames:   []string{"foo.com", "bar.com"},
+				RetryPolicy: retryPolicySpec,
+			},
+		},
+		{
+			name:       "inbound policy with CORS policy and rate limit configured",
+			policyName: "foo",
+			hostnames:  []string{"foo.com", "bar.com"},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					RateLimit: rateLimitSpec,
+					Cors:      corsSpec,
+				},
+			},
+			expected: &InboundTrafficPolicy{
+				Name:      "foo",
+				Hostnames: []string{"foo.com", "bar.com"},
+				RateLimit: rateLimitSpec,
+				Cors:      corsSpec,
+			},
+		},
+		{
+			name:       "inbound policy with WebSocket enabled",
+			policyName: "foo",
+			hostnames:  []string{"foo.com", "bar.com"},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					EnableWebSocket: true,
+				},
+			},
+			expected: &InboundTrafficPolicy{
+				Name:                  "foo",
+				Hostnames:             []string{"foo.com", "bar.com"},
+				AllowWebSocketUpgrade: true,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -475,6 +600,13 @@ func TestNewRouteWeightedCluster(t *testing.T) {
 			Unit:     "second",
 		},
 	}
+	tenSeconds := 10 * time.Second
+	noTimeout := time.Duration(0)
+	caseInsensitive := false
+	faultInjection := &policyv1alpha1.HTTPFaultInjectionSpec{
+		Percentage: 50,
+		Abort:      &policyv1alpha1.HTTPFaultAbortSpec{HTTPStatus: 503},
+	}
 
 	testCases := []struct {
 		name                   string
@@ -509,6 +641,243 @@ func TestNewRouteWeightedCluster(t *testing.T) {
 				RateLimit:        perRouteRateLimitConfig,
 			},
 		},
+		{
+			name:             "per route client certificate requirement",
+			route:            testHTTPRouteMatch,
+			weightedClusters: []service.WeightedCluster{testWeightedCluster},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+						{
+							Path:                     testHTTPRouteMatch.Path, // matches path on HTTPRouteMatch
+							RequireClientCertificate: true,
+						},
+					},
+				},
+			},
+			expected: &RouteWeightedClusters{
+				HTTPRouteMatch:           testHTTPRouteMatch,
+				WeightedClusters:         mapset.NewSet(testWeightedCluster),
+				RequireClientCertificate: true,
+			},
+		},
+		{
+			name:             "upstream host request timeout",
+			route:            testHTTPRouteMatch,
+			weightedClusters: []service.WeightedCluster{testWeightedCluster},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					HTTPRequestTimeout: &metav1.Duration{Duration: 10 * time.Second},
+				},
+			},
+			expected: &RouteWeightedClusters{
+				HTTPRouteMatch:   testHTTPRouteMatch,
+				WeightedClusters: mapset.NewSet(testWeightedCluster),
+				Timeout:          &tenSeconds,
+			},
+		},
+		{
+			name:             "per route request timeout overrides the upstream host request timeout",
+			route:            testHTTPRouteMatch,
+			weightedClusters: []service.WeightedCluster{testWeightedCluster},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					HTTPRequestTimeout: &metav1.Duration{Duration: 10 * time.Second},
+					HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+						{
+							Path:    testHTTPRouteMatch.Path, // matches path on HTTPRouteMatch
+							Timeout: &metav1.Duration{Duration: 0},
+						},
+					},
+				},
+			},
+			expected: &RouteWeightedClusters{
+				HTTPRouteMatch:   testHTTPRouteMatch,
+				WeightedClusters: mapset.NewSet(testWeightedCluster),
+				Timeout:          &noTimeout,
+			},
+		},
+		{
+			name:             "upstream host request timeout applies to routes without a per route request timeout",
+			route:            testHTTPRouteMatch,
+			weightedClusters: []service.WeightedCluster{testWeightedCluster},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					HTTPRequestTimeout: &metav1.Duration{Duration: 10 * time.Second},
+					HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+						{
+							Path:                     testHTTPRouteMatch.Path, // matches path on HTTPRouteMatch
+							RequireClientCertificate: true,
+						},
+					},
+				},
+			},
+			expected: &RouteWeightedClusters{
+				HTTPRouteMatch:           testHTTPRouteMatch,
+				WeightedClusters:         mapset.NewSet(testWeightedCluster),
+				RequireClientCertificate: true,
+				Timeout:                  &tenSeconds,
+			},
+		},
+		{
+			name:             "upstream host case insensitive path matching",
+			route:            testHTTPRouteMatch,
+			weightedClusters: []service.WeightedCluster{testWeightedCluster},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					CaseInsensitivePathMatch: true,
+				},
+			},
+			expected: &RouteWeightedClusters{
+				HTTPRouteMatch: HTTPRouteMatch{
+					Path:          testHTTPRouteMatch.Path,
+					PathMatchType: testHTTPRouteMatch.PathMatchType,
+					Methods:       testHTTPRouteMatch.Methods,
+					Headers:       testHTTPRouteMatch.Headers,
+					CaseSensitive: &caseInsensitive,
+				},
+				WeightedClusters: mapset.NewSet(testWeightedCluster),
+			},
+		},
+		{
+			name:             "per route query parameters",
+			route:            testHTTPRouteMatch,
+			weightedClusters: []service.WeightedCluster{testWeightedCluster},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+						{
+							Path:        testHTTPRouteMatch.Path, // matches path on HTTPRouteMatch
+							QueryParams: map[string]string{"version": "v2"},
+						},
+					},
+				},
+			},
+			expected: &RouteWeightedClusters{
+				HTTPRouteMatch: HTTPRouteMatch{
+					Path:          testHTTPRouteMatch.Path,
+					PathMatchType: testHTTPRouteMatch.PathMatchType,
+					Methods:       testHTTPRouteMatch.Methods,
+					Headers:       testHTTPRouteMatch.Headers,
+					QueryParams:   map[string]string{"version": "v2"},
+				},
+				WeightedClusters: mapset.NewSet(testWeightedCluster),
+			},
+		},
+		{
+			name:             "per route authority",
+			route:            testHTTPRouteMatch,
+			weightedClusters: []service.WeightedCluster{testWeightedCluster},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+						{
+							Path:      testHTTPRouteMatch.Path, // matches path on HTTPRouteMatch
+							Authority: "api.example.com",
+						},
+					},
+				},
+			},
+			expected: &RouteWeightedClusters{
+				HTTPRouteMatch: HTTPRouteMatch{
+					Path:          testHTTPRouteMatch.Path,
+					PathMatchType: testHTTPRouteMatch.PathMatchType,
+					Methods:       testHTTPRouteMatch.Methods,
+					Headers:       testHTTPRouteMatch.Headers,
+					Authority:     "api.example.com",
+				},
+				WeightedClusters: mapset.NewSet(testWeightedCluster),
+			},
+		},
+		{
+			name:             "per route fault injection",
+			route:            testHTTPRouteMatch,
+			weightedClusters: []service.WeightedCluster{testWeightedCluster},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+						{
+							Path:           testHTTPRouteMatch.Path, // matches path on HTTPRouteMatch
+							FaultInjection: faultInjection,
+						},
+					},
+				},
+			},
+			expected: &RouteWeightedClusters{
+				HTTPRouteMatch:   testHTTPRouteMatch,
+				WeightedClusters: mapset.NewSet(testWeightedCluster),
+				FaultInjection:   faultInjection,
+			},
+		},
+		{
+			name:             "host rewrite applies to all routes of the upstream host",
+			route:            testHTTPRouteMatch,
+			weightedClusters: []service.WeightedCluster{testWeightedCluster},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					HostRewrite: &policyv1alpha1.HostRewriteSpec{Literal: "api.example.com"},
+				},
+			},
+			expected: &RouteWeightedClusters{
+				HTTPRouteMatch:   testHTTPRouteMatch,
+				WeightedClusters: mapset.NewSet(testWeightedCluster),
+				HostRewrite:      &policyv1alpha1.HostRewriteSpec{Literal: "api.example.com"},
+			},
+		},
+		{
+			name:             "upstream host requires TLS",
+			route:            testHTTPRouteMatch,
+			weightedClusters: []service.WeightedCluster{testWeightedCluster},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					RequireTLS: true,
+				},
+			},
+			expected: &RouteWeightedClusters{
+				HTTPRouteMatch:   testHTTPRouteMatch,
+				WeightedClusters: mapset.NewSet(),
+				Redirect:         &RouteRedirect{HTTPSRedirect: true},
+			},
+		},
+		{
+			name:             "per route prefix rewrite",
+			route:            HTTPRouteMatch{Path: "/old", PathMatchType: PathMatchExact, Methods: []string{"GET"}},
+			weightedClusters: []service.WeightedCluster{testWeightedCluster},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+						{
+							Path:          "/old",
+							RewritePrefix: "/new",
+						},
+					},
+				},
+			},
+			expected: &RouteWeightedClusters{
+				HTTPRouteMatch:   HTTPRouteMatch{Path: "/old", PathMatchType: PathMatchExact, Methods: []string{"GET"}},
+				WeightedClusters: mapset.NewSet(testWeightedCluster),
+				RewritePrefix:    "/new",
+			},
+		},
+		{
+			name:             "prefix rewrite is not applied to a regex path match",
+			route:            testHTTPRouteMatch,
+			weightedClusters: []service.WeightedCluster{testWeightedCluster},
+			upstreamTrafficSetting: &policyv1alpha1.UpstreamTrafficSetting{
+				Spec: policyv1alpha1.UpstreamTrafficSettingSpec{
+					HTTPRoutes: []policyv1alpha1.HTTPRouteSpec{
+						{
+							Path:          testHTTPRouteMatch.Path, // matches path on HTTPRouteMatch
+							RewritePrefix: "/new",
+						},
+					},
+				},
+			},
+			expected: &RouteWeightedClusters{
+				HTTPRouteMatch:   testHTTPRouteMatch,
+				WeightedClusters: mapset.NewSet(testWeightedCluster),
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -521,6 +890,72 @@ func TestNewRouteWeightedCluster(t *testing.T) {
 	}
 }
 
+func TestGetHTTPRouteSpecForPath(t *testing.T) {
+	testCases := []struct {
+		name         string
+		httpRoutes   []policyv1alpha1.HTTPRouteSpec
+		path         string
+		expectedPath string
+	}{
+		{
+			name:         "no HTTPRoutes",
+			httpRoutes:   nil,
+			path:         "/get",
+			expectedPath: "",
+		},
+		{
+			name:         "no matching path",
+			httpRoutes:   []policyv1alpha1.HTTPRouteSpec{{Path: "/post"}},
+			path:         "/get",
+			expectedPath: "",
+		},
+		{
+			name:         "identical path wins over a longer matching regex",
+			httpRoutes:   []policyv1alpha1.HTTPRouteSpec{{Path: "/ge[t]+"}, {Path: "/get"}},
+			path:         "/get",
+			expectedPath: "/get",
+		},
+		{
+			name:         "longest matching regex wins",
+			httpRoutes:   []policyv1alpha1.HTTPRouteSpec{{Path: ".*"}, {Path: "/get.*"}},
+			path:         "/get/items",
+			expectedPath: "/get.*",
+		},
+		{
+			name:         "first path wins among matching paths of equal length",
+			httpRoutes:   []policyv1alpha1.HTTPRouteSpec{{Path: "/g.*"}, {Path: "/.et"}},
+			path:         "/get",
+			expectedPath: "/g.*",
+		},
+		{
+			name:         "regex must match the full path",
+			httpRoutes:   []policyv1alpha1.HTTPRouteSpec{{Path: "/get"}},
+			path:         "/get/items",
+			expectedPath: "",
+		},
+		{
+			name:         "invalid regex is ignored",
+			httpRoutes:   []policyv1alpha1.HTTPRouteSpec{{Path: "/get("}, {Path: ".*"}},
+			path:         "/get",
+			expectedPath: ".*",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			actual := getHTTPRouteSpecForPath(tc.httpRoutes, tc.path)
+			if tc.expectedPath == "" {
+				assert.Nil(actual)
+				return
+			}
+			assert.NotNil(actual)
+			assert.Equal(tc.expectedPath, actual.Path)
+		})
+	}
+}
+
 func TestNewOutboundPolicy(t *testing.T) {
 	assert := tassert.New(t)
 
@@ -3,6 +3,8 @@
 package trafficpolicy
 
 import (
+	"time"
+
 	mapset "github.com/deckarep/golang-set"
 
 	policyv1alpha1 "github.com/openservicemesh/osm/pkg/apis/policy/v1alpha1"
@@ -31,12 +33,53 @@ const (
 	PathMatchPrefix PathMatchType = iota
 )
 
+// HeaderMatchType is the type used to represent the header value matching type: exact or regex
+type HeaderMatchType int
+
+const (
+	// HeaderMatchExact is the type used to specify exact header value matching
+	HeaderMatchExact HeaderMatchType = iota
+
+	// HeaderMatchRegex is the type used to specify regex based header value matching
+	HeaderMatchRegex HeaderMatchType = iota
+)
+
 // HTTPRouteMatch is a struct to represent an HTTP route match comprised of an HTTP path, path matching type, methods, and headers
 type HTTPRouteMatch struct {
 	Path          string            `json:"path:omitempty"`
 	PathMatchType PathMatchType     `json:"path_match_type:omitempty"`
 	Methods       []string          `json:"methods:omitempty"`
 	Headers       map[string]string `json:"headers:omitempty"`
+
+	// HeaderMatchType defines how the values of Headers are matched.
+	// Defaults to exact matching, SMI HTTPRouteGroup header values are regexes.
+	// +optional
+	HeaderMatchType HeaderMatchType `json:"header_match_type:omitempty"`
+
+	// GRPC defines whether the route only matches gRPC requests
+	// +optional
+	GRPC bool `json:"grpc:omitempty"`
+
+	// CaseSensitive defines whether the Path is matched case sensitively.
+	// Defaults to true if not specified.
+	// +optional
+	CaseSensitive *bool `json:"case_sensitive:omitempty"`
+
+	// QueryParams defines the query parameters, keyed by name, that must be
+	// present in the request with the given exact values
+	// +optional
+	QueryParams map[string]string `json:"query_params:omitempty"`
+
+	// Authority defines the :authority (host) header value the request must
+	// have, matched exactly. It applies within the virtual host of the route,
+	// and does not alter the hostnames the virtual host is matched with.
+	// +optional
+	Authority string `json:"authority:omitempty"`
+}
+
+// IsCaseSensitive returns true if the Path of the HTTPRouteMatch is matched case sensitively
+func (m HTTPRouteMatch) IsCaseSensitive() bool {
+	return m.CaseSensitive == nil || *m.CaseSensitive
 }
 
 // TCPRouteMatch is a struct to represent a TCP route matching based on ports
@@ -54,6 +97,80 @@ type RouteWeightedClusters struct {
 	// for the given HTTPRouteMatch
 	// +optional
 	RateLimit *policyv1alpha1.HTTPPerRouteRateLimitSpec `json:"rate_limit:omitempty"`
+
+	// RequireClientCertificate defines whether the route is only matched for requests
+	// that present a client certificate validated by the proxy
+	// +optional
+	RequireClientCertificate bool `json:"require_client_certificate:omitempty"`
+
+	// StatPrefix defines the prefix used for the per route stats emitted for this route
+	// +optional
+	StatPrefix string `json:"stat_prefix:omitempty"`
+
+	// RuntimeKeyPrefix defines the prefix of the runtime keys that override the weights of the
+	// weighted clusters for this route. The runtime key for a cluster is <RuntimeKeyPrefix>.<cluster name>,
+	// and the cluster's configured weight is used if the runtime key is not set.
+	// +optional
+	RuntimeKeyPrefix string `json:"runtime_key_prefix:omitempty"`
+
+	// Timeout defines the request timeout for the route, a timeout of 0 disables the request timeout.
+	// The mesh default request timeout is used if not specified.
+	// +optional
+	Timeout *time.Duration `json:"timeout:omitempty"`
+
+	// FaultInjection defines the faults injected into the requests matching the route
+	// +optional
+	FaultInjection *policyv1alpha1.HTTPFaultInjectionSpec `json:"fault_injection:omitempty"`
+
+	// Redirect defines the redirect returned for requests matching the route, in which case
+	// the requests are not routed to the WeightedClusters
+	// +optional
+	Redirect *RouteRedirect `json:"redirect:omitempty"`
+
+	// DirectResponse defines the fixed response returned for requests matching the route, in which case
+	// the requests are not routed to the WeightedClusters
+	// +optional
+	DirectResponse *policyv1alpha1.DirectResponseSpec `json:"direct_response:omitempty"`
+
+	// RewritePrefix defines the path prefix that replaces the matched path of the requests
+	// matching the route before they are routed to the WeightedClusters
+	// +optional
+	RewritePrefix string `json:"rewrite_prefix:omitempty"`
+
+	// HostRewrite defines the rewrite of the Host header of the requests matching the route
+	// +optional
+	HostRewrite *policyv1alpha1.HostRewriteSpec `json:"host_rewrite:omitempty"`
+
+	// RequestMirrorPolicies defines the clusters the requests matching the route are mirrored to,
+	// in addition to being routed to the WeightedClusters
+	// +optional
+	RequestMirrorPolicies []RequestMirrorPolicy `json:"request_mirror_policies:omitempty"`
+
+	// HashKeyHeader defines the HTTP header whose value is hashed to select the endpoint of
+	// a cluster using consistent hashing load balancing
+	// +optional
+	HashKeyHeader string `json:"hash_key_header:omitempty"`
+
+	// HashKeyCookie defines the HTTP cookie whose value is hashed to select the endpoint of
+	// a cluster using consistent hashing load balancing, providing session affinity
+	// +optional
+	HashKeyCookie *policyv1alpha1.HashKeyCookieSpec `json:"hash_key_cookie:omitempty"`
+}
+
+// RouteRedirect is a struct to represent the redirect returned for requests matching a route
+type RouteRedirect struct {
+	// HTTPSRedirect defines whether the scheme of the request is redirected to HTTPS
+	HTTPSRedirect bool `json:"https_redirect:omitempty"`
+}
+
+// RequestMirrorPolicy is a struct to represent the mirroring of the requests matching a route to a cluster.
+// Responses to the mirrored requests are ignored.
+type RequestMirrorPolicy struct {
+	// ClusterName defines the name of the cluster the requests are mirrored to
+	ClusterName service.ClusterName `json:"cluster_name:omitempty"`
+
+	// Percentage defines the percentage of the requests mirrored to the cluster
+	Percentage uint32 `json:"percentage:omitempty"`
 }
 
 // InboundTrafficPolicy is a struct that associates incoming traffic on a set of Hostnames with a list of Rules
@@ -66,6 +183,46 @@ type InboundTrafficPolicy struct {
 	// for the given set of hostnames (domains) corresponding to the virtual_host
 	// +optional
 	RateLimit *policyv1alpha1.RateLimitSpec `json:"rate_limit:omitempty"`
+
+	// RetryPolicy defines the retry policy applied at the virtual_host level
+	// for the given set of hostnames (domains) corresponding to the virtual_host
+	// +optional
+	RetryPolicy *policyv1alpha1.RetryPolicySpec `json:"retry_policy:omitempty"`
+
+	// Cors defines the CORS policy applied at the virtual_host level
+	// for the given set of hostnames (domains) corresponding to the virtual_host
+	// +optional
+	Cors *policyv1alpha1.CorsSpec `json:"cors:omitempty"`
+
+	// AllowWebSocketUpgrade defines whether WebSocket upgrades are allowed on all the routes
+	// of the virtual_host for the given set of hostnames (domains)
+	// +optional
+	AllowWebSocketUpgrade bool `json:"allow_websocket_upgrade:omitempty"`
+
+	// ServerName defines the SNI required on the TLS handshake for the Rules to apply.
+	// Policies with a ServerName are programmed on a route configuration specific to it.
+	// +optional
+	ServerName string `json:"server_name:omitempty"`
+
+	// HeaderToMetadata defines the rules copying the values of request headers into the
+	// dynamic metadata of the requests for the given set of hostnames (domains)
+	// +optional
+	HeaderToMetadata []policyv1alpha1.HeaderToMetadataSpec `json:"header_to_metadata:omitempty"`
+
+	// ResponseHeadersToAdd defines the headers added to the responses at the virtual_host level
+	// for the given set of hostnames (domains), ordered by header name
+	// +optional
+	ResponseHeadersToAdd []policyv1alpha1.HTTPHeaderValue `json:"response_headers_to_add:omitempty"`
+
+	// ResponseHeadersToRemove defines the names of the headers removed from the responses at the
+	// virtual_host level for the given set of hostnames (domains), sorted and without duplicates
+	// +optional
+	ResponseHeadersToRemove []string `json:"response_headers_to_remove:omitempty"`
+
+	// EnableGRPCWeb defines whether gRPC-Web requests are translated into gRPC requests
+	// for the given set of hostnames (domains)
+	// +optional
+	EnableGRPCWeb bool `json:"enable_grpc_web:omitempty"`
 }
 
 // Rule is a struct that represents which authenticated principals can access a Route.
@@ -74,6 +231,24 @@ type Rule struct {
 	Route RouteWeightedClusters `json:"route:omitempty"`
 	// Principals contain the trust domain already while identities do not.
 	AllowedPrincipals mapset.Set `json:"allowed_principals:omitempty"`
+
+	// DeniedPrincipals defines the principals denied access to the Route, even if they are
+	// in AllowedPrincipals. Denials take precedence over allows.
+	// +optional
+	DeniedPrincipals mapset.Set `json:"denied_principals:omitempty"`
+
+	// RequiredHeaders defines the headers, keyed by name with a regex value, that a request from an
+	// allowed principal must carry to be authorized on the Route. Unlike the Headers in the Route's
+	// HTTPRouteMatch, which are only used to select the Route, RequiredHeaders are enforced by RBAC.
+	// They are derived from the same HTTPRouteGroup match as the Route, so a request that selects the
+	// Route also satisfies them, but authorization no longer relies on route selection alone.
+	// +optional
+	RequiredHeaders map[string]string `json:"required_headers:omitempty"`
+
+	// AllowedSourceRanges defines the IP address ranges, in CIDR notation, the allowed principals
+	// must connect from to access the Route. All the source addresses are allowed when empty.
+	// +optional
+	AllowedSourceRanges []string `json:"allowed_source_ranges:omitempty"`
 }
 
 // OutboundTrafficPolicy is a struct that associates a list of Routes with outbound traffic on a set of Hostnames
@@ -116,14 +291,70 @@ type MeshClusterConfig struct {
 	// +optional
 	EnableEnvoyActiveHealthChecks bool
 
+	// HealthCheck is the active health checking of the cluster's endpoints, taking precedence
+	// over EnableEnvoyActiveHealthChecks.
+	// This is set for upstream clusters whose UpstreamTrafficSetting specifies a health check.
+	// +optional
+	HealthCheck *policyv1alpha1.HealthCheckSpec
+
+	// OutlierDetection is the passive health checking of the cluster's endpoints.
+	// This is set for upstream clusters whose UpstreamTrafficSetting specifies outlier detection.
+	// +optional
+	OutlierDetection *policyv1alpha1.OutlierDetectionSpec
+
+	// LoadBalancer is the load balancing policy used to distribute the traffic across the cluster's
+	// endpoints, defaulting to round robin load balancing.
+	// This is set for upstream clusters whose UpstreamTrafficSetting specifies a load balancing policy.
+	// +optional
+	LoadBalancer *policyv1alpha1.LBPolicySpec
+
 	// UpstreamTrafficSetting is the traffic setting for the upstream cluster
 	// +optional
 	UpstreamTrafficSetting *policyv1alpha1.UpstreamTrafficSetting
 
+	// ConnectionSettings is the connection settings for the local cluster, used to
+	// configure circuit breaking for the traffic accepted by the local service.
+	// This is set for local (upstream) clusters accepting traffic from a downstream client.
+	// +optional
+	ConnectionSettings *policyv1alpha1.ConnectionSettingsSpec
+
 	// Protocol to use for the cluster
 	// One of http1, http2, h2c
 	// +optional
 	Protocol string
+
+	// UpstreamProtocol is the HTTP protocol used for the connections to the local service,
+	// taking precedence over Protocol. One of http1, http2, h2c. The protocol of the
+	// downstream connection is used if unset.
+	// This is set for local (upstream) clusters accepting traffic from a downstream client.
+	// +optional
+	UpstreamProtocol string
+
+	// SubsetSelectors are the label selectors of the endpoint subsets the traffic to the cluster
+	// can be routed to by weighted clusters.
+	// This is set for local (upstream) clusters accepting traffic from a downstream client.
+	// +optional
+	SubsetSelectors []service.LabelSelector
+
+	// LocalityWeights defines the load balancing weight and priority of each zone
+	// of the cluster's endpoints. When set, locality weighted load balancing is
+	// enabled for the cluster.
+	// This is set for upstream clusters when locality aware load balancing is enabled.
+	// +optional
+	LocalityWeights []LocalityWeight
+}
+
+// LocalityWeight is the type used to represent the load balancing weight and priority of a zone
+type LocalityWeight struct {
+	// Zone is the zone the endpoints reside in
+	Zone string
+
+	// Weight is the load balancing weight of the zone, relative to the other zones with the same priority
+	Weight uint32
+
+	// Priority is the priority of the zone, 0 being the highest priority. Traffic is only sent
+	// to the zones of a lower priority when the zones of a higher priority are unhealthy.
+	Priority uint32
 }
 
 // TrafficMatch is the type used to represent attributes used to match traffic
@@ -163,4 +394,36 @@ type TrafficMatch struct {
 	// RateLimit defines the rate limiting policy applied for this TrafficMatch
 	// +optional
 	RateLimit *policyv1alpha1.RateLimitSpec
+
+	// AllowedPrincipals defines the downstream principals allowed by this TrafficMatch.
+	// It is set on inbound TrafficMatch entries for TCP services scoped to a single
+	// downstream identity. Envoy cannot select a filter chain based on the downstream's
+	// principal, so TrafficMatch entries with the same Name share a filter chain with
+	// a network RBAC policy per TrafficMatch.
+	// +optional
+	AllowedPrincipals []string
+
+	// RequiredServerName defines the SNI required on the TLS handshake for this
+	// TrafficMatch. When set, the HTTP routes for this TrafficMatch are programmed
+	// on a route configuration specific to this SNI.
+	// +optional
+	RequiredServerName string
+
+	// AccessLogFormat defines the access log format for the HTTP traffic
+	// accepted by this TrafficMatch, overriding the access log format of the proxy.
+	// +optional
+	AccessLogFormat string
+
+	// IdleTimeout defines the idle timeout of the TCP connections accepted by
+	// this TrafficMatch, a timeout of 0 disables the idle timeout.
+	// The Envoy default idle timeout is used if not specified.
+	// +optional
+	IdleTimeout *time.Duration
+
+	// AllowedSourceRanges defines the IP address ranges, in CIDR notation, the
+	// downstream clients must connect from to be accepted by this TrafficMatch.
+	// It is set on inbound TrafficMatch entries for TCP and TLS passthrough services,
+	// the source ranges of HTTP services are enforced on their inbound routes.
+	// +optional
+	AllowedSourceRanges []string
 }
@@ -266,11 +266,40 @@ func (kc *validator) upstreamTrafficSettingValidator(req *admissionv1.AdmissionR
 					route.RateLimit.Local.ResponseStatusCode)
 			}
 		}
+		if fi := route.FaultInjection; fi != nil && fi.Abort == nil && fi.Delay == nil {
+			return nil, fmt.Errorf("Fault injection for HTTP route %s must specify an abort or a delay fault", route.Path)
+		}
+	}
+
+	if format := upstreamTrafficSetting.Spec.AccessLogFormat; format != "" {
+		if err := validateAccessLogFormat(format); err != nil {
+			return nil, field.Invalid(field.NewPath("spec").Child("accessLogFormat"), format, err.Error())
+		}
 	}
 
 	return nil, nil
 }
 
+// validateAccessLogFormat validates that the given Envoy access log format parses. A JSON format must be a
+// JSON object, and a text format must not have an unterminated command operator.
+func validateAccessLogFormat(format string) error {
+	if json.Valid([]byte(format)) {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(format), &fields); err != nil {
+			return fmt.Errorf("JSON access log format must be a JSON object: %w", err)
+		}
+		return nil
+	}
+	if strings.HasPrefix(strings.TrimSpace(format), "{") {
+		return fmt.Errorf("invalid JSON access log format")
+	}
+	// Command operators are of the form %OPERATOR%, and a literal '%' is escaped as %%
+	if strings.Count(format, "%")%2 != 0 {
+		return fmt.Errorf("unterminated command operator in access log format")
+	}
+	return nil
+}
+
 func (kc *validator) meshRootCertificateValidator(req *admissionv1.AdmissionRequest) (*admissionv1.AdmissionResponse, error) {
 	switch req.Operation {
 	case admissionv1.Create:
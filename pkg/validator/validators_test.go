@@ -1290,6 +1290,69 @@ func TestUpstreamTrafficSettingValidator(t *testing.T) {
 			expResp:   nil,
 			expErrStr: "Invalid responseStatusCode 1. See https://www.envoyproxy.io/docs/envoy/latest/api-v3/type/v3/http_status.proto#enum-type-v3-statuscode for allowed values",
 		},
+		{
+			name: "UpstreamTrafficSetting with HTTP route fault injection without a fault",
+			input: &admissionv1.AdmissionRequest{
+				Kind: metav1.GroupVersionKind{
+					Group:   "v1alpha1",
+					Version: "policy.openservicemesh.io",
+					Kind:    "UpstreamTrafficSetting",
+				},
+				Object: runtime.RawExtension{
+					Raw: []byte(`
+					{
+						"apiVersion": "policy.openservicemesh.io/v1alpha1",
+						"kind": "UpstreamTrafficSetting",
+						"metadata": {
+							"name": "httpbin",
+							"namespace": "test"
+						},
+						"spec": {
+							"host": "httpbin.test.svc.cluster.local",
+							"httpRoutes": [
+								{
+								"path": "/get",
+								"faultInjection": {
+									"percentage": 50
+								}
+								}
+							]
+						}
+					}
+					`),
+				},
+			},
+			expResp:   nil,
+			expErrStr: "Fault injection for HTTP route /get must specify an abort or a delay fault",
+		},
+		{
+			name: "UpstreamTrafficSetting with invalid access log format",
+			input: &admissionv1.AdmissionRequest{
+				Kind: metav1.GroupVersionKind{
+					Group:   "v1alpha1",
+					Version: "policy.openservicemesh.io",
+					Kind:    "UpstreamTrafficSetting",
+				},
+				Object: runtime.RawExtension{
+					Raw: []byte(`
+					{
+						"apiVersion": "policy.openservicemesh.io/v1alpha1",
+						"kind": "UpstreamTrafficSetting",
+						"metadata": {
+							"name": "httpbin",
+							"namespace": "test"
+						},
+						"spec": {
+							"host": "httpbin.test.svc.cluster.local",
+							"accessLogFormat": "[%START_TIME%] %REQ(:METHOD)"
+						}
+					}
+					`),
+				},
+			},
+			expResp:   nil,
+			expErrStr: `spec.accessLogFormat: Invalid value: "[%START_TIME%] %REQ(:METHOD)": unterminated command operator in access log format`,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1351,6 +1414,49 @@ func TestUpstreamTrafficSettingValidator(t *testing.T) {
 	}
 }
 
+func TestValidateAccessLogFormat(t *testing.T) {
+	testCases := []struct {
+		name      string
+		format    string
+		expectErr bool
+	}{
+		{
+			name:      "valid text format",
+			format:    "[%START_TIME%] %REQ(:METHOD)% %RESPONSE_CODE% 100%%",
+			expectErr: false,
+		},
+		{
+			name:      "valid JSON format",
+			format:    `{"method": "%REQ(:METHOD)%", "response_code": "%RESPONSE_CODE%"}`,
+			expectErr: false,
+		},
+		{
+			name:      "text format with an unterminated command operator",
+			format:    "[%START_TIME%] %REQ(:METHOD)",
+			expectErr: true,
+		},
+		{
+			name:      "malformed JSON format",
+			format:    `{"method": "%REQ(:METHOD)%"`,
+			expectErr: true,
+		},
+		{
+			name:      "JSON format that is not an object",
+			format:    `["%REQ(:METHOD)%"]`,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := tassert.New(t)
+
+			err := validateAccessLogFormat(tc.format)
+			assert.Equal(tc.expectErr, err != nil)
+		})
+	}
+}
+
 func TestMeshRootCertificateValidator(t *testing.T) {
 	testCases := []struct {
 		name      string